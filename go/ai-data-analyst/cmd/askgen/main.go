@@ -0,0 +1,322 @@
+// Command askgen puts load on /api/ask the way cmd/loadgen does for the
+// Temporal example: it asks a weighted mix of ranking, trend and comparison
+// questions at a fixed rate and reports success rate, latency percentiles
+// and token spend per question type. Every request runs under its own
+// client span, and the trace context goes with the request, so each
+// question shows up in Scout as one trace from askgen through the pipeline.
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"sort"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"ai-data-analyst/internal/config"
+	"ai-data-analyst/internal/telemetry"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func main() {
+	defaultURL := os.Getenv("ASKGEN_URL")
+	if defaultURL == "" {
+		defaultURL = "http://localhost:8080/api/ask"
+	}
+
+	var (
+		askURL   = flag.String("url", defaultURL, "ask endpoint URL")
+		apiKey   = flag.String("api-key", os.Getenv("ASKGEN_API_KEY"), "X-API-Key to send, if the server requires one")
+		count    = flag.Int("count", 0, "number of questions to ask (0 = unlimited)")
+		rps      = flag.Float64("rps", 1, "questions per second")
+		duration = flag.Duration("duration", 0, "how long to run (0 = until count is reached)")
+		workers  = flag.Int("workers", 5, "concurrent requests")
+		mixFlag  = flag.String("mix", "ranking=5,trend=3,comparison=2", "question types and their weights")
+		timeout  = flag.Duration("timeout", 2*time.Minute, "per-request timeout")
+		seed     = flag.Int64("seed", 0, "seed for question choice (0 = random)")
+	)
+	flag.Parse()
+
+	if *count == 0 && *duration == 0 {
+		log.Fatal("Specify -count or -duration")
+	}
+	if *rps <= 0 {
+		log.Fatal("-rps must be positive")
+	}
+	if *workers < 1 {
+		log.Fatal("-workers must be at least 1")
+	}
+	if u, err := url.Parse(*askURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		log.Fatalf("Invalid -url %q: must be an http or https URL", *askURL)
+	}
+	mix, err := parseMix(*mixFlag)
+	if err != nil {
+		log.Fatalf("Invalid -mix: %v", err)
+	}
+	if *seed == 0 {
+		*seed = time.Now().UnixNano()
+	}
+
+	cfg := config.Load()
+	ctx := context.Background()
+	tp, err := telemetry.Init(ctx, cfg.OTelServiceName+"-askgen", telemetry.ExporterConfig{
+		Endpoint:       cfg.OTelEndpoint,
+		Protocol:       cfg.OTelProtocol,
+		CACertFile:     cfg.OTelCACert,
+		ClientCertFile: cfg.OTelClientCert,
+		ClientKeyFile:  cfg.OTelClientKey,
+	}, cfg.ScoutEnvironment)
+	if err != nil {
+		log.Fatalf("Failed to init telemetry: %v", err)
+	}
+
+	g := &generator{
+		url:    *askURL,
+		apiKey: *apiKey,
+		tracer: tp.Tracer,
+		client: &http.Client{Timeout: *timeout, Transport: otelhttp.NewTransport(http.DefaultTransport)},
+		stats:  map[string]*typeStats{},
+	}
+	log.Printf("Asking %s at %.2f rps with %d workers (mix %s)", *askURL, *rps, *workers, *mixFlag)
+	elapsed := g.run(mix, rand.New(rand.NewSource(*seed)), *count, *rps, *duration, *workers)
+	g.report(os.Stdout, elapsed)
+
+	// Flush the spans of the run before exiting.
+	shutdownCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	if err := tp.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Telemetry shutdown: %v", err)
+	}
+}
+
+// askResponse is the part of pipeline.AskResult askgen reads.
+type askResponse struct {
+	TotalTokens  int     `json:"total_tokens"`
+	TotalCostUSD float64 `json:"total_cost_usd"`
+	CacheHit     string  `json:"cache_hit"`
+}
+
+type question struct {
+	Type string
+	Text string
+}
+
+// typeStats accumulates the outcomes of one question type.
+type typeStats struct {
+	Success   int
+	Failure   int
+	CacheHits int
+	Tokens    int
+	CostUSD   float64
+	Latencies []time.Duration
+	Statuses  map[int]int
+}
+
+type generator struct {
+	url    string
+	apiKey string
+	tracer trace.Tracer
+	client *http.Client
+
+	mu    sync.Mutex
+	stats map[string]*typeStats
+}
+
+// run asks questions at rps until count questions or duration, whichever
+// comes first, and returns how long it took.
+func (g *generator) run(mix *questionMix, r *rand.Rand, count int, rps float64, duration time.Duration, workers int) time.Duration {
+	start := time.Now()
+	questions := make(chan question, workers*2)
+
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Go(func() {
+			for q := range questions {
+				g.ask(q)
+			}
+		})
+	}
+
+	var stop <-chan time.Time
+	if duration > 0 {
+		stop = time.After(duration)
+	}
+	ticker := time.NewTicker(time.Duration(float64(time.Second) / rps))
+	defer ticker.Stop()
+
+loop:
+	for sent := 0; count == 0 || sent < count; sent++ {
+		select {
+		case <-stop:
+			break loop
+		case <-ticker.C:
+			typ, text := mix.next(r)
+			questions <- question{Type: typ, Text: text}
+		}
+	}
+	close(questions)
+	wg.Wait()
+	return time.Since(start)
+}
+
+// ask sends one question under an askgen span and records the outcome.
+func (g *generator) ask(q question) {
+	ctx, span := g.tracer.Start(context.Background(), "askgen ask",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("nlsql.question_type", q.Type),
+			attribute.String("nlsql.question", q.Text),
+		),
+	)
+	defer span.End()
+
+	start := time.Now()
+	status, resp, err := g.post(ctx, q.Text)
+	latency := time.Since(start)
+
+	span.SetAttributes(attribute.Int("http.response.status_code", status))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		log.Printf("Question failed (%s): %q: %v", q.Type, q.Text, err)
+	} else {
+		span.SetAttributes(
+			attribute.Int("nlsql.total_tokens", resp.TotalTokens),
+			attribute.Float64("nlsql.total_cost_usd", resp.TotalCostUSD),
+			attribute.String("nlsql.cache_hit", resp.CacheHit),
+		)
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	s := g.stats[q.Type]
+	if s == nil {
+		s = &typeStats{Statuses: map[int]int{}}
+		g.stats[q.Type] = s
+	}
+	s.Statuses[status]++
+	if err != nil {
+		s.Failure++
+		return
+	}
+	s.Success++
+	s.Latencies = append(s.Latencies, latency)
+	s.Tokens += resp.TotalTokens
+	s.CostUSD += resp.TotalCostUSD
+	if resp.CacheHit != "" {
+		s.CacheHits++
+	}
+}
+
+// post returns the response status, 0 if there was none, and the decoded
+// answer of a 2xx response.
+func (g *generator) post(ctx context.Context, text string) (int, *askResponse, error) {
+	body, err := json.Marshal(map[string]string{"question": text})
+	if err != nil {
+		return 0, nil, err
+	}
+	// #nosec G704 -- the URL is the operator-supplied target, validated at startup.
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, g.url, bytes.NewReader(body))
+	if err != nil {
+		return 0, nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if g.apiKey != "" {
+		req.Header.Set("X-API-Key", g.apiKey)
+	}
+
+	// #nosec G704 -- see above.
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return resp.StatusCode, nil, fmt.Errorf("status %d", resp.StatusCode)
+	}
+	var answer askResponse
+	if err := json.NewDecoder(resp.Body).Decode(&answer); err != nil {
+		return resp.StatusCode, nil, fmt.Errorf("decode response: %w", err)
+	}
+	return resp.StatusCode, &answer, nil
+}
+
+func (g *generator) report(w io.Writer, elapsed time.Duration) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	types := make([]string, 0, len(g.stats))
+	for t := range g.stats {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+
+	overall := &typeStats{Statuses: map[int]int{}}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TYPE\tQUESTIONS\tSUCCESS RATE\tP50\tP95\tCACHE HITS\tTOKENS\tCOST")
+	row := func(name string, s *typeStats) {
+		total := s.Success + s.Failure
+		latencies := slices.Clone(s.Latencies)
+		slices.Sort(latencies)
+		fmt.Fprintf(tw, "%s\t%d\t%.1f%%\t%s\t%s\t%d\t%d\t$%.4f\n",
+			name, total, float64(s.Success)/float64(max(total, 1))*100,
+			percentile(latencies, 50), percentile(latencies, 95),
+			s.CacheHits, s.Tokens, s.CostUSD)
+	}
+	for _, t := range types {
+		s := g.stats[t]
+		row(t, s)
+		overall.Success += s.Success
+		overall.Failure += s.Failure
+		overall.CacheHits += s.CacheHits
+		overall.Tokens += s.Tokens
+		overall.CostUSD += s.CostUSD
+		overall.Latencies = append(overall.Latencies, s.Latencies...)
+		for code, n := range s.Statuses {
+			overall.Statuses[code] += n
+		}
+	}
+	row("overall", overall)
+	tw.Flush()
+
+	statuses := make([]int, 0, len(overall.Statuses))
+	for code := range overall.Statuses {
+		statuses = append(statuses, code)
+	}
+	sort.Ints(statuses)
+	fmt.Fprint(w, "\nStatus codes:")
+	for _, code := range statuses {
+		label := fmt.Sprint(code)
+		if code == 0 {
+			label = "no response"
+		}
+		fmt.Fprintf(w, " %s=%d", label, overall.Statuses[code])
+	}
+	total := overall.Success + overall.Failure
+	fmt.Fprintf(w, "\nElapsed %s, %.2f questions/s\n", elapsed.Round(time.Millisecond), float64(total)/elapsed.Seconds())
+}
+
+// percentile expects sorted input and uses the nearest-rank method.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	idx := max((p*len(sorted)+99)/100-1, 0)
+	return sorted[idx].Round(time.Millisecond)
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// questionType is one kind of question in the mix. Templates are filled in
+// at random, so repeated runs spread over many distinct questions and do not
+// all come back from the result cache.
+type questionType struct {
+	Name      string
+	Templates []func(r *rand.Rand) string
+}
+
+var (
+	sampleCountries = []string{
+		"United States", "China", "India", "Brazil", "Germany", "Japan", "Nigeria",
+		"Indonesia", "Mexico", "South Africa", "France", "Turkey", "Kenya", "Vietnam",
+	}
+	sampleRegions = []string{
+		"Europe & Central Asia", "Sub-Saharan Africa", "East Asia & Pacific",
+		"Latin America & Caribbean", "South Asia",
+	}
+	sampleIndicators = []string{
+		"GDP per capita", "GDP growth", "life expectancy", "population", "inflation",
+		"unemployment", "CO2 emissions per capita", "internet usage", "health expenditure",
+	}
+)
+
+var questionTypes = map[string]questionType{
+	"ranking": {Name: "ranking", Templates: []func(r *rand.Rand) string{
+		func(r *rand.Rand) string {
+			return fmt.Sprintf("Top %d countries by %s in %d", 5+r.Intn(6), pick(r, sampleIndicators), year(r))
+		},
+		func(r *rand.Rand) string {
+			return fmt.Sprintf("Which %d countries had the lowest %s in %d?", 3+r.Intn(5), pick(r, sampleIndicators), year(r))
+		},
+		func(r *rand.Rand) string {
+			return fmt.Sprintf("Rank the countries in %s by %s in %d", pick(r, sampleRegions), pick(r, sampleIndicators), year(r))
+		},
+	}},
+	"trend": {Name: "trend", Templates: []func(r *rand.Rand) string{
+		func(r *rand.Rand) string {
+			from := 2003 + r.Intn(15)
+			return fmt.Sprintf("How has %s changed in %s from %d to %d?", pick(r, sampleIndicators), pick(r, sampleCountries), from, from+3+r.Intn(2023-from-2))
+		},
+		func(r *rand.Rand) string {
+			return fmt.Sprintf("Show the trend of %s in %s over time", pick(r, sampleIndicators), pick(r, sampleCountries))
+		},
+	}},
+	"comparison": {Name: "comparison", Templates: []func(r *rand.Rand) string{
+		func(r *rand.Rand) string {
+			a, b := pickTwo(r, sampleCountries)
+			return fmt.Sprintf("Compare %s between %s and %s in %d", pick(r, sampleIndicators), a, b, year(r))
+		},
+		func(r *rand.Rand) string {
+			a, b := pickTwo(r, sampleRegions)
+			return fmt.Sprintf("Compare average %s in %s and %s in %d", pick(r, sampleIndicators), a, b, year(r))
+		},
+	}},
+}
+
+func pick(r *rand.Rand, from []string) string {
+	return from[r.Intn(len(from))]
+}
+
+func pickTwo(r *rand.Rand, from []string) (string, string) {
+	i := r.Intn(len(from))
+	j := (i + 1 + r.Intn(len(from)-1)) % len(from)
+	return from[i], from[j]
+}
+
+func year(r *rand.Rand) int {
+	return 2005 + r.Intn(19)
+}
+
+// weightedType is a question type and its share of the mix.
+type weightedType struct {
+	questionType
+	Weight float64
+}
+
+// questionMix picks question types in proportion to their weights.
+type questionMix struct {
+	types []weightedType
+	total float64
+}
+
+// parseMix reads a mix such as "ranking=5,trend=3,comparison=2".
+func parseMix(s string) (*questionMix, error) {
+	m := &questionMix{}
+	for _, part := range strings.Split(s, ",") {
+		name, weight, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return nil, fmt.Errorf("mix entry %q is not type=weight", part)
+		}
+		qt, ok := questionTypes[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown question type %q (have %s)", name, strings.Join(typeNames(), ", "))
+		}
+		w, err := strconv.ParseFloat(weight, 64)
+		if err != nil || w < 0 {
+			return nil, fmt.Errorf("weight of %s must be a non-negative number", name)
+		}
+		m.types = append(m.types, weightedType{questionType: qt, Weight: w})
+		m.total += w
+	}
+	if m.total == 0 {
+		return nil, fmt.Errorf("mix has no weight")
+	}
+	return m, nil
+}
+
+func typeNames() []string {
+	names := make([]string, 0, len(questionTypes))
+	for name := range questionTypes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// next returns a question type and a question of that type.
+func (m *questionMix) next(r *rand.Rand) (string, string) {
+	x := r.Float64() * m.total
+	qt := m.types[len(m.types)-1].questionType
+	for _, t := range m.types {
+		if x < t.Weight {
+			qt = t.questionType
+			break
+		}
+		x -= t.Weight
+	}
+	return qt.Name, qt.Templates[r.Intn(len(qt.Templates))](r)
+}
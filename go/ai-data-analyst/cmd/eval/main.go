@@ -0,0 +1,148 @@
+// Command eval runs a golden set of questions through the pipeline against
+// the seed database and reports accuracy, cost and latency per question type.
+// Results are printed and exported as nlsql.eval.* metrics; with
+// -min-accuracy it exits 1 when overall accuracy falls below the threshold.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"ai-data-analyst/internal/config"
+	"ai-data-analyst/internal/db"
+	"ai-data-analyst/internal/eval"
+	"ai-data-analyst/internal/llm"
+	"ai-data-analyst/internal/pipeline"
+	"ai-data-analyst/internal/telemetry"
+)
+
+func main() {
+	goldenPath := flag.String("golden", "data/eval/golden.yaml", "golden set YAML file")
+	jsonOut := flag.Bool("json", false, "print the report as JSON")
+	minAccuracy := flag.Float64("min-accuracy", 0, "exit 1 if overall accuracy is below this (0-1)")
+	flag.Parse()
+
+	set, err := eval.Load(*goldenPath)
+	if err != nil {
+		log.Fatalf("Failed to load golden set: %v", err)
+	}
+
+	cfg := config.Load()
+	ctx := context.Background()
+
+	tp, err := telemetry.Init(ctx, cfg.OTelServiceName+"-eval", telemetry.ExporterConfig{
+		Endpoint:       cfg.OTelEndpoint,
+		Protocol:       cfg.OTelProtocol,
+		CACertFile:     cfg.OTelCACert,
+		ClientCertFile: cfg.OTelClientCert,
+		ClientKeyFile:  cfg.OTelClientKey,
+	}, cfg.ScoutEnvironment)
+	if err != nil {
+		log.Fatalf("Failed to init telemetry: %v", err)
+	}
+	// Flush the metrics of this run before exiting, whatever the outcome.
+	shutdown := func() {
+		shutdownCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		defer cancel()
+		if err := tp.Shutdown(shutdownCtx); err != nil {
+			log.Printf("Telemetry shutdown: %v", err)
+		}
+	}
+
+	metrics, err := telemetry.NewGenAIMetrics(tp.Meter)
+	if err != nil {
+		log.Fatalf("Failed to init metrics: %v", err)
+	}
+	evalMetrics, err := telemetry.NewEvalMetrics(tp.Meter)
+	if err != nil {
+		log.Fatalf("Failed to init eval metrics: %v", err)
+	}
+
+	database, err := db.NewPool(ctx, cfg.DatabaseURL)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer database.Close()
+	if _, err := db.Migrate(ctx, database); err != nil {
+		log.Fatalf("Failed to migrate database: %v", err)
+	}
+
+	llmClient, err := llm.NewClient(ctx, cfg, tp.Tracer, metrics)
+	if err != nil {
+		log.Fatalf("Failed to init LLM client: %v", err)
+	}
+
+	guardrailMode, err := pipeline.ParseGuardrailMode(cfg.GuardrailMode)
+	if err != nil {
+		log.Fatalf("Invalid GUARDRAIL_MODE: %v", err)
+	}
+	cfg.GuardrailMode = guardrailMode
+
+	// The prompts under test are the ones the server would use: built-in,
+	// PROMPTS_DIR and database versions, with the stored activations. No
+	// result cache, so every question reaches the LLM.
+	prompts := pipeline.NewPromptRegistry()
+	if _, err := prompts.LoadDir(cfg.PromptsDir); err != nil {
+		log.Printf("Failed to load prompts from %s: %v", cfg.PromptsDir, err)
+	}
+	if _, err := prompts.LoadDB(ctx, database); err != nil {
+		log.Printf("Failed to load prompts from database: %v", err)
+	}
+
+	p := &pipeline.Pipeline{
+		LLM:        llmClient,
+		DB:         database,
+		Tracer:     tp.Tracer,
+		Metrics:    metrics,
+		Config:     cfg,
+		Dictionary: db.NewDictionaryCache(database, cfg.DictionaryCacheTTL),
+		LiveSchema: db.NewSchemaCache(database, cfg.SchemaCacheTTL, db.DatasetTables),
+		Prompts:    prompts,
+	}
+
+	runner := &eval.Runner{Pipeline: p, DB: database, Tracer: tp.Tracer, Metrics: evalMetrics}
+	log.Printf("Evaluating %d questions from %s", len(set.Cases), *goldenPath)
+	report := runner.Run(ctx, set)
+
+	if *jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(report)
+	} else {
+		printReport(os.Stdout, report)
+	}
+
+	shutdown()
+	if report.Overall.Accuracy < *minAccuracy {
+		log.Printf("Accuracy %.1f%% is below the minimum of %.1f%%", report.Overall.Accuracy*100, *minAccuracy*100)
+		os.Exit(1)
+	}
+}
+
+func printReport(w io.Writer, r *eval.Report) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TYPE\tQUESTIONS\tPASSED\tFAILED\tERRORS\tACCURACY\tTOKENS\tCOST\tAVG LATENCY\tMAX LATENCY")
+	for _, s := range append(r.ByType, r.Overall) {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\t%.1f%%\t%d\t$%.4f\t%dms\t%dms\n",
+			s.QuestionType, s.Questions, s.Passed, s.Failed, s.Errors, s.Accuracy*100,
+			s.TotalTokens, s.CostUSD, s.AvgDurationMS, s.MaxDurationMS)
+	}
+	tw.Flush()
+
+	for _, c := range r.Cases {
+		if c.Outcome != eval.OutcomePass {
+			fmt.Fprintf(w, "\n[%s] %s\n  %s\n", c.Outcome, c.Question, c.Reason)
+			if c.SQL != "" {
+				fmt.Fprintf(w, "  sql: %s\n", c.SQL)
+			}
+		}
+	}
+	fmt.Fprintf(w, "\ntrace_id: %s\n", r.TraceID)
+}
@@ -0,0 +1,63 @@
+// Command seedgen generates the synthetic World Bank style dataset. It
+// writes the dataset as SQL, to stdout or -out, or with -load replaces the
+// dataset tables in DATABASE_URL with it, as POST /api/admin/reseed does.
+// The same flags always generate the same rows.
+package main
+
+import (
+	"context"
+	"flag"
+	"io"
+	"log"
+	"os"
+
+	"ai-data-analyst/internal/config"
+	"ai-data-analyst/internal/seed"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func main() {
+	def := seed.DefaultConfig()
+	var cfg seed.Config
+	flag.IntVar(&cfg.Countries, "countries", def.Countries, "number of countries to generate")
+	flag.IntVar(&cfg.FirstYear, "first-year", def.FirstYear, "first year of indicator values")
+	flag.IntVar(&cfg.LastYear, "last-year", def.LastYear, "last year of indicator values")
+	flag.Int64Var(&cfg.Seed, "seed", def.Seed, "random number generator seed")
+	out := flag.String("out", "", "write the SQL to this file instead of stdout")
+	load := flag.Bool("load", false, "load into DATABASE_URL instead of writing SQL")
+	flag.Parse()
+
+	dataset, err := seed.Generate(cfg)
+	if err != nil {
+		log.Fatalf("Invalid flags: %v", err)
+	}
+
+	if *load {
+		ctx := context.Background()
+		pool, err := pgxpool.New(ctx, config.Load().DatabaseURL)
+		if err != nil {
+			log.Fatalf("Failed to connect to database: %v", err)
+		}
+		defer pool.Close()
+		stats, err := seed.Load(ctx, pool, dataset)
+		if err != nil {
+			log.Fatalf("Failed to load dataset: %v", err)
+		}
+		log.Printf("Loaded %d countries, %d indicators, %d values", stats.Countries, stats.Indicators, stats.Values)
+		return
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			log.Fatalf("Failed to create %s: %v", *out, err)
+		}
+		defer f.Close()
+		w = f
+	}
+	if err := dataset.WriteSQL(w); err != nil {
+		log.Fatalf("Failed to write SQL: %v", err)
+	}
+}
@@ -6,9 +6,12 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
+	"ai-data-analyst/internal/auth"
+	"ai-data-analyst/internal/cache"
 	"ai-data-analyst/internal/config"
 	"ai-data-analyst/internal/db"
 	"ai-data-analyst/internal/llm"
@@ -17,57 +20,138 @@ import (
 	"ai-data-analyst/internal/routes"
 	"ai-data-analyst/internal/telemetry"
 
+	"github.com/base-14/examples/go/pkg/depwait"
+	"github.com/base-14/examples/go/pkg/healthcheck"
+	"github.com/base-14/examples/go/pkg/shutdown"
 	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 func main() {
 	cfg := config.Load()
+	healthcheck.Main(depwait.Dependency{Name: "server", Check: healthcheck.HTTP(healthcheck.Local(cfg.Port, "/api/health"))})
 	ctx := context.Background()
 
 	// Telemetry
-	tp, err := telemetry.Init(ctx, cfg.OTelServiceName, cfg.OTelEndpoint, cfg.ScoutEnvironment)
+	tp, err := telemetry.Init(ctx, cfg.OTelServiceName, telemetry.ExporterConfig{
+		Endpoint:       cfg.OTelEndpoint,
+		Protocol:       cfg.OTelProtocol,
+		CACertFile:     cfg.OTelCACert,
+		ClientCertFile: cfg.OTelClientCert,
+		ClientKeyFile:  cfg.OTelClientKey,
+	}, cfg.ScoutEnvironment,
+		// The models the server starts with; nlsql.llm.active_model
+		// follows runtime switches.
+		attribute.String("nlsql.llm.provider", cfg.LLMProvider),
+		attribute.String("nlsql.llm.model_capable", cfg.LLMModelCapable),
+		attribute.String("nlsql.llm.model_fast", cfg.LLMModelFast),
+	)
 	if err != nil {
 		log.Fatalf("Failed to init telemetry: %v", err)
 	}
 
+	// Shutdown runs in phases: the HTTP server, then the background jobs,
+	// then the connections they used, and telemetry last so it is flushed
+	// after everything else has finished.
+	sd := shutdown.New(shutdown.Config{OnClose: logShutdown})
+	sd.Add(shutdown.PhaseTelemetry, "telemetry", tp.Shutdown)
+
 	metrics, err := telemetry.NewGenAIMetrics(tp.Meter)
 	if err != nil {
 		log.Fatalf("Failed to init metrics: %v", err)
 	}
 
-	// Database
-	pool, err := db.NewPool(ctx, cfg.DatabaseURL)
-	if err != nil {
+	// Database. A missing database puts the server in degraded mode instead
+	// of failing startup: /api/ask returns SQL without results and /readyz
+	// reports 503 until a background reconnect succeeds.
+	database := db.NewConnector(cfg.DatabaseURL)
+	connectCtx, cancelConnect := context.WithTimeout(ctx, 5*time.Second)
+	if err := database.Connect(connectCtx); err != nil {
 		log.Printf("WARNING: Database not available: %v", err)
-		log.Printf("Running without database — /api/ask will not work")
-		pool = nil
+		log.Printf("Running in degraded mode — /api/ask returns SQL only, retrying every %s", cfg.DBRetryInterval)
+	}
+	cancelConnect()
+	sd.Add(shutdown.PhaseClients, "postgres", shutdown.Func(database.Close))
+
+	// Background jobs run until runCtx is cancelled; shutdown waits for them
+	// before closing the connections they use.
+	runCtx, stopRun := context.WithCancel(ctx)
+	defer stopRun()
+	var jobs sync.WaitGroup
+	sd.Add(shutdown.PhaseWorkers, "background jobs", func(context.Context) error {
+		stopRun()
+		jobs.Wait()
+		return nil
+	})
+	jobs.Go(func() { database.Run(runCtx, cfg.DBRetryInterval) })
+
+	if err := telemetry.RegisterDependencyHealth(tp.Meter, map[string]func() bool{
+		"postgres": database.Healthy,
+	}); err != nil {
+		log.Fatalf("Failed to init dependency health metric: %v", err)
 	}
 
 	// LLM client
-	var primary llm.Provider
-	switch cfg.LLMProvider {
-	case "ollama":
-		primary = llm.NewOllamaProvider(cfg.OllamaBaseURL)
-	case "google":
-		primary = llm.NewGoogleProvider(cfg.GoogleAPIKey)
-	default:
-		primary = llm.NewOpenAIProvider(cfg.OpenAIAPIKey)
+	llmClient, err := llm.NewClient(ctx, cfg, tp.Tracer, metrics)
+	if err != nil {
+		log.Fatalf("Failed to init LLM client: %v", err)
+	}
+	for _, stage := range llm.RoutableStages {
+		if route, ok := llmClient.Routes[stage]; ok {
+			log.Printf("LLM route: %s stage -> %s %s", stage, route.ProviderName, route.Model)
+		}
+	}
+	breakerStates := map[string]func() int64{}
+	for name, b := range llmClient.Breakers() {
+		breakerStates[name] = func() int64 { return int64(b.State()) }
+	}
+	if err := telemetry.RegisterCircuitBreakers(tp.Meter, breakerStates); err != nil {
+		log.Fatalf("Failed to init circuit breaker metric: %v", err)
+	}
+	var ollama *llm.OllamaAdmin
+	if cfg.LLMProvider == "ollama" {
+		ollama = llm.NewOllamaAdmin(cfg.OllamaBaseURL, cfg.LLMModelCapable, cfg.LLMModelFast)
+		checkCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		if missing, err := ollama.Check(checkCtx); err != nil {
+			log.Printf("WARNING: Ollama not reachable at %s: %v", cfg.OllamaBaseURL, err)
+		} else if len(missing) > 0 {
+			log.Printf("WARNING: Ollama models not installed: %v — /api/ask is degraded until pulled", missing)
+		}
+		cancel()
 	}
 
-	var fallback llm.Provider
-	if cfg.FallbackProvider == "anthropic" && cfg.AnthropicAPIKey != "" {
-		fallback = llm.NewAnthropicProvider(cfg.AnthropicAPIKey)
+	// Budgets: over-limit calls move to the fast model, or are rejected
+	// when even that would not fit. Spend is kept in budget_spend so a
+	// restart does not reset it.
+	budgetLimits := llm.BudgetLimits{
+		PerRequestUSD: cfg.BudgetPerRequest,
+		PerSessionUSD: cfg.BudgetPerSession,
+		DailyUSD:      cfg.BudgetDaily,
+	}
+	if budgetLimits.Enabled() {
+		llmClient.Budget = llm.NewBudget(budgetLimits, cfg.LLMModelFast, cfg.BudgetDowngrade)
+		llmClient.Budget.Store = db.NewBudgetRepo(database)
+		log.Printf("LLM budgets: $%.2f per request, $%.2f per session, $%.2f per day (0 = unlimited)",
+			budgetLimits.PerRequestUSD, budgetLimits.PerSessionUSD, budgetLimits.DailyUSD)
 	}
 
-	llmClient := &llm.Client{
-		Primary:              primary,
-		Fallback:             fallback,
-		Tracer:               tp.Tracer,
-		Metrics:              metrics,
-		PrimaryProvider:      cfg.LLMProvider,
-		FallbackProviderName: cfg.FallbackProvider,
-		FallbackModel:        cfg.FallbackModel,
-		CaptureContent:       cfg.CaptureContent,
+	embeddingModel := cfg.EmbeddingModel
+	if embeddingModel == "" {
+		embeddingModel = llm.DefaultEmbeddingModels[cfg.LLMProvider]
+	}
+
+	// Semantic cache: a prompt close enough to an earlier one is answered
+	// without a completion call, at the price of an embedding.
+	if cfg.SemanticCacheEnabled {
+		if llmClient.CanEmbed() {
+			llmClient.Cache = llm.NewSemanticCache(embeddingModel, cfg.SemanticCacheThreshold,
+				cfg.SemanticCacheSize, cfg.SemanticCacheTTL)
+			log.Printf("LLM semantic cache: similarity >= %.2f, %d entries, TTL %s",
+				cfg.SemanticCacheThreshold, cfg.SemanticCacheSize, cfg.SemanticCacheTTL)
+		} else {
+			log.Printf("WARNING: SEMANTIC_CACHE_ENABLED needs a provider with embeddings; %s has none", cfg.LLMProvider)
+		}
 	}
 
 	// Pipeline
@@ -76,23 +160,259 @@ func main() {
 		Tracer:  tp.Tracer,
 		Metrics: metrics,
 		Config:  cfg,
+		Runtime: config.NewRuntimeStore(cfg),
+	}
+	dictionary := db.NewDictionaryCache(database, cfg.DictionaryCacheTTL)
+	schema := db.NewSchemaCache(database, cfg.SchemaCacheTTL, db.DatasetTables)
+	p.DB = database
+	p.Dictionary = dictionary
+
+	// Generated SQL runs on Postgres by default. Other dialects query a
+	// separate database holding the same tables; app state stays in Postgres.
+	dialect, err := pipeline.DialectFor(cfg.SQLDialect)
+	if err != nil {
+		log.Fatalf("Invalid SQL_DIALECT: %v", err)
+	}
+	p.Dialect = dialect
+	if dialect.Name() != pipeline.DialectPostgres {
+		if cfg.TargetDatabaseURL == "" {
+			log.Fatalf("TARGET_DATABASE_URL is required for SQL_DIALECT=%s", dialect.Name())
+		}
+		target, err := db.OpenSQL(ctx, dialect.Name(), cfg.TargetDatabaseURL, dialect.ConnSetup())
+		if err != nil {
+			log.Fatalf("Failed to open %s target database: %v", dialect.Name(), err)
+		}
+		sd.Add(shutdown.PhaseClients, dialect.Name()+" target", shutdown.ErrFunc(target.Close))
+		p.Target = target
+		log.Printf("Generated SQL runs on %s", dialect.Name())
+	} else {
+		// The prompt describes the tables as introspected, so it follows
+		// schema changes. Other dialects keep the static description, whose
+		// types are not Postgres-specific.
+		p.LiveSchema = schema
 	}
-	if pool != nil {
-		p.DB = pool
+
+	// Further data sources: the Parse stage sends a question that names
+	// their keywords to their own database, with their own schema context.
+	// Like the main database, a Postgres source that is down degrades only
+	// the questions about it, and is reconnected in the background.
+	if cfg.DataSourcesFile != "" {
+		specs, err := pipeline.LoadDataSources(cfg.DataSourcesFile)
+		if err != nil {
+			log.Fatalf("Invalid data sources: %v", err)
+		}
+		for _, spec := range specs {
+			d, _ := pipeline.DialectFor(spec.Dialect)
+			source := &pipeline.DataSource{
+				Name:        spec.Name,
+				Description: spec.Description,
+				Keywords:    spec.Keywords,
+				Dialect:     d,
+				Schema:      spec.Schema,
+			}
+			if d.Name() == pipeline.DialectPostgres {
+				conn := db.NewReadConnector(spec.DatabaseURL)
+				connectCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+				if err := conn.Connect(connectCtx); err != nil {
+					log.Printf("WARNING: Data source %s not available: %v", spec.Name, err)
+				}
+				cancel()
+				sd.Add(shutdown.PhaseClients, spec.Name+" source", shutdown.Func(conn.Close))
+				jobs.Go(func() { conn.Run(runCtx, cfg.DBRetryInterval) })
+				source.DB = conn
+			} else {
+				target, err := db.OpenSQL(ctx, d.Name(), spec.DatabaseURL, d.ConnSetup())
+				if err != nil {
+					log.Fatalf("Failed to open data source %s: %v", spec.Name, err)
+				}
+				sd.Add(shutdown.PhaseClients, spec.Name+" source", shutdown.ErrFunc(target.Close))
+				source.DB = target
+			}
+			p.Sources = append(p.Sources, source)
+			log.Printf("Data source %s on %s: %s", spec.Name, d.Name(), spec.Description)
+		}
+	}
+
+	guardrailMode, err := pipeline.ParseGuardrailMode(cfg.GuardrailMode)
+	if err != nil {
+		log.Fatalf("Invalid GUARDRAIL_MODE: %v", err)
+	}
+	cfg.GuardrailMode = guardrailMode
+
+	// Schema retrieval: the Generate prompt gets the schema fragments closest
+	// to the question. Until indexing succeeds, and for providers without an
+	// embeddings API, the full schema context is used.
+	if cfg.SchemaRetrieval && llmClient.CanEmbed() {
+		retriever := &pipeline.SchemaRetriever{
+			LLM:     llmClient,
+			DB:      database,
+			Tracer:  tp.Tracer,
+			Metrics: metrics,
+			Model:   embeddingModel,
+			TopK:    cfg.SchemaTopK,
+		}
+		p.Schema = retriever
+		jobs.Go(func() { retriever.Run(runCtx, cfg.DBRetryInterval) })
+	}
+
+	// Sandbox sessions keep a dedicated connection each for their temporary
+	// tables. They need Postgres: other dialects reject them at creation.
+	var sandboxes *pipeline.Sandboxes
+	if cfg.SandboxEnabled && dialect.Name() == pipeline.DialectPostgres {
+		sandboxes = &pipeline.Sandboxes{
+			Dial: func(ctx context.Context) (*pgx.Conn, error) {
+				return db.Dial(ctx, cfg.DatabaseURL)
+			},
+			Role:        cfg.SandboxRole,
+			IdleTimeout: cfg.SandboxIdleTimeout,
+			MaxSessions: cfg.SandboxMaxSessions,
+			Metrics:     metrics,
+		}
+		p.Sandboxes = sandboxes
+		jobs.Go(func() { sandboxes.Run(runCtx) })
+		// Sandbox schemas are dropped through the pool, so before it closes.
+		sd.Add(shutdown.PhaseWorkers, "sandboxes", func(ctx context.Context) error {
+			sandboxes.CloseAll(ctx)
+			return nil
+		})
+	}
+
+	// Result cache: in process by default, or shared through Redis so
+	// replicas reuse each other's answers.
+	if cfg.CacheEnabled {
+		var store cache.Store = cache.NewLRU(cfg.CacheSize)
+		if cfg.RedisURL != "" {
+			redisStore, err := cache.NewRedis(cfg.RedisURL, "nlsql:")
+			if err != nil {
+				log.Fatalf("Invalid REDIS_URL: %v", err)
+			}
+			sd.Add(shutdown.PhaseClients, "redis", shutdown.ErrFunc(redisStore.Close))
+			store = redisStore
+		}
+		p.Cache = &pipeline.ResultCache{Store: store, TTL: cfg.CacheTTL, Metrics: metrics}
+		log.Printf("Result cache: %s, TTL %s", store.Name(), cfg.CacheTTL)
+	}
+
+	// Prompt registry: built-in prompts plus versions from PROMPTS_DIR and
+	// the prompt_templates table. Activations are persisted in Postgres.
+	prompts := pipeline.NewPromptRegistry()
+	prompts.DB = database
+	if n, err := prompts.LoadDir(cfg.PromptsDir); err != nil {
+		log.Printf("Failed to load prompts from %s: %v", cfg.PromptsDir, err)
+	} else if n > 0 {
+		log.Printf("Loaded %d prompt versions from %s", n, cfg.PromptsDir)
+	}
+	if _, err := prompts.LoadDB(ctx, database); err != nil {
+		log.Printf("Failed to load prompts from database: %v", err)
+	}
+	p.Prompts = prompts
+
+	// Warm-up: ask the demo questions in the background so their answers
+	// are cached before the first visitor.
+	var warmUp *pipeline.WarmUp
+	if cfg.WarmUpEnabled {
+		warmUp = &pipeline.WarmUp{Pipeline: p, Examples: pipeline.Examples, RetryInterval: cfg.DBRetryInterval}
+		jobs.Go(func() { warmUp.Run(runCtx) })
+	}
+
+	// Cost rollups: per user, API key and day sums of query_history for
+	// /api/costs.
+	if cfg.CostRollupInterval > 0 {
+		job := &db.CostRollupJob{DB: database, Tracer: tp.Tracer, Interval: cfg.CostRollupInterval}
+		jobs.Go(func() { job.Run(runCtx) })
 	}
 
 	// Router
 	r := chi.NewRouter()
 	r.Use(middleware.OTelHTTP(cfg.OTelServiceName))
+	r.Use(middleware.Identify(auth.ParseKeys(cfg.APIKeys)))
 
 	r.Get("/api/health", routes.HealthHandler(cfg.OTelServiceName))
-	r.Get("/api/schema", routes.SchemaHandler())
-	r.Post("/api/ask", routes.AskHandler(p))
+	r.Get("/readyz", routes.ReadyHandler(map[string]func() error{"postgres": database.Check}))
+	r.Get("/version", routes.VersionHandler())
+	r.Get("/api/cache/stats", routes.CacheStatsHandler(p.Cache))
+	r.Get("/api/budget", routes.BudgetHandler(llmClient.Budget))
+	r.Get("/api/examples", routes.ExamplesHandler(warmUp))
+	r.Get("/api/prompts", routes.PromptsHandler(prompts))
+
+	// Routes that call the LLM are rate limited, per client and overall.
+	limiter := middleware.NewRateLimiter(middleware.RateLimitConfig{
+		PerIPPerMinute:  cfg.RateLimitPerIP,
+		PerIPBurst:      cfg.RateLimitPerIPBurst,
+		GlobalPerMinute: cfg.RateLimitGlobal,
+		GlobalBurst:     cfg.RateLimitGlobalBurst,
+	}, metrics.RateLimited)
+
+	// A model change, through the admin config or /api/models/active,
+	// moves the Ollama check and the budget downgrade to the new models.
+	onRuntimeChange := func(rt config.Runtime) {
+		if ollama != nil {
+			ollama.SetRequired(rt.ModelCapable, rt.ModelFast)
+		}
+		if llmClient.Budget != nil {
+			llmClient.Budget.SetFastModel(rt.ModelFast)
+		}
+	}
+	if err := telemetry.RegisterActiveModels(tp.Meter, cfg.LLMProvider, func() (string, string) {
+		rt := p.Runtime.Get()
+		return rt.ModelCapable, rt.ModelFast
+	}); err != nil {
+		log.Fatalf("Failed to init active model metric: %v", err)
+	}
+	requireAdmin := middleware.RequireAdmin(auth.ParseUsers(cfg.AdminUsers))
 
-	if pool != nil {
-		r.Get("/api/history", routes.HistoryHandler(pool))
-		r.Get("/api/indicators", routes.IndicatorsHandler(pool))
+	// Questions need the configured models; with Ollama, check they are pulled.
+	askMiddleware := []func(http.Handler) http.Handler{limiter.Middleware}
+	if ollama != nil {
+		askMiddleware = append(askMiddleware, middleware.RequireModels(ollama))
+		r.Get("/api/models", routes.ModelsHandler(ollama, p.Runtime))
+		r.Get("/api/models/{name}", routes.ModelHandler(ollama))
+		r.With(requireAdmin).Post("/api/models/pull", routes.PullModelHandler(ollama))
+		r.With(requireAdmin).Put("/api/models/active", routes.SwitchModelsHandler(ollama, p.Runtime, onRuntimeChange))
 	}
+	r.With(askMiddleware...).Post("/api/ask", routes.AskHandler(p))
+	r.With(askMiddleware...).Post("/api/ask/batch", routes.AskBatchHandler(p, cfg.BatchMaxQuestions, cfg.BatchConcurrency))
+	r.With(askMiddleware...).Handle("/ws", routes.WSHandler(p, routes.WSConfig{
+		Limiter:     limiter,
+		Metrics:     metrics,
+		IdleTimeout: cfg.WSIdleTimeout,
+	}))
+
+	r.With(requireAdmin).Post("/api/prompts/{name}/activate", routes.ActivatePromptHandler(prompts))
+
+	r.Route("/api/admin", func(r chi.Router) {
+		r.Use(requireAdmin)
+		r.Get("/config", routes.AdminConfigHandler(p.Runtime))
+		r.Put("/config", routes.UpdateAdminConfigHandler(p.Runtime, onRuntimeChange))
+		r.With(middleware.RequireDatabase(database.Check)).Post("/reseed", routes.ReseedHandler(database, func() {
+			if p.Cache != nil {
+				p.Cache.Invalidate()
+			}
+			dictionary.Invalidate()
+			schema.Invalidate()
+		}))
+	})
+
+	history := db.NewHistoryRepo(database)
+	sessions := db.NewSessionRepo(database)
+	r.Group(func(r chi.Router) {
+		r.Use(middleware.RequireDatabase(database.Check))
+		r.Get("/api/history", routes.HistoryHandler(history))
+		r.With(limiter.Middleware).Post("/api/history/{id}/replay", routes.ReplayHistoryHandler(p))
+		r.With(limiter.Middleware).Get("/api/history/{id}/export", routes.ExportHistoryHandler(p))
+		r.Post("/api/sessions", routes.CreateSessionHandler(sessions, sandboxes))
+		r.Get("/api/sessions", routes.ListSessionsHandler(sessions))
+		r.Get("/api/sessions/{id}", routes.GetSessionHandler(sessions))
+		r.Delete("/api/sessions/{id}", routes.DeleteSessionHandler(sessions, sandboxes))
+		r.With(askMiddleware...).Post("/api/sessions/{id}/ask", routes.SessionAskHandler(p))
+		r.Get("/api/indicators", routes.IndicatorsHandler(database))
+		r.Get("/api/dictionary", routes.DictionaryHandler(dictionary))
+		r.Get("/api/schema", routes.SchemaHandler(schema))
+		r.Get("/api/usage", routes.UsageHandler(database))
+		r.With(requireAdmin).Get("/api/usage/users", routes.UsageByUserHandler(database))
+		r.With(requireAdmin).Get("/api/usage/tenants", routes.UsageByTenantHandler(database))
+		r.With(requireAdmin).Get("/api/costs", routes.CostsHandler(database))
+	})
 
 	srv := &http.Server{
 		Addr:         ":" + cfg.Port,
@@ -101,6 +421,7 @@ func main() {
 		WriteTimeout: 60 * time.Second,
 		IdleTimeout:  60 * time.Second,
 	}
+	sd.Add(shutdown.PhaseServer, "http", srv.Shutdown)
 
 	go func() {
 		log.Printf("Starting %s on :%s", cfg.OTelServiceName, cfg.Port)
@@ -114,16 +435,15 @@ func main() {
 	<-sigChan
 
 	log.Println("Shutting down...")
-	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	if err := srv.Shutdown(shutdownCtx); err != nil {
-		log.Printf("Server shutdown error: %v", err)
+	if err := sd.Shutdown(context.Background()); err != nil {
+		log.Printf("Shutdown finished with errors: %v", err)
 	}
-	if pool != nil {
-		pool.Close()
-	}
-	if err := tp.Shutdown(shutdownCtx); err != nil {
-		log.Printf("Telemetry shutdown error: %v", err)
+}
+
+func logShutdown(ev shutdown.Event) {
+	if ev.Err != nil {
+		log.Printf("Shutdown: %s failed after %s: %v", ev.Name, ev.Duration.Round(time.Millisecond), ev.Err)
+		return
 	}
+	log.Printf("Shutdown: %s stopped in %s", ev.Name, ev.Duration.Round(time.Millisecond))
 }
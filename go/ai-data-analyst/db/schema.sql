@@ -1,3 +1,6 @@
+-- The World Bank dataset, loaded by seed.sql. The app's own tables, such as
+-- query_history, are created by the server's migrations (internal/db/migrate.go).
+
 CREATE TABLE IF NOT EXISTS countries (
   id SERIAL PRIMARY KEY,
   name VARCHAR(200) NOT NULL,
@@ -36,19 +39,22 @@ CREATE INDEX IF NOT EXISTS idx_values_year ON indicator_values(year);
 CREATE INDEX IF NOT EXISTS idx_values_country_indicator
   ON indicator_values(country_id, indicator_id, year DESC);
 
-CREATE TABLE IF NOT EXISTS query_history (
-  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
-  question TEXT NOT NULL,
-  question_type VARCHAR(50),
-  generated_sql TEXT NOT NULL,
-  confidence NUMERIC(3, 2),
-  row_count INTEGER,
-  execution_ms INTEGER,
-  total_tokens INTEGER,
-  total_cost_usd NUMERIC(10, 6),
-  explanation TEXT,
-  trace_id VARCHAR(32),
-  created_at TIMESTAMPTZ DEFAULT NOW()
-);
+-- Role assumed by sandbox sessions: it can read the dataset and create
+-- temporary objects, so even a statement that slips past the validator cannot
+-- write to real tables.
+DO $$
+BEGIN
+  IF NOT EXISTS (SELECT 1 FROM pg_roles WHERE rolname = 'nlsql_sandbox') THEN
+    CREATE ROLE nlsql_sandbox NOLOGIN;
+  END IF;
+END
+$$;
 
-CREATE INDEX IF NOT EXISTS idx_history_created ON query_history(created_at DESC);
+GRANT nlsql_sandbox TO CURRENT_USER;
+GRANT USAGE ON SCHEMA public TO nlsql_sandbox;
+GRANT SELECT ON countries, indicators, indicator_values TO nlsql_sandbox;
+DO $$
+BEGIN
+  EXECUTE format('GRANT TEMPORARY ON DATABASE %I TO nlsql_sandbox', current_database());
+END
+$$;
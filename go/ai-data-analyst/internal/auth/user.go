@@ -0,0 +1,103 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// AnonymousUser owns requests when no API keys are configured.
+const AnonymousUser = "anonymous"
+
+// DefaultTenant owns requests whose API key names no tenant, and requests
+// when no API keys are configured.
+const DefaultTenant = "default"
+
+// Identity is who an API key belongs to.
+type Identity struct {
+	User   string
+	Tenant string
+}
+
+type contextKey struct{}
+
+func WithUser(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, contextKey{}, userID)
+}
+
+// UserFrom returns the caller's user ID, or AnonymousUser when the request was
+// not identified.
+func UserFrom(ctx context.Context) string {
+	if v, ok := ctx.Value(contextKey{}).(string); ok && v != "" {
+		return v
+	}
+	return AnonymousUser
+}
+
+type keyIDContextKey struct{}
+
+// KeyID identifies an API key in stored data without revealing it: the first
+// 12 hex digits of its SHA-256.
+func KeyID(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:6])
+}
+
+func WithKeyID(ctx context.Context, keyID string) context.Context {
+	return context.WithValue(ctx, keyIDContextKey{}, keyID)
+}
+
+// KeyIDFrom returns the KeyID of the caller's API key, or "" when the
+// request was not identified by one.
+func KeyIDFrom(ctx context.Context) string {
+	v, _ := ctx.Value(keyIDContextKey{}).(string)
+	return v
+}
+
+type tenantContextKey struct{}
+
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantContextKey{}, tenant)
+}
+
+// TenantFrom returns the caller's tenant, or DefaultTenant when the request
+// was not identified.
+func TenantFrom(ctx context.Context) string {
+	if v, ok := ctx.Value(tenantContextKey{}).(string); ok && v != "" {
+		return v
+	}
+	return DefaultTenant
+}
+
+// ParseKeys reads "user:key" or "tenant/user:key" pairs separated by commas,
+// as set in API_KEYS, and returns a key → identity map. Keys without a tenant
+// belong to DefaultTenant. Malformed entries are skipped.
+func ParseKeys(raw string) map[string]Identity {
+	keys := map[string]Identity{}
+	for _, pair := range strings.Split(raw, ",") {
+		owner, key, ok := strings.Cut(strings.TrimSpace(pair), ":")
+		key = strings.TrimSpace(key)
+		tenant, user, scoped := strings.Cut(owner, "/")
+		if !scoped {
+			tenant, user = DefaultTenant, owner
+		}
+		tenant, user = strings.TrimSpace(tenant), strings.TrimSpace(user)
+		if !ok || tenant == "" || user == "" || key == "" {
+			continue
+		}
+		keys[key] = Identity{User: user, Tenant: tenant}
+	}
+	return keys
+}
+
+// ParseUsers reads a comma-separated list of user IDs, as set in ADMIN_USERS.
+func ParseUsers(raw string) []string {
+	var users []string
+	for _, u := range strings.Split(raw, ",") {
+		if u = strings.TrimSpace(u); u != "" {
+			users = append(users, u)
+		}
+	}
+	return users
+}
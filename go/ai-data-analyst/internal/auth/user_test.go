@@ -0,0 +1,42 @@
+package auth
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseKeys(t *testing.T) {
+	keys := ParseKeys("alice:key-a, acme/bob:key-b,broken,:nouser,nokey:,/notenant:key-c")
+	assert.Equal(t, map[string]Identity{
+		"key-a": {User: "alice", Tenant: DefaultTenant},
+		"key-b": {User: "bob", Tenant: "acme"},
+	}, keys)
+}
+
+func TestTenantFromDefaults(t *testing.T) {
+	assert.Equal(t, DefaultTenant, TenantFrom(context.Background()))
+	assert.Equal(t, "acme", TenantFrom(WithTenant(context.Background(), "acme")))
+}
+
+func TestUserFromDefaultsToAnonymous(t *testing.T) {
+	assert.Equal(t, AnonymousUser, UserFrom(context.Background()))
+	assert.Equal(t, "alice", UserFrom(WithUser(context.Background(), "alice")))
+}
+
+func TestParseUsers(t *testing.T) {
+	assert.Equal(t, []string{"alice", "bob"}, ParseUsers(" alice,, bob ,"))
+	assert.Empty(t, ParseUsers(""))
+}
+
+func TestKeyID(t *testing.T) {
+	id := KeyID("key-a")
+	assert.Len(t, id, 12)
+	assert.Equal(t, id, KeyID("key-a"))
+	assert.NotEqual(t, id, KeyID("key-b"))
+	assert.NotContains(t, id, "key-a")
+
+	assert.Empty(t, KeyIDFrom(context.Background()))
+	assert.Equal(t, id, KeyIDFrom(WithKeyID(context.Background(), id)))
+}
@@ -0,0 +1,19 @@
+// Package cache stores serialized pipeline results, in process or in Redis.
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// Store is a byte-value cache with per-entry expiry. A miss is reported as
+// ok == false with a nil error; errors are for an unreachable backend.
+type Store interface {
+	Get(ctx context.Context, key string) (value []byte, ok bool, err error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Len is the number of entries held, or -1 when the backend cannot
+	// count them cheaply.
+	Len(ctx context.Context) int
+	// Name identifies the backend in stats and telemetry.
+	Name() string
+}
@@ -0,0 +1,67 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLRUGetSet(t *testing.T) {
+	ctx := context.Background()
+	c := NewLRU(10)
+
+	_, ok, err := c.Get(ctx, "a")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	require.NoError(t, c.Set(ctx, "a", []byte("1"), 0))
+	v, ok, err := c.Get(ctx, "a")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []byte("1"), v)
+	assert.Equal(t, 1, c.Len(ctx))
+}
+
+func TestLRUEvictsLeastRecentlyUsed(t *testing.T) {
+	ctx := context.Background()
+	c := NewLRU(2)
+
+	require.NoError(t, c.Set(ctx, "a", []byte("1"), 0))
+	require.NoError(t, c.Set(ctx, "b", []byte("2"), 0))
+	_, _, _ = c.Get(ctx, "a")
+	require.NoError(t, c.Set(ctx, "c", []byte("3"), 0))
+
+	_, ok, _ := c.Get(ctx, "b")
+	assert.False(t, ok, "b was least recently used")
+	_, ok, _ = c.Get(ctx, "a")
+	assert.True(t, ok)
+	assert.Equal(t, 2, c.Len(ctx))
+	assert.Equal(t, int64(1), c.Evictions())
+}
+
+func TestLRUExpiry(t *testing.T) {
+	ctx := context.Background()
+	c := NewLRU(10)
+
+	require.NoError(t, c.Set(ctx, "a", []byte("1"), time.Millisecond))
+	time.Sleep(5 * time.Millisecond)
+
+	_, ok, _ := c.Get(ctx, "a")
+	assert.False(t, ok)
+	assert.Equal(t, 0, c.Len(ctx))
+}
+
+func TestLRUOverwrite(t *testing.T) {
+	ctx := context.Background()
+	c := NewLRU(10)
+
+	require.NoError(t, c.Set(ctx, "a", []byte("1"), 0))
+	require.NoError(t, c.Set(ctx, "a", []byte("2"), 0))
+
+	v, _, _ := c.Get(ctx, "a")
+	assert.Equal(t, []byte("2"), v)
+	assert.Equal(t, 1, c.Len(ctx))
+}
@@ -0,0 +1,48 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Redis is a Store backed by Redis, so replicas share cached results. Keys
+// are namespaced with Prefix.
+type Redis struct {
+	Client *redis.Client
+	Prefix string
+}
+
+// NewRedis connects to the Redis server at url, such as
+// redis://localhost:6379/0.
+func NewRedis(url, prefix string) (*Redis, error) {
+	opts, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, err
+	}
+	return &Redis{Client: redis.NewClient(opts), Prefix: prefix}, nil
+}
+
+func (r *Redis) Name() string { return "redis" }
+
+func (r *Redis) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	value, err := r.Client.Get(ctx, r.Prefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return value, true, nil
+}
+
+func (r *Redis) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return r.Client.Set(ctx, r.Prefix+key, value, ttl).Err()
+}
+
+// Len is -1: counting one prefix in a shared Redis means a full key scan.
+func (r *Redis) Len(context.Context) int { return -1 }
+
+func (r *Redis) Close() error { return r.Client.Close() }
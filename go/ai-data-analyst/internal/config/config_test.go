@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -20,9 +21,42 @@ func TestLoadDefaults(t *testing.T) {
 	assert.Equal(t, "http://localhost:11434", cfg.OllamaBaseURL)
 	assert.Equal(t, "ai-data-analyst", cfg.OTelServiceName)
 	assert.Equal(t, "http://localhost:4318", cfg.OTelEndpoint)
+	assert.Equal(t, "http/protobuf", cfg.OTelProtocol)
 	assert.Equal(t, "development", cfg.ScoutEnvironment)
 	assert.InDelta(t, 0.1, cfg.DefaultTemperature, 0.001)
 	assert.Equal(t, 1024, cfg.DefaultMaxTokens)
+	assert.Equal(t, 10*time.Minute, cfg.DictionaryCacheTTL)
+	assert.Equal(t, 5*time.Minute, cfg.SchemaCacheTTL)
+	assert.Equal(t, 30*time.Second, cfg.DBRetryInterval)
+	assert.Equal(t, 5, cfg.SessionTurns)
+	assert.True(t, cfg.SchemaRetrieval)
+	assert.Empty(t, cfg.EmbeddingModel)
+	assert.Equal(t, 8, cfg.SchemaTopK)
+	assert.False(t, cfg.SandboxEnabled)
+	assert.Equal(t, "nlsql_sandbox", cfg.SandboxRole)
+	assert.Equal(t, 15*time.Minute, cfg.SandboxIdleTimeout)
+	assert.Equal(t, 10, cfg.SandboxMaxSessions)
+	assert.True(t, cfg.CacheEnabled)
+	assert.Equal(t, 1000, cfg.CacheSize)
+	assert.Equal(t, 10*time.Minute, cfg.CacheTTL)
+	assert.Empty(t, cfg.RedisURL)
+	assert.Zero(t, cfg.BudgetPerRequest)
+	assert.Zero(t, cfg.BudgetPerSession)
+	assert.Zero(t, cfg.BudgetDaily)
+	assert.True(t, cfg.BudgetDowngrade)
+	assert.Empty(t, cfg.AzureEndpoint)
+	assert.Empty(t, cfg.AzureAPIVersion)
+	assert.Empty(t, cfg.AzureDeployments)
+	assert.Equal(t, "us-east-1", cfg.BedrockRegion)
+	assert.False(t, cfg.WarmUpEnabled)
+	assert.Equal(t, "data/prompts", cfg.PromptsDir)
+	assert.Equal(t, "redact", cfg.GuardrailMode)
+	assert.Equal(t, 2, cfg.SQLRepairAttempts)
+	assert.True(t, cfg.LLMBreakerEnabled)
+	assert.Equal(t, 0.5, cfg.LLMBreakerErrorRate)
+	assert.Equal(t, 5, cfg.LLMBreakerMinCalls)
+	assert.Equal(t, time.Minute, cfg.LLMBreakerWindow)
+	assert.Equal(t, 30*time.Second, cfg.LLMBreakerCooldown)
 }
 
 func TestLoadFromEnv(t *testing.T) {
@@ -43,6 +77,15 @@ func TestLoadFromEnv(t *testing.T) {
 	assert.Equal(t, "sk-test", cfg.OpenAIAPIKey)
 }
 
+func TestGRPCProtocolDefaultsEndpoint(t *testing.T) {
+	os.Clearenv()
+	t.Setenv("OTEL_EXPORTER_OTLP_PROTOCOL", "grpc")
+	cfg := Load()
+
+	assert.Equal(t, "grpc", cfg.OTelProtocol)
+	assert.Equal(t, "http://localhost:4317", cfg.OTelEndpoint)
+}
+
 func TestInvalidNumericFallsBackToDefault(t *testing.T) {
 	t.Setenv("DEFAULT_TEMPERATURE", "not-a-number")
 	t.Setenv("DEFAULT_MAX_TOKENS", "abc")
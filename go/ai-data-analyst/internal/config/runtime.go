@@ -0,0 +1,165 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const maxAuditEntries = 100
+
+// Runtime holds the settings that can be changed while the server is running
+// through the admin config endpoint. Version increases with every change and
+// is recorded on pipeline spans, so a behaviour change can be traced back to
+// the config that caused it.
+type Runtime struct {
+	Version           int64     `json:"version"`
+	Temperature       float64   `json:"temperature"`
+	MaxTokens         int       `json:"max_tokens"`
+	ModelCapable      string    `json:"model_capable"`
+	ModelFast         string    `json:"model_fast"`
+	RowLimit          int       `json:"row_limit"`
+	MaxQuestionLength int       `json:"max_question_length"`
+	MinConfidence     float64   `json:"min_confidence"`
+	UpdatedBy         string    `json:"updated_by"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// RuntimePatch is a partial update; nil fields are left unchanged.
+type RuntimePatch struct {
+	Temperature       *float64 `json:"temperature,omitempty"`
+	MaxTokens         *int     `json:"max_tokens,omitempty"`
+	ModelCapable      *string  `json:"model_capable,omitempty"`
+	ModelFast         *string  `json:"model_fast,omitempty"`
+	RowLimit          *int     `json:"row_limit,omitempty"`
+	MaxQuestionLength *int     `json:"max_question_length,omitempty"`
+	MinConfidence     *float64 `json:"min_confidence,omitempty"`
+}
+
+// FieldChange records one setting changed by an update.
+type FieldChange struct {
+	Field string `json:"field"`
+	From  any    `json:"from"`
+	To    any    `json:"to"`
+}
+
+// AuditEntry records who changed what and which version it produced.
+type AuditEntry struct {
+	Version int64         `json:"version"`
+	User    string        `json:"user"`
+	At      time.Time     `json:"at"`
+	Changes []FieldChange `json:"changes"`
+}
+
+// RuntimeStore is safe for concurrent use. Readers take a snapshot with Get
+// and use it for the whole request, so a change never applies halfway
+// through a pipeline run.
+type RuntimeStore struct {
+	mu      sync.RWMutex
+	current Runtime
+	audit   []AuditEntry
+}
+
+func NewRuntimeStore(cfg *Config) *RuntimeStore {
+	return &RuntimeStore{current: Runtime{
+		Version:           1,
+		Temperature:       cfg.DefaultTemperature,
+		MaxTokens:         cfg.DefaultMaxTokens,
+		ModelCapable:      cfg.LLMModelCapable,
+		ModelFast:         cfg.LLMModelFast,
+		RowLimit:          cfg.RowLimit,
+		MaxQuestionLength: cfg.MaxQuestionLength,
+		MinConfidence:     cfg.MinConfidence,
+		UpdatedBy:         "startup",
+		UpdatedAt:         time.Now().UTC(),
+	}}
+}
+
+func (s *RuntimeStore) Get() Runtime {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.current
+}
+
+// Audit returns the most recent changes, newest first.
+func (s *RuntimeStore) Audit() []AuditEntry {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]AuditEntry, len(s.audit))
+	for i, e := range s.audit {
+		out[len(s.audit)-1-i] = e
+	}
+	return out
+}
+
+// Update validates and applies patch on behalf of user. A patch that changes
+// nothing returns the current config and a nil entry without bumping the
+// version.
+func (s *RuntimeStore) Update(patch RuntimePatch, user string) (Runtime, *AuditEntry, error) {
+	if err := patch.validate(); err != nil {
+		return Runtime{}, nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	next := s.current
+	var changes []FieldChange
+	apply(&changes, "temperature", &next.Temperature, patch.Temperature)
+	apply(&changes, "max_tokens", &next.MaxTokens, patch.MaxTokens)
+	apply(&changes, "model_capable", &next.ModelCapable, patch.ModelCapable)
+	apply(&changes, "model_fast", &next.ModelFast, patch.ModelFast)
+	apply(&changes, "row_limit", &next.RowLimit, patch.RowLimit)
+	apply(&changes, "max_question_length", &next.MaxQuestionLength, patch.MaxQuestionLength)
+	apply(&changes, "min_confidence", &next.MinConfidence, patch.MinConfidence)
+	if len(changes) == 0 {
+		return s.current, nil, nil
+	}
+
+	next.Version++
+	next.UpdatedBy = user
+	next.UpdatedAt = time.Now().UTC()
+	s.current = next
+
+	entry := AuditEntry{Version: next.Version, User: user, At: next.UpdatedAt, Changes: changes}
+	s.audit = append(s.audit, entry)
+	if len(s.audit) > maxAuditEntries {
+		s.audit = s.audit[len(s.audit)-maxAuditEntries:]
+	}
+	return next, &entry, nil
+}
+
+func apply[T comparable](changes *[]FieldChange, field string, dst *T, src *T) {
+	if src == nil || *src == *dst {
+		return
+	}
+	*changes = append(*changes, FieldChange{Field: field, From: *dst, To: *src})
+	*dst = *src
+}
+
+func (p RuntimePatch) validate() error {
+	var errs []error
+	if p.Temperature != nil && (*p.Temperature < 0 || *p.Temperature > 2) {
+		errs = append(errs, fmt.Errorf("temperature must be between 0 and 2"))
+	}
+	if p.MaxTokens != nil && (*p.MaxTokens < 1 || *p.MaxTokens > 32768) {
+		errs = append(errs, fmt.Errorf("max_tokens must be between 1 and 32768"))
+	}
+	if p.ModelCapable != nil && *p.ModelCapable == "" {
+		errs = append(errs, fmt.Errorf("model_capable must not be empty"))
+	}
+	if p.ModelFast != nil && *p.ModelFast == "" {
+		errs = append(errs, fmt.Errorf("model_fast must not be empty"))
+	}
+	if p.RowLimit != nil && (*p.RowLimit < 1 || *p.RowLimit > 10000) {
+		errs = append(errs, fmt.Errorf("row_limit must be between 1 and 10000"))
+	}
+	if p.MaxQuestionLength != nil && (*p.MaxQuestionLength < 1 || *p.MaxQuestionLength > 10000) {
+		errs = append(errs, fmt.Errorf("max_question_length must be between 1 and 10000"))
+	}
+	if p.MinConfidence != nil && (*p.MinConfidence < 0 || *p.MinConfidence > 1) {
+		errs = append(errs, fmt.Errorf("min_confidence must be between 0 and 1"))
+	}
+	return errors.Join(errs...)
+}
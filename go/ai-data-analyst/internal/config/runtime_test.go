@@ -0,0 +1,79 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testRuntimeConfig() *Config {
+	return &Config{
+		DefaultTemperature: 0.1,
+		DefaultMaxTokens:   1024,
+		LLMModelCapable:    "gpt-5.5",
+		LLMModelFast:       "gpt-5.4-mini",
+		RowLimit:           50,
+		MaxQuestionLength:  500,
+		MinConfidence:      0.3,
+	}
+}
+
+func TestRuntimeStoreUpdate(t *testing.T) {
+	store := NewRuntimeStore(testRuntimeConfig())
+	assert.Equal(t, int64(1), store.Get().Version)
+
+	temp, rows := 0.7, 200
+	rt, entry, err := store.Update(RuntimePatch{Temperature: &temp, RowLimit: &rows}, "alice")
+	require.NoError(t, err)
+	require.NotNil(t, entry)
+
+	assert.Equal(t, int64(2), rt.Version)
+	assert.InDelta(t, 0.7, rt.Temperature, 0.001)
+	assert.Equal(t, 200, rt.RowLimit)
+	assert.Equal(t, 1024, rt.MaxTokens)
+	assert.Equal(t, "alice", rt.UpdatedBy)
+	assert.Equal(t, []FieldChange{
+		{Field: "temperature", From: 0.1, To: 0.7},
+		{Field: "row_limit", From: 50, To: 200},
+	}, entry.Changes)
+	assert.Equal(t, rt, store.Get())
+}
+
+func TestRuntimeStoreNoopUpdate(t *testing.T) {
+	store := NewRuntimeStore(testRuntimeConfig())
+
+	model := "gpt-5.5"
+	rt, entry, err := store.Update(RuntimePatch{ModelCapable: &model}, "alice")
+	require.NoError(t, err)
+	assert.Nil(t, entry)
+	assert.Equal(t, int64(1), rt.Version)
+	assert.Empty(t, store.Audit())
+}
+
+func TestRuntimeStoreRejectsInvalidPatch(t *testing.T) {
+	store := NewRuntimeStore(testRuntimeConfig())
+
+	temp, tokens, empty := 3.0, 0, ""
+	_, _, err := store.Update(RuntimePatch{Temperature: &temp, MaxTokens: &tokens, ModelFast: &empty}, "alice")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "temperature")
+	assert.Contains(t, err.Error(), "max_tokens")
+	assert.Contains(t, err.Error(), "model_fast")
+	assert.Equal(t, int64(1), store.Get().Version)
+}
+
+func TestRuntimeStoreAuditNewestFirst(t *testing.T) {
+	store := NewRuntimeStore(testRuntimeConfig())
+
+	for _, tokens := range []int{2048, 4096} {
+		_, _, err := store.Update(RuntimePatch{MaxTokens: &tokens}, "bob")
+		require.NoError(t, err)
+	}
+
+	audit := store.Audit()
+	require.Len(t, audit, 2)
+	assert.Equal(t, int64(3), audit[0].Version)
+	assert.Equal(t, int64(2), audit[1].Version)
+	assert.Equal(t, "bob", audit[0].User)
+}
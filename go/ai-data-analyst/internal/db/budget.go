@@ -0,0 +1,41 @@
+package db
+
+import "context"
+
+// BudgetRepo keeps LLM spend in budget_spend so budget limits survive a
+// restart. It implements llm.BudgetStore.
+type BudgetRepo struct {
+	q Querier
+}
+
+func NewBudgetRepo(q Querier) *BudgetRepo {
+	return &BudgetRepo{q: q}
+}
+
+const addBudgetSpend = `-- name: AddBudgetSpend :exec
+INSERT INTO budget_spend (scope, scope_key, spent_usd)
+SELECT v.scope, v.scope_key, $3
+FROM (VALUES ('daily', $1::text), ('session', $2::text)) v (scope, scope_key)
+WHERE v.scope_key <> ''
+ON CONFLICT (scope, scope_key) DO UPDATE
+SET spent_usd = budget_spend.spent_usd + EXCLUDED.spent_usd,
+	updated_at = NOW()`
+
+// AddSpend adds cost to the spend of day, a YYYY-MM-DD UTC date, and of
+// sessionID when it is set.
+func (r *BudgetRepo) AddSpend(ctx context.Context, day, sessionID string, cost float64) error {
+	_, err := r.q.Exec(ctx, addBudgetSpend, day, sessionID, cost)
+	return err
+}
+
+const getBudgetSpend = `-- name: GetBudgetSpend :one
+SELECT
+	COALESCE((SELECT spent_usd FROM budget_spend WHERE scope = 'daily' AND scope_key = $1), 0),
+	COALESCE((SELECT spent_usd FROM budget_spend WHERE scope = 'session' AND scope_key = $2), 0)`
+
+// Spend returns the spend of day and of sessionID, 0 for either when
+// nothing has been recorded.
+func (r *BudgetRepo) Spend(ctx context.Context, day, sessionID string) (daily, session float64, err error) {
+	err = r.q.QueryRow(ctx, getBudgetSpend, day, sessionID).Scan(&daily, &session)
+	return daily, session, err
+}
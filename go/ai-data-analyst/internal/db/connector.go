@@ -0,0 +1,179 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ErrUnavailable is returned by Connector queries while there is no pool.
+var ErrUnavailable = errors.New("database unavailable")
+
+// Connector owns the pool for a database that may not be up when the server
+// starts. It implements Querier, failing fast with ErrUnavailable until a
+// connection is established, and Run keeps retrying in the background so the
+// server recovers without a restart.
+type Connector struct {
+	url     string
+	migrate bool
+	pool    atomic.Pointer[pgxpool.Pool]
+	healthy atomic.Bool
+
+	mu      sync.Mutex
+	lastErr error
+}
+
+func NewConnector(databaseURL string) *Connector {
+	return &Connector{url: databaseURL, migrate: true}
+}
+
+// NewReadConnector returns a Connector for a database the app only queries,
+// such as a further data source, which Connect does not migrate.
+func NewReadConnector(databaseURL string) *Connector {
+	return &Connector{url: databaseURL}
+}
+
+// Connect makes one connection attempt and, unless the Connector came from
+// NewReadConnector, brings the schema up to date with Migrate. It is a no-op
+// once a pool exists. A failed migration fails the attempt, so the server
+// stays degraded rather than write to old tables.
+func (c *Connector) Connect(ctx context.Context) error {
+	if c.pool.Load() != nil {
+		return nil
+	}
+	pool, err := NewPool(ctx, c.url)
+	if err == nil && c.migrate {
+		var applied int
+		if applied, err = Migrate(ctx, pool); err != nil {
+			pool.Close()
+			err = fmt.Errorf("migrate: %w", err)
+		} else if applied > 0 {
+			log.Printf("Applied %d database migrations", applied)
+		}
+	}
+	c.setErr(err)
+	if err != nil {
+		return err
+	}
+	c.pool.Store(pool)
+	c.healthy.Store(true)
+	return nil
+}
+
+// Run retries Connect while the database is missing and pings it once
+// connected, until ctx is cancelled. Retries back off exponentially from one
+// second up to interval; pings run every interval. Call it after the initial
+// Connect so the first check waits rather than repeating that attempt.
+func (c *Connector) Run(ctx context.Context, interval time.Duration) {
+	backoff := time.Second
+	for {
+		wait := interval
+		if c.pool.Load() == nil {
+			wait = backoff
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+
+		pool := c.pool.Load()
+		if pool == nil {
+			attemptCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+			err := c.Connect(attemptCtx)
+			cancel()
+			if err != nil {
+				backoff = min(backoff*2, interval)
+				log.Printf("Database reconnect failed, retrying in %s: %v", backoff, err)
+				continue
+			}
+			log.Printf("Database connected — leaving degraded mode")
+			continue
+		}
+
+		pingCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		err := pool.Ping(pingCtx)
+		cancel()
+		c.setErr(err)
+		if was := c.healthy.Swap(err == nil); was && err != nil {
+			log.Printf("Database ping failed — entering degraded mode: %v", err)
+		} else if !was && err == nil {
+			log.Printf("Database reachable again — leaving degraded mode")
+		}
+	}
+}
+
+// Healthy reports whether the last connection attempt or ping succeeded.
+func (c *Connector) Healthy() bool {
+	return c.healthy.Load()
+}
+
+// Check returns nil when healthy, otherwise the error from the last failed
+// attempt.
+func (c *Connector) Check() error {
+	if c.Healthy() {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.lastErr != nil {
+		return c.lastErr
+	}
+	return ErrUnavailable
+}
+
+func (c *Connector) Close() {
+	if pool := c.pool.Load(); pool != nil {
+		pool.Close()
+	}
+}
+
+func (c *Connector) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	pool := c.pool.Load()
+	if pool == nil {
+		return errRow{ErrUnavailable}
+	}
+	return pool.QueryRow(ctx, sql, args...)
+}
+
+func (c *Connector) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	pool := c.pool.Load()
+	if pool == nil {
+		return nil, ErrUnavailable
+	}
+	return pool.Query(ctx, sql, args...)
+}
+
+func (c *Connector) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	pool := c.pool.Load()
+	if pool == nil {
+		return pgconn.CommandTag{}, ErrUnavailable
+	}
+	return pool.Exec(ctx, sql, args...)
+}
+
+func (c *Connector) Begin(ctx context.Context) (pgx.Tx, error) {
+	pool := c.pool.Load()
+	if pool == nil {
+		return nil, ErrUnavailable
+	}
+	return pool.Begin(ctx)
+}
+
+func (c *Connector) setErr(err error) {
+	c.mu.Lock()
+	c.lastErr = err
+	c.mu.Unlock()
+}
+
+type errRow struct{ err error }
+
+func (r errRow) Scan(...any) error { return r.err }
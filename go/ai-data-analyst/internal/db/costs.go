@@ -0,0 +1,233 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RollupCosts recomputes the cost_rollups rows for every UTC day from since
+// onwards out of query_history, returning the number of rows written. A
+// zero since rebuilds them all.
+func RollupCosts(ctx context.Context, q Querier, since time.Time) (int64, error) {
+	tag, err := q.Exec(ctx, `
+		INSERT INTO cost_rollups (day, user_id, api_key_id, questions, total_tokens, total_cost_usd, updated_at)
+		SELECT (created_at AT TIME ZONE 'UTC')::date, user_id, api_key_id,
+			COUNT(*), COALESCE(SUM(total_tokens), 0), COALESCE(SUM(total_cost_usd), 0), NOW()
+		FROM query_history
+		WHERE created_at >= $1
+		GROUP BY 1, 2, 3
+		ON CONFLICT (day, user_id, api_key_id) DO UPDATE SET
+			questions = EXCLUDED.questions,
+			total_tokens = EXCLUDED.total_tokens,
+			total_cost_usd = EXCLUDED.total_cost_usd,
+			updated_at = EXCLUDED.updated_at`,
+		since.UTC().Truncate(24*time.Hour))
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+// CostRollupJob keeps cost_rollups current. The first run rebuilds every
+// day; later runs recompute today and yesterday, so history saved around
+// midnight is counted in the right day.
+type CostRollupJob struct {
+	DB       Querier
+	Tracer   trace.Tracer
+	Interval time.Duration
+
+	rolledUp bool
+}
+
+// Run rolls up immediately and then every Interval until ctx is done.
+// Failures, such as the database being down, are logged and retried on the
+// next tick.
+func (j *CostRollupJob) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.Interval)
+	defer ticker.Stop()
+	for {
+		if err := j.RunOnce(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("Cost rollup failed: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce performs one rollup in a "cost rollup" span.
+func (j *CostRollupJob) RunOnce(ctx context.Context) error {
+	ctx, span := j.Tracer.Start(ctx, "cost rollup")
+	defer span.End()
+
+	var since time.Time
+	if j.rolledUp {
+		since = time.Now().UTC().Add(-24 * time.Hour)
+	}
+	span.SetAttributes(attribute.Bool("nlsql.cost_rollup.full", since.IsZero()))
+
+	rows, err := RollupCosts(ctx, j.DB, since)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+	span.SetAttributes(attribute.Int64("nlsql.cost_rollup.rows", rows))
+	j.rolledUp = true
+	return nil
+}
+
+// Cost dimensions accepted by CostQuery.GroupBy. At most one of day, week
+// and month may be used.
+const (
+	CostByDay    = "day"
+	CostByWeek   = "week"
+	CostByMonth  = "month"
+	CostByUser   = "user"
+	CostByAPIKey = "api_key"
+)
+
+var costPeriods = map[string]string{
+	CostByDay:   "to_char(day, 'YYYY-MM-DD')",
+	CostByWeek:  "to_char(date_trunc('week', day), 'YYYY-MM-DD')",
+	CostByMonth: "to_char(date_trunc('month', day), 'YYYY-MM')",
+}
+
+// CostQuery selects and groups cost_rollups rows. Empty filters match
+// everything; From is inclusive and To exclusive.
+type CostQuery struct {
+	GroupBy  []string
+	UserID   string
+	APIKeyID string
+	From, To time.Time
+}
+
+// CostRow is one group of a CostQuery. Only the fields named in GroupBy are
+// set; Period is the first day of the week, or the month, for those.
+type CostRow struct {
+	Period       string  `json:"period,omitempty"`
+	UserID       string  `json:"user_id,omitempty"`
+	APIKeyID     *string `json:"api_key_id,omitempty"`
+	Questions    int     `json:"questions"`
+	TotalTokens  int64   `json:"total_tokens"`
+	TotalCostUSD float64 `json:"total_cost_usd"`
+}
+
+// Validate checks GroupBy for unknown or conflicting dimensions.
+func (cq CostQuery) Validate() error {
+	periods := 0
+	seen := map[string]bool{}
+	for _, g := range cq.GroupBy {
+		switch {
+		case seen[g]:
+			return fmt.Errorf("group_by lists %s twice", g)
+		case costPeriods[g] != "":
+			periods++
+		case g != CostByUser && g != CostByAPIKey:
+			return fmt.Errorf("unknown group_by %q: use day, week, month, user or api_key", g)
+		}
+		seen[g] = true
+	}
+	if periods > 1 {
+		return fmt.Errorf("group_by takes one of day, week and month")
+	}
+	return nil
+}
+
+// Costs aggregates cost_rollups by cq.GroupBy, in period order and then by
+// spend, highest first.
+func Costs(ctx context.Context, q Querier, cq CostQuery) ([]CostRow, error) {
+	if err := cq.Validate(); err != nil {
+		return nil, err
+	}
+
+	var cols, groups []string
+	for _, g := range cq.GroupBy {
+		switch g {
+		case CostByUser:
+			cols = append(cols, "user_id")
+		case CostByAPIKey:
+			cols = append(cols, "api_key_id")
+		default:
+			cols = append(cols, costPeriods[g])
+		}
+		groups = append(groups, fmt.Sprint(len(cols)))
+	}
+
+	var conds []string
+	var args []any
+	add := func(cond string, arg any) {
+		args = append(args, arg)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+	if cq.UserID != "" {
+		add("user_id = $%d", cq.UserID)
+	}
+	if cq.APIKeyID != "" {
+		add("api_key_id = $%d", cq.APIKeyID)
+	}
+	if !cq.From.IsZero() {
+		add("day >= $%d", cq.From)
+	}
+	if !cq.To.IsZero() {
+		add("day < $%d", cq.To)
+	}
+
+	sql := "SELECT " + strings.Join(append(cols,
+		"SUM(questions)::int", "SUM(total_tokens)::bigint", "SUM(total_cost_usd)::float8"), ", ") +
+		" FROM cost_rollups"
+	if len(conds) > 0 {
+		sql += " WHERE " + strings.Join(conds, " AND ")
+	}
+	order := fmt.Sprintf("%d DESC", len(cols)+3)
+	if len(groups) > 0 {
+		sql += " GROUP BY " + strings.Join(groups, ", ")
+		if len(cq.GroupBy) > 0 && costPeriods[cq.GroupBy[0]] != "" {
+			order = "1, " + order
+		}
+	}
+	sql += " ORDER BY " + order
+
+	rows, err := q.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	byKey := slices.Contains(cq.GroupBy, CostByAPIKey)
+	result := []CostRow{}
+	for rows.Next() {
+		var c CostRow
+		var apiKeyID string
+		dest := make([]any, 0, len(cq.GroupBy)+3)
+		for _, g := range cq.GroupBy {
+			switch g {
+			case CostByUser:
+				dest = append(dest, &c.UserID)
+			case CostByAPIKey:
+				dest = append(dest, &apiKeyID)
+			default:
+				dest = append(dest, &c.Period)
+			}
+		}
+		dest = append(dest, &c.Questions, &c.TotalTokens, &c.TotalCostUSD)
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		if byKey {
+			// Requests without a key have an empty ID; keep it in the output.
+			c.APIKeyID = &apiKeyID
+		}
+		result = append(result, c)
+	}
+	return result, rows.Err()
+}
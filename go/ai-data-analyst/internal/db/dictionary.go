@@ -0,0 +1,161 @@
+package db
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+type CountryCoverage struct {
+	CountryCode string `json:"country_code"`
+	FirstYear   int    `json:"first_year"`
+	LastYear    int    `json:"last_year"`
+	Years       int    `json:"years"`
+}
+
+type DictionaryEntry struct {
+	Indicator
+	Coverage []CountryCoverage `json:"coverage"`
+}
+
+// BuildDictionary joins indicator metadata with per-country year coverage
+// computed from indicator_values.
+func BuildDictionary(ctx context.Context, q Querier) ([]DictionaryEntry, error) {
+	indicators, err := ListIndicators(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := q.Query(ctx, `
+		SELECT i.code, c.code, MIN(iv.year), MAX(iv.year), COUNT(DISTINCT iv.year)
+		FROM indicator_values iv
+		JOIN indicators i ON i.id = iv.indicator_id
+		JOIN countries c ON c.id = iv.country_id
+		WHERE iv.value IS NOT NULL
+		GROUP BY i.code, c.code
+		ORDER BY i.code, c.code`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	coverage := map[string][]CountryCoverage{}
+	for rows.Next() {
+		var code string
+		var cc CountryCoverage
+		if err := rows.Scan(&code, &cc.CountryCode, &cc.FirstYear, &cc.LastYear, &cc.Years); err != nil {
+			return nil, err
+		}
+		coverage[code] = append(coverage[code], cc)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	entries := make([]DictionaryEntry, len(indicators))
+	for i, ind := range indicators {
+		entries[i] = DictionaryEntry{Indicator: ind, Coverage: coverage[ind.Code]}
+	}
+	return entries, nil
+}
+
+// DictionaryCache holds the data dictionary for TTL. The dictionary only
+// changes when the dataset is reloaded, so a stale read is harmless: one
+// caller rebuilds it without holding the lock while the others keep getting
+// the previous dictionary, or wait if there is none yet.
+type DictionaryCache struct {
+	q   Querier
+	ttl time.Duration
+
+	mu       sync.Mutex
+	entries  []DictionaryEntry
+	loadedAt time.Time
+	// loading is closed when the running rebuild finishes; nil when none is.
+	loading chan struct{}
+	// generation counts invalidations, so a rebuild that started before one
+	// is not taken as fresh.
+	generation int
+}
+
+func NewDictionaryCache(q Querier, ttl time.Duration) *DictionaryCache {
+	return &DictionaryCache{q: q, ttl: ttl}
+}
+
+func (c *DictionaryCache) Get(ctx context.Context) ([]DictionaryEntry, error) {
+	c.mu.Lock()
+	for {
+		if c.entries != nil && (c.loading != nil || time.Since(c.loadedAt) < c.ttl) {
+			entries := c.entries
+			c.mu.Unlock()
+			return entries, nil
+		}
+		if c.loading == nil {
+			break
+		}
+		loading := c.loading
+		c.mu.Unlock()
+		select {
+		case <-loading:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		c.mu.Lock()
+	}
+	done := make(chan struct{})
+	c.loading = done
+	generation := c.generation
+	c.mu.Unlock()
+
+	entries, err := BuildDictionary(ctx, c.q)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.loading = nil
+	close(done)
+	if err != nil {
+		if c.entries != nil {
+			log.Printf("Data dictionary refresh failed, serving the previous one: %v", err)
+			return c.entries, nil
+		}
+		return nil, err
+	}
+	c.entries = entries
+	if generation == c.generation {
+		c.loadedAt = time.Now()
+	}
+	return entries, nil
+}
+
+// Invalidate makes the next Get reload the dictionary. The old one is kept
+// as the fallback should that fail.
+func (c *DictionaryCache) Invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.loadedAt = time.Time{}
+	c.generation++
+}
+
+// Units maps indicator code to "name (unit)" for the requested codes. Codes
+// missing from the dictionary are skipped.
+func (c *DictionaryCache) Units(ctx context.Context, codes []string) map[string]string {
+	if c == nil || len(codes) == 0 {
+		return nil
+	}
+	entries, err := c.Get(ctx)
+	if err != nil {
+		return nil
+	}
+
+	wanted := make(map[string]bool, len(codes))
+	for _, code := range codes {
+		wanted[code] = true
+	}
+	units := map[string]string{}
+	for _, e := range entries {
+		if wanted[e.Code] {
+			units[e.Code] = e.Name + " (" + e.Unit + ")"
+		}
+	}
+	return units
+}
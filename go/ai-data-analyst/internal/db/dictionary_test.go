@@ -0,0 +1,77 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// blockingQuerier fails every query, but only once release is closed.
+type blockingQuerier struct {
+	release chan struct{}
+	queries atomic.Int32
+}
+
+func (q *blockingQuerier) Query(ctx context.Context, _ string, _ ...any) (pgx.Rows, error) {
+	q.queries.Add(1)
+	<-q.release
+	return nil, errors.New("database unavailable")
+}
+
+func (q *blockingQuerier) QueryRow(context.Context, string, ...any) pgx.Row { return nil }
+
+func (q *blockingQuerier) Exec(context.Context, string, ...any) (pgconn.CommandTag, error) {
+	return pgconn.CommandTag{}, nil
+}
+
+func TestDictionaryCacheServesStaleWhileRefreshing(t *testing.T) {
+	q := &blockingQuerier{release: make(chan struct{})}
+	c := NewDictionaryCache(q, time.Minute)
+	stale := []DictionaryEntry{{Indicator: Indicator{Code: "SP.POP.TOTL"}}}
+	c.entries = stale
+
+	refreshed := make(chan []DictionaryEntry)
+	go func() {
+		entries, _ := c.Get(context.Background())
+		refreshed <- entries
+	}()
+	require.Eventually(t, func() bool { return q.queries.Load() == 1 }, time.Second, time.Millisecond)
+
+	// The refresh is stuck on the database; other callers are not.
+	for range 3 {
+		entries, err := c.Get(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, stale, entries)
+	}
+	assert.Equal(t, int32(1), q.queries.Load())
+
+	close(q.release)
+	assert.Equal(t, stale, <-refreshed)
+}
+
+func TestDictionaryCacheWaitsForFirstLoad(t *testing.T) {
+	q := &blockingQuerier{release: make(chan struct{})}
+	c := NewDictionaryCache(q, time.Minute)
+
+	first := make(chan error)
+	go func() {
+		_, err := c.Get(context.Background())
+		first <- err
+	}()
+	require.Eventually(t, func() bool { return q.queries.Load() == 1 }, time.Second, time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := c.Get(ctx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	close(q.release)
+	require.Error(t, <-first)
+}
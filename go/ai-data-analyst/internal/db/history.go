@@ -2,11 +2,22 @@ package db
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
 	"time"
+
+	"github.com/jackc/pgx/v5"
 )
 
+// ErrHistoryNotFound is returned when a history entry does not exist or
+// belongs to another user.
+var ErrHistoryNotFound = errors.New("history entry not found")
+
 type QueryHistory struct {
 	ID           string    `json:"id"`
+	UserID       string    `json:"user_id"`
 	Question     string    `json:"question"`
 	QuestionType string    `json:"question_type"`
 	GeneratedSQL string    `json:"generated_sql"`
@@ -17,10 +28,19 @@ type QueryHistory struct {
 	TotalCostUSD float64   `json:"total_cost_usd"`
 	Explanation  string    `json:"explanation"`
 	TraceID      string    `json:"trace_id"`
+	ResultHash   string    `json:"result_hash,omitempty"`
+	DataSource   string    `json:"data_source"`
 	CreatedAt    time.Time `json:"created_at"`
+
+	// SlowQueryPlan is the EXPLAIN ANALYZE output captured when the query
+	// ran past SLOW_QUERY_THRESHOLD.
+	SlowQueryPlan json.RawMessage `json:"slow_query_plan,omitempty"`
 }
 
 type InsertHistoryParams struct {
+	UserID       string
+	APIKeyID     string
+	TenantID     string
 	Question     string
 	QuestionType string
 	GeneratedSQL string
@@ -31,47 +51,146 @@ type InsertHistoryParams struct {
 	TotalCostUSD float64
 	Explanation  string
 	TraceID      string
+	ResultHash   string
+	DataSource   string
+
+	// SlowQueryPlan is stored only when set.
+	SlowQueryPlan json.RawMessage
 }
 
-func InsertQueryHistory(ctx context.Context, q Querier, p InsertHistoryParams) (string, error) {
+// HistoryRepo reads and writes query_history.
+type HistoryRepo struct {
+	q Querier
+}
+
+func NewHistoryRepo(q Querier) *HistoryRepo {
+	return &HistoryRepo{q: q}
+}
+
+const insertQueryHistory = `-- name: InsertQueryHistory :one
+INSERT INTO query_history (user_id, question, question_type, generated_sql, confidence, row_count,
+	execution_ms, total_tokens, total_cost_usd, explanation, trace_id, result_hash, slow_query_plan,
+	api_key_id, tenant_id, data_source)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, NULLIF($12, ''), NULLIF($13, '')::jsonb, $14, $15,
+	COALESCE(NULLIF($16, ''), 'worldbank'))
+RETURNING id`
+
+// Insert stores an answered question and returns its ID.
+func (r *HistoryRepo) Insert(ctx context.Context, p InsertHistoryParams) (string, error) {
 	var id string
-	err := q.QueryRow(ctx, `
-		INSERT INTO query_history (question, question_type, generated_sql, confidence, row_count,
-			execution_ms, total_tokens, total_cost_usd, explanation, trace_id)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
-		RETURNING id`,
-		p.Question, p.QuestionType, p.GeneratedSQL, p.Confidence, p.RowCount,
-		p.ExecutionMS, p.TotalTokens, p.TotalCostUSD, p.Explanation, p.TraceID,
+	err := r.q.QueryRow(ctx, insertQueryHistory,
+		p.UserID, p.Question, p.QuestionType, p.GeneratedSQL, p.Confidence, p.RowCount,
+		p.ExecutionMS, p.TotalTokens, p.TotalCostUSD, p.Explanation, p.TraceID, p.ResultHash,
+		string(p.SlowQueryPlan), p.APIKeyID, p.TenantID, p.DataSource,
 	).Scan(&id)
 	return id, err
 }
 
-func ListHistory(ctx context.Context, q Querier, limit, offset int) ([]QueryHistory, error) {
-	if limit <= 0 {
-		limit = 20
+// HistoryFilter narrows HistoryRepo.List. Zero fields do not filter.
+type HistoryFilter struct {
+	QuestionType  string
+	MinConfidence float64
+	// From is inclusive and To exclusive.
+	From, To time.Time
+	Limit    int
+	Offset   int
+}
+
+// where returns the WHERE clause and its arguments for the caller's entries
+// that match f.
+func (f HistoryFilter) where(userID string) (string, []any) {
+	conds := []string{"user_id = $1"}
+	args := []any{userID}
+	add := func(cond string, arg any) {
+		args = append(args, arg)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+	if f.QuestionType != "" {
+		add("question_type = $%d", f.QuestionType)
+	}
+	if f.MinConfidence > 0 {
+		add("confidence >= $%d", f.MinConfidence)
+	}
+	if !f.From.IsZero() {
+		add("created_at >= $%d", f.From)
+	}
+	if !f.To.IsZero() {
+		add("created_at < $%d", f.To)
+	}
+	return " WHERE " + strings.Join(conds, " AND "), args
+}
+
+// The filters of List are appended to these as a WHERE clause.
+const (
+	countQueryHistory = `-- name: CountQueryHistory :one
+SELECT count(*) FROM query_history`
+
+	listQueryHistory = `-- name: ListQueryHistory :many
+SELECT` + historyColumns + `
+FROM query_history`
+)
+
+// List returns a page of the caller's history that matches f, newest first,
+// and the number of matching entries across all pages.
+func (r *HistoryRepo) List(ctx context.Context, userID string, f HistoryFilter) ([]QueryHistory, int, error) {
+	if f.Limit <= 0 {
+		f.Limit = 20
 	}
-	rows, err := q.Query(ctx, `
-		SELECT id, question, COALESCE(question_type, ''), generated_sql,
-			COALESCE(confidence, 0), COALESCE(row_count, 0), COALESCE(execution_ms, 0),
-			COALESCE(total_tokens, 0), COALESCE(total_cost_usd, 0),
-			COALESCE(explanation, ''), COALESCE(trace_id, ''), created_at
-		FROM query_history
-		ORDER BY created_at DESC
-		LIMIT $1 OFFSET $2`, limit, offset)
+	where, args := f.where(userID)
+
+	var total int
+	if err := r.q.QueryRow(ctx, countQueryHistory+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	n := len(args)
+	rows, err := r.q.Query(ctx, listQueryHistory+where+fmt.Sprintf(`
+ORDER BY created_at DESC
+LIMIT $%d OFFSET $%d`, n+1, n+2), append(args, f.Limit, f.Offset)...)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 	defer rows.Close()
 
 	var history []QueryHistory
 	for rows.Next() {
-		var h QueryHistory
-		if err := rows.Scan(&h.ID, &h.Question, &h.QuestionType, &h.GeneratedSQL,
-			&h.Confidence, &h.RowCount, &h.ExecutionMS, &h.TotalTokens,
-			&h.TotalCostUSD, &h.Explanation, &h.TraceID, &h.CreatedAt); err != nil {
-			return nil, err
+		h, err := scanHistory(rows)
+		if err != nil {
+			return nil, 0, err
 		}
-		history = append(history, h)
+		history = append(history, *h)
+	}
+	return history, total, rows.Err()
+}
+
+const getQueryHistory = `-- name: GetQueryHistory :one
+SELECT` + historyColumns + `
+FROM query_history
+WHERE user_id = $1 AND id::text = $2`
+
+// Get returns one of the caller's history entries.
+func (r *HistoryRepo) Get(ctx context.Context, userID, id string) (*QueryHistory, error) {
+	h, err := scanHistory(r.q.QueryRow(ctx, getQueryHistory, userID, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrHistoryNotFound
+	}
+	return h, err
+}
+
+const historyColumns = `
+	id, user_id, question, COALESCE(question_type, ''), generated_sql,
+	COALESCE(confidence, 0), COALESCE(row_count, 0), COALESCE(execution_ms, 0),
+	COALESCE(total_tokens, 0), COALESCE(total_cost_usd, 0),
+	COALESCE(explanation, ''), COALESCE(trace_id, ''), COALESCE(result_hash, ''), created_at,
+	slow_query_plan, data_source`
+
+func scanHistory(row pgx.Row) (*QueryHistory, error) {
+	var h QueryHistory
+	if err := row.Scan(&h.ID, &h.UserID, &h.Question, &h.QuestionType, &h.GeneratedSQL,
+		&h.Confidence, &h.RowCount, &h.ExecutionMS, &h.TotalTokens,
+		&h.TotalCostUSD, &h.Explanation, &h.TraceID, &h.ResultHash, &h.CreatedAt,
+		&h.SlowQueryPlan, &h.DataSource); err != nil {
+		return nil, err
 	}
-	return history, rows.Err()
+	return &h, nil
 }
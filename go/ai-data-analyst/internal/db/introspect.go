@@ -0,0 +1,174 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DatasetTables are the tables generated SQL may query. Introspection is
+// limited to them so app state such as query_history never reaches the
+// Generate prompt.
+var DatasetTables = []string{"countries", "indicators", "indicator_values"}
+
+type SchemaColumn struct {
+	Name       string `json:"name"`
+	Type       string `json:"type"`
+	Nullable   bool   `json:"nullable"`
+	PrimaryKey bool   `json:"primary_key,omitempty"`
+	References string `json:"references,omitempty"`
+}
+
+type SchemaTable struct {
+	Name        string         `json:"name"`
+	RowEstimate int64          `json:"row_estimate"`
+	Columns     []SchemaColumn `json:"columns"`
+}
+
+// IntrospectSchema reads the columns of tables in the public schema from
+// information_schema, with their primary and foreign keys. Row counts are
+// the planner's estimates from pg_class, 0 for a table never analyzed.
+// Tables missing from the database are left out.
+func IntrospectSchema(ctx context.Context, q Querier, tables []string) ([]SchemaTable, error) {
+	rows, err := q.Query(ctx, `
+		SELECT c.table_name, c.column_name, c.data_type, c.is_nullable = 'YES',
+			GREATEST(COALESCE(cl.reltuples, 0), 0)::bigint
+		FROM information_schema.columns c
+		LEFT JOIN pg_class cl ON cl.relname = c.table_name AND cl.relnamespace = 'public'::regnamespace
+		WHERE c.table_schema = 'public' AND c.table_name::text = ANY($1::text[])
+		ORDER BY array_position($1::text[], c.table_name::text), c.ordinal_position`, tables)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []SchemaTable
+	index := map[string]int{}
+	for rows.Next() {
+		var table string
+		var rowEstimate int64
+		var col SchemaColumn
+		if err := rows.Scan(&table, &col.Name, &col.Type, &col.Nullable, &rowEstimate); err != nil {
+			return nil, err
+		}
+		i, ok := index[table]
+		if !ok {
+			i = len(out)
+			index[table] = i
+			out = append(out, SchemaTable{Name: table, RowEstimate: rowEstimate})
+		}
+		out[i].Columns = append(out[i].Columns, col)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	keys, err := q.Query(ctx, `
+		SELECT kcu.table_name, kcu.column_name, tc.constraint_type, COALESCE(ccu.table_name, '')
+		FROM information_schema.table_constraints tc
+		JOIN information_schema.key_column_usage kcu
+			ON kcu.constraint_schema = tc.constraint_schema AND kcu.constraint_name = tc.constraint_name
+		LEFT JOIN information_schema.constraint_column_usage ccu
+			ON tc.constraint_type = 'FOREIGN KEY'
+			AND ccu.constraint_schema = tc.constraint_schema AND ccu.constraint_name = tc.constraint_name
+		WHERE tc.table_schema = 'public' AND tc.constraint_type IN ('PRIMARY KEY', 'FOREIGN KEY')
+			AND kcu.table_name::text = ANY($1::text[])`, tables)
+	if err != nil {
+		return nil, err
+	}
+	defer keys.Close()
+
+	for keys.Next() {
+		var table, column, kind, references string
+		if err := keys.Scan(&table, &column, &kind, &references); err != nil {
+			return nil, err
+		}
+		i, ok := index[table]
+		if !ok {
+			continue
+		}
+		for j := range out[i].Columns {
+			col := &out[i].Columns[j]
+			if col.Name != column {
+				continue
+			}
+			if kind == "PRIMARY KEY" {
+				col.PrimaryKey = true
+			} else {
+				col.References = references
+			}
+		}
+	}
+	return out, keys.Err()
+}
+
+// String describes the table on one line for a prompt, e.g.
+// "countries (~217 rows): id integer PK, code character varying, ...".
+func (t SchemaTable) String() string {
+	cols := make([]string, len(t.Columns))
+	for i, c := range t.Columns {
+		var sb strings.Builder
+		sb.WriteString(c.Name + " " + c.Type)
+		if c.PrimaryKey {
+			sb.WriteString(" PK")
+		}
+		if c.References != "" {
+			sb.WriteString(" FK→" + c.References)
+		}
+		if !c.Nullable && !c.PrimaryKey {
+			sb.WriteString(" NOT NULL")
+		}
+		cols[i] = sb.String()
+	}
+	return fmt.Sprintf("%s (~%d rows): %s", t.Name, t.RowEstimate, strings.Join(cols, ", "))
+}
+
+// SchemaCache holds the introspected dataset schema for TTL, so a column
+// added or renamed while the server runs reaches the prompt without a
+// restart. Like DictionaryCache it keeps serving the last schema while the
+// database cannot be read.
+type SchemaCache struct {
+	q      Querier
+	ttl    time.Duration
+	tables []string
+
+	mu       sync.Mutex
+	schema   []SchemaTable
+	loadedAt time.Time
+}
+
+func NewSchemaCache(q Querier, ttl time.Duration, tables []string) *SchemaCache {
+	return &SchemaCache{q: q, ttl: ttl, tables: tables}
+}
+
+func (c *SchemaCache) Get(ctx context.Context) ([]SchemaTable, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.schema != nil && time.Since(c.loadedAt) < c.ttl {
+		return c.schema, nil
+	}
+
+	schema, err := IntrospectSchema(ctx, c.q, c.tables)
+	if err == nil && len(schema) == 0 {
+		err = fmt.Errorf("none of the tables %s exist", strings.Join(c.tables, ", "))
+	}
+	if err != nil {
+		if c.schema != nil {
+			return c.schema, nil
+		}
+		return nil, err
+	}
+	c.schema = schema
+	c.loadedAt = time.Now()
+	return schema, nil
+}
+
+// Invalidate makes the next Get introspect the schema again.
+func (c *SchemaCache) Invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.loadedAt = time.Time{}
+}
@@ -0,0 +1,192 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Migration is one step of the app's own schema: the tables it reads and
+// writes, as opposed to the World Bank dataset, which db/schema.sql and
+// db/seed.sql load. A released migration is never edited; a change to the
+// schema is a new migration.
+type Migration struct {
+	Version int
+	Name    string
+	SQL     string
+}
+
+// Migrations are applied in order. Version 1 matches what db/schema.sql used
+// to create, with IF NOT EXISTS throughout so databases set up that way
+// adopt it unchanged.
+var Migrations = []Migration{
+	{Version: 1, Name: "baseline", SQL: `
+CREATE TABLE IF NOT EXISTS query_history (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  user_id VARCHAR(100) NOT NULL DEFAULT 'anonymous',
+  question TEXT NOT NULL,
+  question_type VARCHAR(50),
+  generated_sql TEXT NOT NULL,
+  confidence NUMERIC(3, 2),
+  row_count INTEGER,
+  execution_ms INTEGER,
+  total_tokens INTEGER,
+  total_cost_usd NUMERIC(10, 6),
+  explanation TEXT,
+  trace_id VARCHAR(32),
+  created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+ALTER TABLE query_history ADD COLUMN IF NOT EXISTS user_id VARCHAR(100) NOT NULL DEFAULT 'anonymous';
+ALTER TABLE query_history ADD COLUMN IF NOT EXISTS result_hash VARCHAR(64);
+ALTER TABLE query_history ADD COLUMN IF NOT EXISTS slow_query_plan JSONB;
+ALTER TABLE query_history ADD COLUMN IF NOT EXISTS api_key_id VARCHAR(16) NOT NULL DEFAULT '';
+ALTER TABLE query_history ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(100) NOT NULL DEFAULT 'default';
+
+CREATE INDEX IF NOT EXISTS idx_history_created ON query_history(created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_history_user_created ON query_history(user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_history_tenant_created ON query_history(tenant_id, created_at DESC);
+
+-- Daily LLM spend per user and API key, aggregated from query_history by the
+-- cost rollup job. Rows are recomputed, not incremented, so reruns are safe.
+CREATE TABLE IF NOT EXISTS cost_rollups (
+  day DATE NOT NULL,
+  user_id VARCHAR(100) NOT NULL,
+  api_key_id VARCHAR(16) NOT NULL DEFAULT '',
+  questions INTEGER NOT NULL,
+  total_tokens BIGINT NOT NULL,
+  total_cost_usd NUMERIC(12, 6) NOT NULL,
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  PRIMARY KEY (day, user_id, api_key_id)
+);
+
+CREATE TABLE IF NOT EXISTS conversation_sessions (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  user_id VARCHAR(100) NOT NULL DEFAULT 'anonymous',
+  title TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMPTZ DEFAULT NOW(),
+  updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+ALTER TABLE conversation_sessions ADD COLUMN IF NOT EXISTS sandbox BOOLEAN NOT NULL DEFAULT false;
+
+CREATE INDEX IF NOT EXISTS idx_sessions_user_updated ON conversation_sessions(user_id, updated_at DESC);
+
+CREATE TABLE IF NOT EXISTS session_turns (
+  id BIGSERIAL PRIMARY KEY,
+  session_id UUID NOT NULL REFERENCES conversation_sessions(id) ON DELETE CASCADE,
+  question TEXT NOT NULL,
+  generated_sql TEXT NOT NULL,
+  columns JSONB NOT NULL DEFAULT '[]',
+  preview_rows JSONB NOT NULL DEFAULT '[]',
+  row_count INTEGER NOT NULL DEFAULT 0,
+  summary TEXT,
+  trace_id VARCHAR(32),
+  created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_session_turns_session ON session_turns(session_id, id DESC);
+
+-- Embedded schema documentation for retrieval in the Generate stage. The
+-- vector column has no fixed dimension so the embedding model can change;
+-- the table holds a few hundred rows, so an exact scan needs no ANN index.
+CREATE EXTENSION IF NOT EXISTS vector;
+
+CREATE TABLE IF NOT EXISTS schema_fragments (
+  key VARCHAR(200) PRIMARY KEY,
+  kind VARCHAR(20) NOT NULL,
+  content TEXT NOT NULL,
+  requires TEXT[] NOT NULL DEFAULT '{}',
+  content_hash VARCHAR(64) NOT NULL,
+  model VARCHAR(100) NOT NULL,
+  embedding vector NOT NULL,
+  updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+-- Versioned system prompts for the Generate and Explain stages, on top of the
+-- built-in ones and any in PROMPTS_DIR, and the version of each in use.
+CREATE TABLE IF NOT EXISTS prompt_templates (
+  name VARCHAR(50) NOT NULL,
+  version VARCHAR(50) NOT NULL,
+  template TEXT NOT NULL,
+  created_at TIMESTAMPTZ DEFAULT NOW(),
+  PRIMARY KEY (name, version)
+);
+
+CREATE TABLE IF NOT EXISTS prompt_activations (
+  name VARCHAR(50) PRIMARY KEY,
+  version VARCHAR(50) NOT NULL,
+  activated_by VARCHAR(200) NOT NULL,
+  activated_at TIMESTAMPTZ DEFAULT NOW()
+);
+`},
+	{Version: 2, Name: "budget_spend", SQL: `
+-- LLM spend against the budget limits: scope 'daily' is keyed by UTC date,
+-- scope 'session' by session ID.
+CREATE TABLE budget_spend (
+  scope VARCHAR(10) NOT NULL,
+  scope_key VARCHAR(100) NOT NULL,
+  spent_usd NUMERIC(12, 6) NOT NULL DEFAULT 0,
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  PRIMARY KEY (scope, scope_key)
+);
+`},
+	{Version: 3, Name: "history_data_source", SQL: `
+-- The data source each question was answered from, so replay and export
+-- run its SQL against the same database.
+ALTER TABLE query_history ADD COLUMN data_source VARCHAR(50) NOT NULL DEFAULT 'worldbank';
+`},
+}
+
+// migrationLockID is the advisory lock held while migrating, so replicas
+// starting together apply each migration once.
+const migrationLockID = 7_226_851_930
+
+// Beginner starts a transaction. *pgxpool.Pool and *pgx.Conn implement it.
+type Beginner interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// Migrate applies the Migrations newer than the last one recorded in
+// schema_migrations, in a single transaction, and returns how many it
+// applied.
+func Migrate(ctx context.Context, b Beginner) (int, error) {
+	applied := 0
+	err := pgx.BeginFunc(ctx, b, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock($1)`, migrationLockID); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, `
+			CREATE TABLE IF NOT EXISTS schema_migrations (
+				version INTEGER PRIMARY KEY,
+				name VARCHAR(100) NOT NULL,
+				applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+			)`); err != nil {
+			return err
+		}
+
+		var current int
+		if err := tx.QueryRow(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&current); err != nil {
+			return err
+		}
+		for _, m := range Migrations {
+			if m.Version <= current {
+				continue
+			}
+			if _, err := tx.Exec(ctx, m.SQL); err != nil {
+				return fmt.Errorf("migration %d (%s): %w", m.Version, m.Name, err)
+			}
+			if _, err := tx.Exec(ctx, `INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`,
+				m.Version, m.Name); err != nil {
+				return err
+			}
+			applied++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return applied, nil
+}
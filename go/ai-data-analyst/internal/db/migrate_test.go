@@ -0,0 +1,23 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMigrationVersionsAscend(t *testing.T) {
+	for i, m := range Migrations {
+		assert.Equal(t, i+1, m.Version, "migration %s", m.Name)
+		assert.NotEmpty(t, m.Name)
+		assert.NotEmpty(t, m.SQL)
+	}
+}
+
+func TestQueryName(t *testing.T) {
+	assert.Equal(t, "GetSession", queryName(getSession))
+	assert.Equal(t, "InsertQueryHistory", queryName(insertQueryHistory))
+	assert.Equal(t, "CountQueryHistory", queryName(countQueryHistory+" WHERE user_id = $1"))
+	assert.Empty(t, queryName("SELECT 1"))
+	assert.Empty(t, queryName("-- a comment\nSELECT 1"))
+}
@@ -22,14 +22,46 @@ func NewPool(ctx context.Context, databaseURL string) (*pgxpool.Pool, error) {
 		return nil, err
 	}
 
+	config.ConnConfig.Tracer = newQueryTracer(config.ConnConfig)
+
+	pool, err := pgxpool.NewWithConfig(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
+		return nil, err
+	}
+
+	return pool, nil
+}
+
+// Dial opens a single connection outside the pool, traced like pooled ones,
+// for callers that keep session state such as temporary tables.
+func Dial(ctx context.Context, databaseURL string) (*pgx.Conn, error) {
+	config, err := pgx.ParseConfig(databaseURL)
+	if err != nil {
+		return nil, err
+	}
+	config.Tracer = newQueryTracer(config)
+	return pgx.ConnectConfig(ctx, config)
+}
+
+// newQueryTracer names query spans after the database and the statement's
+// name, for the repositories' named statements, or else its SQL verb.
+func newQueryTracer(config *pgx.ConnConfig) *otelpgx.Tracer {
 	dbName := "data_analyst"
-	if config.ConnConfig.Database != "" {
-		dbName = config.ConnConfig.Database
+	if config.Database != "" {
+		dbName = config.Database
 	}
-	config.ConnConfig.Tracer = otelpgx.NewTracer(
+	return otelpgx.NewTracer(
 		otelpgx.WithTrimSQLInSpanName(),
 		otelpgx.WithDisableQuerySpanNamePrefix(),
 		otelpgx.WithSpanNameFunc(func(stmt string) string {
+			if name := queryName(stmt); name != "" {
+				return dbName + " " + name
+			}
 			fields := strings.Fields(stmt)
 			if len(fields) == 0 {
 				return dbName
@@ -37,16 +69,19 @@ func NewPool(ctx context.Context, databaseURL string) (*pgxpool.Pool, error) {
 			return dbName + " " + strings.ToUpper(fields[0])
 		}),
 	)
+}
 
-	pool, err := pgxpool.NewWithConfig(ctx, config)
-	if err != nil {
-		return nil, err
+// queryName returns the name of a statement that starts with a sqlc-style
+// "-- name: GetSession :one" comment, or "" for any other.
+func queryName(stmt string) string {
+	rest, ok := strings.CutPrefix(strings.TrimSpace(stmt), "-- name:")
+	if !ok {
+		return ""
 	}
-
-	if err := pool.Ping(ctx); err != nil {
-		pool.Close()
-		return nil, err
+	line, _, _ := strings.Cut(rest, "\n")
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return ""
 	}
-
-	return pool, nil
+	return fields[0]
 }
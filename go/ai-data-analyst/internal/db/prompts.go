@@ -0,0 +1,75 @@
+package db
+
+import (
+	"context"
+	"time"
+)
+
+// PromptTemplate is a stored version of a system prompt.
+type PromptTemplate struct {
+	Name      string    `json:"name"`
+	Version   string    `json:"version"`
+	Template  string    `json:"template"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// PromptActivation records which version of a prompt is active.
+type PromptActivation struct {
+	Name        string    `json:"name"`
+	Version     string    `json:"version"`
+	ActivatedBy string    `json:"activated_by"`
+	ActivatedAt time.Time `json:"activated_at"`
+}
+
+func ListPromptTemplates(ctx context.Context, q Querier) ([]PromptTemplate, error) {
+	rows, err := q.Query(ctx, `
+		SELECT name, version, template, created_at
+		FROM prompt_templates
+		ORDER BY name, created_at`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []PromptTemplate
+	for rows.Next() {
+		var t PromptTemplate
+		if err := rows.Scan(&t.Name, &t.Version, &t.Template, &t.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, t)
+	}
+	return out, rows.Err()
+}
+
+func ListPromptActivations(ctx context.Context, q Querier) ([]PromptActivation, error) {
+	rows, err := q.Query(ctx, `SELECT name, version, activated_by, activated_at FROM prompt_activations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []PromptActivation
+	for rows.Next() {
+		var a PromptActivation
+		if err := rows.Scan(&a.Name, &a.Version, &a.ActivatedBy, &a.ActivatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, a)
+	}
+	return out, rows.Err()
+}
+
+// SetPromptActivation makes version the active one for the prompt name.
+func SetPromptActivation(ctx context.Context, q Querier, name, version, user string) error {
+	_, err := q.Exec(ctx, `
+		INSERT INTO prompt_activations (name, version, activated_by, activated_at)
+		VALUES ($1, $2, $3, NOW())
+		ON CONFLICT (name) DO UPDATE SET
+			version = EXCLUDED.version,
+			activated_by = EXCLUDED.activated_by,
+			activated_at = EXCLUDED.activated_at`,
+		name, version, user,
+	)
+	return err
+}
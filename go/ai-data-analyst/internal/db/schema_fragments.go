@@ -0,0 +1,119 @@
+package db
+
+import (
+	"context"
+	"strconv"
+	"strings"
+)
+
+// SchemaFragment is a piece of schema documentation that can be embedded and
+// retrieved on its own: a table with its columns, an indicator, or a list of
+// reference values. Requires names fragments that must accompany it in a
+// prompt, such as the tables an indicator is queried through.
+type SchemaFragment struct {
+	Key      string   `json:"key"`
+	Kind     string   `json:"kind"`
+	Content  string   `json:"content"`
+	Requires []string `json:"requires,omitempty"`
+}
+
+// ScoredSchemaFragment is a retrieved fragment with its cosine distance to
+// the query embedding; smaller is closer.
+type ScoredSchemaFragment struct {
+	SchemaFragment
+	Distance float64 `json:"distance"`
+}
+
+// SchemaFragmentHashes returns the content hash of every stored fragment,
+// keyed by fragment key.
+func SchemaFragmentHashes(ctx context.Context, q Querier) (map[string]string, error) {
+	rows, err := q.Query(ctx, `SELECT key, content_hash FROM schema_fragments`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hashes := map[string]string{}
+	for rows.Next() {
+		var key, hash string
+		if err := rows.Scan(&key, &hash); err != nil {
+			return nil, err
+		}
+		hashes[key] = hash
+	}
+	return hashes, rows.Err()
+}
+
+// UpsertSchemaFragment stores a fragment with its embedding. hash should
+// cover the content and the embedding model, so a model change re-embeds.
+func UpsertSchemaFragment(ctx context.Context, q Querier, f SchemaFragment, hash, model string, embedding []float32) error {
+	requires := f.Requires
+	if requires == nil {
+		requires = []string{}
+	}
+	_, err := q.Exec(ctx, `
+		INSERT INTO schema_fragments (key, kind, content, requires, content_hash, model, embedding)
+		VALUES ($1, $2, $3, $4, $5, $6, $7::vector)
+		ON CONFLICT (key) DO UPDATE SET
+			kind = EXCLUDED.kind,
+			content = EXCLUDED.content,
+			requires = EXCLUDED.requires,
+			content_hash = EXCLUDED.content_hash,
+			model = EXCLUDED.model,
+			embedding = EXCLUDED.embedding,
+			updated_at = NOW()`,
+		f.Key, f.Kind, f.Content, requires, hash, model, VectorLiteral(embedding),
+	)
+	return err
+}
+
+// DeleteSchemaFragmentsExcept removes fragments whose key is not in keys,
+// such as indicators dropped from the dataset.
+func DeleteSchemaFragmentsExcept(ctx context.Context, q Querier, keys []string) (int64, error) {
+	tag, err := q.Exec(ctx, `DELETE FROM schema_fragments WHERE NOT (key = ANY($1))`, keys)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+// SearchSchemaFragments returns the limit fragments embedded with model that
+// are closest to embedding.
+func SearchSchemaFragments(ctx context.Context, q Querier, model string, embedding []float32, limit int) ([]ScoredSchemaFragment, error) {
+	rows, err := q.Query(ctx, `
+		SELECT key, kind, content, requires, embedding <=> $2::vector AS distance
+		FROM schema_fragments
+		WHERE model = $1
+		ORDER BY distance
+		LIMIT $3`, model, VectorLiteral(embedding), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	fragments := []ScoredSchemaFragment{}
+	for rows.Next() {
+		var f ScoredSchemaFragment
+		if err := rows.Scan(&f.Key, &f.Kind, &f.Content, &f.Requires, &f.Distance); err != nil {
+			return nil, err
+		}
+		fragments = append(fragments, f)
+	}
+	return fragments, rows.Err()
+}
+
+// VectorLiteral formats v in pgvector's text form, '[1,2,3]', so vectors can
+// be passed as text parameters and cast with ::vector.
+func VectorLiteral(v []float32) string {
+	var sb strings.Builder
+	sb.Grow(len(v)*10 + 2)
+	sb.WriteByte('[')
+	for i, x := range v {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(strconv.FormatFloat(float64(x), 'g', -1, 32))
+	}
+	sb.WriteByte(']')
+	return sb.String()
+}
@@ -0,0 +1,224 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrSessionNotFound is returned when a session does not exist or belongs
+// to another user.
+var ErrSessionNotFound = errors.New("session not found")
+
+type Session struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"user_id"`
+	Title     string    `json:"title"`
+	Sandbox   bool      `json:"sandbox"`
+	TurnCount int       `json:"turn_count"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// SessionTurn is one question asked in a session, with enough of its result
+// kept to give the next question context.
+type SessionTurn struct {
+	ID           int64     `json:"id"`
+	SessionID    string    `json:"session_id"`
+	Question     string    `json:"question"`
+	GeneratedSQL string    `json:"generated_sql"`
+	Columns      []string  `json:"columns"`
+	PreviewRows  [][]any   `json:"preview_rows"`
+	RowCount     int       `json:"row_count"`
+	Summary      string    `json:"summary"`
+	TraceID      string    `json:"trace_id"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type InsertSessionTurnParams struct {
+	SessionID    string
+	Question     string
+	GeneratedSQL string
+	Columns      []string
+	PreviewRows  [][]any
+	RowCount     int
+	Summary      string
+	TraceID      string
+}
+
+const sessionColumns = `
+	s.id, s.user_id, s.title, s.sandbox,
+	(SELECT COUNT(*) FROM session_turns t WHERE t.session_id = s.id),
+	s.created_at, s.updated_at`
+
+func scanSession(row pgx.Row) (*Session, error) {
+	var s Session
+	if err := row.Scan(&s.ID, &s.UserID, &s.Title, &s.Sandbox, &s.TurnCount, &s.CreatedAt, &s.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// SessionRepo reads and writes conversation_sessions and session_turns.
+type SessionRepo struct {
+	q Querier
+}
+
+func NewSessionRepo(q Querier) *SessionRepo {
+	return &SessionRepo{q: q}
+}
+
+const createSession = `-- name: CreateSession :one
+INSERT INTO conversation_sessions (user_id, title, sandbox)
+VALUES ($1, $2, $3)
+RETURNING id, user_id, title, sandbox, created_at, updated_at`
+
+// Create starts a session. A sandbox session may create temporary tables and
+// views for multi-step analysis.
+func (r *SessionRepo) Create(ctx context.Context, userID, title string, sandbox bool) (*Session, error) {
+	var s Session
+	err := r.q.QueryRow(ctx, createSession, userID, title, sandbox).
+		Scan(&s.ID, &s.UserID, &s.Title, &s.Sandbox, &s.CreatedAt, &s.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+const getSession = `-- name: GetSession :one
+SELECT` + sessionColumns + `
+FROM conversation_sessions s
+WHERE s.user_id = $1 AND s.id::text = $2`
+
+// Get returns one of the caller's sessions.
+func (r *SessionRepo) Get(ctx context.Context, userID, id string) (*Session, error) {
+	s, err := scanSession(r.q.QueryRow(ctx, getSession, userID, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrSessionNotFound
+	}
+	return s, err
+}
+
+const listSessions = `-- name: ListSessions :many
+SELECT` + sessionColumns + `
+FROM conversation_sessions s
+WHERE s.user_id = $1
+ORDER BY s.updated_at DESC
+LIMIT $2 OFFSET $3`
+
+// List returns the caller's sessions, most recently used first.
+func (r *SessionRepo) List(ctx context.Context, userID string, limit, offset int) ([]Session, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+	rows, err := r.q.Query(ctx, listSessions, userID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []Session{}
+	for rows.Next() {
+		s, err := scanSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, *s)
+	}
+	return sessions, rows.Err()
+}
+
+const deleteSession = `-- name: DeleteSession :execrows
+DELETE FROM conversation_sessions
+WHERE user_id = $1 AND id::text = $2`
+
+// Delete removes one of the caller's sessions and its turns.
+func (r *SessionRepo) Delete(ctx context.Context, userID, id string) error {
+	tag, err := r.q.Exec(ctx, deleteSession, userID, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrSessionNotFound
+	}
+	return nil
+}
+
+const insertSessionTurn = `-- name: InsertSessionTurn :exec
+WITH turn AS (
+	INSERT INTO session_turns (session_id, question, generated_sql, columns, preview_rows,
+		row_count, summary, trace_id)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	RETURNING session_id
+)
+UPDATE conversation_sessions
+SET updated_at = NOW(),
+	title = CASE WHEN title = '' THEN LEFT($2, 80) ELSE title END
+WHERE id = (SELECT session_id FROM turn)`
+
+// InsertTurn records a turn and marks the session as used. An untitled
+// session takes its title from its first question.
+func (r *SessionRepo) InsertTurn(ctx context.Context, p InsertSessionTurnParams) error {
+	columns, err := json.Marshal(p.Columns)
+	if err != nil {
+		return err
+	}
+	rows := p.PreviewRows
+	if rows == nil {
+		rows = [][]any{}
+	}
+	preview, err := json.Marshal(rows)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.q.Exec(ctx, insertSessionTurn,
+		p.SessionID, p.Question, p.GeneratedSQL, columns, preview,
+		p.RowCount, p.Summary, p.TraceID,
+	)
+	return err
+}
+
+const listSessionTurns = `-- name: ListSessionTurns :many
+SELECT id, session_id, question, generated_sql, columns, preview_rows,
+	row_count, COALESCE(summary, ''), COALESCE(trace_id, ''), created_at
+FROM (
+	SELECT * FROM session_turns
+	WHERE session_id::text = $1
+	ORDER BY id DESC
+	LIMIT $2
+) recent
+ORDER BY id`
+
+// ListTurns returns the last limit turns of a session, oldest first.
+func (r *SessionRepo) ListTurns(ctx context.Context, sessionID string, limit int) ([]SessionTurn, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+	rows, err := r.q.Query(ctx, listSessionTurns, sessionID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	turns := []SessionTurn{}
+	for rows.Next() {
+		var t SessionTurn
+		var columns, preview []byte
+		if err := rows.Scan(&t.ID, &t.SessionID, &t.Question, &t.GeneratedSQL, &columns, &preview,
+			&t.RowCount, &t.Summary, &t.TraceID, &t.CreatedAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(columns, &t.Columns); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(preview, &t.PreviewRows); err != nil {
+			return nil, err
+		}
+		turns = append(turns, t)
+	}
+	return turns, rows.Err()
+}
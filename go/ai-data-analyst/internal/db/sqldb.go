@@ -0,0 +1,158 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	_ "github.com/go-sql-driver/mysql"
+	_ "modernc.org/sqlite"
+)
+
+// SQLDB adapts a database/sql pool to Querier so the pipeline can run
+// generated SQL on MySQL or SQLite. It supports what Execute needs:
+// FieldDescriptions carry only column names, and Values returns driver
+// values with byte slices converted to strings.
+type SQLDB struct {
+	db *sql.DB
+}
+
+// OpenSQL opens driverName at dsn and runs setup on every new connection,
+// which is how read-only and timeout settings stick for drivers without
+// transaction-scoped SET.
+func OpenSQL(ctx context.Context, driverName, dsn string, setup []string) (*SQLDB, error) {
+	probe, err := sql.Open(driverName, dsn)
+	if err != nil {
+		return nil, err
+	}
+	drv := probe.Driver()
+	_ = probe.Close()
+
+	var base driver.Connector = dsnConnector{dsn: dsn, driver: drv}
+	if dc, ok := drv.(driver.DriverContext); ok {
+		if base, err = dc.OpenConnector(dsn); err != nil {
+			return nil, err
+		}
+	}
+
+	db := sql.OpenDB(setupConnector{Connector: base, setup: setup})
+	if err := db.PingContext(ctx); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("connect to %s: %w", driverName, err)
+	}
+	return &SQLDB{db: db}, nil
+}
+
+func (s *SQLDB) Close() error {
+	return s.db.Close()
+}
+
+func (s *SQLDB) Exec(ctx context.Context, query string, args ...any) (pgconn.CommandTag, error) {
+	res, err := s.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return pgconn.CommandTag{}, err
+	}
+	n, _ := res.RowsAffected()
+	return pgconn.NewCommandTag(fmt.Sprintf("EXEC %d", n)), nil
+}
+
+func (s *SQLDB) Query(ctx context.Context, query string, args ...any) (pgx.Rows, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	cols, err := rows.Columns()
+	if err != nil {
+		_ = rows.Close()
+		return nil, err
+	}
+	return &sqlRows{rows: rows, cols: cols}, nil
+}
+
+func (s *SQLDB) QueryRow(ctx context.Context, query string, args ...any) pgx.Row {
+	return s.db.QueryRowContext(ctx, query, args...)
+}
+
+type dsnConnector struct {
+	dsn    string
+	driver driver.Driver
+}
+
+func (c dsnConnector) Connect(context.Context) (driver.Conn, error) { return c.driver.Open(c.dsn) }
+func (c dsnConnector) Driver() driver.Driver                        { return c.driver }
+
+type setupConnector struct {
+	driver.Connector
+	setup []string
+}
+
+func (c setupConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil || len(c.setup) == 0 {
+		return conn, err
+	}
+	execer, ok := conn.(driver.ExecerContext)
+	if !ok {
+		_ = conn.Close()
+		return nil, errors.New("driver does not support connection setup statements")
+	}
+	for _, stmt := range c.setup {
+		if _, err := execer.ExecContext(ctx, stmt, nil); err != nil {
+			_ = conn.Close()
+			return nil, fmt.Errorf("connection setup %q: %w", stmt, err)
+		}
+	}
+	return conn, nil
+}
+
+// sqlRows implements pgx.Rows over *sql.Rows.
+type sqlRows struct {
+	rows *sql.Rows
+	cols []string
+	err  error
+}
+
+func (r *sqlRows) Close()                        { _ = r.rows.Close() }
+func (r *sqlRows) CommandTag() pgconn.CommandTag { return pgconn.NewCommandTag("SELECT") }
+func (r *sqlRows) Next() bool                    { return r.rows.Next() }
+func (r *sqlRows) Scan(dest ...any) error        { return r.rows.Scan(dest...) }
+func (r *sqlRows) RawValues() [][]byte           { return nil }
+func (r *sqlRows) Conn() *pgx.Conn               { return nil }
+
+func (r *sqlRows) Err() error {
+	if r.err != nil {
+		return r.err
+	}
+	return r.rows.Err()
+}
+
+func (r *sqlRows) FieldDescriptions() []pgconn.FieldDescription {
+	fields := make([]pgconn.FieldDescription, len(r.cols))
+	for i, c := range r.cols {
+		fields[i] = pgconn.FieldDescription{Name: c}
+	}
+	return fields
+}
+
+func (r *sqlRows) Values() ([]any, error) {
+	values := make([]any, len(r.cols))
+	ptrs := make([]any, len(r.cols))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+	if err := r.rows.Scan(ptrs...); err != nil {
+		r.err = err
+		return nil, err
+	}
+	for i, v := range values {
+		if b, ok := v.([]byte); ok {
+			values[i] = string(b)
+		}
+	}
+	return values, nil
+}
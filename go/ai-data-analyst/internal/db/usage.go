@@ -0,0 +1,116 @@
+package db
+
+import (
+	"context"
+	"time"
+)
+
+type UsageSummary struct {
+	UserID       string    `json:"user_id"`
+	Questions    int       `json:"questions"`
+	TotalTokens  int       `json:"total_tokens"`
+	TotalCostUSD float64   `json:"total_cost_usd"`
+	LastAskedAt  time.Time `json:"last_asked_at"`
+}
+
+type DailyUsage struct {
+	Day          string  `json:"day"`
+	Questions    int     `json:"questions"`
+	TotalTokens  int     `json:"total_tokens"`
+	TotalCostUSD float64 `json:"total_cost_usd"`
+}
+
+const usageColumns = `
+	COUNT(*), COALESCE(SUM(total_tokens), 0), COALESCE(SUM(total_cost_usd), 0)::float8`
+
+// UserUsage returns the caller's totals and a per-day breakdown for the last
+// `days` days.
+func UserUsage(ctx context.Context, q Querier, userID string, days int) (*UsageSummary, []DailyUsage, error) {
+	if days <= 0 {
+		days = 30
+	}
+
+	summary := &UsageSummary{UserID: userID}
+	var last *time.Time
+	err := q.QueryRow(ctx, `SELECT`+usageColumns+`, MAX(created_at)
+		FROM query_history WHERE user_id = $1`, userID,
+	).Scan(&summary.Questions, &summary.TotalTokens, &summary.TotalCostUSD, &last)
+	if err != nil {
+		return nil, nil, err
+	}
+	if last != nil {
+		summary.LastAskedAt = *last
+	}
+
+	rows, err := q.Query(ctx, `SELECT to_char(date_trunc('day', created_at), 'YYYY-MM-DD'),`+usageColumns+`
+		FROM query_history
+		WHERE user_id = $1 AND created_at >= NOW() - make_interval(days => $2)
+		GROUP BY 1 ORDER BY 1`, userID, days)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	var daily []DailyUsage
+	for rows.Next() {
+		var d DailyUsage
+		if err := rows.Scan(&d.Day, &d.Questions, &d.TotalTokens, &d.TotalCostUSD); err != nil {
+			return nil, nil, err
+		}
+		daily = append(daily, d)
+	}
+	return summary, daily, rows.Err()
+}
+
+// UsageByUser aggregates totals for every user, highest spend first.
+func UsageByUser(ctx context.Context, q Querier) ([]UsageSummary, error) {
+	rows, err := q.Query(ctx, `SELECT user_id,`+usageColumns+`, MAX(created_at)
+		FROM query_history
+		GROUP BY user_id
+		ORDER BY 4 DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var usage []UsageSummary
+	for rows.Next() {
+		var u UsageSummary
+		if err := rows.Scan(&u.UserID, &u.Questions, &u.TotalTokens, &u.TotalCostUSD, &u.LastAskedAt); err != nil {
+			return nil, err
+		}
+		usage = append(usage, u)
+	}
+	return usage, rows.Err()
+}
+
+type TenantUsage struct {
+	TenantID     string    `json:"tenant_id"`
+	Users        int       `json:"users"`
+	Questions    int       `json:"questions"`
+	TotalTokens  int       `json:"total_tokens"`
+	TotalCostUSD float64   `json:"total_cost_usd"`
+	LastAskedAt  time.Time `json:"last_asked_at"`
+}
+
+// UsageByTenant aggregates totals for every tenant, highest spend first.
+func UsageByTenant(ctx context.Context, q Querier) ([]TenantUsage, error) {
+	rows, err := q.Query(ctx, `SELECT tenant_id, COUNT(DISTINCT user_id),`+usageColumns+`, MAX(created_at)
+		FROM query_history
+		GROUP BY tenant_id
+		ORDER BY 5 DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var usage []TenantUsage
+	for rows.Next() {
+		var u TenantUsage
+		if err := rows.Scan(&u.TenantID, &u.Users, &u.Questions, &u.TotalTokens, &u.TotalCostUSD, &u.LastAskedAt); err != nil {
+			return nil, err
+		}
+		usage = append(usage, u)
+	}
+	return usage, rows.Err()
+}
@@ -0,0 +1,128 @@
+package eval
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"ai-data-analyst/internal/pipeline"
+	"ai-data-analyst/internal/telemetry"
+
+	"github.com/base-14/examples/go/pkg/oteltest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestLoadBundledGoldenSet(t *testing.T) {
+	set, err := Load(filepath.Join("..", "..", "data", "eval", "golden.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, "world-bank", set.Name)
+	assert.NotEmpty(t, set.Cases)
+	for _, c := range set.Cases {
+		assert.NotEmpty(t, c.ExpectedSQL, c.Question)
+		assert.NotEqual(t, "unclassified", c.QuestionType, c.Question)
+	}
+}
+
+func TestLoadRejectsInvalidCases(t *testing.T) {
+	for name, body := range map[string]string{
+		"no cases":       "name: x\n",
+		"no question":    "cases:\n  - expected_rows: [[1]]\n",
+		"no expectation": "cases:\n  - question: q\n",
+		"both":           "cases:\n  - question: q\n    expected_sql: SELECT 1\n    expected_rows: [[1]]\n",
+	} {
+		path := filepath.Join(t.TempDir(), "golden.yaml")
+		require.NoError(t, os.WriteFile(path, []byte(body), 0o644))
+		_, err := Load(path)
+		assert.Error(t, err, name)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	got := [][]any{
+		{"Japan", "Life expectancy", int32(2020), "84.56"},
+		{"Nigeria", "Life expectancy", int32(2020), "52.68"},
+	}
+
+	ok, _ := Match([][]any{{"Nigeria", 52.676}, {"japan", 84.56}}, got, false)
+	assert.True(t, ok, "columns and row order are ignored, numbers are rounded")
+
+	ok, why := Match([][]any{{"Nigeria"}, {"Japan"}}, got, true)
+	assert.False(t, ok)
+	assert.Contains(t, why, "row 1")
+
+	ok, why = Match([][]any{{"Japan", 84.56}}, got, false)
+	assert.False(t, ok)
+	assert.Equal(t, "expected 1 rows, got 2", why)
+
+	ok, _ = Match([][]any{{"Japan", 85.0}, {"Nigeria", 52.68}}, got, false)
+	assert.False(t, ok)
+}
+
+type fakeAsker map[string]*pipeline.AskResult
+
+func (f fakeAsker) Ask(_ context.Context, question string) (*pipeline.AskResult, error) {
+	if r, ok := f[question]; ok {
+		return r, nil
+	}
+	return nil, errors.New("generate stage failed")
+}
+
+func TestRunnerReportsPerType(t *testing.T) {
+	tel := oteltest.New(t)
+	metrics, err := telemetry.NewEvalMetrics(tel.Meter("test"))
+	require.NoError(t, err)
+
+	set := &GoldenSet{Name: "test", Cases: []Case{
+		{Question: "GDP of Brazil", QuestionType: "lookup", ExpectedRows: [][]any{{"Brazil", 9000}}},
+		{Question: "GDP of Chile", QuestionType: "lookup", ExpectedRows: [][]any{{"Chile", 15000}}},
+		{Question: "Top 2 by GDP", QuestionType: "ranking", Ordered: true, ExpectedRows: [][]any{{"A"}, {"B"}}},
+		{Question: "From SQL", QuestionType: "ranking", ExpectedSQL: "SELECT 1"},
+	}}
+	asker := fakeAsker{
+		"GDP of Brazil": {SQL: "SELECT ...", Rows: [][]any{{"Brazil", "9000.000000"}}, RowCount: 1, TotalTokens: 100, TotalCostUSD: 0.01},
+		"GDP of Chile":  {SQL: "SELECT ...", Rows: [][]any{{"Chile", "14000"}}, RowCount: 1, TotalTokens: 100, TotalCostUSD: 0.01},
+		"Top 2 by GDP":  {SQL: "SELECT ...", Rows: [][]any{{"A"}, {"B"}}, RowCount: 2, TotalTokens: 50, TotalCostUSD: 0.02},
+	}
+
+	runner := &Runner{Pipeline: asker, Tracer: tel.Tracer("test"), Metrics: metrics}
+	report := runner.Run(context.Background(), set)
+
+	require.Len(t, report.Cases, 4)
+	assert.Equal(t, OutcomePass, report.Cases[0].Outcome)
+	assert.Equal(t, OutcomeFail, report.Cases[1].Outcome)
+	assert.Equal(t, OutcomePass, report.Cases[2].Outcome)
+	assert.Equal(t, OutcomeError, report.Cases[3].Outcome, "expected_sql without a database")
+
+	require.Len(t, report.ByType, 2)
+	lookup, ranking := report.ByType[0], report.ByType[1]
+	assert.Equal(t, "lookup", lookup.QuestionType)
+	assert.Equal(t, 0.5, lookup.Accuracy)
+	assert.Equal(t, 200, lookup.TotalTokens)
+	assert.Equal(t, 1, ranking.Passed)
+	assert.Equal(t, 1, ranking.Errors)
+	assert.Equal(t, 4, report.Overall.Questions)
+	assert.Equal(t, 0.5, report.Overall.Accuracy)
+	assert.InDelta(t, 0.04, report.Overall.CostUSD, 1e-9)
+
+	setAttr := attribute.String("nlsql.eval.set", "test")
+	assert.Equal(t, int64(1), oteltest.Sum[int64](t, tel, "nlsql.eval.questions",
+		setAttr, attribute.String("nlsql.question_type", "lookup"), attribute.String("nlsql.eval.outcome", OutcomeFail)))
+	assert.Equal(t, uint64(2), oteltest.Histogram[float64](t, tel, "nlsql.eval.duration",
+		setAttr, attribute.String("nlsql.question_type", "ranking")).Count)
+
+	gauge, ok := tel.Metric(t, "nlsql.eval.accuracy").Data.(metricdata.Gauge[float64])
+	require.True(t, ok)
+	accuracy := map[string]float64{}
+	for _, dp := range gauge.DataPoints {
+		qt, _ := dp.Attributes.Value("nlsql.question_type")
+		accuracy[qt.AsString()] = dp.Value
+	}
+	assert.Equal(t, map[string]float64{"lookup": 0.5, "ranking": 0.5, "all": 0.5}, accuracy)
+
+	tel.AssertSpanTree(t, oteltest.SpanTree{Name: "eval run", Children: []oteltest.SpanTree{{Name: "eval case"}}})
+}
@@ -0,0 +1,64 @@
+// Package eval measures how accurately the pipeline answers a golden set of
+// questions. Each question's rows are compared with the expected rows, and
+// accuracy, cost and latency are reported per question type, both as a
+// report and as OTel metrics, so a prompt or model change that makes answers
+// worse shows up on a dashboard.
+package eval
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Case is one golden question. The expected rows are given literally in
+// ExpectedRows or produced by running ExpectedSQL against the same database.
+type Case struct {
+	Question     string  `yaml:"question"`
+	QuestionType string  `yaml:"question_type"`
+	ExpectedSQL  string  `yaml:"expected_sql"`
+	ExpectedRows [][]any `yaml:"expected_rows"`
+
+	// Ordered requires the rows in the expected order, for rankings.
+	Ordered bool `yaml:"ordered"`
+}
+
+// GoldenSet is a named list of cases, as read from a YAML file.
+type GoldenSet struct {
+	Name  string `yaml:"name"`
+	Cases []Case `yaml:"cases"`
+}
+
+// Load reads a golden set from path. Cases without a question type are
+// counted under "unclassified".
+func Load(path string) (*GoldenSet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var set GoldenSet
+	if err := yaml.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	if set.Name == "" {
+		set.Name = "golden"
+	}
+	if len(set.Cases) == 0 {
+		return nil, fmt.Errorf("%s: no cases", path)
+	}
+	for i := range set.Cases {
+		c := &set.Cases[i]
+		if c.Question == "" {
+			return nil, fmt.Errorf("%s: case %d: question is required", path, i+1)
+		}
+		if (c.ExpectedSQL == "") == (c.ExpectedRows == nil) {
+			return nil, fmt.Errorf("%s: case %d: set one of expected_sql or expected_rows", path, i+1)
+		}
+		if c.QuestionType == "" {
+			c.QuestionType = "unclassified"
+		}
+	}
+	return &set, nil
+}
@@ -0,0 +1,104 @@
+package eval
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Match reports whether got answers the question the way expected does, and
+// if not, why. Column names and order are ignored, and got may carry extra
+// columns, since the LLM picks its own aliases and often adds the year or
+// indicator name: a row matches when each expected value appears in it. The
+// row counts must be equal, and with ordered the rows must line up one to
+// one. Numbers match within rounding to two decimals or 0.1%.
+func Match(expected, got [][]any, ordered bool) (bool, string) {
+	if len(expected) != len(got) {
+		return false, fmt.Sprintf("expected %d rows, got %d", len(expected), len(got))
+	}
+
+	if ordered {
+		for i := range expected {
+			if !rowContains(got[i], expected[i]) {
+				return false, fmt.Sprintf("row %d: expected %v, got %v", i+1, expected[i], got[i])
+			}
+		}
+		return true, ""
+	}
+
+	used := make([]bool, len(got))
+	for i, want := range expected {
+		found := false
+		for j, row := range got {
+			if !used[j] && rowContains(row, want) {
+				used[j], found = true, true
+				break
+			}
+		}
+		if !found {
+			return false, fmt.Sprintf("row %d: %v not in result", i+1, want)
+		}
+	}
+	return true, ""
+}
+
+// rowContains reports whether each value in want is in row, a value of row
+// matching at most one of want.
+func rowContains(row, want []any) bool {
+	used := make([]bool, len(row))
+	for _, w := range want {
+		found := false
+		for i, v := range row {
+			if !used[i] && valueEqual(w, v) {
+				used[i], found = true, true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func valueEqual(a, b any) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	if x, ok := toFloat(a); ok {
+		if y, ok := toFloat(b); ok {
+			diff := math.Abs(x - y)
+			return diff <= 0.005 || diff <= 0.001*math.Max(math.Abs(x), math.Abs(y))
+		}
+	}
+	return strings.EqualFold(strings.TrimSpace(fmt.Sprint(a)), strings.TrimSpace(fmt.Sprint(b)))
+}
+
+// toFloat reads the numbers in YAML and query results: Go numerics, NUMERIC
+// columns as pgx returns them, and numeric strings.
+func toFloat(v any) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int16:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case float32:
+		return float64(n), true
+	case float64:
+		return n, true
+	case pgtype.Numeric:
+		f, err := n.Float64Value()
+		return f.Float64, err == nil && f.Valid
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(n), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
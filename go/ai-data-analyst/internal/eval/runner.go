@@ -0,0 +1,228 @@
+package eval
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"ai-data-analyst/internal/db"
+	"ai-data-analyst/internal/pipeline"
+	"ai-data-analyst/internal/telemetry"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Outcomes of a golden question.
+const (
+	OutcomePass  = "pass"
+	OutcomeFail  = "fail"
+	OutcomeError = "error"
+)
+
+// Asker answers a question; *pipeline.Pipeline is one.
+type Asker interface {
+	Ask(ctx context.Context, question string) (*pipeline.AskResult, error)
+}
+
+// Runner asks each golden question through the pipeline and grades the
+// answer.
+type Runner struct {
+	Pipeline Asker
+	Tracer   trace.Tracer
+
+	// DB runs the expected_sql of cases that have it. Optional when every
+	// case lists expected_rows.
+	DB db.Querier
+
+	// Metrics records per-question and per-type results. Optional.
+	Metrics *telemetry.EvalMetrics
+}
+
+type CaseResult struct {
+	Question     string  `json:"question"`
+	QuestionType string  `json:"question_type"`
+	Outcome      string  `json:"outcome"`
+	Reason       string  `json:"reason,omitempty"`
+	SQL          string  `json:"sql,omitempty"`
+	RowCount     int     `json:"row_count"`
+	TotalTokens  int     `json:"total_tokens"`
+	CostUSD      float64 `json:"cost_usd"`
+	DurationMS   int64   `json:"duration_ms"`
+}
+
+// Summary aggregates the results of one question type, or of the whole set.
+type Summary struct {
+	QuestionType  string  `json:"question_type"`
+	Questions     int     `json:"questions"`
+	Passed        int     `json:"passed"`
+	Failed        int     `json:"failed"`
+	Errors        int     `json:"errors"`
+	Accuracy      float64 `json:"accuracy"`
+	TotalTokens   int     `json:"total_tokens"`
+	CostUSD       float64 `json:"cost_usd"`
+	AvgDurationMS int64   `json:"avg_duration_ms"`
+	MaxDurationMS int64   `json:"max_duration_ms"`
+}
+
+type Report struct {
+	Set     string       `json:"set"`
+	Overall Summary      `json:"overall"`
+	ByType  []Summary    `json:"by_type"`
+	Cases   []CaseResult `json:"cases"`
+	TraceID string       `json:"trace_id"`
+}
+
+// Run asks the questions of set one at a time, under one "eval run" span,
+// and returns the graded results. A question that fails to answer is
+// recorded as an error rather than stopping the run.
+func (r *Runner) Run(ctx context.Context, set *GoldenSet) *Report {
+	ctx, span := r.Tracer.Start(ctx, "eval run")
+	defer span.End()
+
+	report := &Report{Set: set.Name, TraceID: span.SpanContext().TraceID().String()}
+	for _, c := range set.Cases {
+		report.Cases = append(report.Cases, r.runCase(ctx, set.Name, c))
+	}
+
+	byType := map[string]*Summary{}
+	report.Overall.QuestionType = "all"
+	for _, c := range report.Cases {
+		s, ok := byType[c.QuestionType]
+		if !ok {
+			s = &Summary{QuestionType: c.QuestionType}
+			byType[c.QuestionType] = s
+		}
+		s.add(c)
+		report.Overall.add(c)
+	}
+	for _, s := range byType {
+		s.finish()
+		report.ByType = append(report.ByType, *s)
+		if r.Metrics != nil {
+			r.Metrics.Accuracy.Record(ctx, s.Accuracy, telemetry.WithEvalCase(set.Name, s.QuestionType))
+		}
+	}
+	sort.Slice(report.ByType, func(i, j int) bool { return report.ByType[i].QuestionType < report.ByType[j].QuestionType })
+	report.Overall.finish()
+	if r.Metrics != nil {
+		r.Metrics.Accuracy.Record(ctx, report.Overall.Accuracy, telemetry.WithEvalCase(set.Name, report.Overall.QuestionType))
+	}
+
+	span.SetAttributes(
+		attribute.String("nlsql.eval.set", set.Name),
+		attribute.Int("nlsql.eval.questions", report.Overall.Questions),
+		attribute.Int("nlsql.eval.passed", report.Overall.Passed),
+		attribute.Float64("nlsql.eval.accuracy", report.Overall.Accuracy),
+		attribute.Float64("nlsql.eval.cost_usd", report.Overall.CostUSD),
+	)
+	return report
+}
+
+func (r *Runner) runCase(ctx context.Context, set string, c Case) CaseResult {
+	ctx, span := r.Tracer.Start(ctx, "eval case")
+	defer span.End()
+	span.SetAttributes(
+		attribute.String("nlsql.eval.question", c.Question),
+		attribute.String("nlsql.question_type", c.QuestionType),
+	)
+
+	start := time.Now()
+	res := CaseResult{Question: c.Question, QuestionType: c.QuestionType}
+	res.Outcome, res.Reason = r.grade(ctx, c, &res)
+	res.DurationMS = time.Since(start).Milliseconds()
+
+	span.SetAttributes(attribute.String("nlsql.eval.outcome", res.Outcome))
+	if res.Outcome != OutcomePass {
+		span.SetAttributes(attribute.String("nlsql.eval.reason", res.Reason))
+	}
+	if res.Outcome == OutcomeError {
+		span.SetStatus(codes.Error, res.Reason)
+	}
+
+	if r.Metrics != nil {
+		attrs := telemetry.WithEvalCase(set, c.QuestionType)
+		r.Metrics.Questions.Add(ctx, 1, attrs, telemetry.WithEvalOutcome(res.Outcome))
+		r.Metrics.Cost.Add(ctx, res.CostUSD, attrs)
+		r.Metrics.Duration.Record(ctx, time.Since(start).Seconds(), attrs)
+	}
+	return res
+}
+
+// grade asks the question and compares its rows with the expected ones,
+// filling in what the answer cost on res.
+func (r *Runner) grade(ctx context.Context, c Case, res *CaseResult) (outcome, reason string) {
+	expected := c.ExpectedRows
+	if c.ExpectedSQL != "" {
+		var err error
+		if expected, err = r.expectedRows(ctx, c.ExpectedSQL); err != nil {
+			return OutcomeError, "expected_sql: " + err.Error()
+		}
+	}
+
+	answer, err := r.Pipeline.Ask(ctx, c.Question)
+	if err != nil {
+		return OutcomeError, err.Error()
+	}
+	res.SQL = answer.SQL
+	res.RowCount = answer.RowCount
+	res.TotalTokens = answer.TotalTokens
+	res.CostUSD = answer.TotalCostUSD
+	if answer.Status == pipeline.StatusDegraded {
+		return OutcomeError, "database unavailable"
+	}
+
+	if ok, why := Match(expected, answer.Rows, c.Ordered); !ok {
+		return OutcomeFail, why
+	}
+	return OutcomePass, ""
+}
+
+func (r *Runner) expectedRows(ctx context.Context, sql string) ([][]any, error) {
+	if r.DB == nil {
+		return nil, errors.New("no database to run it on")
+	}
+	rows, err := r.DB.Query(ctx, sql)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out [][]any
+	for rows.Next() {
+		values, err := rows.Values()
+		if err != nil {
+			return nil, fmt.Errorf("scan: %w", err)
+		}
+		out = append(out, values)
+	}
+	return out, rows.Err()
+}
+
+func (s *Summary) add(c CaseResult) {
+	s.Questions++
+	switch c.Outcome {
+	case OutcomePass:
+		s.Passed++
+	case OutcomeFail:
+		s.Failed++
+	default:
+		s.Errors++
+	}
+	s.TotalTokens += c.TotalTokens
+	s.CostUSD += c.CostUSD
+	s.AvgDurationMS += c.DurationMS
+	s.MaxDurationMS = max(s.MaxDurationMS, c.DurationMS)
+}
+
+// finish turns the running totals into averages.
+func (s *Summary) finish() {
+	if s.Questions == 0 {
+		return
+	}
+	s.Accuracy = float64(s.Passed) / float64(s.Questions)
+	s.AvgDurationMS /= int64(s.Questions)
+}
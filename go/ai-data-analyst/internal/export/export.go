@@ -0,0 +1,221 @@
+// Package export writes query results as downloadable CSV and Excel files.
+// Both formats are streamed a row at a time, so an export never holds more
+// than the rows it was given.
+package export
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"time"
+)
+
+// Export formats, as accepted by ?format=.
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+)
+
+// ContentType returns the media type of format, or "" for an unknown one.
+func ContentType(format string) string {
+	switch format {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatXLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return ""
+}
+
+// Write writes columns and rows to w in format.
+func Write(w io.Writer, format string, columns []string, rows [][]any) error {
+	switch format {
+	case FormatCSV:
+		return WriteCSV(w, columns, rows)
+	case FormatXLSX:
+		return WriteXLSX(w, columns, rows)
+	}
+	return fmt.Errorf("unknown export format %q", format)
+}
+
+// WriteCSV writes a header row of column names, then one record per row.
+// NULL is an empty field.
+func WriteCSV(w io.Writer, columns []string, rows [][]any) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(columns); err != nil {
+		return err
+	}
+	record := make([]string, len(columns))
+	for _, row := range rows {
+		for i := range record {
+			record[i] = ""
+			if i < len(row) {
+				record[i], _ = cell(row[i])
+			}
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteXLSX writes a workbook with one sheet, "Results": a bold header row
+// of column names, then one row per result row. Numbers are stored as
+// numbers so they can be summed and charted; everything else is text.
+func WriteXLSX(w io.Writer, columns []string, rows [][]any) error {
+	zw := zip.NewWriter(w)
+	for _, part := range xlsxParts {
+		f, err := zw.Create(part.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(f, part.content); err != nil {
+			return err
+		}
+	}
+
+	f, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return err
+	}
+	sheet := bufio.NewWriter(f)
+	sheet.WriteString(xml.Header + `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+
+	header := make([]any, len(columns))
+	for i, c := range columns {
+		header[i] = c
+	}
+	writeXLSXRow(sheet, 1, header, true)
+	for i, row := range rows {
+		writeXLSXRow(sheet, i+2, row, false)
+	}
+
+	sheet.WriteString(`</sheetData></worksheet>`)
+	if err := sheet.Flush(); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+func writeXLSXRow(w *bufio.Writer, n int, values []any, header bool) {
+	fmt.Fprintf(w, `<row r="%d">`, n)
+	for i, v := range values {
+		if v == nil {
+			continue
+		}
+		text, numeric := cell(v)
+		ref := columnName(i) + strconv.Itoa(n)
+		switch {
+		case header:
+			fmt.Fprintf(w, `<c r="%s" t="inlineStr" s="1"><is><t xml:space="preserve">`, ref)
+		case numeric:
+			fmt.Fprintf(w, `<c r="%s"><v>%s</v></c>`, ref, text)
+			continue
+		default:
+			fmt.Fprintf(w, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">`, ref)
+		}
+		xml.EscapeText(w, []byte(text))
+		w.WriteString(`</t></is></c>`)
+	}
+	w.WriteString(`</row>`)
+}
+
+// columnName returns the spreadsheet name of the zero-based column i:
+// A, B, ... Z, AA, AB, ...
+func columnName(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+	return name
+}
+
+// cell renders a result value as text, and reports whether it is a number.
+// Rows hold what pgx returns, or their JSON form when they come from the
+// result cache, so anything without a case of its own goes through JSON:
+// pgtype.Numeric, for one, marshals to a number.
+func cell(v any) (string, bool) {
+	switch val := v.(type) {
+	case nil:
+		return "", false
+	case string:
+		return val, false
+	case bool:
+		return strconv.FormatBool(val), false
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return fmt.Sprint(val), true
+	case float32:
+		return formatFloat(float64(val), 32)
+	case float64:
+		return formatFloat(val, 64)
+	case json.Number:
+		return val.String(), true
+	case time.Time:
+		return val.Format(time.RFC3339), false
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v), false
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var decoded any
+	if err := dec.Decode(&decoded); err != nil {
+		return string(data), false
+	}
+	switch val := decoded.(type) {
+	case json.Number:
+		return val.String(), true
+	case string:
+		return val, false
+	case nil:
+		return "", false
+	}
+	return string(data), false
+}
+
+// formatFloat reports NaN and the infinities as text, which Excel would
+// not take for a number.
+func formatFloat(f float64, bits int) (string, bool) {
+	return strconv.FormatFloat(f, 'f', -1, bits), !math.IsNaN(f) && !math.IsInf(f, 0)
+}
+
+// xlsxParts are the parts of the workbook besides the sheet itself.
+var xlsxParts = []struct{ name, content string }{
+	{"[Content_Types].xml", xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>` +
+		`</Types>`},
+	{"_rels/.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`},
+	{"xl/workbook.xml", xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" ` +
+		`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="Results" sheetId="1" r:id="rId1"/></sheets></workbook>`},
+	{"xl/_rels/workbook.xml.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>` +
+		`</Relationships>`},
+	// Style 1 is the bold header.
+	{"xl/styles.xml", xml.Header + `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+		`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+		`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+		`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+		`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+		`<cellXfs count="2"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+		`<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/></cellXfs>` +
+		`</styleSheet>`},
+}
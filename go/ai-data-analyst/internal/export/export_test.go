@@ -0,0 +1,91 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	testColumns = []string{"country", "year", "gdp_growth"}
+	testRows    = [][]any{
+		{"India", int32(2023), 8.15},
+		{"Côte d'Ivoire, \"CI\"", json.Number("2022"), nil},
+	}
+)
+
+func TestWriteCSV(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, FormatCSV, testColumns, testRows))
+	assert.Equal(t, "country,year,gdp_growth\n"+
+		"India,2023,8.15\n"+
+		"\"Côte d'Ivoire, \"\"CI\"\"\",2022,\n", buf.String())
+}
+
+func TestWriteXLSX(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, FormatXLSX, testColumns, testRows))
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	parts := map[string]string{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		require.NoError(t, err)
+		data, err := io.ReadAll(rc)
+		require.NoError(t, err)
+		rc.Close()
+		parts[f.Name] = string(data)
+	}
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml",
+		"xl/_rels/workbook.xml.rels", "xl/styles.xml", "xl/worksheets/sheet1.xml"} {
+		assert.Contains(t, parts, name)
+	}
+
+	sheet := parts["xl/worksheets/sheet1.xml"]
+	assert.Contains(t, sheet, `<c r="A1" t="inlineStr" s="1"><is><t xml:space="preserve">country</t></is></c>`)
+	assert.Contains(t, sheet, `<c r="B2"><v>2023</v></c>`)
+	assert.Contains(t, sheet, `<c r="C2"><v>8.15</v></c>`)
+	assert.Contains(t, sheet, `Côte d&#39;Ivoire, &#34;CI&#34;`)
+	assert.NotContains(t, sheet, `r="C3"`, "NULL leaves the cell empty")
+}
+
+func TestWriteUnknownFormat(t *testing.T) {
+	assert.Error(t, Write(io.Discard, "pdf", testColumns, testRows))
+	assert.Empty(t, ContentType("pdf"))
+}
+
+func TestCell(t *testing.T) {
+	for _, tc := range []struct {
+		in      any
+		text    string
+		numeric bool
+	}{
+		{int64(-3), "-3", true},
+		{1e21, "1000000000000000000000", true},
+		{math.NaN(), "NaN", false},
+		{true, "true", false},
+		{time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC), "2026-03-01T12:00:00Z", false},
+		{json.RawMessage(`12.5`), "12.5", true},
+		{[]string{"a"}, `["a"]`, false},
+	} {
+		text, numeric := cell(tc.in)
+		assert.Equal(t, tc.text, text, "%v", tc.in)
+		assert.Equal(t, tc.numeric, numeric, "%v", tc.in)
+	}
+}
+
+func TestColumnName(t *testing.T) {
+	assert.Equal(t, "A", columnName(0))
+	assert.Equal(t, "Z", columnName(25))
+	assert.Equal(t, "AA", columnName(26))
+	assert.Equal(t, "AZ", columnName(51))
+	assert.Equal(t, "BA", columnName(52))
+}
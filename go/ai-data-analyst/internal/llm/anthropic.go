@@ -19,8 +19,8 @@ func NewAnthropicProvider(apiKey string) *AnthropicProvider {
 
 func (p *AnthropicProvider) Name() string { return "anthropic" }
 
-func (p *AnthropicProvider) Generate(ctx context.Context, req GenerateRequest) (*GenerateResponse, error) {
-	resp, err := p.client.Messages.New(ctx, anthropic.MessageNewParams{
+func messageParams(req GenerateRequest) anthropic.MessageNewParams {
+	return anthropic.MessageNewParams{
 		Model:     anthropic.Model(req.Model),
 		MaxTokens: int64(req.MaxTokens),
 		System: []anthropic.TextBlockParam{
@@ -29,7 +29,11 @@ func (p *AnthropicProvider) Generate(ctx context.Context, req GenerateRequest) (
 		Messages: []anthropic.MessageParam{
 			anthropic.NewUserMessage(anthropic.NewTextBlock(req.Prompt)),
 		},
-	})
+	}
+}
+
+func (p *AnthropicProvider) Generate(ctx context.Context, req GenerateRequest) (*GenerateResponse, error) {
+	resp, err := p.client.Messages.New(ctx, messageParams(req))
 	if err != nil {
 		return nil, err
 	}
@@ -49,3 +53,45 @@ func (p *AnthropicProvider) Generate(ctx context.Context, req GenerateRequest) (
 		FinishReason: string(resp.StopReason),
 	}, nil
 }
+
+// GenerateStream reports request errors on the channel rather than as its
+// error: the SDK only sends the request when the stream is first read.
+func (p *AnthropicProvider) GenerateStream(ctx context.Context, req GenerateRequest) (<-chan StreamChunk, error) {
+	stream := p.client.Messages.NewStreaming(ctx, messageParams(req))
+
+	chunks := make(chan StreamChunk)
+	go func() {
+		defer close(chunks)
+		defer stream.Close()
+
+		var message anthropic.Message
+		for stream.Next() {
+			event := stream.Current()
+			if err := message.Accumulate(event); err != nil {
+				sendChunk(ctx, chunks, StreamChunk{Err: err})
+				return
+			}
+			delta, ok := event.AsAny().(anthropic.ContentBlockDeltaEvent)
+			if !ok {
+				continue
+			}
+			if text, ok := delta.Delta.AsAny().(anthropic.TextDelta); ok && text.Text != "" {
+				if !sendChunk(ctx, chunks, StreamChunk{Content: text.Text}) {
+					return
+				}
+			}
+		}
+		if err := stream.Err(); err != nil {
+			sendChunk(ctx, chunks, StreamChunk{Err: err})
+			return
+		}
+
+		sendChunk(ctx, chunks, StreamChunk{Response: &GenerateResponse{
+			Model:        string(message.Model),
+			InputTokens:  int(message.Usage.InputTokens),
+			OutputTokens: int(message.Usage.OutputTokens),
+			FinishReason: string(message.StopReason),
+		}})
+	}()
+	return chunks, nil
+}
@@ -0,0 +1,72 @@
+package llm
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// ProviderAzureOpenAI is the gen_ai.provider.name for Azure OpenAI.
+const ProviderAzureOpenAI = "azure.ai.openai"
+
+// DefaultAzureAPIVersion is the Azure OpenAI REST API version used when
+// AZURE_OPENAI_API_VERSION is not set.
+const DefaultAzureAPIVersion = "2024-10-21"
+
+// AzureOpenAIProvider calls models deployed to an Azure OpenAI resource.
+// Azure addresses a model by deployment name rather than model name, so each
+// request's model is looked up in the deployment map; a model with no entry
+// is assumed to be deployed under its own name.
+type AzureOpenAIProvider struct {
+	*OpenAIProvider
+	host string
+	port int
+}
+
+func NewAzureOpenAIProvider(endpoint, apiKey, apiVersion string, deployments map[string]string) *AzureOpenAIProvider {
+	cfg := openai.DefaultAzureConfig(apiKey, strings.TrimRight(endpoint, "/"))
+	cfg.APIVersion = apiVersion
+	if cfg.APIVersion == "" {
+		cfg.APIVersion = DefaultAzureAPIVersion
+	}
+	cfg.AzureModelMapperFunc = func(model string) string {
+		if deployment, ok := deployments[model]; ok {
+			return deployment
+		}
+		return model
+	}
+
+	p := &AzureOpenAIProvider{
+		OpenAIProvider: &OpenAIProvider{client: openai.NewClientWithConfig(cfg)},
+		port:           443,
+	}
+	if u, err := url.Parse(endpoint); err == nil {
+		p.host = u.Hostname()
+		if port, err := strconv.Atoi(u.Port()); err == nil {
+			p.port = port
+		}
+	}
+	return p
+}
+
+func (p *AzureOpenAIProvider) Name() string { return ProviderAzureOpenAI }
+
+// ServerAddress is the resource's host, which varies per deployment.
+func (p *AzureOpenAIProvider) ServerAddress() (string, int) { return p.host, p.port }
+
+// ParseDeployments reads comma-separated model=deployment pairs, as in
+// AZURE_OPENAI_DEPLOYMENTS. Malformed pairs are skipped.
+func ParseDeployments(s string) map[string]string {
+	deployments := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		model, deployment, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		model, deployment = strings.TrimSpace(model), strings.TrimSpace(deployment)
+		if !ok || model == "" || deployment == "" {
+			continue
+		}
+		deployments[model] = deployment
+	}
+	return deployments
+}
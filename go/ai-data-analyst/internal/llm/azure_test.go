@@ -0,0 +1,73 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/base-14/examples/go/pkg/oteltest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func TestParseDeployments(t *testing.T) {
+	got := ParseDeployments(" gpt-4.1 = prod-gpt41, gpt-4.1-mini=mini ,broken,=x,y=")
+	assert.Equal(t, map[string]string{"gpt-4.1": "prod-gpt41", "gpt-4.1-mini": "mini"}, got)
+	assert.Empty(t, ParseDeployments(""))
+}
+
+func TestAzureOpenAIProviderRoutesToDeployment(t *testing.T) {
+	var gotPath, gotVersion, gotKey string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotVersion = r.URL.Query().Get("api-version")
+		gotKey = r.Header.Get("api-key")
+		json.NewEncoder(w).Encode(map[string]any{
+			"model":   "gpt-4.1-2025-04-14",
+			"choices": []map[string]any{{"message": map[string]string{"role": "assistant", "content": "SELECT 1"}, "finish_reason": "stop"}},
+			"usage":   map[string]int{"prompt_tokens": 12, "completion_tokens": 3},
+		})
+	}))
+	t.Cleanup(srv.Close)
+
+	provider := NewAzureOpenAIProvider(srv.URL, "secret", "", map[string]string{"gpt-4.1": "prod-gpt41"})
+	client, tel := newTestClient(t, provider, nil)
+	client.PrimaryProvider = provider.Name()
+
+	resp, err := client.Generate(context.Background(), testReq())
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT 1", resp.Content)
+	assert.Greater(t, resp.CostUSD, 0.0, "dated model names are priced")
+
+	assert.Equal(t, "/openai/deployments/prod-gpt41/chat/completions", gotPath)
+	assert.Equal(t, DefaultAzureAPIVersion, gotVersion)
+	assert.Equal(t, "secret", gotKey)
+
+	host, port := provider.ServerAddress()
+	span := tel.Span(t, "gen_ai.chat gpt-4.1")
+	oteltest.AssertSpanAttributes(t, span,
+		attribute.String("gen_ai.provider.name", ProviderAzureOpenAI),
+		attribute.String("server.address", host),
+		attribute.Int("server.port", port),
+	)
+	assert.Equal(t, "127.0.0.1", host)
+}
+
+func TestAzureOpenAIProviderUnmappedModel(t *testing.T) {
+	var gotPath, gotVersion string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotVersion = r.URL.Query().Get("api-version")
+		json.NewEncoder(w).Encode(map[string]any{"model": "gpt-4.1-mini", "choices": []any{}})
+	}))
+	t.Cleanup(srv.Close)
+
+	provider := NewAzureOpenAIProvider(srv.URL+"/", "secret", "2025-01-01-preview", nil)
+	_, err := provider.Generate(context.Background(), GenerateRequest{Model: "gpt-4.1-mini", MaxTokens: 10})
+	require.NoError(t, err)
+	assert.Equal(t, "/openai/deployments/gpt-4.1-mini/chat/completions", gotPath)
+	assert.Equal(t, "2025-01-01-preview", gotVersion)
+}
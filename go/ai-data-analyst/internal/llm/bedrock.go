@@ -0,0 +1,140 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/types"
+)
+
+// ProviderBedrock is the gen_ai.provider.name for Amazon Bedrock.
+const ProviderBedrock = "aws.bedrock"
+
+// BedrockProvider calls models on Amazon Bedrock through the Converse API,
+// which takes the same request shape for Claude, Titan and the other model
+// families. Requests are SigV4-signed with credentials from the default AWS
+// chain: environment, shared config, or the instance or task role.
+type BedrockProvider struct {
+	client *bedrockruntime.Client
+	region string
+}
+
+// NewBedrockProvider loads the default AWS configuration for region.
+func NewBedrockProvider(ctx context.Context, region string) (*BedrockProvider, error) {
+	cfg, err := awsconfig.LoadDefaultConfig(ctx, awsconfig.WithRegion(region))
+	if err != nil {
+		return nil, fmt.Errorf("load AWS config: %w", err)
+	}
+	return newBedrockProvider(cfg), nil
+}
+
+func newBedrockProvider(cfg aws.Config, optFns ...func(*bedrockruntime.Options)) *BedrockProvider {
+	return &BedrockProvider{
+		client: bedrockruntime.NewFromConfig(cfg, optFns...),
+		region: cfg.Region,
+	}
+}
+
+func (p *BedrockProvider) Name() string { return ProviderBedrock }
+
+// ServerAddress is the regional runtime endpoint.
+func (p *BedrockProvider) ServerAddress() (string, int) {
+	return "bedrock-runtime." + p.region + ".amazonaws.com", 443
+}
+
+func converseInput(req GenerateRequest) *bedrockruntime.ConverseInput {
+	input := &bedrockruntime.ConverseInput{
+		ModelId: aws.String(req.Model),
+		Messages: []types.Message{{
+			Role:    types.ConversationRoleUser,
+			Content: []types.ContentBlock{&types.ContentBlockMemberText{Value: req.Prompt}},
+		}},
+		InferenceConfig: &types.InferenceConfiguration{
+			MaxTokens:   aws.Int32(int32(req.MaxTokens)),
+			Temperature: aws.Float32(float32(req.Temperature)),
+		},
+	}
+	if req.System != "" {
+		input.System = []types.SystemContentBlock{&types.SystemContentBlockMemberText{Value: req.System}}
+	}
+	return input
+}
+
+// Bedrock does not echo the model back, so responses report the requested
+// model ID; CalculateCost maps it to its pricing entry.
+func (p *BedrockProvider) Generate(ctx context.Context, req GenerateRequest) (*GenerateResponse, error) {
+	out, err := p.client.Converse(ctx, converseInput(req))
+	if err != nil {
+		return nil, err
+	}
+
+	content := ""
+	if msg, ok := out.Output.(*types.ConverseOutputMemberMessage); ok {
+		for _, block := range msg.Value.Content {
+			if text, ok := block.(*types.ContentBlockMemberText); ok {
+				content += text.Value
+			}
+		}
+	}
+
+	resp := &GenerateResponse{
+		Content:      content,
+		Model:        req.Model,
+		FinishReason: string(out.StopReason),
+	}
+	setBedrockUsage(resp, out.Usage)
+	return resp, nil
+}
+
+func (p *BedrockProvider) GenerateStream(ctx context.Context, req GenerateRequest) (<-chan StreamChunk, error) {
+	in := converseInput(req)
+	out, err := p.client.ConverseStream(ctx, &bedrockruntime.ConverseStreamInput{
+		ModelId:         in.ModelId,
+		Messages:        in.Messages,
+		System:          in.System,
+		InferenceConfig: in.InferenceConfig,
+	})
+	if err != nil {
+		return nil, err
+	}
+	stream := out.GetStream()
+
+	chunks := make(chan StreamChunk)
+	go func() {
+		defer close(chunks)
+		defer stream.Close()
+
+		resp := &GenerateResponse{Model: req.Model}
+		for event := range stream.Events() {
+			switch e := event.(type) {
+			case *types.ConverseStreamOutputMemberContentBlockDelta:
+				if text, ok := e.Value.Delta.(*types.ContentBlockDeltaMemberText); ok && text.Value != "" {
+					if !sendChunk(ctx, chunks, StreamChunk{Content: text.Value}) {
+						return
+					}
+				}
+			case *types.ConverseStreamOutputMemberMessageStop:
+				resp.FinishReason = string(e.Value.StopReason)
+			case *types.ConverseStreamOutputMemberMetadata:
+				setBedrockUsage(resp, e.Value.Usage)
+			}
+		}
+		if err := stream.Err(); err != nil {
+			sendChunk(ctx, chunks, StreamChunk{Err: err})
+			return
+		}
+		sendChunk(ctx, chunks, StreamChunk{Response: resp})
+	}()
+	return chunks, nil
+}
+
+func setBedrockUsage(resp *GenerateResponse, usage *types.TokenUsage) {
+	if usage == nil {
+		return
+	}
+	resp.InputTokens = int(aws.ToInt32(usage.InputTokens))
+	resp.OutputTokens = int(aws.ToInt32(usage.OutputTokens))
+}
@@ -0,0 +1,98 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
+	"github.com/base-14/examples/go/pkg/oteltest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const testBedrockModel = "us.anthropic.claude-haiku-4-5-20251001-v1:0"
+
+func newTestBedrockProvider(t *testing.T, handler http.HandlerFunc) *BedrockProvider {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	cfg := aws.Config{
+		Region:      "us-east-1",
+		Credentials: credentials.NewStaticCredentialsProvider("AKIDEXAMPLE", "secret", ""),
+	}
+	return newBedrockProvider(cfg, func(o *bedrockruntime.Options) {
+		o.BaseEndpoint = aws.String(srv.URL)
+	})
+}
+
+func TestBedrockProviderGenerate(t *testing.T) {
+	var gotPath, gotAuth string
+	var gotBody struct {
+		System          []map[string]string `json:"system"`
+		InferenceConfig map[string]any      `json:"inferenceConfig"`
+	}
+	provider := newTestBedrockProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotAuth = r.Header.Get("Authorization")
+		_ = json.NewDecoder(r.Body).Decode(&gotBody)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{
+			"output": map[string]any{"message": map[string]any{
+				"role":    "assistant",
+				"content": []map[string]string{{"text": "SELECT "}, {"text": "1"}},
+			}},
+			"stopReason": "end_turn",
+			"usage":      map[string]int{"inputTokens": 42, "outputTokens": 7, "totalTokens": 49},
+			"metrics":    map[string]int{"latencyMs": 120},
+		})
+	})
+	client, tel := newTestClient(t, provider, nil)
+	client.PrimaryProvider = provider.Name()
+
+	req := testReq()
+	req.Model = testBedrockModel
+	req.System = "You write SQL."
+	resp, err := client.Generate(context.Background(), req)
+	require.NoError(t, err)
+
+	assert.Equal(t, "SELECT 1", resp.Content)
+	assert.Equal(t, testBedrockModel, resp.Model)
+	assert.Equal(t, 42, resp.InputTokens)
+	assert.Equal(t, 7, resp.OutputTokens)
+	assert.Equal(t, "end_turn", resp.FinishReason)
+	assert.Greater(t, resp.CostUSD, 0.0, "Bedrock model IDs are priced")
+
+	assert.Equal(t, "/model/"+testBedrockModel+"/converse", gotPath)
+	assert.Contains(t, gotAuth, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/")
+	assert.Contains(t, gotAuth, "/us-east-1/bedrock/aws4_request")
+	assert.Equal(t, []map[string]string{{"text": "You write SQL."}}, gotBody.System)
+	assert.EqualValues(t, 100, gotBody.InferenceConfig["maxTokens"])
+
+	span := tel.Span(t, "gen_ai.chat "+testBedrockModel)
+	oteltest.AssertSpanAttributes(t, span,
+		attribute.String("gen_ai.provider.name", ProviderBedrock),
+		attribute.String("server.address", "bedrock-runtime.us-east-1.amazonaws.com"),
+		attribute.Int("server.port", 443),
+		attribute.Int("gen_ai.usage.input_tokens", 42),
+		attribute.Int("gen_ai.usage.output_tokens", 7),
+	)
+}
+
+func TestBedrockProviderError(t *testing.T) {
+	provider := newTestBedrockProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Amzn-ErrorType", "AccessDeniedException")
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"message":"no access to model"}`))
+	})
+
+	_, err := provider.Generate(context.Background(), GenerateRequest{Model: testBedrockModel, MaxTokens: 10})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no access to model")
+}
@@ -0,0 +1,256 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned by a BreakerProvider, without calling the
+// provider, while its breaker is open or its half-open probe is in flight.
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// BreakerState is the state of a Breaker, as reported by the
+// gen_ai.client.circuit_breaker.state gauge.
+type BreakerState int64
+
+const (
+	BreakerClosed BreakerState = iota
+	BreakerHalfOpen
+	BreakerOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerHalfOpen:
+		return "half-open"
+	case BreakerOpen:
+		return "open"
+	}
+	return "closed"
+}
+
+// BreakerConfig sets when a Breaker opens and for how long.
+type BreakerConfig struct {
+	// ErrorRate opens the breaker once this share of the calls in the
+	// current window have failed, and the window has at least MinCalls.
+	ErrorRate float64
+	MinCalls  int
+	Window    time.Duration
+
+	// Cooldown is how long the breaker stays open before a single probe
+	// call is let through to decide whether it closes again.
+	Cooldown time.Duration
+}
+
+// Breaker counts the outcomes of calls to one provider. Closed, it lets
+// every call through; open, it turns them all away for Cooldown; half-open,
+// it lets one probe through, closing on success and opening again on
+// failure. Calls the caller cancelled are not counted either way.
+type Breaker struct {
+	Name   string
+	Config BreakerConfig
+
+	now func() time.Time
+
+	mu          sync.Mutex
+	state       BreakerState
+	windowStart time.Time
+	calls       int
+	failures    int
+	openedAt    time.Time
+	probing     bool
+}
+
+func NewBreaker(name string, cfg BreakerConfig) *Breaker {
+	return &Breaker{Name: name, Config: cfg, now: time.Now}
+}
+
+// State reports the breaker's state, half-open once an open breaker's
+// cool-down has passed.
+func (b *Breaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.advance()
+	return b.state
+}
+
+// allow reports whether a call may go ahead. If it may, done must be called
+// with its outcome.
+func (b *Breaker) allow() (done func(ctx context.Context, err error), err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.advance()
+
+	switch b.state {
+	case BreakerOpen:
+		return nil, ErrCircuitOpen
+	case BreakerHalfOpen:
+		if b.probing {
+			return nil, ErrCircuitOpen
+		}
+		b.probing = true
+		return b.probeDone, nil
+	}
+	return b.callDone, nil
+}
+
+func (b *Breaker) callDone(ctx context.Context, err error) {
+	if ctx.Err() != nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state != BreakerClosed {
+		// Opened by other calls while this one ran.
+		return
+	}
+
+	now := b.now()
+	if now.Sub(b.windowStart) >= b.Config.Window {
+		b.windowStart, b.calls, b.failures = now, 0, 0
+	}
+	b.calls++
+	if err != nil {
+		b.failures++
+	}
+	if b.calls >= b.Config.MinCalls && float64(b.failures)/float64(b.calls) >= b.Config.ErrorRate {
+		b.open(now, fmt.Sprintf("%d of %d calls failed", b.failures, b.calls))
+	}
+}
+
+func (b *Breaker) probeDone(ctx context.Context, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+	switch {
+	case ctx.Err() != nil:
+		// Leave it half-open for the next call to probe.
+	case err != nil:
+		b.open(b.now(), "probe failed")
+	default:
+		b.transition(BreakerClosed)
+		b.windowStart, b.calls, b.failures = b.now(), 0, 0
+	}
+}
+
+// advance moves an open breaker to half-open once its cool-down is over.
+// Callers hold b.mu.
+func (b *Breaker) advance() {
+	if b.state == BreakerOpen && b.now().Sub(b.openedAt) >= b.Config.Cooldown {
+		b.transition(BreakerHalfOpen)
+	}
+}
+
+// open starts a cool-down. Callers hold b.mu.
+func (b *Breaker) open(now time.Time, reason string) {
+	log.Printf("LLM circuit breaker for %s: %s -> open (%s), probing again after %s",
+		b.Name, b.state, reason, b.Config.Cooldown)
+	b.openedAt = now
+	b.state = BreakerOpen
+}
+
+// transition changes state and logs it. Callers hold b.mu.
+func (b *Breaker) transition(to BreakerState) {
+	if b.state != to {
+		log.Printf("LLM circuit breaker for %s: %s -> %s", b.Name, b.state, to)
+		b.state = to
+	}
+}
+
+// BreakerProvider is a Provider whose calls go through a Breaker.
+// Embeddings bypass it.
+type BreakerProvider struct {
+	Provider
+	Breaker *Breaker
+}
+
+// NewBreakerProvider wraps p in a breaker named after it.
+func NewBreakerProvider(p Provider, name string, cfg BreakerConfig) *BreakerProvider {
+	return &BreakerProvider{Provider: p, Breaker: NewBreaker(name, cfg)}
+}
+
+// Unwrap returns the provider the breaker guards.
+func (p *BreakerProvider) Unwrap() Provider { return p.Provider }
+
+func (p *BreakerProvider) Generate(ctx context.Context, req GenerateRequest) (*GenerateResponse, error) {
+	done, err := p.Breaker.allow()
+	if err != nil {
+		return nil, err
+	}
+	resp, err := p.Provider.Generate(ctx, req)
+	done(ctx, err)
+	return resp, err
+}
+
+// GenerateStream counts a stream as failed if it does not end with a
+// response.
+func (p *BreakerProvider) GenerateStream(ctx context.Context, req GenerateRequest) (<-chan StreamChunk, error) {
+	done, err := p.Breaker.allow()
+	if err != nil {
+		return nil, err
+	}
+	chunks, err := p.Provider.GenerateStream(ctx, req)
+	if err != nil {
+		done(ctx, err)
+		return nil, err
+	}
+
+	out := make(chan StreamChunk)
+	go func() {
+		defer close(out)
+		outcome := errStreamIncomplete
+		for chunk := range chunks {
+			switch {
+			case chunk.Err != nil:
+				outcome = chunk.Err
+			case chunk.Response != nil:
+				outcome = nil
+			}
+			// Keep draining after ctx is done so the provider can finish.
+			sendChunk(ctx, out, chunk)
+		}
+		done(ctx, outcome)
+	}()
+	return out, nil
+}
+
+// unwrap returns the provider under any BreakerProvider, for the optional
+// interfaces such as Embedder that the wrapper does not pass through.
+func unwrap(p Provider) Provider {
+	for {
+		w, ok := p.(interface{ Unwrap() Provider })
+		if !ok {
+			return p
+		}
+		p = w.Unwrap()
+	}
+}
+
+// breakerOpen reports whether p's breaker is open, so its calls can skip
+// straight to the fallback without a retry loop or a chat span. A half-open
+// breaker is not: the call may be its probe.
+func breakerOpen(p Provider) bool {
+	bp, ok := p.(*BreakerProvider)
+	return ok && bp.Breaker.State() == BreakerOpen
+}
+
+// Breakers returns the breaker of each provider the client calls, by
+// provider name.
+func (c *Client) Breakers() map[string]*Breaker {
+	breakers := make(map[string]*Breaker)
+	add := func(p Provider, name string) {
+		if bp, ok := p.(*BreakerProvider); ok {
+			breakers[name] = bp.Breaker
+		}
+	}
+	add(c.Primary, c.PrimaryProvider)
+	add(c.Fallback, c.FallbackProviderName)
+	for _, route := range c.Routes {
+		add(route.Provider, route.ProviderName)
+	}
+	return breakers
+}
@@ -0,0 +1,182 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"ai-data-analyst/internal/telemetry"
+
+	"github.com/base-14/examples/go/pkg/oteltest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+var testBreakerConfig = BreakerConfig{ErrorRate: 0.5, MinCalls: 4, Window: time.Minute, Cooldown: 30 * time.Second}
+
+// newTestBreaker returns a breaker on a clock the test moves by hand.
+func newTestBreaker() (*Breaker, *time.Time) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	b := NewBreaker("openai", testBreakerConfig)
+	b.now = func() time.Time { return now }
+	return b, &now
+}
+
+func call(t *testing.T, b *Breaker, err error) {
+	t.Helper()
+	done, allowErr := b.allow()
+	require.NoError(t, allowErr)
+	done(context.Background(), err)
+}
+
+func tripBreaker(t *testing.T, b *Breaker) {
+	t.Helper()
+	for range b.Config.MinCalls {
+		call(t, b, errors.New("boom"))
+	}
+	require.Equal(t, BreakerOpen, b.State())
+}
+
+func TestBreakerOpensAtErrorRate(t *testing.T) {
+	b, _ := newTestBreaker()
+	boom := errors.New("boom")
+
+	call(t, b, nil)
+	call(t, b, boom)
+	call(t, b, boom)
+	assert.Equal(t, BreakerClosed, b.State(), "below MinCalls")
+
+	call(t, b, nil)
+	assert.Equal(t, BreakerOpen, b.State(), "2 of 4 calls failed")
+	_, err := b.allow()
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+}
+
+func TestBreakerWindowResets(t *testing.T) {
+	b, now := newTestBreaker()
+	boom := errors.New("boom")
+
+	call(t, b, boom)
+	call(t, b, boom)
+	call(t, b, boom)
+	*now = now.Add(time.Minute)
+	call(t, b, boom)
+	assert.Equal(t, BreakerClosed, b.State(), "failures of an earlier window do not count")
+}
+
+func TestBreakerHalfOpenProbe(t *testing.T) {
+	b, now := newTestBreaker()
+	tripBreaker(t, b)
+
+	*now = now.Add(29 * time.Second)
+	assert.Equal(t, BreakerOpen, b.State())
+	*now = now.Add(time.Second)
+	assert.Equal(t, BreakerHalfOpen, b.State())
+
+	done, err := b.allow()
+	require.NoError(t, err)
+	_, err = b.allow()
+	assert.ErrorIs(t, err, ErrCircuitOpen, "one probe at a time")
+
+	done(context.Background(), errors.New("still down"))
+	assert.Equal(t, BreakerOpen, b.State(), "a failed probe opens the breaker again")
+
+	*now = now.Add(30 * time.Second)
+	call(t, b, nil)
+	assert.Equal(t, BreakerClosed, b.State(), "a successful probe closes it")
+	call(t, b, errors.New("boom"))
+	assert.Equal(t, BreakerClosed, b.State(), "and starts a fresh window")
+}
+
+func TestBreakerIgnoresCancelledCalls(t *testing.T) {
+	b, _ := newTestBreaker()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	for range 10 {
+		done, err := b.allow()
+		require.NoError(t, err)
+		done(ctx, context.Canceled)
+	}
+	assert.Equal(t, BreakerClosed, b.State())
+}
+
+func TestGenerateSkipsOpenBreaker(t *testing.T) {
+	primary := &mockProvider{name: "openai", resp: &GenerateResponse{Content: "Primary response", Model: "gpt-4.1"}}
+	fallback := &mockProvider{
+		name: "anthropic",
+		resp: &GenerateResponse{Content: "Fallback response", Model: "claude-haiku-4-5-20251001"},
+	}
+	guarded := NewBreakerProvider(primary, "openai", testBreakerConfig)
+	client, tel := newTestClient(t, guarded, fallback)
+	tripBreaker(t, guarded.Breaker)
+
+	ctx, parent := tel.Tracer("test").Start(context.Background(), "ask")
+	resp, err := client.Generate(ctx, testReq())
+	require.NoError(t, err)
+	assert.Equal(t, "Fallback response", resp.Content)
+
+	var deltas []string
+	resp, err = client.GenerateStream(ctx, testReq(), func(d string) { deltas = append(deltas, d) })
+	parent.End()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Fallback response"}, deltas)
+	assert.Equal(t, "Fallback response", resp.Content)
+
+	assert.Zero(t, primary.calls, "an open breaker keeps calls off the primary")
+	assert.Equal(t, 2, fallback.calls)
+	events := tel.Span(t, "ask").Events
+	require.Len(t, events, 2)
+	assert.Equal(t, "gen_ai.circuit_breaker.open", events[0].Name)
+	tel.AssertSpanTree(t, oteltest.SpanTree{
+		Name: "ask",
+		Children: []oteltest.SpanTree{
+			{Name: "gen_ai.chat claude-haiku-4-5-20251001"},
+			{Name: "gen_ai.chat claude-haiku-4-5-20251001"},
+		},
+	})
+}
+
+func TestBreakerRejectionIsNotRetried(t *testing.T) {
+	primary := &mockProvider{name: "openai", resp: &GenerateResponse{Content: "Primary response", Model: "gpt-4.1"}}
+	guarded := NewBreakerProvider(primary, "openai", testBreakerConfig)
+	client, _ := newTestClient(t, guarded, nil)
+
+	// Half-open with its probe in flight: the breaker turns the call away.
+	tripBreaker(t, guarded.Breaker)
+	guarded.Breaker.openedAt = time.Time{}
+	_, err := guarded.Breaker.allow()
+	require.NoError(t, err)
+
+	_, err = client.GenerateWithRetry(context.Background(), guarded, "openai", testReq())
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.Zero(t, primary.calls)
+	assert.Equal(t, "circuit_open", classifyError(err))
+}
+
+func TestCircuitBreakerGauge(t *testing.T) {
+	client, tel := newTestClient(t,
+		NewBreakerProvider(&mockProvider{name: "openai"}, "openai", testBreakerConfig),
+		NewBreakerProvider(&mockProvider{name: "anthropic"}, "anthropic", testBreakerConfig),
+	)
+	breakers := client.Breakers()
+	require.Len(t, breakers, 2)
+	tripBreaker(t, breakers["openai"])
+
+	states := map[string]func() int64{}
+	for name, b := range breakers {
+		states[name] = func() int64 { return int64(b.State()) }
+	}
+	require.NoError(t, telemetry.RegisterCircuitBreakers(tel.Meter("test"), states))
+
+	gauge, ok := tel.Metric(t, "gen_ai.client.circuit_breaker.state").Data.(metricdata.Gauge[int64])
+	require.True(t, ok)
+	got := map[string]int64{}
+	for _, dp := range gauge.DataPoints {
+		provider, _ := dp.Attributes.Value("gen_ai.provider.name")
+		got[provider.AsString()] = dp.Value
+	}
+	assert.Equal(t, map[string]int64{"openai": 2, "anthropic": 0}, got)
+}
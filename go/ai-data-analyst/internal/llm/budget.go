@@ -0,0 +1,312 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// Budget scopes, checked in this order.
+const (
+	BudgetScopeRequest = "request"
+	BudgetScopeSession = "session"
+	BudgetScopeDaily   = "daily"
+)
+
+var ErrBudgetExceeded = errors.New("LLM budget exceeded")
+
+// BudgetError reports which limit a call would have exceeded.
+type BudgetError struct {
+	Scope       string
+	LimitUSD    float64
+	SpentUSD    float64
+	EstimateUSD float64
+}
+
+func (e *BudgetError) Error() string {
+	return fmt.Sprintf("%s LLM budget of $%.4f exceeded: $%.4f spent, next call estimated at $%.4f",
+		e.Scope, e.LimitUSD, e.SpentUSD, e.EstimateUSD)
+}
+
+func (e *BudgetError) Unwrap() error { return ErrBudgetExceeded }
+
+// BudgetLimits are spending limits in USD. Zero means unlimited.
+type BudgetLimits struct {
+	PerRequestUSD float64
+	PerSessionUSD float64
+	DailyUSD      float64
+}
+
+func (l BudgetLimits) Enabled() bool {
+	return l.PerRequestUSD > 0 || l.PerSessionUSD > 0 || l.DailyUSD > 0
+}
+
+// BudgetStore persists session and daily spend. db.BudgetRepo implements
+// it. day is a UTC date, 2006-01-02; sessionID may be empty.
+type BudgetStore interface {
+	AddSpend(ctx context.Context, day, sessionID string, cost float64) error
+	Spend(ctx context.Context, day, sessionID string) (daily, session float64, err error)
+}
+
+// Budget tracks LLM spend per request, per session and per UTC day, and
+// turns away calls that would go over a limit. Spend is kept in memory, so
+// each replica enforces its own limits. With a Store, spend is also written
+// through and loaded the first time a day or session is seen, so a restart
+// carries on where the last process left off.
+type Budget struct {
+	Limits BudgetLimits
+
+	// Downgrade retries a call that would exceed a limit on the fast model
+	// before rejecting it.
+	Downgrade bool
+
+	// Store persists spend. Optional; while it fails, the budget works from
+	// memory and tries to load again on the next call.
+	Store BudgetStore
+
+	now func() time.Time
+
+	mu             sync.Mutex
+	fastModel      string
+	day            string
+	daySpent       float64
+	dayLoaded      bool
+	sessions       map[string]float64
+	sessionsLoaded map[string]bool
+}
+
+func NewBudget(limits BudgetLimits, fastModel string, downgrade bool) *Budget {
+	return &Budget{
+		Limits:    limits,
+		Downgrade: downgrade,
+		now:       time.Now,
+		fastModel: fastModel,
+		sessions:  make(map[string]float64),
+
+		sessionsLoaded: make(map[string]bool),
+	}
+}
+
+// SetFastModel changes the model over-budget calls are downgraded to.
+func (b *Budget) SetFastModel(model string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.fastModel = model
+}
+
+type budgetScopeKey struct{}
+
+// budgetScope is the spend of one request, which may make several calls.
+type budgetScope struct {
+	sessionID string
+
+	mu    sync.Mutex
+	spent float64
+}
+
+// WithBudgetScope starts a request scope: calls made with the returned
+// context share the per-request limit, and count against sessionID's limit
+// when it is set.
+func WithBudgetScope(ctx context.Context, sessionID string) context.Context {
+	return context.WithValue(ctx, budgetScopeKey{}, &budgetScope{sessionID: sessionID})
+}
+
+func scopeFrom(ctx context.Context) *budgetScope {
+	s, _ := ctx.Value(budgetScopeKey{}).(*budgetScope)
+	return s
+}
+
+// session returns the session the scope counts against, "" for none.
+func (s *budgetScope) session() string {
+	if s == nil {
+		return ""
+	}
+	return s.sessionID
+}
+
+// EstimateCost prices a call before it is made: about four characters per
+// input token, and the full MaxTokens of output.
+func EstimateCost(req GenerateRequest) float64 {
+	inputTokens := (len(req.System) + len(req.Prompt)) / 4
+	return CalculateCost(req.Model, inputTokens, req.MaxTokens)
+}
+
+// admit decides how a call may proceed. With no limit in the way it returns
+// req unchanged. Otherwise it returns the limit that was hit and, if the
+// call fits on the fast model, req downgraded to it.
+func (b *Budget) admit(ctx context.Context, req GenerateRequest) (GenerateRequest, *BudgetError, bool) {
+	scope := scopeFrom(ctx)
+	b.load(ctx, scope.session())
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.rollDay()
+
+	exceeded := b.check(scope, EstimateCost(req))
+	if exceeded == nil {
+		return req, nil, false
+	}
+	if b.Downgrade && b.fastModel != "" && req.Model != b.fastModel {
+		downgraded := req
+		downgraded.Model = b.fastModel
+		if b.check(scope, EstimateCost(downgraded)) == nil {
+			return downgraded, exceeded, true
+		}
+	}
+	return req, exceeded, false
+}
+
+func (b *Budget) check(scope *budgetScope, estimate float64) *BudgetError {
+	over := func(scopeName string, limit, spent float64) *BudgetError {
+		if limit > 0 && spent+estimate > limit {
+			return &BudgetError{Scope: scopeName, LimitUSD: limit, SpentUSD: spent, EstimateUSD: estimate}
+		}
+		return nil
+	}
+
+	if scope != nil {
+		scope.mu.Lock()
+		spent := scope.spent
+		scope.mu.Unlock()
+		if err := over(BudgetScopeRequest, b.Limits.PerRequestUSD, spent); err != nil {
+			return err
+		}
+		if scope.sessionID != "" {
+			if err := over(BudgetScopeSession, b.Limits.PerSessionUSD, b.sessions[scope.sessionID]); err != nil {
+				return err
+			}
+		}
+	}
+	return over(BudgetScopeDaily, b.Limits.DailyUSD, b.daySpent)
+}
+
+// record adds the cost of a completed call to every scope it belongs to.
+func (b *Budget) record(ctx context.Context, cost float64) {
+	scope := scopeFrom(ctx)
+	if scope != nil {
+		scope.mu.Lock()
+		scope.spent += cost
+		scope.mu.Unlock()
+	}
+
+	sessionID := scope.session()
+
+	b.mu.Lock()
+	b.rollDay()
+	b.daySpent += cost
+	if sessionID != "" {
+		b.sessions[sessionID] += cost
+	}
+	day := b.day
+	b.mu.Unlock()
+
+	if b.Store != nil {
+		if err := b.Store.AddSpend(context.WithoutCancel(ctx), day, sessionID, cost); err != nil {
+			log.Printf("LLM budget: failed to store spend: %v", err)
+		}
+	}
+}
+
+// load reads today's spend, and sessionID's when it is set, from the Store
+// the first time each is needed. Stored spend includes what this process
+// has written through, so the larger of the two is kept.
+func (b *Budget) load(ctx context.Context, sessionID string) {
+	if b.Store == nil {
+		return
+	}
+	b.mu.Lock()
+	b.rollDay()
+	day := b.day
+	needDay := !b.dayLoaded
+	needSession := sessionID != "" && !b.sessionsLoaded[sessionID]
+	b.mu.Unlock()
+	if !needDay && !needSession {
+		return
+	}
+
+	daily, session, err := b.Store.Spend(ctx, day, sessionID)
+	if err != nil {
+		log.Printf("LLM budget: failed to load spend: %v", err)
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.day != day {
+		return
+	}
+	if needDay && !b.dayLoaded {
+		b.daySpent = max(b.daySpent, daily)
+		b.dayLoaded = true
+	}
+	if needSession && !b.sessionsLoaded[sessionID] {
+		b.sessions[sessionID] = max(b.sessions[sessionID], session)
+		b.sessionsLoaded[sessionID] = true
+	}
+}
+
+// rollDay resets the daily spend at UTC midnight. Callers hold b.mu.
+func (b *Budget) rollDay() {
+	day := b.now().UTC().Format(time.DateOnly)
+	if day != b.day {
+		b.day = day
+		b.daySpent = 0
+		b.dayLoaded = false
+	}
+}
+
+// BudgetUsage is the spend against one limit. RemainingUSD is nil when the
+// scope has no limit.
+type BudgetUsage struct {
+	LimitUSD     float64  `json:"limit_usd"`
+	SpentUSD     float64  `json:"spent_usd"`
+	RemainingUSD *float64 `json:"remaining_usd"`
+}
+
+type BudgetStatus struct {
+	Day            string       `json:"day"`
+	ResetsAt       time.Time    `json:"resets_at"`
+	PerRequestUSD  float64      `json:"per_request_limit_usd"`
+	Daily          BudgetUsage  `json:"daily"`
+	Session        *BudgetUsage `json:"session,omitempty"`
+	Downgrade      bool         `json:"downgrade"`
+	DowngradeModel string       `json:"downgrade_model,omitempty"`
+}
+
+// Status reports today's spend, and sessionID's when it is set.
+func (b *Budget) Status(ctx context.Context, sessionID string) BudgetStatus {
+	b.load(ctx, sessionID)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.rollDay()
+
+	usage := func(limit, spent float64) BudgetUsage {
+		u := BudgetUsage{LimitUSD: limit, SpentUSD: spent}
+		if limit > 0 {
+			remaining := max(limit-spent, 0)
+			u.RemainingUSD = &remaining
+		}
+		return u
+	}
+
+	day, _ := time.Parse(time.DateOnly, b.day)
+	status := BudgetStatus{
+		Day:           b.day,
+		ResetsAt:      day.AddDate(0, 0, 1),
+		PerRequestUSD: b.Limits.PerRequestUSD,
+		Daily:         usage(b.Limits.DailyUSD, b.daySpent),
+		Downgrade:     b.Downgrade,
+	}
+	if b.Downgrade {
+		status.DowngradeModel = b.fastModel
+	}
+	if sessionID != "" {
+		s := usage(b.Limits.PerSessionUSD, b.sessions[sessionID])
+		status.Session = &s
+	}
+	return status
+}
@@ -0,0 +1,180 @@
+package llm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/base-14/examples/go/pkg/oteltest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// budgetResp costs $0.006 on gpt-4.1; testReq is estimated at about $0.0008.
+func budgetResp() *GenerateResponse {
+	return &GenerateResponse{Content: "SELECT 1", Model: "gpt-4.1", InputTokens: 1000, OutputTokens: 500}
+}
+
+func budgetExhausted(t *testing.T, tel *oteltest.Telemetry, scope, action string) int64 {
+	t.Helper()
+	return oteltest.Sum[int64](t, tel, "gen_ai.client.budget.exhausted",
+		attribute.String("gen_ai.budget.scope", scope),
+		attribute.String("gen_ai.budget.action", action),
+	)
+}
+
+func TestBudgetRejectsOverDailyLimit(t *testing.T) {
+	primary := &mockProvider{name: "openai", resp: budgetResp()}
+	client, tel := newTestClient(t, primary, nil)
+	client.Budget = NewBudget(BudgetLimits{DailyUSD: 0.005}, "gpt-4.1-mini", false)
+
+	_, err := client.Generate(context.Background(), testReq())
+	require.NoError(t, err)
+
+	_, err = client.Generate(context.Background(), testReq())
+	require.ErrorIs(t, err, ErrBudgetExceeded)
+	var budgetErr *BudgetError
+	require.ErrorAs(t, err, &budgetErr)
+	assert.Equal(t, BudgetScopeDaily, budgetErr.Scope)
+	assert.InDelta(t, 0.006, budgetErr.SpentUSD, 1e-9)
+	assert.Equal(t, 1, primary.calls)
+	assert.Equal(t, int64(1), budgetExhausted(t, tel, BudgetScopeDaily, "rejected"))
+}
+
+func TestBudgetDowngradesToFastModel(t *testing.T) {
+	primary := &mockProvider{name: "openai", resp: budgetResp()}
+	client, tel := newTestClient(t, primary, nil)
+	client.Budget = NewBudget(BudgetLimits{DailyUSD: 0.0005}, "gpt-4.1-mini", true)
+
+	_, err := client.Generate(context.Background(), testReq())
+	require.NoError(t, err)
+	assert.Equal(t, "gpt-4.1-mini", primary.lastModel)
+	assert.Equal(t, int64(1), budgetExhausted(t, tel, BudgetScopeDaily, "downgraded"))
+
+	// The first call spent the whole limit: not even the fast model fits.
+	_, err = client.Generate(context.Background(), testReq())
+	require.ErrorIs(t, err, ErrBudgetExceeded)
+	assert.Equal(t, 1, primary.calls)
+	assert.Equal(t, int64(1), budgetExhausted(t, tel, BudgetScopeDaily, "rejected"))
+}
+
+func TestBudgetPerRequestLimit(t *testing.T) {
+	primary := &mockProvider{name: "openai", resp: budgetResp()}
+	client, _ := newTestClient(t, primary, nil)
+	client.Budget = NewBudget(BudgetLimits{PerRequestUSD: 0.005}, "", true)
+
+	first := WithBudgetScope(context.Background(), "")
+	_, err := client.Generate(first, testReq())
+	require.NoError(t, err)
+
+	_, err = client.Generate(first, testReq())
+	var budgetErr *BudgetError
+	require.ErrorAs(t, err, &budgetErr)
+	assert.Equal(t, BudgetScopeRequest, budgetErr.Scope)
+
+	// A new request starts from zero.
+	_, err = client.Generate(WithBudgetScope(context.Background(), ""), testReq())
+	require.NoError(t, err)
+}
+
+func TestBudgetPerSessionLimit(t *testing.T) {
+	primary := &mockProvider{name: "openai", resp: budgetResp()}
+	client, _ := newTestClient(t, primary, nil)
+	client.Budget = NewBudget(BudgetLimits{PerSessionUSD: 0.005}, "", false)
+
+	_, err := client.Generate(WithBudgetScope(context.Background(), "s1"), testReq())
+	require.NoError(t, err)
+
+	_, err = client.Generate(WithBudgetScope(context.Background(), "s1"), testReq())
+	var budgetErr *BudgetError
+	require.ErrorAs(t, err, &budgetErr)
+	assert.Equal(t, BudgetScopeSession, budgetErr.Scope)
+
+	_, err = client.Generate(WithBudgetScope(context.Background(), "s2"), testReq())
+	require.NoError(t, err)
+
+	status := client.Budget.Status(context.Background(), "s1")
+	require.NotNil(t, status.Session)
+	assert.InDelta(t, 0.006, status.Session.SpentUSD, 1e-9)
+	require.NotNil(t, status.Session.RemainingUSD)
+	assert.Zero(t, *status.Session.RemainingUSD)
+	assert.Nil(t, status.Daily.RemainingUSD, "no daily limit is configured")
+	assert.InDelta(t, 0.012, status.Daily.SpentUSD, 1e-9)
+}
+
+func TestBudgetResetsDaily(t *testing.T) {
+	now := time.Date(2026, 3, 1, 23, 30, 0, 0, time.UTC)
+	b := NewBudget(BudgetLimits{DailyUSD: 1}, "", false)
+	b.now = func() time.Time { return now }
+
+	b.record(context.Background(), 0.25)
+	status := b.Status(context.Background(), "")
+	assert.Equal(t, "2026-03-01", status.Day)
+	assert.Equal(t, time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC), status.ResetsAt)
+	require.NotNil(t, status.Daily.RemainingUSD)
+	assert.InDelta(t, 0.75, *status.Daily.RemainingUSD, 1e-9)
+	assert.Nil(t, status.Session)
+
+	now = now.Add(time.Hour)
+	status = b.Status(context.Background(), "")
+	assert.Equal(t, "2026-03-02", status.Day)
+	assert.Zero(t, status.Daily.SpentUSD)
+}
+
+// memBudgetStore is a BudgetStore in maps, failing while err is set.
+type memBudgetStore struct {
+	err      error
+	daily    map[string]float64
+	sessions map[string]float64
+}
+
+func (s *memBudgetStore) AddSpend(_ context.Context, day, sessionID string, cost float64) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.daily[day] += cost
+	if sessionID != "" {
+		s.sessions[sessionID] += cost
+	}
+	return nil
+}
+
+func (s *memBudgetStore) Spend(_ context.Context, day, sessionID string) (float64, float64, error) {
+	return s.daily[day], s.sessions[sessionID], s.err
+}
+
+func TestBudgetStoreSurvivesRestart(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	store := &memBudgetStore{daily: map[string]float64{}, sessions: map[string]float64{}}
+
+	first := NewBudget(BudgetLimits{DailyUSD: 1, PerSessionUSD: 1}, "", false)
+	first.now = func() time.Time { return now }
+	first.Store = store
+	first.record(WithBudgetScope(context.Background(), "s1"), 0.25)
+	assert.InDelta(t, 0.25, store.daily["2026-03-01"], 1e-9)
+	assert.InDelta(t, 0.25, store.sessions["s1"], 1e-9)
+
+	restarted := NewBudget(BudgetLimits{DailyUSD: 1, PerSessionUSD: 1}, "", false)
+	restarted.now = func() time.Time { return now }
+	restarted.Store = store
+	restarted.record(context.Background(), 0.5)
+	status := restarted.Status(context.Background(), "s1")
+	assert.InDelta(t, 0.75, status.Daily.SpentUSD, 1e-9, "stored spend is loaded, not counted twice")
+	require.NotNil(t, status.Session)
+	assert.InDelta(t, 0.25, status.Session.SpentUSD, 1e-9)
+}
+
+func TestBudgetStoreFailureFallsBackToMemory(t *testing.T) {
+	store := &memBudgetStore{err: assert.AnError, daily: map[string]float64{"2026-03-01": 0.5}}
+	b := NewBudget(BudgetLimits{DailyUSD: 1}, "", false)
+	b.now = func() time.Time { return time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC) }
+	b.Store = store
+
+	b.record(context.Background(), 0.25)
+	assert.InDelta(t, 0.25, b.Status(context.Background(), "").Daily.SpentUSD, 1e-9)
+
+	store.err = nil
+	assert.InDelta(t, 0.5, b.Status(context.Background(), "").Daily.SpentUSD, 1e-9,
+		"the stored spend is loaded once the store recovers")
+}
@@ -2,10 +2,12 @@ package llm
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
 
+	"ai-data-analyst/internal/auth"
 	"ai-data-analyst/internal/telemetry"
 
 	"github.com/cenkalti/backoff/v5"
@@ -34,6 +36,7 @@ type GenerateResponse struct {
 
 type Provider interface {
 	Generate(ctx context.Context, req GenerateRequest) (*GenerateResponse, error)
+	GenerateStream(ctx context.Context, req GenerateRequest) (<-chan StreamChunk, error)
 	Name() string
 }
 
@@ -50,17 +53,46 @@ type Client struct {
 	// Off by default: message content is sensitive and increases span size and
 	// cost. Toggled via OTEL_INSTRUMENTATION_GENAI_CAPTURE_MESSAGE_CONTENT.
 	CaptureContent bool
+
+	// OpenInference also records each call with OpenInference attributes
+	// (openinference.span.kind, llm.*, input.value, ...), alongside gen_ai,
+	// for backends such as Arize Phoenix. Toggled via
+	// OPENINFERENCE_ATTRIBUTES.
+	OpenInference bool
+
+	// Budget limits spend per request, session and day. Optional.
+	Budget *Budget
+
+	// Cache answers prompts similar to earlier ones without calling a
+	// provider. Optional; needs a primary provider with embeddings.
+	Cache *SemanticCache
+
+	// Routes sends the calls of a stage to its own provider and model
+	// instead of the primary provider and the requested model. Optional;
+	// stages without a route keep the default.
+	Routes map[string]Route
 }
 
 func (c *Client) GenerateOnce(ctx context.Context, provider Provider, providerName string, req GenerateRequest) (*GenerateResponse, error) {
-	spanName := "gen_ai.chat " + req.Model
 	start := time.Now()
-
-	ctx, span := c.Tracer.Start(ctx, spanName)
+	ctx, span := c.startChat(ctx, provider, providerName, req)
 	defer span.End()
 
-	serverAddr := ProviderServers[providerName]
-	serverPort := ProviderPorts[providerName]
+	resp, err := provider.Generate(ctx, req)
+	if err != nil {
+		c.chatFailed(ctx, span, providerName, req, err)
+		return nil, err
+	}
+
+	c.chatDone(ctx, span, providerName, req, resp, time.Since(start).Seconds())
+	return resp, nil
+}
+
+// startChat opens the gen_ai.chat span for a call and records the request.
+func (c *Client) startChat(ctx context.Context, provider Provider, providerName string, req GenerateRequest) (context.Context, trace.Span) {
+	ctx, span := c.Tracer.Start(ctx, "gen_ai.chat "+req.Model)
+
+	serverAddr, serverPort := serverAddress(provider, providerName)
 
 	span.SetAttributes(
 		attribute.String("gen_ai.operation.name", "chat"),
@@ -75,6 +107,9 @@ func (c *Client) GenerateOnce(ctx context.Context, provider Provider, providerNa
 	if req.Stage != "" {
 		span.SetAttributes(attribute.String("nlsql.stage", req.Stage))
 	}
+	if c.OpenInference {
+		span.SetAttributes(openInferenceRequest(providerName, req, c.CaptureContent)...)
+	}
 
 	if c.CaptureContent {
 		span.AddEvent("gen_ai.user.message", trace.WithAttributes(
@@ -86,21 +121,26 @@ func (c *Client) GenerateOnce(ctx context.Context, provider Provider, providerNa
 			))
 		}
 	}
+	return ctx, span
+}
 
-	resp, err := provider.Generate(ctx, req)
-	duration := time.Since(start).Seconds()
-
-	if err != nil {
-		span.SetStatus(codes.Error, err.Error())
-		span.SetAttributes(attribute.String("error.type", classifyError(err)))
-		if c.Metrics != nil {
-			c.Metrics.ErrorCount.Add(ctx, 1,
-				telemetry.WithProviderModel(providerName, req.Model),
-			)
-		}
-		return nil, err
+func (c *Client) chatFailed(ctx context.Context, span trace.Span, providerName string, req GenerateRequest, err error) {
+	span.SetStatus(codes.Error, err.Error())
+	if c.OpenInference {
+		// OpenInference reads the error from an exception event.
+		span.RecordError(err)
+	}
+	span.SetAttributes(attribute.String("error.type", classifyError(err)))
+	if c.Metrics != nil {
+		c.Metrics.ErrorCount.Add(ctx, 1,
+			telemetry.WithProviderModel(providerName, req.Model),
+		)
 	}
+}
 
+// chatDone prices a completed call and records its usage on the span and
+// in the GenAI metrics.
+func (c *Client) chatDone(ctx context.Context, span trace.Span, providerName string, req GenerateRequest, resp *GenerateResponse, duration float64) {
 	resp.CostUSD = CalculateCost(resp.Model, resp.InputTokens, resp.OutputTokens)
 
 	span.SetAttributes(
@@ -112,6 +152,9 @@ func (c *Client) GenerateOnce(ctx context.Context, provider Provider, providerNa
 	if resp.FinishReason != "" {
 		span.SetAttributes(attribute.String("gen_ai.response.finish_reasons", resp.FinishReason))
 	}
+	if c.OpenInference {
+		span.SetAttributes(openInferenceResponse(resp, c.CaptureContent)...)
+	}
 
 	if c.CaptureContent {
 		span.AddEvent("gen_ai.assistant.message", trace.WithAttributes(
@@ -128,10 +171,9 @@ func (c *Client) GenerateOnce(ctx context.Context, provider Provider, providerNa
 			OutputTokens: resp.OutputTokens,
 			DurationSec:  duration,
 			CostUSD:      resp.CostUSD,
+			Tenant:       auth.TenantFrom(ctx),
 		})
 	}
-
-	return resp, nil
 }
 
 func (c *Client) GenerateWithRetry(ctx context.Context, provider Provider, providerName string, req GenerateRequest) (*GenerateResponse, error) {
@@ -143,6 +185,9 @@ func (c *Client) GenerateWithRetry(ctx context.Context, provider Provider, provi
 
 	resp, err := backoff.Retry(ctx, func() (*GenerateResponse, error) {
 		resp, err := c.GenerateOnce(ctx, provider, providerName, req)
+		if errors.Is(err, ErrCircuitOpen) {
+			return nil, backoff.Permanent(err)
+		}
 		if err != nil {
 			retries++
 			if c.Metrics != nil {
@@ -161,14 +206,92 @@ func (c *Client) GenerateWithRetry(ctx context.Context, provider Provider, provi
 	return resp, err
 }
 
+// Generate calls the stage's routed provider, or the primary one, then the
+// fallback if it keeps failing. With a Cache, a prompt close enough to an
+// earlier one gets that response without a provider call. With a Budget, a
+// call that would go over a limit is moved to the fast model or rejected
+// with a *BudgetError before any provider is called.
 func (c *Client) Generate(ctx context.Context, req GenerateRequest) (*GenerateResponse, error) {
-	resp, err := c.GenerateWithRetry(ctx, c.Primary, c.PrimaryProvider, req)
-	if err == nil {
-		return resp, nil
+	req, provider, providerName := c.route(ctx, req)
+	cached, vector := c.semanticLookup(ctx, req)
+	if cached != nil {
+		return cached, nil
+	}
+
+	admitted, provider, providerName, err := c.admit(ctx, req, provider, providerName)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.generate(ctx, provider, providerName, admitted)
+	if err != nil {
+		return nil, err
+	}
+	if c.Budget != nil {
+		c.Budget.record(ctx, resp.CostUSD)
+	}
+	c.semanticStore(admitted, vector, resp)
+	return resp, nil
+}
+
+// semanticStore caches resp under the request it answered. A request
+// downgraded by the Budget is stored under the fast model, so its answer is
+// not served to callers of the capable one.
+func (c *Client) semanticStore(req GenerateRequest, vector []float32, resp *GenerateResponse) {
+	if c.Cache != nil && vector != nil {
+		c.Cache.store(semanticScope(req), vector, resp)
+	}
+}
+
+// admit checks req against the Budget, returning it unchanged, downgraded
+// to the fast model, or a *BudgetError. The fast model is the primary
+// provider's, so a downgraded call leaves its stage's route.
+func (c *Client) admit(ctx context.Context, req GenerateRequest, provider Provider, providerName string) (GenerateRequest, Provider, string, error) {
+	if c.Budget == nil {
+		return req, provider, providerName, nil
+	}
+
+	admitted, exceeded, downgraded := c.Budget.admit(ctx, req)
+	if exceeded != nil {
+		action := "rejected"
+		if downgraded {
+			action = "downgraded"
+		}
+		trace.SpanFromContext(ctx).AddEvent("gen_ai.budget.exceeded", trace.WithAttributes(
+			attribute.String("gen_ai.budget.scope", exceeded.Scope),
+			attribute.String("gen_ai.budget.action", action),
+			attribute.String("gen_ai.request.model", req.Model),
+			attribute.Float64("gen_ai.budget.limit_usd", exceeded.LimitUSD),
+		))
+		if c.Metrics != nil {
+			c.Metrics.BudgetExhausted.Add(ctx, 1, telemetry.WithBudget(exceeded.Scope, action))
+		}
+		if !downgraded {
+			return req, provider, providerName, exceeded
+		}
+		return admitted, c.Primary, c.PrimaryProvider, nil
+	}
+	return admitted, provider, providerName, nil
+}
+
+// generate calls provider with retries, then the fallback. While
+// provider's circuit breaker is open it goes to the fallback directly.
+func (c *Client) generate(ctx context.Context, provider Provider, providerName string, req GenerateRequest) (*GenerateResponse, error) {
+	err := ErrCircuitOpen
+	if !c.skipOpen(ctx, provider, providerName) {
+		var resp *GenerateResponse
+		resp, err = c.GenerateWithRetry(ctx, provider, providerName, req)
+		if err == nil {
+			return resp, nil
+		}
 	}
 
 	if c.Fallback == nil {
-		return nil, fmt.Errorf("primary provider %s failed after retries: %w", c.PrimaryProvider, err)
+		role := "primary"
+		if provider != c.Primary {
+			role = "routed"
+		}
+		return nil, fmt.Errorf("%s provider %s failed after retries: %w", role, providerName, err)
 	}
 
 	if c.Metrics != nil {
@@ -182,10 +305,25 @@ func (c *Client) Generate(ctx context.Context, req GenerateRequest) (*GenerateRe
 	return c.GenerateWithRetry(ctx, c.Fallback, c.FallbackProviderName, fallbackReq)
 }
 
+// skipOpen reports whether provider's breaker is open, recording the
+// skipped call on the span in ctx.
+func (c *Client) skipOpen(ctx context.Context, provider Provider, providerName string) bool {
+	if !breakerOpen(provider) {
+		return false
+	}
+	trace.SpanFromContext(ctx).AddEvent("gen_ai.circuit_breaker.open", trace.WithAttributes(
+		attribute.String("gen_ai.provider.name", providerName),
+	))
+	return true
+}
+
 func classifyError(err error) string {
 	if err == nil {
 		return "unknown_error"
 	}
+	if errors.Is(err, ErrCircuitOpen) {
+		return "circuit_open"
+	}
 	msg := strings.ToLower(err.Error())
 	switch {
 	case strings.Contains(msg, "rate limit") || strings.Contains(msg, "429"):
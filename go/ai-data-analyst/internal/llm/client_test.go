@@ -3,13 +3,15 @@ package llm
 import (
 	"context"
 	"errors"
+	"strings"
 	"testing"
 
 	"ai-data-analyst/internal/telemetry"
 
+	"github.com/base-14/examples/go/pkg/oteltest"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
-	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 )
 
@@ -33,15 +35,30 @@ func (m *mockProvider) Generate(_ context.Context, req GenerateRequest) (*Genera
 	return m.resp, nil
 }
 
-func newTestClient(t *testing.T, primary, fallback Provider) (*Client, *tracetest.InMemoryExporter) {
+// GenerateStream sends the Generate response a word at a time.
+func (m *mockProvider) GenerateStream(ctx context.Context, req GenerateRequest) (<-chan StreamChunk, error) {
+	resp, err := m.Generate(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	words := strings.SplitAfter(resp.Content, " ")
+	chunks := make(chan StreamChunk, len(words)+1)
+	for _, w := range words {
+		chunks <- StreamChunk{Content: w}
+	}
+	final := *resp
+	final.Content = ""
+	chunks <- StreamChunk{Response: &final}
+	close(chunks)
+	return chunks, nil
+}
+
+func newTestClient(t *testing.T, primary, fallback Provider) (*Client, *oteltest.Telemetry) {
 	t.Helper()
-	exporter := tracetest.NewInMemoryExporter()
-	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
-	tracer := tp.Tracer("test")
+	tel := oteltest.New(t)
 
-	p, err := telemetry.Init(context.Background(), "test", "http://localhost:4318", "test")
-	require.NoError(t, err)
-	metrics, err := telemetry.NewGenAIMetrics(p.Meter)
+	metrics, err := telemetry.NewGenAIMetrics(tel.Meter("test"))
 	require.NoError(t, err)
 
 	primaryName := "openai"
@@ -61,12 +78,12 @@ func newTestClient(t *testing.T, primary, fallback Provider) (*Client, *tracetes
 	return &Client{
 		Primary:              primary,
 		Fallback:             fallback,
-		Tracer:               tracer,
+		Tracer:               tel.Tracer("test"),
 		Metrics:              metrics,
 		PrimaryProvider:      primaryName,
 		FallbackProviderName: fallbackName,
 		FallbackModel:        fallbackModel,
-	}, exporter
+	}, tel
 }
 
 func testReq() GenerateRequest {
@@ -90,7 +107,7 @@ func TestGenerateOnceSuccess(t *testing.T) {
 			OutputTokens: 5,
 		},
 	}
-	client, exporter := newTestClient(t, primary, nil)
+	client, tel := newTestClient(t, primary, nil)
 	req := testReq()
 
 	resp, err := client.GenerateOnce(context.Background(), primary, "openai", req)
@@ -100,26 +117,28 @@ func TestGenerateOnceSuccess(t *testing.T) {
 	assert.Greater(t, resp.CostUSD, 0.0)
 	assert.Equal(t, 1, primary.calls)
 
-	spans := exporter.GetSpans()
-	assert.Len(t, spans, 1)
-	assert.Equal(t, "gen_ai.chat gpt-4.1", spans[0].Name)
+	assert.Len(t, tel.Spans(), 1)
+	tel.Span(t, "gen_ai.chat gpt-4.1")
+
+	usage := oteltest.Histogram[float64](t, tel, "gen_ai.client.token.usage", attribute.String("gen_ai.token.type", "input"))
+	assert.Equal(t, 10.0, usage.Sum)
 }
 
 func TestGenerateOnceContentCaptureGate(t *testing.T) {
-	run := func(capture bool) *tracetest.InMemoryExporter {
+	run := func(capture bool) *oteltest.Telemetry {
 		primary := &mockProvider{
 			name: "openai",
 			resp: &GenerateResponse{Content: "Hello!", Model: "gpt-4.1", InputTokens: 10, OutputTokens: 5},
 		}
-		client, exporter := newTestClient(t, primary, nil)
+		client, tel := newTestClient(t, primary, nil)
 		client.CaptureContent = capture
 		_, err := client.GenerateOnce(context.Background(), primary, "openai", testReq())
 		require.NoError(t, err)
-		return exporter
+		return tel
 	}
 
-	hasContent := func(exporter *tracetest.InMemoryExporter) bool {
-		for _, span := range exporter.GetSpans() {
+	hasContent := func(tel *oteltest.Telemetry) bool {
+		for _, span := range tel.Spans() {
 			for _, ev := range span.Events {
 				for _, kv := range ev.Attributes {
 					switch string(kv.Key) {
@@ -141,6 +160,64 @@ func TestGenerateOnceContentCaptureGate(t *testing.T) {
 	})
 }
 
+func TestGenerateOnceOpenInference(t *testing.T) {
+	run := func(openInference, capture bool) tracetest.SpanStub {
+		primary := &mockProvider{
+			name: "openai",
+			resp: &GenerateResponse{Content: "Hello!", Model: "gpt-4.1", InputTokens: 10, OutputTokens: 5},
+		}
+		client, tel := newTestClient(t, primary, nil)
+		client.OpenInference = openInference
+		client.CaptureContent = capture
+		_, err := client.GenerateOnce(context.Background(), primary, "openai", testReq())
+		require.NoError(t, err)
+		return tel.Span(t, "gen_ai.chat gpt-4.1")
+	}
+
+	has := func(span tracetest.SpanStub, key string) bool {
+		for _, kv := range span.Attributes {
+			if string(kv.Key) == key {
+				return true
+			}
+		}
+		return false
+	}
+
+	t.Run("off records gen_ai only", func(t *testing.T) {
+		span := run(false, true)
+		assert.False(t, has(span, "openinference.span.kind"))
+		assert.True(t, has(span, "gen_ai.request.model"))
+	})
+
+	t.Run("on records both conventions", func(t *testing.T) {
+		span := run(true, false)
+		oteltest.AssertSpanAttributes(t, span,
+			attribute.String("gen_ai.request.model", "gpt-4.1"),
+			attribute.String("openinference.span.kind", "LLM"),
+			attribute.String("llm.provider", "openai"),
+			attribute.String("llm.system", "openai"),
+			attribute.String("llm.model_name", "gpt-4.1"),
+			attribute.Int("llm.token_count.prompt", 10),
+			attribute.Int("llm.token_count.completion", 5),
+			attribute.Int("llm.token_count.total", 15),
+		)
+		assert.False(t, has(span, "input.value"), "content must not be recorded when capture is off")
+		assert.False(t, has(span, "llm.input_messages.0.message.content"))
+	})
+
+	t.Run("on with capture records messages", func(t *testing.T) {
+		span := run(true, true)
+		oteltest.AssertSpanAttributes(t, span,
+			attribute.String("llm.input_messages.0.message.role", "system"),
+			attribute.String("llm.input_messages.1.message.role", "user"),
+			attribute.String("llm.input_messages.1.message.content", "Say hello"),
+			attribute.String("input.value", "Say hello"),
+			attribute.String("llm.output_messages.0.message.content", "Hello!"),
+			attribute.String("output.value", "Hello!"),
+		)
+	})
+}
+
 func TestGenerateWithRetrySuccess(t *testing.T) {
 	primary := &mockProvider{
 		name:    "openai",
@@ -189,14 +266,25 @@ func TestGenerateWithFallback(t *testing.T) {
 			OutputTokens: 5,
 		},
 	}
-	client, _ := newTestClient(t, primary, fallback)
+	client, tel := newTestClient(t, primary, fallback)
 
-	resp, err := client.Generate(context.Background(), testReq())
+	ctx, parent := tel.Tracer("test").Start(context.Background(), "ask")
+	resp, err := client.Generate(ctx, testReq())
+	parent.End()
 	require.NoError(t, err)
 	assert.Equal(t, "Fallback response", resp.Content)
 	assert.Equal(t, 3, primary.calls)
 	assert.Equal(t, 1, fallback.calls)
 	assert.Equal(t, "claude-haiku-4-5-20251001", fallback.lastModel, "fallback should use FallbackModel, not the primary model")
+
+	tel.AssertSpanTree(t, oteltest.SpanTree{
+		Name: "ask",
+		Children: []oteltest.SpanTree{
+			{Name: "gen_ai.chat gpt-4.1"},
+			{Name: "gen_ai.chat claude-haiku-4-5-20251001"},
+		},
+	})
+	assert.Equal(t, int64(1), oteltest.Sum[int64](t, tel, "gen_ai.client.fallback.count"))
 }
 
 func TestClassifyError(t *testing.T) {
@@ -245,3 +333,52 @@ func TestGenerateNoFallbackReturnsError(t *testing.T) {
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "primary provider")
 }
+
+type mockEmbedder struct {
+	mockProvider
+	dims int
+}
+
+func (m *mockEmbedder) Embed(_ context.Context, req EmbedRequest) (*EmbedResponse, error) {
+	vectors := make([][]float32, len(req.Input))
+	for i := range vectors {
+		vectors[i] = make([]float32, m.dims)
+	}
+	return &EmbedResponse{Vectors: vectors, Model: req.Model, InputTokens: 4 * len(req.Input)}, nil
+}
+
+func TestEmbed(t *testing.T) {
+	primary := &mockEmbedder{mockProvider: mockProvider{name: "openai"}, dims: 3}
+	client, tel := newTestClient(t, primary, nil)
+	require.True(t, client.CanEmbed())
+
+	resp, err := client.Embed(context.Background(), EmbedRequest{
+		Model: "text-embedding-3-small",
+		Input: []string{"gdp growth", "population"},
+		Stage: "retrieve_schema",
+	})
+	require.NoError(t, err)
+	assert.Len(t, resp.Vectors, 2)
+	assert.Len(t, resp.Vectors[0], 3)
+
+	span := tel.Span(t, "gen_ai.embeddings text-embedding-3-small")
+	oteltest.AssertSpanAttributes(t, span,
+		attribute.String("gen_ai.operation.name", "embeddings"),
+		attribute.Int("gen_ai.embeddings.dimension.count", 3),
+		attribute.Int("gen_ai.usage.input_tokens", 8),
+	)
+
+	usage := oteltest.Histogram[float64](t, tel, "gen_ai.client.token.usage",
+		attribute.String("gen_ai.operation.name", "embeddings"),
+		attribute.String("gen_ai.token.type", "input"),
+	)
+	assert.Equal(t, 8.0, usage.Sum)
+}
+
+func TestEmbedUnsupportedProvider(t *testing.T) {
+	client, _ := newTestClient(t, &mockProvider{name: "anthropic"}, nil)
+	assert.False(t, client.CanEmbed())
+
+	_, err := client.Embed(context.Background(), EmbedRequest{Model: "m", Input: []string{"x"}})
+	assert.ErrorIs(t, err, ErrEmbeddingsUnsupported)
+}
@@ -0,0 +1,129 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"ai-data-analyst/internal/auth"
+	"ai-data-analyst/internal/telemetry"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// ErrEmbeddingsUnsupported is returned by Client.Embed when the primary
+// provider has no embeddings API.
+var ErrEmbeddingsUnsupported = errors.New("provider does not support embeddings")
+
+type EmbedRequest struct {
+	Model string
+	Input []string
+	Stage string
+}
+
+type EmbedResponse struct {
+	Vectors     [][]float32
+	Model       string
+	InputTokens int
+}
+
+// Embedder is implemented by providers with an embeddings API. Anthropic has
+// none, so embeddings always use the primary provider and never fall back.
+type Embedder interface {
+	Embed(ctx context.Context, req EmbedRequest) (*EmbedResponse, error)
+}
+
+// DefaultEmbeddingModels is the embedding model used per provider when
+// EMBEDDING_MODEL is not set.
+var DefaultEmbeddingModels = map[string]string{
+	"openai": "text-embedding-3-small",
+	"azure":  "text-embedding-3-small",
+	"google": "text-embedding-004",
+	"ollama": "nomic-embed-text",
+}
+
+// CanEmbed reports whether the primary provider supports embeddings.
+func (c *Client) CanEmbed() bool {
+	_, ok := unwrap(c.Primary).(Embedder)
+	return ok
+}
+
+// Embed returns one vector per input, in order.
+func (c *Client) Embed(ctx context.Context, req EmbedRequest) (*EmbedResponse, error) {
+	embedder, ok := unwrap(c.Primary).(Embedder)
+	if !ok {
+		return nil, ErrEmbeddingsUnsupported
+	}
+
+	start := time.Now()
+	ctx, span := c.Tracer.Start(ctx, "gen_ai.embeddings "+req.Model)
+	defer span.End()
+
+	serverAddr, serverPort := serverAddress(c.Primary, c.PrimaryProvider)
+
+	span.SetAttributes(
+		attribute.String("gen_ai.operation.name", "embeddings"),
+		attribute.String("gen_ai.provider.name", c.PrimaryProvider),
+		attribute.String("gen_ai.request.model", req.Model),
+		attribute.String("server.address", serverAddr),
+		attribute.Int("server.port", serverPort),
+		attribute.Int("gen_ai.embeddings.input_count", len(req.Input)),
+	)
+	if req.Stage != "" {
+		span.SetAttributes(attribute.String("nlsql.stage", req.Stage))
+	}
+	if c.OpenInference {
+		span.SetAttributes(openInferenceEmbedding(req, c.CaptureContent)...)
+	}
+
+	resp, err := embedder.Embed(ctx, req)
+	if err == nil && len(resp.Vectors) != len(req.Input) {
+		err = fmt.Errorf("embeddings: got %d vectors for %d inputs", len(resp.Vectors), len(req.Input))
+	}
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		if c.OpenInference {
+			span.RecordError(err)
+		}
+		span.SetAttributes(attribute.String("error.type", classifyError(err)))
+		if c.Metrics != nil {
+			c.Metrics.ErrorCount.Add(ctx, 1,
+				telemetry.WithProviderModel(c.PrimaryProvider, req.Model),
+			)
+		}
+		return nil, err
+	}
+
+	cost := CalculateCost(resp.Model, resp.InputTokens, 0)
+	span.SetAttributes(
+		attribute.String("gen_ai.response.model", resp.Model),
+		attribute.Int("gen_ai.usage.input_tokens", resp.InputTokens),
+		attribute.Float64("gen_ai.usage.cost_usd", cost),
+	)
+	if len(resp.Vectors) > 0 {
+		span.SetAttributes(attribute.Int("gen_ai.embeddings.dimension.count", len(resp.Vectors[0])))
+	}
+	if c.OpenInference {
+		span.SetAttributes(
+			attribute.Int("llm.token_count.prompt", resp.InputTokens),
+			attribute.Int("llm.token_count.total", resp.InputTokens),
+		)
+	}
+
+	if c.Metrics != nil {
+		c.Metrics.RecordGenAIMetrics(ctx, telemetry.RecordParams{
+			Operation:   "embeddings",
+			Provider:    c.PrimaryProvider,
+			Model:       resp.Model,
+			Stage:       req.Stage,
+			InputTokens: resp.InputTokens,
+			DurationSec: time.Since(start).Seconds(),
+			CostUSD:     cost,
+			Tenant:      auth.TenantFrom(ctx),
+		})
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,228 @@
+package llm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+var (
+	errNotChecked = errors.New("required models not checked yet")
+
+	ErrModelNotInstalled = errors.New("model not installed")
+)
+
+type OllamaModel struct {
+	Name       string             `json:"name"`
+	Size       int64              `json:"size"`
+	Digest     string             `json:"digest"`
+	ModifiedAt time.Time          `json:"modified_at"`
+	Details    OllamaModelDetails `json:"details"`
+}
+
+// OllamaModelDetails is what Ollama reports of a model's build, such as
+// "8.0B" parameters quantized to "Q4_K_M".
+type OllamaModelDetails struct {
+	Format            string `json:"format,omitempty"`
+	Family            string `json:"family,omitempty"`
+	ParameterSize     string `json:"parameter_size,omitempty"`
+	QuantizationLevel string `json:"quantization_level,omitempty"`
+}
+
+// PullProgress is one line of the NDJSON stream returned by Ollama's /api/pull.
+type PullProgress struct {
+	Status    string `json:"status"`
+	Digest    string `json:"digest,omitempty"`
+	Total     int64  `json:"total,omitempty"`
+	Completed int64  `json:"completed,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// OllamaAdmin talks to Ollama's native API (not the OpenAI-compatible /v1)
+// to list and pull models, and tracks whether the models the pipeline needs
+// are present.
+type OllamaAdmin struct {
+	baseURL  string
+	http     *http.Client
+	required []string
+
+	mu      sync.RWMutex
+	missing []string
+	err     error
+}
+
+func NewOllamaAdmin(baseURL string, required ...string) *OllamaAdmin {
+	return &OllamaAdmin{
+		baseURL:  strings.TrimRight(baseURL, "/"),
+		http:     &http.Client{Transport: otelhttp.NewTransport(http.DefaultTransport)},
+		required: dedupe(required),
+	}
+}
+
+func (o *OllamaAdmin) ListModels(ctx context.Context) ([]OllamaModel, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, o.baseURL+"/api/tags", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := o.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("ollama unreachable: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ollama /api/tags: %s", resp.Status)
+	}
+
+	var body struct {
+		Models []OllamaModel `json:"models"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+	return body.Models, nil
+}
+
+// Model returns the installed model called name, which may leave out a
+// ":latest" tag, or ErrModelNotInstalled.
+func (o *OllamaAdmin) Model(ctx context.Context, name string) (*OllamaModel, error) {
+	models, err := o.ListModels(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range models {
+		if m.Name == name || m.Name == name+":latest" {
+			return &m, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrModelNotInstalled, name)
+}
+
+// NotInstalled returns the models in names that are not installed.
+func (o *OllamaAdmin) NotInstalled(ctx context.Context, names ...string) ([]string, error) {
+	models, err := o.ListModels(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return missingModels(dedupe(names), models), nil
+}
+
+// Pull downloads a model, calling onProgress for every status line Ollama
+// streams back. The required-model check is refreshed once the pull succeeds.
+func (o *OllamaAdmin) Pull(ctx context.Context, model string, onProgress func(PullProgress) error) error {
+	payload, _ := json.Marshal(map[string]any{"model": model, "stream": true})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.baseURL+"/api/pull", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	// Pulls can take many minutes; rely on the request context for cancellation.
+	resp, err := o.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("ollama unreachable: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("ollama /api/pull: %s", resp.Status)
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		var p PullProgress
+		if err := json.Unmarshal(scanner.Bytes(), &p); err != nil {
+			continue
+		}
+		if p.Error != "" {
+			return fmt.Errorf("pull %s: %s", model, p.Error)
+		}
+		if onProgress != nil {
+			if err := onProgress(p); err != nil {
+				return err
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	o.Check(ctx)
+	return nil
+}
+
+// SetRequired replaces the models the pipeline depends on, e.g. after a
+// runtime config change, and forces the next Missing call to re-check.
+func (o *OllamaAdmin) SetRequired(models ...string) {
+	o.mu.Lock()
+	o.required = dedupe(models)
+	o.missing, o.err = nil, errNotChecked
+	o.mu.Unlock()
+}
+
+// Check compares the installed models against the required ones and caches
+// the result for Missing.
+func (o *OllamaAdmin) Check(ctx context.Context) ([]string, error) {
+	models, err := o.ListModels(ctx)
+
+	o.mu.RLock()
+	required := o.required
+	o.mu.RUnlock()
+
+	var missing []string
+	if err == nil {
+		missing = missingModels(required, models)
+	}
+
+	o.mu.Lock()
+	o.missing, o.err = missing, err
+	o.mu.Unlock()
+	return missing, err
+}
+
+// Missing returns the required models that were absent at the last check,
+// or the error that prevented checking.
+func (o *OllamaAdmin) Missing() ([]string, error) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	return o.missing, o.err
+}
+
+func missingModels(required []string, installed []OllamaModel) []string {
+	have := make(map[string]bool, len(installed)*2)
+	for _, m := range installed {
+		have[m.Name] = true
+		// Ollama reports untagged pulls as "name:latest".
+		if name, tag, ok := strings.Cut(m.Name, ":"); ok && tag == "latest" {
+			have[name] = true
+		}
+	}
+
+	var missing []string
+	for _, r := range required {
+		if !have[r] {
+			missing = append(missing, r)
+		}
+	}
+	return missing
+}
+
+func dedupe(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	var out []string
+	for _, v := range values {
+		if v == "" || seen[v] {
+			continue
+		}
+		seen[v] = true
+		out = append(out, v)
+	}
+	return out
+}
@@ -0,0 +1,99 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newFakeOllama(t *testing.T, installed ...string) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/tags", func(w http.ResponseWriter, _ *http.Request) {
+		models := make([]OllamaModel, 0, len(installed))
+		for _, name := range installed {
+			models = append(models, OllamaModel{
+				Name:    name,
+				Size:    2019393189,
+				Details: OllamaModelDetails{Format: "gguf", Family: "llama", ParameterSize: "3.2B", QuantizationLevel: "Q4_K_M"},
+			})
+		}
+		json.NewEncoder(w).Encode(map[string]any{"models": models})
+	})
+	mux.HandleFunc("POST /api/pull", func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Model string `json:"model"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		enc := json.NewEncoder(w)
+		enc.Encode(PullProgress{Status: "pulling manifest"})
+		enc.Encode(PullProgress{Status: "downloading", Total: 100, Completed: 50})
+		enc.Encode(PullProgress{Status: "success"})
+		installed = append(installed, req.Model)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestOllamaAdmin_CheckReportsMissing(t *testing.T) {
+	srv := newFakeOllama(t, "llama3.2:latest")
+	admin := NewOllamaAdmin(srv.URL, "llama3.2", "qwen2.5-coder:7b")
+
+	missing, err := admin.Check(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"qwen2.5-coder:7b"}, missing)
+
+	cached, err := admin.Missing()
+	require.NoError(t, err)
+	assert.Equal(t, missing, cached)
+}
+
+func TestOllamaAdmin_PullStreamsProgressAndRefreshes(t *testing.T) {
+	srv := newFakeOllama(t)
+	admin := NewOllamaAdmin(srv.URL, "llama3.2")
+	_, err := admin.Check(context.Background())
+	require.NoError(t, err)
+
+	var statuses []string
+	err = admin.Pull(context.Background(), "llama3.2", func(p PullProgress) error {
+		statuses = append(statuses, p.Status)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"pulling manifest", "downloading", "success"}, statuses)
+
+	missing, err := admin.Missing()
+	require.NoError(t, err)
+	assert.Empty(t, missing)
+}
+
+func TestOllamaAdmin_Unreachable(t *testing.T) {
+	admin := NewOllamaAdmin("http://127.0.0.1:1", "llama3.2")
+	_, err := admin.Check(context.Background())
+	assert.Error(t, err)
+}
+
+func TestOllamaAdmin_ModelReportsDetails(t *testing.T) {
+	srv := newFakeOllama(t, "llama3.2:latest", "qwen2.5-coder:7b")
+	admin := NewOllamaAdmin(srv.URL)
+
+	model, err := admin.Model(context.Background(), "llama3.2")
+	require.NoError(t, err)
+	assert.Equal(t, "llama3.2:latest", model.Name)
+	assert.Equal(t, int64(2019393189), model.Size)
+	assert.Equal(t, "Q4_K_M", model.Details.QuantizationLevel)
+	assert.Equal(t, "3.2B", model.Details.ParameterSize)
+
+	_, err = admin.Model(context.Background(), "mistral")
+	assert.ErrorIs(t, err, ErrModelNotInstalled)
+
+	missing, err := admin.NotInstalled(context.Background(), "qwen2.5-coder:7b", "mistral", "mistral")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"mistral"}, missing)
+}
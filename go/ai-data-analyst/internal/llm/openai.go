@@ -2,6 +2,8 @@ package llm
 
 import (
 	"context"
+	"errors"
+	"io"
 
 	openai "github.com/sashabaranov/go-openai"
 )
@@ -56,3 +58,88 @@ func (p *OpenAIProvider) Generate(ctx context.Context, req GenerateRequest) (*Ge
 		FinishReason: finishReason,
 	}, nil
 }
+
+func (p *OpenAIProvider) GenerateStream(ctx context.Context, req GenerateRequest) (<-chan StreamChunk, error) {
+	stream, err := p.client.CreateChatCompletionStream(ctx, openai.ChatCompletionRequest{
+		Model: req.Model,
+		Messages: []openai.ChatCompletionMessage{
+			{Role: openai.ChatMessageRoleSystem, Content: req.System},
+			{Role: openai.ChatMessageRoleUser, Content: req.Prompt},
+		},
+		Temperature:   float32(req.Temperature),
+		MaxTokens:     req.MaxTokens,
+		Stream:        true,
+		StreamOptions: &openai.StreamOptions{IncludeUsage: true},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	chunks := make(chan StreamChunk)
+	go func() {
+		defer close(chunks)
+		defer stream.Close()
+
+		final := &GenerateResponse{Model: req.Model}
+		for {
+			resp, err := stream.Recv()
+			if errors.Is(err, io.EOF) {
+				sendChunk(ctx, chunks, StreamChunk{Response: final})
+				return
+			}
+			if err != nil {
+				sendChunk(ctx, chunks, StreamChunk{Err: err})
+				return
+			}
+
+			if resp.Model != "" {
+				final.Model = resp.Model
+			}
+			// Usage arrives on a last chunk with no choices.
+			if resp.Usage != nil {
+				final.InputTokens = resp.Usage.PromptTokens
+				final.OutputTokens = resp.Usage.CompletionTokens
+			}
+			if len(resp.Choices) == 0 {
+				continue
+			}
+			if reason := resp.Choices[0].FinishReason; reason != "" {
+				final.FinishReason = string(reason)
+			}
+			if delta := resp.Choices[0].Delta.Content; delta != "" {
+				if !sendChunk(ctx, chunks, StreamChunk{Content: delta}) {
+					return
+				}
+			}
+		}
+	}()
+	return chunks, nil
+}
+
+func (p *OpenAIProvider) Embed(ctx context.Context, req EmbedRequest) (*EmbedResponse, error) {
+	resp, err := p.client.CreateEmbeddings(ctx, openai.EmbeddingRequestStrings{
+		Input: req.Input,
+		Model: openai.EmbeddingModel(req.Model),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	vectors := make([][]float32, len(resp.Data))
+	for _, d := range resp.Data {
+		if d.Index < 0 || d.Index >= len(vectors) {
+			continue
+		}
+		vectors[d.Index] = d.Embedding
+	}
+
+	model := string(resp.Model)
+	if model == "" {
+		model = req.Model
+	}
+	return &EmbedResponse{
+		Vectors:     vectors,
+		Model:       model,
+		InputTokens: resp.Usage.PromptTokens,
+	}, nil
+}
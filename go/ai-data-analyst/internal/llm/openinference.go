@@ -0,0 +1,110 @@
+package llm
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// OpenInference span kinds, for backends such as Arize Phoenix that read
+// OpenInference attributes instead of the gen_ai semantic conventions.
+const (
+	spanKindLLM       = "LLM"
+	spanKindEmbedding = "EMBEDDING"
+)
+
+// openInferenceProvider maps a gen_ai.provider.name to OpenInference's
+// llm.provider and llm.system. Providers OpenInference has no name for keep
+// their own and report no system.
+func openInferenceProvider(providerName string) (provider, system string) {
+	switch providerName {
+	case "openai":
+		return "openai", "openai"
+	case ProviderAzureOpenAI:
+		return "azure", "openai"
+	case "anthropic":
+		return "anthropic", "anthropic"
+	case ProviderBedrock:
+		return "aws", ""
+	default:
+		return providerName, ""
+	}
+}
+
+// openInferenceRequest is the OpenInference form of a chat request. The
+// messages are included only when content capture is on, as for gen_ai.
+func openInferenceRequest(providerName string, req GenerateRequest, capture bool) []attribute.KeyValue {
+	provider, system := openInferenceProvider(providerName)
+	params, _ := json.Marshal(map[string]any{
+		"temperature": req.Temperature,
+		"max_tokens":  req.MaxTokens,
+	})
+	attrs := []attribute.KeyValue{
+		attribute.String("openinference.span.kind", spanKindLLM),
+		attribute.String("llm.provider", provider),
+		attribute.String("llm.model_name", req.Model),
+		attribute.String("llm.invocation_parameters", string(params)),
+	}
+	if system != "" {
+		attrs = append(attrs, attribute.String("llm.system", system))
+	}
+	if !capture {
+		return attrs
+	}
+
+	i := 0
+	message := func(role, content string) {
+		prefix := "llm.input_messages." + strconv.Itoa(i) + ".message."
+		attrs = append(attrs,
+			attribute.String(prefix+"role", role),
+			attribute.String(prefix+"content", content),
+		)
+		i++
+	}
+	if req.System != "" {
+		message("system", truncate(req.System, 500))
+	}
+	message("user", truncate(req.Prompt, 1000))
+	return append(attrs,
+		attribute.String("input.value", truncate(req.Prompt, 1000)),
+		attribute.String("input.mime_type", "text/plain"),
+	)
+}
+
+// openInferenceResponse is the OpenInference form of a chat response.
+func openInferenceResponse(resp *GenerateResponse, capture bool) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{
+		attribute.String("llm.model_name", resp.Model),
+		attribute.Int("llm.token_count.prompt", resp.InputTokens),
+		attribute.Int("llm.token_count.completion", resp.OutputTokens),
+		attribute.Int("llm.token_count.total", resp.InputTokens+resp.OutputTokens),
+		attribute.Float64("llm.cost.total", resp.CostUSD),
+	}
+	if !capture {
+		return attrs
+	}
+	content := truncate(resp.Content, 2000)
+	return append(attrs,
+		attribute.String("llm.output_messages.0.message.role", "assistant"),
+		attribute.String("llm.output_messages.0.message.content", content),
+		attribute.String("output.value", content),
+		attribute.String("output.mime_type", "text/plain"),
+	)
+}
+
+// openInferenceEmbedding is the OpenInference form of an embeddings call.
+func openInferenceEmbedding(req EmbedRequest, capture bool) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{
+		attribute.String("openinference.span.kind", spanKindEmbedding),
+		attribute.String("embedding.model_name", req.Model),
+	}
+	if !capture {
+		return attrs
+	}
+	for i, text := range req.Input {
+		attrs = append(attrs, attribute.String(
+			"embedding.embeddings."+strconv.Itoa(i)+".embedding.text", truncate(text, 500)))
+	}
+	return attrs
+}
@@ -59,14 +59,18 @@ func findRelativePricing() string {
 }
 
 // normalizeModel maps a provider's dated snapshot ("gpt-4.1-2025-04-14",
-// "claude-haiku-4-5-20251001") back to the canonical pricing.json key.
+// "claude-haiku-4-5-20251001") or Bedrock model ID
+// ("us.anthropic.claude-haiku-4-5-20251001-v1:0") back to the canonical
+// pricing.json key.
 var (
+	bedrockModelID   = regexp.MustCompile(`^(?:(?:us|eu|apac|global)\.)?(?:anthropic|amazon|meta|mistral|cohere|ai21)\.(.+?)-v\d+(?::\d+)?$`)
 	dateSuffix       = regexp.MustCompile(`-\d{4}-\d{2}-\d{2}$|-\d{8}$`)
 	anthropicVersion = regexp.MustCompile(`-(\d+)-(\d+)$`)
 )
 
 func normalizeModel(model string) string {
-	m := dateSuffix.ReplaceAllString(model, "")
+	m := bedrockModelID.ReplaceAllString(model, "$1")
+	m = dateSuffix.ReplaceAllString(m, "")
 	return anthropicVersion.ReplaceAllString(m, "-$1.$2")
 }
 
@@ -89,6 +93,16 @@ var ProviderServers = map[string]string{
 	"ollama":    "localhost",
 }
 
+// serverAddress is where a provider's API is served: from the provider
+// itself when the host varies per deployment, as with Azure, otherwise the
+// well-known host for providerName.
+func serverAddress(provider Provider, providerName string) (string, int) {
+	if s, ok := unwrap(provider).(interface{ ServerAddress() (string, int) }); ok {
+		return s.ServerAddress()
+	}
+	return ProviderServers[providerName], ProviderPorts[providerName]
+}
+
 var ProviderPorts = map[string]int{
 	"openai":    443,
 	"anthropic": 443,
@@ -34,6 +34,17 @@ func TestCalculateCostDatedSnapshot(t *testing.T) {
 	assert.InDelta(t, CalculateCost("claude-haiku-4.5", 1000, 500), anthropic, 0.0001)
 }
 
+func TestCalculateCostBedrockModelID(t *testing.T) {
+	for _, id := range []string{
+		"anthropic.claude-haiku-4-5-20251001-v1:0",
+		"us.anthropic.claude-haiku-4-5-20251001-v1:0",
+		"global.anthropic.claude-haiku-4-5-20251001-v1:0",
+	} {
+		assert.InDelta(t, CalculateCost("claude-haiku-4.5", 1000, 500), CalculateCost(id, 1000, 500), 0.0001, id)
+	}
+	assert.Equal(t, "titan-text-express", normalizeModel("amazon.titan-text-express-v1"))
+}
+
 func TestProviderPorts(t *testing.T) {
 	assert.Equal(t, 443, ProviderPorts["openai"])
 	assert.Equal(t, 443, ProviderPorts["anthropic"])
@@ -0,0 +1,108 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"ai-data-analyst/internal/config"
+	"ai-data-analyst/internal/telemetry"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+var errUnknownProvider = errors.New("unknown provider")
+
+// NewClient builds the client for cfg's LLM_PROVIDER, with the Anthropic
+// fallback when FALLBACK_PROVIDER=anthropic and a key is set, and the stage
+// routes in LLM_STAGE_MODELS. With LLM_BREAKER_ENABLED each provider is
+// wrapped in a BreakerProvider. Budgets are left to the caller.
+func NewClient(ctx context.Context, cfg *config.Config, tracer trace.Tracer, metrics *telemetry.GenAIMetrics) (*Client, error) {
+	primary, primaryName, err := newProvider(ctx, cfg, cfg.LLMProvider)
+	if errors.Is(err, errUnknownProvider) {
+		primary, primaryName, err = withBreaker(cfg, NewOpenAIProvider(cfg.OpenAIAPIKey), cfg.LLMProvider), cfg.LLMProvider, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	built := map[string]Route{
+		cfg.LLMProvider: {Provider: primary, ProviderName: primaryName},
+	}
+	var fallback Provider
+	if cfg.FallbackProvider == "anthropic" && cfg.AnthropicAPIKey != "" {
+		fallback = withBreaker(cfg, NewAnthropicProvider(cfg.AnthropicAPIKey), cfg.FallbackProvider)
+		if _, ok := built[cfg.FallbackProvider]; !ok {
+			// Routes to the fallback share its breaker.
+			built[cfg.FallbackProvider] = Route{Provider: fallback, ProviderName: cfg.FallbackProvider}
+		}
+	}
+
+	routes, err := newRoutes(ctx, cfg, built)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{
+		Primary:              primary,
+		Fallback:             fallback,
+		Tracer:               tracer,
+		Metrics:              metrics,
+		PrimaryProvider:      primaryName,
+		FallbackProviderName: cfg.FallbackProvider,
+		FallbackModel:        cfg.FallbackModel,
+		CaptureContent:       cfg.CaptureContent,
+		OpenInference:        cfg.OpenInference,
+		Routes:               routes,
+	}, nil
+}
+
+// newProvider builds the provider called name in cfg, behind its breaker,
+// returning it with the name its telemetry reports.
+func newProvider(ctx context.Context, cfg *config.Config, name string) (Provider, string, error) {
+	p, name, err := newBareProvider(ctx, cfg, name)
+	if err != nil {
+		return nil, "", err
+	}
+	return withBreaker(cfg, p, name), name, nil
+}
+
+// withBreaker wraps p in a BreakerProvider unless LLM_BREAKER_ENABLED is off.
+func withBreaker(cfg *config.Config, p Provider, name string) Provider {
+	if !cfg.LLMBreakerEnabled {
+		return p
+	}
+	return NewBreakerProvider(p, name, BreakerConfig{
+		ErrorRate: cfg.LLMBreakerErrorRate,
+		MinCalls:  cfg.LLMBreakerMinCalls,
+		Window:    cfg.LLMBreakerWindow,
+		Cooldown:  cfg.LLMBreakerCooldown,
+	})
+}
+
+func newBareProvider(ctx context.Context, cfg *config.Config, name string) (Provider, string, error) {
+	switch name {
+	case "openai":
+		return NewOpenAIProvider(cfg.OpenAIAPIKey), name, nil
+	case "anthropic":
+		return NewAnthropicProvider(cfg.AnthropicAPIKey), name, nil
+	case "ollama":
+		return NewOllamaProvider(cfg.OllamaBaseURL), name, nil
+	case "google":
+		return NewGoogleProvider(cfg.GoogleAPIKey), name, nil
+	case "azure":
+		if cfg.AzureEndpoint == "" {
+			return nil, "", errors.New("AZURE_OPENAI_ENDPOINT is required for provider azure")
+		}
+		azure := NewAzureOpenAIProvider(cfg.AzureEndpoint, cfg.AzureAPIKey, cfg.AzureAPIVersion,
+			ParseDeployments(cfg.AzureDeployments))
+		return azure, azure.Name(), nil
+	case "bedrock":
+		bedrock, err := NewBedrockProvider(ctx, cfg.BedrockRegion)
+		if err != nil {
+			return nil, "", fmt.Errorf("init Bedrock provider: %w", err)
+		}
+		return bedrock, bedrock.Name(), nil
+	}
+	return nil, "", fmt.Errorf("%w %q", errUnknownProvider, name)
+}
@@ -0,0 +1,138 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"ai-data-analyst/internal/config"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RoutableStages are the pipeline stages that make completion calls, and
+// so the stages LLM_STAGE_MODELS may route.
+var RoutableStages = []string{"generate", "repair", "explain"}
+
+// Route sends the completions of one pipeline stage to a provider and
+// model of its own.
+type Route struct {
+	Provider     Provider
+	ProviderName string
+	Model        string
+}
+
+// RouteSpec is one entry of LLM_STAGE_MODELS before its provider is built.
+type RouteSpec struct {
+	Provider string
+	Model    string
+}
+
+// ParseRoutes reads comma-separated stage=provider:model entries, as in
+// LLM_STAGE_MODELS. Unlike ParseDeployments it rejects malformed entries,
+// repeated stages and stages not in stages, so a typo stops the server
+// instead of quietly leaving a stage on the default model.
+func ParseRoutes(s string, stages []string) (map[string]RouteSpec, error) {
+	routes := make(map[string]RouteSpec)
+	var errs []error
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		stage, target, ok := strings.Cut(entry, "=")
+		provider, model, ok2 := strings.Cut(target, ":")
+		stage, provider, model = strings.TrimSpace(stage), strings.TrimSpace(provider), strings.TrimSpace(model)
+		switch {
+		case !ok || !ok2 || stage == "" || provider == "" || model == "":
+			errs = append(errs, fmt.Errorf("%q: want stage=provider:model", entry))
+		case !slices.Contains(stages, stage):
+			errs = append(errs, fmt.Errorf("%q: unknown stage %q, want one of %s", entry, stage, strings.Join(stages, ", ")))
+		default:
+			if _, dup := routes[stage]; dup {
+				errs = append(errs, fmt.Errorf("%q: stage %q is routed twice", entry, stage))
+				continue
+			}
+			routes[stage] = RouteSpec{Provider: provider, Model: model}
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, fmt.Errorf("LLM_STAGE_MODELS: %w", err)
+	}
+	return routes, nil
+}
+
+// newRoutes builds the providers named by cfg's LLM_STAGE_MODELS, reusing
+// those in built, which maps provider names to routes without a model. A
+// route to a provider whose credentials are missing is an error rather than
+// a call that fails on every request.
+func newRoutes(ctx context.Context, cfg *config.Config, built map[string]Route) (map[string]Route, error) {
+	specs, err := ParseRoutes(cfg.LLMStageModels, RoutableStages)
+	if err != nil {
+		return nil, err
+	}
+
+	routes := make(map[string]Route, len(specs))
+	for stage, spec := range specs {
+		route, ok := built[spec.Provider]
+		if !ok {
+			if err := checkCredentials(cfg, spec.Provider); err != nil {
+				return nil, fmt.Errorf("LLM_STAGE_MODELS: route for %s: %w", stage, err)
+			}
+			provider, name, err := newProvider(ctx, cfg, spec.Provider)
+			if err != nil {
+				return nil, fmt.Errorf("LLM_STAGE_MODELS: route for %s: %w", stage, err)
+			}
+			route = Route{Provider: provider, ProviderName: name}
+			built[spec.Provider] = route
+		}
+		route.Model = spec.Model
+		routes[stage] = route
+	}
+	return routes, nil
+}
+
+func checkCredentials(cfg *config.Config, provider string) error {
+	var missing string
+	switch provider {
+	case "openai":
+		if cfg.OpenAIAPIKey == "" {
+			missing = "OPENAI_API_KEY"
+		}
+	case "anthropic":
+		if cfg.AnthropicAPIKey == "" {
+			missing = "ANTHROPIC_API_KEY"
+		}
+	case "google":
+		if cfg.GoogleAPIKey == "" {
+			missing = "GOOGLE_API_KEY"
+		}
+	}
+	if missing != "" {
+		return fmt.Errorf("%s is required for provider %s", missing, provider)
+	}
+	return nil
+}
+
+// route picks the provider and model for req: its stage's Route if there
+// is one, otherwise the primary provider and the requested model. With any
+// routes configured, the choice is recorded on the stage's span.
+func (c *Client) route(ctx context.Context, req GenerateRequest) (GenerateRequest, Provider, string) {
+	route, ok := c.Routes[req.Stage]
+	provider, providerName, source := c.Primary, c.PrimaryProvider, "default"
+	if ok {
+		req.Model = route.Model
+		provider, providerName, source = route.Provider, route.ProviderName, "stage"
+	}
+	if len(c.Routes) > 0 {
+		trace.SpanFromContext(ctx).SetAttributes(
+			attribute.String("nlsql.route.source", source),
+			attribute.String("nlsql.route.provider", providerName),
+			attribute.String("nlsql.route.model", req.Model),
+		)
+	}
+	return req, provider, providerName
+}
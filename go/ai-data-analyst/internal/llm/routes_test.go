@@ -0,0 +1,114 @@
+package llm
+
+import (
+	"context"
+	"testing"
+
+	"ai-data-analyst/internal/config"
+
+	"github.com/base-14/examples/go/pkg/oteltest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func TestParseRoutes(t *testing.T) {
+	routes, err := ParseRoutes(" generate=anthropic:claude-sonnet-4-5 , explain=bedrock:anthropic.claude-3-haiku-20240307-v1:0,", RoutableStages)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]RouteSpec{
+		"generate": {Provider: "anthropic", Model: "claude-sonnet-4-5"},
+		"explain":  {Provider: "bedrock", Model: "anthropic.claude-3-haiku-20240307-v1:0"},
+	}, routes)
+
+	routes, err = ParseRoutes("", RoutableStages)
+	require.NoError(t, err)
+	assert.Empty(t, routes)
+}
+
+func TestParseRoutesRejectsInvalid(t *testing.T) {
+	for name, s := range map[string]string{
+		"no provider":   "generate=gpt-5.5",
+		"no model":      "generate=openai:",
+		"no stage":      "=openai:gpt-5.5",
+		"unknown stage": "plan=openai:gpt-5.5",
+		"repeated":      "explain=openai:gpt-5.4-mini,explain=ollama:llama3.2",
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseRoutes(s, RoutableStages)
+			assert.ErrorContains(t, err, "LLM_STAGE_MODELS")
+		})
+	}
+}
+
+func TestNewClientRoutes(t *testing.T) {
+	cfg := &config.Config{
+		LLMProvider:    "openai",
+		OpenAIAPIKey:   "sk-test",
+		OllamaBaseURL:  "http://localhost:11434",
+		LLMStageModels: "generate=openai:gpt-5.5,explain=ollama:llama3.2",
+	}
+	client, err := NewClient(context.Background(), cfg, nil, nil)
+	require.NoError(t, err)
+	require.Len(t, client.Routes, 2)
+	assert.Same(t, client.Primary, client.Routes["generate"].Provider, "a route to LLM_PROVIDER reuses the primary")
+	assert.Equal(t, "ollama", client.Routes["explain"].ProviderName)
+	assert.Equal(t, "llama3.2", client.Routes["explain"].Model)
+
+	cfg.LLMStageModels = "generate=anthropic:claude-sonnet-4-5"
+	_, err = NewClient(context.Background(), cfg, nil, nil)
+	assert.ErrorContains(t, err, "ANTHROPIC_API_KEY")
+
+	cfg.LLMStageModels = "generate=mistral:large"
+	_, err = NewClient(context.Background(), cfg, nil, nil)
+	assert.ErrorContains(t, err, "unknown provider")
+}
+
+func TestGenerateFollowsStageRoute(t *testing.T) {
+	primary := &mockProvider{name: "openai", resp: &GenerateResponse{Content: "SELECT 1", Model: "gpt-4.1"}}
+	routed := &mockProvider{name: "anthropic", resp: &GenerateResponse{Content: "It is one.", Model: "claude-haiku-4-5"}}
+	client, tel := newTestClient(t, primary, nil)
+	client.Routes = map[string]Route{
+		"explain": {Provider: routed, ProviderName: "anthropic", Model: "claude-haiku-4-5"},
+	}
+
+	ctx, span := tel.Tracer("test").Start(context.Background(), "pipeline_stage explain")
+	req := testReq()
+	req.Stage = "explain"
+	_, err := client.Generate(ctx, req)
+	require.NoError(t, err)
+	span.End()
+
+	assert.Equal(t, 0, primary.calls)
+	assert.Equal(t, "claude-haiku-4-5", routed.lastModel)
+	oteltest.AssertSpanAttributes(t, tel.Span(t, "pipeline_stage explain"),
+		attribute.String("nlsql.route.source", "stage"),
+		attribute.String("nlsql.route.provider", "anthropic"),
+		attribute.String("nlsql.route.model", "claude-haiku-4-5"),
+	)
+	oteltest.AssertSpanAttributes(t, tel.Span(t, "gen_ai.chat claude-haiku-4-5"),
+		attribute.String("gen_ai.provider.name", "anthropic"),
+	)
+
+	// A stage without a route stays on the primary and the requested model.
+	_, err = client.Generate(context.Background(), testReq())
+	require.NoError(t, err)
+	assert.Equal(t, 1, primary.calls)
+	assert.Equal(t, "gpt-4.1", primary.lastModel)
+}
+
+func TestGenerateDowngradeLeavesRoute(t *testing.T) {
+	primary := &mockProvider{name: "openai", resp: &GenerateResponse{Content: "SELECT 1", Model: "gpt-4.1-mini"}}
+	routed := &mockProvider{name: "azure", resp: &GenerateResponse{Content: "SELECT 1", Model: "gpt-4.1"}}
+	client, _ := newTestClient(t, primary, nil)
+	client.Routes = map[string]Route{
+		"generate": {Provider: routed, ProviderName: "azure.ai.openai", Model: "gpt-4.1"},
+	}
+	// The fast model belongs to the primary provider, so a downgraded call
+	// goes there rather than to the route's.
+	client.Budget = NewBudget(BudgetLimits{DailyUSD: 0.0005}, "gpt-4.1-mini", true)
+
+	_, err := client.Generate(context.Background(), testReq())
+	require.NoError(t, err)
+	assert.Equal(t, 0, routed.calls)
+	assert.Equal(t, "gpt-4.1-mini", primary.lastModel)
+}
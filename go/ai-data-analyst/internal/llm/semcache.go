@@ -0,0 +1,197 @@
+package llm
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"ai-data-analyst/internal/telemetry"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// SemanticCache answers a prompt with the response to an earlier one whose
+// embedding has a cosine similarity of at least Threshold to it, so
+// rephrasings of a question already asked cost one embedding instead of a
+// completion. Only
+// prompts sent with the same model, system prompt and sampling settings are
+// compared.
+type SemanticCache struct {
+	// Model is the embedding model prompts are embedded with.
+	Model      string
+	Threshold  float64
+	MaxEntries int
+	TTL        time.Duration
+
+	now func() time.Time
+
+	mu sync.Mutex
+	// entries is oldest first; the oldest is evicted when full.
+	entries []semanticEntry
+}
+
+type semanticEntry struct {
+	scope  string
+	vector []float32
+	norm   float64
+	resp   GenerateResponse
+	stored time.Time
+}
+
+func NewSemanticCache(model string, threshold float64, maxEntries int, ttl time.Duration) *SemanticCache {
+	return &SemanticCache{
+		Model:      model,
+		Threshold:  threshold,
+		MaxEntries: maxEntries,
+		TTL:        ttl,
+		now:        time.Now,
+	}
+}
+
+// semanticScope is the part of a request that must match exactly for two
+// prompts to share a response.
+func semanticScope(req GenerateRequest) string {
+	return req.Model + "\x00" + req.System + "\x00" +
+		strconv.FormatFloat(req.Temperature, 'g', -1, 64) + "\x00" + strconv.Itoa(req.MaxTokens)
+}
+
+func norm(v []float32) float64 {
+	var sum float64
+	for _, x := range v {
+		sum += float64(x) * float64(x)
+	}
+	return math.Sqrt(sum)
+}
+
+// lookup returns the stored response most similar to vector within scope,
+// if any reaches the threshold, and its similarity.
+func (s *SemanticCache) lookup(scope string, vector []float32) (*GenerateResponse, float64, bool) {
+	n := norm(vector)
+	if n == 0 {
+		return nil, 0, false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expire()
+
+	var best *semanticEntry
+	bestSimilarity := -1.0
+	for i := range s.entries {
+		e := &s.entries[i]
+		if e.scope != scope || len(e.vector) != len(vector) || e.norm == 0 {
+			continue
+		}
+		var dot float64
+		for j, x := range vector {
+			dot += float64(x) * float64(e.vector[j])
+		}
+		if similarity := dot / (n * e.norm); similarity > bestSimilarity {
+			best, bestSimilarity = e, similarity
+		}
+	}
+	if best == nil || bestSimilarity < s.Threshold {
+		return nil, bestSimilarity, false
+	}
+	resp := best.resp
+	return &resp, bestSimilarity, true
+}
+
+func (s *SemanticCache) store(scope string, vector []float32, resp *GenerateResponse) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expire()
+
+	s.entries = append(s.entries, semanticEntry{
+		scope:  scope,
+		vector: vector,
+		norm:   norm(vector),
+		resp:   *resp,
+		stored: s.now(),
+	})
+	if s.MaxEntries > 0 && len(s.entries) > s.MaxEntries {
+		s.entries = append(s.entries[:0], s.entries[len(s.entries)-s.MaxEntries:]...)
+	}
+}
+
+// expire drops entries older than TTL. Entries are in insertion order, so
+// the expired ones are a prefix.
+func (s *SemanticCache) expire() {
+	if s.TTL <= 0 {
+		return
+	}
+	cutoff := s.now().Add(-s.TTL)
+	i := 0
+	for i < len(s.entries) && s.entries[i].stored.Before(cutoff) {
+		i++
+	}
+	if i > 0 {
+		s.entries = append(s.entries[:0], s.entries[i:]...)
+	}
+}
+
+// Len is the number of cached responses.
+func (s *SemanticCache) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.entries)
+}
+
+type skipCacheKey struct{}
+
+// SkipCache returns a context whose calls bypass the semantic cache, for
+// answers that must come from the model, such as a replay.
+func SkipCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, skipCacheKey{}, true)
+}
+
+// semanticLookup embeds the prompt and looks it up in the cache. On a hit it
+// returns a copy of the cached response with no tokens or cost, since none
+// were spent. The vector is returned so a miss can be stored once answered; it
+// is nil when the cache is off for this call or embedding failed, which
+// never fails the call itself.
+func (c *Client) semanticLookup(ctx context.Context, req GenerateRequest) (*GenerateResponse, []float32) {
+	if c.Cache == nil || !c.CanEmbed() {
+		return nil, nil
+	}
+	if skip, _ := ctx.Value(skipCacheKey{}).(bool); skip {
+		return nil, nil
+	}
+
+	ctx, span := c.Tracer.Start(ctx, "gen_ai.cache.lookup")
+	defer span.End()
+	span.SetAttributes(attribute.String("gen_ai.request.model", req.Model))
+	if req.Stage != "" {
+		span.SetAttributes(attribute.String("nlsql.stage", req.Stage))
+	}
+
+	emb, err := c.Embed(ctx, EmbedRequest{Model: c.Cache.Model, Input: []string{req.Prompt}, Stage: req.Stage})
+	if err != nil {
+		span.SetAttributes(attribute.String("error.type", classifyError(err)))
+		return nil, nil
+	}
+	vector := emb.Vectors[0]
+
+	cached, similarity, hit := c.Cache.lookup(semanticScope(req), vector)
+	span.SetAttributes(attribute.Bool("gen_ai.cache.hit", hit))
+	if similarity >= 0 {
+		span.SetAttributes(attribute.Float64("gen_ai.cache.similarity", similarity))
+	}
+	if c.Metrics != nil {
+		attrs := telemetry.WithSemanticCache(req.Model, req.Stage)
+		if hit {
+			c.Metrics.SemanticCacheHits.Add(ctx, 1, attrs)
+			c.Metrics.SemanticCacheSavings.Add(ctx, cached.CostUSD, attrs)
+		} else {
+			c.Metrics.SemanticCacheMisses.Add(ctx, 1, attrs)
+		}
+	}
+	if !hit {
+		return nil, vector
+	}
+	span.SetAttributes(attribute.Float64("gen_ai.cache.saved_cost_usd", cached.CostUSD))
+	cached.InputTokens, cached.OutputTokens, cached.CostUSD = 0, 0, 0
+	return cached, vector
+}
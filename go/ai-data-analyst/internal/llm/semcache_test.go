@@ -0,0 +1,144 @@
+package llm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/base-14/examples/go/pkg/oteltest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// vectorEmbedder embeds each prompt as the vector given for it.
+type vectorEmbedder struct {
+	mockProvider
+	vectors map[string][]float32
+}
+
+func (m *vectorEmbedder) Embed(_ context.Context, req EmbedRequest) (*EmbedResponse, error) {
+	vectors := make([][]float32, len(req.Input))
+	for i, in := range req.Input {
+		vectors[i] = m.vectors[in]
+	}
+	return &EmbedResponse{Vectors: vectors, Model: req.Model, InputTokens: 4 * len(req.Input)}, nil
+}
+
+func newCachedClient(t *testing.T) (*Client, *vectorEmbedder, *oteltest.Telemetry) {
+	t.Helper()
+	primary := &vectorEmbedder{
+		mockProvider: mockProvider{
+			name: "openai",
+			resp: &GenerateResponse{Content: "SELECT 1", Model: "gpt-4.1", InputTokens: 1000, OutputTokens: 100},
+		},
+		vectors: map[string][]float32{
+			"gdp of india":       {1, 0, 0},
+			"india's gdp":        {0.99, 0.1, 0},
+			"population of fiji": {0, 1, 0},
+		},
+	}
+	client, tel := newTestClient(t, primary, nil)
+	client.Cache = NewSemanticCache("text-embedding-3-small", 0.95, 10, time.Hour)
+	return client, primary, tel
+}
+
+func cacheReq(prompt string) GenerateRequest {
+	req := testReq()
+	req.Prompt = prompt
+	return req
+}
+
+func TestSemanticCacheHit(t *testing.T) {
+	client, primary, tel := newCachedClient(t)
+	ctx := context.Background()
+
+	first, err := client.Generate(ctx, cacheReq("gdp of india"))
+	require.NoError(t, err)
+	assert.Greater(t, first.CostUSD, 0.0)
+	assert.Equal(t, 1, primary.calls)
+
+	second, err := client.Generate(ctx, cacheReq("india's gdp"))
+	require.NoError(t, err)
+	assert.Equal(t, 1, primary.calls, "a similar prompt must not call the provider")
+	assert.Equal(t, "SELECT 1", second.Content)
+	assert.Zero(t, second.CostUSD)
+	assert.Zero(t, second.InputTokens)
+
+	_, err = client.Generate(ctx, cacheReq("population of fiji"))
+	require.NoError(t, err)
+	assert.Equal(t, 2, primary.calls, "an unrelated prompt must call the provider")
+
+	stage := attribute.String("nlsql.stage", "generate")
+	assert.Equal(t, int64(1), oteltest.Sum[int64](t, tel, "gen_ai.cache.hit", stage))
+	assert.Equal(t, int64(2), oteltest.Sum[int64](t, tel, "gen_ai.cache.miss", stage))
+	assert.InDelta(t, first.CostUSD, oteltest.Sum[float64](t, tel, "gen_ai.cache.saved_cost", stage), 1e-9)
+
+	var hits int
+	for _, span := range tel.Spans() {
+		if span.Name != "gen_ai.cache.lookup" {
+			continue
+		}
+		for _, kv := range span.Attributes {
+			if kv.Key == "gen_ai.cache.hit" && kv.Value.AsBool() {
+				hits++
+			}
+		}
+	}
+	assert.Equal(t, 1, hits)
+}
+
+func TestSemanticCacheStream(t *testing.T) {
+	client, primary, _ := newCachedClient(t)
+	ctx := context.Background()
+
+	_, err := client.Generate(ctx, cacheReq("gdp of india"))
+	require.NoError(t, err)
+
+	var deltas []string
+	resp, err := client.GenerateStream(ctx, cacheReq("india's gdp"), func(d string) { deltas = append(deltas, d) })
+	require.NoError(t, err)
+	assert.Equal(t, 1, primary.calls)
+	assert.Equal(t, []string{"SELECT 1"}, deltas, "a hit is delivered as one delta")
+	assert.Equal(t, "SELECT 1", resp.Content)
+}
+
+func TestSemanticCacheScope(t *testing.T) {
+	client, primary, _ := newCachedClient(t)
+	ctx := context.Background()
+
+	_, err := client.Generate(ctx, cacheReq("gdp of india"))
+	require.NoError(t, err)
+
+	other := cacheReq("gdp of india")
+	other.System = "You explain SQL."
+	_, err = client.Generate(ctx, other)
+	require.NoError(t, err)
+	assert.Equal(t, 2, primary.calls, "a different system prompt must not share answers")
+
+	_, err = client.Generate(SkipCache(ctx), cacheReq("gdp of india"))
+	require.NoError(t, err)
+	assert.Equal(t, 3, primary.calls, "SkipCache must call the provider")
+}
+
+func TestSemanticCacheEviction(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	cache := NewSemanticCache("m", 0.9, 2, time.Minute)
+	cache.now = func() time.Time { return now }
+
+	resp := &GenerateResponse{Content: "a"}
+	cache.store("s", []float32{1, 0}, resp)
+	cache.store("s", []float32{0, 1}, resp)
+	cache.store("s", []float32{1, 1}, resp)
+	assert.Equal(t, 2, cache.Len(), "the oldest entry is evicted when full")
+
+	_, _, hit := cache.lookup("s", []float32{1, 0})
+	assert.False(t, hit, "the evicted entry must not be found")
+	_, _, hit = cache.lookup("s", []float32{0, 1})
+	assert.True(t, hit)
+
+	now = now.Add(2 * time.Minute)
+	_, _, hit = cache.lookup("s", []float32{0, 1})
+	assert.False(t, hit, "expired entries must not be found")
+	assert.Zero(t, cache.Len())
+}
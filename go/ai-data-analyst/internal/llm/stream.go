@@ -0,0 +1,131 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"ai-data-analyst/internal/telemetry"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+var errStreamIncomplete = errors.New("stream ended without a final response")
+
+// StreamChunk is one piece of a streamed completion. Content chunks come
+// first; the last chunk carries either Response, with the model, token usage
+// and finish reason but no Content, or Err if the stream broke. Providers
+// close the channel after the last chunk.
+type StreamChunk struct {
+	Content  string
+	Response *GenerateResponse
+	Err      error
+}
+
+// sendChunk delivers chunk unless ctx is done first, reporting whether it
+// was delivered.
+func sendChunk(ctx context.Context, chunks chan<- StreamChunk, chunk StreamChunk) bool {
+	select {
+	case chunks <- chunk:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// GenerateStream is Generate with the completion handed to onDelta as it is
+// produced. Streams are not retried: if the provider fails before
+// sending any content, the call takes Generate's retry and fallback path and
+// onDelta receives the whole completion at once, as it does for a semantic
+// cache hit, and so does a call to a provider whose breaker is open. A
+// stream that breaks after content has been delivered returns the error.
+func (c *Client) GenerateStream(ctx context.Context, req GenerateRequest, onDelta func(string)) (*GenerateResponse, error) {
+	req, provider, providerName := c.route(ctx, req)
+	cached, vector := c.semanticLookup(ctx, req)
+	if cached != nil {
+		onDelta(cached.Content)
+		return cached, nil
+	}
+
+	admitted, provider, providerName, err := c.admit(ctx, req, provider, providerName)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		resp    *GenerateResponse
+		started bool
+	)
+	err = ErrCircuitOpen
+	if !breakerOpen(provider) {
+		resp, started, err = c.StreamOnce(ctx, provider, providerName, admitted, onDelta)
+	}
+	if err != nil && !started {
+		resp, err = c.generate(ctx, provider, providerName, admitted)
+		if err == nil {
+			onDelta(resp.Content)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if c.Budget != nil {
+		c.Budget.record(ctx, resp.CostUSD)
+	}
+	c.semanticStore(admitted, vector, resp)
+	return resp, nil
+}
+
+// StreamOnce makes one streamed call, traced like GenerateOnce, and records
+// the time to the first content chunk. started reports whether any content
+// reached onDelta.
+func (c *Client) StreamOnce(ctx context.Context, provider Provider, providerName string, req GenerateRequest, onDelta func(string)) (resp *GenerateResponse, started bool, err error) {
+	start := time.Now()
+	ctx, span := c.startChat(ctx, provider, providerName, req)
+	defer span.End()
+
+	chunks, err := provider.GenerateStream(ctx, req)
+	if err != nil {
+		c.chatFailed(ctx, span, providerName, req, err)
+		return nil, false, err
+	}
+
+	var content strings.Builder
+	for chunk := range chunks {
+		if chunk.Err != nil {
+			c.chatFailed(ctx, span, providerName, req, chunk.Err)
+			return nil, started, chunk.Err
+		}
+		if chunk.Content != "" {
+			if !started {
+				started = true
+				ttft := time.Since(start).Seconds()
+				span.SetAttributes(attribute.Float64("gen_ai.response.time_to_first_token", ttft))
+				if c.Metrics != nil {
+					c.Metrics.TimeToFirstToken.Record(ctx, ttft,
+						telemetry.WithProviderModel(providerName, req.Model),
+					)
+				}
+			}
+			content.WriteString(chunk.Content)
+			onDelta(chunk.Content)
+		}
+		if chunk.Response != nil {
+			resp = chunk.Response
+		}
+	}
+
+	if resp == nil {
+		err := errStreamIncomplete
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		}
+		c.chatFailed(ctx, span, providerName, req, err)
+		return nil, started, err
+	}
+
+	resp.Content = content.String()
+	c.chatDone(ctx, span, providerName, req, resp, time.Since(start).Seconds())
+	return resp, started, nil
+}
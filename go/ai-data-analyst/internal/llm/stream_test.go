@@ -0,0 +1,92 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/base-14/examples/go/pkg/oteltest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func TestGenerateStream(t *testing.T) {
+	primary := &mockProvider{
+		name: "openai",
+		resp: &GenerateResponse{Content: "Three words here", Model: "gpt-4.1", InputTokens: 10, OutputTokens: 3},
+	}
+	client, tel := newTestClient(t, primary, nil)
+
+	var deltas []string
+	resp, err := client.GenerateStream(context.Background(), testReq(), func(d string) {
+		deltas = append(deltas, d)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Three ", "words ", "here"}, deltas)
+	assert.Equal(t, "Three words here", resp.Content)
+	assert.Greater(t, resp.CostUSD, 0.0)
+
+	span := tel.Span(t, "gen_ai.chat gpt-4.1")
+	var hasTTFT bool
+	for _, kv := range span.Attributes {
+		hasTTFT = hasTTFT || kv.Key == "gen_ai.response.time_to_first_token"
+	}
+	assert.True(t, hasTTFT)
+
+	ttft := oteltest.Histogram[float64](t, tel, "gen_ai.client.time_to_first_token",
+		attribute.String("gen_ai.provider.name", "openai"),
+		attribute.String("gen_ai.request.model", "gpt-4.1"),
+	)
+	assert.Equal(t, uint64(1), ttft.Count)
+
+	usage := oteltest.Histogram[float64](t, tel, "gen_ai.client.token.usage", attribute.String("gen_ai.token.type", "output"))
+	assert.Equal(t, 3.0, usage.Sum)
+}
+
+func TestGenerateStreamFallsBackBeforeContent(t *testing.T) {
+	primary := &mockProvider{
+		name:    "openai",
+		failN:   1,
+		failErr: errors.New("503 service unavailable"),
+		resp:    &GenerateResponse{Content: "Hello there", Model: "gpt-4.1"},
+	}
+	client, _ := newTestClient(t, primary, nil)
+
+	var deltas []string
+	resp, err := client.GenerateStream(context.Background(), testReq(), func(d string) {
+		deltas = append(deltas, d)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Hello there", resp.Content)
+	assert.Equal(t, []string{"Hello there"}, deltas, "the non-streamed retry delivers the completion at once")
+	assert.Equal(t, 2, primary.calls)
+}
+
+// brokenStream sends some content and then fails.
+type brokenStream struct{ mockProvider }
+
+func (b *brokenStream) GenerateStream(context.Context, GenerateRequest) (<-chan StreamChunk, error) {
+	b.calls++
+	chunks := make(chan StreamChunk, 2)
+	chunks <- StreamChunk{Content: "partial "}
+	chunks <- StreamChunk{Err: errors.New("connection reset")}
+	close(chunks)
+	return chunks, nil
+}
+
+func TestGenerateStreamErrorAfterContent(t *testing.T) {
+	primary := &brokenStream{mockProvider{name: "openai"}}
+	client, tel := newTestClient(t, primary, nil)
+
+	var deltas []string
+	_, err := client.GenerateStream(context.Background(), testReq(), func(d string) {
+		deltas = append(deltas, d)
+	})
+	require.Error(t, err)
+	assert.Equal(t, []string{"partial "}, deltas)
+	assert.Equal(t, 1, primary.calls, "a stream that has delivered content is not retried")
+
+	errCount := oteltest.Sum[int64](t, tel, "gen_ai.client.error.count")
+	assert.Equal(t, int64(1), errCount)
+}
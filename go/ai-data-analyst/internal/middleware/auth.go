@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"ai-data-analyst/internal/auth"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Identify resolves the caller and their tenant from an X-API-Key header or a
+// Bearer token. With no keys configured every request runs as
+// auth.AnonymousUser in auth.DefaultTenant, keeping the single-user demo
+// working out of the box.
+func Identify(keys map[string]auth.Identity) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if len(keys) == 0 || r.URL.Path == "/api/health" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			key := r.Header.Get("X-API-Key")
+			if key == "" {
+				key = strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+			}
+			id, ok := keys[key]
+			if key == "" || !ok {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusUnauthorized)
+				json.NewEncoder(w).Encode(map[string]string{"error": "missing or invalid API key"})
+				return
+			}
+
+			trace.SpanFromContext(r.Context()).SetAttributes(
+				attribute.String("enduser.id", id.User),
+				attribute.String("tenant.id", id.Tenant),
+			)
+			ctx := auth.WithUser(r.Context(), id.User)
+			ctx = auth.WithTenant(ctx, id.Tenant)
+			ctx = auth.WithKeyID(ctx, auth.KeyID(key))
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// RequireAdmin lets through only callers whose resolved user is listed in
+// admins. With no admins configured the guarded routes are disabled.
+func RequireAdmin(admins []string) func(http.Handler) http.Handler {
+	allowed := make(map[string]bool, len(admins))
+	for _, a := range admins {
+		allowed[a] = true
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !allowed[auth.UserFrom(r.Context())] {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusForbidden)
+				json.NewEncoder(w).Encode(map[string]string{"error": "admin access required"})
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
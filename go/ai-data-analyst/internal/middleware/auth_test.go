@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"ai-data-analyst/internal/auth"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdentifyResolvesTenant(t *testing.T) {
+	var user, tenant, keyID string
+	h := Identify(auth.ParseKeys("acme/alice:key-a,bob:key-b"))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, tenant, keyID = auth.UserFrom(r.Context()), auth.TenantFrom(r.Context()), auth.KeyIDFrom(r.Context())
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/usage", nil)
+	req.Header.Set("X-API-Key", "key-a")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(t, "alice", user)
+	assert.Equal(t, "acme", tenant)
+	assert.Equal(t, auth.KeyID("key-a"), keyID)
+
+	req = httptest.NewRequest(http.MethodGet, "/api/usage", nil)
+	req.Header.Set("Authorization", "Bearer key-b")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(t, "bob", user)
+	assert.Equal(t, auth.DefaultTenant, tenant)
+
+	req = httptest.NewRequest(http.MethodGet, "/api/usage", nil)
+	req.Header.Set("X-API-Key", "unknown")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// RequireDatabase answers 503 for routes that only read from the database
+// while check reports it unavailable, instead of a 500 from the first query.
+func RequireDatabase(check func() error) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if err := check(); err != nil {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusServiceUnavailable)
+				json.NewEncoder(w).Encode(map[string]string{
+					"status": "degraded",
+					"error":  "database unavailable: " + err.Error(),
+				})
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"ai-data-analyst/internal/llm"
+)
+
+// RequireModels answers 503 while any model the pipeline depends on is
+// missing from Ollama, instead of letting the request fail deep inside an
+// LLM call with an opaque "model not found". A degraded result is re-checked
+// on each request so models pulled out-of-band are picked up.
+func RequireModels(admin *llm.OllamaAdmin) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			missing, err := admin.Missing()
+			if err != nil || len(missing) > 0 {
+				missing, err = admin.Check(r.Context())
+			}
+			if err == nil && len(missing) == 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			body := map[string]any{"status": "degraded"}
+			if err != nil {
+				body["error"] = "ollama is unreachable: " + err.Error()
+			} else {
+				body["error"] = "required models are not installed"
+				body["missing"] = missing
+				body["hint"] = "POST /api/models/pull with {\"model\": \"<name>\"}"
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			json.NewEncoder(w).Encode(body)
+		})
+	}
+}
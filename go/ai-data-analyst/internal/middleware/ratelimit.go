@@ -0,0 +1,178 @@
+package middleware
+
+import (
+	"encoding/json"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Rate limit scopes, reported in 429 responses and as nlsql.rate_limit.scope.
+const (
+	RateLimitScopeIP     = "ip"
+	RateLimitScopeGlobal = "global"
+)
+
+// RateLimitConfig sets the token buckets of RateLimit. A rate of zero
+// disables that bucket; a burst below one allows one request.
+type RateLimitConfig struct {
+	PerIPPerMinute  float64
+	PerIPBurst      int
+	GlobalPerMinute float64
+	GlobalBurst     int
+}
+
+// bucket is a token bucket that refills continuously at rate tokens per
+// second up to burst.
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// take refills b for the time since it was last used and takes a token if
+// one is available. Otherwise it returns how long until one is.
+func (b *bucket) take(now time.Time, rate, burst float64) (bool, time.Duration) {
+	b.tokens = min(burst, b.tokens+now.Sub(b.last).Seconds()*rate)
+	b.last = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	return false, time.Duration((1 - b.tokens) / rate * float64(time.Second))
+}
+
+// RateLimiter holds a token bucket per client IP and one shared by all
+// clients. A request needs a token from both.
+type RateLimiter struct {
+	cfg       RateLimitConfig
+	throttled metric.Int64Counter
+	now       func() time.Time
+
+	mu        sync.Mutex
+	global    *bucket
+	clients   map[string]*bucket
+	lastSweep time.Time
+}
+
+// NewRateLimiter returns a limiter for cfg. throttled, if not nil, counts
+// rejected requests by scope.
+func NewRateLimiter(cfg RateLimitConfig, throttled metric.Int64Counter) *RateLimiter {
+	return &RateLimiter{
+		cfg:       cfg,
+		throttled: throttled,
+		now:       time.Now,
+		clients:   map[string]*bucket{},
+	}
+}
+
+func perSecond(perMinute float64, burst int) (float64, float64) {
+	return perMinute / 60, float64(max(burst, 1))
+}
+
+// allow takes a token for ip, returning the scope that ran out and how long
+// to wait when the request is over a limit.
+func (l *RateLimiter) allow(ip string) (bool, string, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+
+	var client *bucket
+	if l.cfg.PerIPPerMinute > 0 {
+		rate, burst := perSecond(l.cfg.PerIPPerMinute, l.cfg.PerIPBurst)
+		l.sweep(now, rate, burst)
+		client = l.clients[ip]
+		if client == nil {
+			client = &bucket{tokens: burst, last: now}
+			l.clients[ip] = client
+		}
+		if ok, wait := client.take(now, rate, burst); !ok {
+			return false, RateLimitScopeIP, wait
+		}
+	}
+
+	if l.cfg.GlobalPerMinute > 0 {
+		rate, burst := perSecond(l.cfg.GlobalPerMinute, l.cfg.GlobalBurst)
+		if l.global == nil {
+			l.global = &bucket{tokens: burst, last: now}
+		}
+		if ok, wait := l.global.take(now, rate, burst); !ok {
+			// The client's token is given back: the request was not served.
+			if client != nil {
+				client.tokens++
+			}
+			return false, RateLimitScopeGlobal, wait
+		}
+	}
+	return true, "", 0
+}
+
+// sweep drops, at most once a minute, the buckets of clients idle long
+// enough to have refilled, since a new bucket starts full anyway.
+func (l *RateLimiter) sweep(now time.Time, rate, burst float64) {
+	if now.Sub(l.lastSweep) < time.Minute {
+		return
+	}
+	l.lastSweep = now
+	full := time.Duration(burst / rate * float64(time.Second))
+	for ip, b := range l.clients {
+		if now.Sub(b.last) >= full {
+			delete(l.clients, ip)
+		}
+	}
+}
+
+// Allow takes a token for the request's client, reporting the scope that
+// ran out and how long to wait when it is over a limit. Middleware calls it
+// once per request; a WebSocket handler can call it once per message.
+func (l *RateLimiter) Allow(r *http.Request) (bool, string, time.Duration) {
+	ok, scope, wait := l.allow(clientIP(r))
+	if !ok && l.throttled != nil {
+		l.throttled.Add(r.Context(), 1,
+			metric.WithAttributes(attribute.String("nlsql.rate_limit.scope", scope)))
+	}
+	return ok, scope, wait
+}
+
+// Middleware answers 429, with Retry-After, for requests over the per-IP or
+// global limit, so a handful of clients cannot run up the LLM bill.
+func (l *RateLimiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ok, scope, wait := l.Allow(r)
+		if ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		trace.SpanFromContext(r.Context()).SetAttributes(
+			attribute.Bool("nlsql.rate_limited", true),
+			attribute.String("nlsql.rate_limit.scope", scope),
+		)
+
+		retryAfter := int(math.Ceil(wait.Seconds()))
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Retry-After", strconv.Itoa(max(retryAfter, 1)))
+		w.WriteHeader(http.StatusTooManyRequests)
+		json.NewEncoder(w).Encode(map[string]any{
+			"error":               "rate limit exceeded",
+			"scope":               scope,
+			"retry_after_seconds": max(retryAfter, 1),
+		})
+	})
+}
+
+// clientIP is the host part of the connection's remote address. Forwarded
+// headers are ignored: any client can set them.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,95 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestLimiter(cfg RateLimitConfig) (*RateLimiter, *time.Time) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	l := NewRateLimiter(cfg, nil)
+	l.now = func() time.Time { return now }
+	return l, &now
+}
+
+func TestRateLimiterPerIP(t *testing.T) {
+	l, now := newTestLimiter(RateLimitConfig{PerIPPerMinute: 60, PerIPBurst: 2})
+
+	ok, _, _ := l.allow("10.0.0.1")
+	assert.True(t, ok)
+	ok, _, _ = l.allow("10.0.0.1")
+	assert.True(t, ok)
+
+	ok, scope, wait := l.allow("10.0.0.1")
+	assert.False(t, ok)
+	assert.Equal(t, RateLimitScopeIP, scope)
+	assert.Equal(t, time.Second, wait)
+
+	// Other clients have their own bucket.
+	ok, _, _ = l.allow("10.0.0.2")
+	assert.True(t, ok)
+
+	*now = now.Add(time.Second)
+	ok, _, _ = l.allow("10.0.0.1")
+	assert.True(t, ok)
+}
+
+func TestRateLimiterGlobal(t *testing.T) {
+	l, _ := newTestLimiter(RateLimitConfig{PerIPPerMinute: 60, PerIPBurst: 5, GlobalPerMinute: 60, GlobalBurst: 1})
+
+	ok, _, _ := l.allow("10.0.0.1")
+	assert.True(t, ok)
+
+	ok, scope, _ := l.allow("10.0.0.2")
+	assert.False(t, ok)
+	assert.Equal(t, RateLimitScopeGlobal, scope)
+	// The rejected request does not use up the client's own allowance.
+	assert.Equal(t, 5.0, l.clients["10.0.0.2"].tokens)
+}
+
+func TestRateLimiterDisabled(t *testing.T) {
+	l, _ := newTestLimiter(RateLimitConfig{})
+	for range 100 {
+		ok, _, _ := l.allow("10.0.0.1")
+		require.True(t, ok)
+	}
+}
+
+func TestRateLimiterSweepsIdleClients(t *testing.T) {
+	l, now := newTestLimiter(RateLimitConfig{PerIPPerMinute: 60, PerIPBurst: 10})
+	l.allow("10.0.0.1")
+	*now = now.Add(time.Minute)
+	l.allow("10.0.0.2")
+
+	assert.NotContains(t, l.clients, "10.0.0.1")
+	assert.Contains(t, l.clients, "10.0.0.2")
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	l, _ := newTestLimiter(RateLimitConfig{PerIPPerMinute: 6, PerIPBurst: 1})
+	h := l.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/api/ask", nil)
+	req.RemoteAddr = "10.0.0.1:51000"
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "10", w.Header().Get("Retry-After"))
+
+	var body map[string]any
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&body))
+	assert.Equal(t, RateLimitScopeIP, body["scope"])
+}
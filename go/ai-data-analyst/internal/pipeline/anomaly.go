@@ -0,0 +1,253 @@
+package pipeline
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Kinds of Anomaly.
+const (
+	AnomalyOutlier      = "outlier"
+	AnomalyMissingYears = "missing_years"
+	AnomalyJump         = "jump"
+)
+
+const (
+	// outlierThreshold is the modified z-score (Iglewicz and Hoaglin) above
+	// which a value, or a year-on-year change, is flagged.
+	outlierThreshold = 3.5
+	// minAnomalyPoints is the fewest values a median and spread are taken
+	// over; shorter series are only checked for gaps and relative jumps.
+	minAnomalyPoints = 5
+	// jumpRatio is the relative year-on-year change flagged in series too
+	// short to have a spread of their own.
+	jumpRatio    = 0.5
+	maxAnomalies = 8
+)
+
+// Anomaly is a statistical finding about the rows, worked out before the
+// Explain stage so the explanation can rest on it instead of on what the
+// model guesses from the first rows.
+type Anomaly struct {
+	Kind    string  `json:"kind"`
+	Series  string  `json:"series,omitempty"`
+	Year    int     `json:"year,omitempty"`
+	Years   []int   `json:"years,omitempty"`
+	Value   float64 `json:"value,omitempty"`
+	Message string  `json:"message"`
+}
+
+type anomalyPoint struct {
+	year  int
+	value float64
+	label string
+}
+
+// DetectAnomalies checks the value column of the rows for outliers and, when
+// there is a year column, each series (grouped by the first text column) for
+// missing years and sudden jumps. Columns are found as in Forecast. At most
+// maxAnomalies are returned.
+func DetectAnomalies(execResult *ExecuteResult) []Anomaly {
+	yearCol, valueCol, labelCol := forecastColumns(execResult)
+	if valueCol < 0 {
+		return nil
+	}
+	column := execResult.Columns[valueCol]
+
+	var order []string
+	series := map[string][]anomalyPoint{}
+	for _, row := range execResult.Rows {
+		if valueCol >= len(row) {
+			continue
+		}
+		value, ok := toFloat(row[valueCol])
+		if !ok {
+			continue
+		}
+		p := anomalyPoint{value: value}
+		if labelCol >= 0 && row[labelCol] != nil {
+			p.label, _ = row[labelCol].(string)
+		}
+		// Without years the rows are one cross-section, such as a ranking,
+		// and each value is judged against all of them.
+		key := ""
+		if yearCol >= 0 {
+			year, ok := toFloat(row[yearCol])
+			if !ok {
+				continue
+			}
+			p.year = int(year)
+			key = p.label
+		}
+		if _, seen := series[key]; !seen {
+			order = append(order, key)
+		}
+		series[key] = append(series[key], p)
+	}
+
+	var found []Anomaly
+	for _, key := range order {
+		points := series[key]
+		if yearCol >= 0 {
+			sort.SliceStable(points, func(i, j int) bool { return points[i].year < points[j].year })
+			found = append(found, missingYears(column, key, points)...)
+			found = append(found, jumps(column, key, points)...)
+		}
+		found = append(found, outliers(column, yearCol >= 0, points)...)
+	}
+	if len(found) > maxAnomalies {
+		found = found[:maxAnomalies]
+	}
+	return found
+}
+
+// missingYears reports the gaps between the first and last year of a series.
+func missingYears(column, label string, points []anomalyPoint) []Anomaly {
+	var missing []int
+	for i := 1; i < len(points); i++ {
+		for y := points[i-1].year + 1; y < points[i].year; y++ {
+			missing = append(missing, y)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	return []Anomaly{{
+		Kind:    AnomalyMissingYears,
+		Series:  label,
+		Years:   missing,
+		Message: fmt.Sprintf("No %s%s in %s.", column, forSeries(label), formatYears(missing)),
+	}}
+}
+
+// jumps reports year-on-year changes far out of line with the series' other
+// changes or, for short series, larger than jumpRatio of the previous value.
+func jumps(column, label string, points []anomalyPoint) []Anomaly {
+	if len(points) < 2 {
+		return nil
+	}
+	changes := make([]float64, len(points)-1)
+	for i := 1; i < len(points); i++ {
+		changes[i-1] = points[i].value - points[i-1].value
+	}
+	median, mad := medianAbsoluteDeviation(changes)
+
+	var found []Anomaly
+	for i, change := range changes {
+		prev, cur := points[i], points[i+1]
+		var flagged bool
+		if len(changes) >= minAnomalyPoints && mad > 0 {
+			flagged = modifiedZ(change, median, mad) > outlierThreshold
+		} else {
+			flagged = prev.value != 0 && math.Abs(change/prev.value) > jumpRatio
+		}
+		if !flagged {
+			continue
+		}
+		msg := fmt.Sprintf("%s%s moved from %s in %d to %s in %d", column, forSeries(label),
+			formatValue(prev.value), prev.year, formatValue(cur.value), cur.year)
+		if prev.value != 0 {
+			msg += fmt.Sprintf(" (%+.0f%%)", change/math.Abs(prev.value)*100)
+		}
+		found = append(found, Anomaly{
+			Kind:    AnomalyJump,
+			Series:  label,
+			Year:    cur.year,
+			Value:   cur.value,
+			Message: msg + ".",
+		})
+	}
+	return found
+}
+
+// outliers reports values whose modified z-score within points exceeds
+// outlierThreshold.
+func outliers(column string, byYear bool, points []anomalyPoint) []Anomaly {
+	if len(points) < minAnomalyPoints {
+		return nil
+	}
+	values := make([]float64, len(points))
+	for i, p := range points {
+		values[i] = p.value
+	}
+	median, mad := medianAbsoluteDeviation(values)
+	if mad == 0 {
+		return nil
+	}
+
+	var found []Anomaly
+	for _, p := range points {
+		if modifiedZ(p.value, median, mad) <= outlierThreshold {
+			continue
+		}
+		a := Anomaly{Kind: AnomalyOutlier, Series: p.label, Value: p.value}
+		if byYear {
+			a.Year = p.year
+			a.Message = fmt.Sprintf("%s%s in %d (%s) is far from the series median (%s).",
+				column, forSeries(p.label), p.year, formatValue(p.value), formatValue(median))
+		} else {
+			a.Message = fmt.Sprintf("%s%s (%s) is far from the median of the results (%s).",
+				column, forSeries(p.label), formatValue(p.value), formatValue(median))
+		}
+		found = append(found, a)
+	}
+	return found
+}
+
+func medianAbsoluteDeviation(values []float64) (median, mad float64) {
+	median = medianOf(values)
+	deviations := make([]float64, len(values))
+	for i, v := range values {
+		deviations[i] = math.Abs(v - median)
+	}
+	return median, medianOf(deviations)
+}
+
+func medianOf(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	n := len(sorted)
+	if n == 0 {
+		return 0
+	}
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+func modifiedZ(v, median, mad float64) float64 {
+	return math.Abs(0.6745 * (v - median) / mad)
+}
+
+func forSeries(label string) string {
+	if label == "" {
+		return ""
+	}
+	return " for " + label
+}
+
+// formatYears writes runs of consecutive years as ranges: 2010-2012, 2015.
+func formatYears(years []int) string {
+	var parts []string
+	for i := 0; i < len(years); {
+		j := i
+		for j+1 < len(years) && years[j+1] == years[j]+1 {
+			j++
+		}
+		if j > i {
+			parts = append(parts, fmt.Sprintf("%d-%d", years[i], years[j]))
+		} else {
+			parts = append(parts, strconv.Itoa(years[i]))
+		}
+		i = j + 1
+	}
+	return strings.Join(parts, ", ")
+}
+
+func formatValue(v float64) string {
+	return strconv.FormatFloat(math.Round(v*100)/100, 'f', -1, 64)
+}
@@ -0,0 +1,128 @@
+package pipeline
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func anomaliesOf(kind string, all []Anomaly) []Anomaly {
+	var out []Anomaly
+	for _, a := range all {
+		if a.Kind == kind {
+			out = append(out, a)
+		}
+	}
+	return out
+}
+
+func TestDetectAnomaliesMissingYears(t *testing.T) {
+	execResult := &ExecuteResult{
+		Columns: []string{"country", "year", "value"},
+		Rows: [][]any{
+			{"India", int32(2008), 1.0},
+			{"India", int32(2009), 1.1},
+			{"India", int32(2012), 1.2},
+			{"India", int32(2014), 1.3},
+		},
+	}
+
+	missing := anomaliesOf(AnomalyMissingYears, DetectAnomalies(execResult))
+	require.Len(t, missing, 1)
+	assert.Equal(t, "India", missing[0].Series)
+	assert.Equal(t, []int{2010, 2011, 2013}, missing[0].Years)
+	assert.Equal(t, "No value for India in 2010-2011, 2013.", missing[0].Message)
+}
+
+func TestDetectAnomaliesJump(t *testing.T) {
+	execResult := &ExecuteResult{
+		Columns: []string{"year", "gdp_growth"},
+		Rows: [][]any{
+			{int32(2015), 2.0},
+			{int32(2016), 2.1},
+			{int32(2017), 2.2},
+			{int32(2018), 2.1},
+			{int32(2019), 2.3},
+			{int32(2020), -6.0},
+			{int32(2021), -5.8},
+		},
+	}
+
+	jumps := anomaliesOf(AnomalyJump, DetectAnomalies(execResult))
+	require.Len(t, jumps, 1)
+	assert.Equal(t, 2020, jumps[0].Year)
+	assert.Equal(t, "gdp_growth moved from 2.3 in 2019 to -6 in 2020 (-361%).", jumps[0].Message)
+}
+
+func TestDetectAnomaliesShortSeriesJump(t *testing.T) {
+	execResult := &ExecuteResult{
+		Columns: []string{"country", "year", "value"},
+		Rows: [][]any{
+			{"Chad", int32(2020), "10"},
+			{"Chad", int32(2021), "11"},
+			{"Chad", int32(2022), "25"},
+		},
+	}
+
+	jumps := anomaliesOf(AnomalyJump, DetectAnomalies(execResult))
+	require.Len(t, jumps, 1)
+	assert.Equal(t, "Chad", jumps[0].Series)
+	assert.Equal(t, 2022, jumps[0].Year)
+}
+
+func TestDetectAnomaliesCrossSectionOutlier(t *testing.T) {
+	execResult := &ExecuteResult{
+		Columns: []string{"country", "co2_per_capita"},
+		Rows: [][]any{
+			{"Qatar", 37.0},
+			{"India", 1.9},
+			{"Brazil", 2.2},
+			{"Mexico", 3.6},
+			{"Kenya", 0.4},
+			{"France", 4.6},
+		},
+	}
+
+	found := DetectAnomalies(execResult)
+	require.Len(t, found, 1)
+	assert.Equal(t, AnomalyOutlier, found[0].Kind)
+	assert.Equal(t, "Qatar", found[0].Series)
+	assert.Zero(t, found[0].Year)
+	assert.Contains(t, found[0].Message, "co2_per_capita for Qatar (37)")
+}
+
+func TestDetectAnomaliesSteadySeries(t *testing.T) {
+	execResult := &ExecuteResult{
+		Columns: []string{"country", "year", "value"},
+		Rows: [][]any{
+			{"Japan", int32(2019), 84.0},
+			{"Japan", int32(2020), 84.5},
+			{"Japan", int32(2021), 84.4},
+			{"Japan", int32(2022), 84.8},
+			{"Japan", int32(2023), 85.0},
+		},
+	}
+	assert.Empty(t, DetectAnomalies(execResult))
+}
+
+func TestDetectAnomaliesWithoutValueColumn(t *testing.T) {
+	execResult := &ExecuteResult{
+		Columns: []string{"country"},
+		Rows:    [][]any{{"India"}},
+	}
+	assert.Nil(t, DetectAnomalies(execResult))
+	assert.Nil(t, DetectAnomalies(&ExecuteResult{}))
+}
+
+func TestDetectAnomaliesCapped(t *testing.T) {
+	execResult := &ExecuteResult{Columns: []string{"country", "year", "value"}}
+	for i := range 2 * maxAnomalies {
+		label := string(rune('A' + i))
+		execResult.Rows = append(execResult.Rows,
+			[]any{label, int32(2000), 1.0},
+			[]any{label, int32(2005), 1.0},
+		)
+	}
+	assert.Len(t, DetectAnomalies(execResult), maxAnomalies)
+}
@@ -0,0 +1,115 @@
+package pipeline
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// BatchItem is the outcome of one question of a batch: its result, or the
+// error that stopped it.
+type BatchItem struct {
+	Index    int        `json:"index"`
+	Question string     `json:"question"`
+	Result   *AskResult `json:"result,omitempty"`
+	Error    string     `json:"error,omitempty"`
+	err      error
+}
+
+// Err returns the error that stopped the question, if any.
+func (b *BatchItem) Err() error {
+	return b.err
+}
+
+type BatchResult struct {
+	Results      []BatchItem `json:"results"`
+	Succeeded    int         `json:"succeeded"`
+	Failed       int         `json:"failed"`
+	TotalTokens  int         `json:"total_tokens"`
+	TotalCostUSD float64     `json:"total_cost_usd"`
+	DurationMS   int64       `json:"duration_ms"`
+	TraceID      string      `json:"trace_id"`
+}
+
+// AskBatch answers the questions with at most concurrency running at once.
+// See RunBatch.
+func (p *Pipeline) AskBatch(ctx context.Context, questions []string, concurrency int) *BatchResult {
+	return RunBatch(ctx, p.Tracer, questions, concurrency, p.Ask)
+}
+
+// RunBatch asks every question under one "pipeline batch" span, so each
+// question's "pipeline ask" span is its child and the whole batch is one
+// trace. Results keep the order of questions. A failed question is
+// recorded in its item and does not stop the others; totals count only the
+// questions that were answered.
+func RunBatch(ctx context.Context, tracer trace.Tracer, questions []string, concurrency int,
+	ask func(context.Context, string) (*AskResult, error)) *BatchResult {
+	start := time.Now()
+	ctx, span := tracer.Start(ctx, "pipeline batch")
+	defer span.End()
+
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	if concurrency > len(questions) {
+		concurrency = len(questions)
+	}
+	span.SetAttributes(
+		attribute.Int("nlsql.batch.size", len(questions)),
+		attribute.Int("nlsql.batch.concurrency", concurrency),
+	)
+
+	result := &BatchResult{
+		Results: make([]BatchItem, len(questions)),
+		TraceID: span.SpanContext().TraceID().String(),
+	}
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for range concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				item := &result.Results[i]
+				item.Index, item.Question = i, questions[i]
+				item.Result, item.err = ask(ctx, questions[i])
+				if item.err != nil {
+					item.Result = nil
+					item.Error = item.err.Error()
+				}
+			}
+		}()
+	}
+	for i := range questions {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	for _, item := range result.Results {
+		if item.err != nil {
+			result.Failed++
+			continue
+		}
+		result.Succeeded++
+		result.TotalTokens += item.Result.TotalTokens
+		result.TotalCostUSD += item.Result.TotalCostUSD
+	}
+	result.DurationMS = time.Since(start).Milliseconds()
+
+	span.SetAttributes(
+		attribute.Int("nlsql.batch.succeeded", result.Succeeded),
+		attribute.Int("nlsql.batch.failed", result.Failed),
+		attribute.Int("nlsql.batch.total_tokens", result.TotalTokens),
+		attribute.Float64("nlsql.batch.cost_usd", result.TotalCostUSD),
+	)
+	if result.Failed > 0 && result.Succeeded == 0 {
+		span.SetStatus(codes.Error, "every question in the batch failed")
+	}
+	return result
+}
@@ -0,0 +1,67 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/base-14/examples/go/pkg/oteltest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestRunBatch(t *testing.T) {
+	tel := oteltest.New(t)
+	tracer := tel.Tracer("test")
+
+	var running, peak atomic.Int32
+	ask := func(ctx context.Context, question string) (*AskResult, error) {
+		_, span := tracer.Start(ctx, "pipeline ask")
+		defer span.End()
+
+		if n := running.Add(1); n > peak.Load() {
+			peak.Store(n)
+		}
+		defer running.Add(-1)
+		time.Sleep(5 * time.Millisecond)
+
+		if question == "bad" {
+			return nil, errors.New("generate stage failed")
+		}
+		return &AskResult{Question: question, TotalTokens: 100, TotalCostUSD: 0.01,
+			TraceID: trace.SpanContextFromContext(ctx).TraceID().String()}, nil
+	}
+
+	questions := []string{"q0", "bad", "q2", "q3", "q4"}
+	result := RunBatch(context.Background(), tracer, questions, 2, ask)
+
+	require.Len(t, result.Results, 5)
+	for i, item := range result.Results {
+		assert.Equal(t, i, item.Index)
+		assert.Equal(t, questions[i], item.Question)
+	}
+	assert.Equal(t, "generate stage failed", result.Results[1].Error)
+	assert.Nil(t, result.Results[1].Result)
+	assert.Equal(t, 4, result.Succeeded)
+	assert.Equal(t, 1, result.Failed)
+	assert.Equal(t, 400, result.TotalTokens)
+	assert.InDelta(t, 0.04, result.TotalCostUSD, 1e-9)
+	assert.LessOrEqual(t, peak.Load(), int32(2))
+
+	// Every question runs in the batch's trace, under its span.
+	assert.Equal(t, result.TraceID, result.Results[0].Result.TraceID)
+	batch := tel.Span(t, "pipeline batch")
+	assert.Contains(t, batch.Attributes, attribute.Int("nlsql.batch.failed", 1))
+	children := 0
+	for _, s := range tel.Spans() {
+		if s.Name == "pipeline ask" {
+			assert.Equal(t, batch.SpanContext.SpanID(), s.Parent.SpanID())
+			children++
+		}
+	}
+	assert.Equal(t, 5, children)
+}
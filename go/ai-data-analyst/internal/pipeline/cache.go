@@ -0,0 +1,198 @@
+package pipeline
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"ai-data-analyst/internal/cache"
+	"ai-data-analyst/internal/telemetry"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Cache levels. A question hit returns a whole earlier answer, skipping the
+// LLM and the database; a SQL hit reuses the rows of an earlier query that
+// validated to the same SQL, skipping only the database.
+const (
+	CacheLevelQuestion = "question"
+	CacheLevelSQL      = "sql"
+)
+
+// ResultCache caches answers by normalized question and query results by
+// validated SQL.
+type ResultCache struct {
+	Store   cache.Store
+	TTL     time.Duration
+	Metrics *telemetry.GenAIMetrics
+
+	questionHits, questionMisses atomic.Int64
+	sqlHits, sqlMisses           atomic.Int64
+	errors                       atomic.Int64
+
+	// generation moves every key on Invalidate, so entries stored before it
+	// are never read again and expire with their TTL.
+	generation atomic.Int64
+}
+
+// Invalidate drops every entry from this replica's view of the cache, for
+// when the data under the cached answers has changed.
+func (c *ResultCache) Invalidate() {
+	c.generation.Add(1)
+}
+
+func (c *ResultCache) scoped(key string) string {
+	if g := c.generation.Load(); g > 0 {
+		return "g" + strconv.FormatInt(g, 10) + ":" + key
+	}
+	return key
+}
+
+type CacheLevelStats struct {
+	Hits     int64   `json:"hits"`
+	Misses   int64   `json:"misses"`
+	HitRatio float64 `json:"hit_ratio"`
+}
+
+type CacheStats struct {
+	Backend    string                     `json:"backend"`
+	Entries    int                        `json:"entries"`
+	Evictions  *int64                     `json:"evictions,omitempty"`
+	TTLSeconds int64                      `json:"ttl_seconds"`
+	Errors     int64                      `json:"errors"`
+	Levels     map[string]CacheLevelStats `json:"levels"`
+}
+
+// Stats reports lookups since startup. Entries is -1 for backends that
+// cannot count theirs.
+func (c *ResultCache) Stats(ctx context.Context) CacheStats {
+	level := func(hits, misses int64) CacheLevelStats {
+		s := CacheLevelStats{Hits: hits, Misses: misses}
+		if total := hits + misses; total > 0 {
+			s.HitRatio = float64(hits) / float64(total)
+		}
+		return s
+	}
+	stats := CacheStats{
+		Backend:    c.Store.Name(),
+		Entries:    c.Store.Len(ctx),
+		TTLSeconds: int64(c.TTL.Seconds()),
+		Errors:     c.errors.Load(),
+		Levels: map[string]CacheLevelStats{
+			CacheLevelQuestion: level(c.questionHits.Load(), c.questionMisses.Load()),
+			CacheLevelSQL:      level(c.sqlHits.Load(), c.sqlMisses.Load()),
+		},
+	}
+	if lru, ok := c.Store.(*cache.LRU); ok {
+		evictions := lru.Evictions()
+		stats.Evictions = &evictions
+	}
+	return stats
+}
+
+// normalizeQuestion folds case, whitespace and trailing punctuation so
+// trivially different phrasings of a question share an entry.
+func normalizeQuestion(question string) string {
+	q := strings.Join(strings.Fields(strings.ToLower(question)), " ")
+	return strings.TrimRight(q, "?!. ")
+}
+
+// questionKey also covers the dialect, the runtime settings version and the
+// prompt versions, so changing the models, row limit or prompts through the
+// admin API starts afresh.
+func questionKey(question, dialect string, version int64, prompts string) string {
+	return cacheKey(CacheLevelQuestion, dialect, strconv.FormatInt(version, 10), prompts, normalizeQuestion(question))
+}
+
+func sqlKey(sql, dialect string) string {
+	return cacheKey(CacheLevelSQL, dialect, strings.TrimSpace(sql))
+}
+
+func cacheKey(level string, parts ...string) string {
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return level + ":" + hex.EncodeToString(sum[:])
+}
+
+// get decodes the entry at key into v. Backend errors count as misses: the
+// cache must never fail a question.
+func (c *ResultCache) get(ctx context.Context, level, key string, v any) bool {
+	data, ok, err := c.Store.Get(ctx, c.scoped(key))
+	if err == nil && ok {
+		err = json.Unmarshal(data, v)
+	}
+	if err != nil {
+		c.errors.Add(1)
+		trace.SpanFromContext(ctx).AddEvent("cache_error", trace.WithAttributes(
+			attribute.String("nlsql.cache.level", level),
+			attribute.String("error.message", err.Error()),
+		))
+		ok = false
+	}
+
+	hits, misses := &c.questionHits, &c.questionMisses
+	if level == CacheLevelSQL {
+		hits, misses = &c.sqlHits, &c.sqlMisses
+	}
+	if ok {
+		hits.Add(1)
+	} else {
+		misses.Add(1)
+	}
+	if c.Metrics != nil {
+		counter := c.Metrics.CacheMisses
+		if ok {
+			counter = c.Metrics.CacheHits
+		}
+		counter.Add(ctx, 1, telemetry.WithCacheLevel(level, c.Store.Name()))
+	}
+	return ok
+}
+
+func (c *ResultCache) put(ctx context.Context, key string, v any) {
+	data, err := json.Marshal(v)
+	if err == nil {
+		err = c.Store.Set(ctx, c.scoped(key), data, c.TTL)
+	}
+	if err != nil {
+		c.errors.Add(1)
+		trace.SpanFromContext(ctx).AddEvent("cache_error", trace.WithAttributes(
+			attribute.String("error.message", err.Error()),
+		))
+	}
+}
+
+// cachedRows is an ExecuteResult as stored in the cache.
+type cachedRows struct {
+	Columns    []string `json:"columns"`
+	Rows       [][]any  `json:"rows"`
+	RowCount   int      `json:"row_count"`
+	DurationMS int64    `json:"duration_ms"`
+}
+
+func (c *ResultCache) getRows(ctx context.Context, sql, dialect string) (*ExecuteResult, bool) {
+	var cr cachedRows
+	if !c.get(ctx, CacheLevelSQL, sqlKey(sql, dialect), &cr) {
+		return nil, false
+	}
+	return &ExecuteResult{
+		Columns:  cr.Columns,
+		Rows:     cr.Rows,
+		RowCount: cr.RowCount,
+		Duration: time.Duration(cr.DurationMS) * time.Millisecond,
+	}, true
+}
+
+func (c *ResultCache) putRows(ctx context.Context, sql, dialect string, r *ExecuteResult) {
+	c.put(ctx, sqlKey(sql, dialect), cachedRows{
+		Columns:    r.Columns,
+		Rows:       r.Rows,
+		RowCount:   r.RowCount,
+		DurationMS: r.Duration.Milliseconds(),
+	})
+}
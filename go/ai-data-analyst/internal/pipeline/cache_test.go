@@ -0,0 +1,95 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"ai-data-analyst/internal/cache"
+	"ai-data-analyst/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeQuestion(t *testing.T) {
+	assert.Equal(t, "top 10 countries by gdp", normalizeQuestion("  Top 10   countries by GDP? "))
+	assert.Equal(t, questionKey("Top 10 countries by GDP?", "postgres", 1, ""), questionKey("top 10 countries  by gdp", "postgres", 1, ""))
+	assert.NotEqual(t, questionKey("top 10 countries by gdp", "postgres", 1, ""), questionKey("top 10 countries by gdp", "postgres", 2, ""))
+	assert.NotEqual(t, questionKey("top 10 countries by gdp", "postgres", 1, ""), questionKey("top 10 countries by gdp", "mysql", 1, ""))
+}
+
+func TestResultCacheRows(t *testing.T) {
+	ctx := context.Background()
+	c := &ResultCache{Store: cache.NewLRU(10), TTL: time.Minute}
+
+	_, ok := c.getRows(ctx, "SELECT 1 LIMIT 50", "postgres")
+	assert.False(t, ok)
+
+	c.putRows(ctx, "SELECT 1 LIMIT 50", "postgres", &ExecuteResult{
+		Columns:  []string{"x"},
+		Rows:     [][]any{{1}},
+		RowCount: 1,
+		Duration: 12 * time.Millisecond,
+	})
+	r, ok := c.getRows(ctx, " SELECT 1 LIMIT 50 ", "postgres")
+	require.True(t, ok)
+	assert.Equal(t, []string{"x"}, r.Columns)
+	assert.Equal(t, 1, r.RowCount)
+	assert.Equal(t, 12*time.Millisecond, r.Duration)
+
+	stats := c.Stats(ctx)
+	assert.Equal(t, "memory", stats.Backend)
+	assert.Equal(t, 1, stats.Entries)
+	assert.Equal(t, CacheLevelStats{Hits: 1, Misses: 1, HitRatio: 0.5}, stats.Levels[CacheLevelSQL])
+	assert.Equal(t, CacheLevelStats{}, stats.Levels[CacheLevelQuestion])
+}
+
+func TestResultCacheInvalidate(t *testing.T) {
+	ctx := context.Background()
+	c := &ResultCache{Store: cache.NewLRU(10), TTL: time.Minute}
+
+	c.putRows(ctx, "SELECT 1", "postgres", &ExecuteResult{Columns: []string{"x"}, Rows: [][]any{{1}}, RowCount: 1})
+	_, ok := c.getRows(ctx, "SELECT 1", "postgres")
+	require.True(t, ok)
+
+	c.Invalidate()
+	_, ok = c.getRows(ctx, "SELECT 1", "postgres")
+	assert.False(t, ok)
+
+	c.putRows(ctx, "SELECT 1", "postgres", &ExecuteResult{Columns: []string{"y"}, Rows: [][]any{{2}}, RowCount: 1})
+	r, ok := c.getRows(ctx, "SELECT 1", "postgres")
+	require.True(t, ok)
+	assert.Equal(t, []string{"y"}, r.Columns)
+}
+
+func TestAskAnswersRepeatedQuestionFromCache(t *testing.T) {
+	ctx := context.Background()
+	cfg := &config.Config{RowLimit: 50, MinConfidence: 0.3}
+	p := &Pipeline{
+		Tracer: testTracer().Tracer("test"),
+		Config: cfg,
+		Cache:  &ResultCache{Store: cache.NewLRU(10), TTL: time.Minute},
+	}
+	settings := p.Settings()
+
+	// No LLM or database is configured, so only a cache hit can answer.
+	p.Cache.put(ctx, questionKey("Top 5 countries by population", "postgres", settings.Version, p.Prompts.snapshot().key()), &AskResult{
+		Question:     "Top 5 countries by population",
+		SQL:          "SELECT name FROM countries LIMIT 5",
+		Columns:      []string{"name"},
+		RowCount:     5,
+		TotalTokens:  900,
+		TotalCostUSD: 0.01,
+		TraceID:      "old",
+	})
+
+	result, err := p.Ask(ctx, "top 5 countries by population?")
+	require.NoError(t, err)
+	assert.Equal(t, CacheLevelQuestion, result.CacheHit)
+	assert.Equal(t, "SELECT name FROM countries LIMIT 5", result.SQL)
+	assert.Zero(t, result.TotalTokens)
+	assert.Zero(t, result.TotalCostUSD)
+	assert.NotEqual(t, "old", result.TraceID)
+	assert.Equal(t, int64(1), p.Cache.Stats(ctx).Levels[CacheLevelQuestion].Hits)
+}
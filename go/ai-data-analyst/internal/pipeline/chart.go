@@ -0,0 +1,221 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Chart types, named after the Vega-Lite marks they map to.
+const (
+	ChartLine  = "line"
+	ChartBar   = "bar"
+	ChartPoint = "point"
+)
+
+// Vega-Lite encoding types used in chart fields.
+const (
+	FieldQuantitative = "quantitative"
+	FieldTemporal     = "temporal"
+	FieldNominal      = "nominal"
+)
+
+// maxChartCategories caps the bars or series in a chart; past that it is
+// unreadable and the table is the better view.
+const maxChartCategories = 25
+
+// ChartField is one encoding channel: the result column it reads and its
+// Vega-Lite type.
+type ChartField struct {
+	Field string `json:"field"`
+	Type  string `json:"type"`
+}
+
+// ChartSpec is a Vega-Lite style chart of an answer's rows: a mark type and
+// the columns on each axis, with Series splitting the rows into lines.
+// Front ends fill in the data from the response's columns and rows.
+type ChartSpec struct {
+	Type   string      `json:"type"`
+	X      ChartField  `json:"x"`
+	Y      ChartField  `json:"y"`
+	Series *ChartField `json:"series,omitempty"`
+}
+
+type chartOptionKey struct{}
+
+// WithChart returns a context under which the Explain stage adds a chart
+// spec to its result.
+func WithChart(ctx context.Context) context.Context {
+	return context.WithValue(ctx, chartOptionKey{}, true)
+}
+
+func chartRequested(ctx context.Context) bool {
+	ok, _ := ctx.Value(chartOptionKey{}).(bool)
+	return ok
+}
+
+// InferChart picks a chart for the result from its column names and value
+// types: a line per label over a year column, bars for a label and a
+// number, or a scatter of two numbers. It returns nil when no chart fits.
+func InferChart(execResult *ExecuteResult) *ChartSpec {
+	if execResult == nil || len(execResult.Rows) == 0 {
+		return nil
+	}
+
+	yearCol, labelCol := -1, -1
+	var numeric []int
+	for i, name := range execResult.Columns {
+		switch {
+		case yearCol < 0 && strings.Contains(strings.ToLower(name), "year"):
+			yearCol = i
+		case columnIs(execResult, i, isNumber):
+			numeric = append(numeric, i)
+		case labelCol < 0 && columnIs(execResult, i, isText):
+			labelCol = i
+		}
+	}
+	field := func(col int, typ string) ChartField {
+		return ChartField{Field: execResult.Columns[col], Type: typ}
+	}
+
+	switch {
+	case yearCol >= 0 && len(numeric) > 0:
+		spec := &ChartSpec{
+			Type: ChartLine,
+			X:    field(yearCol, FieldTemporal),
+			Y:    field(numeric[len(numeric)-1], FieldQuantitative),
+		}
+		if labelCol >= 0 {
+			series := field(labelCol, FieldNominal)
+			spec.Series = &series
+		}
+		return spec
+	case labelCol >= 0 && len(numeric) > 0:
+		return &ChartSpec{
+			Type: ChartBar,
+			X:    field(labelCol, FieldNominal),
+			Y:    field(numeric[len(numeric)-1], FieldQuantitative),
+		}
+	case len(numeric) >= 2:
+		return &ChartSpec{
+			Type: ChartPoint,
+			X:    field(numeric[0], FieldQuantitative),
+			Y:    field(numeric[1], FieldQuantitative),
+		}
+	}
+	return nil
+}
+
+// ValidateChart checks that spec can be drawn from the result: a known mark,
+// axes on existing columns, a numeric y, and few enough categories.
+func ValidateChart(spec *ChartSpec, execResult *ExecuteResult) error {
+	var errs []error
+	switch spec.Type {
+	case ChartLine, ChartBar, ChartPoint:
+	default:
+		errs = append(errs, fmt.Errorf("unknown chart type %q", spec.Type))
+	}
+
+	fields := []*ChartField{&spec.X, &spec.Y}
+	if spec.Series != nil {
+		fields = append(fields, spec.Series)
+	}
+	cols := map[*ChartField]int{}
+	for _, f := range fields {
+		col := columnIndex(execResult.Columns, f.Field)
+		if col < 0 {
+			errs = append(errs, fmt.Errorf("chart field %q is not a result column", f.Field))
+			continue
+		}
+		switch f.Type {
+		case FieldQuantitative, FieldTemporal, FieldNominal:
+		default:
+			errs = append(errs, fmt.Errorf("chart field %q has unknown type %q", f.Field, f.Type))
+		}
+		cols[f] = col
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	if !columnIs(execResult, cols[&spec.Y], isNumber) {
+		errs = append(errs, fmt.Errorf("chart y field %q is not numeric", spec.Y.Field))
+	}
+	if spec.X.Type == FieldNominal {
+		if n := distinctValues(execResult, cols[&spec.X]); n > maxChartCategories {
+			errs = append(errs, fmt.Errorf("chart has %d categories, more than %d", n, maxChartCategories))
+		}
+	}
+	if spec.Series != nil {
+		if n := distinctValues(execResult, cols[spec.Series]); n > maxChartCategories {
+			errs = append(errs, fmt.Errorf("chart has %d series, more than %d", n, maxChartCategories))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// chartFor infers and validates the chart for a result, recording on span
+// which chart was chosen, or why there is none.
+func chartFor(span trace.Span, execResult *ExecuteResult) *ChartSpec {
+	spec := InferChart(execResult)
+	if spec == nil {
+		span.SetAttributes(attribute.String("nlsql.chart", "none"))
+		return nil
+	}
+	if err := ValidateChart(spec, execResult); err != nil {
+		span.SetAttributes(attribute.String("nlsql.chart", "invalid"))
+		span.AddEvent("chart_rejected", trace.WithAttributes(attribute.String("nlsql.chart.error", err.Error())))
+		return nil
+	}
+	span.SetAttributes(attribute.String("nlsql.chart", spec.Type))
+	return spec
+}
+
+func isNumber(v any) bool {
+	_, ok := toFloat(v)
+	return ok
+}
+
+func isText(v any) bool {
+	_, ok := v.(string)
+	return ok
+}
+
+// columnIs reports whether every non-null value in the column satisfies
+// match, and there is at least one.
+func columnIs(execResult *ExecuteResult, col int, match func(any) bool) bool {
+	seen := false
+	for _, row := range execResult.Rows {
+		if col >= len(row) || row[col] == nil {
+			continue
+		}
+		if !match(row[col]) {
+			return false
+		}
+		seen = true
+	}
+	return seen
+}
+
+func distinctValues(execResult *ExecuteResult, col int) int {
+	seen := map[string]bool{}
+	for _, row := range execResult.Rows {
+		if col < len(row) {
+			seen[fmt.Sprint(row[col])] = true
+		}
+	}
+	return len(seen)
+}
+
+func columnIndex(columns []string, name string) int {
+	for i, c := range columns {
+		if c == name {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,98 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInferChartLineBySeries(t *testing.T) {
+	spec := InferChart(&ExecuteResult{
+		Columns: []string{"country", "year", "value"},
+		Rows: [][]any{
+			{"USA", int32(2020), "2.3"},
+			{"USA", int32(2021), "5.9"},
+			{"CHN", int32(2020), nil},
+		},
+	})
+	require.NotNil(t, spec)
+	assert.Equal(t, ChartLine, spec.Type)
+	assert.Equal(t, ChartField{Field: "year", Type: FieldTemporal}, spec.X)
+	assert.Equal(t, ChartField{Field: "value", Type: FieldQuantitative}, spec.Y)
+	require.NotNil(t, spec.Series)
+	assert.Equal(t, ChartField{Field: "country", Type: FieldNominal}, *spec.Series)
+}
+
+func TestInferChartBar(t *testing.T) {
+	spec := InferChart(&ExecuteResult{
+		Columns: []string{"name", "population"},
+		Rows:    [][]any{{"India", int64(1417)}, {"China", int64(1412)}},
+	})
+	require.NotNil(t, spec)
+	assert.Equal(t, ChartBar, spec.Type)
+	assert.Equal(t, "name", spec.X.Field)
+	assert.Equal(t, "population", spec.Y.Field)
+	assert.Nil(t, spec.Series)
+}
+
+func TestInferChartScatter(t *testing.T) {
+	spec := InferChart(&ExecuteResult{
+		Columns: []string{"gdp_per_capita", "life_expectancy"},
+		Rows:    [][]any{{65000.0, 77.4}, {12000.0, 78.2}},
+	})
+	require.NotNil(t, spec)
+	assert.Equal(t, ChartPoint, spec.Type)
+	assert.Equal(t, FieldQuantitative, spec.X.Type)
+}
+
+func TestInferChartNone(t *testing.T) {
+	assert.Nil(t, InferChart(&ExecuteResult{Columns: []string{"name"}, Rows: [][]any{{"France"}}}))
+	assert.Nil(t, InferChart(&ExecuteResult{Columns: []string{"name", "value"}}))
+	assert.Nil(t, InferChart(nil))
+}
+
+func TestValidateChart(t *testing.T) {
+	result := &ExecuteResult{
+		Columns: []string{"name", "value"},
+		Rows:    [][]any{{"India", 1.5}, {"China", 2.5}},
+	}
+	assert.NoError(t, ValidateChart(&ChartSpec{
+		Type: ChartBar,
+		X:    ChartField{Field: "name", Type: FieldNominal},
+		Y:    ChartField{Field: "value", Type: FieldQuantitative},
+	}, result))
+
+	err := ValidateChart(&ChartSpec{
+		Type: "pie",
+		X:    ChartField{Field: "name", Type: FieldNominal},
+		Y:    ChartField{Field: "missing", Type: FieldQuantitative},
+	}, result)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unknown chart type "pie"`)
+	assert.Contains(t, err.Error(), `"missing" is not a result column`)
+
+	err = ValidateChart(&ChartSpec{
+		Type: ChartBar,
+		X:    ChartField{Field: "value", Type: FieldQuantitative},
+		Y:    ChartField{Field: "name", Type: FieldQuantitative},
+	}, result)
+	assert.ErrorContains(t, err, `y field "name" is not numeric`)
+}
+
+func TestValidateChartTooManyCategories(t *testing.T) {
+	result := &ExecuteResult{Columns: []string{"name", "value"}}
+	for i := range maxChartCategories + 1 {
+		result.Rows = append(result.Rows, []any{fmt.Sprintf("country-%d", i), float64(i)})
+	}
+	spec := InferChart(result)
+	require.NotNil(t, spec)
+	assert.ErrorContains(t, ValidateChart(spec, result), "more than 25")
+}
+
+func TestChartRequested(t *testing.T) {
+	assert.False(t, chartRequested(context.Background()))
+	assert.True(t, chartRequested(WithChart(context.Background())))
+}
@@ -0,0 +1,231 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Kinds of hallucination found by verifyCitations, the
+// nlsql.hallucination.kind of the nlsql.explain.hallucinations metric.
+const (
+	HallucinationRowOutOfRange     = "row_out_of_range"
+	HallucinationUnsupportedNumber = "unsupported_number"
+)
+
+// explainPromptRows is how many rows the explain prompt shows, and so the
+// highest row number an insight can cite.
+const explainPromptRows = 20
+
+// Citation ties an insight to the result rows it is based on.
+//
+// As the LLM returns them, Insight and Rows count from 1, as the prompt
+// numbers rows; verifyCitations turns them into indices into Insights and
+// the result's rows.
+type Citation struct {
+	Insight int   `json:"insight"`
+	Rows    []int `json:"rows"`
+
+	// Verified is set when every row exists and every number in the
+	// insight matches a value in its rows.
+	Verified bool              `json:"verified"`
+	Problems []CitationProblem `json:"problems,omitempty"`
+}
+
+type CitationProblem struct {
+	Kind   string `json:"kind"`
+	Detail string `json:"detail"`
+}
+
+var (
+	// rowRefPattern finds the rows an insight names, as in "row 3" or
+	// "rows 2, 4 and 5".
+	rowRefPattern = regexp.MustCompile(`(?i)\brows?\s+#?\d+(?:\s*(?:,|and|&)\s*#?\d+)*`)
+	digitsPattern = regexp.MustCompile(`\d+`)
+
+	// numberPattern finds quoted figures with an optional magnitude, as in
+	// "1,428,627,663", "7.2%" or "1.43 billion".
+	numberPattern = regexp.MustCompile(`(?i)-?\d[\d,]*(?:\.\d+)?(?:\s*(thousand|million|billion|trillion|bn|tn|[kmb])\b)?`)
+)
+
+var magnitudes = map[string]float64{
+	"k": 1e3, "thousand": 1e3,
+	"m": 1e6, "million": 1e6,
+	"b": 1e9, "bn": 1e9, "billion": 1e9,
+	"tn": 1e12, "trillion": 1e12,
+}
+
+// verifyCitations checks each insight against the rows: the rows it cites,
+// in the response's citations or as "row N" in its text, must be among
+// those the prompt showed, and every number it quotes must match a value in
+// those rows (or in any row, when it cites none) to the precision quoted.
+// Numbers that appear in context, the question and SQL, are not checked.
+// The citations are returned with indices counted from 0.
+func verifyCitations(result *ExplainResult, exec *ExecuteResult, context string) []Citation {
+	shown := min(exec.RowCount, explainPromptRows)
+
+	cited := make(map[int][]int)
+	for _, c := range result.Citations {
+		cited[c.Insight-1] = append(cited[c.Insight-1], c.Rows...)
+	}
+
+	var citations []Citation
+	for i, insight := range result.Insights {
+		refs := rowRefPattern.FindAllStringIndex(insight, -1)
+		rows := cited[i]
+		for _, ref := range refs {
+			for _, d := range digitsPattern.FindAllString(insight[ref[0]:ref[1]], -1) {
+				n, _ := strconv.Atoi(d)
+				rows = append(rows, n)
+			}
+		}
+		slices.Sort(rows)
+		rows = slices.Compact(rows)
+
+		numbers := quotedNumbers(insight, refs)
+		if len(rows) == 0 && len(numbers) == 0 {
+			continue
+		}
+
+		c := Citation{Insight: i, Rows: []int{}}
+		for _, n := range rows {
+			if n < 1 || n > shown {
+				c.Problems = append(c.Problems, CitationProblem{
+					Kind:   HallucinationRowOutOfRange,
+					Detail: fmt.Sprintf("row %d is not among the %d rows shown", n, shown),
+				})
+				continue
+			}
+			c.Rows = append(c.Rows, n-1)
+		}
+
+		candidates := exec.Rows
+		if len(rows) > 0 {
+			candidates = make([][]any, 0, len(c.Rows))
+			for _, idx := range c.Rows {
+				candidates = append(candidates, exec.Rows[idx])
+			}
+		}
+		for _, n := range numbers {
+			if strings.Contains(context, n.text) || n.matchesAny(candidates) {
+				continue
+			}
+			where := "in the result"
+			if len(rows) > 0 {
+				where = "in the cited rows"
+			}
+			c.Problems = append(c.Problems, CitationProblem{
+				Kind:   HallucinationUnsupportedNumber,
+				Detail: fmt.Sprintf("%s is not %s", n.text, where),
+			})
+		}
+		c.Verified = len(c.Problems) == 0
+		citations = append(citations, c)
+	}
+	return citations
+}
+
+// quotedNumber is a figure from an insight, with the tolerance its quoted
+// precision allows: "7.2" covers 7.15 to 7.25, "1.4 billion" 1.35 to 1.45
+// billion.
+type quotedNumber struct {
+	text      string
+	value     float64
+	tolerance float64
+}
+
+// quotedNumbers returns the figures in text outside the row references.
+func quotedNumbers(text string, refs [][]int) []quotedNumber {
+	var numbers []quotedNumber
+	for _, m := range numberPattern.FindAllStringSubmatchIndex(text, -1) {
+		start, end := m[0], m[1]
+		switch {
+		case start > 0 && isWordByte(text[start-1]) && text[start] == '-':
+			// The end of a range, such as 2010-2020, rather than a sign.
+			start++
+		case start > 0 && isWordByte(text[start-1]):
+			// Part of a word, such as CO2.
+			continue
+		}
+		if slices.ContainsFunc(refs, func(r []int) bool { return start >= r[0] && start < r[1] }) {
+			continue
+		}
+
+		digits := text[start:end]
+		scale := 1.0
+		if m[2] >= 0 {
+			scale = magnitudes[strings.ToLower(text[m[2]:m[3]])]
+			digits = strings.TrimSpace(text[start:m[2]])
+		}
+		digits = strings.ReplaceAll(digits, ",", "")
+		v, err := strconv.ParseFloat(digits, 64)
+		if err != nil {
+			continue
+		}
+		decimals := 0
+		if _, frac, ok := strings.Cut(digits, "."); ok {
+			decimals = len(frac)
+		}
+		numbers = append(numbers, quotedNumber{
+			text:      strings.TrimSpace(text[start:end]),
+			value:     v * scale,
+			tolerance: 0.5 * math.Pow10(-decimals) * scale,
+		})
+	}
+	return numbers
+}
+
+func isWordByte(b byte) bool {
+	return b == '_' || b == '.' || b >= '0' && b <= '9' || b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z'
+}
+
+// matchesAny reports whether a numeric value in rows rounds to n. A value
+// within 0.5% also counts, for figures quoted to fewer significant digits
+// than their magnitude suggests, such as "1,430 million".
+func (n quotedNumber) matchesAny(rows [][]any) bool {
+	for _, row := range rows {
+		for _, cell := range row {
+			v, ok := cellNumber(cell)
+			if !ok {
+				continue
+			}
+			diff := math.Abs(v - n.value)
+			if diff <= n.tolerance*1.000001 || v != 0 && diff/math.Abs(v) <= 0.005 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// cellNumber returns a cell's numeric value: a Go number, a numeric string,
+// or a database type such as pgtype.Numeric that marshals to a JSON number.
+func cellNumber(cell any) (float64, bool) {
+	switch v := cell.(type) {
+	case nil, bool:
+		return 0, false
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		return f, err == nil
+	}
+	data, err := json.Marshal(cell)
+	if err != nil {
+		return 0, false
+	}
+	f, err := strconv.ParseFloat(strings.Trim(string(data), `"`), 64)
+	return f, err == nil
+}
@@ -0,0 +1,81 @@
+package pipeline
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var citationRows = &ExecuteResult{
+	Columns: []string{"country", "year", "population", "gdp_growth"},
+	Rows: [][]any{
+		{"China", int64(2021), int64(1412360000), 8.45},
+		{"India", int64(2021), int64(1407563842), 9.05},
+		{"United States", int64(2021), int64(331893745), "5.95"},
+	},
+	RowCount: 3,
+}
+
+func TestVerifyCitations(t *testing.T) {
+	result := parseExplainResponse(`{"summary": "India grew fastest.",
+		"insights": [
+			"India grew 9.05% (row 2: India, 2021), ahead of China at 8.5%",
+			"India's population was about 1.41 billion",
+			"The US grew 6.9% in 2021",
+			"Growth was strongest in Asia"
+		],
+		"citations": [{"insight": 1, "rows": [2, 1]}, {"insight": 2, "rows": [2]}, {"insight": 3, "rows": [3, 7]}]}`)
+	require.Len(t, result.Citations, 3, "parsed as returned")
+
+	citations := verifyCitations(result, citationRows, "Top 3 countries by GDP growth in 2021")
+	require.Len(t, citations, 3, "the insight with no rows and no numbers has no citation")
+
+	assert.Equal(t, Citation{Insight: 0, Rows: []int{0, 1}, Verified: true}, citations[0])
+	assert.Equal(t, Citation{Insight: 1, Rows: []int{1}, Verified: true}, citations[1],
+		"1.41 billion rounds 1,407,563,842")
+
+	assert.Equal(t, 2, citations[2].Insight)
+	assert.Equal(t, []int{2}, citations[2].Rows)
+	assert.False(t, citations[2].Verified)
+	assert.Equal(t, []CitationProblem{
+		{Kind: HallucinationRowOutOfRange, Detail: "row 7 is not among the 3 rows shown"},
+		{Kind: HallucinationUnsupportedNumber, Detail: "6.9 is not in the cited rows"},
+	}, citations[2].Problems)
+}
+
+func TestVerifyCitationsFromText(t *testing.T) {
+	result := &ExplainResult{Insights: []string{
+		"Rows 1 and 3 show growth above 5%",
+		"China grew 8.45% over 2010-2021",
+		"India had 1,500,000,000 people",
+	}}
+	citations := verifyCitations(result, citationRows, "SELECT ... WHERE year BETWEEN 2010 AND 2021")
+	require.Len(t, citations, 3)
+
+	assert.Equal(t, []int{0, 2}, citations[0].Rows, "row references in the text count as citations")
+	assert.Equal(t, []CitationProblem{{Kind: HallucinationUnsupportedNumber, Detail: "5 is not in the cited rows"}},
+		citations[0].Problems)
+
+	assert.True(t, citations[1].Verified, "numbers from the question and SQL are not checked, and 2010-2021 is a range")
+	assert.Empty(t, citations[1].Rows)
+
+	assert.Equal(t, []CitationProblem{{Kind: HallucinationUnsupportedNumber, Detail: "1,500,000,000 is not in the result"}},
+		citations[2].Problems)
+}
+
+func TestQuotedNumbers(t *testing.T) {
+	numbers := quotedNumbers("CO2 fell 12.5% to 3.4 tn tons, or 1,200k, from -0.5", nil)
+	var values []float64
+	for _, n := range numbers {
+		values = append(values, n.value)
+	}
+	assert.Equal(t, []float64{12.5, 3.4e12, 1.2e6, -0.5}, values)
+	assert.InDelta(t, 0.05e12, numbers[1].tolerance, 1)
+}
+
+func TestBuildExplainPromptNumbersRows(t *testing.T) {
+	prompt := buildExplainPrompt("Top countries", "SELECT ...", citationRows, nil, nil)
+	assert.Contains(t, prompt, "| # | country | year | population | gdp_growth |")
+	assert.Contains(t, prompt, "| 2 | India | 2021 | 1407563842 | 9.05 |")
+}
@@ -0,0 +1,137 @@
+package pipeline
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Dialect covers what Validate and Execute need to know about the database
+// the generated SQL runs against.
+type Dialect interface {
+	// Name is the SQL_DIALECT value and the name used in the Generate prompt.
+	Name() string
+	// DBSystem is the OpenTelemetry db.system value.
+	DBSystem() string
+	// SystemSchemas are catalog schemas and tables queries may not touch.
+	SystemSchemas() []string
+	// DeniedKeywords are statements specific to the dialect that can change
+	// state or reach outside the database, on top of the common mutations.
+	DeniedKeywords() []string
+	// ApplyLimit appends a row limit to a query that has none.
+	ApplyLimit(sql string, limit int) string
+	// QuerySetup statements run before every query on the same Querier.
+	QuerySetup() []string
+	// ConnSetup statements run once on each new connection, for drivers
+	// whose session settings cannot be scoped to a single query.
+	ConnSetup() []string
+	// ExplainAnalyze wraps sql in a statement that runs it and returns its
+	// plan in Postgres EXPLAIN JSON form, or returns "" if the dialect has
+	// no such statement.
+	ExplainAnalyze(sql string) string
+}
+
+// Dialect names accepted by SQL_DIALECT.
+const (
+	DialectPostgres = "postgres"
+	DialectMySQL    = "mysql"
+	DialectSQLite   = "sqlite"
+)
+
+// DialectFor returns the dialect for a SQL_DIALECT value.
+func DialectFor(name string) (Dialect, error) {
+	switch strings.ToLower(name) {
+	case DialectPostgres, "postgresql", "":
+		return Postgres{}, nil
+	case DialectMySQL:
+		return MySQL{}, nil
+	case DialectSQLite, "sqlite3":
+		return SQLite{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported SQL dialect %q (want %s, %s or %s)", name, DialectPostgres, DialectMySQL, DialectSQLite)
+	}
+}
+
+// appendLimit is the LIMIT n form shared by all supported dialects.
+func appendLimit(sql string, limit int) string {
+	return strings.TrimRight(sql, ";") + " LIMIT " + strconv.Itoa(limit)
+}
+
+type Postgres struct{}
+
+func (Postgres) Name() string     { return DialectPostgres }
+func (Postgres) DBSystem() string { return "postgresql" }
+
+func (Postgres) SystemSchemas() []string {
+	return []string{"pg_catalog", "information_schema", "pg_temp", "pg_toast"}
+}
+
+func (Postgres) DeniedKeywords() []string { return nil }
+
+func (Postgres) ApplyLimit(sql string, limit int) string { return appendLimit(sql, limit) }
+
+func (Postgres) QuerySetup() []string {
+	return []string{
+		"SET TRANSACTION READ ONLY",
+		"SET LOCAL statement_timeout = '10s'",
+	}
+}
+
+func (Postgres) ConnSetup() []string { return nil }
+
+func (Postgres) ExplainAnalyze(sql string) string {
+	return "EXPLAIN (ANALYZE, FORMAT JSON) " + strings.TrimRight(sql, ";")
+}
+
+type MySQL struct{}
+
+func (MySQL) Name() string     { return DialectMySQL }
+func (MySQL) DBSystem() string { return "mysql" }
+
+func (MySQL) SystemSchemas() []string {
+	return []string{"information_schema", "performance_schema", "mysql.", "sys."}
+}
+
+func (MySQL) DeniedKeywords() []string {
+	return []string{"OUTFILE", "DUMPFILE", "LOAD_FILE", "HANDLER"}
+}
+
+func (MySQL) ApplyLimit(sql string, limit int) string { return appendLimit(sql, limit) }
+
+func (MySQL) QuerySetup() []string { return nil }
+
+func (MySQL) ConnSetup() []string {
+	return []string{
+		"SET SESSION TRANSACTION READ ONLY",
+		"SET SESSION MAX_EXECUTION_TIME = 10000",
+	}
+}
+
+// ExplainAnalyze is unsupported: MySQL's EXPLAIN ANALYZE only prints a
+// text tree.
+func (MySQL) ExplainAnalyze(string) string { return "" }
+
+type SQLite struct{}
+
+func (SQLite) Name() string     { return DialectSQLite }
+func (SQLite) DBSystem() string { return "sqlite" }
+
+func (SQLite) SystemSchemas() []string {
+	return []string{"sqlite_master", "sqlite_schema", "sqlite_temp_master", "sqlite_sequence"}
+}
+
+func (SQLite) DeniedKeywords() []string {
+	return []string{"ATTACH", "DETACH", "PRAGMA", "LOAD_EXTENSION"}
+}
+
+func (SQLite) ApplyLimit(sql string, limit int) string { return appendLimit(sql, limit) }
+
+func (SQLite) QuerySetup() []string { return nil }
+
+func (SQLite) ConnSetup() []string {
+	return []string{"PRAGMA query_only = ON", "PRAGMA busy_timeout = 10000"}
+}
+
+// ExplainAnalyze is unsupported: SQLite's EXPLAIN QUERY PLAN neither runs
+// the query nor reports costs.
+func (SQLite) ExplainAnalyze(string) string { return "" }
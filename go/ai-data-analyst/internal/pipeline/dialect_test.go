@@ -0,0 +1,85 @@
+package pipeline
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"ai-data-analyst/internal/db"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDialectFor(t *testing.T) {
+	for name, want := range map[string]string{
+		"":           DialectPostgres,
+		"postgresql": DialectPostgres,
+		"MySQL":      DialectMySQL,
+		"sqlite3":    DialectSQLite,
+	} {
+		d, err := DialectFor(name)
+		require.NoError(t, err, name)
+		assert.Equal(t, want, d.Name(), name)
+	}
+
+	_, err := DialectFor("oracle")
+	assert.Error(t, err)
+}
+
+func TestValidateDialectSystemSchemas(t *testing.T) {
+	tracer := testTracer().Tracer("test")
+
+	r := ValidateDialect(context.Background(), tracer, "SELECT name FROM sqlite_master", 50, SQLite{})
+	assert.False(t, r.Valid)
+	assert.Contains(t, r.Violations, "system_schema_access: sqlite_master")
+
+	r = ValidateDialect(context.Background(), tracer, "SELECT * FROM performance_schema.threads", 50, MySQL{})
+	assert.False(t, r.Valid)
+
+	// Postgres catalogs are only off limits on Postgres
+	r = ValidateDialect(context.Background(), tracer, "SELECT name FROM countries", 50, SQLite{})
+	assert.True(t, r.Valid)
+	assert.Equal(t, "SELECT name FROM countries LIMIT 50", r.SafeSQL)
+}
+
+func TestValidateDialectDeniedKeywords(t *testing.T) {
+	tracer := testTracer().Tracer("test")
+
+	r := ValidateDialect(context.Background(), tracer, "SELECT * FROM pragma_table_info('countries')", 50, SQLite{})
+	assert.True(t, r.Valid, "pragma table-valued functions are reads")
+
+	r = ValidateDialect(context.Background(), tracer, "SELECT name FROM countries INTO OUTFILE '/tmp/x'", 50, MySQL{})
+	assert.False(t, r.Valid)
+	assert.Contains(t, r.Violations, "denied_keyword: OUTFILE")
+
+	r = ValidateDialect(context.Background(), tracer, "SELECT REPLACE(name, 'a', 'b') FROM countries", 50, MySQL{})
+	assert.True(t, r.Valid)
+}
+
+func TestExecuteSQLite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "analyst.db")
+
+	seed, err := sql.Open("sqlite", path)
+	require.NoError(t, err)
+	_, err = seed.Exec(`CREATE TABLE countries (name TEXT, code TEXT, region TEXT);
+		INSERT INTO countries VALUES ('India', 'IND', 'South Asia'), ('Japan', 'JPN', 'East Asia & Pacific');`)
+	require.NoError(t, err)
+	require.NoError(t, seed.Close())
+
+	d := SQLite{}
+	target, err := db.OpenSQL(context.Background(), "sqlite", path, d.ConnSetup())
+	require.NoError(t, err)
+	defer target.Close()
+
+	tracer := testTracer().Tracer("test")
+	result, err := Execute(context.Background(), tracer, target, d, "SELECT name, code FROM countries ORDER BY name")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"name", "code"}, result.Columns)
+	assert.Equal(t, [][]any{{"India", "IND"}, {"Japan", "JPN"}}, result.Rows)
+
+	// The connection is read-only even if a write got past validation
+	_, err = target.Exec(context.Background(), "DELETE FROM countries")
+	assert.Error(t, err)
+}
@@ -0,0 +1,153 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"ai-data-analyst/internal/auth"
+	"ai-data-analyst/internal/llm"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Example is a curated demo question.
+type Example struct {
+	Question     string `json:"question"`
+	QuestionType string `json:"question_type"`
+}
+
+// Examples are the demo questions served by /api/examples and asked by the
+// warm-up. Each is answerable from the bundled World Bank data.
+var Examples = []Example{
+	{Question: "Top 10 countries by GDP growth in 2023", QuestionType: "ranking"},
+	{Question: "Which countries have the highest CO2 emissions per capita?", QuestionType: "ranking"},
+	{Question: "Compare life expectancy between Japan and Nigeria", QuestionType: "comparison"},
+	{Question: "How has internet usage changed in China?", QuestionType: "trend"},
+	{Question: "What was India's population growth from 2000 to 2023?", QuestionType: "trend"},
+	{Question: "What is the average unemployment rate in Europe?", QuestionType: "aggregate"},
+	{Question: "What is the GDP of Brazil in 2022?", QuestionType: "lookup"},
+}
+
+// Warm-up outcomes per example, reported by WarmUp.Status.
+const (
+	WarmUpPending = "pending"
+	WarmUpWarmed  = "warmed"
+	WarmUpCached  = "cached"
+	WarmUpFailed  = "failed"
+	WarmUpSkipped = "skipped"
+)
+
+// WarmUpUser is who warm-up questions are asked as, so their cost shows
+// separately in /api/usage/users.
+const WarmUpUser = "warmup"
+
+// WarmUp asks the demo questions once at startup, so their answers are in
+// the result cache and the question and GenAI metrics have data before the
+// first visitor arrives. Its LLM calls go through the configured budgets:
+// once one is exhausted the remaining questions are skipped.
+type WarmUp struct {
+	Pipeline *Pipeline
+	Examples []Example
+
+	// RetryInterval is how often to check for the database while waiting
+	// for it, so the cached answers carry results.
+	RetryInterval time.Duration
+
+	mu     sync.Mutex
+	status map[string]string
+	done   bool
+}
+
+// ExampleStatus is an example with its warm-up outcome.
+type ExampleStatus struct {
+	Example
+	WarmUp string `json:"warmup"`
+}
+
+// Status reports each example's warm-up outcome, and whether the warm-up
+// has finished.
+func (w *WarmUp) Status() ([]ExampleStatus, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	out := make([]ExampleStatus, len(w.Examples))
+	for i, ex := range w.Examples {
+		status := w.status[ex.Question]
+		if status == "" {
+			status = WarmUpPending
+		}
+		out[i] = ExampleStatus{Example: ex, WarmUp: status}
+	}
+	return out, w.done
+}
+
+func (w *WarmUp) set(question, status string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.status == nil {
+		w.status = make(map[string]string)
+	}
+	w.status[question] = status
+}
+
+// Run waits for the database, then asks each example in turn. It returns
+// early when ctx is cancelled.
+func (w *WarmUp) Run(ctx context.Context) {
+	for !w.Pipeline.dbAvailable() {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(w.RetryInterval):
+		}
+	}
+
+	ctx, span := w.Pipeline.Tracer.Start(ctx, "pipeline warmup")
+	defer span.End()
+	ctx = auth.WithUser(ctx, WarmUpUser)
+
+	var warmed, failed int
+	var costUSD float64
+	for i, ex := range w.Examples {
+		if ctx.Err() != nil {
+			return
+		}
+
+		result, err := w.Pipeline.Ask(ctx, ex.Question)
+		if errors.Is(err, llm.ErrBudgetExceeded) {
+			log.Printf("Warm-up stopped after %d questions: %v", i, err)
+			for _, rest := range w.Examples[i:] {
+				w.set(rest.Question, WarmUpSkipped)
+			}
+			span.SetAttributes(attribute.Bool("nlsql.warmup.budget_exhausted", true))
+			break
+		}
+
+		switch {
+		case err != nil:
+			log.Printf("Warm-up question %q failed: %v", ex.Question, err)
+			w.set(ex.Question, WarmUpFailed)
+			failed++
+		case result.CacheHit == CacheLevelQuestion:
+			w.set(ex.Question, WarmUpCached)
+			warmed++
+		default:
+			w.set(ex.Question, WarmUpWarmed)
+			warmed++
+			costUSD += result.TotalCostUSD
+		}
+	}
+
+	span.SetAttributes(
+		attribute.Int("nlsql.warmup.warmed", warmed),
+		attribute.Int("nlsql.warmup.failed", failed),
+		attribute.Float64("nlsql.warmup.cost_usd", costUSD),
+	)
+	log.Printf("Warm-up done: %d of %d questions ready, $%.4f spent", warmed, len(w.Examples), costUSD)
+
+	w.mu.Lock()
+	w.done = true
+	w.mu.Unlock()
+}
@@ -0,0 +1,43 @@
+package pipeline
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExamplesQuestionTypes(t *testing.T) {
+	seen := map[string]bool{}
+	for _, ex := range Examples {
+		assert.Equal(t, ex.QuestionType, classifyQuestion(strings.ToLower(ex.Question)), ex.Question)
+		assert.False(t, seen[normalizeQuestion(ex.Question)], "duplicate example %q", ex.Question)
+		seen[normalizeQuestion(ex.Question)] = true
+	}
+}
+
+func TestWarmUpStatusStartsPending(t *testing.T) {
+	w := &WarmUp{Examples: Examples[:2]}
+	status, done := w.Status()
+	require.Len(t, status, 2)
+	assert.False(t, done)
+	for i, s := range status {
+		assert.Equal(t, Examples[i].Question, s.Question)
+		assert.Equal(t, WarmUpPending, s.WarmUp)
+	}
+}
+
+func TestWarmUpWaitsForDatabase(t *testing.T) {
+	w := &WarmUp{Pipeline: &Pipeline{}, Examples: Examples, RetryInterval: time.Millisecond}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	w.Run(ctx)
+
+	status, done := w.Status()
+	assert.False(t, done, "no question is asked without a database")
+	assert.Equal(t, WarmUpPending, status[0].WarmUp)
+}
@@ -17,15 +17,28 @@ type ExecuteResult struct {
 	Rows     [][]any  `json:"rows"`
 	RowCount int      `json:"row_count"`
 	Duration time.Duration
+
+	// Plan is the EXPLAIN ANALYZE summary, when one was requested with
+	// WithQueryPlan or the query was slow.
+	Plan *QueryPlan `json:"-"`
+	// Slow is set when the query took at least the WithSlowQueryThreshold
+	// threshold.
+	Slow bool `json:"-"`
 }
 
-func Execute(ctx context.Context, tracer trace.Tracer, q db.Querier, sql string) (*ExecuteResult, error) {
+// Execute runs validated SQL on q, after the dialect's read-only and
+// timeout setup statements. Under WithQueryPlan, or when the query is slower
+// than the WithSlowQueryThreshold threshold, it then runs the query again
+// under EXPLAIN ANALYZE for its plan; a slow query's plan is also attached
+// to the span as an event.
+func Execute(ctx context.Context, tracer trace.Tracer, q db.Querier, d Dialect, sql string) (*ExecuteResult, error) {
 	ctx, span := tracer.Start(ctx, "pipeline_stage execute")
 	defer span.End()
 
 	span.SetAttributes(
 		attribute.String("nlsql.stage", "execute"),
-		attribute.String("db.system", "postgresql"),
+		attribute.String("nlsql.data_source", dataSourceFrom(ctx)),
+		attribute.String("db.system", d.DBSystem()),
 		attribute.String("db.statement", sql),
 		attribute.String("db.operation", "SELECT"),
 	)
@@ -33,16 +46,11 @@ func Execute(ctx context.Context, tracer trace.Tracer, q db.Querier, sql string)
 	start := time.Now()
 
 	// Set read-only transaction and statement timeout
-	_, err := q.Exec(ctx, "SET TRANSACTION READ ONLY")
-	if err != nil {
-		span.SetStatus(codes.Error, err.Error())
-		return nil, fmt.Errorf("failed to set read-only transaction: %w", err)
-	}
-
-	_, err = q.Exec(ctx, "SET LOCAL statement_timeout = '10s'")
-	if err != nil {
-		span.SetStatus(codes.Error, err.Error())
-		return nil, fmt.Errorf("failed to set statement timeout: %w", err)
+	for _, stmt := range d.QuerySetup() {
+		if _, err := q.Exec(ctx, stmt); err != nil {
+			span.SetStatus(codes.Error, err.Error())
+			return nil, fmt.Errorf("failed to run %q: %w", stmt, err)
+		}
 	}
 
 	rows, err := q.Query(ctx, sql)
@@ -96,6 +104,20 @@ func Execute(ctx context.Context, tracer trace.Tracer, q db.Querier, sql string)
 		attribute.Int("nlsql.execution_ms", int(duration.Milliseconds())),
 	)
 
+	threshold := slowQueryThreshold(ctx)
+	result.Slow = threshold > 0 && duration >= threshold
+	if result.Slow {
+		span.SetAttributes(attribute.Bool("nlsql.slow_query", true))
+	}
+	if planRequested(ctx) || result.Slow {
+		result.Plan = capturePlan(ctx, span, q, d, sql)
+	}
+	if result.Slow && result.Plan != nil {
+		recordSlowPlan(span, result.Plan, threshold)
+	}
+
+	emitStage(ctx, span, "execute", result)
+
 	return result, nil
 }
 
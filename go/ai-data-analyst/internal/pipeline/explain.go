@@ -4,6 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"regexp"
+	"sort"
 	"strings"
 
 	"ai-data-analyst/internal/llm"
@@ -21,33 +23,67 @@ type ExplainResult struct {
 	InputTokens  int      `json:"-"`
 	OutputTokens int      `json:"-"`
 	CostUSD      float64  `json:"-"`
+
+	// Chart is set when the caller asked for one with WithChart and the
+	// rows suit a chart.
+	Chart *ChartSpec `json:"chart,omitempty"`
+
+	// Anomalies are the statistical checks run on the rows before the
+	// explanation was asked for. Their messages also lead Caveats.
+	Anomalies []Anomaly `json:"anomalies,omitempty"`
+
+	// Citations map insights to the rows they are based on, checked against
+	// the rows so numbers the model made up are flagged.
+	Citations []Citation `json:"citations,omitempty"`
 }
 
 const explainSystemPrompt = `You are a data analyst explaining query results to a non-technical audience.
 Given a question, the SQL query used, and the results, provide:
 1. A 2-3 sentence summary answering the question
-2. Key insights from the data (2-4 bullet points)
+2. Key insights from the data (2-4 bullet points), each naming the rows it is based on, as in "row 3: India, 2021",
+   and quoting values exactly as they appear in those rows
 3. Any caveats or limitations
 4. Suggested follow-up questions (1-3)
 
-Respond with JSON: {"summary": "...", "insights": [...], "caveats": [...], "follow_ups": [...]}`
+The results are numbered by row in the # column. List the rows behind each insight in citations, with insights
+numbered from 1.
 
-func Explain(ctx context.Context, tracer trace.Tracer, client *llm.Client, question string, sql string, execResult *ExecuteResult, model string, temperature float64, maxTokens int) (*ExplainResult, error) {
+Respond with JSON: {"summary": "...", "insights": [...], "citations": [{"insight": 1, "rows": [3]}], "caveats": [...], "follow_ups": [...]}`
+
+// Explain asks the LLM to explain the rows, with prompt as the system prompt.
+func Explain(ctx context.Context, tracer trace.Tracer, client *llm.Client, prompt PromptTemplate, question string, sql string, execResult *ExecuteResult, units map[string]string, model string, temperature float64, maxTokens int) (*ExplainResult, error) {
 	ctx, span := tracer.Start(ctx, "pipeline_stage explain")
 	defer span.End()
 
-	span.SetAttributes(attribute.String("nlsql.stage", "explain"))
+	span.SetAttributes(
+		attribute.String("nlsql.stage", "explain"),
+		attribute.Int("nlsql.dictionary_units", len(units)),
+		attribute.String("gen_ai.prompt.name", PromptExplain),
+		attribute.String("gen_ai.prompt.version", prompt.Version),
+	)
 
-	prompt := buildExplainPrompt(question, sql, execResult)
+	anomalies := DetectAnomalies(execResult)
+	span.SetAttributes(attribute.Int("nlsql.anomaly_count", len(anomalies)))
 
-	resp, err := client.Generate(ctx, llm.GenerateRequest{
+	req := llm.GenerateRequest{
 		Model:       model,
-		System:      explainSystemPrompt,
-		Prompt:      prompt,
+		System:      prompt.Template,
+		Prompt:      buildExplainPrompt(question, sql, execResult, units, anomalies),
 		Temperature: temperature,
 		MaxTokens:   maxTokens,
 		Stage:       "explain",
-	})
+	}
+
+	var resp *llm.GenerateResponse
+	var err error
+	if onToken := tokenObserverFrom(ctx); onToken != nil {
+		summary := &summaryStream{emit: func(delta string) {
+			onToken(TokenEvent{Stage: "explain", Delta: delta})
+		}}
+		resp, err = client.GenerateStream(ctx, req, summary.write)
+	} else {
+		resp, err = client.Generate(ctx, req)
+	}
 	if err != nil {
 		span.SetStatus(codes.Error, err.Error())
 		return nil, fmt.Errorf("explanation generation failed: %w", err)
@@ -57,21 +93,124 @@ func Explain(ctx context.Context, tracer trace.Tracer, client *llm.Client, quest
 	result.InputTokens = resp.InputTokens
 	result.OutputTokens = resp.OutputTokens
 	result.CostUSD = resp.CostUSD
+	// The chart and anomalies come from the rows, never from the response.
+	result.Chart = nil
+	result.Anomalies = anomalies
+	result.Citations = verifyCitations(result, execResult, question+"\n"+sql)
+	hallucinations := recordHallucinations(span, result.Citations)
+	if hallucinations > 0 {
+		result.Caveats = append(result.Caveats,
+			"Some figures in the insights could not be matched to the result rows; check them against the data.")
+	}
+	if len(anomalies) > 0 {
+		caveats := make([]string, 0, len(anomalies)+len(result.Caveats))
+		for _, a := range anomalies {
+			caveats = append(caveats, a.Message)
+		}
+		result.Caveats = append(caveats, result.Caveats...)
+	}
+	if chartRequested(ctx) {
+		result.Chart = chartFor(span, execResult)
+	}
 
 	span.SetAttributes(
 		attribute.Int("nlsql.summary_length", len(result.Summary)),
 		attribute.Int("nlsql.insights_count", len(result.Insights)),
 		attribute.Int("nlsql.follow_ups_count", len(result.FollowUps)),
+		attribute.Int("nlsql.citations_count", len(result.Citations)),
+		attribute.Int("nlsql.hallucinations", hallucinations),
 	)
 
+	emitStage(ctx, span, "explain", result)
+
 	return result, nil
 }
 
-func buildExplainPrompt(question string, sql string, execResult *ExecuteResult) string {
+var summaryKeyPattern = regexp.MustCompile(`"summary"\s*:\s*"`)
+
+// summaryStream picks the "summary" value out of the explain response as it
+// streams in, so a client can show the summary before the rest of the JSON
+// arrives. Responses that are not JSON stream nothing; the final explain
+// stage event still carries the parsed result.
+type summaryStream struct {
+	emit func(string)
+
+	buf     strings.Builder
+	pos     int // start of the unsent part of the value, once found
+	inValue bool
+	done    bool
+}
+
+func (s *summaryStream) write(delta string) {
+	if s.done {
+		return
+	}
+	s.buf.WriteString(delta)
+	text := s.buf.String()
+
+	if !s.inValue {
+		loc := summaryKeyPattern.FindStringIndex(text)
+		if loc == nil {
+			return
+		}
+		s.inValue = true
+		s.pos = loc[1]
+	}
+
+	n, closed := scanJSONString(text[s.pos:])
+	if n > 0 {
+		var decoded string
+		if err := json.Unmarshal([]byte(`"`+text[s.pos:s.pos+n]+`"`), &decoded); err == nil && decoded != "" {
+			s.emit(decoded)
+		}
+		s.pos += n
+	}
+	s.done = closed
+}
+
+// scanJSONString returns how many bytes of s, the inside of a JSON string,
+// can be decoded so far, stopping before an escape sequence that is cut off,
+// and whether the closing quote was reached.
+func scanJSONString(s string) (int, bool) {
+	i := 0
+	for i < len(s) {
+		switch s[i] {
+		case '"':
+			return i, true
+		case '\\':
+			n := 2
+			if i+1 < len(s) && s[i+1] == 'u' {
+				n = 6
+			}
+			if i+n > len(s) {
+				return i, false
+			}
+			i += n
+		default:
+			i++
+		}
+	}
+	return i, false
+}
+
+func buildExplainPrompt(question string, sql string, execResult *ExecuteResult, units map[string]string, anomalies []Anomaly) string {
 	var sb strings.Builder
 	sb.WriteString("Question: " + question + "\n\n")
 	sb.WriteString("SQL Query:\n" + sql + "\n\n")
 
+	if len(units) > 0 {
+		codes := make([]string, 0, len(units))
+		for code := range units {
+			codes = append(codes, code)
+		}
+		sort.Strings(codes)
+		sb.WriteString("Indicator units (use these when quoting values):\n")
+		for _, code := range codes {
+			sb.WriteString("- " + code + ": " + units[code] + "\n")
+		}
+		sb.WriteString("\n")
+	}
+
 	if execResult.RowCount == 0 {
 		sb.WriteString("Results: No data returned (0 rows).\n")
 		sb.WriteString("Please explain why there might be no data and suggest how to broaden the query.\n")
@@ -80,11 +219,11 @@ func buildExplainPrompt(question string, sql string, execResult *ExecuteResult)
 
 	sb.WriteString(fmt.Sprintf("Results (%d rows):\n", execResult.RowCount))
 
-	// Format as markdown table
-	sb.WriteString("| " + strings.Join(execResult.Columns, " | ") + " |\n")
-	sb.WriteString("|" + strings.Repeat(" --- |", len(execResult.Columns)) + "\n")
+	// Format as markdown table, numbered from 1 for citations
+	sb.WriteString("| # | " + strings.Join(execResult.Columns, " | ") + " |\n")
+	sb.WriteString("|" + strings.Repeat(" --- |", len(execResult.Columns)+1) + "\n")
 
-	maxRows := 20
+	maxRows := explainPromptRows
 	if execResult.RowCount < maxRows {
 		maxRows = execResult.RowCount
 	}
@@ -98,15 +237,40 @@ func buildExplainPrompt(question string, sql string, execResult *ExecuteResult)
 				vals[j] = fmt.Sprintf("%v", v)
 			}
 		}
-		sb.WriteString("| " + strings.Join(vals, " | ") + " |\n")
+		sb.WriteString(fmt.Sprintf("| %d | ", i+1) + strings.Join(vals, " | ") + " |\n")
 	}
 	if execResult.RowCount > maxRows {
 		sb.WriteString(fmt.Sprintf("\n... and %d more rows\n", execResult.RowCount-maxRows))
 	}
 
+	if len(anomalies) > 0 {
+		sb.WriteString("\nStatistical checks on all the rows (these are added to the caveats for you; " +
+			"take them into account in the summary and insights, and do not claim patterns they contradict):\n")
+		for _, a := range anomalies {
+			sb.WriteString("- " + a.Message + "\n")
+		}
+	}
+
 	return sb.String()
 }
 
+// recordHallucinations adds a hallucination_detected event to span for each
+// problem found in citations, and returns how many there were.
+func recordHallucinations(span trace.Span, citations []Citation) int {
+	n := 0
+	for _, c := range citations {
+		for _, p := range c.Problems {
+			span.AddEvent("hallucination_detected", trace.WithAttributes(
+				attribute.String("nlsql.hallucination.kind", p.Kind),
+				attribute.Int("nlsql.insight", c.Insight),
+				attribute.String("nlsql.hallucination.detail", p.Detail),
+			))
+			n++
+		}
+	}
+	return n
+}
+
 func parseExplainResponse(content string) *ExplainResult {
 	result := &ExplainResult{}
 
@@ -1,6 +1,7 @@
 package pipeline
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -27,7 +28,7 @@ func TestBuildExplainPromptWithData(t *testing.T) {
 		Rows:     [][]any{{"India", 7.2}, {"China", 5.1}},
 		RowCount: 2,
 	}
-	prompt := buildExplainPrompt("Top countries by GDP growth", "SELECT ...", execResult)
+	prompt := buildExplainPrompt("Top countries by GDP growth", "SELECT ...", execResult, nil, nil)
 	assert.Contains(t, prompt, "Top countries by GDP growth")
 	assert.Contains(t, prompt, "2 rows")
 	assert.Contains(t, prompt, "India")
@@ -39,7 +40,54 @@ func TestBuildExplainPromptEmptyResults(t *testing.T) {
 		Rows:     nil,
 		RowCount: 0,
 	}
-	prompt := buildExplainPrompt("GDP of Atlantis", "SELECT ...", execResult)
+	prompt := buildExplainPrompt("GDP of Atlantis", "SELECT ...", execResult, nil, nil)
 	assert.Contains(t, prompt, "No data returned")
 	assert.Contains(t, prompt, "broaden")
 }
+
+func TestBuildExplainPromptWithUnits(t *testing.T) {
+	execResult := &ExecuteResult{
+		Columns:  []string{"country", "value"},
+		Rows:     [][]any{{"India", 7.2}},
+		RowCount: 1,
+	}
+	units := map[string]string{"NY.GDP.MKTP.KD.ZG": "GDP growth (annual %)"}
+	prompt := buildExplainPrompt("GDP growth of India", "SELECT ...", execResult, units, nil)
+	assert.Contains(t, prompt, "Indicator units")
+	assert.Contains(t, prompt, "NY.GDP.MKTP.KD.ZG: GDP growth (annual %)")
+}
+
+func TestSummaryStream(t *testing.T) {
+	var got []string
+	s := &summaryStream{emit: func(d string) { got = append(got, d) }}
+
+	for _, chunk := range []string{
+		"```json\n{\"sum", "mary\": \"India ", "grew \\\"fast", "est\\\" at 7.2%\\", "u0021\",",
+		" \"insights\": [\"ignored\"], \"summary\": \"not this\"}",
+	} {
+		s.write(chunk)
+	}
+
+	assert.Equal(t, []string{"India ", "grew \"fast", "est\" at 7.2%", "!"}, got)
+	assert.Equal(t, `India grew "fastest" at 7.2%!`, strings.Join(got, ""))
+}
+
+func TestSummaryStreamPlainText(t *testing.T) {
+	var got []string
+	s := &summaryStream{emit: func(d string) { got = append(got, d) }}
+	s.write("GDP growth was highest ")
+	s.write("in India.")
+	assert.Empty(t, got)
+}
+
+func TestBuildExplainPromptWithAnomalies(t *testing.T) {
+	execResult := &ExecuteResult{
+		Columns:  []string{"country", "value"},
+		Rows:     [][]any{{"India", 7.2}},
+		RowCount: 1,
+	}
+	anomalies := []Anomaly{{Kind: AnomalyMissingYears, Message: "No value for India in 2010."}}
+	prompt := buildExplainPrompt("GDP growth of India", "SELECT ...", execResult, nil, anomalies)
+	assert.Contains(t, prompt, "Statistical checks")
+	assert.Contains(t, prompt, "- No value for India in 2010.")
+}
@@ -0,0 +1,119 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"ai-data-analyst/internal/db"
+	"ai-data-analyst/internal/export"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// Where an export's rows came from, recorded as nlsql.export.source.
+const (
+	ExportSourceCache = "cache"
+	ExportSourceQuery = "query"
+)
+
+// Export writes the result of a history entry's SQL as a file in format.
+// History keeps no rows, so they come from the result cache while the SQL's
+// entry lasts, and otherwise from running the stored SQL again, validated
+// first as in Replay. open is called with the file name once the rows are
+// ready and returns where to write them; an error returned before that
+// means nothing was written.
+func (p *Pipeline) Export(ctx context.Context, h *db.QueryHistory, format string, open func(filename string) io.Writer) error {
+	start := time.Now()
+
+	ctx, span := p.Tracer.Start(ctx, "pipeline export")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("nlsql.history_id", h.ID),
+		attribute.String("nlsql.export.format", format),
+	)
+	dataSource := p.source(h.DataSource)
+	ctx = withDataSource(ctx, dataSource.Name)
+	span.SetAttributes(attribute.String("nlsql.data_source", dataSource.Name))
+
+	validated := ValidateDialect(ctx, p.Tracer, h.GeneratedSQL, p.Settings().RowLimit, dataSource.Dialect)
+	if !validated.Valid {
+		span.SetAttributes(attribute.StringSlice("nlsql.violations", validated.Violations))
+		span.SetStatus(codes.Error, ErrReplayRejected.Error())
+		return fmt.Errorf("%w: %v", ErrReplayRejected, validated.Violations)
+	}
+
+	var result *ExecuteResult
+	source := ExportSourceQuery
+	if p.Cache != nil {
+		if cached, ok := p.Cache.getRows(ctx, validated.SafeSQL, dataSource.cacheName()); ok {
+			result = cached
+			source = ExportSourceCache
+		}
+	}
+	span.SetAttributes(attribute.String("nlsql.export.source", source))
+
+	if result == nil {
+		if !dataSource.available() {
+			span.SetStatus(codes.Error, db.ErrUnavailable.Error())
+			return db.ErrUnavailable
+		}
+		var err error
+		result, err = Execute(ctx, p.Tracer, dataSource.DB, dataSource.Dialect, validated.SafeSQL)
+		if err != nil {
+			span.SetStatus(codes.Error, err.Error())
+			return fmt.Errorf("execute stage failed: %w", err)
+		}
+	}
+
+	size, err := p.serialize(ctx, open(fmt.Sprintf("history-%s.%s", h.ID, format)), format, result)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+
+	if p.Metrics != nil {
+		attrs := metric.WithAttributes(
+			attribute.String("nlsql.export.format", format),
+			attribute.String("nlsql.export.source", source),
+		)
+		p.Metrics.ExportSize.Record(ctx, size, attrs)
+		p.Metrics.ExportDuration.Record(ctx, time.Since(start).Seconds(), attrs)
+	}
+	return nil
+}
+
+// serialize writes r to w in format and returns the bytes written.
+func (p *Pipeline) serialize(ctx context.Context, w io.Writer, format string, r *ExecuteResult) (int64, error) {
+	_, span := p.Tracer.Start(ctx, "pipeline_stage serialize")
+	defer span.End()
+
+	cw := &countingWriter{w: w}
+	err := export.Write(cw, format, r.Columns, r.Rows)
+
+	span.SetAttributes(
+		attribute.String("nlsql.export.format", format),
+		attribute.Int("nlsql.row_count", r.RowCount),
+		attribute.Int64("nlsql.export.bytes", cw.n),
+	)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return cw.n, err
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(b []byte) (int, error) {
+	n, err := c.w.Write(b)
+	c.n += int64(n)
+	return n, err
+}
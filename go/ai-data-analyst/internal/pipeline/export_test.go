@@ -0,0 +1,64 @@
+package pipeline
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"ai-data-analyst/internal/cache"
+	"ai-data-analyst/internal/config"
+	"ai-data-analyst/internal/db"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportFromCachedRows(t *testing.T) {
+	ctx := context.Background()
+	p := &Pipeline{
+		Tracer: testTracer().Tracer("test"),
+		Config: &config.Config{RowLimit: 50},
+		Cache:  &ResultCache{Store: cache.NewLRU(10), TTL: time.Minute},
+	}
+	h := &db.QueryHistory{ID: "h1", GeneratedSQL: "SELECT name, population FROM countries"}
+
+	validated := ValidateDialect(ctx, p.Tracer, h.GeneratedSQL, 50, p.dialect())
+	require.True(t, validated.Valid)
+	p.Cache.putRows(ctx, validated.SafeSQL, p.dialect().Name(), &ExecuteResult{
+		Columns:  []string{"name", "population"},
+		Rows:     [][]any{{"India", 1428627663}},
+		RowCount: 1,
+	})
+
+	var buf bytes.Buffer
+	var filename string
+	err := p.Export(ctx, h, "csv", func(name string) io.Writer {
+		filename = name
+		return &buf
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "history-h1.csv", filename)
+	assert.Equal(t, "name,population\nIndia,1428627663\n", buf.String())
+}
+
+func TestExportWithoutRowsOrDatabase(t *testing.T) {
+	p := &Pipeline{
+		Tracer: testTracer().Tracer("test"),
+		Config: &config.Config{RowLimit: 50},
+	}
+	h := &db.QueryHistory{ID: "h1", GeneratedSQL: "SELECT name FROM countries"}
+
+	opened := false
+	err := p.Export(context.Background(), h, "xlsx", func(string) io.Writer {
+		opened = true
+		return io.Discard
+	})
+	assert.ErrorIs(t, err, db.ErrUnavailable)
+	assert.False(t, opened, "nothing is written before the rows are ready")
+
+	h.GeneratedSQL = "DELETE FROM countries"
+	assert.ErrorIs(t, p.Export(context.Background(), h, "csv", func(string) io.Writer { return io.Discard }),
+		ErrReplayRejected)
+}
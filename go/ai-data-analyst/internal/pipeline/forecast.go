@@ -0,0 +1,229 @@
+package pipeline
+
+import (
+	"context"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// The seeded World Bank dataset covers these years; anything later is a
+// projection.
+const (
+	dataFirstYear = 2003
+	dataLastYear  = 2023
+
+	defaultForecastHorizon = 3
+	maxForecastHorizon     = 10
+	minForecastPoints      = 3
+)
+
+type ForecastPoint struct {
+	Year     int     `json:"year"`
+	Value    float64 `json:"value"`
+	Estimate bool    `json:"estimate"`
+}
+
+type ForecastSeries struct {
+	Label  string          `json:"label,omitempty"`
+	Slope  float64         `json:"slope"`
+	Points []ForecastPoint `json:"points"`
+}
+
+type ForecastResult struct {
+	Method  string `json:"method"`
+	Horizon int    `json:"horizon"`
+	// TargetYear is the last projected year, horizon years past the dataset.
+	TargetYear int              `json:"target_year"`
+	Series     []ForecastSeries `json:"series"`
+	Note       string           `json:"note"`
+}
+
+var (
+	forecastWords = []string{"forecast", "predict", "projection", "project", "future", "expected to", "outlook"}
+	// "will" alone is too common ("which countries will show up"); it counts
+	// only before a verb of change within a few words.
+	willChangePattern   = regexp.MustCompile(`\bwill\s+(?:[\w']+\s+){0,3}?(?:be|reach|grow|rise|fall|drop|increase|decrease|decline|change|look)\b`)
+	nextYearsPattern    = regexp.MustCompile(`\bnext\s+(\d{1,2})\s+years?\b`)
+	nextYearSingularPat = regexp.MustCompile(`\bnext\s+year\b`)
+)
+
+// detectForecastHorizon returns how many years past the dataset the question
+// asks about, or 0 when it doesn't look ahead.
+func detectForecastHorizon(lower string, tr *TimeRange) int {
+	if tr != nil && tr.EndYear > dataLastYear {
+		return min(tr.EndYear-dataLastYear, maxForecastHorizon)
+	}
+	if m := nextYearsPattern.FindStringSubmatch(lower); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		return min(max(n, 1), maxForecastHorizon)
+	}
+	if nextYearSingularPat.MatchString(lower) {
+		return 1
+	}
+	for _, w := range forecastWords {
+		if strings.Contains(lower, w) {
+			return defaultForecastHorizon
+		}
+	}
+	if willChangePattern.MatchString(lower) {
+		return defaultForecastHorizon
+	}
+	return 0
+}
+
+// Forecast fits an ordinary least-squares line to each series in the result
+// and extends it to the target year, horizon years past the dataset. A series
+// whose observations stop early is projected across the gap as well, so every
+// series ends in the year the question asked about. Rows are grouped by the
+// first text column (usually country); the year and value columns are found
+// by name and type.
+func Forecast(ctx context.Context, tracer trace.Tracer, execResult *ExecuteResult, horizon int) *ForecastResult {
+	_, span := tracer.Start(ctx, "pipeline_stage forecast")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("nlsql.stage", "forecast"),
+		attribute.Int("nlsql.forecast.horizon", horizon),
+	)
+
+	yearCol, valueCol, labelCol := forecastColumns(execResult)
+	if yearCol < 0 || valueCol < 0 || horizon <= 0 {
+		span.SetAttributes(attribute.Bool("nlsql.forecast.skipped", true))
+		return nil
+	}
+
+	type observation struct {
+		year  int
+		value float64
+	}
+	var order []string
+	grouped := map[string][]observation{}
+	for _, row := range execResult.Rows {
+		year, ok := toFloat(row[yearCol])
+		if !ok {
+			continue
+		}
+		value, ok := toFloat(row[valueCol])
+		if !ok {
+			continue
+		}
+		label := ""
+		if labelCol >= 0 && row[labelCol] != nil {
+			label, _ = row[labelCol].(string)
+		}
+		if _, seen := grouped[label]; !seen {
+			order = append(order, label)
+		}
+		grouped[label] = append(grouped[label], observation{year: int(year), value: value})
+	}
+
+	result := &ForecastResult{
+		Method:     "linear_regression",
+		Horizon:    horizon,
+		TargetYear: dataLastYear + horizon,
+		Note:       "Values marked estimate=true are projections from a linear trend, not observed data.",
+	}
+	for _, label := range order {
+		obs := grouped[label]
+		if len(obs) < minForecastPoints {
+			continue
+		}
+		xs := make([]float64, len(obs))
+		ys := make([]float64, len(obs))
+		lastYear := 0
+		for i, o := range obs {
+			xs[i], ys[i] = float64(o.year), o.value
+			lastYear = max(lastYear, o.year)
+		}
+		slope, intercept, ok := linearFit(xs, ys)
+		if !ok {
+			continue
+		}
+		series := ForecastSeries{Label: label, Slope: slope}
+		for y := lastYear + 1; y <= result.TargetYear; y++ {
+			series.Points = append(series.Points, ForecastPoint{
+				Year:     y,
+				Value:    math.Round((intercept+slope*float64(y))*1000) / 1000,
+				Estimate: true,
+			})
+		}
+		result.Series = append(result.Series, series)
+	}
+
+	span.SetAttributes(attribute.Int("nlsql.forecast.series_count", len(result.Series)))
+	if len(result.Series) == 0 {
+		return nil
+	}
+	return result
+}
+
+func forecastColumns(execResult *ExecuteResult) (yearCol, valueCol, labelCol int) {
+	yearCol, valueCol, labelCol = -1, -1, -1
+	if execResult == nil || len(execResult.Rows) == 0 {
+		return
+	}
+	for i, name := range execResult.Columns {
+		if yearCol < 0 && strings.Contains(strings.ToLower(name), "year") {
+			yearCol = i
+		}
+	}
+	first := execResult.Rows[0]
+	for i := range execResult.Columns {
+		if i == yearCol || i >= len(first) {
+			continue
+		}
+		if _, ok := toFloat(first[i]); ok {
+			valueCol = i
+		} else if _, ok := first[i].(string); ok && labelCol < 0 {
+			labelCol = i
+		}
+	}
+	return
+}
+
+func linearFit(xs, ys []float64) (slope, intercept float64, ok bool) {
+	n := float64(len(xs))
+	var sumX, sumY, sumXY, sumXX float64
+	for i := range xs {
+		sumX += xs[i]
+		sumY += ys[i]
+		sumXY += xs[i] * ys[i]
+		sumXX += xs[i] * xs[i]
+	}
+	denom := n*sumXX - sumX*sumX
+	if denom == 0 {
+		return 0, 0, false
+	}
+	slope = (n*sumXY - sumX*sumY) / denom
+	intercept = (sumY - slope*sumX) / n
+	return slope, intercept, true
+}
+
+// toFloat accepts the numeric shapes convertPgValue produces, including
+// NUMERIC columns rendered as strings.
+func toFloat(v any) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int16:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case string:
+		f, err := strconv.ParseFloat(n, 64)
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
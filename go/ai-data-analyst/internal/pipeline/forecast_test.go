@@ -0,0 +1,78 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectForecastHorizon(t *testing.T) {
+	assert.Equal(t, 7, detectForecastHorizon("population of india in 2030", &TimeRange{StartYear: 2030, EndYear: 2030}))
+	assert.Equal(t, 5, detectForecastHorizon("how will gdp growth change over the next 5 years", nil))
+	assert.Equal(t, 1, detectForecastHorizon("inflation trend next year", nil))
+	assert.Equal(t, defaultForecastHorizon, detectForecastHorizon("forecast co2 emissions in china", nil))
+	assert.Equal(t, 0, detectForecastHorizon("how has life expectancy changed in japan", nil))
+	assert.Equal(t, defaultForecastHorizon, detectForecastHorizon("how will india's population grow", nil))
+	assert.Equal(t, 0, detectForecastHorizon("which countries will show the highest gdp growth in 2010", nil))
+}
+
+func TestParseFutureTrendClampsTimeRange(t *testing.T) {
+	tracer := testTracer().Tracer("test")
+	r := Parse(context.Background(), tracer, "How will India's population grow from 2015 to 2030?")
+	assert.Equal(t, "trend", r.QuestionType)
+	assert.Equal(t, 7, r.ForecastHorizon)
+	require.NotNil(t, r.TimeRange)
+	assert.Equal(t, 2015, r.TimeRange.StartYear)
+	assert.Equal(t, dataLastYear, r.TimeRange.EndYear)
+}
+
+func TestForecastLinearSeries(t *testing.T) {
+	tracer := testTracer().Tracer("test")
+	execResult := &ExecuteResult{
+		Columns: []string{"country", "year", "value"},
+		Rows: [][]any{
+			{"India", int32(2020), "10"},
+			{"India", int32(2021), "12"},
+			{"India", int32(2022), "14"},
+			{"China", int32(2021), 5.0},
+			{"China", int32(2022), 5.0},
+			{"China", int32(2023), 5.0},
+		},
+		RowCount: 6,
+	}
+
+	f := Forecast(context.Background(), tracer, execResult, 2)
+	require.NotNil(t, f)
+	assert.Equal(t, 2, f.Horizon)
+	assert.Equal(t, 2025, f.TargetYear)
+	require.Len(t, f.Series, 2)
+
+	// India's data stops a year early, so its projection covers 2023 too and
+	// still ends in the target year.
+	india := f.Series[0]
+	assert.Equal(t, "India", india.Label)
+	assert.InDelta(t, 2.0, india.Slope, 1e-9)
+	assert.Equal(t, []ForecastPoint{
+		{Year: 2023, Value: 16, Estimate: true},
+		{Year: 2024, Value: 18, Estimate: true},
+		{Year: 2025, Value: 20, Estimate: true},
+	}, india.Points)
+
+	china := f.Series[1]
+	require.Len(t, china.Points, 2)
+	assert.Equal(t, 2024, china.Points[0].Year)
+	assert.Equal(t, 2025, china.Points[1].Year)
+	assert.InDelta(t, 5.0, china.Points[0].Value, 1e-9)
+}
+
+func TestForecastSkipsWithoutYearColumn(t *testing.T) {
+	tracer := testTracer().Tracer("test")
+	execResult := &ExecuteResult{
+		Columns:  []string{"country", "value"},
+		Rows:     [][]any{{"India", 1.0}, {"China", 2.0}, {"Japan", 3.0}},
+		RowCount: 3,
+	}
+	assert.Nil(t, Forecast(context.Background(), tracer, execResult, 3))
+}
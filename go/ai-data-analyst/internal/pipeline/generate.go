@@ -10,6 +10,7 @@ import (
 	"runtime"
 	"strings"
 
+	"ai-data-analyst/internal/db"
 	"ai-data-analyst/internal/llm"
 
 	"go.opentelemetry.io/otel/attribute"
@@ -57,17 +58,33 @@ func findSchemaContext() string {
 	return filepath.Join(dir, "..", "..", "data", "schema-context.txt")
 }
 
-func Generate(ctx context.Context, tracer trace.Tracer, client *llm.Client, question string, parsed *ParseResult, model string, temperature float64, maxTokens int) (*GenerateResult, error) {
+// Generate asks the LLM for SQL. turns holds earlier questions from the same
+// conversation, oldest first, so follow-ups can refer back to them; it is
+// empty for one-off questions. system is the schema context for the prompt,
+// built from version promptVersion of the generate prompt.
+func Generate(ctx context.Context, tracer trace.Tracer, client *llm.Client, question string, parsed *ParseResult, turns []db.SessionTurn, d Dialect, system string, promptVersion string, model string, temperature float64, maxTokens int) (*GenerateResult, error) {
 	ctx, span := tracer.Start(ctx, "pipeline_stage generate")
 	defer span.End()
 
-	span.SetAttributes(attribute.String("nlsql.stage", "generate"))
+	span.SetAttributes(
+		attribute.String("nlsql.stage", "generate"),
+		attribute.String("nlsql.data_source", dataSourceFrom(ctx)),
+		attribute.String("nlsql.dialect", d.Name()),
+		attribute.String("gen_ai.prompt.name", PromptGenerate),
+		attribute.String("gen_ai.prompt.version", promptVersion),
+	)
+	if len(turns) > 0 {
+		span.SetAttributes(
+			attribute.String("session.id", turns[0].SessionID),
+			attribute.Int("nlsql.session.context_turns", len(turns)),
+		)
+	}
 
-	prompt := buildGeneratePrompt(question, parsed)
+	prompt := buildGeneratePrompt(question, parsed, turns, d)
 
 	resp, err := client.Generate(ctx, llm.GenerateRequest{
 		Model:       model,
-		System:      schemaContext,
+		System:      system,
 		Prompt:      prompt,
 		Temperature: temperature,
 		MaxTokens:   maxTokens,
@@ -88,11 +105,29 @@ func Generate(ctx context.Context, tracer trace.Tracer, client *llm.Client, ques
 		attribute.Int("nlsql.sql_length", len(result.SQL)),
 	)
 
+	emitStage(ctx, span, "generate", result)
+
 	return result, nil
 }
 
-func buildGeneratePrompt(question string, parsed *ParseResult) string {
+func buildGeneratePrompt(question string, parsed *ParseResult, turns []db.SessionTurn, d Dialect) string {
 	var sb strings.Builder
+
+	if len(turns) > 0 {
+		sb.WriteString("Conversation so far (oldest first):\n")
+		for i, t := range turns {
+			sb.WriteString(fmt.Sprintf("%d. Question: %s\n   SQL: %s\n   Result: %d rows", i+1, t.Question, t.GeneratedSQL, t.RowCount))
+			if len(t.Columns) > 0 {
+				sb.WriteString(" with columns " + strings.Join(t.Columns, ", "))
+			}
+			sb.WriteString("\n")
+			if t.Summary != "" {
+				sb.WriteString("   Summary: " + t.Summary + "\n")
+			}
+		}
+		sb.WriteString("\nThe question may be a follow-up. Keep the indicators, countries, filters and time range of the previous query unless the question changes them.\n\n")
+	}
+
 	sb.WriteString("Question: " + question + "\n\n")
 
 	if len(parsed.Indicators) > 0 {
@@ -105,6 +140,12 @@ func buildGeneratePrompt(question string, parsed *ParseResult) string {
 		sb.WriteString(fmt.Sprintf("Time range: %d-%d\n", parsed.TimeRange.StartYear, parsed.TimeRange.EndYear))
 	}
 	sb.WriteString("Question type: " + parsed.QuestionType + "\n")
+	if d.Name() != DialectPostgres {
+		sb.WriteString("SQL dialect: " + d.Name() + ". Use only syntax and functions this database supports.\n")
+	}
+	if parsed.ForecastHorizon > 0 {
+		sb.WriteString("The question asks about future years. Return the observed yearly series (include a year column), ordered by year; projections are computed separately.\n")
+	}
 	sb.WriteString("\nRespond with a JSON object: {\"sql\": \"...\", \"explanation\": \"...\", \"tables_used\": [...], \"confidence\": 0.0-1.0}")
 
 	return sb.String()
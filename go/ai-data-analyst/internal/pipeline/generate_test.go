@@ -1,8 +1,11 @@
 package pipeline
 
 import (
+	"strings"
 	"testing"
 
+	"ai-data-analyst/internal/db"
+
 	"github.com/stretchr/testify/assert"
 )
 
@@ -48,10 +51,30 @@ func TestBuildGeneratePrompt(t *testing.T) {
 		Countries:    []string{"USA", "CHN"},
 		TimeRange:    &TimeRange{StartYear: 2020, EndYear: 2023},
 	}
-	prompt := buildGeneratePrompt("Top countries by GDP growth", parsed)
+	prompt := buildGeneratePrompt("Top countries by GDP growth", parsed, nil, Postgres{})
 	assert.Contains(t, prompt, "Top countries by GDP growth")
 	assert.Contains(t, prompt, "NY.GDP.MKTP.KD.ZG")
 	assert.Contains(t, prompt, "USA")
 	assert.Contains(t, prompt, "2020-2023")
 	assert.Contains(t, prompt, "ranking")
 }
+
+func TestBuildGeneratePromptWithTurns(t *testing.T) {
+	turns := []db.SessionTurn{{
+		SessionID:    "s1",
+		Question:     "Population of the largest countries in 2020",
+		GeneratedSQL: "SELECT c.name, v.value FROM indicator_values v JOIN countries c ON c.id = v.country_id",
+		Columns:      []string{"name", "value"},
+		RowCount:     10,
+		Summary:      "China and India lead.",
+	}}
+	prompt := buildGeneratePrompt("now only for Asia", &ParseResult{QuestionType: "lookup"}, turns, Postgres{})
+	assert.Contains(t, prompt, "Conversation so far")
+	assert.Contains(t, prompt, "Population of the largest countries in 2020")
+	assert.Contains(t, prompt, "JOIN countries")
+	assert.Contains(t, prompt, "10 rows with columns name, value")
+	assert.Contains(t, prompt, "China and India lead.")
+	assert.Less(t, strings.Index(prompt, "Conversation so far"), strings.Index(prompt, "Question: now only for Asia"))
+
+	assert.NotContains(t, buildGeneratePrompt("now only for Asia", &ParseResult{}, nil, Postgres{}), "Conversation so far")
+}
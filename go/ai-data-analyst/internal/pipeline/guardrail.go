@@ -0,0 +1,220 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Guardrail modes: what the guardrail stage does with PII it finds.
+const (
+	GuardrailOff    = "off"
+	GuardrailRedact = "redact"
+	GuardrailBlock  = "block"
+)
+
+// Guardrail actions, as recorded on the result and span.
+const (
+	GuardrailActionPassed   = "passed"
+	GuardrailActionRedacted = "redacted"
+	GuardrailActionBlocked  = "blocked"
+)
+
+// Kinds of PII the guardrail recognises.
+const (
+	PIIEmail      = "email"
+	PIIPhone      = "phone"
+	PIICreditCard = "credit_card"
+)
+
+// Where PII was found: the user's question or the LLM's explanation.
+const (
+	GuardrailSourceQuestion = "question"
+	GuardrailSourceOutput   = "output"
+)
+
+var ErrGuardrailBlocked = errors.New("answer blocked by guardrail")
+
+// GuardrailError reports the PII that got an answer blocked.
+type GuardrailError struct {
+	Findings []PIIFinding
+}
+
+func (e *GuardrailError) Error() string {
+	parts := make([]string, len(e.Findings))
+	for i, f := range e.Findings {
+		parts[i] = f.Kind + " in " + f.Source
+	}
+	return fmt.Sprintf("%v: %s", ErrGuardrailBlocked, strings.Join(parts, ", "))
+}
+
+func (e *GuardrailError) Unwrap() error { return ErrGuardrailBlocked }
+
+// PIIFinding counts the matches of one kind of PII in one source.
+type PIIFinding struct {
+	Kind   string `json:"kind"`
+	Source string `json:"source"`
+	Count  int    `json:"count"`
+}
+
+type GuardrailResult struct {
+	Mode     string       `json:"mode"`
+	Action   string       `json:"action"`
+	Findings []PIIFinding `json:"findings,omitempty"`
+}
+
+// ParseGuardrailMode checks a GUARDRAIL_MODE value; empty means off.
+func ParseGuardrailMode(mode string) (string, error) {
+	switch strings.ToLower(mode) {
+	case "", GuardrailOff:
+		return GuardrailOff, nil
+	case GuardrailRedact:
+		return GuardrailRedact, nil
+	case GuardrailBlock:
+		return GuardrailBlock, nil
+	}
+	return "", fmt.Errorf("unknown guardrail mode %q (want off, redact or block)", mode)
+}
+
+type piiPattern struct {
+	kind    string
+	pattern *regexp.Regexp
+	// valid, if set, rejects matches the pattern alone cannot rule out.
+	valid func(string) bool
+}
+
+// Card numbers are matched before phone numbers so the digits of a card are
+// not half-redacted as a phone number.
+var piiPatterns = []piiPattern{
+	{kind: PIIEmail, pattern: regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}`)},
+	{kind: PIICreditCard, pattern: regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`), valid: isCardNumber},
+	{kind: PIIPhone, pattern: regexp.MustCompile(`(?:\+\d{1,3}[ .-]?)?(?:\(\d{3}\)|\b\d{3})[ .-]?\d{3}[ .-]\d{4}\b`)},
+}
+
+// isCardNumber keeps the 13-19 digit numbers that start like a card from a
+// major network and pass the Luhn check, so that large indicator values in an
+// explanation are not mistaken for cards.
+func isCardNumber(s string) bool {
+	digits := strings.NewReplacer(" ", "", "-", "").Replace(s)
+	if len(digits) < 13 || len(digits) > 19 || digits[0] < '3' || digits[0] > '6' {
+		return false
+	}
+	sum := 0
+	for i := range len(digits) {
+		d := int(digits[len(digits)-1-i] - '0')
+		if i%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	return sum%10 == 0
+}
+
+// redactPII replaces the PII in text with a [REDACTED:<kind>] marker and
+// counts the matches of each kind.
+func redactPII(text string, counts map[string]int) string {
+	for _, p := range piiPatterns {
+		text = p.pattern.ReplaceAllStringFunc(text, func(match string) string {
+			if p.valid != nil && !p.valid(match) {
+				return match
+			}
+			counts[p.kind]++
+			return "[REDACTED:" + p.kind + "]"
+		})
+	}
+	return text
+}
+
+// Guardrail scans the question and the explanation for email addresses,
+// phone numbers and credit card numbers. In redact mode the matches are
+// replaced in place and the redacted question is returned; in block mode any
+// match fails the question with a *GuardrailError. Each finding is recorded
+// as a guardrail.redaction or guardrail.block event on the span.
+func Guardrail(ctx context.Context, tracer trace.Tracer, mode, question string, explanation *ExplainResult) (string, *GuardrailResult, error) {
+	_, span := tracer.Start(ctx, "pipeline_stage guardrail")
+	defer span.End()
+
+	questionCounts := map[string]int{}
+	redactedQuestion := redactPII(question, questionCounts)
+
+	outputCounts := map[string]int{}
+	var redacted ExplainResult
+	if explanation != nil {
+		redacted = *explanation
+		redacted.Summary = redactPII(explanation.Summary, outputCounts)
+		redacted.Insights = redactAll(explanation.Insights, outputCounts)
+		redacted.Caveats = redactAll(explanation.Caveats, outputCounts)
+		redacted.FollowUps = redactAll(explanation.FollowUps, outputCounts)
+	}
+
+	result := &GuardrailResult{Mode: mode, Action: GuardrailActionPassed}
+	result.Findings = append(findings(GuardrailSourceQuestion, questionCounts), findings(GuardrailSourceOutput, outputCounts)...)
+
+	event := "guardrail.redaction"
+	if len(result.Findings) > 0 {
+		result.Action = GuardrailActionRedacted
+		if mode == GuardrailBlock {
+			result.Action = GuardrailActionBlocked
+			event = "guardrail.block"
+		}
+	}
+	for _, f := range result.Findings {
+		span.AddEvent(event, trace.WithAttributes(
+			attribute.String("nlsql.guardrail.pii_type", f.Kind),
+			attribute.String("nlsql.guardrail.source", f.Source),
+			attribute.Int("nlsql.guardrail.count", f.Count),
+		))
+	}
+	span.SetAttributes(
+		attribute.String("nlsql.stage", "guardrail"),
+		attribute.String("nlsql.guardrail.mode", mode),
+		attribute.String("nlsql.guardrail.action", result.Action),
+		attribute.Int("nlsql.guardrail.findings", len(result.Findings)),
+	)
+
+	if result.Action == GuardrailActionBlocked {
+		err := &GuardrailError{Findings: result.Findings}
+		span.SetStatus(codes.Error, err.Error())
+		return question, result, err
+	}
+	if explanation != nil {
+		*explanation = redacted
+	}
+
+	emitStage(ctx, span, "guardrail", struct {
+		*GuardrailResult
+		Explanation *ExplainResult `json:"explanation,omitempty"`
+	}{result, explanation})
+
+	return redactedQuestion, result, nil
+}
+
+func redactAll(texts []string, counts map[string]int) []string {
+	if texts == nil {
+		return nil
+	}
+	out := make([]string, len(texts))
+	for i, t := range texts {
+		out[i] = redactPII(t, counts)
+	}
+	return out
+}
+
+func findings(source string, counts map[string]int) []PIIFinding {
+	var out []PIIFinding
+	for kind, n := range counts {
+		out = append(out, PIIFinding{Kind: kind, Source: source, Count: n})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Kind < out[j].Kind })
+	return out
+}
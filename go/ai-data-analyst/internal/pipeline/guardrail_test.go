@@ -0,0 +1,111 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedactPII(t *testing.T) {
+	cases := []struct {
+		text string
+		want string
+		kind string
+	}{
+		{"email jane.doe+gdp@example.co.uk the report", "email [REDACTED:email] the report", PIIEmail},
+		{"call (555) 123-4567 today", "call [REDACTED:phone] today", PIIPhone},
+		{"call +1 555.123.4567 today", "call [REDACTED:phone] today", PIIPhone},
+		{"card 4111 1111 1111 1111 on file", "card [REDACTED:credit_card] on file", PIICreditCard},
+		{"card 5500-0000-0000-0004", "card [REDACTED:credit_card]", PIICreditCard},
+	}
+	for _, c := range cases {
+		counts := map[string]int{}
+		assert.Equal(t, c.want, redactPII(c.text, counts), c.text)
+		assert.Equal(t, map[string]int{c.kind: 1}, counts, c.text)
+	}
+}
+
+func TestRedactPIIKeepsIndicatorValues(t *testing.T) {
+	for _, text := range []string{
+		"GDP of the United States was 25462700000000 USD in 2022",
+		"Population grew from 2003-2023 by 12.5%",
+		"China's GDP reached 17963171479205 in 2022",
+		"Between 1990 and 2020 emissions rose 60%",
+	} {
+		counts := map[string]int{}
+		assert.Equal(t, text, redactPII(text, counts))
+		assert.Empty(t, counts, text)
+	}
+}
+
+func TestIsCardNumber(t *testing.T) {
+	assert.True(t, isCardNumber("4111111111111111"))
+	assert.True(t, isCardNumber("3782 822463 10005"))
+	assert.False(t, isCardNumber("4111111111111112"), "fails Luhn")
+	assert.False(t, isCardNumber("1234567812345670"), "not a card prefix")
+	assert.False(t, isCardNumber("411111111111"), "too short")
+}
+
+func TestGuardrailRedact(t *testing.T) {
+	tracer := testTracer().Tracer("test")
+	explanation := &ExplainResult{
+		Summary:  "Send questions to analyst@example.com.",
+		Insights: []string{"India's population was 1.41 billion in 2022."},
+		Caveats:  []string{"Call 555-123-4567 for details."},
+	}
+
+	question, result, err := Guardrail(context.Background(), tracer, GuardrailRedact,
+		"Email me at bob@example.com: population of India", explanation)
+	require.NoError(t, err)
+
+	assert.Equal(t, "Email me at [REDACTED:email]: population of India", question)
+	assert.Equal(t, "Send questions to [REDACTED:email].", explanation.Summary)
+	assert.Equal(t, "India's population was 1.41 billion in 2022.", explanation.Insights[0])
+	assert.Equal(t, "Call [REDACTED:phone] for details.", explanation.Caveats[0])
+
+	assert.Equal(t, GuardrailActionRedacted, result.Action)
+	assert.Equal(t, []PIIFinding{
+		{Kind: PIIEmail, Source: GuardrailSourceQuestion, Count: 1},
+		{Kind: PIIEmail, Source: GuardrailSourceOutput, Count: 1},
+		{Kind: PIIPhone, Source: GuardrailSourceOutput, Count: 1},
+	}, result.Findings)
+}
+
+func TestGuardrailBlock(t *testing.T) {
+	tracer := testTracer().Tracer("test")
+	explanation := &ExplainResult{Summary: "Card 4111 1111 1111 1111 was used."}
+
+	question, result, err := Guardrail(context.Background(), tracer, GuardrailBlock, "Which cards were used?", explanation)
+	require.ErrorIs(t, err, ErrGuardrailBlocked)
+
+	var guardErr *GuardrailError
+	require.ErrorAs(t, err, &guardErr)
+	assert.Equal(t, []PIIFinding{{Kind: PIICreditCard, Source: GuardrailSourceOutput, Count: 1}}, guardErr.Findings)
+	assert.Equal(t, GuardrailActionBlocked, result.Action)
+	assert.Equal(t, "Which cards were used?", question)
+	// A blocked answer is not rewritten.
+	assert.Equal(t, "Card 4111 1111 1111 1111 was used.", explanation.Summary)
+}
+
+func TestGuardrailPassesCleanAnswer(t *testing.T) {
+	tracer := testTracer().Tracer("test")
+	explanation := &ExplainResult{Summary: "Japan's life expectancy was 84.8 years in 2021."}
+
+	question, result, err := Guardrail(context.Background(), tracer, GuardrailBlock, "Life expectancy in Japan?", explanation)
+	require.NoError(t, err)
+	assert.Equal(t, "Life expectancy in Japan?", question)
+	assert.Equal(t, GuardrailActionPassed, result.Action)
+	assert.Empty(t, result.Findings)
+}
+
+func TestParseGuardrailMode(t *testing.T) {
+	for in, want := range map[string]string{"": GuardrailOff, "off": GuardrailOff, "REDACT": GuardrailRedact, "block": GuardrailBlock} {
+		got, err := ParseGuardrailMode(in)
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+	}
+	_, err := ParseGuardrailMode("mask")
+	assert.Error(t, err)
+}
@@ -0,0 +1,117 @@
+package pipeline
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type LintFinding struct {
+	Rule     string `json:"rule"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+type LintResult struct {
+	FormattedSQL string        `json:"formatted_sql"`
+	Findings     []LintFinding `json:"findings"`
+}
+
+const (
+	LintRuleSelectStar        = "select_star"
+	LintRuleCartesianJoin     = "cartesian_join"
+	LintRuleMissingYearFilter = "missing_year_filter"
+)
+
+var (
+	selectStarPattern    = regexp.MustCompile(`(?i)\bSELECT\s+(DISTINCT\s+)?(\w+\.)?\*`)
+	commaJoinPattern     = regexp.MustCompile(`(?i)\bFROM\s+\w+(\s+(AS\s+)?\w+)?\s*,\s*\w+`)
+	crossJoinPattern     = regexp.MustCompile(`(?i)\bCROSS\s+JOIN\b`)
+	joinPattern          = regexp.MustCompile(`(?i)\bJOIN\b`)
+	joinConditionPattern = regexp.MustCompile(`(?i)\b(ON|USING)\b`)
+	wherePattern         = regexp.MustCompile(`(?i)\bWHERE\b`)
+	yearFilterPattern    = regexp.MustCompile(`(?i)\byear\s*(=|<|>|<=|>=|\bBETWEEN\b|\bIN\b)`)
+	whitespacePattern    = regexp.MustCompile(`\s+`)
+	leadingPattern       = regexp.MustCompile(`(?i)^(SELECT|WITH)\b`)
+	clausePattern        = regexp.MustCompile(`(?i)\s+\b(FROM|WHERE|GROUP BY|HAVING|ORDER BY|LIMIT|OFFSET|UNION ALL|UNION|(?:LEFT |RIGHT |INNER |FULL |CROSS )?(?:OUTER )?JOIN)\b`)
+)
+
+// Lint formats generated SQL and flags patterns that are legal but usually
+// wrong for this dataset. Findings are advisory: they never block execution.
+func Lint(ctx context.Context, tracer trace.Tracer, sql string) *LintResult {
+	_, span := tracer.Start(ctx, "pipeline_stage lint")
+	defer span.End()
+
+	code := stripStringLiterals(sql)
+	result := &LintResult{FormattedSQL: FormatSQL(sql)}
+
+	if selectStarPattern.MatchString(code) {
+		result.Findings = append(result.Findings, LintFinding{
+			Rule:     LintRuleSelectStar,
+			Severity: "warning",
+			Message:  "SELECT * returns every column; name the columns the answer needs",
+		})
+	}
+
+	joins := len(joinPattern.FindAllString(code, -1)) - len(crossJoinPattern.FindAllString(code, -1))
+	conditions := len(joinConditionPattern.FindAllString(code, -1))
+	if crossJoinPattern.MatchString(code) ||
+		(commaJoinPattern.MatchString(code) && !wherePattern.MatchString(code)) ||
+		joins > conditions {
+		result.Findings = append(result.Findings, LintFinding{
+			Rule:     LintRuleCartesianJoin,
+			Severity: "error",
+			Message:  "join without a join condition produces a cartesian product",
+		})
+	}
+
+	if strings.Contains(strings.ToLower(code), "indicator_values") && !yearFilterPattern.MatchString(code) {
+		result.Findings = append(result.Findings, LintFinding{
+			Rule:     LintRuleMissingYearFilter,
+			Severity: "warning",
+			Message:  "indicator_values is queried without a year filter; results mix all years 2003-2023",
+		})
+	}
+
+	rules := make([]string, len(result.Findings))
+	for i, f := range result.Findings {
+		rules[i] = f.Rule
+	}
+	span.SetAttributes(
+		attribute.String("nlsql.stage", "lint"),
+		attribute.Int("nlsql.lint.findings_count", len(result.Findings)),
+		attribute.StringSlice("nlsql.lint.rules", rules),
+	)
+
+	return result
+}
+
+// FormatSQL collapses whitespace and starts each major clause on its own line.
+// String literals are left untouched.
+func FormatSQL(sql string) string {
+	parts := strings.Split(strings.TrimSpace(sql), "'")
+	for i := 0; i < len(parts); i += 2 {
+		p := whitespacePattern.ReplaceAllString(parts[i], " ")
+		p = clausePattern.ReplaceAllStringFunc(p, func(m string) string {
+			return "\n" + strings.ToUpper(strings.TrimSpace(m))
+		})
+		if i == 0 {
+			p = leadingPattern.ReplaceAllStringFunc(p, strings.ToUpper)
+		}
+		parts[i] = p
+	}
+	return strings.Join(parts, "'")
+}
+
+// stripStringLiterals blanks out quoted strings so keywords inside values
+// (e.g. a country named "Cross Join") don't trigger rules.
+func stripStringLiterals(sql string) string {
+	parts := strings.Split(sql, "'")
+	for i := 1; i < len(parts); i += 2 {
+		parts[i] = ""
+	}
+	return strings.Join(parts, "''")
+}
@@ -0,0 +1,57 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func lintRules(r *LintResult) []string {
+	rules := make([]string, len(r.Findings))
+	for i, f := range r.Findings {
+		rules[i] = f.Rule
+	}
+	return rules
+}
+
+func TestLintCleanQuery(t *testing.T) {
+	tracer := testTracer().Tracer("test")
+	r := Lint(context.Background(), tracer,
+		"SELECT c.name, iv.value FROM countries c JOIN indicator_values iv ON c.id = iv.country_id WHERE iv.year = 2023 LIMIT 10")
+	assert.Empty(t, r.Findings)
+}
+
+func TestLintSelectStar(t *testing.T) {
+	tracer := testTracer().Tracer("test")
+	r := Lint(context.Background(), tracer, "SELECT * FROM countries LIMIT 10")
+	assert.Contains(t, lintRules(r), LintRuleSelectStar)
+}
+
+func TestLintCountStarIsNotSelectStar(t *testing.T) {
+	tracer := testTracer().Tracer("test")
+	r := Lint(context.Background(), tracer, "SELECT COUNT(*) FROM countries LIMIT 10")
+	assert.NotContains(t, lintRules(r), LintRuleSelectStar)
+}
+
+func TestLintCartesianJoin(t *testing.T) {
+	tracer := testTracer().Tracer("test")
+
+	r := Lint(context.Background(), tracer, "SELECT c.name, i.name FROM countries c, indicators i LIMIT 10")
+	assert.Contains(t, lintRules(r), LintRuleCartesianJoin)
+
+	r = Lint(context.Background(), tracer, "SELECT c.name FROM countries c CROSS JOIN indicators i LIMIT 10")
+	assert.Contains(t, lintRules(r), LintRuleCartesianJoin)
+}
+
+func TestLintMissingYearFilter(t *testing.T) {
+	tracer := testTracer().Tracer("test")
+	r := Lint(context.Background(), tracer,
+		"SELECT c.name, iv.value FROM countries c JOIN indicator_values iv ON c.id = iv.country_id LIMIT 10")
+	assert.Equal(t, []string{LintRuleMissingYearFilter}, lintRules(r))
+}
+
+func TestFormatSQL(t *testing.T) {
+	formatted := FormatSQL("select name   from countries  where region = 'Europe & Central Asia' order by name limit 5")
+	assert.Equal(t, "SELECT name\nFROM countries\nWHERE region = 'Europe & Central Asia'\nORDER BY name\nLIMIT 5", formatted)
+}
@@ -28,6 +28,11 @@ type ParseResult struct {
 	QuestionType     string     `json:"question_type"`
 	Indicators       []string   `json:"indicators"`
 	Countries        []string   `json:"countries"`
+	ForecastHorizon  int        `json:"forecast_horizon,omitempty"`
+
+	// DataSource is the database the question is about: DefaultDataSource
+	// or one of the sources passed to Parse.
+	DataSource string `json:"data_source"`
 }
 
 var indicatorKeywords = map[string]string{
@@ -76,7 +81,9 @@ var countryKeywords = map[string]string{
 var yearPattern = regexp.MustCompile(`\b(19|20)\d{2}\b`)
 var rangePattern = regexp.MustCompile(`\b((?:19|20)\d{2})\s*(?:-|to|through)\s*((?:19|20)\d{2})\b`)
 
-func Parse(ctx context.Context, tracer trace.Tracer, question string) *ParseResult {
+// Parse extracts the entities of a question and picks which of the default
+// source and sources it is about.
+func Parse(ctx context.Context, tracer trace.Tracer, question string, sources ...*DataSource) *ParseResult {
 	ctx, span := tracer.Start(ctx, "pipeline_stage parse")
 	defer span.End()
 
@@ -134,21 +141,40 @@ func Parse(ctx context.Context, tracer trace.Tracer, question string) *ParseResu
 		}
 	}
 
+	result.DataSource = pickDataSource(lower, sources)
+
 	// Classify question type
 	result.QuestionType = classifyQuestion(lower)
 
+	// Future-looking trend questions get a forecast; query the observed years.
+	if result.QuestionType == "trend" {
+		result.ForecastHorizon = detectForecastHorizon(lower, result.TimeRange)
+		if result.ForecastHorizon > 0 && result.TimeRange != nil && result.TimeRange.EndYear > dataLastYear {
+			result.TimeRange.EndYear = dataLastYear
+			if result.TimeRange.StartYear > dataLastYear {
+				result.TimeRange.StartYear = dataFirstYear
+			}
+		}
+	}
+
 	span.SetAttributes(
 		attribute.String("nlsql.stage", "parse"),
+		attribute.String("nlsql.data_source", result.DataSource),
 		attribute.String("nlsql.question_type", result.QuestionType),
 		attribute.Int("nlsql.entities_found", len(result.Entities)),
 		attribute.StringSlice("nlsql.indicators_matched", result.Indicators),
 		attribute.StringSlice("nlsql.countries_matched", result.Countries),
 	)
+	if result.ForecastHorizon > 0 {
+		span.SetAttributes(attribute.Int("nlsql.forecast.horizon", result.ForecastHorizon))
+	}
 	if result.TimeRange != nil {
 		span.SetAttributes(attribute.String("nlsql.time_range",
 			strconv.Itoa(result.TimeRange.StartYear)+"-"+strconv.Itoa(result.TimeRange.EndYear)))
 	}
 
+	emitStage(ctx, span, "parse", result)
+
 	return result
 }
 
@@ -2,9 +2,13 @@ package pipeline
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
+	"ai-data-analyst/internal/auth"
 	"ai-data-analyst/internal/config"
 	"ai-data-analyst/internal/db"
 	"ai-data-analyst/internal/llm"
@@ -15,18 +19,53 @@ import (
 	"go.opentelemetry.io/otel/trace"
 )
 
+// StatusDegraded marks a result that was generated and validated but not
+// executed because the database is unavailable.
+const StatusDegraded = "degraded"
+
 type AskResult struct {
-	Question     string         `json:"question"`
-	SQL          string         `json:"sql"`
-	Columns      []string       `json:"columns"`
-	Rows         [][]any        `json:"rows"`
-	RowCount     int            `json:"row_count"`
-	Explanation  *ExplainResult `json:"explanation"`
-	Confidence   float64        `json:"confidence"`
-	TotalTokens  int            `json:"total_tokens"`
-	TotalCostUSD float64        `json:"total_cost_usd"`
-	DurationMS   int64          `json:"duration_ms"`
-	TraceID      string         `json:"trace_id"`
+	Status       string          `json:"status,omitempty"`
+	Question     string          `json:"question"`
+	SQL          string          `json:"sql"`
+	FormattedSQL string          `json:"formatted_sql,omitempty"`
+	LintFindings []LintFinding   `json:"lint_findings,omitempty"`
+	Columns      []string        `json:"columns"`
+	Rows         [][]any         `json:"rows"`
+	RowCount     int             `json:"row_count"`
+	Explanation  *ExplainResult  `json:"explanation"`
+	Forecast     *ForecastResult `json:"forecast,omitempty"`
+	Confidence   float64         `json:"confidence"`
+	TotalTokens  int             `json:"total_tokens"`
+	TotalCostUSD float64         `json:"total_cost_usd"`
+	DurationMS   int64           `json:"duration_ms"`
+	TraceID      string          `json:"trace_id"`
+	SessionID    string          `json:"session_id,omitempty"`
+
+	// DataSource is the database the question was answered from.
+	DataSource string `json:"data_source,omitempty"`
+
+	// CacheHit is the cache level the answer came from, if any.
+	CacheHit string `json:"cache_hit,omitempty"`
+
+	// SetupSQL and SandboxObjects are set for sandbox sessions: the
+	// statements run before SQL and the temporary objects that exist after.
+	SetupSQL       []string        `json:"setup_sql,omitempty"`
+	SandboxObjects []SandboxObject `json:"sandbox_objects,omitempty"`
+
+	// Repairs is how many times the SQL went back to the LLM after the
+	// database rejected it.
+	Repairs int `json:"repair_attempts,omitempty"`
+
+	// QueryPlan summarises EXPLAIN ANALYZE for the query, when requested
+	// with WithQueryPlan and the query ran rather than coming from a cache.
+	QueryPlan *QueryPlan `json:"query_plan,omitempty"`
+
+	// Guardrail lists the PII redacted from the question and explanation.
+	Guardrail *GuardrailResult `json:"guardrail,omitempty"`
+
+	// validated is set once the SQL has passed validation, whether or not
+	// it ran; only such answers are kept as session context.
+	validated bool
 }
 
 type Pipeline struct {
@@ -35,22 +74,193 @@ type Pipeline struct {
 	Tracer  trace.Tracer
 	Metrics *telemetry.GenAIMetrics
 	Config  *config.Config
+
+	// Dictionary supplies indicator units for the explain prompt. Optional.
+	Dictionary *db.DictionaryCache
+
+	// Runtime holds settings adjustable through the admin API. Optional;
+	// without it the startup Config is used.
+	Runtime *config.RuntimeStore
+
+	// Dialect and Target describe the database generated SQL runs against.
+	// Both are optional: by default queries run on DB as Postgres. History,
+	// sessions and usage are always stored in DB.
+	Dialect Dialect
+	Target  db.Querier
+
+	// Sources are further databases, each with a schema of its own, that
+	// the Parse stage may send a question to instead of the default
+	// source. Optional.
+	Sources []*DataSource
+
+	// Schema retrieves the schema fragments relevant to each question for the
+	// Generate prompt. Optional; without it the full schema context is sent.
+	Schema *SchemaRetriever
+
+	// LiveSchema describes the dataset tables as the database has them, for
+	// the Generate prompt in place of the static schema. Optional; without
+	// it the tables are described as in data/schema-context.txt.
+	LiveSchema *db.SchemaCache
+
+	// Sandboxes runs questions in sandbox sessions, which may create
+	// temporary tables and views. Optional; without it sandbox sessions are
+	// answered like any other.
+	Sandboxes *Sandboxes
+
+	// Cache holds earlier answers and query results. Optional.
+	Cache *ResultCache
+
+	// Prompts holds the versioned system prompts. Optional; without it the
+	// built-in prompts are used.
+	Prompts *PromptRegistry
+}
+
+// askOptions carries the session a question is asked in, if any. fresh
+// skips the cache reads, including the LLM's semantic cache, so the question
+// runs through every stage.
+type askOptions struct {
+	sessionID string
+	turns     []db.SessionTurn
+	sandbox   bool
+	fresh     bool
+}
+
+// Settings returns a snapshot of the current runtime settings.
+func (p *Pipeline) Settings() config.Runtime {
+	if p.Runtime != nil {
+		return p.Runtime.Get()
+	}
+	return config.NewRuntimeStore(p.Config).Get()
+}
+
+func (p *Pipeline) dialect() Dialect {
+	if p.Dialect != nil {
+		return p.Dialect
+	}
+	return Postgres{}
+}
+
+// guardrailMode is the configured GUARDRAIL_MODE. An unknown mode, which
+// main rejects at startup, blocks rather than letting PII through.
+func (p *Pipeline) guardrailMode() string {
+	mode, err := ParseGuardrailMode(p.Config.GuardrailMode)
+	if err != nil {
+		return GuardrailBlock
+	}
+	return mode
+}
+
+func (p *Pipeline) target() db.Querier {
+	if p.Target != nil {
+		return p.Target
+	}
+	return p.DB
+}
+
+// dbAvailable reports whether queries can run on the default source.
+func (p *Pipeline) dbAvailable() bool {
+	return p.source(DefaultDataSource).available()
 }
 
 func (p *Pipeline) Ask(ctx context.Context, question string) (*AskResult, error) {
+	return p.ask(ctx, question, askOptions{})
+}
+
+func (p *Pipeline) ask(ctx context.Context, question string, opts askOptions) (*AskResult, error) {
 	start := time.Now()
 
 	ctx, span := p.Tracer.Start(ctx, "pipeline ask")
 	defer span.End()
+	span.SetAttributes(attribute.String("tenant.id", auth.TenantFrom(ctx)))
+
+	// The LLM calls for this question share one per-request budget.
+	ctx = llm.WithBudgetScope(ctx, opts.sessionID)
+	if opts.fresh {
+		ctx = llm.SkipCache(ctx)
+	}
+	if p.Config != nil {
+		ctx = WithSlowQueryThreshold(ctx, p.Config.SlowQueryThreshold)
+	}
+
+	if opts.sessionID != "" {
+		span.SetAttributes(
+			attribute.String("session.id", opts.sessionID),
+			attribute.Int("nlsql.session.context_turns", len(opts.turns)),
+		)
+	}
+
+	sandbox := opts.sandbox && opts.sessionID != "" && p.Sandboxes != nil
+	var objects []SandboxObject
+	if sandbox {
+		objects = p.Sandboxes.Objects(opts.sessionID)
+		span.SetAttributes(
+			attribute.Bool("nlsql.sandbox", true),
+			attribute.Int("nlsql.sandbox.objects", len(objects)),
+		)
+	}
 
 	traceID := span.SpanContext().TraceID().String()
 
-	// Stage 1: Parse
-	parsed := Parse(ctx, p.Tracer, question)
+	settings := p.Settings()
+	prompts := p.Prompts.snapshot()
+	span.SetAttributes(
+		attribute.Int64("app.config.version", settings.Version),
+		attribute.Bool("nlsql.stream", stageObserverFrom(ctx) != nil),
+		attribute.String("nlsql.prompt.generate.version", prompts.Generate.Version),
+		attribute.String("nlsql.prompt.explain.version", prompts.Explain.Version),
+	)
+
+	// A repeated question outside a session is answered from the cache,
+	// without the LLM or the database. In a session the same words can
+	// mean something else, depending on the earlier turns.
+	var answerKey string
+	if p.Cache != nil && opts.sessionID == "" {
+		source := p.source(pickDataSource(strings.ToLower(question), p.Sources))
+		answerKey = questionKey(question, source.cacheName(), settings.Version, prompts.key())
+		var cached AskResult
+		if !opts.fresh && p.Cache.get(ctx, CacheLevelQuestion, answerKey, &cached) {
+			span.SetAttributes(attribute.String("nlsql.cache", "question_hit"))
+			cached.CacheHit = CacheLevelQuestion
+			cached.TotalTokens, cached.TotalCostUSD = 0, 0
+			cached.DurationMS = time.Since(start).Milliseconds()
+			cached.TraceID = traceID
+			// Nothing ran, so there is no plan to report.
+			cached.QueryPlan = nil
+			// The chart depends on the request, not the answer.
+			if cached.Explanation != nil {
+				cached.Explanation.Chart = nil
+				if chartRequested(ctx) {
+					cached.Explanation.Chart = chartFor(span, &ExecuteResult{
+						Columns: cached.Columns, Rows: cached.Rows, RowCount: cached.RowCount,
+					})
+				}
+			}
+			emitStage(ctx, span, "cache", map[string]string{"level": CacheLevelQuestion})
+			return &cached, nil
+		}
+	}
+
+	// Stage 1: Parse, which also picks the data source. Sandbox sessions
+	// stay on the default source, where their connection is.
+	var sources []*DataSource
+	if !sandbox {
+		sources = p.Sources
+	}
+	parsed := Parse(ctx, p.Tracer, question, sources...)
+	source := p.source(parsed.DataSource)
+	ctx = withDataSource(ctx, source.Name)
+	span.SetAttributes(attribute.String("nlsql.data_source", source.Name))
 
-	// Stage 2: Generate SQL
-	genResult, err := Generate(ctx, p.Tracer, p.LLM, question, parsed,
-		p.Config.LLMModelCapable, p.Config.DefaultTemperature, p.Config.DefaultMaxTokens)
+	// Stage 2: Generate SQL, with the schema context relevant to the question
+	system := source.Schema
+	if source.Name == DefaultDataSource {
+		system = p.schemaContext(ctx, question, parsed, prompts.Generate.Template)
+	}
+	if sandbox {
+		system += sandboxInstructions(objects)
+	}
+	genResult, err := Generate(ctx, p.Tracer, p.LLM, question, parsed, opts.turns, source.Dialect, system, prompts.Generate.Version,
+		settings.ModelCapable, settings.Temperature, settings.MaxTokens)
 	if err != nil {
 		span.SetStatus(codes.Error, err.Error())
 		return nil, fmt.Errorf("generate stage failed: %w", err)
@@ -62,7 +272,7 @@ func (p *Pipeline) Ask(ctx context.Context, question string) (*AskResult, error)
 	}
 
 	// Low confidence check
-	if genResult.Confidence < 0.3 {
+	if genResult.Confidence < settings.MinConfidence {
 		return &AskResult{
 			Question:   question,
 			SQL:        genResult.SQL,
@@ -75,14 +285,22 @@ func (p *Pipeline) Ask(ctx context.Context, question string) (*AskResult, error)
 		}, nil
 	}
 
-	// Stage 3: Validate SQL
-	validated := Validate(ctx, p.Tracer, genResult.SQL)
-
-	if p.Metrics != nil {
-		p.Metrics.SQLValid.Add(ctx, 1,
-			telemetry.WithBoolAttr("nlsql.valid", validated.Valid),
-		)
+	// Stage 3: Validate SQL. Repaired SQL is validated the same way.
+	validate := func(ctx context.Context, sql string) *ValidateResult {
+		var v *ValidateResult
+		if sandbox {
+			v = ValidateSandbox(ctx, p.Tracer, sql, settings.RowLimit, objects)
+		} else {
+			v = ValidateDialect(ctx, p.Tracer, sql, settings.RowLimit, source.Dialect)
+		}
+		if p.Metrics != nil {
+			p.Metrics.SQLValid.Add(ctx, 1,
+				telemetry.WithBoolAttr("nlsql.valid", v.Valid),
+			)
+		}
+		return v
 	}
+	validated := validate(ctx, genResult.SQL)
 
 	if !validated.Valid {
 		span.SetAttributes(
@@ -100,28 +318,182 @@ func (p *Pipeline) Ask(ctx context.Context, question string) (*AskResult, error)
 		}, nil
 	}
 
-	// Stage 4: Execute
-	execResult, err := Execute(ctx, p.Tracer, p.DB, validated.SafeSQL)
-	if err != nil {
-		span.SetStatus(codes.Error, err.Error())
-		return nil, fmt.Errorf("execute stage failed: %w", err)
+	// Lint: advisory formatting and rule checks on the query about to run
+	linted := Lint(ctx, p.Tracer, validated.SafeSQL)
+
+	if p.Metrics != nil {
+		for _, f := range linted.Findings {
+			p.Metrics.LintFindings.Add(ctx, 1, telemetry.WithLintRule(f.Rule))
+		}
 	}
 
+	var repairAttempts int
+
+	// Without a database, return the validated SQL so the caller can still
+	// use it, rather than failing the whole request.
+	degraded := func() *AskResult {
+		span.SetAttributes(attribute.Bool("nlsql.degraded", true))
+		return &AskResult{
+			Status:       StatusDegraded,
+			Question:     question,
+			DataSource:   source.Name,
+			SQL:          validated.SafeSQL,
+			FormattedSQL: linted.FormattedSQL,
+			LintFindings: linted.Findings,
+			SetupSQL:     validated.Setup,
+			Confidence:   genResult.Confidence,
+			Repairs:      repairAttempts,
+			TotalTokens:  genResult.InputTokens + genResult.OutputTokens,
+			TotalCostUSD: genResult.CostUSD,
+			DurationMS:   time.Since(start).Milliseconds(),
+			TraceID:      traceID,
+			validated:    true,
+			Explanation: &ExplainResult{
+				Summary: "The database is unavailable, so the query was generated and validated but not run. Retry once the database is back.",
+			},
+		}
+	}
+
+	// Rows for the same validated SQL are reused, even while the database
+	// is down. Sandbox queries can read session-local tables, so they are
+	// never cached.
+	cacheRows := p.Cache != nil && !sandbox
+	var execResult *ExecuteResult
+	var cacheHit string
+	if cacheRows && !opts.fresh {
+		if cached, ok := p.Cache.getRows(ctx, validated.SafeSQL, source.cacheName()); ok {
+			execResult = cached
+			cacheHit = CacheLevelSQL
+			span.SetAttributes(attribute.String("nlsql.cache", "sql_hit"))
+		} else {
+			span.SetAttributes(attribute.String("nlsql.cache", "miss"))
+		}
+	}
 	questionTypeAttr := telemetry.WithQuestionType(parsed.QuestionType)
 
+	if execResult == nil {
+		if !source.available() {
+			return degraded(), nil
+		}
+
+		// Stage 4: Execute, on the session's own connection in a sandbox so
+		// its temporary objects outlive the question
+		execute := func(ctx context.Context, v *ValidateResult) (*ExecuteResult, error) {
+			if !sandbox {
+				return Execute(ctx, p.Tracer, source.DB, source.Dialect, v.SafeSQL)
+			}
+			r, created, err := p.Sandboxes.Execute(ctx, p.Tracer, opts.sessionID, v.Setup, v.SafeSQL)
+			if err == nil {
+				objects = created
+			}
+			return r, err
+		}
+		execResult, err = execute(ctx, validated)
+
+		// A query the database rejects goes back to the LLM with the error,
+		// up to SQL_REPAIR_ATTEMPTS times, until one runs.
+		for attempt := 1; err != nil && attempt <= p.Config.SQLRepairAttempts; attempt++ {
+			pgErr, ok := repairableError(err)
+			if !ok {
+				break
+			}
+			repairAttempts = attempt
+			run := p.repair(ctx, settings, attempt, question, parsed, system, validated.SafeSQL, pgErr, validate, execute)
+			if run.Gen == nil {
+				break
+			}
+			if run.Validated == nil {
+				genResult.addUsage(run.Gen)
+				break
+			}
+			run.Gen.addUsage(genResult)
+			genResult, validated = run.Gen, run.Validated
+			execResult, err = run.Exec, run.Err
+			linted = Lint(ctx, p.Tracer, validated.SafeSQL)
+		}
+		if repairAttempts > 0 {
+			span.SetAttributes(
+				attribute.Int("nlsql.repair.attempts", repairAttempts),
+				attribute.Bool("nlsql.repaired", err == nil),
+			)
+		}
+
+		if errors.Is(err, db.ErrUnavailable) {
+			return degraded(), nil
+		}
+		if err != nil {
+			span.SetStatus(codes.Error, err.Error())
+			return nil, fmt.Errorf("execute stage failed: %w", err)
+		}
+		if cacheRows {
+			p.Cache.putRows(ctx, validated.SafeSQL, source.cacheName(), execResult)
+		}
+
+		if p.Metrics != nil {
+			p.Metrics.QueryRows.Record(ctx, float64(execResult.RowCount), questionTypeAttr)
+			p.Metrics.QueryExecutionTime.Record(ctx, float64(execResult.Duration.Milliseconds()), questionTypeAttr)
+		}
+	}
+
 	if p.Metrics != nil {
-		p.Metrics.QueryRows.Record(ctx, float64(execResult.RowCount), questionTypeAttr)
-		p.Metrics.QueryExecutionTime.Record(ctx, float64(execResult.Duration.Milliseconds()), questionTypeAttr)
 		p.Metrics.Confidence.Record(ctx, genResult.Confidence, questionTypeAttr)
 	}
 
-	// Stage 5: Explain
-	explainResult, err := Explain(ctx, p.Tracer, p.LLM, question, validated.SafeSQL, execResult,
-		p.Config.LLMModelFast, 0.3, 512)
+	// Stage 5: Explain. With the guardrail on, the explanation is not
+	// streamed until it has been filtered.
+	guardrail := p.guardrailMode()
+	explainCtx := ctx
+	if guardrail != GuardrailOff {
+		explainCtx = WithTokenObserver(WithStageObserver(ctx, nil), nil)
+	}
+	units := p.Dictionary.Units(ctx, parsed.Indicators)
+	explainResult, err := Explain(explainCtx, p.Tracer, p.LLM, prompts.Explain, question, validated.SafeSQL, execResult, units,
+		settings.ModelFast, 0.3, 512)
+	// Running out of budget after the query ran still returns the rows,
+	// just without the explanation.
+	if errors.Is(err, llm.ErrBudgetExceeded) {
+		explainResult, err = &ExplainResult{Summary: "Explanation skipped: " + err.Error()}, nil
+	}
 	if err != nil {
 		span.SetStatus(codes.Error, err.Error())
 		return nil, fmt.Errorf("explain stage failed: %w", err)
 	}
+	if p.Metrics != nil {
+		for _, c := range explainResult.Citations {
+			for _, problem := range c.Problems {
+				p.Metrics.Hallucinations.Add(ctx, 1, telemetry.WithHallucinationKind(problem.Kind))
+			}
+		}
+	}
+
+	// Stage 6: Forecast (trend questions about the future only)
+	var forecast *ForecastResult
+	if parsed.QuestionType == "trend" && parsed.ForecastHorizon > 0 {
+		forecast = Forecast(ctx, p.Tracer, execResult, parsed.ForecastHorizon)
+		if forecast != nil {
+			explainResult.Caveats = append(explainResult.Caveats, forecast.Note)
+		}
+	}
+
+	// Stage 7: Guardrail. PII in the question or the explanation is
+	// redacted, or the answer is blocked, before anything is returned or
+	// stored.
+	var guardrailResult *GuardrailResult
+	if guardrail != GuardrailOff {
+		question, guardrailResult, err = Guardrail(ctx, p.Tracer, guardrail, question, explainResult)
+		if err != nil {
+			if p.Metrics != nil {
+				for _, f := range guardrailResult.Findings {
+					p.Metrics.GuardrailBlocked.Add(ctx, 1, telemetry.WithGuardrail(f.Kind, f.Source))
+				}
+			}
+			span.SetStatus(codes.Error, err.Error())
+			return nil, err
+		}
+		if len(guardrailResult.Findings) == 0 {
+			guardrailResult = nil
+		}
+	}
 
 	duration := time.Since(start)
 
@@ -130,16 +502,43 @@ func (p *Pipeline) Ask(ctx context.Context, question string) (*AskResult, error)
 
 	result := &AskResult{
 		Question:     question,
+		DataSource:   source.Name,
 		SQL:          validated.SafeSQL,
+		FormattedSQL: linted.FormattedSQL,
+		LintFindings: linted.Findings,
+		SetupSQL:     validated.Setup,
 		Columns:      execResult.Columns,
 		Rows:         execResult.Rows,
 		RowCount:     execResult.RowCount,
 		Explanation:  explainResult,
+		Forecast:     forecast,
+		Guardrail:    guardrailResult,
 		Confidence:   genResult.Confidence,
+		Repairs:      repairAttempts,
 		TotalTokens:  totalTokens,
 		TotalCostUSD: totalCost,
 		DurationMS:   duration.Milliseconds(),
 		TraceID:      traceID,
+		CacheHit:     cacheHit,
+		validated:    true,
+	}
+	if sandbox {
+		result.SandboxObjects = objects
+	}
+	if answerKey != "" {
+		p.Cache.put(ctx, answerKey, result)
+	}
+	// Cached rows carry the plan of the request that ran them. A plan
+	// captured only because the query was slow goes to history, not the
+	// response.
+	var slowPlan json.RawMessage
+	if cacheHit == "" {
+		if planRequested(ctx) {
+			result.QueryPlan = execResult.Plan
+		}
+		if execResult.Slow && execResult.Plan != nil {
+			slowPlan = execResult.Plan.Raw
+		}
 	}
 
 	if p.Metrics != nil {
@@ -147,17 +546,23 @@ func (p *Pipeline) Ask(ctx context.Context, question string) (*AskResult, error)
 	}
 
 	// Save to history
-	_, _ = db.InsertQueryHistory(ctx, p.DB, db.InsertHistoryParams{
-		Question:     question,
-		QuestionType: parsed.QuestionType,
-		GeneratedSQL: validated.SafeSQL,
-		Confidence:   genResult.Confidence,
-		RowCount:     execResult.RowCount,
-		ExecutionMS:  int(execResult.Duration.Milliseconds()),
-		TotalTokens:  result.TotalTokens,
-		TotalCostUSD: result.TotalCostUSD,
-		Explanation:  explainResult.Summary,
-		TraceID:      traceID,
+	_, _ = db.NewHistoryRepo(p.DB).Insert(ctx, db.InsertHistoryParams{
+		UserID:        auth.UserFrom(ctx),
+		APIKeyID:      auth.KeyIDFrom(ctx),
+		TenantID:      auth.TenantFrom(ctx),
+		Question:      question,
+		QuestionType:  parsed.QuestionType,
+		DataSource:    source.Name,
+		GeneratedSQL:  result.Script(),
+		Confidence:    genResult.Confidence,
+		RowCount:      execResult.RowCount,
+		ExecutionMS:   int(execResult.Duration.Milliseconds()),
+		TotalTokens:   result.TotalTokens,
+		TotalCostUSD:  result.TotalCostUSD,
+		Explanation:   explainResult.Summary,
+		TraceID:       traceID,
+		ResultHash:    ResultHash(execResult),
+		SlowQueryPlan: slowPlan,
 	})
 
 	span.SetAttributes(
@@ -169,3 +574,12 @@ func (p *Pipeline) Ask(ctx context.Context, question string) (*AskResult, error)
 
 	return result, nil
 }
+
+// Script returns the SQL that produced the result, with any sandbox setup
+// statements before the query.
+func (r *AskResult) Script() string {
+	if len(r.SetupSQL) == 0 {
+		return r.SQL
+	}
+	return strings.Join(append(append([]string(nil), r.SetupSQL...), r.SQL), ";\n")
+}
@@ -0,0 +1,171 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"ai-data-analyst/internal/db"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// QueryPlan summarises the EXPLAIN ANALYZE output for an executed query.
+type QueryPlan struct {
+	// NodeType is the plan's root node, such as "Limit" or "Sort".
+	NodeType string `json:"node_type"`
+	// TotalCost is the planner's estimated cost of the whole query.
+	TotalCost float64 `json:"total_cost"`
+	// PlanRows is the planner's row estimate; ActualRows is what the root
+	// node returned.
+	PlanRows   float64 `json:"plan_rows"`
+	ActualRows float64 `json:"actual_rows"`
+	// SeqScans counts sequential scan nodes, and SeqScanTables the tables
+	// they read.
+	SeqScans      int      `json:"seq_scans"`
+	SeqScanTables []string `json:"seq_scan_tables,omitempty"`
+	PlanningMS    float64  `json:"planning_ms"`
+	ExecutionMS   float64  `json:"execution_ms"`
+
+	// Raw is the full EXPLAIN output, kept in history for slow queries.
+	Raw json.RawMessage `json:"-"`
+}
+
+// slowPlanEventMaxBytes caps the plan text attached to a span event.
+const slowPlanEventMaxBytes = 4096
+
+type planOptionKey struct{}
+
+// WithQueryPlan returns a context under which the Execute stage also runs
+// EXPLAIN ANALYZE on the query and attaches the plan summary to its result.
+func WithQueryPlan(ctx context.Context) context.Context {
+	return context.WithValue(ctx, planOptionKey{}, true)
+}
+
+func planRequested(ctx context.Context) bool {
+	ok, _ := ctx.Value(planOptionKey{}).(bool)
+	return ok
+}
+
+type slowQueryKey struct{}
+
+// WithSlowQueryThreshold returns a context under which the Execute stage
+// captures the plan of any query that takes threshold or longer. Zero
+// disables the capture.
+func WithSlowQueryThreshold(ctx context.Context, threshold time.Duration) context.Context {
+	return context.WithValue(ctx, slowQueryKey{}, threshold)
+}
+
+func slowQueryThreshold(ctx context.Context) time.Duration {
+	threshold, _ := ctx.Value(slowQueryKey{}).(time.Duration)
+	return threshold
+}
+
+// recordSlowPlan attaches the plan of a slow query to span as a
+// "slow_query_plan" event, truncated to slowPlanEventMaxBytes.
+func recordSlowPlan(span trace.Span, plan *QueryPlan, threshold time.Duration) {
+	text, truncated := truncatePlan(string(plan.Raw), slowPlanEventMaxBytes)
+	span.AddEvent("slow_query_plan", trace.WithAttributes(
+		attribute.Int64("nlsql.slow_query.threshold_ms", threshold.Milliseconds()),
+		attribute.String("nlsql.plan.text", text),
+		attribute.Bool("nlsql.plan.truncated", truncated),
+	))
+}
+
+// truncatePlan cuts text to at most limit bytes without splitting a rune.
+func truncatePlan(text string, limit int) (string, bool) {
+	if len(text) <= limit {
+		return text, false
+	}
+	return strings.ToValidUTF8(text[:limit], ""), true
+}
+
+// planNode is the part of a Postgres JSON plan node the summary reads.
+type planNode struct {
+	NodeType     string     `json:"Node Type"`
+	RelationName string     `json:"Relation Name"`
+	TotalCost    float64    `json:"Total Cost"`
+	PlanRows     float64    `json:"Plan Rows"`
+	ActualRows   float64    `json:"Actual Rows"`
+	ActualLoops  float64    `json:"Actual Loops"`
+	Plans        []planNode `json:"Plans"`
+}
+
+// ParsePlan summarises the output of EXPLAIN (ANALYZE, FORMAT JSON).
+func ParsePlan(raw []byte) (*QueryPlan, error) {
+	var out []struct {
+		Plan          planNode `json:"Plan"`
+		PlanningTime  float64  `json:"Planning Time"`
+		ExecutionTime float64  `json:"Execution Time"`
+	}
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return nil, fmt.Errorf("parse query plan: %w", err)
+	}
+	if len(out) == 0 {
+		return nil, errors.New("parse query plan: empty plan")
+	}
+
+	root := out[0].Plan
+	plan := &QueryPlan{
+		NodeType:    root.NodeType,
+		TotalCost:   root.TotalCost,
+		PlanRows:    root.PlanRows,
+		ActualRows:  root.ActualRows * max(root.ActualLoops, 1),
+		PlanningMS:  out[0].PlanningTime,
+		ExecutionMS: out[0].ExecutionTime,
+	}
+	var walk func(n planNode)
+	walk = func(n planNode) {
+		if n.NodeType == "Seq Scan" {
+			plan.SeqScans++
+			if n.RelationName != "" && !slices.Contains(plan.SeqScanTables, n.RelationName) {
+				plan.SeqScanTables = append(plan.SeqScanTables, n.RelationName)
+			}
+		}
+		for _, child := range n.Plans {
+			walk(child)
+		}
+	}
+	walk(root)
+	return plan, nil
+}
+
+// capturePlan runs sql again under EXPLAIN ANALYZE and records the summary
+// on span. A plan that cannot be captured is recorded as an event and
+// returns nil; the query has already run, so it does not fail the stage.
+func capturePlan(ctx context.Context, span trace.Span, q db.Querier, d Dialect, sql string) *QueryPlan {
+	stmt := d.ExplainAnalyze(sql)
+	if stmt == "" {
+		span.SetAttributes(attribute.String("nlsql.plan", "unsupported"))
+		return nil
+	}
+
+	var raw []byte
+	err := q.QueryRow(ctx, stmt).Scan(&raw)
+	var plan *QueryPlan
+	if err == nil {
+		plan, err = ParsePlan(raw)
+	}
+	if err != nil {
+		span.SetAttributes(attribute.String("nlsql.plan", "failed"))
+		span.AddEvent("plan_capture_failed", trace.WithAttributes(attribute.String("nlsql.plan.error", err.Error())))
+		return nil
+	}
+	plan.Raw = raw
+
+	span.SetAttributes(
+		attribute.String("nlsql.plan", plan.NodeType),
+		attribute.Float64("nlsql.plan.total_cost", plan.TotalCost),
+		attribute.Float64("nlsql.plan.rows", plan.PlanRows),
+		attribute.Float64("nlsql.plan.actual_rows", plan.ActualRows),
+		attribute.Int("nlsql.plan.seq_scans", plan.SeqScans),
+		attribute.StringSlice("nlsql.plan.seq_scan_tables", plan.SeqScanTables),
+		attribute.Float64("nlsql.plan.execution_ms", plan.ExecutionMS),
+	)
+	return plan
+}
@@ -0,0 +1,121 @@
+package pipeline
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"ai-data-analyst/internal/db"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+const samplePlan = `[{
+  "Plan": {
+    "Node Type": "Limit", "Total Cost": 1520.75, "Plan Rows": 50, "Actual Rows": 50, "Actual Loops": 1,
+    "Plans": [{
+      "Node Type": "Hash Join", "Total Cost": 1510.2, "Plan Rows": 4200, "Actual Rows": 50, "Actual Loops": 1,
+      "Plans": [
+        {"Node Type": "Seq Scan", "Relation Name": "indicator_values", "Total Cost": 1300, "Plan Rows": 74000, "Actual Rows": 900, "Actual Loops": 1},
+        {"Node Type": "Hash", "Total Cost": 5.2, "Plan Rows": 217, "Actual Rows": 217, "Actual Loops": 1,
+         "Plans": [{"Node Type": "Seq Scan", "Relation Name": "countries", "Total Cost": 5.17, "Plan Rows": 217, "Actual Rows": 217, "Actual Loops": 1}]}
+      ]
+    }]
+  },
+  "Planning Time": 0.42,
+  "Execution Time": 3.9
+}]`
+
+func TestParsePlan(t *testing.T) {
+	plan, err := ParsePlan([]byte(samplePlan))
+	require.NoError(t, err)
+	assert.Equal(t, "Limit", plan.NodeType)
+	assert.InDelta(t, 1520.75, plan.TotalCost, 1e-9)
+	assert.InDelta(t, 50, plan.PlanRows, 1e-9)
+	assert.InDelta(t, 50, plan.ActualRows, 1e-9)
+	assert.Equal(t, 2, plan.SeqScans)
+	assert.Equal(t, []string{"indicator_values", "countries"}, plan.SeqScanTables)
+	assert.InDelta(t, 0.42, plan.PlanningMS, 1e-9)
+	assert.InDelta(t, 3.9, plan.ExecutionMS, 1e-9)
+}
+
+func TestParsePlanNoSeqScans(t *testing.T) {
+	plan, err := ParsePlan([]byte(`[{"Plan": {"Node Type": "Index Scan", "Relation Name": "countries", "Total Cost": 8.3, "Plan Rows": 1, "Actual Rows": 1, "Actual Loops": 1}}]`))
+	require.NoError(t, err)
+	assert.Equal(t, 0, plan.SeqScans)
+	assert.Empty(t, plan.SeqScanTables)
+}
+
+func TestParsePlanInvalid(t *testing.T) {
+	_, err := ParsePlan([]byte(`[]`))
+	assert.Error(t, err)
+	_, err = ParsePlan([]byte(`not json`))
+	assert.Error(t, err)
+}
+
+func TestExplainAnalyze(t *testing.T) {
+	assert.Equal(t, "EXPLAIN (ANALYZE, FORMAT JSON) SELECT 1", Postgres{}.ExplainAnalyze("SELECT 1;"))
+	assert.Equal(t, "EXPLAIN (ANALYZE, FORMAT JSON) SELECT 1", sandboxDialect{}.ExplainAnalyze("SELECT 1"))
+	assert.Empty(t, MySQL{}.ExplainAnalyze("SELECT 1"))
+	assert.Empty(t, SQLite{}.ExplainAnalyze("SELECT 1"))
+}
+
+func TestWithQueryPlan(t *testing.T) {
+	assert.False(t, planRequested(context.Background()))
+	assert.True(t, planRequested(WithQueryPlan(context.Background())))
+}
+
+// planSQLite answers EXPLAIN ANALYZE with samplePlan, so plan capture can run
+// against SQLite.
+type planSQLite struct{ SQLite }
+
+func (planSQLite) ExplainAnalyze(string) string {
+	return "SELECT '" + strings.ReplaceAll(samplePlan, "'", "''") + "'"
+}
+
+func TestExecuteCapturesSlowQueryPlan(t *testing.T) {
+	target, err := db.OpenSQL(context.Background(), "sqlite", filepath.Join(t.TempDir(), "analyst.db"), SQLite{}.ConnSetup())
+	require.NoError(t, err)
+	defer target.Close()
+
+	exporter := tracetest.NewInMemoryExporter()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)).Tracer("test")
+
+	fast := WithSlowQueryThreshold(context.Background(), time.Hour)
+	result, err := Execute(fast, tracer, target, planSQLite{}, "SELECT 1")
+	require.NoError(t, err)
+	assert.False(t, result.Slow)
+	assert.Nil(t, result.Plan)
+
+	slow := WithSlowQueryThreshold(context.Background(), time.Nanosecond)
+	result, err = Execute(slow, tracer, target, planSQLite{}, "SELECT 1")
+	require.NoError(t, err)
+	assert.True(t, result.Slow)
+	require.NotNil(t, result.Plan)
+	assert.Equal(t, "Limit", result.Plan.NodeType)
+	assert.JSONEq(t, samplePlan, string(result.Plan.Raw))
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+	assert.Empty(t, spans[0].Events)
+	require.Len(t, spans[1].Events, 1)
+	event := spans[1].Events[0]
+	assert.Equal(t, "slow_query_plan", event.Name)
+	assert.Contains(t, event.Attributes, attribute.Bool("nlsql.plan.truncated", false))
+}
+
+func TestTruncatePlan(t *testing.T) {
+	text, truncated := truncatePlan("short", 10)
+	assert.Equal(t, "short", text)
+	assert.False(t, truncated)
+
+	text, truncated = truncatePlan("héllo", 2)
+	assert.Equal(t, "h", text, "a split rune is dropped")
+	assert.True(t, truncated)
+}
@@ -0,0 +1,245 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"ai-data-analyst/internal/db"
+)
+
+// Prompt names: the system prompts of the Generate and Explain stages.
+const (
+	PromptGenerate = "generate"
+	PromptExplain  = "explain"
+)
+
+// PromptVersionBuiltin is the version of each prompt compiled into the
+// server, active until another is activated.
+const PromptVersionBuiltin = "builtin"
+
+// Where a prompt version was loaded from.
+const (
+	PromptSourceBuiltin = "builtin"
+	PromptSourceFile    = "file"
+	PromptSourceDB      = "db"
+)
+
+var ErrPromptNotFound = errors.New("prompt version not found")
+
+// PromptTemplate is one version of a system prompt. For PromptGenerate it is
+// the full schema context; schema retrieval keeps its first paragraph and
+// its constraints around the retrieved fragments.
+type PromptTemplate struct {
+	Name     string `json:"name"`
+	Version  string `json:"version"`
+	Source   string `json:"source"`
+	Template string `json:"template"`
+}
+
+// PromptInfo is a prompt's versions and which one is active.
+type PromptInfo struct {
+	Name        string           `json:"name"`
+	Active      string           `json:"active"`
+	ActivatedBy string           `json:"activated_by,omitempty"`
+	ActivatedAt *time.Time       `json:"activated_at,omitempty"`
+	Versions    []PromptTemplate `json:"versions"`
+}
+
+// PromptRegistry holds the versions of each system prompt and which one is
+// active. Like the runtime config, a question reads the active versions once
+// and uses them throughout, and the versions are recorded on its spans as
+// gen_ai.prompt.version so a change in answers can be traced to a prompt.
+//
+// A nil registry serves the built-in prompts.
+type PromptRegistry struct {
+	// DB, if set, persists activations so they survive a restart.
+	DB db.Querier
+
+	mu       sync.RWMutex
+	versions map[string][]PromptTemplate
+	active   map[string]PromptInfo
+}
+
+// NewPromptRegistry returns a registry with the built-in prompts active.
+func NewPromptRegistry() *PromptRegistry {
+	r := &PromptRegistry{
+		versions: map[string][]PromptTemplate{},
+		active:   map[string]PromptInfo{},
+	}
+	for name, text := range builtinPrompts() {
+		r.add(PromptTemplate{Name: name, Version: PromptVersionBuiltin, Source: PromptSourceBuiltin, Template: text})
+		r.active[name] = PromptInfo{Name: name, Active: PromptVersionBuiltin}
+	}
+	return r
+}
+
+func builtinPrompts() map[string]string {
+	return map[string]string{
+		PromptGenerate: schemaContext,
+		PromptExplain:  explainSystemPrompt,
+	}
+}
+
+// add registers t, replacing an earlier version of the same name.
+func (r *PromptRegistry) add(t PromptTemplate) {
+	list := r.versions[t.Name]
+	for i, existing := range list {
+		if existing.Version == t.Version {
+			list[i] = t
+			return
+		}
+	}
+	r.versions[t.Name] = append(list, t)
+}
+
+// LoadDir adds the versions in dir, laid out as <name>/<version>.txt, such
+// as generate/v2.txt. A missing dir adds nothing.
+func (r *PromptRegistry) LoadDir(dir string) (int, error) {
+	loaded := 0
+	for name := range builtinPrompts() {
+		files, err := filepath.Glob(filepath.Join(dir, name, "*.txt"))
+		if err != nil {
+			return loaded, err
+		}
+		sort.Strings(files)
+		for _, f := range files {
+			version := strings.TrimSuffix(filepath.Base(f), ".txt")
+			if version == PromptVersionBuiltin {
+				continue
+			}
+			data, err := os.ReadFile(f)
+			if err != nil {
+				return loaded, fmt.Errorf("read prompt %s: %w", f, err)
+			}
+			r.mu.Lock()
+			r.add(PromptTemplate{Name: name, Version: version, Source: PromptSourceFile, Template: strings.TrimSpace(string(data))})
+			r.mu.Unlock()
+			loaded++
+		}
+	}
+	return loaded, nil
+}
+
+// LoadDB adds the versions stored in prompt_templates, replacing file
+// versions of the same name, then restores the persisted activations.
+func (r *PromptRegistry) LoadDB(ctx context.Context, q db.Querier) (int, error) {
+	templates, err := db.ListPromptTemplates(ctx, q)
+	if err != nil {
+		return 0, fmt.Errorf("list prompt templates: %w", err)
+	}
+	activations, err := db.ListPromptActivations(ctx, q)
+	if err != nil {
+		return 0, fmt.Errorf("list prompt activations: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	loaded := 0
+	for _, t := range templates {
+		if _, known := r.versions[t.Name]; !known || t.Version == PromptVersionBuiltin {
+			continue
+		}
+		r.add(PromptTemplate{Name: t.Name, Version: t.Version, Source: PromptSourceDB, Template: t.Template})
+		loaded++
+	}
+	for _, a := range activations {
+		if _, ok := r.find(a.Name, a.Version); ok {
+			at := a.ActivatedAt
+			r.active[a.Name] = PromptInfo{Name: a.Name, Active: a.Version, ActivatedBy: a.ActivatedBy, ActivatedAt: &at}
+		}
+	}
+	return loaded, nil
+}
+
+func (r *PromptRegistry) find(name, version string) (PromptTemplate, bool) {
+	for _, t := range r.versions[name] {
+		if t.Version == version {
+			return t, true
+		}
+	}
+	return PromptTemplate{}, false
+}
+
+// Get returns the active version of the prompt name.
+func (r *PromptRegistry) Get(name string) PromptTemplate {
+	if r == nil {
+		return PromptTemplate{Name: name, Version: PromptVersionBuiltin, Source: PromptSourceBuiltin, Template: builtinPrompts()[name]}
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	t, _ := r.find(name, r.active[name].Active)
+	return t
+}
+
+// List returns every prompt with its versions, by name.
+func (r *PromptRegistry) List() []PromptInfo {
+	if r == nil {
+		return nil
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	out := make([]PromptInfo, 0, len(r.versions))
+	for name, versions := range r.versions {
+		info := r.active[name]
+		info.Versions = append([]PromptTemplate(nil), versions...)
+		out = append(out, info)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// Activate makes version the active one for the prompt name on behalf of
+// user, persisting the choice first when DB is set.
+func (r *PromptRegistry) Activate(ctx context.Context, name, version, user string) (PromptInfo, error) {
+	r.mu.RLock()
+	_, ok := r.find(name, version)
+	r.mu.RUnlock()
+	if !ok {
+		return PromptInfo{}, fmt.Errorf("%w: %s %s", ErrPromptNotFound, name, version)
+	}
+
+	if r.DB != nil {
+		if err := db.SetPromptActivation(ctx, r.DB, name, version, user); err != nil {
+			return PromptInfo{}, fmt.Errorf("save prompt activation: %w", err)
+		}
+	}
+
+	now := time.Now().UTC()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	info := PromptInfo{Name: name, Active: version, ActivatedBy: user, ActivatedAt: &now}
+	r.active[name] = info
+	info.Versions = append([]PromptTemplate(nil), r.versions[name]...)
+	return info, nil
+}
+
+// promptSet is the prompts a question was answered with.
+type promptSet struct {
+	Generate PromptTemplate
+	Explain  PromptTemplate
+}
+
+func (r *PromptRegistry) snapshot() promptSet {
+	if r == nil {
+		return promptSet{Generate: r.Get(PromptGenerate), Explain: r.Get(PromptExplain)}
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	generate, _ := r.find(PromptGenerate, r.active[PromptGenerate].Active)
+	explain, _ := r.find(PromptExplain, r.active[PromptExplain].Active)
+	return promptSet{Generate: generate, Explain: explain}
+}
+
+// key identifies the versions in s, for cache keys.
+func (s promptSet) key() string {
+	return PromptGenerate + "=" + s.Generate.Version + "," + PromptExplain + "=" + s.Explain.Version
+}
@@ -0,0 +1,70 @@
+package pipeline
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPromptRegistryBuiltins(t *testing.T) {
+	r := NewPromptRegistry()
+	assert.Equal(t, PromptVersionBuiltin, r.Get(PromptGenerate).Version)
+	assert.Equal(t, schemaContext, r.Get(PromptGenerate).Template)
+	assert.Equal(t, explainSystemPrompt, r.Get(PromptExplain).Template)
+
+	var nilRegistry *PromptRegistry
+	assert.Equal(t, explainSystemPrompt, nilRegistry.Get(PromptExplain).Template)
+	assert.Equal(t, r.snapshot(), nilRegistry.snapshot())
+	assert.Nil(t, nilRegistry.List())
+}
+
+func TestPromptRegistryLoadDirAndActivate(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, PromptExplain), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, PromptExplain, "v2.txt"), []byte("Explain briefly.\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, PromptExplain, "builtin.txt"), []byte("ignored"), 0o644))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "unknown"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "unknown", "v1.txt"), []byte("ignored"), 0o644))
+
+	r := NewPromptRegistry()
+	n, err := r.LoadDir(dir)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	// Loading alone does not change the active version.
+	before := r.snapshot()
+	assert.Equal(t, PromptVersionBuiltin, before.Explain.Version)
+
+	info, err := r.Activate(context.Background(), PromptExplain, "v2", "alice")
+	require.NoError(t, err)
+	assert.Equal(t, "v2", info.Active)
+	assert.Equal(t, "alice", info.ActivatedBy)
+	assert.Len(t, info.Versions, 2)
+
+	after := r.snapshot()
+	assert.Equal(t, "Explain briefly.", after.Explain.Template)
+	assert.Equal(t, PromptSourceFile, after.Explain.Source)
+	assert.NotEqual(t, before.key(), after.key())
+
+	_, err = r.Activate(context.Background(), PromptExplain, "v9", "alice")
+	assert.ErrorIs(t, err, ErrPromptNotFound)
+	assert.Equal(t, "v2", r.Get(PromptExplain).Version)
+}
+
+func TestPromptRegistryLoadDirMissing(t *testing.T) {
+	n, err := NewPromptRegistry().LoadDir(filepath.Join(t.TempDir(), "missing"))
+	require.NoError(t, err)
+	assert.Zero(t, n)
+}
+
+func TestPromptRegistryList(t *testing.T) {
+	prompts := NewPromptRegistry().List()
+	require.Len(t, prompts, 2)
+	assert.Equal(t, PromptExplain, prompts[0].Name)
+	assert.Equal(t, PromptGenerate, prompts[1].Name)
+	assert.Equal(t, PromptVersionBuiltin, prompts[1].Active)
+}
@@ -0,0 +1,154 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"ai-data-analyst/internal/config"
+	"ai-data-analyst/internal/llm"
+	"ai-data-analyst/internal/telemetry"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// Outcomes of a repair attempt, recorded as nlsql.repair.outcome.
+const (
+	RepairOutcomeSuccess       = "success"
+	RepairOutcomeExecuteError  = "execute_error"
+	RepairOutcomeInvalid       = "invalid"
+	RepairOutcomeGenerateError = "generate_error"
+)
+
+// repairableError returns the error to show the LLM when err is a Postgres
+// error caused by the query itself, such as a misspelt column or a type
+// mismatch. Connection, resource and server errors are not the query's
+// fault, and rewriting it would not help.
+func repairableError(err error) (*pgconn.PgError, bool) {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return nil, false
+	}
+	switch pgErr.Code[:min(2, len(pgErr.Code))] {
+	case "08", "53", "57", "58", "XX":
+		return nil, false
+	}
+	return pgErr, true
+}
+
+// repairRun is the result of one repair attempt. Gen is nil when the LLM
+// call failed, and Validated is nil when the repaired SQL was empty or
+// rejected, so nothing ran. Err is set when the repaired query also failed.
+type repairRun struct {
+	Gen       *GenerateResult
+	Validated *ValidateResult
+	Exec      *ExecuteResult
+	Err       error
+}
+
+// executeFunc runs validated SQL, in the question's sandbox if it has one.
+type executeFunc func(ctx context.Context, validated *ValidateResult) (*ExecuteResult, error)
+
+// validateFunc validates SQL the way the question's first query was.
+type validateFunc func(ctx context.Context, sql string) *ValidateResult
+
+// repair asks the LLM to correct sql, which failed with pgErr, then
+// validates and runs the correction. Each attempt is its own span, with the
+// LLM call, validation and execution under it.
+func (p *Pipeline) repair(ctx context.Context, settings config.Runtime, attempt int, question string, parsed *ParseResult, system string, sql string, pgErr *pgconn.PgError, validate validateFunc, execute executeFunc) *repairRun {
+	ctx, span := p.Tracer.Start(ctx, "pipeline_stage repair")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("nlsql.stage", "repair"),
+		attribute.Int("nlsql.repair.attempt", attempt),
+		attribute.String("db.response.status_code", pgErr.Code),
+		attribute.String("nlsql.repair.error", pgErr.Message),
+	)
+
+	outcome := RepairOutcomeSuccess
+	defer func() {
+		span.SetAttributes(attribute.String("nlsql.repair.outcome", outcome))
+		if p.Metrics != nil {
+			p.Metrics.RepairCount.Add(ctx, 1, telemetry.WithRepairOutcome(outcome))
+		}
+	}()
+
+	gen, err := Repair(ctx, p.LLM, question, parsed, p.source(parsed.DataSource).Dialect, system, sql, pgErr,
+		settings.ModelCapable, settings.Temperature, settings.MaxTokens)
+	if err != nil {
+		outcome = RepairOutcomeGenerateError
+		span.SetStatus(codes.Error, err.Error())
+		return &repairRun{}
+	}
+	if gen.SQL == "" {
+		outcome = RepairOutcomeGenerateError
+		return &repairRun{Gen: gen}
+	}
+
+	validated := validate(ctx, gen.SQL)
+	if !validated.Valid {
+		outcome = RepairOutcomeInvalid
+		span.SetAttributes(attribute.StringSlice("nlsql.violations", validated.Violations))
+		return &repairRun{Gen: gen}
+	}
+
+	exec, err := execute(ctx, validated)
+	if err != nil {
+		outcome = RepairOutcomeExecuteError
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	emitStage(ctx, span, "repair", gen)
+
+	return &repairRun{Gen: gen, Validated: validated, Exec: exec, Err: err}
+}
+
+// Repair asks the LLM to fix SQL that the database rejected. The prompt is
+// the Generate prompt plus the failed query and the database's error.
+func Repair(ctx context.Context, client *llm.Client, question string, parsed *ParseResult, d Dialect, system string, sql string, pgErr *pgconn.PgError, model string, temperature float64, maxTokens int) (*GenerateResult, error) {
+	resp, err := client.Generate(ctx, llm.GenerateRequest{
+		Model:       model,
+		System:      system,
+		Prompt:      buildRepairPrompt(question, parsed, d, sql, pgErr),
+		Temperature: temperature,
+		MaxTokens:   maxTokens,
+		Stage:       "repair",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("SQL repair failed: %w", err)
+	}
+
+	result := parseGenerateResponse(resp.Content)
+	result.InputTokens = resp.InputTokens
+	result.OutputTokens = resp.OutputTokens
+	result.CostUSD = resp.CostUSD
+	return result, nil
+}
+
+func buildRepairPrompt(question string, parsed *ParseResult, d Dialect, sql string, pgErr *pgconn.PgError) string {
+	var sb strings.Builder
+	sb.WriteString(buildGeneratePrompt(question, parsed, nil, d))
+	sb.WriteString("\n\nA previous attempt produced this query:\n")
+	sb.WriteString(sql)
+	sb.WriteString("\n\nThe database rejected it with:\n")
+	sb.WriteString(fmt.Sprintf("ERROR: %s (SQLSTATE %s)\n", pgErr.Message, pgErr.Code))
+	if pgErr.Detail != "" {
+		sb.WriteString("DETAIL: " + pgErr.Detail + "\n")
+	}
+	if pgErr.Hint != "" {
+		sb.WriteString("HINT: " + pgErr.Hint + "\n")
+	}
+	sb.WriteString("\nCorrect the query so it answers the question and runs without this error. Respond in the same JSON format.")
+	return sb.String()
+}
+
+// addUsage adds the tokens and cost of another generation to g.
+func (g *GenerateResult) addUsage(other *GenerateResult) {
+	g.InputTokens += other.InputTokens
+	g.OutputTokens += other.OutputTokens
+	g.CostUSD += other.CostUSD
+}
@@ -0,0 +1,43 @@
+package pipeline
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRepairableError(t *testing.T) {
+	undefinedColumn := &pgconn.PgError{Code: "42703", Message: `column "gdp" does not exist`}
+	pgErr, ok := repairableError(fmt.Errorf("query execution failed: %w", undefinedColumn))
+	assert.True(t, ok)
+	assert.Same(t, undefinedColumn, pgErr)
+
+	_, ok = repairableError(&pgconn.PgError{Code: "22012", Message: "division by zero"})
+	assert.True(t, ok, "data exceptions are the query's fault")
+
+	for _, code := range []string{"57014", "08006", "53300", "XX000"} {
+		_, ok := repairableError(&pgconn.PgError{Code: code})
+		assert.False(t, ok, code)
+	}
+	_, ok = repairableError(errors.New("connection refused"))
+	assert.False(t, ok)
+}
+
+func TestBuildRepairPrompt(t *testing.T) {
+	pgErr := &pgconn.PgError{
+		Code:    "42703",
+		Message: `column "gdp" does not exist`,
+		Hint:    `Perhaps you meant to reference the column "v.value".`,
+	}
+	prompt := buildRepairPrompt("GDP of France", &ParseResult{QuestionType: "lookup"}, Postgres{},
+		"SELECT gdp FROM indicator_values v", pgErr)
+
+	assert.Contains(t, prompt, "Question: GDP of France")
+	assert.Contains(t, prompt, "SELECT gdp FROM indicator_values v")
+	assert.Contains(t, prompt, `ERROR: column "gdp" does not exist (SQLSTATE 42703)`)
+	assert.Contains(t, prompt, `HINT: Perhaps you meant to reference the column "v.value".`)
+	assert.NotContains(t, prompt, "DETAIL:")
+}
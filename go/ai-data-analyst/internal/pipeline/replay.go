@@ -0,0 +1,295 @@
+package pipeline
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"ai-data-analyst/internal/db"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// Replay outcomes reported in ReplayResult.Status.
+const (
+	ReplayUnchanged  = "unchanged"
+	ReplayDrifted    = "drifted"
+	ReplayNoBaseline = "no_baseline"
+	// ReplayNotRun is a re-asked question whose new SQL did not run: it was
+	// rejected, the confidence was too low, or the database was down.
+	ReplayNotRun = "not_run"
+)
+
+// Replay modes, recorded as nlsql.replay.mode.
+const (
+	ReplayModeSQL      = "sql"
+	ReplayModeQuestion = "question"
+)
+
+// ErrReplayRejected is returned when stored SQL no longer passes validation,
+// for example after the row limit was lowered.
+var ErrReplayRejected = errors.New("stored SQL failed validation")
+
+type ReplayResult struct {
+	HistoryID          string   `json:"history_id"`
+	Question           string   `json:"question"`
+	SQL                string   `json:"sql"`
+	Status             string   `json:"status"`
+	Drift              bool     `json:"drift"`
+	ResultHash         string   `json:"result_hash"`
+	PreviousResultHash string   `json:"previous_result_hash,omitempty"`
+	RowCount           int      `json:"row_count"`
+	PreviousRowCount   int      `json:"previous_row_count"`
+	Columns            []string `json:"columns"`
+	Rows               [][]any  `json:"rows"`
+	DurationMS         int64    `json:"duration_ms"`
+	TraceID            string   `json:"trace_id"`
+	PreviousTraceID    string   `json:"previous_trace_id,omitempty"`
+}
+
+// ResultHash is a SHA-256 over the column names and rows of a result, so
+// two runs of the same query can be compared without storing the rows.
+func ResultHash(r *ExecuteResult) string {
+	rows := r.Rows
+	if rows == nil {
+		rows = [][]any{}
+	}
+	data, err := json.Marshal(struct {
+		Columns []string `json:"columns"`
+		Rows    [][]any  `json:"rows"`
+	}{r.Columns, rows})
+	if err != nil {
+		// Rows hold JSON-friendly values from convertPgValue; fall back to
+		// fmt so a stray type still produces a stable hash.
+		data = fmt.Appendf(nil, "%v|%v", r.Columns, r.Rows)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Replay re-runs the SQL stored in a history entry, skipping the LLM
+// stages, and compares the result hash with the one recorded at ask time.
+func (p *Pipeline) Replay(ctx context.Context, h *db.QueryHistory) (*ReplayResult, error) {
+	start := time.Now()
+
+	ctx, span := p.Tracer.Start(ctx, "pipeline replay")
+	defer span.End()
+
+	source := p.source(h.DataSource)
+	ctx = withDataSource(ctx, source.Name)
+	span.SetAttributes(
+		attribute.String("nlsql.history_id", h.ID),
+		attribute.String("nlsql.replay.previous_trace_id", h.TraceID),
+		attribute.String("nlsql.data_source", source.Name),
+	)
+
+	// Validate again: the stored SQL passed the rules in force when it was
+	// generated, which may have been tightened since.
+	validated := ValidateDialect(ctx, p.Tracer, h.GeneratedSQL, p.Settings().RowLimit, source.Dialect)
+	if !validated.Valid {
+		span.SetAttributes(attribute.StringSlice("nlsql.violations", validated.Violations))
+		span.SetStatus(codes.Error, ErrReplayRejected.Error())
+		return nil, fmt.Errorf("%w: %v", ErrReplayRejected, validated.Violations)
+	}
+
+	execResult, err := Execute(ctx, p.Tracer, source.DB, source.Dialect, validated.SafeSQL)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return nil, fmt.Errorf("execute stage failed: %w", err)
+	}
+
+	result := &ReplayResult{
+		HistoryID:          h.ID,
+		Question:           h.Question,
+		SQL:                validated.SafeSQL,
+		ResultHash:         ResultHash(execResult),
+		PreviousResultHash: h.ResultHash,
+		RowCount:           execResult.RowCount,
+		PreviousRowCount:   h.RowCount,
+		Columns:            execResult.Columns,
+		Rows:               execResult.Rows,
+		TraceID:            span.SpanContext().TraceID().String(),
+		PreviousTraceID:    h.TraceID,
+	}
+
+	result.Status = replayStatus(h.ResultHash, result.ResultHash)
+	result.Drift = result.Status == ReplayDrifted
+	result.DurationMS = time.Since(start).Milliseconds()
+
+	p.recordReplay(ctx, ReplayModeSQL, result.Status)
+
+	span.SetAttributes(
+		attribute.String("nlsql.replay.status", result.Status),
+		attribute.Bool("nlsql.replay.drift", result.Drift),
+		attribute.Int("nlsql.row_count", result.RowCount),
+		attribute.Int("nlsql.replay.row_count_delta", result.RowCount-h.RowCount),
+	)
+
+	return result, nil
+}
+
+// replayStatus compares a result hash with the one saved in history.
+// Entries saved before result hashes were recorded have nothing to compare
+// against.
+func replayStatus(previous, current string) string {
+	switch {
+	case previous == "":
+		return ReplayNoBaseline
+	case previous == current:
+		return ReplayUnchanged
+	default:
+		return ReplayDrifted
+	}
+}
+
+func (p *Pipeline) recordReplay(ctx context.Context, mode, status string) {
+	if p.Metrics == nil {
+		return
+	}
+	p.Metrics.Replays.Add(ctx, 1, metric.WithAttributes(
+		attribute.String("nlsql.replay.mode", mode),
+		attribute.String("nlsql.replay.status", status),
+	))
+}
+
+// ReaskResult compares a fresh answer to a history entry's question with
+// the answer recorded at the time.
+type ReaskResult struct {
+	HistoryID          string     `json:"history_id"`
+	Question           string     `json:"question"`
+	Status             string     `json:"status"`
+	Drift              bool       `json:"drift"`
+	PreviousSQL        string     `json:"previous_sql"`
+	SQL                string     `json:"sql"`
+	SQLChanged         bool       `json:"sql_changed"`
+	SQLDiff            []string   `json:"sql_diff,omitempty"`
+	PreviousRowCount   int        `json:"previous_row_count"`
+	RowCount           int        `json:"row_count"`
+	RowCountDelta      int        `json:"row_count_delta"`
+	PreviousResultHash string     `json:"previous_result_hash,omitempty"`
+	ResultHash         string     `json:"result_hash,omitempty"`
+	Result             *AskResult `json:"result"`
+	DurationMS         int64      `json:"duration_ms"`
+	TraceID            string     `json:"trace_id"`
+	PreviousTraceID    string     `json:"previous_trace_id,omitempty"`
+}
+
+// Reask runs a history entry's question through the current pipeline, with
+// the current prompts, models and settings, and compares the SQL and result
+// with the ones recorded. Unlike Replay it calls the LLM, and it skips the
+// caches so the SQL is generated again. The new answer is saved to history
+// like any other.
+func (p *Pipeline) Reask(ctx context.Context, h *db.QueryHistory) (*ReaskResult, error) {
+	start := time.Now()
+
+	ctx, span := p.Tracer.Start(ctx, "pipeline reask")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("nlsql.history_id", h.ID),
+		attribute.String("nlsql.replay.previous_trace_id", h.TraceID),
+	)
+
+	answer, err := p.ask(ctx, h.Question, askOptions{fresh: true})
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	result := &ReaskResult{
+		HistoryID:          h.ID,
+		Question:           h.Question,
+		PreviousSQL:        h.GeneratedSQL,
+		SQL:                answer.Script(),
+		PreviousRowCount:   h.RowCount,
+		RowCount:           answer.RowCount,
+		RowCountDelta:      answer.RowCount - h.RowCount,
+		PreviousResultHash: h.ResultHash,
+		Result:             answer,
+		TraceID:            span.SpanContext().TraceID().String(),
+		PreviousTraceID:    h.TraceID,
+	}
+	result.SQLDiff = DiffSQL(result.PreviousSQL, result.SQL)
+	result.SQLChanged = result.SQLDiff != nil
+
+	// Only an answer whose query ran has a result to compare.
+	if answer.Columns == nil {
+		result.Status = ReplayNotRun
+	} else {
+		result.ResultHash = ResultHash(&ExecuteResult{Columns: answer.Columns, Rows: answer.Rows})
+		result.Status = replayStatus(h.ResultHash, result.ResultHash)
+		result.Drift = result.Status == ReplayDrifted
+	}
+	result.DurationMS = time.Since(start).Milliseconds()
+
+	p.recordReplay(ctx, ReplayModeQuestion, result.Status)
+
+	span.SetAttributes(
+		attribute.String("nlsql.replay.status", result.Status),
+		attribute.Bool("nlsql.replay.drift", result.Drift),
+		attribute.Bool("nlsql.replay.sql_changed", result.SQLChanged),
+		attribute.Int("nlsql.row_count", result.RowCount),
+		attribute.Int("nlsql.replay.row_count_delta", result.RowCountDelta),
+	)
+
+	return result, nil
+}
+
+// DiffSQL returns a line diff of two queries, each formatted with FormatSQL
+// so whitespace alone is not a change, or nil if they are the same. Lines
+// are prefixed "- " when only in a, "+ " when only in b and "  " when in
+// both.
+func DiffSQL(a, b string) []string {
+	x := strings.Split(FormatSQL(a), "\n")
+	y := strings.Split(FormatSQL(b), "\n")
+	if slices.Equal(x, y) {
+		return nil
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence of x[i:]
+	// and y[j:].
+	lcs := make([][]int, len(x)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(y)+1)
+	}
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			if x[i] == y[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var diff []string
+	i, j := 0, 0
+	for i < len(x) && j < len(y) {
+		switch {
+		case x[i] == y[j]:
+			diff = append(diff, "  "+x[i])
+			i, j = i+1, j+1
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			diff = append(diff, "- "+x[i])
+			i++
+		default:
+			diff = append(diff, "+ "+y[j])
+			j++
+		}
+	}
+	for ; i < len(x); i++ {
+		diff = append(diff, "- "+x[i])
+	}
+	for ; j < len(y); j++ {
+		diff = append(diff, "+ "+y[j])
+	}
+	return diff
+}
@@ -0,0 +1,58 @@
+package pipeline
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResultHashStable(t *testing.T) {
+	a := &ExecuteResult{Columns: []string{"name", "value"}, Rows: [][]any{{"Kenya", 5.6}, {"India", 8.2}}}
+	b := &ExecuteResult{Columns: []string{"name", "value"}, Rows: [][]any{{"Kenya", 5.6}, {"India", 8.2}}}
+	assert.Equal(t, ResultHash(a), ResultHash(b))
+	assert.Len(t, ResultHash(a), 64)
+}
+
+func TestResultHashDetectsDrift(t *testing.T) {
+	base := &ExecuteResult{Columns: []string{"name", "value"}, Rows: [][]any{{"Kenya", 5.6}, {"India", 8.2}}}
+
+	changedValue := &ExecuteResult{Columns: []string{"name", "value"}, Rows: [][]any{{"Kenya", 5.7}, {"India", 8.2}}}
+	reordered := &ExecuteResult{Columns: []string{"name", "value"}, Rows: [][]any{{"India", 8.2}, {"Kenya", 5.6}}}
+	renamed := &ExecuteResult{Columns: []string{"country", "value"}, Rows: [][]any{{"Kenya", 5.6}, {"India", 8.2}}}
+
+	assert.NotEqual(t, ResultHash(base), ResultHash(changedValue))
+	assert.NotEqual(t, ResultHash(base), ResultHash(reordered))
+	assert.NotEqual(t, ResultHash(base), ResultHash(renamed))
+}
+
+func TestResultHashEmpty(t *testing.T) {
+	assert.Equal(t,
+		ResultHash(&ExecuteResult{Columns: []string{"name"}}),
+		ResultHash(&ExecuteResult{Columns: []string{"name"}, Rows: [][]any{}}),
+	)
+}
+
+func TestDiffSQL(t *testing.T) {
+	diff := DiffSQL(
+		"SELECT name, value FROM indicators WHERE year = 2020 LIMIT 100",
+		"SELECT name, value FROM indicators WHERE year = 2021 ORDER BY value DESC LIMIT 100",
+	)
+	assert.Equal(t, []string{
+		"  SELECT name, value",
+		"  FROM indicators",
+		"- WHERE year = 2020",
+		"+ WHERE year = 2021",
+		"+ ORDER BY value DESC",
+		"  LIMIT 100",
+	}, diff)
+}
+
+func TestDiffSQLIgnoresWhitespace(t *testing.T) {
+	assert.Nil(t, DiffSQL("SELECT 1\n  FROM t", "select 1 from t"))
+}
+
+func TestReplayStatus(t *testing.T) {
+	assert.Equal(t, ReplayNoBaseline, replayStatus("", "abc"))
+	assert.Equal(t, ReplayUnchanged, replayStatus("abc", "abc"))
+	assert.Equal(t, ReplayDrifted, replayStatus("abc", "def"))
+}
@@ -0,0 +1,444 @@
+package pipeline
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"ai-data-analyst/internal/db"
+	"ai-data-analyst/internal/llm"
+	"ai-data-analyst/internal/telemetry"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// defaultSchemaTopK is how many fragments are retrieved per question when
+// the config does not set it.
+const defaultSchemaTopK = 8
+
+// embedBatchSize caps how many fragments are embedded per provider call.
+const embedBatchSize = 64
+
+// Fragment kinds, in the order they appear in the prompt.
+const (
+	fragmentTable     = "table"
+	fragmentIndicator = "indicator"
+	fragmentReference = "reference"
+)
+
+var errSchemaNotIndexed = errors.New("schema fragments not indexed yet")
+
+// SchemaRetriever builds the Generate system prompt from the schema
+// fragments closest to the question instead of the whole schema context.
+// Fragments are embedded once into pgvector by Index; at question time only
+// the question is embedded.
+type SchemaRetriever struct {
+	LLM     *llm.Client
+	DB      db.Querier
+	Tracer  trace.Tracer
+	Metrics *telemetry.GenAIMetrics
+	Model   string
+	TopK    int
+
+	mu        sync.RWMutex
+	fragments map[string]db.SchemaFragment
+}
+
+func (r *SchemaRetriever) topK() int {
+	if r.TopK > 0 {
+		return r.TopK
+	}
+	return defaultSchemaTopK
+}
+
+// Index builds the fragments for the current dataset, embeds those that are
+// new or changed since they were stored, and deletes ones that no longer
+// exist. It returns how many fragments were embedded.
+func (r *SchemaRetriever) Index(ctx context.Context) (int, error) {
+	ctx, span := r.Tracer.Start(ctx, "schema_index")
+	defer span.End()
+
+	fail := func(err error) (int, error) {
+		span.SetStatus(codes.Error, err.Error())
+		return 0, err
+	}
+
+	indicators, err := db.ListIndicators(ctx, r.DB)
+	if err != nil {
+		return fail(fmt.Errorf("list indicators: %w", err))
+	}
+	fragments := schemaFragments(indicators)
+
+	stored, err := db.SchemaFragmentHashes(ctx, r.DB)
+	if err != nil {
+		return fail(fmt.Errorf("load fragment hashes: %w", err))
+	}
+
+	var changed []db.SchemaFragment
+	keys := make([]string, len(fragments))
+	byKey := make(map[string]db.SchemaFragment, len(fragments))
+	for i, f := range fragments {
+		keys[i] = f.Key
+		byKey[f.Key] = f
+		if stored[f.Key] != fragmentHash(f, r.Model) {
+			changed = append(changed, f)
+		}
+	}
+
+	for start := 0; start < len(changed); start += embedBatchSize {
+		batch := changed[start:min(start+embedBatchSize, len(changed))]
+		input := make([]string, len(batch))
+		for i, f := range batch {
+			input[i] = f.Content
+		}
+		resp, err := r.LLM.Embed(ctx, llm.EmbedRequest{Model: r.Model, Input: input, Stage: "schema_index"})
+		if err != nil {
+			return fail(fmt.Errorf("embed fragments: %w", err))
+		}
+		for i, f := range batch {
+			if err := db.UpsertSchemaFragment(ctx, r.DB, f, fragmentHash(f, r.Model), r.Model, resp.Vectors[i]); err != nil {
+				return fail(fmt.Errorf("store fragment %s: %w", f.Key, err))
+			}
+		}
+	}
+
+	deleted, err := db.DeleteSchemaFragmentsExcept(ctx, r.DB, keys)
+	if err != nil {
+		return fail(fmt.Errorf("delete stale fragments: %w", err))
+	}
+
+	span.SetAttributes(
+		attribute.Int("nlsql.schema_index.fragments", len(fragments)),
+		attribute.Int("nlsql.schema_index.embedded", len(changed)),
+		attribute.Int64("nlsql.schema_index.deleted", deleted),
+	)
+
+	r.mu.Lock()
+	r.fragments = byKey
+	r.mu.Unlock()
+	return len(changed), nil
+}
+
+// Run indexes in the background, retrying every interval until it succeeds;
+// at startup the database or the embedding model may not be reachable yet.
+// Until then Generate uses the full static schema context.
+func (r *SchemaRetriever) Run(ctx context.Context, interval time.Duration) {
+	for {
+		embedded, err := r.Index(ctx)
+		if err == nil {
+			log.Printf("Schema retrieval ready — %d fragments re-embedded with %s", embedded, r.Model)
+			return
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		log.Printf("Schema indexing failed, retrying in %s: %v", interval, err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// Retrieve returns the Generate system prompt for question: the top-K
+// fragments by cosine distance plus the fragments they require, framed by
+// the preamble and constraints of the full prompt base. Retrieved tables
+// found in live are described as introspected rather than as indexed.
+func (r *SchemaRetriever) Retrieve(ctx context.Context, question string, parsed *ParseResult, base string, live []db.SchemaTable) (string, error) {
+	start := time.Now()
+	ctx, span := r.Tracer.Start(ctx, "pipeline_stage retrieve_schema")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("nlsql.stage", "retrieve_schema"),
+		attribute.String("gen_ai.request.model", r.Model),
+		attribute.Int("nlsql.schema_retrieval.top_k", r.topK()),
+	)
+
+	outcome := "error"
+	defer func() {
+		if r.Metrics != nil {
+			r.Metrics.SchemaRetrievalDuration.Record(ctx, time.Since(start).Seconds(),
+				telemetry.WithRetrievalOutcome(outcome))
+		}
+	}()
+
+	r.mu.RLock()
+	known := r.fragments
+	r.mu.RUnlock()
+	if known == nil {
+		outcome = "not_indexed"
+		span.SetStatus(codes.Error, errSchemaNotIndexed.Error())
+		return "", errSchemaNotIndexed
+	}
+
+	resp, err := r.LLM.Embed(ctx, llm.EmbedRequest{
+		Model: r.Model,
+		Input: []string{retrievalQuery(question, parsed)},
+		Stage: "retrieve_schema",
+	})
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return "", fmt.Errorf("embed question: %w", err)
+	}
+
+	hits, err := db.SearchSchemaFragments(ctx, r.DB, r.Model, resp.Vectors[0], r.topK())
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return "", fmt.Errorf("search schema fragments: %w", err)
+	}
+	if len(hits) == 0 {
+		outcome = "not_indexed"
+		span.SetStatus(codes.Error, errSchemaNotIndexed.Error())
+		return "", errSchemaNotIndexed
+	}
+
+	fragments := withRequired(hits, known)
+	outcome = "success"
+
+	keys := make([]string, len(fragments))
+	for i, f := range fragments {
+		keys[i] = f.Key
+	}
+	span.SetAttributes(
+		attribute.Int("nlsql.schema_retrieval.hits", len(hits)),
+		attribute.Int("nlsql.schema_retrieval.fragments", len(fragments)),
+		attribute.Float64("nlsql.schema_retrieval.best_distance", hits[0].Distance),
+		attribute.StringSlice("nlsql.schema_retrieval.keys", keys),
+	)
+	if r.Metrics != nil {
+		r.Metrics.SchemaRetrievalHits.Record(ctx, int64(len(fragments)))
+	}
+
+	emitStage(ctx, span, "retrieve_schema", hits)
+
+	return buildSchemaPrompt(base, fragments, live), nil
+}
+
+// schemaContext returns the Generate system prompt, from retrieval when it
+// is configured and falling back to the full prompt base otherwise. Either
+// way the tables are described as the live database has them when the
+// schema can be introspected.
+func (p *Pipeline) schemaContext(ctx context.Context, question string, parsed *ParseResult, base string) string {
+	live := p.liveSchema(ctx)
+	if p.Schema == nil {
+		return withLiveSchema(base, live)
+	}
+	system, err := p.Schema.Retrieve(ctx, question, parsed, base, live)
+	if err != nil {
+		trace.SpanFromContext(ctx).AddEvent("schema_retrieval_fallback", trace.WithAttributes(
+			attribute.String("error", err.Error()),
+		))
+		return withLiveSchema(base, live)
+	}
+	return system
+}
+
+// liveSchema returns the introspected dataset tables, or nil without a
+// LiveSchema or when the database has never been readable.
+func (p *Pipeline) liveSchema(ctx context.Context) []db.SchemaTable {
+	if p.LiveSchema == nil {
+		return nil
+	}
+	tables, err := p.LiveSchema.Get(ctx)
+	if err != nil {
+		trace.SpanFromContext(ctx).AddEvent("schema_introspection_failed", trace.WithAttributes(
+			attribute.String("error", err.Error()),
+		))
+		return nil
+	}
+	return tables
+}
+
+// withLiveSchema replaces the "Schema:" paragraph of full with the live
+// tables. A prompt without one, such as a custom version, is left as is.
+func withLiveSchema(full string, live []db.SchemaTable) string {
+	if len(live) == 0 {
+		return full
+	}
+	paragraphs := strings.Split(strings.TrimSpace(full), "\n\n")
+	for i, para := range paragraphs {
+		if strings.HasPrefix(strings.TrimSpace(para), "Schema:") {
+			paragraphs[i] = "Schema:\n" + liveSchemaLines(live)
+			return strings.Join(paragraphs, "\n\n")
+		}
+	}
+	return full
+}
+
+func liveSchemaLines(live []db.SchemaTable) string {
+	lines := make([]string, len(live))
+	for i, t := range live {
+		lines[i] = "- " + t.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// retrievalQuery is the text embedded for a question. Detected indicator
+// codes are appended so a question that names a code matches its fragment.
+func retrievalQuery(question string, parsed *ParseResult) string {
+	if parsed == nil || len(parsed.Indicators) == 0 {
+		return question
+	}
+	return question + "\nIndicators: " + strings.Join(parsed.Indicators, ", ")
+}
+
+// withRequired returns the hits followed by any fragments they require that
+// were not retrieved themselves.
+func withRequired(hits []db.ScoredSchemaFragment, known map[string]db.SchemaFragment) []db.SchemaFragment {
+	seen := map[string]bool{}
+	var out []db.SchemaFragment
+	for _, h := range hits {
+		if !seen[h.Key] {
+			seen[h.Key] = true
+			out = append(out, h.SchemaFragment)
+		}
+	}
+	for i := 0; i < len(out); i++ {
+		for _, key := range out[i].Requires {
+			if seen[key] {
+				continue
+			}
+			if f, ok := known[key]; ok {
+				seen[key] = true
+				out = append(out, f)
+			}
+		}
+	}
+	return out
+}
+
+// buildSchemaPrompt puts the fragments between the opening paragraph and the
+// constraints of the static schema context, grouped by kind. Table
+// fragments for tables in live take their columns from there.
+func buildSchemaPrompt(full string, fragments []db.SchemaFragment, live []db.SchemaTable) string {
+	preamble, constraints := splitSchemaContext(full)
+	introspected := make(map[string]string, len(live))
+	for _, t := range live {
+		introspected["table:"+t.Name] = t.String()
+	}
+
+	var sb strings.Builder
+	sb.WriteString(preamble)
+	for _, kind := range []struct{ kind, heading string }{
+		{fragmentTable, "Schema (tables relevant to this question):"},
+		{fragmentIndicator, "Relevant indicators:"},
+		{fragmentReference, "Reference values:"},
+	} {
+		var lines []string
+		for _, f := range fragments {
+			if f.Kind != kind.kind {
+				continue
+			}
+			content := f.Content
+			if table, ok := introspected[f.Key]; ok {
+				content = table
+			}
+			lines = append(lines, "- "+content)
+		}
+		if len(lines) == 0 {
+			continue
+		}
+		sb.WriteString("\n\n" + kind.heading + "\n" + strings.Join(lines, "\n"))
+	}
+	if constraints != "" {
+		sb.WriteString("\n\n" + constraints)
+	}
+	return sb.String()
+}
+
+// splitSchemaContext returns the first paragraph of the static context and
+// its "Constraints:" paragraph, which apply whatever schema is retrieved.
+func splitSchemaContext(full string) (preamble, constraints string) {
+	paragraphs := strings.Split(strings.TrimSpace(full), "\n\n")
+	preamble = strings.TrimSpace(paragraphs[0])
+	for _, p := range paragraphs[1:] {
+		if strings.HasPrefix(strings.TrimSpace(p), "Constraints:") {
+			constraints = strings.TrimSpace(p)
+		}
+	}
+	return preamble, constraints
+}
+
+func fragmentHash(f db.SchemaFragment, model string) string {
+	h := sha256.New()
+	for _, part := range append([]string{model, f.Kind, f.Content}, f.Requires...) {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+var (
+	regions = []string{
+		"East Asia & Pacific", "Europe & Central Asia", "Latin America & Caribbean",
+		"Middle East & North Africa", "North America", "South Asia", "Sub-Saharan Africa",
+	}
+	incomeGroups = []string{"High income", "Upper middle income", "Lower middle income", "Low income"}
+)
+
+// schemaFragments documents the tables, every indicator in the dataset and
+// the reference values used in filters, one retrievable fragment each.
+func schemaFragments(indicators []db.Indicator) []db.SchemaFragment {
+	valueTables := []string{"table:indicator_values", "table:indicators", "table:countries"}
+
+	fragments := []db.SchemaFragment{
+		{
+			Key:     "table:countries",
+			Kind:    fragmentTable,
+			Content: "countries (id SERIAL PK, name VARCHAR country name, code VARCHAR(3) UNIQUE ISO 3166 alpha-3 code, region VARCHAR World Bank region, income_group VARCHAR World Bank income classification)",
+		},
+		{
+			Key:     "table:indicators",
+			Kind:    fragmentTable,
+			Content: "indicators (id SERIAL PK, name VARCHAR, code VARCHAR(50) UNIQUE World Bank indicator code, category VARCHAR, unit VARCHAR, description TEXT)",
+		},
+		{
+			Key:      "table:indicator_values",
+			Kind:     fragmentTable,
+			Content:  "indicator_values (id SERIAL PK, country_id INT FK→countries, indicator_id INT FK→indicators, year INT, value NUMERIC), UNIQUE(country_id, indicator_id, year); one yearly observation of an indicator for a country, value may be NULL",
+			Requires: []string{"table:indicators", "table:countries"},
+		},
+		{
+			Key:      "reference:regions",
+			Kind:     fragmentReference,
+			Content:  "countries.region values: " + strings.Join(regions, ", "),
+			Requires: []string{"table:countries"},
+		},
+		{
+			Key:      "reference:income_groups",
+			Kind:     fragmentReference,
+			Content:  "countries.income_group values: " + strings.Join(incomeGroups, ", "),
+			Requires: []string{"table:countries"},
+		},
+		{
+			Key:      "reference:coverage",
+			Kind:     fragmentReference,
+			Content:  "indicator_values covers years 2003-2023 for 217 countries",
+			Requires: []string{"table:indicator_values"},
+		},
+	}
+
+	for _, ind := range indicators {
+		content := fmt.Sprintf("%s = %s (%s), category %s", ind.Code, ind.Name, ind.Unit, ind.Category)
+		if ind.Description != "" {
+			content += ": " + ind.Description
+		}
+		fragments = append(fragments, db.SchemaFragment{
+			Key:      "indicator:" + ind.Code,
+			Kind:     fragmentIndicator,
+			Content:  content,
+			Requires: valueTables,
+		})
+	}
+	return fragments
+}
@@ -0,0 +1,159 @@
+package pipeline
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"ai-data-analyst/internal/db"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+var testIndicators = []db.Indicator{
+	{Code: "NY.GDP.MKTP.KD.ZG", Name: "GDP growth (annual %)", Unit: "%", Category: "Economy"},
+	{Code: "SP.POP.TOTL", Name: "Population, total", Unit: "people", Category: "Demographics", Description: "Total population"},
+}
+
+func TestSchemaFragments(t *testing.T) {
+	fragments := schemaFragments(testIndicators)
+
+	byKey := map[string]db.SchemaFragment{}
+	for _, f := range fragments {
+		byKey[f.Key] = f
+	}
+	require.Contains(t, byKey, "table:countries")
+	require.Contains(t, byKey, "table:indicator_values")
+	require.Contains(t, byKey, "reference:regions")
+
+	pop := byKey["indicator:SP.POP.TOTL"]
+	assert.Equal(t, fragmentIndicator, pop.Kind)
+	assert.Contains(t, pop.Content, "Population, total")
+	assert.Contains(t, pop.Content, "Total population")
+	for _, key := range pop.Requires {
+		assert.Contains(t, byKey, key, "required fragment must exist")
+	}
+}
+
+func TestWithRequired(t *testing.T) {
+	known := map[string]db.SchemaFragment{}
+	for _, f := range schemaFragments(testIndicators) {
+		known[f.Key] = f
+	}
+	hits := []db.ScoredSchemaFragment{
+		{SchemaFragment: known["indicator:SP.POP.TOTL"], Distance: 0.2},
+		{SchemaFragment: known["table:countries"], Distance: 0.4},
+	}
+
+	got := withRequired(hits, known)
+	var keys []string
+	for _, f := range got {
+		keys = append(keys, f.Key)
+	}
+	assert.Equal(t, []string{
+		"indicator:SP.POP.TOTL",
+		"table:countries",
+		"table:indicator_values",
+		"table:indicators",
+	}, keys)
+}
+
+func TestBuildSchemaPrompt(t *testing.T) {
+	known := map[string]db.SchemaFragment{}
+	for _, f := range schemaFragments(testIndicators) {
+		known[f.Key] = f
+	}
+	fragments := withRequired([]db.ScoredSchemaFragment{
+		{SchemaFragment: known["indicator:SP.POP.TOTL"]},
+	}, known)
+
+	full := "You are a SQL expert.\n\nSchema:\n- everything\n\nConstraints:\n- SELECT only."
+	prompt := buildSchemaPrompt(full, fragments, nil)
+
+	assert.True(t, strings.HasPrefix(prompt, "You are a SQL expert."))
+	assert.True(t, strings.HasSuffix(prompt, "Constraints:\n- SELECT only."))
+	assert.Contains(t, prompt, "SP.POP.TOTL")
+	assert.Contains(t, prompt, "indicator_values (")
+	assert.NotContains(t, prompt, "NY.GDP.MKTP.KD.ZG", "unretrieved indicators are left out")
+	assert.NotContains(t, prompt, "- everything")
+	assert.Less(t, strings.Index(prompt, "Schema (tables"), strings.Index(prompt, "Relevant indicators:"))
+}
+
+func TestSplitSchemaContextUsesStaticFile(t *testing.T) {
+	preamble, constraints := splitSchemaContext(schemaContext)
+	assert.NotEmpty(t, preamble)
+	if strings.Contains(schemaContext, "Constraints:") {
+		assert.True(t, strings.HasPrefix(constraints, "Constraints:"))
+	}
+}
+
+func TestRetrievalQuery(t *testing.T) {
+	assert.Equal(t, "population of India", retrievalQuery("population of India", &ParseResult{}))
+	assert.Equal(t, "population of India\nIndicators: SP.POP.TOTL",
+		retrievalQuery("population of India", &ParseResult{Indicators: []string{"SP.POP.TOTL"}}))
+}
+
+func TestFragmentHashCoversModel(t *testing.T) {
+	f := schemaFragments(testIndicators)[0]
+	assert.Equal(t, fragmentHash(f, "a"), fragmentHash(f, "a"))
+	assert.NotEqual(t, fragmentHash(f, "a"), fragmentHash(f, "b"))
+
+	changed := f
+	changed.Content += " changed"
+	assert.NotEqual(t, fragmentHash(f, "a"), fragmentHash(changed, "a"))
+}
+
+func TestSchemaContextFallsBackUntilIndexed(t *testing.T) {
+	p := &Pipeline{Schema: &SchemaRetriever{Tracer: noop.NewTracerProvider().Tracer("test"), Model: "m"}}
+	assert.Equal(t, schemaContext, p.schemaContext(context.Background(), "population of India", &ParseResult{}, schemaContext))
+
+	assert.Equal(t, schemaContext, (&Pipeline{}).schemaContext(context.Background(), "q", &ParseResult{}, schemaContext))
+}
+
+var testLiveSchema = []db.SchemaTable{
+	{Name: "countries", RowEstimate: 217, Columns: []db.SchemaColumn{
+		{Name: "id", Type: "integer", PrimaryKey: true},
+		{Name: "name", Type: "character varying"},
+		{Name: "iso_code", Type: "character varying", Nullable: true},
+	}},
+	{Name: "indicator_values", RowEstimate: 74000, Columns: []db.SchemaColumn{
+		{Name: "country_id", Type: "integer", References: "countries"},
+	}},
+}
+
+func TestSchemaTableString(t *testing.T) {
+	assert.Equal(t, "countries (~217 rows): id integer PK, name character varying NOT NULL, iso_code character varying",
+		testLiveSchema[0].String())
+	assert.Equal(t, "indicator_values (~74000 rows): country_id integer FK→countries NOT NULL",
+		testLiveSchema[1].String())
+}
+
+func TestWithLiveSchemaReplacesSchemaParagraph(t *testing.T) {
+	full := "You are a SQL expert.\n\nSchema:\n- countries (code VARCHAR(3))\n\nConstraints:\n- SELECT only."
+	prompt := withLiveSchema(full, testLiveSchema)
+
+	assert.True(t, strings.HasPrefix(prompt, "You are a SQL expert.\n\nSchema:\n- countries (~217 rows)"))
+	assert.Contains(t, prompt, "iso_code character varying")
+	assert.NotContains(t, prompt, "code VARCHAR(3)")
+	assert.True(t, strings.HasSuffix(prompt, "Constraints:\n- SELECT only."))
+
+	assert.Equal(t, full, withLiveSchema(full, nil), "without a live schema the static one stays")
+	assert.Equal(t, "Answer in SQL.", withLiveSchema("Answer in SQL.", testLiveSchema))
+}
+
+func TestBuildSchemaPromptUsesLiveTables(t *testing.T) {
+	known := map[string]db.SchemaFragment{}
+	for _, f := range schemaFragments(testIndicators) {
+		known[f.Key] = f
+	}
+	fragments := withRequired([]db.ScoredSchemaFragment{
+		{SchemaFragment: known["indicator:SP.POP.TOTL"]},
+	}, known)
+
+	prompt := buildSchemaPrompt("You are a SQL expert.", fragments, testLiveSchema)
+	assert.Contains(t, prompt, "- countries (~217 rows): id integer PK")
+	assert.NotContains(t, prompt, "ISO 3166 alpha-3 code", "the indexed description gives way to the live one")
+	assert.Contains(t, prompt, "- indicators (id SERIAL PK", "tables not introspected keep their fragment")
+}
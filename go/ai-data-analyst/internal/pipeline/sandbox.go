@@ -0,0 +1,418 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"ai-data-analyst/internal/telemetry"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ErrSandboxLimit is returned when MaxSessions sandboxes are already open.
+var ErrSandboxLimit = errors.New("too many active sandbox sessions")
+
+// sandboxObjectPrefix is required on sandbox object names so a temporary
+// table can never shadow a real one on the search path.
+const sandboxObjectPrefix = "tmp_"
+
+// maxSandboxSetup caps the statements a sandbox script may run before its
+// final SELECT.
+const maxSandboxSetup = 5
+
+// sandboxStatementTimeout bounds every statement on a sandbox connection.
+const sandboxStatementTimeout = "10s"
+
+var (
+	createTempPattern = regexp.MustCompile(`(?is)^CREATE\s+(?:TEMP|TEMPORARY)\s+(TABLE|VIEW)\s+(?:IF\s+NOT\s+EXISTS\s+)?([A-Za-z_][A-Za-z0-9_]*)\s+AS\s+(.+)$`)
+	dropTempPattern   = regexp.MustCompile(`(?is)^DROP\s+(TABLE|VIEW)\s+(?:IF\s+EXISTS\s+)?([A-Za-z_][A-Za-z0-9_]*)$`)
+)
+
+// SandboxObject is a temporary table or view created in a sandbox session.
+type SandboxObject struct {
+	Name    string   `json:"name"`
+	Kind    string   `json:"kind"`
+	Columns []string `json:"columns,omitempty"`
+}
+
+// ValidateSandbox validates a sandbox script: up to maxSandboxSetup
+// statements that create temporary tables or views from a SELECT, or drop
+// the session's own, followed by a SELECT. objects are the session's
+// existing temporary objects. Writes to real tables are rejected as in
+// ValidateDialect.
+func ValidateSandbox(ctx context.Context, tracer trace.Tracer, sql string, rowLimit int, objects []SandboxObject) *ValidateResult {
+	_, span := tracer.Start(ctx, "pipeline_stage validate")
+	defer span.End()
+
+	d := Postgres{}
+	statements := splitStatements(sql)
+	if len(statements) == 0 {
+		statements = []string{""}
+	}
+	setup, final := statements[:len(statements)-1], statements[len(statements)-1]
+
+	result, limitInjected := validateQuery(final, rowLimit, d)
+
+	if len(setup) > maxSandboxSetup {
+		result.Valid = false
+		result.Violations = append(result.Violations, fmt.Sprintf("sandbox_too_many_statements: %d", len(setup)))
+	}
+
+	owned := map[string]bool{}
+	for _, o := range objects {
+		owned[o.Name] = true
+	}
+	var created, dropped int
+	for _, stmt := range setup {
+		if m := createTempPattern.FindStringSubmatch(stmt); m != nil {
+			name := strings.ToLower(m[2])
+			if !strings.HasPrefix(name, sandboxObjectPrefix) {
+				result.Violations = append(result.Violations, "sandbox_object_name: "+name)
+			}
+			// The body is an ordinary query and gets the read-only checks.
+			body, _ := validateQuery(m[3], rowLimit, d)
+			result.Violations = append(result.Violations, body.Violations...)
+			owned[name] = true
+			created++
+			continue
+		}
+		if m := dropTempPattern.FindStringSubmatch(stmt); m != nil {
+			name := strings.ToLower(m[2])
+			if !owned[name] {
+				result.Violations = append(result.Violations, "sandbox_drop_not_owned: "+name)
+			}
+			delete(owned, name)
+			dropped++
+			continue
+		}
+		result.Violations = append(result.Violations, "sandbox_statement_not_allowed: "+firstWords(stmt, 3))
+	}
+	if len(result.Violations) > 0 {
+		result.Valid = false
+	}
+	result.Setup = setup
+
+	span.SetAttributes(
+		attribute.String("nlsql.stage", "validate"),
+		attribute.String("nlsql.dialect", d.Name()),
+		attribute.Bool("nlsql.sandbox", true),
+		attribute.Bool("nlsql.valid", result.Valid),
+		attribute.Int("nlsql.violations_count", len(result.Violations)),
+		attribute.Bool("nlsql.limit_injected", limitInjected),
+		attribute.Int("nlsql.sandbox.created", created),
+		attribute.Int("nlsql.sandbox.dropped", dropped),
+	)
+
+	emitStage(ctx, span, "validate", result)
+
+	return result
+}
+
+// splitStatements splits a script on semicolons. Generated SQL has no
+// semicolons inside string literals in practice; one that does fails
+// validation rather than running differently.
+func splitStatements(sql string) []string {
+	var out []string
+	for _, stmt := range strings.Split(sql, ";") {
+		if stmt = strings.TrimSpace(stmt); stmt != "" {
+			out = append(out, stmt)
+		}
+	}
+	return out
+}
+
+func firstWords(s string, n int) string {
+	fields := strings.Fields(strings.ToUpper(s))
+	if len(fields) > n {
+		fields = fields[:n]
+	}
+	return strings.Join(fields, " ")
+}
+
+// sandboxInstructions tells the Generate stage what the sandbox allows and
+// which objects already exist.
+func sandboxInstructions(objects []SandboxObject) string {
+	var sb strings.Builder
+	sb.WriteString("\n\nSandbox mode:\n")
+	sb.WriteString("- For multi-step analysis you may return several statements separated by semicolons, ending with a SELECT.\n")
+	sb.WriteString(fmt.Sprintf("- Before the final SELECT, at most %d statements of the form CREATE TEMP TABLE <name> AS SELECT ... or CREATE TEMP VIEW <name> AS SELECT ..., or DROP TABLE/VIEW of an existing sandbox object.\n", maxSandboxSetup))
+	sb.WriteString("- Names must start with " + sandboxObjectPrefix + " and must not be schema-qualified. No other statements are allowed.\n")
+	sb.WriteString("- Temporary objects persist for the rest of the session and can be queried by later questions.\n")
+	if len(objects) == 0 {
+		sb.WriteString("- No sandbox objects exist yet.")
+		return sb.String()
+	}
+	sb.WriteString("Existing sandbox objects:")
+	for _, o := range objects {
+		sb.WriteString(fmt.Sprintf("\n- %s %s (%s)", o.Kind, o.Name, strings.Join(o.Columns, ", ")))
+	}
+	return sb.String()
+}
+
+// sandboxDialect is Postgres without the per-query read-only setup: sandbox
+// connections are guarded by their role and statement timeout instead.
+type sandboxDialect struct{ Postgres }
+
+func (sandboxDialect) QuerySetup() []string { return nil }
+
+// Sandboxes holds one dedicated connection per sandbox session. Temporary
+// objects live on that connection, so it stays open between questions and
+// closing it, when the session is deleted or goes idle, drops them.
+type Sandboxes struct {
+	// Dial opens a connection outside the shared pool.
+	Dial func(ctx context.Context) (*pgx.Conn, error)
+	// Role is assumed on every sandbox connection. Empty keeps the
+	// connecting user.
+	Role        string
+	IdleTimeout time.Duration
+	MaxSessions int
+	Metrics     *telemetry.GenAIMetrics
+
+	mu       sync.Mutex
+	sessions map[string]*sandboxSession
+}
+
+type sandboxSession struct {
+	// mu serializes scripts: a connection runs one statement at a time.
+	mu       sync.Mutex
+	conn     *pgx.Conn
+	objects  []SandboxObject
+	lastUsed time.Time
+}
+
+// Objects returns the session's temporary objects, oldest first.
+func (s *Sandboxes) Objects(sessionID string) []SandboxObject {
+	s.mu.Lock()
+	sb := s.sessions[sessionID]
+	s.mu.Unlock()
+	if sb == nil {
+		return nil
+	}
+	sb.mu.Lock()
+	defer sb.mu.Unlock()
+	return append([]SandboxObject(nil), sb.objects...)
+}
+
+func (s *Sandboxes) session(ctx context.Context, sessionID string) (*sandboxSession, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if sb := s.sessions[sessionID]; sb != nil {
+		return sb, nil
+	}
+	if s.MaxSessions > 0 && len(s.sessions) >= s.MaxSessions {
+		return nil, ErrSandboxLimit
+	}
+
+	conn, err := s.Dial(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("open sandbox connection: %w", err)
+	}
+	setup := []string{"SET statement_timeout = '" + sandboxStatementTimeout + "'"}
+	if s.Role != "" {
+		setup = append(setup, "SET ROLE "+pgx.Identifier{s.Role}.Sanitize())
+	}
+	for _, stmt := range setup {
+		if _, err := conn.Exec(ctx, stmt); err != nil {
+			_ = conn.Close(ctx)
+			return nil, fmt.Errorf("sandbox connection setup %q: %w", stmt, err)
+		}
+	}
+
+	if s.sessions == nil {
+		s.sessions = map[string]*sandboxSession{}
+	}
+	sb := &sandboxSession{conn: conn, lastUsed: time.Now()}
+	s.sessions[sessionID] = sb
+	return sb, nil
+}
+
+// Execute runs a validated sandbox script in one transaction on the
+// session's connection: the setup statements, then the final query. It
+// returns the query result and the session's objects afterwards.
+func (s *Sandboxes) Execute(ctx context.Context, tracer trace.Tracer, sessionID string, setup []string, sql string) (*ExecuteResult, []SandboxObject, error) {
+	sb, err := s.session(ctx, sessionID)
+	if err != nil {
+		return nil, nil, err
+	}
+	sb.mu.Lock()
+	defer sb.mu.Unlock()
+	sb.lastUsed = time.Now()
+
+	ctx, span := tracer.Start(ctx, "pipeline_stage sandbox")
+	defer span.End()
+	span.SetAttributes(
+		attribute.String("nlsql.stage", "sandbox"),
+		attribute.String("session.id", sessionID),
+		attribute.Int("nlsql.sandbox.statements", len(setup)),
+	)
+
+	fail := func(err error) (*ExecuteResult, []SandboxObject, error) {
+		span.SetStatus(codes.Error, err.Error())
+		return nil, nil, err
+	}
+
+	tx, err := sb.conn.Begin(ctx)
+	if err != nil {
+		return fail(fmt.Errorf("begin sandbox transaction: %w", err))
+	}
+	defer tx.Rollback(ctx)
+
+	objects := append([]SandboxObject(nil), sb.objects...)
+	for _, stmt := range setup {
+		if _, err := tx.Exec(ctx, stmt); err != nil {
+			return fail(fmt.Errorf("sandbox statement failed: %w", err))
+		}
+		if m := createTempPattern.FindStringSubmatch(stmt); m != nil {
+			obj := SandboxObject{Name: strings.ToLower(m[2]), Kind: strings.ToLower(m[1])}
+			if obj.Columns, err = tempColumns(ctx, tx, obj.Name); err != nil {
+				return fail(fmt.Errorf("describe %s: %w", obj.Name, err))
+			}
+			objects = append(removeObject(objects, obj.Name), obj)
+		} else if m := dropTempPattern.FindStringSubmatch(stmt); m != nil {
+			objects = removeObject(objects, strings.ToLower(m[2]))
+		}
+	}
+
+	result, err := Execute(ctx, tracer, tx, sandboxDialect{}, sql)
+	if err != nil {
+		return fail(err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fail(fmt.Errorf("commit sandbox transaction: %w", err))
+	}
+
+	s.recordObjects(ctx, sb.objects, objects)
+	sb.objects = objects
+	span.SetAttributes(attribute.Int("nlsql.sandbox.objects", len(objects)))
+
+	return result, append([]SandboxObject(nil), objects...), nil
+}
+
+func tempColumns(ctx context.Context, tx pgx.Tx, name string) ([]string, error) {
+	rows, err := tx.Query(ctx, `
+		SELECT attname FROM pg_attribute
+		WHERE attrelid = ('pg_temp.' || quote_ident($1))::regclass AND attnum > 0 AND NOT attisdropped
+		ORDER BY attnum`, name)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowTo[string])
+}
+
+func removeObject(objects []SandboxObject, name string) []SandboxObject {
+	out := objects[:0:0]
+	for _, o := range objects {
+		if o.Name != name {
+			out = append(out, o)
+		}
+	}
+	return out
+}
+
+// recordObjects moves the object gauge from before to after, by kind.
+func (s *Sandboxes) recordObjects(ctx context.Context, before, after []SandboxObject) {
+	if s.Metrics == nil {
+		return
+	}
+	delta := map[string]int64{}
+	for _, o := range before {
+		delta[o.Kind]--
+	}
+	for _, o := range after {
+		delta[o.Kind]++
+	}
+	for kind, n := range delta {
+		if n != 0 {
+			s.Metrics.SandboxObjects.Add(ctx, n, telemetry.WithSandboxObjectKind(kind))
+		}
+	}
+}
+
+// Close ends a session's sandbox, dropping its temporary objects with the
+// connection. It is a no-op for sessions without an open sandbox.
+func (s *Sandboxes) Close(ctx context.Context, sessionID, reason string) {
+	s.mu.Lock()
+	sb := s.sessions[sessionID]
+	delete(s.sessions, sessionID)
+	s.mu.Unlock()
+	if sb == nil {
+		return
+	}
+
+	sb.mu.Lock()
+	defer sb.mu.Unlock()
+	if err := sb.conn.Close(ctx); err != nil {
+		log.Printf("Sandbox %s: closing connection: %v", sessionID, err)
+	}
+	s.recordObjects(ctx, sb.objects, nil)
+	if s.Metrics != nil {
+		s.Metrics.SandboxCleanups.Add(ctx, 1, telemetry.WithCleanupReason(reason))
+	}
+	trace.SpanFromContext(ctx).AddEvent("sandbox_cleanup", trace.WithAttributes(
+		attribute.String("session.id", sessionID),
+		attribute.String("nlsql.sandbox.cleanup_reason", reason),
+		attribute.Int("nlsql.sandbox.objects", len(sb.objects)),
+	))
+	sb.objects = nil
+}
+
+// Run closes sandboxes idle for longer than IdleTimeout until ctx is
+// cancelled. A zero IdleTimeout keeps sandboxes until their session ends.
+func (s *Sandboxes) Run(ctx context.Context) {
+	if s.IdleTimeout <= 0 {
+		return
+	}
+	ticker := time.NewTicker(min(s.IdleTimeout/2, time.Minute))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for _, id := range s.idle(s.IdleTimeout) {
+				s.Close(ctx, id, "idle")
+			}
+		}
+	}
+}
+
+// CloseAll closes every sandbox, for shutdown.
+func (s *Sandboxes) CloseAll(ctx context.Context) {
+	s.mu.Lock()
+	ids := make([]string, 0, len(s.sessions))
+	for id := range s.sessions {
+		ids = append(ids, id)
+	}
+	s.mu.Unlock()
+	for _, id := range ids {
+		s.Close(ctx, id, "shutdown")
+	}
+}
+
+// idle returns sessions unused for at least d. A session running a script
+// is never idle.
+func (s *Sandboxes) idle(d time.Duration) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var ids []string
+	for id, sb := range s.sessions {
+		if !sb.mu.TryLock() {
+			continue
+		}
+		if time.Since(sb.lastUsed) >= d {
+			ids = append(ids, id)
+		}
+		sb.mu.Unlock()
+	}
+	return ids
+}
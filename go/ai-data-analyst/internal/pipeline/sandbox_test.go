@@ -0,0 +1,102 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateSandboxScript(t *testing.T) {
+	tracer := testTracer().Tracer("test")
+	r := ValidateSandbox(context.Background(), tracer, `
+		CREATE TEMP TABLE tmp_growth AS SELECT country_id, AVG(value) AS avg_growth FROM indicator_values GROUP BY country_id;
+		SELECT c.name, g.avg_growth FROM tmp_growth g JOIN countries c ON c.id = g.country_id ORDER BY g.avg_growth DESC`,
+		50, nil)
+
+	require.True(t, r.Valid, r.Violations)
+	require.Len(t, r.Setup, 1)
+	assert.Contains(t, r.Setup[0], "CREATE TEMP TABLE tmp_growth")
+	assert.Contains(t, r.SafeSQL, "LIMIT 50")
+}
+
+func TestValidateSandboxPlainSelect(t *testing.T) {
+	tracer := testTracer().Tracer("test")
+	r := ValidateSandbox(context.Background(), tracer, "SELECT name FROM countries LIMIT 5", 50, nil)
+
+	assert.True(t, r.Valid)
+	assert.Empty(t, r.Setup)
+	assert.Equal(t, "SELECT name FROM countries LIMIT 5", r.SafeSQL)
+}
+
+func TestValidateSandboxRejectsRealTableWrites(t *testing.T) {
+	tracer := testTracer().Tracer("test")
+	cases := map[string]string{
+		"create permanent table": "CREATE TABLE tmp_x AS SELECT 1; SELECT * FROM tmp_x",
+		"insert":                 "INSERT INTO countries (name) VALUES ('x'); SELECT name FROM countries",
+		"drop real table":        "DROP TABLE countries; SELECT 1",
+		"write in temp body":     "CREATE TEMP TABLE tmp_x AS SELECT 1 FROM countries WHERE id IN (DELETE FROM countries RETURNING id); SELECT * FROM tmp_x",
+		"final statement write":  "CREATE TEMP TABLE tmp_x AS SELECT 1; UPDATE countries SET name = 'x'",
+	}
+	for name, sql := range cases {
+		t.Run(name, func(t *testing.T) {
+			r := ValidateSandbox(context.Background(), tracer, sql, 50, nil)
+			assert.False(t, r.Valid)
+			assert.NotEmpty(t, r.Violations)
+		})
+	}
+}
+
+func TestValidateSandboxObjectNames(t *testing.T) {
+	tracer := testTracer().Tracer("test")
+	r := ValidateSandbox(context.Background(), tracer,
+		"CREATE TEMP VIEW growth AS SELECT 1 AS x; SELECT x FROM growth", 50, nil)
+
+	assert.False(t, r.Valid)
+	assert.Contains(t, r.Violations, "sandbox_object_name: growth")
+}
+
+func TestValidateSandboxDropOwnedOnly(t *testing.T) {
+	tracer := testTracer().Tracer("test")
+	objects := []SandboxObject{{Name: "tmp_growth", Kind: "table"}}
+
+	r := ValidateSandbox(context.Background(), tracer,
+		"DROP TABLE IF EXISTS tmp_growth; SELECT name FROM countries", 50, objects)
+	assert.True(t, r.Valid, r.Violations)
+
+	r = ValidateSandbox(context.Background(), tracer,
+		"DROP TABLE tmp_other; SELECT name FROM countries", 50, objects)
+	assert.False(t, r.Valid)
+	assert.Contains(t, r.Violations, "sandbox_drop_not_owned: tmp_other")
+}
+
+func TestValidateSandboxStatementCap(t *testing.T) {
+	tracer := testTracer().Tracer("test")
+	sql := ""
+	for _, name := range []string{"tmp_a", "tmp_b", "tmp_c", "tmp_d", "tmp_e", "tmp_f"} {
+		sql += "CREATE TEMP TABLE " + name + " AS SELECT 1 AS x; "
+	}
+	sql += "SELECT x FROM tmp_a"
+
+	r := ValidateSandbox(context.Background(), tracer, sql, 50, nil)
+	assert.False(t, r.Valid)
+	assert.Contains(t, r.Violations, "sandbox_too_many_statements: 6")
+}
+
+func TestSandboxInstructions(t *testing.T) {
+	empty := sandboxInstructions(nil)
+	assert.Contains(t, empty, "CREATE TEMP TABLE")
+	assert.Contains(t, empty, "No sandbox objects exist yet")
+
+	listed := sandboxInstructions([]SandboxObject{{Name: "tmp_growth", Kind: "table", Columns: []string{"country_id", "avg_growth"}}})
+	assert.Contains(t, listed, "table tmp_growth (country_id, avg_growth)")
+}
+
+func TestAskResultScript(t *testing.T) {
+	r := &AskResult{SQL: "SELECT * FROM tmp_a LIMIT 50"}
+	assert.Equal(t, "SELECT * FROM tmp_a LIMIT 50", r.Script())
+
+	r.SetupSQL = []string{"CREATE TEMP TABLE tmp_a AS SELECT 1"}
+	assert.Equal(t, "CREATE TEMP TABLE tmp_a AS SELECT 1;\nSELECT * FROM tmp_a LIMIT 50", r.Script())
+}
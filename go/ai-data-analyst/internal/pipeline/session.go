@@ -0,0 +1,76 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+
+	"ai-data-analyst/internal/auth"
+	"ai-data-analyst/internal/db"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// sessionPreviewRows is how many result rows each turn keeps for display
+// alongside the session.
+const sessionPreviewRows = 5
+
+// defaultSessionTurns is used when the config does not set how many earlier
+// turns are sent to the Generate stage.
+const defaultSessionTurns = 5
+
+// AskInSession answers a question in the context of one of the caller's
+// conversation sessions. The most recent turns are fed to the Generate stage
+// so follow-ups such as "now only for Asia" build on the previous query, and
+// the answer is stored as a new turn once its SQL has passed validation.
+func (p *Pipeline) AskInSession(ctx context.Context, sessionID, question string) (*AskResult, error) {
+	sessions := db.NewSessionRepo(p.DB)
+	session, err := sessions.Get(ctx, auth.UserFrom(ctx), sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := defaultSessionTurns
+	if p.Config != nil && p.Config.SessionTurns > 0 {
+		limit = p.Config.SessionTurns
+	}
+	turns, err := sessions.ListTurns(ctx, session.ID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("load session turns: %w", err)
+	}
+
+	result, err := p.ask(ctx, question, askOptions{
+		sessionID: session.ID,
+		turns:     turns,
+		sandbox:   session.Sandbox,
+	})
+	if err != nil {
+		return nil, err
+	}
+	result.SessionID = session.ID
+
+	if result.validated {
+		preview := result.Rows
+		if len(preview) > sessionPreviewRows {
+			preview = preview[:sessionPreviewRows]
+		}
+		var summary string
+		if result.Explanation != nil {
+			summary = result.Explanation.Summary
+		}
+		if err := sessions.InsertTurn(ctx, db.InsertSessionTurnParams{
+			SessionID:    session.ID,
+			Question:     result.Question,
+			GeneratedSQL: result.Script(),
+			Columns:      result.Columns,
+			PreviewRows:  preview,
+			RowCount:     result.RowCount,
+			Summary:      summary,
+			TraceID:      result.TraceID,
+		}); err != nil {
+			// The answer is still good; only the follow-up context is lost.
+			trace.SpanFromContext(ctx).RecordError(fmt.Errorf("save session turn: %w", err))
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,184 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"ai-data-analyst/internal/db"
+)
+
+// DefaultDataSource is the name of the World Bank database the pipeline
+// queries through DB or Target, described by the generate prompt.
+const DefaultDataSource = "worldbank"
+
+// DataSource is a database questions can be answered from, besides the
+// default one, with the schema context the Generate stage describes it by.
+type DataSource struct {
+	Name        string
+	Description string
+
+	// Keywords in a question point the Parse stage at this source. The
+	// source with the most matches wins; a question matching none goes to
+	// the default source.
+	Keywords []string
+
+	Dialect Dialect
+	DB      db.Querier
+
+	// Schema is the Generate system prompt for the source, in place of the
+	// versioned generate prompt, which describes the default source.
+	Schema string
+}
+
+// DataSourceSpec is one entry of the DATA_SOURCES_FILE before its database
+// is opened.
+type DataSourceSpec struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Keywords    []string `json:"keywords"`
+	Dialect     string   `json:"dialect"`
+
+	// DatabaseURLEnv names the environment variable holding the source's
+	// connection string, so the file itself holds no credentials.
+	DatabaseURLEnv string `json:"database_url_env"`
+
+	// SchemaFile is the source's Generate prompt, relative to the file.
+	SchemaFile string `json:"schema_file"`
+
+	// DatabaseURL and Schema are read from DatabaseURLEnv and SchemaFile.
+	DatabaseURL string `json:"-"`
+	Schema      string `json:"-"`
+}
+
+var sourceNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// LoadDataSources reads the JSON array of sources in path, with each
+// source's schema file and connection string. Every problem is reported at
+// once, as with LLM_STAGE_MODELS, so one restart fixes them all.
+func LoadDataSources(path string) ([]DataSourceSpec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var specs []DataSourceSpec
+	if err := json.Unmarshal(data, &specs); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	var errs []error
+	seen := map[string]bool{DefaultDataSource: true}
+	for i := range specs {
+		s := &specs[i]
+		switch {
+		case !sourceNamePattern.MatchString(s.Name):
+			errs = append(errs, fmt.Errorf("source %d: name %q must be lower case letters, digits and _", i, s.Name))
+			continue
+		case seen[s.Name]:
+			errs = append(errs, fmt.Errorf("source %q: name is taken", s.Name))
+			continue
+		}
+		seen[s.Name] = true
+
+		if len(s.Keywords) == 0 {
+			errs = append(errs, fmt.Errorf("source %q: no keywords, so no question would reach it", s.Name))
+		}
+		if _, err := DialectFor(s.Dialect); err != nil {
+			errs = append(errs, fmt.Errorf("source %q: %w", s.Name, err))
+		}
+		if s.DatabaseURL = os.Getenv(s.DatabaseURLEnv); s.DatabaseURLEnv == "" || s.DatabaseURL == "" {
+			errs = append(errs, fmt.Errorf("source %q: database_url_env %q is not set", s.Name, s.DatabaseURLEnv))
+		}
+		schema, err := os.ReadFile(filepath.Join(filepath.Dir(path), s.SchemaFile))
+		if s.SchemaFile == "" || err != nil {
+			errs = append(errs, fmt.Errorf("source %q: schema_file %q cannot be read", s.Name, s.SchemaFile))
+		}
+		s.Schema = string(schema)
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, fmt.Errorf("DATA_SOURCES_FILE: %w", err)
+	}
+	return specs, nil
+}
+
+// pickDataSource returns the name of the source whose keywords the question
+// matches most often, or DefaultDataSource when it matches none. Ties go to
+// the source listed first.
+func pickDataSource(lower string, sources []*DataSource) string {
+	best, bestHits := DefaultDataSource, 0
+	for _, s := range sources {
+		hits := 0
+		for _, kw := range s.Keywords {
+			if strings.Contains(lower, strings.ToLower(kw)) {
+				hits++
+			}
+		}
+		if hits > bestHits {
+			best, bestHits = s.Name, hits
+		}
+	}
+	return best
+}
+
+// DataSourceNames lists the default source and then the configured ones.
+func (p *Pipeline) DataSourceNames() []string {
+	names := []string{DefaultDataSource}
+	for _, s := range p.Sources {
+		names = append(names, s.Name)
+	}
+	return names
+}
+
+// source returns the source called name, with the default source built
+// from the pipeline's DB, Target and Dialect. An unknown name, such as one
+// recorded in history before a source was removed, is the default source.
+func (p *Pipeline) source(name string) *DataSource {
+	if i := slices.IndexFunc(p.Sources, func(s *DataSource) bool { return s.Name == name }); i >= 0 {
+		return p.Sources[i]
+	}
+	return &DataSource{Name: DefaultDataSource, Dialect: p.dialect(), DB: p.target()}
+}
+
+// cacheName scopes cached rows to the source, so the same SQL against two
+// databases is cached twice. The default source keeps the dialect name
+// alone, as before there were sources.
+func (s *DataSource) cacheName() string {
+	if s.Name == DefaultDataSource {
+		return s.Dialect.Name()
+	}
+	return s.Name + ":" + s.Dialect.Name()
+}
+
+// available reports whether queries can run. A Querier that tracks its own
+// health, such as db.Connector, is asked; otherwise a non-nil one is assumed up.
+func (s *DataSource) available() bool {
+	if s.DB == nil {
+		return false
+	}
+	if h, ok := s.DB.(interface{ Healthy() bool }); ok {
+		return h.Healthy()
+	}
+	return true
+}
+
+type dataSourceKey struct{}
+
+// withDataSource records the source a question runs against, for the stage
+// spans to carry as nlsql.data_source.
+func withDataSource(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, dataSourceKey{}, name)
+}
+
+// dataSourceFrom returns the source set by withDataSource, or the default.
+func dataSourceFrom(ctx context.Context) string {
+	if name, ok := ctx.Value(dataSourceKey{}).(string); ok {
+		return name
+	}
+	return DefaultDataSource
+}
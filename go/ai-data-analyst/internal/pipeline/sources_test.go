@@ -0,0 +1,115 @@
+package pipeline
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"ai-data-analyst/internal/cache"
+	"ai-data-analyst/internal/config"
+	"ai-data-analyst/internal/db"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+var salesSource = &DataSource{
+	Name:     "sales",
+	Keywords: []string{"revenue", "order", "customer"},
+	Dialect:  Postgres{},
+	Schema:   "Schema:\n- orders (id, customer_id, total)",
+}
+
+func TestLoadDataSourcesExample(t *testing.T) {
+	t.Setenv("SALES_DATABASE_URL", "postgres://localhost/sales")
+	specs, err := LoadDataSources("../../data/sources/sources.example.json")
+	require.NoError(t, err)
+	require.Len(t, specs, 1)
+	assert.Equal(t, "sales", specs[0].Name)
+	assert.Equal(t, "postgres://localhost/sales", specs[0].DatabaseURL)
+	assert.Contains(t, specs[0].Schema, "order_items")
+}
+
+func TestLoadDataSourcesReportsEveryProblem(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "sources.json")
+	require.NoError(t, os.WriteFile(path, []byte(`[
+		{"name": "worldbank", "keywords": ["x"], "dialect": "postgres", "database_url_env": "HOME", "schema_file": "s.txt"},
+		{"name": "Sales", "keywords": ["x"]},
+		{"name": "hr", "dialect": "oracle", "database_url_env": "NLSQL_UNSET_URL", "schema_file": "missing.txt"}
+	]`), 0o600))
+
+	_, err := LoadDataSources(path)
+	require.Error(t, err)
+	for _, want := range []string{
+		`source "worldbank": name is taken`,
+		`name "Sales" must be lower case`,
+		`source "hr": no keywords`,
+		`unsupported SQL dialect "oracle"`,
+		`database_url_env "NLSQL_UNSET_URL" is not set`,
+		`schema_file "missing.txt" cannot be read`,
+	} {
+		assert.Contains(t, err.Error(), want)
+	}
+}
+
+func TestParsePicksDataSource(t *testing.T) {
+	tracer := testTracer().Tracer("test")
+	hr := &DataSource{Name: "hr", Keywords: []string{"employee", "customer"}}
+
+	assert.Equal(t, DefaultDataSource, Parse(context.Background(), tracer, "GDP of India in 2020").DataSource)
+	assert.Equal(t, DefaultDataSource, Parse(context.Background(), tracer, "Revenue by customer").DataSource,
+		"without sources everything goes to the default")
+	assert.Equal(t, "sales", Parse(context.Background(), tracer, "Revenue by customer", salesSource, hr).DataSource)
+	assert.Equal(t, "hr", Parse(context.Background(), tracer, "Employees per customer", salesSource, hr).DataSource,
+		"the most keywords wins")
+	assert.Equal(t, "sales", Parse(context.Background(), tracer, "Orders per employee", salesSource, hr).DataSource,
+		"a tie goes to the source listed first")
+}
+
+func TestDataSourceCacheName(t *testing.T) {
+	p := &Pipeline{Sources: []*DataSource{salesSource}}
+	assert.Equal(t, "postgres", p.source(DefaultDataSource).cacheName(), "the default keeps its old cache keys")
+	assert.Equal(t, "sales:postgres", p.source("sales").cacheName())
+	assert.Equal(t, DefaultDataSource, p.source("removed").Name)
+}
+
+func TestExportUsesHistoryDataSource(t *testing.T) {
+	ctx := context.Background()
+	exporter := tracetest.NewInMemoryExporter()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)).Tracer("test")
+	p := &Pipeline{
+		Tracer:  tracer,
+		Config:  &config.Config{RowLimit: 50},
+		Cache:   &ResultCache{Store: cache.NewLRU(10), TTL: time.Minute},
+		Sources: []*DataSource{salesSource},
+	}
+	h := &db.QueryHistory{ID: "h1", GeneratedSQL: "SELECT id FROM orders", DataSource: "sales"}
+
+	validated := ValidateDialect(ctx, tracer, h.GeneratedSQL, 50, Postgres{})
+	p.Cache.putRows(ctx, validated.SafeSQL, salesSource.cacheName(), &ExecuteResult{
+		Columns: []string{"id"}, Rows: [][]any{{1}}, RowCount: 1,
+	})
+
+	var buf bytes.Buffer
+	require.NoError(t, p.Export(ctx, h, "csv", func(string) io.Writer { return &buf }))
+	assert.Equal(t, "id\n1\n", buf.String())
+
+	// The same SQL on the default source has no cached rows, and no database.
+	h.DataSource = DefaultDataSource
+	assert.ErrorIs(t, p.Export(ctx, h, "csv", func(string) io.Writer { return io.Discard }), db.ErrUnavailable)
+
+	for _, s := range exporter.GetSpans() {
+		if s.Name == "pipeline export" {
+			assert.Contains(t, s.Attributes, attribute.String("nlsql.data_source", "sales"))
+			break
+		}
+	}
+}
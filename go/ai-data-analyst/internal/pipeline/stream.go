@@ -0,0 +1,73 @@
+package pipeline
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// StageEvent reports a completed pipeline stage. TraceID and SpanID identify
+// the stage span, so a streaming client can link each step to its trace.
+type StageEvent struct {
+	Stage   string    `json:"stage"`
+	TraceID string    `json:"trace_id"`
+	SpanID  string    `json:"span_id"`
+	Time    time.Time `json:"time"`
+	Result  any       `json:"result"`
+}
+
+// StageObserver receives stage events on the goroutine running the pipeline.
+type StageObserver func(StageEvent)
+
+type stageObserverKey struct{}
+
+// WithStageObserver returns a context under which every pipeline stage
+// reports its result to fn as it completes.
+func WithStageObserver(ctx context.Context, fn StageObserver) context.Context {
+	return context.WithValue(ctx, stageObserverKey{}, fn)
+}
+
+func stageObserverFrom(ctx context.Context) StageObserver {
+	fn, _ := ctx.Value(stageObserverKey{}).(StageObserver)
+	return fn
+}
+
+// emitStage hands a stage result to the observer in ctx, if any.
+func emitStage(ctx context.Context, span trace.Span, stage string, result any) {
+	fn := stageObserverFrom(ctx)
+	if fn == nil {
+		return
+	}
+	sc := span.SpanContext()
+	fn(StageEvent{
+		Stage:   stage,
+		TraceID: sc.TraceID().String(),
+		SpanID:  sc.SpanID().String(),
+		Time:    time.Now().UTC(),
+		Result:  result,
+	})
+}
+
+// TokenEvent is a piece of a stage's output, sent as the LLM produces it.
+type TokenEvent struct {
+	Stage string `json:"stage"`
+	Delta string `json:"delta"`
+}
+
+// TokenObserver receives token events on the goroutine running the pipeline.
+type TokenObserver func(TokenEvent)
+
+type tokenObserverKey struct{}
+
+// WithTokenObserver returns a context under which stages that can stream
+// their LLM output, currently the explain summary, send it to fn as it
+// arrives.
+func WithTokenObserver(ctx context.Context, fn TokenObserver) context.Context {
+	return context.WithValue(ctx, tokenObserverKey{}, fn)
+}
+
+func tokenObserverFrom(ctx context.Context) TokenObserver {
+	fn, _ := ctx.Value(tokenObserverKey{}).(TokenObserver)
+	return fn
+}
@@ -0,0 +1,39 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStageObserverReceivesStageSpans(t *testing.T) {
+	tp := testTracer()
+	tracer := tp.Tracer("test")
+
+	var events []StageEvent
+	ctx := WithStageObserver(context.Background(), func(e StageEvent) {
+		events = append(events, e)
+	})
+
+	ctx, parent := tracer.Start(ctx, "pipeline ask")
+	Parse(ctx, tracer, "Top 10 countries by GDP growth in 2023")
+	ValidateWithLimit(ctx, tracer, "SELECT * FROM countries", 100)
+	parent.End()
+
+	require.Len(t, events, 2)
+	assert.Equal(t, "parse", events[0].Stage)
+	assert.Equal(t, "validate", events[1].Stage)
+
+	traceID := parent.SpanContext().TraceID().String()
+	for _, e := range events {
+		assert.Equal(t, traceID, e.TraceID)
+		assert.NotEqual(t, parent.SpanContext().SpanID().String(), e.SpanID)
+	}
+	assert.NotEqual(t, events[0].SpanID, events[1].SpanID)
+
+	validated, ok := events[1].Result.(*ValidateResult)
+	require.True(t, ok)
+	assert.True(t, validated.Valid)
+}
@@ -13,6 +13,10 @@ type ValidateResult struct {
 	Valid      bool     `json:"valid"`
 	SafeSQL    string   `json:"safe_sql"`
 	Violations []string `json:"violations"`
+
+	// Setup holds the sandbox statements that run before SafeSQL. It is
+	// only set by ValidateSandbox.
+	Setup []string `json:"setup_sql,omitempty"`
 }
 
 var mutationKeywords = []string{
@@ -20,17 +24,46 @@ var mutationKeywords = []string{
 	"TRUNCATE", "EXECUTE", "PREPARE", "GRANT", "REVOKE",
 }
 
-var systemSchemas = []string{
-	"pg_catalog", "information_schema", "pg_temp", "pg_toast",
-}
-
 var limitPattern = regexp.MustCompile(`(?i)\bLIMIT\s+\d+`)
 var semicolonSplit = regexp.MustCompile(`;\s*\S`)
 
+// DefaultRowLimit is the LIMIT injected into queries that have none.
+const DefaultRowLimit = 50
+
 func Validate(ctx context.Context, tracer trace.Tracer, sql string) *ValidateResult {
+	return ValidateWithLimit(ctx, tracer, sql, DefaultRowLimit)
+}
+
+// ValidateWithLimit is Validate with the injected row limit set by the caller.
+func ValidateWithLimit(ctx context.Context, tracer trace.Tracer, sql string, rowLimit int) *ValidateResult {
+	return ValidateDialect(ctx, tracer, sql, rowLimit, Postgres{})
+}
+
+// ValidateDialect validates SQL for the database it will run against,
+// applying that dialect's system-schema denylist, extra denied keywords and
+// LIMIT syntax.
+func ValidateDialect(ctx context.Context, tracer trace.Tracer, sql string, rowLimit int, d Dialect) *ValidateResult {
 	_, span := tracer.Start(ctx, "pipeline_stage validate")
 	defer span.End()
 
+	result, limitInjected := validateQuery(sql, rowLimit, d)
+
+	span.SetAttributes(
+		attribute.String("nlsql.stage", "validate"),
+		attribute.String("nlsql.dialect", d.Name()),
+		attribute.Bool("nlsql.valid", result.Valid),
+		attribute.Int("nlsql.violations_count", len(result.Violations)),
+		attribute.Bool("nlsql.limit_injected", limitInjected),
+	)
+
+	emitStage(ctx, span, "validate", result)
+
+	return result
+}
+
+// validateQuery applies the read-only checks to a single query and injects
+// the row limit when it is valid and has none.
+func validateQuery(sql string, rowLimit int, d Dialect) (*ValidateResult, bool) {
 	result := &ValidateResult{
 		Valid:   true,
 		SafeSQL: strings.TrimSpace(sql),
@@ -54,9 +87,16 @@ func Validate(ctx context.Context, tracer trace.Tracer, sql string) *ValidateRes
 		}
 	}
 
+	for _, kw := range d.DeniedKeywords() {
+		if regexp.MustCompile(`(?i)\b` + kw + `\b`).MatchString(sql) {
+			result.Valid = false
+			result.Violations = append(result.Violations, "denied_keyword: "+kw)
+		}
+	}
+
 	// Check for system schema access
 	lower := strings.ToLower(sql)
-	for _, schema := range systemSchemas {
+	for _, schema := range d.SystemSchemas() {
 		if strings.Contains(lower, schema) {
 			result.Valid = false
 			result.Violations = append(result.Violations, "system_schema_access: "+schema)
@@ -79,19 +119,12 @@ func Validate(ctx context.Context, tracer trace.Tracer, sql string) *ValidateRes
 	// Inject LIMIT if missing
 	limitInjected := false
 	if result.Valid && !limitPattern.MatchString(sql) {
-		result.SafeSQL = strings.TrimRight(result.SafeSQL, ";") + " LIMIT 50"
+		result.SafeSQL = d.ApplyLimit(result.SafeSQL, rowLimit)
 		limitInjected = true
 	}
 
 	// Remove trailing semicolons
 	result.SafeSQL = strings.TrimRight(result.SafeSQL, ";")
 
-	span.SetAttributes(
-		attribute.String("nlsql.stage", "validate"),
-		attribute.Bool("nlsql.valid", result.Valid),
-		attribute.Int("nlsql.violations_count", len(result.Violations)),
-		attribute.Bool("nlsql.limit_injected", limitInjected),
-	)
-
-	return result
+	return result, limitInjected
 }
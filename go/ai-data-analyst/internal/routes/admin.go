@@ -0,0 +1,134 @@
+package routes
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"ai-data-analyst/internal/auth"
+	"ai-data-analyst/internal/config"
+	"ai-data-analyst/internal/db"
+	"ai-data-analyst/internal/seed"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type AdminConfigResponse struct {
+	Config config.Runtime      `json:"config"`
+	Audit  []config.AuditEntry `json:"audit"`
+}
+
+func AdminConfigHandler(store *config.RuntimeStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(AdminConfigResponse{Config: store.Get(), Audit: store.Audit()})
+	}
+}
+
+// UpdateAdminConfigHandler applies a partial update. Every change is written
+// to the server log and as an event on the request span; onChange, if set,
+// runs after a successful change so dependants can pick up new settings.
+func UpdateAdminConfigHandler(store *config.RuntimeStore, onChange func(config.Runtime)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var patch config.RuntimePatch
+		dec := json.NewDecoder(r.Body)
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&patch); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+			return
+		}
+
+		updateRuntime(w, r, store, patch, onChange)
+	}
+}
+
+// updateRuntime applies patch for the request's user, logging and tracing
+// each change, and answers with the new config and audit log.
+func updateRuntime(w http.ResponseWriter, r *http.Request, store *config.RuntimeStore, patch config.RuntimePatch, onChange func(config.Runtime)) {
+	user := auth.UserFrom(r.Context())
+	updated, entry, err := store.Update(patch, user)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if entry != nil {
+		span := trace.SpanFromContext(r.Context())
+		for _, c := range entry.Changes {
+			log.Printf("config change: version=%d user=%s %s: %v -> %v", entry.Version, user, c.Field, c.From, c.To)
+			span.AddEvent("config.change", trace.WithAttributes(
+				attribute.String("config.field", c.Field),
+				attribute.String("config.from", fmt.Sprint(c.From)),
+				attribute.String("config.to", fmt.Sprint(c.To)),
+			))
+		}
+		span.SetAttributes(attribute.Int64("app.config.version", entry.Version))
+		if onChange != nil {
+			onChange(updated)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(AdminConfigResponse{Config: updated, Audit: store.Audit()})
+}
+
+type ReseedResponse struct {
+	Config     seed.Config    `json:"config"`
+	Stats      seed.LoadStats `json:"stats"`
+	DurationMS int64          `json:"duration_ms"`
+}
+
+// ReseedHandler replaces the dataset tables with a generated dataset. The
+// body is a seed.Config whose omitted fields take their defaults; an empty
+// body reseeds with seed.DefaultConfig. onReseed, if set, runs after the
+// load commits so caches of the old rows can be dropped.
+func ReseedHandler(b db.Beginner, onReseed func()) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cfg := seed.DefaultConfig()
+		dec := json.NewDecoder(r.Body)
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
+			writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+			return
+		}
+		dataset, err := seed.Generate(cfg)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		start := time.Now()
+		stats, err := seed.Load(r.Context(), b, dataset)
+		if err != nil {
+			status := http.StatusInternalServerError
+			if errors.Is(err, db.ErrUnavailable) {
+				status = http.StatusServiceUnavailable
+			}
+			writeError(w, status, err.Error())
+			return
+		}
+		duration := time.Since(start)
+		if onReseed != nil {
+			onReseed()
+		}
+
+		user := auth.UserFrom(r.Context())
+		log.Printf("dataset reseeded: user=%s countries=%d years=%d-%d seed=%d values=%d in %s",
+			user, cfg.Countries, cfg.FirstYear, cfg.LastYear, cfg.Seed, stats.Values, duration)
+		trace.SpanFromContext(r.Context()).SetAttributes(
+			attribute.Int("seed.countries", cfg.Countries),
+			attribute.Int("seed.first_year", cfg.FirstYear),
+			attribute.Int("seed.last_year", cfg.LastYear),
+			attribute.Int64("seed.rng_seed", cfg.Seed),
+			attribute.Int64("seed.values", stats.Values),
+		)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(ReseedResponse{Config: cfg, Stats: stats, DurationMS: duration.Milliseconds()})
+	}
+}
@@ -1,8 +1,13 @@
 package routes
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"ai-data-analyst/internal/pipeline"
 )
@@ -13,34 +18,127 @@ type AskRequest struct {
 
 func AskHandler(p *pipeline.Pipeline) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		var req AskRequest
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			writeError(w, http.StatusBadRequest, "invalid request body")
+		r = withAskOptions(r)
+		question, ok := decodeQuestion(w, r, p)
+		if !ok {
 			return
 		}
 
-		if req.Question == "" {
-			writeError(w, http.StatusBadRequest, "question is required")
+		if wantsStream(r) {
+			streamAsk(w, r, func(ctx context.Context) (*pipeline.AskResult, error) {
+				return p.Ask(ctx, question)
+			})
 			return
 		}
 
-		result, err := p.Ask(r.Context(), req.Question)
+		result, err := p.Ask(r.Context(), question)
+		if writeBudgetError(w, err) || writeGuardrailError(w, err) {
+			return
+		}
 		if err != nil {
 			writeError(w, http.StatusInternalServerError, err.Error())
 			return
 		}
 
-		// If validation failed
-		if result.Explanation != nil && result.SQL != "" && result.RowCount == 0 && result.Confidence < 0.3 {
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusUnprocessableEntity)
-			json.NewEncoder(w).Encode(result)
-			return
-		}
+		writeAskResult(w, p, result)
+	}
+}
+
+// decodeQuestion reads an AskRequest and checks the question against the
+// current settings, writing a 400 and returning false if it is unusable.
+func decodeQuestion(w http.ResponseWriter, r *http.Request, p *pipeline.Pipeline) (string, bool) {
+	var req AskRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return "", false
+	}
+
+	if req.Question == "" {
+		writeError(w, http.StatusBadRequest, "question is required")
+		return "", false
+	}
 
+	settings := p.Settings()
+	if len(req.Question) > settings.MaxQuestionLength {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("question exceeds %d characters", settings.MaxQuestionLength))
+		return "", false
+	}
+	return req.Question, true
+}
+
+func writeAskResult(w http.ResponseWriter, p *pipeline.Pipeline, result *pipeline.AskResult) {
+	// If validation failed
+	if result.Explanation != nil && result.SQL != "" && result.RowCount == 0 && result.Confidence < p.Settings().MinConfidence {
 		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnprocessableEntity)
 		json.NewEncoder(w).Encode(result)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// wantsStream reports whether the client asked for Server-Sent Events,
+// either with an Accept header or ?stream=true.
+func wantsStream(r *http.Request) bool {
+	if strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		return true
 	}
+	stream, _ := strconv.ParseBool(r.URL.Query().Get("stream"))
+	return stream
+}
+
+// withAskOptions applies the request's optional extras to its context: a
+// chart spec for ?include_chart=true and the query plan for
+// ?include_plan=true.
+func withAskOptions(r *http.Request) *http.Request {
+	ctx := r.Context()
+	if include, _ := strconv.ParseBool(r.URL.Query().Get("include_chart")); include {
+		ctx = pipeline.WithChart(ctx)
+	}
+	if include, _ := strconv.ParseBool(r.URL.Query().Get("include_plan")); include {
+		ctx = pipeline.WithQueryPlan(ctx)
+	}
+	return r.WithContext(ctx)
+}
+
+// streamAsk runs the pipeline and sends a "stage" event as each stage
+// completes, "token" events as the explanation summary is written, then a
+// final "result" event, or "error" if the pipeline fails after the stream
+// has started.
+func streamAsk(w http.ResponseWriter, r *http.Request, ask func(context.Context) (*pipeline.AskResult, error)) {
+	rc := http.NewResponseController(w)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	_ = rc.Flush()
+
+	send := func(event string, v any) {
+		data, err := json.Marshal(v)
+		if err != nil {
+			data, _ = json.Marshal(map[string]string{"error": err.Error()})
+			event = "error"
+		}
+		fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data)
+		_ = rc.Flush()
+	}
+
+	ctx := pipeline.WithStageObserver(r.Context(), func(e pipeline.StageEvent) {
+		send("stage", e)
+	})
+	ctx = pipeline.WithTokenObserver(ctx, func(e pipeline.TokenEvent) {
+		send("token", e)
+	})
+
+	result, err := ask(ctx)
+	if err != nil {
+		send("error", map[string]string{"error": err.Error()})
+		return
+	}
+	send("result", result)
 }
 
 func writeError(w http.ResponseWriter, code int, message string) {
@@ -48,3 +146,13 @@ func writeError(w http.ResponseWriter, code int, message string) {
 	w.WriteHeader(code)
 	json.NewEncoder(w).Encode(map[string]string{"error": message})
 }
+
+// writeGuardrailError answers a question whose answer the PII guardrail
+// blocked with 422, and returns false for any other error.
+func writeGuardrailError(w http.ResponseWriter, err error) bool {
+	if !errors.Is(err, pipeline.ErrGuardrailBlocked) {
+		return false
+	}
+	writeError(w, http.StatusUnprocessableEntity, err.Error())
+	return true
+}
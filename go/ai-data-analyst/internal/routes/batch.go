@@ -0,0 +1,53 @@
+package routes
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"ai-data-analyst/internal/pipeline"
+)
+
+type AskBatchRequest struct {
+	Questions []string `json:"questions"`
+}
+
+// AskBatchHandler answers up to maxQuestions questions in one request, at
+// most concurrency at a time. Questions that fail are reported in their
+// result; the response is 200 unless the request itself is invalid.
+func AskBatchHandler(p *pipeline.Pipeline, maxQuestions, concurrency int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		r = withAskOptions(r)
+
+		var req AskBatchRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+		if len(req.Questions) == 0 {
+			writeError(w, http.StatusBadRequest, "questions is required")
+			return
+		}
+		if len(req.Questions) > maxQuestions {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("a batch holds at most %d questions", maxQuestions))
+			return
+		}
+
+		maxLength := p.Settings().MaxQuestionLength
+		for i, q := range req.Questions {
+			if q == "" {
+				writeError(w, http.StatusBadRequest, fmt.Sprintf("question %d is empty", i))
+				return
+			}
+			if len(q) > maxLength {
+				writeError(w, http.StatusBadRequest, fmt.Sprintf("question %d exceeds %d characters", i, maxLength))
+				return
+			}
+		}
+
+		result := p.AskBatch(r.Context(), req.Questions, concurrency)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(result)
+	}
+}
@@ -0,0 +1,42 @@
+package routes
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"ai-data-analyst/internal/llm"
+)
+
+// BudgetHandler reports LLM spend against the configured limits: today's,
+// and a session's with ?session_id=.
+func BudgetHandler(b *llm.Budget) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if b == nil {
+			writeError(w, http.StatusNotFound, "LLM budgets are not configured")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(b.Status(r.Context(), r.URL.Query().Get("session_id")))
+	}
+}
+
+// writeBudgetError answers a question turned away by an LLM budget with 429,
+// and returns false for any other error. A daily limit resets at UTC
+// midnight, which Retry-After points to.
+func writeBudgetError(w http.ResponseWriter, err error) bool {
+	var budgetErr *llm.BudgetError
+	if !errors.As(err, &budgetErr) {
+		return false
+	}
+	if budgetErr.Scope == llm.BudgetScopeDaily {
+		now := time.Now().UTC()
+		midnight := now.Truncate(24 * time.Hour).Add(24 * time.Hour)
+		w.Header().Set("Retry-After", strconv.Itoa(int(midnight.Sub(now).Seconds())+1))
+	}
+	writeError(w, http.StatusTooManyRequests, err.Error())
+	return true
+}
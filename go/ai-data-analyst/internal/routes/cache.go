@@ -0,0 +1,22 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"ai-data-analyst/internal/pipeline"
+)
+
+// CacheStatsHandler reports result cache hits and misses per level since
+// startup.
+func CacheStatsHandler(c *pipeline.ResultCache) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if c == nil {
+			writeError(w, http.StatusNotFound, "result cache is disabled")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(c.Stats(r.Context()))
+	}
+}
@@ -0,0 +1,105 @@
+package routes
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"ai-data-analyst/internal/db"
+)
+
+// defaultCostDays is the range /api/costs covers when from is not given.
+const defaultCostDays = 30
+
+type CostTotal struct {
+	Questions    int     `json:"questions"`
+	TotalTokens  int64   `json:"total_tokens"`
+	TotalCostUSD float64 `json:"total_cost_usd"`
+}
+
+type CostsResponse struct {
+	From    string       `json:"from"`
+	To      string       `json:"to"`
+	GroupBy []string     `json:"group_by"`
+	Rows    []db.CostRow `json:"rows"`
+	Total   CostTotal    `json:"total"`
+}
+
+// CostsHandler reports LLM spend from cost_rollups. group_by takes a comma
+// separated list of day, week or month and user and api_key; user_id and
+// api_key_id filter it, and from and to are inclusive YYYY-MM-DD dates
+// defaulting to the last 30 days.
+func CostsHandler(q db.Querier) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cq, err := parseCostQuery(r.URL.Query(), time.Now().UTC())
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		rows, err := db.Costs(r.Context(), q, cq)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		resp := CostsResponse{
+			From:    cq.From.Format(time.DateOnly),
+			To:      cq.To.AddDate(0, 0, -1).Format(time.DateOnly),
+			GroupBy: cq.GroupBy,
+			Rows:    rows,
+		}
+		for _, row := range rows {
+			resp.Total.Questions += row.Questions
+			resp.Total.TotalTokens += row.TotalTokens
+			resp.Total.TotalCostUSD += row.TotalCostUSD
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+// parseCostQuery reads the /api/costs query parameters. The returned To is
+// exclusive: the day after the to parameter.
+func parseCostQuery(values url.Values, now time.Time) (db.CostQuery, error) {
+	cq := db.CostQuery{
+		GroupBy:  []string{},
+		UserID:   values.Get("user_id"),
+		APIKeyID: values.Get("api_key_id"),
+	}
+	if v := values.Get("group_by"); v != "" {
+		for _, g := range strings.Split(v, ",") {
+			cq.GroupBy = append(cq.GroupBy, strings.TrimSpace(g))
+		}
+	}
+	if err := cq.Validate(); err != nil {
+		return cq, err
+	}
+
+	today := now.Truncate(24 * time.Hour)
+	cq.To = today.AddDate(0, 0, 1)
+	cq.From = today.AddDate(0, 0, 1-defaultCostDays)
+	for _, p := range []struct {
+		name string
+		dst  *time.Time
+		day  int
+	}{{"from", &cq.From, 0}, {"to", &cq.To, 1}} {
+		v := values.Get(p.name)
+		if v == "" {
+			continue
+		}
+		d, err := time.Parse(time.DateOnly, v)
+		if err != nil {
+			return cq, fmt.Errorf("%s must be a YYYY-MM-DD date", p.name)
+		}
+		*p.dst = d.AddDate(0, 0, p.day)
+	}
+	if !cq.From.Before(cq.To) {
+		return cq, fmt.Errorf("from must not be after to")
+	}
+	return cq, nil
+}
@@ -0,0 +1,49 @@
+package routes
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCostQuery(t *testing.T) {
+	now := time.Date(2026, 3, 15, 9, 30, 0, 0, time.UTC)
+
+	cq, err := parseCostQuery(url.Values{
+		"group_by":   {"week, api_key"},
+		"api_key_id": {"3f2a9c1b7d4e"},
+		"from":       {"2026-03-01"},
+		"to":         {"2026-03-07"},
+	}, now)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"week", "api_key"}, cq.GroupBy)
+	assert.Equal(t, "3f2a9c1b7d4e", cq.APIKeyID)
+	assert.Equal(t, time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), cq.From)
+	// to includes that whole day.
+	assert.Equal(t, time.Date(2026, 3, 8, 0, 0, 0, 0, time.UTC), cq.To)
+
+	cq, err = parseCostQuery(url.Values{}, now)
+	require.NoError(t, err)
+	assert.Empty(t, cq.GroupBy)
+	assert.Equal(t, time.Date(2026, 2, 14, 0, 0, 0, 0, time.UTC), cq.From, "the last 30 days by default")
+	assert.Equal(t, time.Date(2026, 3, 16, 0, 0, 0, 0, time.UTC), cq.To)
+}
+
+func TestParseCostQueryRejectsInvalid(t *testing.T) {
+	now := time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC)
+	for name, values := range map[string]url.Values{
+		"unknown group":  {"group_by": {"model"}},
+		"two periods":    {"group_by": {"day,month"}},
+		"repeated group": {"group_by": {"user,user"}},
+		"from":           {"from": {"2026-03-01T00:00:00Z"}},
+		"range":          {"from": {"2026-03-02"}, "to": {"2026-03-01"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := parseCostQuery(values, now)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,21 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"ai-data-analyst/internal/db"
+)
+
+func DictionaryHandler(cache *db.DictionaryCache) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		entries, err := cache.Get(r.Context())
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(entries)
+	}
+}
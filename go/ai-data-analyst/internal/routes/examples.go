@@ -0,0 +1,28 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"ai-data-analyst/internal/pipeline"
+)
+
+// ExamplesHandler lists the demo questions. With the warm-up enabled each
+// carries its outcome, and "warmed" or "cached" ones are answered from the
+// result cache.
+func ExamplesHandler(warm *pipeline.WarmUp) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body := map[string]any{"examples": pipeline.Examples, "warmup": "disabled"}
+		if warm != nil {
+			examples, done := warm.Status()
+			body["examples"] = examples
+			body["warmup"] = "running"
+			if done {
+				body["warmup"] = "done"
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(body)
+	}
+}
@@ -19,3 +19,31 @@ func HealthHandler(serviceName string) http.HandlerFunc {
 		})
 	}
 }
+
+type ReadyResponse struct {
+	Status       string            `json:"status"`
+	Dependencies map[string]string `json:"dependencies"`
+}
+
+// ReadyHandler answers 503 while any dependency check fails, so traffic is
+// routed away from an instance that can only serve degraded results. The
+// process stays live (see HealthHandler) and recovers on its own.
+func ReadyHandler(checks map[string]func() error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		resp := ReadyResponse{Status: "ready", Dependencies: make(map[string]string, len(checks))}
+		code := http.StatusOK
+		for name, check := range checks {
+			if err := check(); err != nil {
+				resp.Dependencies[name] = "down: " + err.Error()
+				resp.Status = "degraded"
+				code = http.StatusServiceUnavailable
+				continue
+			}
+			resp.Dependencies[name] = "up"
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		json.NewEncoder(w).Encode(resp)
+	}
+}
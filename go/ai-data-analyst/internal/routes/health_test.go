@@ -2,10 +2,13 @@ package routes
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
+	"github.com/base-14/examples/go/pkg/buildinfo"
+
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -24,3 +27,40 @@ func TestHealthHandler(t *testing.T) {
 	assert.Equal(t, "ok", resp.Status)
 	assert.Equal(t, "ai-data-analyst", resp.Service)
 }
+
+func TestVersionHandler(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/version", nil)
+	w := httptest.NewRecorder()
+
+	VersionHandler()(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var info buildinfo.Info
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&info))
+	assert.Equal(t, buildinfo.Version, info.Version)
+	assert.NotEmpty(t, info.GoVersion)
+	assert.Len(t, info.InstanceID, 36)
+}
+
+func TestReadyHandler(t *testing.T) {
+	up := func() error { return nil }
+	down := func() error { return errors.New("connection refused") }
+
+	w := httptest.NewRecorder()
+	ReadyHandler(map[string]func() error{"postgres": up})(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var resp ReadyResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	assert.Equal(t, "ready", resp.Status)
+	assert.Equal(t, "up", resp.Dependencies["postgres"])
+
+	w = httptest.NewRecorder()
+	ReadyHandler(map[string]func() error{"postgres": down})(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	assert.Equal(t, "degraded", resp.Status)
+	assert.Equal(t, "down: connection refused", resp.Dependencies["postgres"])
+}
@@ -2,27 +2,204 @@ package routes
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"net/url"
 	"strconv"
+	"time"
 
+	"ai-data-analyst/internal/auth"
 	"ai-data-analyst/internal/db"
+	"ai-data-analyst/internal/export"
+	"ai-data-analyst/internal/pipeline"
+
+	"github.com/go-chi/chi/v5"
 )
 
-func HistoryHandler(q db.Querier) http.HandlerFunc {
+// maxHistoryLimit caps the page size of /api/history.
+const maxHistoryLimit = 100
+
+// HistoryHandler lists the caller's history, newest first. The query
+// parameters question_type, min_confidence, from and to filter it, limit and
+// offset page it, and X-Total-Count carries the number of matching entries.
+func HistoryHandler(repo *db.HistoryRepo) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
-		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
-		if limit <= 0 {
-			limit = 20
+		filter, err := parseHistoryFilter(r.URL.Query())
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
 		}
 
-		history, err := db.ListHistory(r.Context(), q, limit, offset)
+		history, total, err := repo.List(r.Context(), auth.UserFrom(r.Context()), filter)
 		if err != nil {
 			writeError(w, http.StatusInternalServerError, err.Error())
 			return
 		}
+		if history == nil {
+			history = []db.QueryHistory{}
+		}
 
 		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Total-Count", strconv.Itoa(total))
+		if next := filter.Offset + len(history); len(history) > 0 && next < total {
+			u := *r.URL
+			values := u.Query()
+			values.Set("limit", strconv.Itoa(filter.Limit))
+			values.Set("offset", strconv.Itoa(next))
+			u.RawQuery = values.Encode()
+			w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="next"`, u.RequestURI()))
+		}
 		json.NewEncoder(w).Encode(history)
 	}
 }
+
+// parseHistoryFilter reads the /api/history query parameters. from and to
+// take RFC 3339 timestamps or dates; a date in to includes the whole day.
+func parseHistoryFilter(values url.Values) (db.HistoryFilter, error) {
+	f := db.HistoryFilter{
+		QuestionType: values.Get("question_type"),
+		Limit:        20,
+	}
+	if v := values.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return f, errors.New("limit must be a positive integer")
+		}
+		f.Limit = min(n, maxHistoryLimit)
+	}
+	if v := values.Get("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return f, errors.New("offset must be a non-negative integer")
+		}
+		f.Offset = n
+	}
+	if v := values.Get("min_confidence"); v != "" {
+		c, err := strconv.ParseFloat(v, 64)
+		if err != nil || c < 0 || c > 1 {
+			return f, errors.New("min_confidence must be a number between 0 and 1")
+		}
+		f.MinConfidence = c
+	}
+	for _, p := range []struct {
+		name string
+		dst  *time.Time
+		day  time.Duration
+	}{{"from", &f.From, 0}, {"to", &f.To, 24 * time.Hour}} {
+		v := values.Get(p.name)
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			d, derr := time.Parse(time.DateOnly, v)
+			if derr != nil {
+				return f, fmt.Errorf("%s must be an RFC 3339 timestamp or a YYYY-MM-DD date", p.name)
+			}
+			t = d.Add(p.day)
+		}
+		*p.dst = t
+	}
+	if !f.From.IsZero() && !f.To.IsZero() && !f.From.Before(f.To) {
+		return f, errors.New("from must be before to")
+	}
+	return f, nil
+}
+
+// ReplayHistoryHandler re-runs a history entry's SQL and reports whether the
+// result has drifted since it was first asked. With ?mode=question it asks
+// the question again instead, and also reports how the SQL changed.
+func ReplayHistoryHandler(p *pipeline.Pipeline) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		mode := r.URL.Query().Get("mode")
+		if mode == "" {
+			mode = pipeline.ReplayModeSQL
+		}
+		if mode != pipeline.ReplayModeSQL && mode != pipeline.ReplayModeQuestion {
+			writeError(w, http.StatusBadRequest, "mode must be sql or question")
+			return
+		}
+
+		h, err := db.NewHistoryRepo(p.DB).Get(r.Context(), auth.UserFrom(r.Context()), chi.URLParam(r, "id"))
+		if errors.Is(err, db.ErrHistoryNotFound) {
+			writeError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		if mode == pipeline.ReplayModeQuestion {
+			result, err := p.Reask(r.Context(), h)
+			if err != nil {
+				if !writeGuardrailError(w, err) {
+					writeError(w, http.StatusInternalServerError, err.Error())
+				}
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(result)
+			return
+		}
+
+		result, err := p.Replay(r.Context(), h)
+		if errors.Is(err, pipeline.ErrReplayRejected) {
+			writeError(w, http.StatusUnprocessableEntity, err.Error())
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(result)
+	}
+}
+
+// ExportHistoryHandler downloads the result of a history entry as CSV or,
+// with ?format=xlsx, an Excel workbook. The rows come from the result cache
+// when it still holds them, otherwise the stored SQL runs again.
+func ExportHistoryHandler(p *pipeline.Pipeline) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		format := r.URL.Query().Get("format")
+		if format == "" {
+			format = export.FormatCSV
+		}
+		contentType := export.ContentType(format)
+		if contentType == "" {
+			writeError(w, http.StatusBadRequest, "format must be csv or xlsx")
+			return
+		}
+
+		h, err := db.NewHistoryRepo(p.DB).Get(r.Context(), auth.UserFrom(r.Context()), chi.URLParam(r, "id"))
+		if errors.Is(err, db.ErrHistoryNotFound) {
+			writeError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		err = p.Export(r.Context(), h, format, func(filename string) io.Writer {
+			w.Header().Set("Content-Type", contentType)
+			w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+			return w
+		})
+		switch {
+		case err == nil:
+		case w.Header().Get("Content-Disposition") != "":
+			// The download has started; the span records the failure.
+		case errors.Is(err, pipeline.ErrReplayRejected):
+			writeError(w, http.StatusUnprocessableEntity, err.Error())
+		case errors.Is(err, db.ErrUnavailable):
+			writeError(w, http.StatusServiceUnavailable, err.Error())
+		default:
+			writeError(w, http.StatusInternalServerError, err.Error())
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package routes
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseHistoryFilter(t *testing.T) {
+	f, err := parseHistoryFilter(url.Values{
+		"question_type":  {"trend"},
+		"min_confidence": {"0.8"},
+		"from":           {"2026-03-01"},
+		"to":             {"2026-03-31"},
+		"limit":          {"500"},
+		"offset":         {"40"},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "trend", f.QuestionType)
+	assert.Equal(t, 0.8, f.MinConfidence)
+	assert.Equal(t, time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), f.From)
+	// A date in to includes that whole day.
+	assert.Equal(t, time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC), f.To)
+	assert.Equal(t, maxHistoryLimit, f.Limit)
+	assert.Equal(t, 40, f.Offset)
+}
+
+func TestParseHistoryFilterDefaults(t *testing.T) {
+	f, err := parseHistoryFilter(url.Values{"to": {"2026-03-01T12:00:00Z"}})
+	require.NoError(t, err)
+
+	assert.Equal(t, 20, f.Limit)
+	assert.Zero(t, f.Offset)
+	assert.True(t, f.From.IsZero())
+	assert.Equal(t, time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC), f.To)
+}
+
+func TestParseHistoryFilterRejectsInvalid(t *testing.T) {
+	for name, values := range map[string]url.Values{
+		"limit":          {"limit": {"0"}},
+		"offset":         {"offset": {"-1"}},
+		"min_confidence": {"min_confidence": {"1.5"}},
+		"from":           {"from": {"last week"}},
+		"range":          {"from": {"2026-03-02"}, "to": {"2026-03-01"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := parseHistoryFilter(values)
+			assert.Error(t, err)
+		})
+	}
+}
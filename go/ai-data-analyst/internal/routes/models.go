@@ -0,0 +1,150 @@
+package routes
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"ai-data-analyst/internal/config"
+	"ai-data-analyst/internal/llm"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type ModelsResponse struct {
+	Models  []llm.OllamaModel `json:"models"`
+	Missing []string          `json:"missing"`
+	Active  ActiveModels      `json:"active"`
+}
+
+// ActiveModels are the models the pipeline calls, as set by LLM_MODEL_* or
+// changed at runtime.
+type ActiveModels struct {
+	Capable string `json:"model_capable"`
+	Fast    string `json:"model_fast"`
+}
+
+type PullRequest struct {
+	Model string `json:"model"`
+}
+
+func ModelsHandler(admin *llm.OllamaAdmin, store *config.RuntimeStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		models, err := admin.ListModels(r.Context())
+		if err != nil {
+			writeError(w, http.StatusBadGateway, err.Error())
+			return
+		}
+		missing, _ := admin.Check(r.Context())
+
+		w.Header().Set("Content-Type", "application/json")
+		rt := store.Get()
+		json.NewEncoder(w).Encode(ModelsResponse{
+			Models:  models,
+			Missing: missing,
+			Active:  ActiveModels{Capable: rt.ModelCapable, Fast: rt.ModelFast},
+		})
+	}
+}
+
+// ModelHandler reports one installed model's size, parameter count and
+// quantization.
+func ModelHandler(admin *llm.OllamaAdmin) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		model, err := admin.Model(r.Context(), chi.URLParam(r, "name"))
+		if errors.Is(err, llm.ErrModelNotInstalled) {
+			writeError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusBadGateway, err.Error())
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(model)
+	}
+}
+
+// SwitchModelsHandler changes the models the pipeline calls, like a
+// model_capable or model_fast change through the admin config, after
+// checking that Ollama has them. A model that is not installed is refused
+// with 409 rather than putting /api/ask into degraded mode.
+func SwitchModelsHandler(admin *llm.OllamaAdmin, store *config.RuntimeStore, onChange func(config.Runtime)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req ActiveModels
+		dec := json.NewDecoder(r.Body)
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+			return
+		}
+		if req.Capable == "" && req.Fast == "" {
+			writeError(w, http.StatusBadRequest, "model_capable or model_fast is required")
+			return
+		}
+
+		var patch config.RuntimePatch
+		var names []string
+		if req.Capable != "" {
+			patch.ModelCapable = &req.Capable
+			names = append(names, req.Capable)
+		}
+		if req.Fast != "" {
+			patch.ModelFast = &req.Fast
+			names = append(names, req.Fast)
+		}
+		missing, err := admin.NotInstalled(r.Context(), names...)
+		if err != nil {
+			writeError(w, http.StatusBadGateway, err.Error())
+			return
+		}
+		if len(missing) > 0 {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(map[string]any{
+				"error":   "models are not installed",
+				"missing": missing,
+				"hint":    "POST /api/models/pull with {\"model\": \"<name>\"}",
+			})
+			return
+		}
+
+		updateRuntime(w, r, store, patch, onChange)
+	}
+}
+
+// PullModelHandler relays Ollama's pull progress to the client as NDJSON,
+// flushing after every line so callers can render a progress bar.
+func PullModelHandler(admin *llm.OllamaAdmin) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req PullRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+		if req.Model == "" {
+			writeError(w, http.StatusBadRequest, "model is required")
+			return
+		}
+
+		// Pulls outlive the server's write timeout.
+		rc := http.NewResponseController(w)
+		_ = rc.SetWriteDeadline(time.Time{})
+
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		enc := json.NewEncoder(w)
+		err := admin.Pull(r.Context(), req.Model, func(p llm.PullProgress) error {
+			if err := enc.Encode(p); err != nil {
+				return err
+			}
+			return rc.Flush()
+		})
+		if err != nil {
+			enc.Encode(llm.PullProgress{Status: "error", Error: err.Error()})
+			return
+		}
+		enc.Encode(llm.PullProgress{Status: "success"})
+	}
+}
@@ -0,0 +1,56 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"ai-data-analyst/internal/config"
+	"ai-data-analyst/internal/llm"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newFakeOllamaTags(t *testing.T, installed ...string) *llm.OllamaAdmin {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		models := make([]llm.OllamaModel, 0, len(installed))
+		for _, name := range installed {
+			models = append(models, llm.OllamaModel{Name: name})
+		}
+		json.NewEncoder(w).Encode(map[string]any{"models": models})
+	}))
+	t.Cleanup(srv.Close)
+	return llm.NewOllamaAdmin(srv.URL)
+}
+
+func TestSwitchModelsHandler(t *testing.T) {
+	admin := newFakeOllamaTags(t, "llama3.2:latest", "qwen2.5-coder:7b")
+	store := config.NewRuntimeStore(&config.Config{LLMModelCapable: "qwen2.5-coder:7b", LLMModelFast: "qwen2.5-coder:7b"})
+	var changed *config.Runtime
+	handler := SwitchModelsHandler(admin, store, func(rt config.Runtime) { changed = &rt })
+
+	switchTo := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler(w, httptest.NewRequest(http.MethodPut, "/api/models/active", strings.NewReader(body)))
+		return w
+	}
+
+	w := switchTo(`{"model_fast": "mistral"}`)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), `"missing":["mistral"]`)
+	assert.Nil(t, changed)
+
+	w = switchTo(`{}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = switchTo(`{"model_fast": "llama3.2"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NotNil(t, changed)
+	assert.Equal(t, "llama3.2", changed.ModelFast)
+	assert.Equal(t, "qwen2.5-coder:7b", changed.ModelCapable)
+	assert.Equal(t, int64(2), store.Get().Version)
+}
@@ -0,0 +1,64 @@
+package routes
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"ai-data-analyst/internal/auth"
+	"ai-data-analyst/internal/pipeline"
+
+	"github.com/go-chi/chi/v5"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type PromptsResponse struct {
+	Prompts []pipeline.PromptInfo `json:"prompts"`
+}
+
+type ActivatePromptRequest struct {
+	Version string `json:"version"`
+}
+
+func PromptsHandler(registry *pipeline.PromptRegistry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(PromptsResponse{Prompts: registry.List()})
+	}
+}
+
+// ActivatePromptHandler switches the active version of a prompt. Like config
+// changes, every activation is written to the server log and as an event on
+// the request span.
+func ActivatePromptHandler(registry *pipeline.PromptRegistry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req ActivatePromptRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Version == "" {
+			writeError(w, http.StatusBadRequest, "version is required")
+			return
+		}
+
+		name := chi.URLParam(r, "name")
+		user := auth.UserFrom(r.Context())
+		info, err := registry.Activate(r.Context(), name, req.Version, user)
+		if errors.Is(err, pipeline.ErrPromptNotFound) {
+			writeError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		log.Printf("prompt activated: user=%s %s -> %s", user, name, req.Version)
+		trace.SpanFromContext(r.Context()).AddEvent("prompt.activate", trace.WithAttributes(
+			attribute.String("gen_ai.prompt.name", name),
+			attribute.String("gen_ai.prompt.version", req.Version),
+		))
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(info)
+	}
+}
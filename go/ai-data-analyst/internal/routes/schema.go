@@ -1,33 +1,23 @@
 package routes
 
 import (
+	"encoding/json"
 	"net/http"
-	"os"
-	"path/filepath"
-	"runtime"
+
+	"ai-data-analyst/internal/db"
 )
 
-func SchemaHandler() http.HandlerFunc {
-	content := loadSchemaContext()
+// SchemaHandler describes the dataset tables as introspected from the live
+// database: columns, types, keys and estimated row counts.
+func SchemaHandler(cache *db.SchemaCache) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "text/plain")
-		w.Write([]byte(content))
-	}
-}
-
-func loadSchemaContext() string {
-	paths := []string{
-		"/app/data/schema-context.txt",
-	}
-	if _, filename, _, ok := runtime.Caller(0); ok {
-		dir := filepath.Dir(filename)
-		paths = append(paths, filepath.Join(dir, "..", "..", "data", "schema-context.txt"))
-	}
-	for _, p := range paths {
-		data, err := os.ReadFile(p)
-		if err == nil {
-			return string(data)
+		tables, err := cache.Get(r.Context())
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
 		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(tables)
 	}
-	return "Schema context not available"
 }
@@ -0,0 +1,170 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"ai-data-analyst/internal/auth"
+	"ai-data-analyst/internal/db"
+	"ai-data-analyst/internal/pipeline"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type CreateSessionRequest struct {
+	Title   string `json:"title"`
+	Sandbox bool   `json:"sandbox"`
+}
+
+type SessionResponse struct {
+	db.Session
+	Turns []db.SessionTurn `json:"turns"`
+}
+
+// CreateSessionHandler starts a session. Sandbox sessions are only accepted
+// when sandboxes is non-nil.
+func CreateSessionHandler(sessions *db.SessionRepo, sandboxes *pipeline.Sandboxes) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req CreateSessionRequest
+		// The body is optional; an untitled session is named by its first question.
+		if r.ContentLength != 0 {
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				writeError(w, http.StatusBadRequest, "invalid request body")
+				return
+			}
+		}
+
+		if req.Sandbox && sandboxes == nil {
+			writeError(w, http.StatusBadRequest, "sandbox sessions are not enabled")
+			return
+		}
+
+		session, err := sessions.Create(r.Context(), auth.UserFrom(r.Context()), req.Title, req.Sandbox)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(session)
+	}
+}
+
+func ListSessionsHandler(sessions *db.SessionRepo) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+
+		list, err := sessions.List(r.Context(), auth.UserFrom(r.Context()), limit, offset)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(list)
+	}
+}
+
+// GetSessionHandler returns a session with its turns, oldest first.
+func GetSessionHandler(sessions *db.SessionRepo) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		session, err := sessions.Get(r.Context(), auth.UserFrom(r.Context()), chi.URLParam(r, "id"))
+		if errors.Is(err, db.ErrSessionNotFound) {
+			writeError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		if limit <= 0 {
+			limit = 50
+		}
+		turns, err := sessions.ListTurns(r.Context(), session.ID, limit)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(SessionResponse{Session: *session, Turns: turns})
+	}
+}
+
+// DeleteSessionHandler deletes a session and drops its sandbox objects, if
+// it has any.
+func DeleteSessionHandler(sessions *db.SessionRepo, sandboxes *pipeline.Sandboxes) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := chi.URLParam(r, "id")
+		err := sessions.Delete(r.Context(), auth.UserFrom(r.Context()), id)
+		if errors.Is(err, db.ErrSessionNotFound) {
+			writeError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if sandboxes != nil {
+			sandboxes.Close(r.Context(), id, "session_end")
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// SessionAskHandler asks a follow-up question within a session. It accepts
+// the same body as /api/ask and also supports streaming.
+func SessionAskHandler(p *pipeline.Pipeline) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sessionID := chi.URLParam(r, "id")
+
+		// Check the session up front so a bad ID is a 404 even when streaming.
+		if _, err := db.NewSessionRepo(p.DB).Get(r.Context(), auth.UserFrom(r.Context()), sessionID); err != nil {
+			if errors.Is(err, db.ErrSessionNotFound) {
+				writeError(w, http.StatusNotFound, err.Error())
+			} else {
+				writeError(w, http.StatusInternalServerError, err.Error())
+			}
+			return
+		}
+
+		r = withAskOptions(r)
+		question, ok := decodeQuestion(w, r, p)
+		if !ok {
+			return
+		}
+
+		if wantsStream(r) {
+			streamAsk(w, r, func(ctx context.Context) (*pipeline.AskResult, error) {
+				return p.AskInSession(ctx, sessionID, question)
+			})
+			return
+		}
+
+		result, err := p.AskInSession(r.Context(), sessionID, question)
+		if errors.Is(err, db.ErrSessionNotFound) {
+			writeError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		if errors.Is(err, pipeline.ErrSandboxLimit) {
+			writeError(w, http.StatusServiceUnavailable, err.Error())
+			return
+		}
+		if writeBudgetError(w, err) || writeGuardrailError(w, err) {
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		writeAskResult(w, p, result)
+	}
+}
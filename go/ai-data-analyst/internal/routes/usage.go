@@ -0,0 +1,66 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"ai-data-analyst/internal/auth"
+	"ai-data-analyst/internal/db"
+)
+
+type UserUsageResponse struct {
+	*db.UsageSummary
+	TenantID string          `json:"tenant_id"`
+	Daily    []db.DailyUsage `json:"daily"`
+}
+
+func UsageHandler(q db.Querier) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		days, _ := strconv.Atoi(r.URL.Query().Get("days"))
+
+		summary, daily, err := db.UserUsage(r.Context(), q, auth.UserFrom(r.Context()), days)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(UserUsageResponse{
+			UsageSummary: summary,
+			TenantID:     auth.TenantFrom(r.Context()),
+			Daily:        daily,
+		})
+	}
+}
+
+func UsageByUserHandler(q db.Querier) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		usage, err := db.UsageByUser(r.Context(), q)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(usage)
+	}
+}
+
+// UsageByTenantHandler reports question, token and cost totals per tenant,
+// as recorded in query_history.
+func UsageByTenantHandler(q db.Querier) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		usage, err := db.UsageByTenant(r.Context(), q)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if usage == nil {
+			usage = []db.TenantUsage{}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(usage)
+	}
+}
@@ -0,0 +1,15 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/base-14/examples/go/pkg/buildinfo"
+)
+
+func VersionHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(buildinfo.Get())
+	}
+}
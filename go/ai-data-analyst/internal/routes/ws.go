@@ -0,0 +1,186 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"ai-data-analyst/internal/pipeline"
+	"ai-data-analyst/internal/telemetry"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/net/websocket"
+)
+
+// wsMaxMessageBytes caps an incoming chat message.
+const wsMaxMessageBytes = 64 << 10
+
+// WSMessage is a question sent over /ws. ID is echoed on every reply to it.
+// Traceparent, if set, makes the question's spans part of the client's
+// trace instead of the connection's.
+type WSMessage struct {
+	ID          string `json:"id,omitempty"`
+	Question    string `json:"question"`
+	SessionID   string `json:"session_id,omitempty"`
+	Traceparent string `json:"traceparent,omitempty"`
+}
+
+// WSReply is a frame sent over /ws: a "stage" or "token" event while the
+// question runs, then its "result" or an "error".
+type WSReply struct {
+	Type   string               `json:"type"`
+	ID     string               `json:"id,omitempty"`
+	Stage  *pipeline.StageEvent `json:"stage,omitempty"`
+	Token  *pipeline.TokenEvent `json:"token,omitempty"`
+	Result *pipeline.AskResult  `json:"result,omitempty"`
+	Error  string               `json:"error,omitempty"`
+}
+
+// Limiter rate limits questions; see middleware.RateLimiter.
+type Limiter interface {
+	Allow(r *http.Request) (ok bool, scope string, wait time.Duration)
+}
+
+// WSConfig tunes WSHandler. A nil Limiter or Metrics turns that off.
+type WSConfig struct {
+	Limiter     Limiter
+	Metrics     *telemetry.GenAIMetrics
+	IdleTimeout time.Duration
+}
+
+// WSHandler serves the chat protocol on /ws: each message is a question,
+// answered in order with the same stage and token events as a streamed
+// /api/ask, then the result. A question with a session_id is asked in that
+// session. The connection is traced as one "ws connection" span, with a
+// "ws message" span per question around its "pipeline ask".
+func WSHandler(p *pipeline.Pipeline, cfg WSConfig) http.Handler {
+	return websocket.Server{
+		// Any origin: browsers cannot send the API key header on a
+		// WebSocket, so a cross-site page gets no more than anonymous access.
+		Handshake: func(*websocket.Config, *http.Request) error { return nil },
+		Handler: func(ws *websocket.Conn) {
+			serveChat(ws, p.Tracer, cfg, p.Settings().MaxQuestionLength,
+				func(ctx context.Context, m WSMessage) (*pipeline.AskResult, error) {
+					if m.SessionID != "" {
+						return p.AskInSession(ctx, m.SessionID, m.Question)
+					}
+					return p.Ask(ctx, m.Question)
+				})
+		},
+	}
+}
+
+func serveChat(ws *websocket.Conn, tracer trace.Tracer, cfg WSConfig, maxLength int,
+	ask func(context.Context, WSMessage) (*pipeline.AskResult, error)) {
+	defer ws.Close()
+	ws.MaxPayloadBytes = wsMaxMessageBytes
+	// The hijacked connection keeps the server's request deadlines; a chat
+	// outlives them.
+	_ = ws.SetDeadline(time.Time{})
+
+	r := ws.Request()
+	ctx, span := tracer.Start(r.Context(), "ws connection")
+	defer span.End()
+
+	if cfg.Metrics != nil {
+		cfg.Metrics.WSConnections.Add(ctx, 1)
+		defer cfg.Metrics.WSConnections.Add(context.WithoutCancel(ctx), -1)
+	}
+	count := func(outcome string) {
+		if cfg.Metrics != nil {
+			cfg.Metrics.WSMessages.Add(ctx, 1,
+				metric.WithAttributes(attribute.String("nlsql.ws.outcome", outcome)))
+		}
+	}
+
+	var messages int
+	defer func() { span.SetAttributes(attribute.Int("nlsql.ws.messages", messages)) }()
+
+	for {
+		if cfg.IdleTimeout > 0 {
+			_ = ws.SetReadDeadline(time.Now().Add(cfg.IdleTimeout))
+		}
+		var m WSMessage
+		if err := websocket.JSON.Receive(ws, &m); err != nil {
+			var syntaxErr *json.SyntaxError
+			var typeErr *json.UnmarshalTypeError
+			if errors.As(err, &syntaxErr) || errors.As(err, &typeErr) {
+				count("invalid")
+				websocket.JSON.Send(ws, WSReply{Type: "error", Error: "invalid message: " + err.Error()})
+				continue
+			}
+			// Closed by the client, idle too long, or too large to read.
+			return
+		}
+		messages++
+
+		switch {
+		case m.Question == "":
+			count("invalid")
+			websocket.JSON.Send(ws, WSReply{Type: "error", ID: m.ID, Error: "question is required"})
+			continue
+		case len(m.Question) > maxLength:
+			count("invalid")
+			websocket.JSON.Send(ws, WSReply{Type: "error", ID: m.ID, Error: fmt.Sprintf("question exceeds %d characters", maxLength)})
+			continue
+		}
+		if cfg.Limiter != nil {
+			if ok, scope, wait := cfg.Limiter.Allow(r); !ok {
+				count("throttled")
+				websocket.JSON.Send(ws, WSReply{Type: "error", ID: m.ID,
+					Error: fmt.Sprintf("rate limit exceeded (%s), retry in %s", scope, wait.Round(time.Second))})
+				continue
+			}
+		}
+
+		reply := chatMessage(ctx, ws, tracer, m, ask)
+		if reply.Type == "error" {
+			count("failed")
+		} else {
+			count("answered")
+		}
+		websocket.JSON.Send(ws, reply)
+	}
+}
+
+// chatMessage answers one question, sending its stage and token events as
+// they happen, and returns the final reply.
+func chatMessage(connCtx context.Context, ws *websocket.Conn, tracer trace.Tracer, m WSMessage,
+	ask func(context.Context, WSMessage) (*pipeline.AskResult, error)) WSReply {
+	// A client traceparent parents the question, with a link back to the
+	// connection so the two traces stay connected.
+	ctx := connCtx
+	var opts []trace.SpanStartOption
+	if m.Traceparent != "" {
+		ctx = otel.GetTextMapPropagator().Extract(connCtx,
+			propagation.MapCarrier{"traceparent": m.Traceparent})
+		opts = append(opts, trace.WithLinks(trace.LinkFromContext(connCtx)))
+	}
+	ctx, span := tracer.Start(ctx, "ws message", opts...)
+	defer span.End()
+	if m.SessionID != "" {
+		span.SetAttributes(attribute.String("session.id", m.SessionID))
+	}
+
+	ctx = pipeline.WithStageObserver(ctx, func(e pipeline.StageEvent) {
+		websocket.JSON.Send(ws, WSReply{Type: "stage", ID: m.ID, Stage: &e})
+	})
+	ctx = pipeline.WithTokenObserver(ctx, func(e pipeline.TokenEvent) {
+		websocket.JSON.Send(ws, WSReply{Type: "token", ID: m.ID, Token: &e})
+	})
+
+	result, err := ask(ctx, m)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return WSReply{Type: "error", ID: m.ID, Error: err.Error()}
+	}
+	return WSReply{Type: "result", ID: m.ID, Result: result}
+}
@@ -0,0 +1,111 @@
+package routes
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"ai-data-analyst/internal/pipeline"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace/noop"
+	"golang.org/x/net/websocket"
+)
+
+type denyAfter struct{ n int }
+
+func (d *denyAfter) Allow(*http.Request) (bool, string, time.Duration) {
+	if d.n == 0 {
+		return false, "ip", 2 * time.Second
+	}
+	d.n--
+	return true, "", 0
+}
+
+func dialChat(t *testing.T, cfg WSConfig, ask func(context.Context, WSMessage) (*pipeline.AskResult, error)) *websocket.Conn {
+	t.Helper()
+	srv := httptest.NewServer(websocket.Server{
+		Handshake: func(*websocket.Config, *http.Request) error { return nil },
+		Handler: func(ws *websocket.Conn) {
+			serveChat(ws, noop.NewTracerProvider().Tracer("test"), cfg, 50, ask)
+		},
+	})
+	t.Cleanup(srv.Close)
+
+	ws, err := websocket.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), "", srv.URL)
+	require.NoError(t, err)
+	t.Cleanup(func() { ws.Close() })
+	return ws
+}
+
+func receive(t *testing.T, ws *websocket.Conn) WSReply {
+	t.Helper()
+	var reply WSReply
+	require.NoError(t, websocket.JSON.Receive(ws, &reply))
+	return reply
+}
+
+func TestChatAnswersQuestions(t *testing.T) {
+	ws := dialChat(t, WSConfig{}, func(_ context.Context, m WSMessage) (*pipeline.AskResult, error) {
+		if m.Question == "fail" {
+			return nil, errors.New("generate stage failed")
+		}
+		return &pipeline.AskResult{Question: m.Question, RowCount: 3}, nil
+	})
+
+	require.NoError(t, websocket.JSON.Send(ws, WSMessage{ID: "1", Question: "GDP of India"}))
+	reply := receive(t, ws)
+	assert.Equal(t, "result", reply.Type)
+	assert.Equal(t, "1", reply.ID)
+	require.NotNil(t, reply.Result)
+	assert.Equal(t, "GDP of India", reply.Result.Question)
+
+	// A failed question is reported and the connection stays open.
+	require.NoError(t, websocket.JSON.Send(ws, WSMessage{ID: "2", Question: "fail"}))
+	reply = receive(t, ws)
+	assert.Equal(t, "error", reply.Type)
+	assert.Equal(t, "2", reply.ID)
+	assert.Equal(t, "generate stage failed", reply.Error)
+
+	require.NoError(t, websocket.JSON.Send(ws, WSMessage{ID: "3", Question: "again"}))
+	assert.Equal(t, "result", receive(t, ws).Type)
+}
+
+func TestChatRejectsInvalidMessages(t *testing.T) {
+	ws := dialChat(t, WSConfig{}, func(context.Context, WSMessage) (*pipeline.AskResult, error) {
+		t.Fatal("invalid messages must not reach the pipeline")
+		return nil, nil
+	})
+
+	require.NoError(t, websocket.Message.Send(ws, "not json"))
+	reply := receive(t, ws)
+	assert.Equal(t, "error", reply.Type)
+	assert.Contains(t, reply.Error, "invalid message")
+
+	require.NoError(t, websocket.JSON.Send(ws, WSMessage{ID: "empty"}))
+	reply = receive(t, ws)
+	assert.Equal(t, "empty", reply.ID)
+	assert.Equal(t, "question is required", reply.Error)
+
+	require.NoError(t, websocket.JSON.Send(ws, WSMessage{ID: "long", Question: strings.Repeat("x", 51)}))
+	assert.Equal(t, "question exceeds 50 characters", receive(t, ws).Error)
+}
+
+func TestChatRateLimitsQuestions(t *testing.T) {
+	ws := dialChat(t, WSConfig{Limiter: &denyAfter{n: 1}}, func(_ context.Context, m WSMessage) (*pipeline.AskResult, error) {
+		return &pipeline.AskResult{Question: m.Question}, nil
+	})
+
+	require.NoError(t, websocket.JSON.Send(ws, WSMessage{ID: "1", Question: "first"}))
+	assert.Equal(t, "result", receive(t, ws).Type)
+
+	require.NoError(t, websocket.JSON.Send(ws, WSMessage{ID: "2", Question: "second"}))
+	reply := receive(t, ws)
+	assert.Equal(t, "error", reply.Type)
+	assert.Contains(t, reply.Error, "rate limit exceeded (ip)")
+}
@@ -1,30 +1,21 @@
-//go:build ignore
-// +build ignore
+package seed
 
-package main
-
-import (
-	"fmt"
-	"math"
-	"math/rand"
-	"os"
-	"strings"
-)
-
-type country struct {
+// Country is a row of the countries table.
+type Country struct {
 	Name, Code, Region, IncomeGroup string
 }
 
-type indicator struct {
+// Indicator is a row of the indicators table.
+type Indicator struct {
 	Name, Code, Category, Unit, Description string
 }
 
 type countryProfile struct {
-	GDPpc0    float64 // GDP per capita base (2003)
-	Pop0      float64 // Population base (2003)
+	GDPpc0    float64 // GDP per capita base (first year)
+	Pop0      float64 // Population base (first year)
 	LE0       float64 // Life expectancy base
 	CO2       float64 // CO2 per capita base
-	Internet0 float64 // Internet % base (2003)
+	Internet0 float64 // Internet % base (first year)
 	Unemp     float64 // Unemployment base
 	Growth    float64 // avg GDP growth
 	Urban0    float64 // Urban population %
@@ -32,7 +23,9 @@ type countryProfile struct {
 	Forest0   float64 // Forest %
 }
 
-var countries = []country{
+// countries is ordered roughly by economic weight, so a smaller dataset
+// keeps the countries questions most often name.
+var countries = []Country{
 	{"United States", "USA", "North America", "High income"},
 	{"China", "CHN", "East Asia & Pacific", "Upper middle income"},
 	{"India", "IND", "South Asia", "Lower middle income"},
@@ -85,7 +78,7 @@ var countries = []country{
 	{"New Zealand", "NZL", "East Asia & Pacific", "High income"},
 }
 
-var indicators = []indicator{
+var indicators = []Indicator{
 	{"GDP growth (annual %)", "NY.GDP.MKTP.KD.ZG", "Economic", "%", "Annual percentage growth rate of GDP at market prices based on constant local currency"},
 	{"GDP per capita (current US$)", "NY.GDP.PCAP.CD", "Economic", "USD", "GDP per capita is gross domestic product divided by midyear population"},
 	{"Population, total", "SP.POP.TOTL", "Social", "persons", "Total population based on the de facto definition of population"},
@@ -108,6 +101,8 @@ var indicators = []indicator{
 	{"Military expenditure (% of GDP)", "MS.MIL.XPND.GD.ZS", "Economic", "%", "Military expenditures data from SIPRI"},
 }
 
+// profiles are each country's starting values, at the first year of the
+// dataset, and its average growth.
 var profiles = map[string]countryProfile{
 	"USA": {39000, 290e6, 77.0, 19.5, 62, 5.5, 2.2, 79, 12.0, 33},
 	"CHN": {1300, 1290e6, 72.0, 3.5, 6, 4.5, 9.5, 40, 28.0, 21},
@@ -160,243 +155,3 @@ var profiles = map[string]countryProfile{
 	"CZE": {10000, 10.2e6, 75.5, 11.5, 40, 4.0, 3.0, 74, 8.0, 34},
 	"NZL": {22000, 4.1e6, 79.0, 8.5, 62, 4.0, 3.5, 86, 10.0, 31},
 }
-
-func main() {
-	rng := rand.New(rand.NewSource(42))
-	var b strings.Builder
-
-	b.WriteString("-- World Bank Seed Data\n-- Generated for ai-data-analyst example\n-- 50 countries, 20 indicators, years 2003-2023\n\n")
-
-	// Countries
-	b.WriteString("-- Countries\n")
-	for _, c := range countries {
-		fmt.Fprintf(&b, "INSERT INTO countries (name, code, region, income_group) VALUES ('%s', '%s', '%s', '%s') ON CONFLICT DO NOTHING;\n",
-			escape(c.Name), c.Code, c.Region, c.IncomeGroup)
-	}
-
-	b.WriteString("\n-- Indicators\n")
-	for _, i := range indicators {
-		fmt.Fprintf(&b, "INSERT INTO indicators (name, code, category, unit, description) VALUES ('%s', '%s', '%s', '%s', '%s') ON CONFLICT DO NOTHING;\n",
-			escape(i.Name), i.Code, i.Category, i.Unit, escape(i.Description))
-	}
-
-	b.WriteString("\n-- Indicator values\n")
-	b.WriteString("INSERT INTO indicator_values (country_id, indicator_id, year, value)\nSELECT c.id, i.id, v.year, v.value::numeric\nFROM (VALUES\n")
-
-	first := true
-	for _, c := range countries {
-		p := profiles[c.Code]
-		for year := 2003; year <= 2023; year++ {
-			yr := year - 2003
-			yrf := float64(yr)
-
-			// GDP growth
-			growth := p.Growth + rng.Float64()*2 - 1
-			if year == 2009 {
-				growth = -3.5 + rng.Float64()*3
-			}
-			if year == 2020 {
-				growth = -4.0 + rng.Float64()*3
-			}
-			if year == 2021 {
-				growth = 4.0 + rng.Float64()*4
-			}
-			writeVal(&b, &first, rng, c.Code, "NY.GDP.MKTP.KD.ZG", year, round2(growth))
-
-			// GDP per capita
-			gdppc := p.GDPpc0 * math.Pow(1+p.Growth/100, yrf)
-			gdppc *= 1 + (rng.Float64()*0.06 - 0.03)
-			writeVal(&b, &first, rng, c.Code, "NY.GDP.PCAP.CD", year, round2(gdppc))
-
-			// Population
-			popGrowth := 0.01 + rng.Float64()*0.005
-			if c.Code == "JPN" || c.Code == "DEU" || c.Code == "ITA" || c.Code == "UKR" {
-				popGrowth = -0.002
-			}
-			pop := p.Pop0 * math.Pow(1+popGrowth, yrf)
-			writeVal(&b, &first, rng, c.Code, "SP.POP.TOTL", year, round0(pop))
-
-			// Life expectancy
-			le := p.LE0 + yrf*0.15 + rng.Float64()*0.5 - 0.25
-			if year == 2020 {
-				le -= 1.0 + rng.Float64()
-			}
-			writeVal(&b, &first, rng, c.Code, "SP.DYN.LE00.IN", year, round2(le))
-
-			// Death rate
-			dr := 8.0 + rng.Float64()*2
-			if p.LE0 < 60 {
-				dr = 12.0 + rng.Float64()*3
-			}
-			if p.LE0 > 78 {
-				dr = 7.0 + rng.Float64()*2
-			}
-			dr -= yrf * 0.05
-			if year == 2020 {
-				dr += 1.5
-			}
-			writeVal(&b, &first, rng, c.Code, "SP.DYN.CDRT.IN", year, round2(clamp(dr, 2, 20)))
-
-			// Education expenditure
-			edu := 4.0 + rng.Float64()*2.5
-			if c.IncomeGroup == "High income" {
-				edu = 4.5 + rng.Float64()*2
-			}
-			writeVal(&b, &first, rng, c.Code, "SE.XPD.TOTL.GD.ZS", year, round2(edu))
-
-			// Health expenditure
-			health := 5.0 + rng.Float64()*3
-			if c.IncomeGroup == "High income" {
-				health = 7.0 + rng.Float64()*5
-			}
-			if c.IncomeGroup == "Low income" {
-				health = 3.0 + rng.Float64()*2
-			}
-			writeVal(&b, &first, rng, c.Code, "SH.XPD.CHEX.GD.ZS", year, round2(health))
-
-			// CO2 emissions
-			co2 := p.CO2*(1+yrf*0.01) + rng.Float64()*0.5 - 0.25
-			if c.IncomeGroup == "High income" && year > 2015 {
-				co2 *= 0.97
-			}
-			writeVal(&b, &first, rng, c.Code, "EN.ATM.CO2E.PC", year, round2(clamp(co2, 0.05, 30)))
-
-			// Electric power consumption
-			elec := 1000.0 + p.GDPpc0*0.25 + yrf*100
-			elec += rng.Float64()*200 - 100
-			writeVal(&b, &first, rng, c.Code, "EG.USE.ELEC.KH.PC", year, round0(clamp(elec, 50, 25000)))
-
-			// Internet usage
-			inet := p.Internet0 + yrf*3.5
-			if inet > 98 {
-				inet = 95 + rng.Float64()*4
-			}
-			inet += rng.Float64()*2 - 1
-			writeVal(&b, &first, rng, c.Code, "IT.NET.USER.ZS", year, round2(clamp(inet, 0.1, 99.5)))
-
-			// Unemployment
-			unemp := p.Unemp + rng.Float64()*1.5 - 0.75
-			if year == 2009 {
-				unemp += 2.5
-			}
-			if year == 2020 {
-				unemp += 3.0
-			}
-			if year == 2021 {
-				unemp -= 1.0
-			}
-			writeVal(&b, &first, rng, c.Code, "SL.UEM.TOTL.ZS", year, round2(clamp(unemp, 1, 35)))
-
-			// Inflation
-			infl := 2.5 + rng.Float64()*2
-			if c.IncomeGroup == "Lower middle income" {
-				infl = 5 + rng.Float64()*4
-			}
-			if c.IncomeGroup == "Low income" {
-				infl = 6 + rng.Float64()*5
-			}
-			if year == 2022 {
-				infl += 4.0
-			}
-			writeVal(&b, &first, rng, c.Code, "FP.CPI.TOTL.ZG", year, round2(infl))
-
-			// Trade % GDP
-			trade := 50 + rng.Float64()*30
-			if c.Code == "SGP" || c.Code == "ARE" || c.Code == "BEL" || c.Code == "NLD" {
-				trade = 120 + rng.Float64()*80
-			}
-			if c.Code == "USA" || c.Code == "BRA" || c.Code == "JPN" {
-				trade = 25 + rng.Float64()*15
-			}
-			writeVal(&b, &first, rng, c.Code, "NE.TRD.GNFS.ZS", year, round2(trade))
-
-			// FDI % GDP
-			fdi := 1.5 + rng.Float64()*3
-			if c.Code == "SGP" || c.Code == "ARE" {
-				fdi = 5 + rng.Float64()*10
-			}
-			writeVal(&b, &first, rng, c.Code, "BX.KLT.DINV.WD.GD.ZS", year, round2(fdi))
-
-			// Government debt % GDP
-			debt := 40 + rng.Float64()*30
-			if c.Code == "JPN" {
-				debt = 170 + yrf*4
-			}
-			if c.Code == "USA" {
-				debt = 60 + yrf*3
-			}
-			if year >= 2020 {
-				debt += 15
-			}
-			writeVal(&b, &first, rng, c.Code, "GC.DOD.TOTL.GD.ZS", year, round2(debt))
-
-			// Poverty
-			pov := p.Poverty0*math.Pow(0.96, yrf) + rng.Float64()*2 - 1
-			if year == 2020 {
-				pov += 3
-			}
-			writeVal(&b, &first, rng, c.Code, "SI.POV.NAHC", year, round2(clamp(pov, 0.5, 80)))
-
-			// Forest area
-			forest := p.Forest0 - yrf*0.1 + rng.Float64()*0.3 - 0.15
-			writeVal(&b, &first, rng, c.Code, "AG.LND.FRST.ZS", year, round2(clamp(forest, 0, 90)))
-
-			// Urban population
-			urban := p.Urban0 + yrf*0.4 + rng.Float64()*0.3
-			writeVal(&b, &first, rng, c.Code, "SP.URB.TOTL.IN.ZS", year, round2(clamp(urban, 10, 100)))
-
-			// Gross savings
-			savings := 20 + rng.Float64()*10
-			if c.Code == "CHN" || c.Code == "SGP" {
-				savings = 35 + rng.Float64()*15
-			}
-			if c.Code == "SAU" || c.Code == "NOR" {
-				savings = 30 + rng.Float64()*15
-			}
-			writeVal(&b, &first, rng, c.Code, "NY.GNS.ICTR.ZS", year, round2(savings))
-
-			// Military expenditure
-			mil := 1.5 + rng.Float64()*1
-			if c.Code == "USA" || c.Code == "SAU" || c.Code == "ISR" {
-				mil = 3.0 + rng.Float64()*2
-			}
-			if c.Code == "RUS" {
-				mil = 3.5 + rng.Float64()*1.5
-			}
-			writeVal(&b, &first, rng, c.Code, "MS.MIL.XPND.GD.ZS", year, round2(mil))
-		}
-	}
-
-	b.WriteString("\n) AS v(country_code, indicator_code, year, value)\n")
-	b.WriteString("JOIN countries c ON c.code = v.country_code\n")
-	b.WriteString("JOIN indicators i ON i.code = v.indicator_code\n")
-	b.WriteString("ON CONFLICT DO NOTHING;\n")
-
-	os.WriteFile("db/seed.sql", []byte(b.String()), 0644)
-	fmt.Printf("Generated seed.sql: %d bytes\n", b.Len())
-}
-
-func writeVal(b *strings.Builder, first *bool, rng *rand.Rand, countryCode, indicatorCode string, year int, value float64) {
-	// ~5% chance of NULL
-	if rng.Float64() < 0.05 {
-		return
-	}
-	if !*first {
-		b.WriteString(",\n")
-	}
-	*first = false
-	fmt.Fprintf(b, "  ('%s', '%s', %d, '%.6f')", countryCode, indicatorCode, year, value)
-}
-
-func round2(v float64) float64 { return math.Round(v*100) / 100 }
-func round0(v float64) float64 { return math.Round(v) }
-func clamp(v, min, max float64) float64 {
-	if v < min {
-		return min
-	}
-	if v > max {
-		return max
-	}
-	return v
-}
-func escape(s string) string { return strings.ReplaceAll(s, "'", "''") }
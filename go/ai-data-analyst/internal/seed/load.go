@@ -0,0 +1,90 @@
+package seed
+
+import (
+	"context"
+	"fmt"
+
+	"ai-data-analyst/internal/db"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// LoadStats counts the rows Load wrote.
+type LoadStats struct {
+	Countries  int64 `json:"countries"`
+	Indicators int64 `json:"indicators"`
+	Values     int64 `json:"values"`
+}
+
+// Load replaces the dataset tables with d in one transaction, so questions
+// asked meanwhile see either the old rows or the new ones, never a mix.
+// The tables are analyzed before commit so /api/schema's row estimates
+// match the new size.
+func Load(ctx context.Context, b db.Beginner, d *Dataset) (LoadStats, error) {
+	var stats LoadStats
+	err := pgx.BeginFunc(ctx, b, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, `TRUNCATE indicator_values, indicators, countries RESTART IDENTITY`); err != nil {
+			return err
+		}
+
+		var err error
+		stats.Countries, err = tx.CopyFrom(ctx, pgx.Identifier{"countries"},
+			[]string{"name", "code", "region", "income_group"},
+			pgx.CopyFromSlice(len(d.Countries), func(i int) ([]any, error) {
+				c := d.Countries[i]
+				return []any{c.Name, c.Code, c.Region, c.IncomeGroup}, nil
+			}))
+		if err != nil {
+			return fmt.Errorf("countries: %w", err)
+		}
+		stats.Indicators, err = tx.CopyFrom(ctx, pgx.Identifier{"indicators"},
+			[]string{"name", "code", "category", "unit", "description"},
+			pgx.CopyFromSlice(len(d.Indicators), func(i int) ([]any, error) {
+				ind := d.Indicators[i]
+				return []any{ind.Name, ind.Code, ind.Category, ind.Unit, ind.Description}, nil
+			}))
+		if err != nil {
+			return fmt.Errorf("indicators: %w", err)
+		}
+
+		countryIDs, err := idsByCode(ctx, tx, `SELECT id, code FROM countries`)
+		if err != nil {
+			return err
+		}
+		indicatorIDs, err := idsByCode(ctx, tx, `SELECT id, code FROM indicators`)
+		if err != nil {
+			return err
+		}
+		stats.Values, err = tx.CopyFrom(ctx, pgx.Identifier{"indicator_values"},
+			[]string{"country_id", "indicator_id", "year", "value"},
+			pgx.CopyFromSlice(len(d.Values), func(i int) ([]any, error) {
+				v := d.Values[i]
+				return []any{countryIDs[v.CountryCode], indicatorIDs[v.IndicatorCode], v.Year, v.Value}, nil
+			}))
+		if err != nil {
+			return fmt.Errorf("indicator_values: %w", err)
+		}
+
+		_, err = tx.Exec(ctx, `ANALYZE countries, indicators, indicator_values`)
+		return err
+	})
+	if err != nil {
+		return LoadStats{}, err
+	}
+	return stats, nil
+}
+
+func idsByCode(ctx context.Context, tx pgx.Tx, query string) (map[string]int, error) {
+	rows, err := tx.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	ids := map[string]int{}
+	var id int
+	var code string
+	_, err = pgx.ForEachRow(rows, []any{&id, &code}, func() error {
+		ids[code] = id
+		return nil
+	})
+	return ids, err
+}
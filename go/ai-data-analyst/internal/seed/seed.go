@@ -0,0 +1,317 @@
+package seed
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"strings"
+)
+
+// Config sizes a generated dataset. The same Config always generates the
+// same rows.
+type Config struct {
+	Countries int   `json:"countries"`
+	FirstYear int   `json:"first_year"`
+	LastYear  int   `json:"last_year"`
+	Seed      int64 `json:"seed"`
+}
+
+// DefaultConfig is every country, 2003 to 2023, seed 42.
+func DefaultConfig() Config {
+	return Config{Countries: len(countries), FirstYear: 2003, LastYear: 2023, Seed: 42}
+}
+
+// MaxYears bounds the year range of a Config.
+const MaxYears = 100
+
+func (c Config) Validate() error {
+	if c.Countries < 1 || c.Countries > len(countries) {
+		return fmt.Errorf("countries must be between 1 and %d", len(countries))
+	}
+	if c.FirstYear < 1900 || c.LastYear > 2100 {
+		return errors.New("years must be between 1900 and 2100")
+	}
+	if c.LastYear < c.FirstYear {
+		return errors.New("last_year must not be before first_year")
+	}
+	if c.LastYear-c.FirstYear+1 > MaxYears {
+		return fmt.Errorf("the year range must span at most %d years", MaxYears)
+	}
+	return nil
+}
+
+// Value is a row of the indicator_values table, keyed by codes.
+type Value struct {
+	CountryCode   string
+	IndicatorCode string
+	Year          int
+	Value         float64
+}
+
+// Dataset is a generated World Bank style dataset.
+type Dataset struct {
+	Config     Config
+	Countries  []Country
+	Indicators []Indicator
+	Values     []Value
+}
+
+// Generate builds a synthetic dataset from per-country profiles: smooth
+// trends with noise, shocks in 2009, 2020 to 2022 where the range covers
+// them, and about 5% of values missing.
+func Generate(cfg Config) (*Dataset, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	rng := rand.New(rand.NewSource(cfg.Seed))
+	d := &Dataset{
+		Config:     cfg,
+		Countries:  countries[:cfg.Countries],
+		Indicators: indicators,
+	}
+	add := func(countryCode, indicatorCode string, year int, value float64) {
+		if rng.Float64() < 0.05 {
+			return
+		}
+		d.Values = append(d.Values, Value{countryCode, indicatorCode, year, value})
+	}
+
+	for _, c := range d.Countries {
+		p := profiles[c.Code]
+		for year := cfg.FirstYear; year <= cfg.LastYear; year++ {
+			yr := year - cfg.FirstYear
+			yrf := float64(yr)
+
+			// GDP growth
+			growth := p.Growth + rng.Float64()*2 - 1
+			if year == 2009 {
+				growth = -3.5 + rng.Float64()*3
+			}
+			if year == 2020 {
+				growth = -4.0 + rng.Float64()*3
+			}
+			if year == 2021 {
+				growth = 4.0 + rng.Float64()*4
+			}
+			add(c.Code, "NY.GDP.MKTP.KD.ZG", year, round2(growth))
+
+			// GDP per capita
+			gdppc := p.GDPpc0 * math.Pow(1+p.Growth/100, yrf)
+			gdppc *= 1 + (rng.Float64()*0.06 - 0.03)
+			add(c.Code, "NY.GDP.PCAP.CD", year, round2(gdppc))
+
+			// Population
+			popGrowth := 0.01 + rng.Float64()*0.005
+			if c.Code == "JPN" || c.Code == "DEU" || c.Code == "ITA" || c.Code == "UKR" {
+				popGrowth = -0.002
+			}
+			pop := p.Pop0 * math.Pow(1+popGrowth, yrf)
+			add(c.Code, "SP.POP.TOTL", year, round0(pop))
+
+			// Life expectancy
+			le := p.LE0 + yrf*0.15 + rng.Float64()*0.5 - 0.25
+			if year == 2020 {
+				le -= 1.0 + rng.Float64()
+			}
+			add(c.Code, "SP.DYN.LE00.IN", year, round2(le))
+
+			// Death rate
+			dr := 8.0 + rng.Float64()*2
+			if p.LE0 < 60 {
+				dr = 12.0 + rng.Float64()*3
+			}
+			if p.LE0 > 78 {
+				dr = 7.0 + rng.Float64()*2
+			}
+			dr -= yrf * 0.05
+			if year == 2020 {
+				dr += 1.5
+			}
+			add(c.Code, "SP.DYN.CDRT.IN", year, round2(clamp(dr, 2, 20)))
+
+			// Education expenditure
+			edu := 4.0 + rng.Float64()*2.5
+			if c.IncomeGroup == "High income" {
+				edu = 4.5 + rng.Float64()*2
+			}
+			add(c.Code, "SE.XPD.TOTL.GD.ZS", year, round2(edu))
+
+			// Health expenditure
+			health := 5.0 + rng.Float64()*3
+			if c.IncomeGroup == "High income" {
+				health = 7.0 + rng.Float64()*5
+			}
+			if c.IncomeGroup == "Low income" {
+				health = 3.0 + rng.Float64()*2
+			}
+			add(c.Code, "SH.XPD.CHEX.GD.ZS", year, round2(health))
+
+			// CO2 emissions
+			co2 := p.CO2*(1+yrf*0.01) + rng.Float64()*0.5 - 0.25
+			if c.IncomeGroup == "High income" && year > 2015 {
+				co2 *= 0.97
+			}
+			add(c.Code, "EN.ATM.CO2E.PC", year, round2(clamp(co2, 0.05, 30)))
+
+			// Electric power consumption
+			elec := 1000.0 + p.GDPpc0*0.25 + yrf*100
+			elec += rng.Float64()*200 - 100
+			add(c.Code, "EG.USE.ELEC.KH.PC", year, round0(clamp(elec, 50, 25000)))
+
+			// Internet usage
+			inet := p.Internet0 + yrf*3.5
+			if inet > 98 {
+				inet = 95 + rng.Float64()*4
+			}
+			inet += rng.Float64()*2 - 1
+			add(c.Code, "IT.NET.USER.ZS", year, round2(clamp(inet, 0.1, 99.5)))
+
+			// Unemployment
+			unemp := p.Unemp + rng.Float64()*1.5 - 0.75
+			if year == 2009 {
+				unemp += 2.5
+			}
+			if year == 2020 {
+				unemp += 3.0
+			}
+			if year == 2021 {
+				unemp -= 1.0
+			}
+			add(c.Code, "SL.UEM.TOTL.ZS", year, round2(clamp(unemp, 1, 35)))
+
+			// Inflation
+			infl := 2.5 + rng.Float64()*2
+			if c.IncomeGroup == "Lower middle income" {
+				infl = 5 + rng.Float64()*4
+			}
+			if c.IncomeGroup == "Low income" {
+				infl = 6 + rng.Float64()*5
+			}
+			if year == 2022 {
+				infl += 4.0
+			}
+			add(c.Code, "FP.CPI.TOTL.ZG", year, round2(infl))
+
+			// Trade % GDP
+			trade := 50 + rng.Float64()*30
+			if c.Code == "SGP" || c.Code == "ARE" || c.Code == "BEL" || c.Code == "NLD" {
+				trade = 120 + rng.Float64()*80
+			}
+			if c.Code == "USA" || c.Code == "BRA" || c.Code == "JPN" {
+				trade = 25 + rng.Float64()*15
+			}
+			add(c.Code, "NE.TRD.GNFS.ZS", year, round2(trade))
+
+			// FDI % GDP
+			fdi := 1.5 + rng.Float64()*3
+			if c.Code == "SGP" || c.Code == "ARE" {
+				fdi = 5 + rng.Float64()*10
+			}
+			add(c.Code, "BX.KLT.DINV.WD.GD.ZS", year, round2(fdi))
+
+			// Government debt % GDP
+			debt := 40 + rng.Float64()*30
+			if c.Code == "JPN" {
+				debt = 170 + yrf*4
+			}
+			if c.Code == "USA" {
+				debt = 60 + yrf*3
+			}
+			if year >= 2020 {
+				debt += 15
+			}
+			add(c.Code, "GC.DOD.TOTL.GD.ZS", year, round2(debt))
+
+			// Poverty
+			pov := p.Poverty0*math.Pow(0.96, yrf) + rng.Float64()*2 - 1
+			if year == 2020 {
+				pov += 3
+			}
+			add(c.Code, "SI.POV.NAHC", year, round2(clamp(pov, 0.5, 80)))
+
+			// Forest area
+			forest := p.Forest0 - yrf*0.1 + rng.Float64()*0.3 - 0.15
+			add(c.Code, "AG.LND.FRST.ZS", year, round2(clamp(forest, 0, 90)))
+
+			// Urban population
+			urban := p.Urban0 + yrf*0.4 + rng.Float64()*0.3
+			add(c.Code, "SP.URB.TOTL.IN.ZS", year, round2(clamp(urban, 10, 100)))
+
+			// Gross savings
+			savings := 20 + rng.Float64()*10
+			if c.Code == "CHN" || c.Code == "SGP" {
+				savings = 35 + rng.Float64()*15
+			}
+			if c.Code == "SAU" || c.Code == "NOR" {
+				savings = 30 + rng.Float64()*15
+			}
+			add(c.Code, "NY.GNS.ICTR.ZS", year, round2(savings))
+
+			// Military expenditure
+			mil := 1.5 + rng.Float64()*1
+			if c.Code == "USA" || c.Code == "SAU" || c.Code == "ISR" {
+				mil = 3.0 + rng.Float64()*2
+			}
+			if c.Code == "RUS" {
+				mil = 3.5 + rng.Float64()*1.5
+			}
+			add(c.Code, "MS.MIL.XPND.GD.ZS", year, round2(mil))
+		}
+	}
+	return d, nil
+}
+
+// WriteSQL writes d as INSERT statements for countries, indicators and
+// indicator_values, in the shape of db/seed.sql.
+func (d *Dataset) WriteSQL(w io.Writer) error {
+	b := bufio.NewWriter(w)
+	fmt.Fprintf(b, "-- World Bank style seed data\n-- Generated by cmd/seedgen -countries %d -first-year %d -last-year %d -seed %d\n",
+		d.Config.Countries, d.Config.FirstYear, d.Config.LastYear, d.Config.Seed)
+	fmt.Fprintf(b, "-- %d countries, %d indicators, years %d-%d\n-- %d data points\n\n",
+		len(d.Countries), len(d.Indicators), d.Config.FirstYear, d.Config.LastYear, len(d.Values))
+
+	b.WriteString("-- Countries\n")
+	for _, c := range d.Countries {
+		fmt.Fprintf(b, "INSERT INTO countries (name, code, region, income_group) VALUES ('%s', '%s', '%s', '%s') ON CONFLICT (code) DO NOTHING;\n",
+			escape(c.Name), c.Code, escape(c.Region), escape(c.IncomeGroup))
+	}
+
+	b.WriteString("\n-- Indicators\n")
+	for _, i := range d.Indicators {
+		fmt.Fprintf(b, "INSERT INTO indicators (name, code, category, unit, description) VALUES ('%s', '%s', '%s', '%s', '%s') ON CONFLICT (code) DO NOTHING;\n",
+			escape(i.Name), i.Code, i.Category, escape(i.Unit), escape(i.Description))
+	}
+
+	if len(d.Values) > 0 {
+		b.WriteString("\n-- Indicator values\n")
+		b.WriteString("INSERT INTO indicator_values (country_id, indicator_id, year, value)\nSELECT c.id, i.id, v.year, v.value::numeric\nFROM (VALUES\n")
+		for n, v := range d.Values {
+			if n > 0 {
+				b.WriteString(",\n")
+			}
+			fmt.Fprintf(b, "  ('%s', '%s', %d, '%.6f')", v.CountryCode, v.IndicatorCode, v.Year, v.Value)
+		}
+		b.WriteString("\n) AS v(country_code, indicator_code, year, value)\n")
+		b.WriteString("JOIN countries c ON c.code = v.country_code\n")
+		b.WriteString("JOIN indicators i ON i.code = v.indicator_code\n")
+		b.WriteString("ON CONFLICT DO NOTHING;\n")
+	}
+
+	return b.Flush()
+}
+
+func round2(v float64) float64 { return math.Round(v*100) / 100 }
+func round0(v float64) float64 { return math.Round(v) }
+func clamp(v, min, max float64) float64 {
+	if v < min {
+		return min
+	}
+	if v > max {
+		return max
+	}
+	return v
+}
+func escape(s string) string { return strings.ReplaceAll(s, "'", "''") }
@@ -0,0 +1,76 @@
+package seed
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateIsDeterministic(t *testing.T) {
+	cfg := Config{Countries: 5, FirstYear: 2010, LastYear: 2015, Seed: 7}
+	a, err := Generate(cfg)
+	require.NoError(t, err)
+	b, err := Generate(cfg)
+	require.NoError(t, err)
+	assert.Equal(t, a.Values, b.Values)
+
+	cfg.Seed = 8
+	c, err := Generate(cfg)
+	require.NoError(t, err)
+	assert.NotEqual(t, a.Values, c.Values)
+}
+
+func TestGenerateHonoursConfig(t *testing.T) {
+	d, err := Generate(Config{Countries: 3, FirstYear: 2018, LastYear: 2020, Seed: 1})
+	require.NoError(t, err)
+	require.Len(t, d.Countries, 3)
+	assert.Len(t, d.Indicators, len(indicators))
+
+	codes := map[string]bool{}
+	for _, c := range d.Countries {
+		codes[c.Code] = true
+	}
+	max := 3 * 3 * len(indicators)
+	assert.NotEmpty(t, d.Values)
+	assert.LessOrEqual(t, len(d.Values), max)
+	for _, v := range d.Values {
+		assert.True(t, codes[v.CountryCode], v.CountryCode)
+		assert.GreaterOrEqual(t, v.Year, 2018)
+		assert.LessOrEqual(t, v.Year, 2020)
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	require.NoError(t, DefaultConfig().Validate())
+
+	for name, cfg := range map[string]Config{
+		"no countries":      {Countries: 0, FirstYear: 2003, LastYear: 2023},
+		"too many":          {Countries: len(countries) + 1, FirstYear: 2003, LastYear: 2023},
+		"reversed range":    {Countries: 5, FirstYear: 2023, LastYear: 2003},
+		"range too long":    {Countries: 5, FirstYear: 1900, LastYear: 2100},
+		"year out of range": {Countries: 5, FirstYear: 1800, LastYear: 1810},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := Generate(cfg)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestWriteSQL(t *testing.T) {
+	d, err := Generate(Config{Countries: 2, FirstYear: 2020, LastYear: 2021, Seed: 42})
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, d.WriteSQL(&buf))
+	sql := buf.String()
+
+	assert.Contains(t, sql, "-seed 42")
+	assert.Equal(t, 2, strings.Count(sql, "INSERT INTO countries"))
+	assert.Equal(t, len(indicators), strings.Count(sql, "INSERT INTO indicators"))
+	assert.Contains(t, sql, "INSERT INTO indicator_values")
+	assert.True(t, strings.HasSuffix(strings.TrimSpace(sql), ";"))
+}
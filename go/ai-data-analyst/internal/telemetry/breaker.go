@@ -0,0 +1,29 @@
+package telemetry
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// RegisterCircuitBreakers reports gen_ai.client.circuit_breaker.state for
+// each named provider: 0 closed, 1 half-open, 2 open. Like
+// app.dependency.health, the states are read at every collection.
+func RegisterCircuitBreakers(m metric.Meter, states map[string]func() int64) error {
+	gauge, err := m.Int64ObservableGauge("gen_ai.client.circuit_breaker.state",
+		metric.WithUnit("1"),
+		metric.WithDescription("LLM provider circuit breaker state: 0 closed, 1 half-open, 2 open"),
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = m.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		for provider, state := range states {
+			o.ObserveInt64(gauge, state(), metric.WithAttributes(attribute.String("gen_ai.provider.name", provider)))
+		}
+		return nil
+	}, gauge)
+	return err
+}
@@ -0,0 +1,67 @@
+package telemetry
+
+import (
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// EvalMetrics are recorded by the evaluation harness (cmd/eval), one run of
+// a golden set at a time.
+type EvalMetrics struct {
+	Questions metric.Int64Counter
+	Accuracy  metric.Float64Gauge
+	Cost      metric.Float64Counter
+	Duration  metric.Float64Histogram
+}
+
+func NewEvalMetrics(m metric.Meter) (*EvalMetrics, error) {
+	questions, err := m.Int64Counter("nlsql.eval.questions",
+		metric.WithUnit("{question}"),
+		metric.WithDescription("Golden questions evaluated, by question type and outcome (pass, fail, error)"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	accuracy, err := m.Float64Gauge("nlsql.eval.accuracy",
+		metric.WithUnit("1"),
+		metric.WithDescription("Share of golden questions answered with the expected rows in the latest run, by question type"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	cost, err := m.Float64Counter("nlsql.eval.cost",
+		metric.WithUnit("usd"),
+		metric.WithDescription("LLM cost of answering golden questions, by question type"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	duration, err := m.Float64Histogram("nlsql.eval.duration",
+		metric.WithUnit("s"),
+		metric.WithDescription("Time to answer a golden question, by question type"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &EvalMetrics{
+		Questions: questions,
+		Accuracy:  accuracy,
+		Cost:      cost,
+		Duration:  duration,
+	}, nil
+}
+
+func WithEvalCase(set, questionType string) metric.MeasurementOption {
+	return metric.WithAttributes(
+		attribute.String("nlsql.eval.set", set),
+		attribute.String("nlsql.question_type", questionType),
+	)
+}
+
+func WithEvalOutcome(outcome string) metric.MeasurementOption {
+	return metric.WithAttributes(attribute.String("nlsql.eval.outcome", outcome))
+}
@@ -0,0 +1,156 @@
+package telemetry
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"google.golang.org/grpc/credentials"
+)
+
+// OTLP transport protocols, as spelled by OTEL_EXPORTER_OTLP_PROTOCOL.
+const (
+	ProtocolHTTP = "http/protobuf"
+	ProtocolGRPC = "grpc"
+)
+
+// ExporterConfig selects the OTLP transport. An https endpoint turns on TLS,
+// verified against the system roots plus CACertFile if set; ClientCertFile
+// and ClientKeyFile add a client certificate for mTLS.
+type ExporterConfig struct {
+	Endpoint       string
+	Protocol       string
+	CACertFile     string
+	ClientCertFile string
+	ClientKeyFile  string
+}
+
+func (c ExporterConfig) secure() (bool, error) {
+	u, err := url.Parse(c.Endpoint)
+	if err != nil {
+		return false, fmt.Errorf("invalid OTLP endpoint %q: %w", c.Endpoint, err)
+	}
+	switch u.Scheme {
+	case "https":
+		return true, nil
+	case "http":
+		return false, nil
+	default:
+		return false, fmt.Errorf("OTLP endpoint %q must start with http:// or https://", c.Endpoint)
+	}
+}
+
+func (c ExporterConfig) tlsConfig() (*tls.Config, error) {
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if c.CACertFile != "" {
+		pem, err := os.ReadFile(c.CACertFile)
+		if err != nil {
+			return nil, fmt.Errorf("read OTLP CA certificate: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", c.CACertFile)
+		}
+		cfg.RootCAs = pool
+	}
+
+	if c.ClientCertFile != "" || c.ClientKeyFile != "" {
+		if c.ClientCertFile == "" || c.ClientKeyFile == "" {
+			return nil, fmt.Errorf("OTLP client certificate and key must be set together")
+		}
+		cert, err := tls.LoadX509KeyPair(c.ClientCertFile, c.ClientKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load OTLP client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
+
+func newTraceExporter(ctx context.Context, c ExporterConfig) (sdktrace.SpanExporter, error) {
+	secure, err := c.secure()
+	if err != nil {
+		return nil, err
+	}
+	var tlsCfg *tls.Config
+	if secure {
+		if tlsCfg, err = c.tlsConfig(); err != nil {
+			return nil, err
+		}
+	}
+
+	switch c.Protocol {
+	case ProtocolGRPC:
+		opts := []otlptracegrpc.Option{otlptracegrpc.WithEndpointURL(c.Endpoint)}
+		if secure {
+			opts = append(opts, otlptracegrpc.WithTLSCredentials(credentials.NewTLS(tlsCfg)))
+		} else {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		}
+		return otlptracegrpc.New(ctx, opts...)
+	case ProtocolHTTP, "":
+		opts := []otlptracehttp.Option{otlptracehttp.WithEndpointURL(endpointPath(c.Endpoint, "/v1/traces"))}
+		if secure {
+			opts = append(opts, otlptracehttp.WithTLSClientConfig(tlsCfg))
+		} else {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		return otlptracehttp.New(ctx, opts...)
+	default:
+		return nil, fmt.Errorf("unsupported OTLP protocol %q (want %q or %q)", c.Protocol, ProtocolGRPC, ProtocolHTTP)
+	}
+}
+
+func newMetricExporter(ctx context.Context, c ExporterConfig) (sdkmetric.Exporter, error) {
+	secure, err := c.secure()
+	if err != nil {
+		return nil, err
+	}
+	var tlsCfg *tls.Config
+	if secure {
+		if tlsCfg, err = c.tlsConfig(); err != nil {
+			return nil, err
+		}
+	}
+
+	switch c.Protocol {
+	case ProtocolGRPC:
+		opts := []otlpmetricgrpc.Option{otlpmetricgrpc.WithEndpointURL(c.Endpoint)}
+		if secure {
+			opts = append(opts, otlpmetricgrpc.WithTLSCredentials(credentials.NewTLS(tlsCfg)))
+		} else {
+			opts = append(opts, otlpmetricgrpc.WithInsecure())
+		}
+		return otlpmetricgrpc.New(ctx, opts...)
+	case ProtocolHTTP, "":
+		opts := []otlpmetrichttp.Option{otlpmetrichttp.WithEndpointURL(endpointPath(c.Endpoint, "/v1/metrics"))}
+		if secure {
+			opts = append(opts, otlpmetrichttp.WithTLSClientConfig(tlsCfg))
+		} else {
+			opts = append(opts, otlpmetrichttp.WithInsecure())
+		}
+		return otlpmetrichttp.New(ctx, opts...)
+	default:
+		return nil, fmt.Errorf("unsupported OTLP protocol %q (want %q or %q)", c.Protocol, ProtocolGRPC, ProtocolHTTP)
+	}
+}
+
+// endpointPath appends the per-signal path to the base OTLP/HTTP endpoint,
+// as the OTEL_EXPORTER_OTLP_ENDPOINT spec describes.
+func endpointPath(endpoint, path string) string {
+	return strings.TrimSuffix(endpoint, "/") + path
+}
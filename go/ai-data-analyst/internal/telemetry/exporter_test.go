@@ -0,0 +1,90 @@
+package telemetry
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeTestCert writes a self-signed certificate and its key as PEM files.
+func writeTestCert(t *testing.T) (certFile, keyFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "otel-collector"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	certFile = filepath.Join(dir, "cert.pem")
+	keyFile = filepath.Join(dir, "key.pem")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	return certFile, keyFile
+}
+
+// TestInitGRPC verifies the gRPC exporters are built for both plaintext and
+// TLS endpoints. gRPC clients connect lazily, so no collector is needed.
+func TestInitGRPC(t *testing.T) {
+	ctx := context.Background()
+	certFile, keyFile := writeTestCert(t)
+
+	for _, exp := range []ExporterConfig{
+		{Endpoint: "http://localhost:4317", Protocol: ProtocolGRPC},
+		{Endpoint: "https://localhost:4317", Protocol: ProtocolGRPC, CACertFile: certFile, ClientCertFile: certFile, ClientKeyFile: keyFile},
+	} {
+		p, err := Init(ctx, "test-service", exp, "test")
+		require.NoError(t, err, exp.Endpoint)
+		assert.NotNil(t, p.Tracer)
+
+		shutdownCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+		_ = p.Shutdown(shutdownCtx)
+		cancel()
+	}
+}
+
+func TestInitRejectsBadExporterConfig(t *testing.T) {
+	ctx := context.Background()
+	certFile, _ := writeTestCert(t)
+
+	cases := map[string]ExporterConfig{
+		"unknown protocol": {Endpoint: "http://localhost:4318", Protocol: "http/json"},
+		"missing scheme":   {Endpoint: "localhost:4317", Protocol: ProtocolGRPC},
+		"missing CA file":  {Endpoint: "https://localhost:4318", CACertFile: filepath.Join(t.TempDir(), "absent.pem")},
+		"cert without key": {Endpoint: "https://localhost:4317", Protocol: ProtocolGRPC, ClientCertFile: certFile},
+	}
+	for name, exp := range cases {
+		_, err := Init(ctx, "test-service", exp, "test")
+		assert.Error(t, err, name)
+	}
+}
+
+func TestTLSConfigLoadsCAAndClientCert(t *testing.T) {
+	certFile, keyFile := writeTestCert(t)
+
+	cfg, err := ExporterConfig{CACertFile: certFile, ClientCertFile: certFile, ClientKeyFile: keyFile}.tlsConfig()
+	require.NoError(t, err)
+	assert.NotNil(t, cfg.RootCAs)
+	assert.Len(t, cfg.Certificates, 1)
+}
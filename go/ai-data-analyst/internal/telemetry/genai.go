@@ -14,12 +14,43 @@ type GenAIMetrics struct {
 	RetryCount        metric.Int64Counter
 	FallbackCount     metric.Int64Counter
 	ErrorCount        metric.Int64Counter
+	TimeToFirstToken  metric.Float64Histogram
 
 	QuestionDuration   metric.Float64Histogram
 	SQLValid           metric.Int64Counter
 	QueryRows          metric.Float64Histogram
 	QueryExecutionTime metric.Float64Histogram
 	Confidence         metric.Float64Histogram
+	LintFindings       metric.Int64Counter
+	Replays            metric.Int64Counter
+	RepairCount        metric.Int64Counter
+
+	ExportSize     metric.Int64Histogram
+	ExportDuration metric.Float64Histogram
+
+	SchemaRetrievalDuration metric.Float64Histogram
+	SchemaRetrievalHits     metric.Int64Histogram
+
+	SandboxObjects  metric.Int64UpDownCounter
+	SandboxCleanups metric.Int64Counter
+
+	CacheHits   metric.Int64Counter
+	CacheMisses metric.Int64Counter
+
+	SemanticCacheHits    metric.Int64Counter
+	SemanticCacheMisses  metric.Int64Counter
+	SemanticCacheSavings metric.Float64Counter
+
+	BudgetExhausted metric.Int64Counter
+
+	GuardrailBlocked metric.Int64Counter
+
+	Hallucinations metric.Int64Counter
+
+	RateLimited metric.Int64Counter
+
+	WSConnections metric.Int64UpDownCounter
+	WSMessages    metric.Int64Counter
 }
 
 func NewGenAIMetrics(m metric.Meter) (*GenAIMetrics, error) {
@@ -71,6 +102,14 @@ func NewGenAIMetrics(m metric.Meter) (*GenAIMetrics, error) {
 		return nil, err
 	}
 
+	timeToFirstToken, err := m.Float64Histogram("gen_ai.client.time_to_first_token",
+		metric.WithUnit("s"),
+		metric.WithDescription("Time from sending a streamed LLM request to receiving its first content"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
 	questionDuration, err := m.Float64Histogram("nlsql.question.duration",
 		metric.WithUnit("s"),
 		metric.WithDescription("Total question-to-answer duration"),
@@ -111,6 +150,166 @@ func NewGenAIMetrics(m metric.Meter) (*GenAIMetrics, error) {
 		return nil, err
 	}
 
+	lintFindings, err := m.Int64Counter("nlsql.lint.findings",
+		metric.WithUnit("{finding}"),
+		metric.WithDescription("SQL lint findings on generated queries, by rule"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	replays, err := m.Int64Counter("nlsql.replay.count",
+		metric.WithUnit("{replay}"),
+		metric.WithDescription("History replays, by mode and whether the result drifted"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	repairCount, err := m.Int64Counter("nlsql.repair.count",
+		metric.WithUnit("{attempt}"),
+		metric.WithDescription("Attempts to repair SQL the database rejected, by outcome"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	exportSize, err := m.Int64Histogram("nlsql.export.size",
+		metric.WithUnit("By"),
+		metric.WithDescription("Size of history exports, by format and where the rows came from"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	exportDuration, err := m.Float64Histogram("nlsql.export.duration",
+		metric.WithUnit("s"),
+		metric.WithDescription("Time to load and serialize a history export, by format and where the rows came from"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	schemaRetrievalDuration, err := m.Float64Histogram("nlsql.schema_retrieval.duration",
+		metric.WithUnit("s"),
+		metric.WithDescription("Time to embed a question and look up relevant schema fragments"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	schemaRetrievalHits, err := m.Int64Histogram("nlsql.schema_retrieval.hits",
+		metric.WithUnit("{fragment}"),
+		metric.WithDescription("Schema fragments put in the Generate prompt per question"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	sandboxObjects, err := m.Int64UpDownCounter("nlsql.sandbox.objects",
+		metric.WithUnit("{object}"),
+		metric.WithDescription("Temporary tables and views currently held by sandbox sessions"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	sandboxCleanups, err := m.Int64Counter("nlsql.sandbox.cleanups",
+		metric.WithUnit("{sandbox}"),
+		metric.WithDescription("Sandbox sessions closed and their temporary objects dropped, by reason"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	cacheHits, err := m.Int64Counter("nlsql.cache.hits",
+		metric.WithUnit("{lookup}"),
+		metric.WithDescription("Result cache lookups that found an entry, by level"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	cacheMisses, err := m.Int64Counter("nlsql.cache.misses",
+		metric.WithUnit("{lookup}"),
+		metric.WithDescription("Result cache lookups that found no entry, by level"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	semanticCacheHits, err := m.Int64Counter("gen_ai.cache.hit",
+		metric.WithUnit("{call}"),
+		metric.WithDescription("LLM calls answered from the semantic cache"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	semanticCacheMisses, err := m.Int64Counter("gen_ai.cache.miss",
+		metric.WithUnit("{call}"),
+		metric.WithDescription("LLM calls the semantic cache had no similar prompt for"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	semanticCacheSavings, err := m.Float64Counter("gen_ai.cache.saved_cost",
+		metric.WithUnit("usd"),
+		metric.WithDescription("Cost of the LLM calls the semantic cache answered, in USD"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	budgetExhausted, err := m.Int64Counter("gen_ai.client.budget.exhausted",
+		metric.WithUnit("{call}"),
+		metric.WithDescription("LLM calls that would have exceeded a budget, by scope and whether they were downgraded or rejected"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	guardrailBlocked, err := m.Int64Counter("nlsql.guardrail.blocked",
+		metric.WithUnit("{answer}"),
+		metric.WithDescription("Answers blocked by the PII guardrail, counted once per PII type and source (question or output) found"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	hallucinations, err := m.Int64Counter("nlsql.explain.hallucinations",
+		metric.WithUnit("{finding}"),
+		metric.WithDescription("Explanation insights citing rows outside the result or quoting numbers not in their rows, by kind"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	rateLimited, err := m.Int64Counter("nlsql.rate_limit.throttled",
+		metric.WithUnit("{request}"),
+		metric.WithDescription("Requests rejected with 429 by the rate limiter, by scope (ip or global)"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	wsConnections, err := m.Int64UpDownCounter("nlsql.ws.connections",
+		metric.WithUnit("{connection}"),
+		metric.WithDescription("Open /ws chat connections"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	wsMessages, err := m.Int64Counter("nlsql.ws.messages",
+		metric.WithUnit("{message}"),
+		metric.WithDescription("Questions received over /ws, by outcome (answered, failed, invalid, throttled)"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
 	return &GenAIMetrics{
 		TokenUsage:         tokenUsage,
 		OperationDuration:  operationDuration,
@@ -118,15 +317,48 @@ func NewGenAIMetrics(m metric.Meter) (*GenAIMetrics, error) {
 		RetryCount:         retryCount,
 		FallbackCount:      fallbackCount,
 		ErrorCount:         errorCount,
+		TimeToFirstToken:   timeToFirstToken,
 		QuestionDuration:   questionDuration,
 		SQLValid:           sqlValid,
 		QueryRows:          queryRows,
 		QueryExecutionTime: queryExecutionTime,
 		Confidence:         confidence,
+		LintFindings:       lintFindings,
+		Replays:            replays,
+		RepairCount:        repairCount,
+
+		ExportSize:     exportSize,
+		ExportDuration: exportDuration,
+
+		SchemaRetrievalDuration: schemaRetrievalDuration,
+		SchemaRetrievalHits:     schemaRetrievalHits,
+
+		SandboxObjects:  sandboxObjects,
+		SandboxCleanups: sandboxCleanups,
+
+		CacheHits:   cacheHits,
+		CacheMisses: cacheMisses,
+
+		SemanticCacheHits:    semanticCacheHits,
+		SemanticCacheMisses:  semanticCacheMisses,
+		SemanticCacheSavings: semanticCacheSavings,
+
+		BudgetExhausted: budgetExhausted,
+
+		GuardrailBlocked: guardrailBlocked,
+
+		Hallucinations: hallucinations,
+
+		RateLimited: rateLimited,
+
+		WSConnections: wsConnections,
+		WSMessages:    wsMessages,
 	}, nil
 }
 
 type RecordParams struct {
+	// Operation is the gen_ai.operation.name; empty means chat.
+	Operation    string
 	Provider     string
 	Model        string
 	Stage        string
@@ -134,27 +366,38 @@ type RecordParams struct {
 	OutputTokens int
 	DurationSec  float64
 	CostUSD      float64
+	// Tenant attributes usage and cost to the caller's tenant when set.
+	Tenant string
 }
 
 func (g *GenAIMetrics) RecordGenAIMetrics(ctx context.Context, p RecordParams) {
+	operation := p.Operation
+	if operation == "" {
+		operation = "chat"
+	}
 	baseAttrs := []attribute.KeyValue{
-		attribute.String("gen_ai.operation.name", "chat"),
+		attribute.String("gen_ai.operation.name", operation),
 		attribute.String("gen_ai.provider.name", p.Provider),
 		attribute.String("gen_ai.request.model", p.Model),
 	}
 	if p.Stage != "" {
 		baseAttrs = append(baseAttrs, attribute.String("nlsql.stage", p.Stage))
 	}
+	if p.Tenant != "" {
+		baseAttrs = append(baseAttrs, attribute.String("tenant.id", p.Tenant))
+	}
 	attrs := metric.WithAttributes(baseAttrs...)
 
 	g.TokenUsage.Record(ctx, float64(p.InputTokens),
 		attrs,
 		metric.WithAttributes(attribute.String("gen_ai.token.type", "input")),
 	)
-	g.TokenUsage.Record(ctx, float64(p.OutputTokens),
-		attrs,
-		metric.WithAttributes(attribute.String("gen_ai.token.type", "output")),
-	)
+	if operation == "chat" {
+		g.TokenUsage.Record(ctx, float64(p.OutputTokens),
+			attrs,
+			metric.WithAttributes(attribute.String("gen_ai.token.type", "output")),
+		)
+	}
 	g.OperationDuration.Record(ctx, p.DurationSec, attrs)
 	g.Cost.Add(ctx, p.CostUSD, attrs)
 }
@@ -173,3 +416,56 @@ func WithBoolAttr(key string, val bool) metric.MeasurementOption {
 func WithQuestionType(qt string) metric.MeasurementOption {
 	return metric.WithAttributes(attribute.String("nlsql.question_type", qt))
 }
+
+func WithLintRule(rule string) metric.MeasurementOption {
+	return metric.WithAttributes(attribute.String("nlsql.lint.rule", rule))
+}
+
+func WithRepairOutcome(outcome string) metric.MeasurementOption {
+	return metric.WithAttributes(attribute.String("nlsql.repair.outcome", outcome))
+}
+
+func WithRetrievalOutcome(outcome string) metric.MeasurementOption {
+	return metric.WithAttributes(attribute.String("nlsql.schema_retrieval.outcome", outcome))
+}
+
+func WithSandboxObjectKind(kind string) metric.MeasurementOption {
+	return metric.WithAttributes(attribute.String("nlsql.sandbox.object_kind", kind))
+}
+
+func WithCleanupReason(reason string) metric.MeasurementOption {
+	return metric.WithAttributes(attribute.String("nlsql.sandbox.cleanup_reason", reason))
+}
+
+func WithCacheLevel(level, backend string) metric.MeasurementOption {
+	return metric.WithAttributes(
+		attribute.String("nlsql.cache.level", level),
+		attribute.String("nlsql.cache.backend", backend),
+	)
+}
+
+func WithSemanticCache(model, stage string) metric.MeasurementOption {
+	attrs := []attribute.KeyValue{attribute.String("gen_ai.request.model", model)}
+	if stage != "" {
+		attrs = append(attrs, attribute.String("nlsql.stage", stage))
+	}
+	return metric.WithAttributes(attrs...)
+}
+
+func WithBudget(scope, action string) metric.MeasurementOption {
+	return metric.WithAttributes(
+		attribute.String("gen_ai.budget.scope", scope),
+		attribute.String("gen_ai.budget.action", action),
+	)
+}
+
+func WithGuardrail(piiType, source string) metric.MeasurementOption {
+	return metric.WithAttributes(
+		attribute.String("nlsql.guardrail.pii_type", piiType),
+		attribute.String("nlsql.guardrail.source", source),
+	)
+}
+
+func WithHallucinationKind(kind string) metric.MeasurementOption {
+	return metric.WithAttributes(attribute.String("nlsql.hallucination.kind", kind))
+}
@@ -0,0 +1,33 @@
+package telemetry
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// RegisterDependencyHealth reports app.dependency.health as 1 while healthy
+// returns true for each named dependency and 0 otherwise. The callbacks are
+// read at every collection, so no recording is needed at state changes.
+func RegisterDependencyHealth(m metric.Meter, deps map[string]func() bool) error {
+	gauge, err := m.Int64ObservableGauge("app.dependency.health",
+		metric.WithUnit("1"),
+		metric.WithDescription("Whether a dependency is reachable (1) or not (0)"),
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = m.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		for name, healthy := range deps {
+			var v int64
+			if healthy() {
+				v = 1
+			}
+			o.ObserveInt64(gauge, v, metric.WithAttributes(attribute.String("dependency", name)))
+		}
+		return nil
+	}, gauge)
+	return err
+}
@@ -0,0 +1,34 @@
+package telemetry
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// RegisterActiveModels reports nlsql.llm.active_model as 1 for the model in
+// each role (capable, fast) that active returns. The resource carries the
+// models the process started with; this follows runtime switches.
+func RegisterActiveModels(m metric.Meter, provider string, active func() (capable, fast string)) error {
+	gauge, err := m.Int64ObservableGauge("nlsql.llm.active_model",
+		metric.WithUnit("1"),
+		metric.WithDescription("The model currently used in each role (1)"),
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = m.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		capable, fast := active()
+		for role, model := range map[string]string{"capable": capable, "fast": fast} {
+			o.ObserveInt64(gauge, 1, metric.WithAttributes(
+				attribute.String("gen_ai.provider.name", provider),
+				attribute.String("gen_ai.request.model", model),
+				attribute.String("nlsql.llm.model_role", role),
+			))
+		}
+		return nil
+	}, gauge)
+	return err
+}
@@ -4,9 +4,10 @@ import (
 	"context"
 	"time"
 
+	"github.com/base-14/examples/go/pkg/buildinfo"
+
 	"go.opentelemetry.io/otel"
-	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
-	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/metric"
 	"go.opentelemetry.io/otel/propagation"
 	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
@@ -23,22 +24,23 @@ type Provider struct {
 	Meter          metric.Meter
 }
 
-func Init(ctx context.Context, serviceName, endpoint, environment string) (*Provider, error) {
+// Init sets up tracing and metrics export. attrs are added to the
+// resource alongside the service name, version, instance and environment.
+func Init(ctx context.Context, serviceName string, exporter ExporterConfig, environment string, attrs ...attribute.KeyValue) (*Provider, error) {
 	res, err := resource.New(ctx,
 		resource.WithAttributes(
 			semconv.ServiceName(serviceName),
-			semconv.ServiceVersion("1.0.0"),
+			semconv.ServiceVersion(buildinfo.Version),
+			semconv.ServiceInstanceID(buildinfo.InstanceID),
 			semconv.DeploymentEnvironmentName(environment),
 		),
+		resource.WithAttributes(attrs...),
 	)
 	if err != nil {
 		return nil, err
 	}
 
-	traceExp, err := otlptracehttp.New(ctx,
-		otlptracehttp.WithEndpointURL(endpoint+"/v1/traces"),
-		otlptracehttp.WithInsecure(),
-	)
+	traceExp, err := newTraceExporter(ctx, exporter)
 	if err != nil {
 		return nil, err
 	}
@@ -49,10 +51,7 @@ func Init(ctx context.Context, serviceName, endpoint, environment string) (*Prov
 		sdktrace.WithSampler(sdktrace.AlwaysSample()),
 	)
 
-	metricExp, err := otlpmetrichttp.New(ctx,
-		otlpmetrichttp.WithEndpointURL(endpoint+"/v1/metrics"),
-		otlpmetrichttp.WithInsecure(),
-	)
+	metricExp, err := newMetricExporter(ctx, exporter)
 	if err != nil {
 		return nil, err
 	}
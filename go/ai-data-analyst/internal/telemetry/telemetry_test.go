@@ -8,10 +8,10 @@ import (
 	"testing"
 	"time"
 
+	"github.com/base-14/examples/go/pkg/oteltest"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.opentelemetry.io/otel/attribute"
-	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
 	"go.opentelemetry.io/otel/sdk/metric/metricdata"
 )
 
@@ -21,7 +21,7 @@ import (
 // depends on a live collector and is therefore not asserted here.
 func TestInitReturnsProvider(t *testing.T) {
 	ctx := context.Background()
-	p, err := Init(ctx, "test-service", "http://localhost:4318", "test")
+	p, err := Init(ctx, "test-service", ExporterConfig{Endpoint: "http://localhost:4318"}, "test")
 	require.NoError(t, err)
 	assert.NotNil(t, p.Tracer)
 	assert.NotNil(t, p.Meter)
@@ -35,12 +35,11 @@ func TestInitReturnsProvider(t *testing.T) {
 }
 
 // TestGenAIMetricsInit verifies every GenAI instrument is constructed. Uses an
-// in-memory ManualReader so it needs no collector.
+// in-memory reader so it needs no collector.
 func TestGenAIMetricsInit(t *testing.T) {
-	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(sdkmetric.NewManualReader()))
-	t.Cleanup(func() { _ = mp.Shutdown(context.Background()) })
+	tel := oteltest.New(t)
 
-	metrics, err := NewGenAIMetrics(mp.Meter("test"))
+	metrics, err := NewGenAIMetrics(tel.Meter("test"))
 	require.NoError(t, err)
 	assert.NotNil(t, metrics.TokenUsage)
 	assert.NotNil(t, metrics.OperationDuration)
@@ -54,15 +53,12 @@ func TestGenAIMetricsInit(t *testing.T) {
 // GenAI semantic-convention metrics and splits token usage by input/output.
 // Fully in-memory — no collector required.
 func TestGenAIMetricsRecord(t *testing.T) {
-	ctx := context.Background()
-	reader := sdkmetric.NewManualReader()
-	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
-	t.Cleanup(func() { _ = mp.Shutdown(ctx) })
+	tel := oteltest.New(t)
 
-	metrics, err := NewGenAIMetrics(mp.Meter("test"))
+	metrics, err := NewGenAIMetrics(tel.Meter("test"))
 	require.NoError(t, err)
 
-	metrics.RecordGenAIMetrics(ctx, RecordParams{
+	metrics.RecordGenAIMetrics(context.Background(), RecordParams{
 		Provider:     "openai",
 		Model:        "gpt-4.1",
 		Stage:        "generate",
@@ -72,33 +68,57 @@ func TestGenAIMetricsRecord(t *testing.T) {
 		CostUSD:      0.0021,
 	})
 
-	var rm metricdata.ResourceMetrics
-	require.NoError(t, reader.Collect(ctx, &rm))
+	tel.Metric(t, "gen_ai.client.operation.duration")
+	tel.Metric(t, "gen_ai.client.cost")
 
-	collected := make(map[string]metricdata.Metrics)
-	for _, sm := range rm.ScopeMetrics {
-		for _, m := range sm.Metrics {
-			collected[m.Name] = m
-		}
-	}
+	input := oteltest.Histogram[float64](t, tel, "gen_ai.client.token.usage", attribute.String("gen_ai.token.type", "input"))
+	output := oteltest.Histogram[float64](t, tel, "gen_ai.client.token.usage", attribute.String("gen_ai.token.type", "output"))
+	assert.Equal(t, 120.0, input.Sum, "input tokens recorded under token.type=input")
+	assert.Equal(t, 45.0, output.Sum, "output tokens recorded under token.type=output")
+}
 
-	assert.Contains(t, collected, "gen_ai.client.operation.duration")
-	assert.Contains(t, collected, "gen_ai.client.cost")
+// TestGenAIMetricsTenant verifies usage and cost carry tenant.id when the
+// caller's tenant is known.
+func TestGenAIMetricsTenant(t *testing.T) {
+	tel := oteltest.New(t)
 
-	tokenUsage, ok := collected["gen_ai.client.token.usage"]
-	require.True(t, ok, "gen_ai.client.token.usage must be recorded")
+	metrics, err := NewGenAIMetrics(tel.Meter("test"))
+	require.NoError(t, err)
+
+	for _, tenant := range []string{"acme", "globex", "acme"} {
+		metrics.RecordGenAIMetrics(context.Background(), RecordParams{
+			Provider:    "openai",
+			Model:       "gpt-4.1",
+			InputTokens: 100,
+			CostUSD:     0.01,
+			Tenant:      tenant,
+		})
+	}
 
-	hist, ok := tokenUsage.Data.(metricdata.Histogram[float64])
-	require.True(t, ok, "token usage must be a float64 histogram")
+	acme := attribute.String("tenant.id", "acme")
+	assert.InDelta(t, 0.02, oteltest.Sum[float64](t, tel, "gen_ai.client.cost", acme), 1e-9)
+	input := oteltest.Histogram[float64](t, tel, "gen_ai.client.token.usage", acme, attribute.String("gen_ai.token.type", "input"))
+	assert.Equal(t, 200.0, input.Sum)
+}
 
-	byType := make(map[string]float64)
-	for _, dp := range hist.DataPoints {
-		typ, present := dp.Attributes.Value(attribute.Key("gen_ai.token.type"))
-		require.True(t, present, "each token-usage point carries gen_ai.token.type")
-		byType[typ.AsString()] = dp.Sum
+// TestDependencyHealth verifies the gauge reports 1/0 per dependency from the
+// callbacks at collection time.
+func TestDependencyHealth(t *testing.T) {
+	tel := oteltest.New(t)
+
+	require.NoError(t, RegisterDependencyHealth(tel.Meter("test"), map[string]func() bool{
+		"postgres": func() bool { return false },
+		"ollama":   func() bool { return true },
+	}))
+
+	gauge, ok := tel.Metric(t, "app.dependency.health").Data.(metricdata.Gauge[int64])
+	require.True(t, ok)
+	got := map[string]int64{}
+	for _, dp := range gauge.DataPoints {
+		dep, _ := dp.Attributes.Value("dependency")
+		got[dep.AsString()] = dp.Value
 	}
-	assert.Equal(t, 120.0, byType["input"], "input tokens recorded under token.type=input")
-	assert.Equal(t, 45.0, byType["output"], "output tokens recorded under token.type=output")
+	assert.Equal(t, map[string]int64{"postgres": 0, "ollama": 1}, got)
 }
 
 // TestOTLPExportIntegration exercises the real OTLP export path end to end
@@ -115,7 +135,7 @@ func TestOTLPExportIntegration(t *testing.T) {
 	}
 
 	ctx := context.Background()
-	p, err := Init(ctx, "test-export", endpoint, "test")
+	p, err := Init(ctx, "test-export", ExporterConfig{Endpoint: endpoint}, "test")
 	require.NoError(t, err)
 
 	metrics, err := NewGenAIMetrics(p.Meter)
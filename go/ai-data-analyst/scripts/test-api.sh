@@ -33,6 +33,9 @@ BODY=$(curl -s "$BASE_URL/api/health")
 SVC=$(echo "$BODY" | python3 -c "import sys,json; print(json.load(sys.stdin)['status'])" 2>/dev/null || echo "error")
 check "GET /api/health status=ok" "$SVC" "ok"
 
+READY_STATUS=$(curl -s -o /dev/null -w "%{http_code}" "$BASE_URL/readyz")
+check "GET /readyz returns 200" "$READY_STATUS" "200"
+
 # Schema
 SCHEMA_STATUS=$(curl -s -o /dev/null -w "%{http_code}" "$BASE_URL/api/schema")
 check "GET /api/schema returns 200" "$SCHEMA_STATUS" "200"
@@ -50,6 +53,13 @@ fi
 IND_STATUS=$(curl -s -o /dev/null -w "%{http_code}" "$BASE_URL/api/indicators")
 check "GET /api/indicators returns 200" "$IND_STATUS" "200"
 
+# Dictionary
+DICT_STATUS=$(curl -s -o /dev/null -w "%{http_code}" "$BASE_URL/api/dictionary")
+check "GET /api/dictionary returns 200" "$DICT_STATUS" "200"
+
+USAGE_STATUS=$(curl -s -o /dev/null -w "%{http_code}" "$BASE_URL/api/usage")
+check "GET /api/usage returns 200" "$USAGE_STATUS" "200"
+
 # History
 HIST_STATUS=$(curl -s -o /dev/null -w "%{http_code}" "$BASE_URL/api/history")
 check "GET /api/history returns 200" "$HIST_STATUS" "200"
@@ -81,6 +91,112 @@ else
   FAIL=$((FAIL + 1))
 fi
 
+# Ask — the same question again is answered from the result cache
+CACHE_HIT=$(curl -s -X POST "$BASE_URL/api/ask" \
+  -H "Content-Type: application/json" \
+  -d '{"question":"top 5 countries by GDP growth in 2023?"}' | python3 -c "import sys,json; print(json.load(sys.stdin).get('cache_hit',''))" 2>/dev/null || echo "")
+check "POST /api/ask repeated question is a cache hit" "$CACHE_HIT" "question"
+
+# Ask — a chart spec on request, even for a cached answer
+CHART_TYPE=$(curl -s -X POST "$BASE_URL/api/ask?include_chart=true" \
+  -H "Content-Type: application/json" \
+  -d '{"question":"Top 5 countries by GDP growth in 2023"}' | python3 -c "import sys,json; print(((json.load(sys.stdin).get('explanation') or {}).get('chart') or {}).get('type',''))" 2>/dev/null || echo "")
+check "POST /api/ask?include_chart=true returns a bar chart" "$CHART_TYPE" "bar"
+
+# Ask — the EXPLAIN ANALYZE summary on request, for a question not yet cached
+HAS_PLAN=$(curl -s -X POST "$BASE_URL/api/ask?include_plan=true" \
+  -H "Content-Type: application/json" \
+  -d '{"question":"Which 3 countries had the highest life expectancy in 2021?"}' | python3 -c "import sys,json; print('total_cost' in (json.load(sys.stdin).get('query_plan') or {}))" 2>/dev/null || echo "")
+check "POST /api/ask?include_plan=true returns a query plan" "$HAS_PLAN" "True"
+CACHE_STATUS=$(curl -s -o /dev/null -w "%{http_code}" "$BASE_URL/api/cache/stats")
+check "GET /api/cache/stats returns 200" "$CACHE_STATUS" "200"
+
+# Examples — the demo question list
+EXAMPLES=$(curl -s "$BASE_URL/api/examples" | python3 -c "import sys,json; print(len(json.load(sys.stdin).get('examples',[])) > 0)" 2>/dev/null || echo "")
+check "GET /api/examples lists demo questions" "$EXAMPLES" "True"
+
+# Prompts — the built-in generate and explain prompts are active
+PROMPTS=$(curl -s "$BASE_URL/api/prompts" | python3 -c "import sys,json; print(sorted(p['name'] for p in json.load(sys.stdin).get('prompts',[])))" 2>/dev/null || echo "")
+check "GET /api/prompts lists generate and explain" "$PROMPTS" "['explain', 'generate']"
+
+# Budget — no limits are configured by default
+BUDGET_STATUS=$(curl -s -o /dev/null -w "%{http_code}" "$BASE_URL/api/budget")
+check "GET /api/budget returns 404 without limits" "$BUDGET_STATUS" "404"
+
+# Ask — streamed, one event per stage then the result. A new question, so
+# the stages run rather than the cache answering.
+STREAM_BODY=$(curl -s -N -X POST "$BASE_URL/api/ask?stream=true" \
+  -H "Content-Type: application/json" \
+  -d '{"question":"Top 5 countries by population in 2023"}')
+STREAM_STAGES=$(echo "$STREAM_BODY" | grep -c '^event: stage' || true)
+if [[ "$STREAM_STAGES" -ge 3 ]] && echo "$STREAM_BODY" | grep -q '^event: result'; then
+  green "POST /api/ask?stream=true streams stages and result"
+  PASS=$((PASS + 1))
+else
+  red "POST /api/ask?stream=true missing stage or result events"
+  FAIL=$((FAIL + 1))
+fi
+
+# Replay — the question just asked should replay without drift
+HIST_ID=$(curl -s "$BASE_URL/api/history?limit=1" | python3 -c "import sys,json; h=json.load(sys.stdin); print(h[0]['id'] if h else '')" 2>/dev/null || echo "")
+if [[ -n "$HIST_ID" ]]; then
+  REPLAY_BODY=$(curl -s -X POST "$BASE_URL/api/history/$HIST_ID/replay")
+  REPLAY_STATE=$(echo "$REPLAY_BODY" | python3 -c "import sys,json; print(json.load(sys.stdin).get('status',''))" 2>/dev/null || echo "")
+  check "POST /api/history/{id}/replay reports unchanged" "$REPLAY_STATE" "unchanged"
+fi
+
+REPLAY_MISSING=$(curl -s -o /dev/null -w "%{http_code}" -X POST "$BASE_URL/api/history/00000000-0000-0000-0000-000000000000/replay")
+check "POST /api/history/{id}/replay unknown id returns 404" "$REPLAY_MISSING" "404"
+
+# Export — the same entry as CSV and Excel
+if [[ -n "$HIST_ID" ]]; then
+  EXPORT_TYPE=$(curl -s -o /dev/null -w "%{content_type}" "$BASE_URL/api/history/$HIST_ID/export")
+  check "GET /api/history/{id}/export returns CSV" "$EXPORT_TYPE" "text/csv; charset=utf-8"
+  XLSX_MAGIC=$(curl -s "$BASE_URL/api/history/$HIST_ID/export?format=xlsx" | head -c 2)
+  check "GET /api/history/{id}/export?format=xlsx returns a workbook" "$XLSX_MAGIC" "PK"
+fi
+
+EXPORT_BAD=$(curl -s -o /dev/null -w "%{http_code}" "$BASE_URL/api/history/00000000-0000-0000-0000-000000000000/export?format=pdf")
+check "GET /api/history/{id}/export?format=pdf returns 400" "$EXPORT_BAD" "400"
+
+# Sessions — a follow-up is stored as a second turn
+SESSION_ID=$(curl -s -X POST "$BASE_URL/api/sessions" | python3 -c "import sys,json; print(json.load(sys.stdin).get('id',''))" 2>/dev/null || echo "")
+if [[ -n "$SESSION_ID" ]]; then
+  curl -s -o /dev/null -X POST "$BASE_URL/api/sessions/$SESSION_ID/ask" \
+    -H "Content-Type: application/json" \
+    -d '{"question":"Top 5 countries by GDP growth in 2023"}'
+  FOLLOWUP_STATUS=$(curl -s -o /dev/null -w "%{http_code}" -X POST "$BASE_URL/api/sessions/$SESSION_ID/ask" \
+    -H "Content-Type: application/json" \
+    -d '{"question":"now only for Asia"}')
+  check "POST /api/sessions/{id}/ask follow-up returns 200" "$FOLLOWUP_STATUS" "200"
+  TURNS=$(curl -s "$BASE_URL/api/sessions/$SESSION_ID" | python3 -c "import sys,json; print(json.load(sys.stdin).get('turn_count',0))" 2>/dev/null || echo "0")
+  check "GET /api/sessions/{id} has two turns" "$TURNS" "2"
+  DELETE_STATUS=$(curl -s -o /dev/null -w "%{http_code}" -X DELETE "$BASE_URL/api/sessions/$SESSION_ID")
+  check "DELETE /api/sessions/{id} returns 204" "$DELETE_STATUS" "204"
+else
+  red "POST /api/sessions did not return an id"
+  FAIL=$((FAIL + 1))
+fi
+
+# Sandbox sessions — only accepted when SANDBOX_ENABLED=true
+SANDBOX_BODY=$(curl -s -w "\n%{http_code}" -X POST "$BASE_URL/api/sessions" \
+  -H "Content-Type: application/json" \
+  -d '{"sandbox":true}')
+SANDBOX_STATUS=$(echo "$SANDBOX_BODY" | tail -n1)
+if [[ "${SANDBOX_ENABLED:-false}" == "true" ]]; then
+  check "POST /api/sessions sandbox returns 201" "$SANDBOX_STATUS" "201"
+  SANDBOX_ID=$(echo "$SANDBOX_BODY" | head -n1 | python3 -c "import sys,json; print(json.load(sys.stdin).get('id',''))" 2>/dev/null || echo "")
+  if [[ -n "$SANDBOX_ID" ]]; then
+    SANDBOX_ASK=$(curl -s -o /dev/null -w "%{http_code}" -X POST "$BASE_URL/api/sessions/$SANDBOX_ID/ask" \
+      -H "Content-Type: application/json" \
+      -d '{"question":"Save average GDP growth per country since 2015 as a temp table, then show the top 5"}')
+    check "POST /api/sessions/{id}/ask in sandbox returns 200" "$SANDBOX_ASK" "200"
+    curl -s -o /dev/null -X DELETE "$BASE_URL/api/sessions/$SANDBOX_ID"
+  fi
+else
+  check "POST /api/sessions sandbox returns 400 when disabled" "$SANDBOX_STATUS" "400"
+fi
+
 # Ask — empty question
 BAD_STATUS=$(curl -s -o /dev/null -w "%{http_code}" -X POST "$BASE_URL/api/ask" \
   -H "Content-Type: application/json" \
@@ -0,0 +1,127 @@
+// Command gate runs the entry or exit gate of the parking lot, a separate
+// service that parks and releases vehicles through the lot service's API.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"parking-lot/internal/gate"
+	"parking-lot/internal/parking"
+)
+
+const (
+	defaultLotURL      = "http://localhost:8080"
+	defaultLotCapacity = 20
+)
+
+func main() {
+	kindFlag := flag.String("gate", "entry", "gate to run: entry or exit")
+	port := flag.String("port", "", "HTTP port (default 8081 for entry, 8082 for exit)")
+	flag.Parse()
+
+	kind, err := gate.ParseKind(*kindFlag)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *port == "" {
+		*port = "8081"
+		if kind == gate.KindExit {
+			*port = "8082"
+		}
+	}
+
+	lotURL := os.Getenv("LOT_SERVICE_URL")
+	if lotURL == "" {
+		lotURL = defaultLotURL
+	}
+
+	var interval time.Duration
+	if v := os.Getenv("GATE_SIMULATE_INTERVAL"); v != "" {
+		if interval, err = time.ParseDuration(v); err != nil {
+			log.Fatalf("Invalid GATE_SIMULATE_INTERVAL: %v", err)
+		}
+	}
+
+	capacity := defaultLotCapacity
+	if v := os.Getenv("GATE_LOT_CAPACITY"); v != "" {
+		if capacity, err = strconv.Atoi(v); err != nil {
+			log.Fatalf("Invalid GATE_LOT_CAPACITY: %v", err)
+		}
+	}
+
+	telemetry, err := parking.NewTelemetryProvider()
+	if err != nil {
+		log.Fatalf("Failed to initialize telemetry: %v", err)
+	}
+
+	lot := gate.NewLotClient(lotURL)
+	g, err := gate.New(kind, lot, telemetry.Meter())
+	if err != nil {
+		log.Fatalf("Failed to create %s gate: %v", kind, err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// The entry gate opens the lot if nobody has, so the stack works
+	// without a manual create call.
+	if kind == gate.KindEntry && capacity > 0 {
+		go ensureLot(ctx, lot, capacity)
+	}
+	if interval > 0 {
+		log.Printf("Simulating %s traffic every %s", kind, interval)
+		go g.Simulate(ctx, interval)
+	}
+
+	srv := &http.Server{
+		Addr:         ":" + *port,
+		Handler:      g.Routes(),
+		ReadTimeout:  15 * time.Second,
+		WriteTimeout: 15 * time.Second,
+		IdleTimeout:  60 * time.Second,
+	}
+	go func() {
+		log.Printf("Starting %s gate on %s, lot service at %s", kind, srv.Addr, lotURL)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("HTTP server error: %v", err)
+		}
+	}()
+
+	<-ctx.Done()
+	log.Println("Shutting down gate...")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("HTTP server shutdown: %v", err)
+	}
+	if err := telemetry.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Telemetry shutdown: %v", err)
+	}
+}
+
+// ensureLot retries until the lot service is reachable, since it may start
+// after the gate.
+func ensureLot(ctx context.Context, lot *gate.LotClient, capacity int) {
+	for {
+		err := lot.EnsureLot(ctx, capacity)
+		if err == nil {
+			return
+		}
+		log.Printf("Waiting for lot service: %v", err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(2 * time.Second):
+		}
+	}
+}
@@ -0,0 +1,253 @@
+// Command parking-loadgen drives the parking lot service's HTTP API with a
+// weighted mix of park, leave and status calls and reports latency
+// percentiles per operation. With -soak it runs for hours, printing a
+// report every -report-every along with the lot's heap and goroutine count
+// from /metrics, so a leak shows up as a trend rather than a crash.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"parking-lot/internal/gate"
+	"parking-lot/internal/parking"
+	"parking-lot/internal/server"
+)
+
+const defaultLotURL = "http://localhost:8080"
+
+var colors = []string{"White", "Black", "Red", "Blue", "Silver"}
+
+func main() {
+	defaultURL := os.Getenv("LOT_SERVICE_URL")
+	if defaultURL == "" {
+		defaultURL = defaultLotURL
+	}
+
+	lotURL := flag.String("url", defaultURL, "lot service URL")
+	capacity := flag.Int("capacity", 100, "capacity of the lot to create if there is none (0 = expect one)")
+	mixFlag := flag.String("mix", "park=4,leave=4,status=2", "operations and their weights")
+	workers := flag.Int("workers", 10, "concurrent requests")
+	rps := flag.Float64("rps", 0, "requests per second across all workers (0 = as fast as they go)")
+	count := flag.Int("count", 0, "number of requests to send (0 = unlimited)")
+	duration := flag.Duration("duration", 0, "how long to run (0 = until count is reached)")
+	soak := flag.Duration("soak", 0, "run a soak test this long, reporting every -report-every")
+	reportEvery := flag.Duration("report-every", time.Minute, "report interval in soak mode")
+	traces := flag.Bool("trace", true, "send a client span per request to the collector")
+	flag.Parse()
+
+	if *soak > 0 {
+		*duration = *soak
+	}
+	if *count == 0 && *duration == 0 {
+		log.Fatal("Specify -count, -duration or -soak")
+	}
+	if *workers < 1 {
+		log.Fatal("-workers must be at least 1")
+	}
+	if *rps < 0 {
+		log.Fatal("-rps must not be negative")
+	}
+	if *soak > 0 && *reportEvery <= 0 {
+		log.Fatal("-report-every must be positive")
+	}
+	mix, err := parseMix(*mixFlag)
+	if err != nil {
+		log.Fatalf("Invalid -mix: %v", err)
+	}
+
+	var telemetry *parking.TelemetryProvider
+	if *traces {
+		if os.Getenv("OTEL_SERVICE_NAME") == "" {
+			os.Setenv("OTEL_SERVICE_NAME", "parking-loadgen")
+		}
+		if telemetry, err = parking.NewTelemetryProvider(); err != nil {
+			log.Fatalf("Failed to initialize telemetry: %v", err)
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	lot := gate.NewLotClient(*lotURL)
+	if *capacity > 0 {
+		if err := lot.EnsureLot(ctx, *capacity); err != nil {
+			log.Fatalf("Failed to open the lot at %s: %v", *lotURL, err)
+		}
+	}
+
+	runCtx, cancelRun := ctx, context.CancelFunc(func() {})
+	if *duration > 0 {
+		runCtx, cancelRun = context.WithTimeout(ctx, *duration)
+	}
+	defer cancelRun()
+
+	g := &generator{lot: lot, total: newStats()}
+	g.interval.Store(newStats())
+	var reporting sync.WaitGroup
+	if *soak > 0 {
+		g.soak = newSoakMonitor(*lotURL)
+		g.soak.sample(ctx)
+		reporting.Go(func() { g.reportEvery(runCtx, *reportEvery) })
+	}
+
+	log.Printf("Driving %s with %d workers (mix %s)", *lotURL, *workers, *mixFlag)
+	elapsed := g.run(runCtx, mix, *count, *rps, *workers)
+	cancelRun()
+	reporting.Wait()
+	g.total.report(os.Stdout, "Total", elapsed)
+	if g.soak != nil {
+		g.soak.sample(context.Background())
+		g.soak.summary(os.Stdout)
+	}
+
+	if telemetry != nil {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := telemetry.Shutdown(shutdownCtx); err != nil {
+			log.Printf("Telemetry shutdown: %v", err)
+		}
+	}
+}
+
+type generator struct {
+	lot  *gate.LotClient
+	soak *soakMonitor
+	seq  atomic.Int64
+
+	// parked holds the slots this run has parked in and not yet left, for
+	// leave to pick from.
+	mu     sync.Mutex
+	parked []int
+
+	total    *stats
+	interval atomic.Pointer[stats]
+}
+
+// run sends operations until count is reached or ctx is done, and returns
+// how long it took. Without a rate each worker sends its next request as
+// soon as the last one returns.
+func (g *generator) run(ctx context.Context, mix *opMix, count int, rps float64, workers int) time.Duration {
+	start := time.Now()
+
+	ops := make(chan string, workers)
+	var wg sync.WaitGroup
+	for i := range workers {
+		r := rand.New(rand.NewPCG(uint64(start.UnixNano()), uint64(i)))
+		wg.Go(func() {
+			for op := range ops {
+				g.do(ctx, op, r)
+			}
+		})
+	}
+
+	var tick <-chan time.Time
+	if rps > 0 {
+		ticker := time.NewTicker(time.Duration(float64(time.Second) / rps))
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	r := rand.New(rand.NewPCG(uint64(start.UnixNano()), uint64(workers)))
+
+loop:
+	for sent := 0; count == 0 || sent < count; sent++ {
+		if tick != nil {
+			select {
+			case <-ctx.Done():
+				break loop
+			case <-tick:
+			}
+		}
+		select {
+		case <-ctx.Done():
+			break loop
+		case ops <- mix.next(r):
+		}
+	}
+	close(ops)
+	wg.Wait()
+	return time.Since(start)
+}
+
+// do sends one operation. A leave with nothing parked by this run parks
+// instead.
+func (g *generator) do(ctx context.Context, op string, r *rand.Rand) {
+	slot, ok := 0, false
+	if op == opLeave {
+		if slot, ok = g.takeParked(r); !ok {
+			op = opPark
+		}
+	}
+
+	start := time.Now()
+	var err error
+	switch op {
+	case opPark:
+		var ticket gate.Ticket
+		ticket, err = g.lot.Park(ctx, server.ParkVehicleRequest{
+			Registration:   fmt.Sprintf("LOAD-%06d", g.seq.Add(1)),
+			Color:          colors[r.IntN(len(colors))],
+			DisabledPermit: r.IntN(10) == 0,
+		})
+		if err == nil {
+			g.mu.Lock()
+			g.parked = append(g.parked, ticket.SlotNumber)
+			g.mu.Unlock()
+		}
+	case opLeave:
+		_, err = g.lot.Leave(ctx, slot)
+	case opStatus:
+		_, err = g.lot.Status(ctx)
+	}
+	latency := time.Since(start)
+
+	// Requests cut off by the end of the run are not the lot's doing.
+	if ctx.Err() != nil && !errors.As(err, new(*gate.LotError)) {
+		return
+	}
+	g.total.record(op, latency, err)
+	g.interval.Load().record(op, latency, err)
+}
+
+func (g *generator) takeParked(r *rand.Rand) (int, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if len(g.parked) == 0 {
+		return 0, false
+	}
+	i := r.IntN(len(g.parked))
+	slot := g.parked[i]
+	g.parked[i] = g.parked[len(g.parked)-1]
+	g.parked = g.parked[:len(g.parked)-1]
+	return slot, true
+}
+
+// reportEvery prints the operations of each interval, then the lot's heap
+// and goroutines, until ctx is done.
+func (g *generator) reportEvery(ctx context.Context, every time.Duration) {
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+	last := time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			interval := g.interval.Swap(newStats())
+			interval.report(os.Stdout, "Interval ending "+now.Format(time.TimeOnly), now.Sub(last))
+			last = now
+			g.soak.sample(ctx)
+			g.soak.latest(os.Stdout)
+		}
+	}
+}
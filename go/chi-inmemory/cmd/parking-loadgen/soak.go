@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// soakSample is the lot's memory and goroutines at one point of a soak.
+type soakSample struct {
+	At         time.Time
+	HeapBytes  float64
+	Goroutines float64
+}
+
+// soakMonitor samples the lot's Prometheus /metrics during a soak. A heap
+// or goroutine count that keeps climbing under a steady load is a leak.
+type soakMonitor struct {
+	url    string
+	client *http.Client
+
+	mu      sync.Mutex
+	samples []soakSample
+}
+
+func newSoakMonitor(lotURL string) *soakMonitor {
+	return &soakMonitor{
+		url:    strings.TrimRight(lotURL, "/") + "/metrics",
+		client: &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+// sample reads /metrics once. A failed read is logged and skipped, so a
+// restart of the lot during a soak does not end it.
+func (m *soakMonitor) sample(ctx context.Context) {
+	s, err := m.scrape(ctx)
+	if err != nil {
+		log.Printf("Failed to read %s: %v", m.url, err)
+		return
+	}
+	m.mu.Lock()
+	m.samples = append(m.samples, s)
+	m.mu.Unlock()
+}
+
+func (m *soakMonitor) scrape(ctx context.Context) (soakSample, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, m.url, nil)
+	if err != nil {
+		return soakSample{}, err
+	}
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return soakSample{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return soakSample{}, fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return parseMetrics(resp.Body, time.Now())
+}
+
+// parseMetrics picks the Go runtime's heap and goroutine gauges out of the
+// Prometheus text format.
+func parseMetrics(r io.Reader, at time.Time) (soakSample, error) {
+	s := soakSample{At: at}
+	var heap, goroutines bool
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		name, value, ok := strings.Cut(scanner.Text(), " ")
+		if !ok || (name != "go_memstats_heap_alloc_bytes" && name != "go_goroutines") {
+			continue
+		}
+		v, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return soakSample{}, fmt.Errorf("parse %s: %w", name, err)
+		}
+		if name == "go_goroutines" {
+			s.Goroutines, goroutines = v, true
+		} else {
+			s.HeapBytes, heap = v, true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return soakSample{}, err
+	}
+	if !heap || !goroutines {
+		return soakSample{}, errors.New("no go_memstats_heap_alloc_bytes or go_goroutines")
+	}
+	return s, nil
+}
+
+func (m *soakMonitor) latest(w io.Writer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.samples) == 0 {
+		return
+	}
+	s := m.samples[len(m.samples)-1]
+	fmt.Fprintf(w, "lot heap %s, %.0f goroutines\n", formatBytes(s.HeapBytes), s.Goroutines)
+}
+
+// summary compares the last sample with the first.
+func (m *soakMonitor) summary(w io.Writer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.samples) < 2 {
+		fmt.Fprintln(w, "\nToo few /metrics samples to compare the lot's heap and goroutines")
+		return
+	}
+	first, last := m.samples[0], m.samples[len(m.samples)-1]
+	fmt.Fprintf(w, "\nSoak (%d samples over %s)\n", len(m.samples), last.At.Sub(first.At).Round(time.Second))
+	growth := 0.0
+	if first.HeapBytes > 0 {
+		growth = (last.HeapBytes - first.HeapBytes) / first.HeapBytes * 100
+	}
+	fmt.Fprintf(w, "heap        %s -> %s (%+.1f%%)\n", formatBytes(first.HeapBytes), formatBytes(last.HeapBytes), growth)
+	fmt.Fprintf(w, "goroutines  %.0f -> %.0f (%+.0f)\n", first.Goroutines, last.Goroutines, last.Goroutines-first.Goroutines)
+}
+
+func formatBytes(b float64) string {
+	return fmt.Sprintf("%.1f MiB", b/(1<<20))
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"parking-lot/internal/gate"
+)
+
+const (
+	opPark   = "park"
+	opLeave  = "leave"
+	opStatus = "status"
+)
+
+// maxSamples bounds the latencies kept per operation, so a soak of many
+// hours keeps a fixed-size sample instead of every request.
+const maxSamples = 100_000
+
+// opMix picks operations in proportion to their weights.
+type opMix struct {
+	ops     []string
+	weights []float64
+	total   float64
+}
+
+// parseMix reads a mix such as "park=4,leave=4,status=2".
+func parseMix(s string) (*opMix, error) {
+	m := &opMix{}
+	for _, part := range strings.Split(s, ",") {
+		op, weight, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return nil, fmt.Errorf("mix entry %q is not op=weight", part)
+		}
+		if op != opPark && op != opLeave && op != opStatus {
+			return nil, fmt.Errorf("unknown operation %q (have park, leave, status)", op)
+		}
+		if slices.Contains(m.ops, op) {
+			return nil, fmt.Errorf("operation %q appears twice", op)
+		}
+		w, err := strconv.ParseFloat(weight, 64)
+		if err != nil || w < 0 {
+			return nil, fmt.Errorf("weight of %s must be a non-negative number", op)
+		}
+		m.ops = append(m.ops, op)
+		m.weights = append(m.weights, w)
+		m.total += w
+	}
+	if m.total == 0 {
+		return nil, errors.New("mix has no weight")
+	}
+	return m, nil
+}
+
+func (m *opMix) next(r *rand.Rand) string {
+	x := r.Float64() * m.total
+	for i, w := range m.weights {
+		if x < w {
+			return m.ops[i]
+		}
+		x -= w
+	}
+	return m.ops[len(m.ops)-1]
+}
+
+// opStats are the outcomes of one operation. Rejected requests are ones
+// the lot refused with a 4xx, such as parking in a full lot; errors are
+// 5xx answers and failed requests.
+type opStats struct {
+	OK        int
+	Rejected  int
+	Errors    int
+	seen      int
+	latencies []time.Duration
+}
+
+type stats struct {
+	mu  sync.Mutex
+	ops map[string]*opStats
+}
+
+func newStats() *stats {
+	return &stats{ops: map[string]*opStats{}}
+}
+
+func (s *stats) record(op string, latency time.Duration, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	o := s.ops[op]
+	if o == nil {
+		o = &opStats{}
+		s.ops[op] = o
+	}
+
+	var lotErr *gate.LotError
+	switch {
+	case err == nil:
+		o.OK++
+	case errors.As(err, &lotErr) && lotErr.Status < http.StatusInternalServerError:
+		o.Rejected++
+	default:
+		o.Errors++
+	}
+
+	// Reservoir sampling keeps every latency equally likely to be in the
+	// sample however long the run.
+	o.seen++
+	if len(o.latencies) < maxSamples {
+		o.latencies = append(o.latencies, latency)
+	} else if i := rand.IntN(o.seen); i < maxSamples {
+		o.latencies[i] = latency
+	}
+}
+
+func (s *stats) report(w io.Writer, title string, elapsed time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ops := make([]string, 0, len(s.ops))
+	for op := range s.ops {
+		ops = append(ops, op)
+	}
+	sort.Strings(ops)
+
+	fmt.Fprintf(w, "\n%s (%s)\n", title, elapsed.Round(time.Second))
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "OP\tREQUESTS\tOK\tREJECTED\tERRORS\tP50\tP95\tP99\tMAX")
+	var total int
+	for _, op := range ops {
+		o := s.ops[op]
+		total += o.seen
+		latencies := slices.Clone(o.latencies)
+		slices.Sort(latencies)
+		var slowest time.Duration
+		if len(latencies) > 0 {
+			slowest = latencies[len(latencies)-1].Round(time.Microsecond)
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\t%s\t%s\t%s\t%s\n",
+			op, o.seen, o.OK, o.Rejected, o.Errors,
+			percentile(latencies, 50), percentile(latencies, 95), percentile(latencies, 99), slowest)
+	}
+	tw.Flush()
+	fmt.Fprintf(w, "%.1f requests/s\n", float64(total)/elapsed.Seconds())
+}
+
+// percentile expects sorted input and uses the nearest-rank method.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	idx := max((p*len(sorted)+99)/100-1, 0)
+	return sorted[idx].Round(time.Microsecond)
+}
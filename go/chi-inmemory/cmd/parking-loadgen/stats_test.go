@@ -0,0 +1,83 @@
+package main
+
+import (
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"parking-lot/internal/gate"
+)
+
+func TestParseMix(t *testing.T) {
+	mix, err := parseMix("park=3, leave=1,status=0")
+	if err != nil {
+		t.Fatalf("parseMix: %v", err)
+	}
+	counts := map[string]int{}
+	r := rand.New(rand.NewPCG(1, 2))
+	for range 4000 {
+		counts[mix.next(r)]++
+	}
+	if counts[opStatus] != 0 {
+		t.Errorf("status has no weight but was picked %d times", counts[opStatus])
+	}
+	if counts[opPark] < 2700 || counts[opPark] > 3300 {
+		t.Errorf("park picked %d of 4000 times, want about 3000", counts[opPark])
+	}
+
+	for _, bad := range []string{"", "park", "park=x", "park=-1", "drive=1", "park=1,park=2", "park=0"} {
+		if _, err := parseMix(bad); err == nil {
+			t.Errorf("parseMix(%q) succeeded", bad)
+		}
+	}
+}
+
+func TestStatsRecord(t *testing.T) {
+	s := newStats()
+	s.record(opPark, time.Millisecond, nil)
+	s.record(opPark, time.Millisecond, &gate.LotError{Status: http.StatusConflict, Type: "lot_full"})
+	s.record(opPark, time.Millisecond, &gate.LotError{Status: http.StatusInternalServerError})
+	s.record(opPark, time.Millisecond, errors.New("connection refused"))
+
+	got := *s.ops[opPark]
+	if got.OK != 1 || got.Rejected != 1 || got.Errors != 2 || got.seen != 4 {
+		t.Errorf("got ok=%d rejected=%d errors=%d seen=%d, want 1, 1, 2, 4", got.OK, got.Rejected, got.Errors, got.seen)
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	var sorted []time.Duration
+	for i := 1; i <= 100; i++ {
+		sorted = append(sorted, time.Duration(i)*time.Millisecond)
+	}
+	for p, want := range map[int]time.Duration{50: 50 * time.Millisecond, 95: 95 * time.Millisecond, 99: 99 * time.Millisecond} {
+		if got := percentile(sorted, p); got != want {
+			t.Errorf("p%d = %s, want %s", p, got, want)
+		}
+	}
+	if got := percentile(nil, 50); got != 0 {
+		t.Errorf("p50 of nothing = %s, want 0", got)
+	}
+}
+
+func TestParseMetrics(t *testing.T) {
+	body := `# HELP go_goroutines Number of goroutines that currently exist.
+# TYPE go_goroutines gauge
+go_goroutines 12
+go_memstats_heap_alloc_bytes 2.097152e+06
+go_memstats_heap_alloc_bytes_total 1e+09
+`
+	s, err := parseMetrics(strings.NewReader(body), time.Now())
+	if err != nil {
+		t.Fatalf("parseMetrics: %v", err)
+	}
+	if s.Goroutines != 12 || s.HeapBytes != 2<<20 {
+		t.Errorf("got %v goroutines and %v heap bytes, want 12 and %d", s.Goroutines, s.HeapBytes, 2<<20)
+	}
+	if _, err := parseMetrics(strings.NewReader("up 1\n"), time.Now()); err == nil {
+		t.Error("parseMetrics succeeded without the runtime gauges")
+	}
+}
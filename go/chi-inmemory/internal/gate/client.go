@@ -0,0 +1,173 @@
+package gate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"parking-lot/internal/server"
+)
+
+const lotRequestTimeout = 5 * time.Second
+
+var tracer = otel.Tracer("parking-lot-gate")
+
+// LotError is a request the lot service refused, with the status and
+// error_type it answered with.
+type LotError struct {
+	Status  int
+	Message string
+	Type    string
+}
+
+func (e *LotError) Error() string {
+	if e.Type != "" {
+		return fmt.Sprintf("lot service: %s (%s)", e.Message, e.Type)
+	}
+	return "lot service: " + e.Message
+}
+
+// Ticket is the lot's answer to a park request.
+type Ticket struct {
+	SlotNumber int  `json:"slot_number"`
+	Duplicate  bool `json:"duplicate"`
+}
+
+// Receipt is the lot's answer to a leave request.
+type Receipt struct {
+	SlotNumber      int     `json:"slot_number"`
+	Fee             float64 `json:"fee"`
+	Currency        string  `json:"currency"`
+	BilledHours     int     `json:"billed_hours"`
+	DurationSeconds int     `json:"duration_seconds"`
+}
+
+// LotClient calls the parking lot service's HTTP API. Every call is a
+// client span whose context is sent as traceparent, so the lot's spans join
+// the gate's trace.
+type LotClient struct {
+	baseURL string
+	client  *http.Client
+}
+
+func NewLotClient(baseURL string) *LotClient {
+	return &LotClient{
+		baseURL: baseURL,
+		client:  &http.Client{Timeout: lotRequestTimeout},
+	}
+}
+
+// lotResponse is the envelope of every lot service response.
+type lotResponse struct {
+	Success   bool            `json:"success"`
+	Data      json.RawMessage `json:"data"`
+	Error     string          `json:"error"`
+	ErrorType string          `json:"error_type"`
+}
+
+func (c *LotClient) do(ctx context.Context, method, path string, body, out any) error {
+	ctx, span := tracer.Start(ctx, method+" "+path,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("http.method", method),
+			attribute.String("http.url", c.baseURL+path),
+			attribute.String("peer.service", "parking-lot"),
+		))
+	defer span.End()
+
+	var reqBody io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reqBody)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+	defer resp.Body.Close()
+	span.SetAttributes(attribute.Int("http.status_code", resp.StatusCode))
+
+	var envelope lotResponse
+	if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		err = fmt.Errorf("decode lot response: %w", err)
+		span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+	if !envelope.Success {
+		lotErr := &LotError{Status: resp.StatusCode, Message: envelope.Error, Type: envelope.ErrorType}
+		if lotErr.Type != "" {
+			span.SetAttributes(attribute.String("error.type", lotErr.Type))
+		}
+		if resp.StatusCode >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, lotErr.Error())
+		}
+		return lotErr
+	}
+	if out != nil {
+		return json.Unmarshal(envelope.Data, out)
+	}
+	return nil
+}
+
+// EnsureLot creates a lot of the given capacity unless the lot service
+// already has one.
+func (c *LotClient) EnsureLot(ctx context.Context, capacity int) error {
+	_, err := c.Status(ctx)
+	var lotErr *LotError
+	if !errors.As(err, &lotErr) || lotErr.Status != http.StatusBadRequest {
+		return err
+	}
+	return c.do(ctx, http.MethodPost, "/api/parking-lot",
+		server.ParkingLotCreateRequest{Capacity: capacity}, nil)
+}
+
+func (c *LotClient) Park(ctx context.Context, req server.ParkVehicleRequest) (Ticket, error) {
+	var ticket Ticket
+	err := c.do(ctx, http.MethodPost, "/api/parking-lot/park", req, &ticket)
+	return ticket, err
+}
+
+// Find returns the slot holding registration.
+func (c *LotClient) Find(ctx context.Context, registration string) (int, error) {
+	var found server.FindVehicleResponse
+	err := c.do(ctx, http.MethodGet, "/api/parking-lot/find/"+url.PathEscape(registration), nil, &found)
+	return found.SlotNumber, err
+}
+
+func (c *LotClient) Leave(ctx context.Context, slotNumber int) (Receipt, error) {
+	var receipt Receipt
+	err := c.do(ctx, http.MethodPost, "/api/parking-lot/leave",
+		server.LeaveSlotRequest{SlotNumber: slotNumber}, &receipt)
+	return receipt, err
+}
+
+func (c *LotClient) Status(ctx context.Context) (server.StatusResponse, error) {
+	var status server.StatusResponse
+	err := c.do(ctx, http.MethodGet, "/api/parking-lot/status", nil, &status)
+	return status, err
+}
@@ -0,0 +1,294 @@
+// Package gate runs the entry and exit gates of the parking lot as services
+// of their own. A gate owns no state: it asks the lot service over HTTP to
+// park or release each vehicle, so one passage is traced across the gate
+// and the lot.
+package gate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+
+	"parking-lot/internal/server"
+)
+
+type Kind string
+
+const (
+	KindEntry Kind = "entry"
+	KindExit  Kind = "exit"
+)
+
+// ParseKind accepts "entry" or "exit".
+func ParseKind(s string) (Kind, error) {
+	switch Kind(s) {
+	case KindEntry, KindExit:
+		return Kind(s), nil
+	default:
+		return "", fmt.Errorf("unknown gate %q: must be entry or exit", s)
+	}
+}
+
+// Passage outcomes, recorded as the outcome label of gate_passages_total.
+const (
+	OutcomeAdmitted  = "admitted"
+	OutcomeDuplicate = "duplicate"
+	OutcomeReleased  = "released"
+	OutcomeRefused   = "refused"
+	OutcomeError     = "error"
+)
+
+type ExitRequest struct {
+	Registration string `json:"registration"`
+}
+
+type Gate struct {
+	kind Kind
+	lot  *LotClient
+
+	passages        metric.Int64Counter
+	passageDuration metric.Float64Histogram
+}
+
+func New(kind Kind, lot *LotClient, meter metric.Meter) (*Gate, error) {
+	passages, err := meter.Int64Counter("gate_passages_total",
+		metric.WithDescription("Vehicles handled by a gate, by outcome"),
+		metric.WithUnit("1"))
+	if err != nil {
+		return nil, err
+	}
+
+	passageDuration, err := meter.Float64Histogram("gate_passage_duration_seconds",
+		metric.WithDescription("Time for a gate to admit or release a vehicle, including the lot service call"),
+		metric.WithUnit("s"))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Gate{
+		kind:            kind,
+		lot:             lot,
+		passages:        passages,
+		passageDuration: passageDuration,
+	}, nil
+}
+
+// passage wraps one vehicle going through the gate in a gate.<kind> span
+// and records its outcome.
+func (g *Gate) passage(ctx context.Context, registration string, fn func(ctx context.Context) (string, error)) error {
+	start := time.Now()
+	ctx, span := tracer.Start(ctx, "gate."+string(g.kind),
+		trace.WithAttributes(
+			attribute.String("gate.kind", string(g.kind)),
+			attribute.String("vehicle.registration_number", registration),
+		))
+	defer span.End()
+
+	outcome, err := fn(ctx)
+	span.SetAttributes(attribute.String("gate.outcome", outcome))
+	if outcome == OutcomeError {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	attrs := metric.WithAttributes(
+		attribute.String("gate", string(g.kind)),
+		attribute.String("outcome", outcome),
+	)
+	g.passages.Add(ctx, 1, attrs)
+	g.passageDuration.Record(ctx, time.Since(start).Seconds(), attrs)
+	return err
+}
+
+// refusal returns the outcome for a failed lot call: the lot saying no is
+// a refusal, anything else an error.
+func refusal(err error) string {
+	var lotErr *LotError
+	if errors.As(err, &lotErr) && lotErr.Status < http.StatusInternalServerError {
+		return OutcomeRefused
+	}
+	return OutcomeError
+}
+
+// Enter asks the lot for a slot for the vehicle. A vehicle already inside
+// keeps its slot and is reported as a duplicate.
+func (g *Gate) Enter(ctx context.Context, req server.ParkVehicleRequest) (Ticket, error) {
+	var ticket Ticket
+	err := g.passage(ctx, req.Registration, func(ctx context.Context) (string, error) {
+		var err error
+		ticket, err = g.lot.Park(ctx, req)
+		switch {
+		case err != nil:
+			return refusal(err), err
+		case ticket.Duplicate:
+			return OutcomeDuplicate, nil
+		}
+		trace.SpanFromContext(ctx).SetAttributes(attribute.Int("allocated_slot_number", ticket.SlotNumber))
+		return OutcomeAdmitted, nil
+	})
+	return ticket, err
+}
+
+// Exit looks up the vehicle's slot and has the lot release it, returning
+// the fee.
+func (g *Gate) Exit(ctx context.Context, registration string) (Receipt, error) {
+	var receipt Receipt
+	err := g.passage(ctx, registration, func(ctx context.Context) (string, error) {
+		slot, err := g.lot.Find(ctx, registration)
+		if err != nil {
+			return refusal(err), err
+		}
+		receipt, err = g.lot.Leave(ctx, slot)
+		if err != nil {
+			return refusal(err), err
+		}
+		trace.SpanFromContext(ctx).SetAttributes(
+			attribute.Int("slot_number", slot),
+			attribute.Float64("parking.fee", receipt.Fee),
+		)
+		return OutcomeReleased, nil
+	})
+	return receipt, err
+}
+
+// Routes is the gate's HTTP API: POST /api/gate/entry or /api/gate/exit,
+// depending on its kind, plus /health.
+func (g *Gate) Routes() http.Handler {
+	r := chi.NewRouter()
+	r.Use(server.RecoveryMiddleware)
+	r.Use(server.RequestIDMiddleware)
+	r.Use(server.LoggingMiddleware)
+	r.Use(server.TracingMiddleware)
+
+	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
+		server.WriteJSON(w, http.StatusOK, map[string]any{"status": "healthy", "gate": g.kind})
+	})
+	switch g.kind {
+	case KindEntry:
+		r.Post("/api/gate/entry", g.handleEntry)
+	case KindExit:
+		r.Post("/api/gate/exit", g.handleExit)
+	}
+	return r
+}
+
+func (g *Gate) handleEntry(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var req server.ParkVehicleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		server.WriteError(ctx, w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.Registration == "" || req.Color == "" {
+		server.WriteError(ctx, w, http.StatusBadRequest, "Registration and color are required")
+		return
+	}
+
+	ticket, err := g.Enter(ctx, req)
+	if err != nil {
+		writeLotError(ctx, w, err)
+		return
+	}
+	server.WriteSuccess(ctx, w, "Vehicle admitted", map[string]any{
+		"slot_number":  ticket.SlotNumber,
+		"registration": req.Registration,
+		"duplicate":    ticket.Duplicate,
+	})
+}
+
+func (g *Gate) handleExit(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var req ExitRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		server.WriteError(ctx, w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.Registration == "" {
+		server.WriteError(ctx, w, http.StatusBadRequest, "Registration is required")
+		return
+	}
+
+	receipt, err := g.Exit(ctx, req.Registration)
+	if err != nil {
+		writeLotError(ctx, w, err)
+		return
+	}
+	server.WriteSuccess(ctx, w, "Vehicle released", receipt)
+}
+
+// writeLotError passes a refusal from the lot on with its status and
+// error_type; a lot that could not be reached is a 502.
+func writeLotError(ctx context.Context, w http.ResponseWriter, err error) {
+	var lotErr *LotError
+	if !errors.As(err, &lotErr) {
+		server.WriteError(ctx, w, http.StatusBadGateway, "Lot service unavailable")
+		return
+	}
+	trace.SpanFromContext(ctx).SetAttributes(attribute.String("error.type", lotErr.Type))
+	server.WriteJSON(w, lotErr.Status, server.Response{
+		Success:   false,
+		Error:     lotErr.Message,
+		ErrorType: lotErr.Type,
+	})
+}
+
+var colors = []string{"White", "Black", "Red", "Blue", "Silver", "Green"}
+
+// Simulate drives the gate until ctx is done: every interval the entry gate
+// admits a new vehicle, and the exit gate releases a random parked one.
+func (g *Gate) Simulate(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		var err error
+		switch g.kind {
+		case KindEntry:
+			_, err = g.Enter(ctx, server.ParkVehicleRequest{
+				Registration:   fmt.Sprintf("SIM-%04d", rand.IntN(10000)),
+				Color:          colors[rand.IntN(len(colors))],
+				DisabledPermit: rand.IntN(10) == 0,
+			})
+		case KindExit:
+			err = g.releaseRandom(ctx)
+		}
+		if err != nil && ctx.Err() == nil {
+			log.Printf("Simulated %s failed: %v", g.kind, err)
+		}
+	}
+}
+
+func (g *Gate) releaseRandom(ctx context.Context) error {
+	status, err := g.lot.Status(ctx)
+	if err != nil {
+		return err
+	}
+	var parked []string
+	for _, slot := range status.Slots {
+		if slot.Occupied {
+			parked = append(parked, slot.Registration)
+		}
+	}
+	if len(parked) == 0 {
+		return nil
+	}
+	_, err = g.Exit(ctx, parked[rand.IntN(len(parked))])
+	return err
+}
@@ -0,0 +1,164 @@
+package gate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/metric/noop"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"parking-lot/internal/server"
+)
+
+// fakeLot answers the lot service routes a gate uses and records the
+// traceparent of each request.
+type fakeLot struct {
+	parked       map[string]int
+	full         bool
+	traceparents []string
+}
+
+func (l *fakeLot) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	l.traceparents = append(l.traceparents, r.Header.Get("traceparent"))
+	ctx := r.Context()
+
+	switch {
+	case r.URL.Path == "/api/parking-lot/park":
+		var req server.ParkVehicleRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if l.full {
+			server.WriteJSON(w, http.StatusConflict, server.Response{Error: "parking lot is full", ErrorType: "lot_full"})
+			return
+		}
+		slot, duplicate := l.parked[req.Registration]
+		if !duplicate {
+			slot = len(l.parked) + 1
+			l.parked[req.Registration] = slot
+		}
+		server.WriteSuccess(ctx, w, "", map[string]any{"slot_number": slot, "duplicate": duplicate})
+	case strings.HasPrefix(r.URL.Path, "/api/parking-lot/find/"):
+		registration := strings.TrimPrefix(r.URL.Path, "/api/parking-lot/find/")
+		slot, ok := l.parked[registration]
+		if !ok {
+			server.WriteJSON(w, http.StatusNotFound, server.Response{Error: "vehicle not found", ErrorType: "vehicle_not_found"})
+			return
+		}
+		server.WriteSuccess(ctx, w, "", server.FindVehicleResponse{SlotNumber: slot, Registration: registration})
+	case r.URL.Path == "/api/parking-lot/leave":
+		var req server.LeaveSlotRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		server.WriteSuccess(ctx, w, "", Receipt{SlotNumber: req.SlotNumber, Fee: 2, Currency: "USD", BilledHours: 1})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func newTestGate(t *testing.T, kind Kind, lot *fakeLot) (*Gate, *tracetest.SpanRecorder) {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { provider.Shutdown(context.Background()) })
+
+	srv := httptest.NewServer(lot)
+	t.Cleanup(srv.Close)
+
+	g, err := New(kind, NewLotClient(srv.URL), noop.NewMeterProvider().Meter("test"))
+	if err != nil {
+		t.Fatalf("Failed to create gate: %v", err)
+	}
+	return g, recorder
+}
+
+func TestEnterPropagatesTrace(t *testing.T) {
+	lot := &fakeLot{parked: map[string]int{}}
+	g, recorder := newTestGate(t, KindEntry, lot)
+
+	ticket, err := g.Enter(context.Background(), server.ParkVehicleRequest{Registration: "KA-01-HH-1234", Color: "White"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if ticket.SlotNumber != 1 || ticket.Duplicate {
+		t.Errorf("Expected a new ticket for slot 1, got %+v", ticket)
+	}
+
+	var entry sdktrace.ReadOnlySpan
+	for _, span := range recorder.Ended() {
+		if span.Name() == "gate.entry" {
+			entry = span
+		}
+	}
+	if entry == nil {
+		t.Fatal("Expected a gate.entry span")
+	}
+	if len(lot.traceparents) != 1 || !strings.Contains(lot.traceparents[0], entry.SpanContext().TraceID().String()) {
+		t.Errorf("Expected the lot request to carry trace %s, got %v", entry.SpanContext().TraceID(), lot.traceparents)
+	}
+
+	ticket, err = g.Enter(context.Background(), server.ParkVehicleRequest{Registration: "KA-01-HH-1234", Color: "White"})
+	if err != nil || !ticket.Duplicate {
+		t.Errorf("Expected a duplicate ticket, got %+v, %v", ticket, err)
+	}
+}
+
+func TestEnterRefusedWhenFull(t *testing.T) {
+	g, _ := newTestGate(t, KindEntry, &fakeLot{parked: map[string]int{}, full: true})
+
+	_, err := g.Enter(context.Background(), server.ParkVehicleRequest{Registration: "KA-01-HH-1234", Color: "White"})
+	var lotErr *LotError
+	if !errors.As(err, &lotErr) {
+		t.Fatalf("Expected a LotError, got %v", err)
+	}
+	if lotErr.Status != http.StatusConflict || lotErr.Type != "lot_full" {
+		t.Errorf("Expected 409 lot_full, got %d %s", lotErr.Status, lotErr.Type)
+	}
+	if refusal(err) != OutcomeRefused {
+		t.Errorf("Expected outcome %s, got %s", OutcomeRefused, refusal(err))
+	}
+}
+
+func TestExit(t *testing.T) {
+	lot := &fakeLot{parked: map[string]int{"KA-01-HH-1234": 3}}
+	g, _ := newTestGate(t, KindExit, lot)
+
+	receipt, err := g.Exit(context.Background(), "KA-01-HH-1234")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if receipt.SlotNumber != 3 || receipt.Fee != 2 {
+		t.Errorf("Expected slot 3 released for 2, got %+v", receipt)
+	}
+
+	_, err = g.Exit(context.Background(), "KA-99-ZZ-0000")
+	var lotErr *LotError
+	if !errors.As(err, &lotErr) || lotErr.Type != "vehicle_not_found" {
+		t.Errorf("Expected vehicle_not_found, got %v", err)
+	}
+}
+
+func TestRoutesByKind(t *testing.T) {
+	g, _ := newTestGate(t, KindExit, &fakeLot{parked: map[string]int{}})
+
+	rec := httptest.NewRecorder()
+	body := strings.NewReader(`{"registration":"KA-01-HH-1234","color":"White"}`)
+	g.Routes().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/gate/entry", body))
+	if rec.Code == http.StatusOK {
+		t.Error("Expected the exit gate not to serve entries")
+	}
+
+	rec = httptest.NewRecorder()
+	body = strings.NewReader(`{"registration":"KA-01-HH-1234"}`)
+	g.Routes().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/gate/exit", body))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected the lot's 404 for an unknown vehicle, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,257 @@
+package parking
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	defaultAlertThresholds = "80,95,100"
+	webhookTimeout         = 5 * time.Second
+)
+
+const (
+	AlertRaised  = "raised"
+	AlertCleared = "cleared"
+)
+
+// OccupancyAlert is emitted each time occupancy crosses a threshold, in
+// either direction.
+type OccupancyAlert struct {
+	Threshold string    `json:"threshold"`
+	Direction string    `json:"direction"`
+	Occupied  int       `json:"occupied"`
+	Capacity  int       `json:"capacity"`
+	Occupancy float64   `json:"occupancy_percent"`
+	TraceID   string    `json:"trace_id,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+type AlertNotifier interface {
+	Notify(ctx context.Context, alert OccupancyAlert) error
+}
+
+// OccupancyAlerter tracks which thresholds the lot is currently above and
+// reports transitions as a structured log line, a counter increment and,
+// when configured, a notifier call.
+type OccupancyAlerter struct {
+	thresholds []float64
+	notifier   AlertNotifier
+	logger     *slog.Logger
+	alerts     metric.Int64Counter
+
+	mu     sync.Mutex
+	active int
+}
+
+// NewOccupancyAlerter takes thresholds as percentages of capacity. A nil
+// notifier disables outbound notifications.
+func NewOccupancyAlerter(meter metric.Meter, thresholds []float64, notifier AlertNotifier) (*OccupancyAlerter, error) {
+	alerts, err := meter.Int64Counter("parking_lot_occupancy_alerts_total",
+		metric.WithDescription("Occupancy threshold crossings"),
+		metric.WithUnit("1"))
+	if err != nil {
+		return nil, err
+	}
+
+	sorted := append([]float64(nil), thresholds...)
+	sort.Float64s(sorted)
+
+	return &OccupancyAlerter{
+		thresholds: sorted,
+		notifier:   notifier,
+		logger:     slog.New(slog.NewJSONHandler(os.Stdout, nil)),
+		alerts:     alerts,
+	}, nil
+}
+
+// NewOccupancyAlerterFromEnv reads PARKING_ALERT_THRESHOLDS (comma-separated
+// percentages, default 80,95,100) and PARKING_ALERT_WEBHOOK_URL.
+func NewOccupancyAlerterFromEnv(meter metric.Meter) (*OccupancyAlerter, error) {
+	raw := os.Getenv("PARKING_ALERT_THRESHOLDS")
+	if raw == "" {
+		raw = defaultAlertThresholds
+	}
+	thresholds, err := ParseThresholds(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	var notifier AlertNotifier
+	if url := os.Getenv("PARKING_ALERT_WEBHOOK_URL"); url != "" {
+		notifier = NewWebhookNotifier(url)
+	}
+
+	return NewOccupancyAlerter(meter, thresholds, notifier)
+}
+
+func ParseThresholds(raw string) ([]float64, error) {
+	var thresholds []float64
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		value, err := strconv.ParseFloat(part, 64)
+		if err != nil || value <= 0 || value > 100 {
+			return nil, fmt.Errorf("invalid alert threshold %q: must be a percentage in (0, 100]", part)
+		}
+		thresholds = append(thresholds, value)
+	}
+	return thresholds, nil
+}
+
+// Observe compares the current occupancy against the thresholds and emits
+// one alert per threshold crossed since the previous observation.
+func (a *OccupancyAlerter) Observe(ctx context.Context, occupied, capacity int) {
+	if capacity <= 0 {
+		return
+	}
+	percent := float64(occupied) / float64(capacity) * 100
+
+	a.mu.Lock()
+	level := 0
+	for level < len(a.thresholds) && percent >= a.thresholds[level] {
+		level++
+	}
+	prev := a.active
+	a.active = level
+	a.mu.Unlock()
+
+	for i := prev; i < level; i++ {
+		a.emit(ctx, a.thresholds[i], AlertRaised, occupied, capacity, percent)
+	}
+	for i := prev - 1; i >= level; i-- {
+		a.emit(ctx, a.thresholds[i], AlertCleared, occupied, capacity, percent)
+	}
+}
+
+func (a *OccupancyAlerter) emit(ctx context.Context, threshold float64, direction string, occupied, capacity int, percent float64) {
+	alert := OccupancyAlert{
+		Threshold: thresholdName(threshold),
+		Direction: direction,
+		Occupied:  occupied,
+		Capacity:  capacity,
+		Occupancy: percent,
+		Timestamp: time.Now().UTC(),
+	}
+
+	spanCtx := trace.SpanContextFromContext(ctx)
+	if spanCtx.HasTraceID() {
+		alert.TraceID = spanCtx.TraceID().String()
+	}
+	trace.SpanFromContext(ctx).AddEvent("occupancy_threshold_crossed", trace.WithAttributes(
+		attribute.String("alert.threshold", alert.Threshold),
+		attribute.String("alert.direction", direction),
+	))
+
+	a.alerts.Add(ctx, 1, metric.WithAttributes(
+		attribute.String("threshold", alert.Threshold),
+		attribute.String("direction", direction),
+	))
+
+	level := slog.LevelWarn
+	if direction == AlertCleared {
+		level = slog.LevelInfo
+	}
+	a.logger.LogAttrs(ctx, level, "parking lot occupancy threshold crossed",
+		slog.String("event", "occupancy_alert"),
+		slog.String("threshold", alert.Threshold),
+		slog.String("direction", direction),
+		slog.Int("occupied", occupied),
+		slog.Int("capacity", capacity),
+		slog.Float64("occupancy_percent", percent),
+		slog.String("trace_id", alert.TraceID),
+	)
+
+	if a.notifier != nil {
+		if err := a.notifier.Notify(ctx, alert); err != nil {
+			a.logger.LogAttrs(ctx, slog.LevelError, "occupancy alert notification failed",
+				slog.String("threshold", alert.Threshold),
+				slog.String("error", err.Error()),
+			)
+		}
+	}
+}
+
+func thresholdName(threshold float64) string {
+	if threshold >= 100 {
+		return "full"
+	}
+	return strconv.FormatFloat(threshold, 'f', -1, 64) + "%"
+}
+
+// WebhookNotifier POSTs each alert as JSON. Delivery runs in the background
+// so a slow receiver never holds up a park or leave request; failures are
+// logged but not retried.
+type WebhookNotifier struct {
+	url    string
+	client *http.Client
+	logger *slog.Logger
+}
+
+func NewWebhookNotifier(url string) *WebhookNotifier {
+	return &WebhookNotifier{
+		url:    url,
+		client: &http.Client{Timeout: webhookTimeout},
+		logger: slog.New(slog.NewJSONHandler(os.Stdout, nil)),
+	}
+}
+
+func (n *WebhookNotifier) Notify(ctx context.Context, alert OccupancyAlert) error {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+
+	// Keep the trace context for propagation but detach from the request's
+	// cancellation, which fires as soon as the handler returns.
+	headers := http.Header{}
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(headers))
+
+	go func() {
+		if err := n.send(context.Background(), headers, body); err != nil {
+			n.logger.Error("occupancy alert webhook failed",
+				slog.String("threshold", alert.Threshold),
+				slog.String("error", err.Error()),
+			)
+		}
+	}()
+	return nil
+}
+
+func (n *WebhookNotifier) send(ctx context.Context, headers http.Header, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header = headers
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package parking
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/metric/noop"
+)
+
+type recordingNotifier struct {
+	alerts []OccupancyAlert
+}
+
+func (n *recordingNotifier) Notify(_ context.Context, alert OccupancyAlert) error {
+	n.alerts = append(n.alerts, alert)
+	return nil
+}
+
+func TestOccupancyAlerterCrossings(t *testing.T) {
+	notifier := &recordingNotifier{}
+	alerter, err := NewOccupancyAlerter(noop.NewMeterProvider().Meter("test"), []float64{100, 80, 95}, notifier)
+	if err != nil {
+		t.Fatalf("Failed to create alerter: %v", err)
+	}
+
+	ctx := context.Background()
+	steps := []struct {
+		occupied int
+		want     []string
+	}{
+		{occupied: 15, want: nil},
+		{occupied: 16, want: []string{"80% raised"}},
+		{occupied: 17, want: nil},
+		{occupied: 20, want: []string{"95% raised", "full raised"}},
+		{occupied: 18, want: []string{"full cleared", "95% cleared"}},
+		{occupied: 10, want: []string{"80% cleared"}},
+	}
+
+	for _, step := range steps {
+		notifier.alerts = nil
+		alerter.Observe(ctx, step.occupied, 20)
+
+		var got []string
+		for _, a := range notifier.alerts {
+			got = append(got, a.Threshold+" "+a.Direction)
+		}
+		if len(got) != len(step.want) {
+			t.Fatalf("occupied=%d: expected %v, got %v", step.occupied, step.want, got)
+		}
+		for i := range got {
+			if got[i] != step.want[i] {
+				t.Errorf("occupied=%d: expected %v, got %v", step.occupied, step.want, got)
+			}
+		}
+	}
+}
+
+func TestParseThresholds(t *testing.T) {
+	thresholds, err := ParseThresholds(" 80, 95 ,100")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+	if len(thresholds) != 3 || thresholds[0] != 80 || thresholds[2] != 100 {
+		t.Errorf("Unexpected thresholds: %v", thresholds)
+	}
+
+	for _, raw := range []string{"abc", "0", "120"} {
+		if _, err := ParseThresholds(raw); err == nil {
+			t.Errorf("Expected error for %q", raw)
+		}
+	}
+}
+
+func TestWebhookNotifierPostsAlert(t *testing.T) {
+	received := make(chan OccupancyAlert, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var alert OccupancyAlert
+		if err := json.NewDecoder(r.Body).Decode(&alert); err != nil {
+			t.Errorf("Failed to decode webhook body: %v", err)
+		}
+		received <- alert
+	}))
+	defer srv.Close()
+
+	notifier := NewWebhookNotifier(srv.URL)
+	if err := notifier.Notify(context.Background(), OccupancyAlert{Threshold: "full", Direction: AlertRaised}); err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+
+	select {
+	case alert := <-received:
+		if alert.Threshold != "full" || alert.Direction != AlertRaised {
+			t.Errorf("Unexpected alert: %+v", alert)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Webhook was not called")
+	}
+}
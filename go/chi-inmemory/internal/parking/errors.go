@@ -0,0 +1,44 @@
+package parking
+
+import "errors"
+
+// Errors returned by ParkingLot operations. Callers match them with
+// errors.Is; ErrorType names each one for telemetry.
+var (
+	ErrLotFull         = errors.New("parking lot is full")
+	ErrInvalidSlot     = errors.New("invalid slot number")
+	ErrSlotEmpty       = errors.New("slot is already empty")
+	ErrSlotOccupied    = errors.New("slot is occupied")
+	ErrVehicleNotFound = errors.New("vehicle not found")
+
+	// ErrDuplicateVehicle is returned, together with the vehicle's current
+	// slot, when a registration that is already in the lot is parked again.
+	ErrDuplicateVehicle = errors.New("vehicle is already parked")
+)
+
+var errorTypes = []struct {
+	err  error
+	name string
+}{
+	{ErrLotFull, "lot_full"},
+	{ErrInvalidSlot, "invalid_slot"},
+	{ErrSlotEmpty, "slot_empty"},
+	{ErrSlotOccupied, "slot_occupied"},
+	{ErrVehicleNotFound, "vehicle_not_found"},
+	{ErrDuplicateVehicle, "duplicate_vehicle"},
+}
+
+// ErrorType is the error.type attribute value for err: a fixed name per
+// parking error, or "_OTHER" for anything else, so dashboards can break
+// error rates down by cause without unbounded label values.
+func ErrorType(err error) string {
+	if err == nil {
+		return ""
+	}
+	for _, t := range errorTypes {
+		if errors.Is(err, t.err) {
+			return t.name
+		}
+	}
+	return "_OTHER"
+}
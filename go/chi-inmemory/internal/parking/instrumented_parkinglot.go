@@ -2,6 +2,9 @@ package parking
 
 import (
 	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"go.opentelemetry.io/otel/attribute"
@@ -14,12 +17,24 @@ type InstrumentedParkingLot struct {
 	*ParkingLot
 	telemetry *TelemetryProvider
 
+	// mu serializes changes to the slots so two concurrent requests for the
+	// same registration cannot both be allocated a slot.
+	mu sync.Mutex
+
 	// Metrics
-	parkingOperations metric.Int64Counter
-	leavingOperations metric.Int64Counter
-	occupancyGauge    metric.Int64UpDownCounter
-	operationDuration metric.Float64Histogram
-	totalSlotsGauge   metric.Int64UpDownCounter
+	parkingOperations   metric.Int64Counter
+	duplicatesPrevented metric.Int64Counter
+	leavingOperations   metric.Int64Counter
+	occupancyGauge      metric.Int64UpDownCounter
+	operationDuration   metric.Float64Histogram
+	totalSlotsGauge     metric.Int64UpDownCounter
+
+	alerter *OccupancyAlerter
+	pricing *Pricing
+
+	// occupied mirrors the occupied slot count for the pricing gauge
+	// callback, which runs on the metric reader's goroutine.
+	occupied atomic.Int64
 }
 
 func NewInstrumentedParkingLot(capacity int, telemetry *TelemetryProvider) (*InstrumentedParkingLot, error) {
@@ -34,6 +49,13 @@ func NewInstrumentedParkingLot(capacity int, telemetry *TelemetryProvider) (*Ins
 		return nil, err
 	}
 
+	duplicatesPrevented, err := meter.Int64Counter("parking_duplicates_prevented_total",
+		metric.WithDescription("Park requests for a registration that was already parked"),
+		metric.WithUnit("1"))
+	if err != nil {
+		return nil, err
+	}
+
 	leavingOperations, err := meter.Int64Counter("leaving_operations_total",
 		metric.WithDescription("Total number of leaving operations"),
 		metric.WithUnit("1"))
@@ -62,36 +84,75 @@ func NewInstrumentedParkingLot(capacity int, telemetry *TelemetryProvider) (*Ins
 		return nil, err
 	}
 
+	alerter, err := NewOccupancyAlerterFromEnv(meter)
+	if err != nil {
+		return nil, err
+	}
+
+	pricing, err := NewPricingFromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	pricingMultiplier, err := meter.Float64ObservableGauge("parking_lot_pricing_multiplier",
+		metric.WithDescription("Price multiplier quoted to a vehicle arriving now"),
+		metric.WithUnit("1"))
+	if err != nil {
+		return nil, err
+	}
+
 	ipl := &InstrumentedParkingLot{
-		ParkingLot:        baseParkingLot,
-		telemetry:         telemetry,
-		parkingOperations: parkingOperations,
-		leavingOperations: leavingOperations,
-		occupancyGauge:    occupancyGauge,
-		operationDuration: operationDuration,
-		totalSlotsGauge:   totalSlotsGauge,
+		ParkingLot:          baseParkingLot,
+		telemetry:           telemetry,
+		parkingOperations:   parkingOperations,
+		duplicatesPrevented: duplicatesPrevented,
+		leavingOperations:   leavingOperations,
+		occupancyGauge:      occupancyGauge,
+		operationDuration:   operationDuration,
+		totalSlotsGauge:     totalSlotsGauge,
+		alerter:             alerter,
+		pricing:             pricing,
+	}
+
+	_, err = meter.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		quote := ipl.pricing.Config().Quote(time.Now(), int(ipl.occupied.Load()), capacity)
+		o.ObserveFloat64(pricingMultiplier, quote.Multiplier)
+		return nil
+	}, pricingMultiplier)
+	if err != nil {
+		return nil, err
 	}
 
-	// Set initial total slots metric
-	totalSlotsGauge.Add(context.Background(), int64(capacity))
+	// Every slot starts as standard
+	totalSlotsGauge.Add(context.Background(), int64(capacity), slotClassAttr(SlotClassStandard))
 
 	return ipl, nil
 }
 
 func (ipl *InstrumentedParkingLot) Park(ctx context.Context, registrationNumber, color string) (int, error) {
+	return ipl.ParkWithPermit(ctx, registrationNumber, color, false)
+}
+
+func (ipl *InstrumentedParkingLot) ParkWithPermit(ctx context.Context, registrationNumber, color string, disabledPermit bool) (int, error) {
 	tracer := ipl.telemetry.Tracer()
 	ctx, span := tracer.Start(ctx, "parking_lot.park",
 		trace.WithAttributes(
 			attribute.String("vehicle.registration_number", registrationNumber),
 			attribute.String("vehicle.color", color),
+			attribute.Bool("vehicle.disabled_permit", disabledPermit),
 		))
 	defer span.End()
 
+	ipl.mu.Lock()
+	defer ipl.mu.Unlock()
+
 	start := time.Now()
 
 	span.AddEvent("finding_available_slot")
 
-	slotNumber, err := ipl.ParkingLot.Park(registrationNumber, color)
+	quote := ipl.pricing.Config().Quote(start, len(ipl.ParkingLot.GetStatus()), ipl.capacity)
+
+	slotNumber, err := ipl.ParkingLot.ParkWithPermit(registrationNumber, color, disabledPermit)
 
 	duration := time.Since(start).Seconds()
 
@@ -100,23 +161,51 @@ func (ipl *InstrumentedParkingLot) Park(ctx context.Context, registrationNumber,
 		attribute.String("vehicle_color", color),
 	}
 
-	if err != nil {
+	switch {
+	case errors.Is(err, ErrDuplicateVehicle):
+		// Not a failure: the caller gets the slot the vehicle already holds.
+		labels = append(labels,
+			attribute.String("status", "duplicate"),
+			attribute.Int("allocated_slot", slotNumber),
+		)
+		span.SetAttributes(
+			attribute.Bool("parking.duplicate", true),
+			attribute.Int("allocated_slot_number", slotNumber),
+		)
+		span.AddEvent("duplicate_prevented", trace.WithAttributes(
+			attribute.Int("slot_number", slotNumber),
+		))
+		ipl.parkingOperations.Add(ctx, 1, metric.WithAttributes(labels...))
+		ipl.duplicatesPrevented.Add(ctx, 1)
+	case err != nil:
 		span.RecordError(err)
 		span.SetStatus(codes.Error, err.Error())
-		labels = append(labels, attribute.String("status", "failed"))
+		span.SetAttributes(errorTypeAttr(err))
+		labels = append(labels, attribute.String("status", "failed"), errorTypeAttr(err))
 		ipl.parkingOperations.Add(ctx, 1, metric.WithAttributes(labels...))
-	} else {
+	default:
+		slot := ipl.slots[slotNumber-1]
+		slot.Vehicle.PricingMultiplier = quote.Multiplier
+		class := slot.Class
 		labels = append(labels,
 			attribute.String("status", "success"),
 			attribute.Int("allocated_slot", slotNumber),
+			attribute.String("slot_class", string(class)),
+		)
+		span.SetAttributes(
+			attribute.Int("allocated_slot_number", slotNumber),
+			attribute.String("slot.class", string(class)),
+			attribute.Float64("pricing.multiplier", quote.Multiplier),
+			attribute.StringSlice("pricing.rules", quote.Rules),
 		)
-		span.SetAttributes(attribute.Int("allocated_slot_number", slotNumber))
 		span.AddEvent("slot_allocated", trace.WithAttributes(
 			attribute.Int("slot_number", slotNumber),
 		))
 
 		ipl.parkingOperations.Add(ctx, 1, metric.WithAttributes(labels...))
-		ipl.occupancyGauge.Add(ctx, 1)
+		ipl.occupancyGauge.Add(ctx, 1, slotClassAttr(class))
+		ipl.occupied.Add(1)
+		ipl.alerter.Observe(ctx, len(ipl.ParkingLot.GetStatus()), ipl.capacity)
 	}
 
 	ipl.operationDuration.Record(ctx, duration, metric.WithAttributes(labels...))
@@ -124,7 +213,9 @@ func (ipl *InstrumentedParkingLot) Park(ctx context.Context, registrationNumber,
 	return slotNumber, err
 }
 
-func (ipl *InstrumentedParkingLot) Leave(ctx context.Context, slotNumber int) error {
+// Leave frees the slot and returns the charge for the stay, priced at the
+// multiplier quoted when the vehicle arrived.
+func (ipl *InstrumentedParkingLot) Leave(ctx context.Context, slotNumber int) (ParkingCharge, error) {
 	tracer := ipl.telemetry.Tracer()
 	ctx, span := tracer.Start(ctx, "parking_lot.leave",
 		trace.WithAttributes(
@@ -132,12 +223,17 @@ func (ipl *InstrumentedParkingLot) Leave(ctx context.Context, slotNumber int) er
 		))
 	defer span.End()
 
+	ipl.mu.Lock()
+	defer ipl.mu.Unlock()
+
 	start := time.Now()
 
 	// Get vehicle info before leaving for metrics
 	var vehicleInfo *Vehicle
+	class := SlotClassStandard
 	if slotNumber >= 1 && slotNumber <= ipl.capacity {
 		slot := ipl.slots[slotNumber-1]
+		class = slot.Class
 		if slot.IsOccupied {
 			vehicleInfo = slot.Vehicle
 		}
@@ -149,9 +245,11 @@ func (ipl *InstrumentedParkingLot) Leave(ctx context.Context, slotNumber int) er
 
 	duration := time.Since(start).Seconds()
 
+	var charge ParkingCharge
 	labels := []attribute.KeyValue{
 		attribute.String("operation", "leave"),
 		attribute.Int("slot_number", slotNumber),
+		attribute.String("slot_class", string(class)),
 	}
 
 	if vehicleInfo != nil {
@@ -168,16 +266,82 @@ func (ipl *InstrumentedParkingLot) Leave(ctx context.Context, slotNumber int) er
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, err.Error())
-		labels = append(labels, attribute.String("status", "failed"))
+		span.SetAttributes(errorTypeAttr(err))
+		labels = append(labels, attribute.String("status", "failed"), errorTypeAttr(err))
 	} else {
 		labels = append(labels, attribute.String("status", "success"))
 		span.AddEvent("slot_released")
-		ipl.occupancyGauge.Add(ctx, -1)
+
+		charge = ipl.pricing.Config().Charge(vehicleInfo.ParkedAt, start, vehicleInfo.PricingMultiplier)
+		span.SetAttributes(
+			attribute.Float64("pricing.multiplier", charge.Multiplier),
+			attribute.Float64("parking.fee", charge.Fee),
+			attribute.String("parking.currency", charge.Currency),
+			attribute.Int("parking.billed_hours", charge.BilledHours),
+			attribute.Float64("parking.duration_seconds", charge.Duration.Seconds()),
+		)
+
+		ipl.occupancyGauge.Add(ctx, -1, slotClassAttr(class))
+		ipl.occupied.Add(-1)
+		ipl.alerter.Observe(ctx, len(ipl.ParkingLot.GetStatus()), ipl.capacity)
 	}
 
 	ipl.leavingOperations.Add(ctx, 1, metric.WithAttributes(labels...))
 	ipl.operationDuration.Record(ctx, duration, metric.WithAttributes(labels...))
 
+	return charge, err
+}
+
+// SetSlotClass reclassifies a slot and moves its contribution to the
+// per-class slot and occupancy metrics.
+func (ipl *InstrumentedParkingLot) SetSlotClass(ctx context.Context, slotNumber int, class SlotClass) error {
+	tracer := ipl.telemetry.Tracer()
+	ctx, span := tracer.Start(ctx, "parking_lot.set_slot_class",
+		trace.WithAttributes(
+			attribute.Int("slot_number", slotNumber),
+			attribute.String("slot.class", string(class)),
+		))
+	defer span.End()
+
+	ipl.mu.Lock()
+	defer ipl.mu.Unlock()
+
+	start := time.Now()
+
+	var previous SlotClass
+	if slotNumber >= 1 && slotNumber <= ipl.capacity {
+		previous = ipl.slots[slotNumber-1].Class
+	}
+
+	err := ipl.ParkingLot.SetSlotClass(slotNumber, class)
+
+	duration := time.Since(start).Seconds()
+
+	labels := []attribute.KeyValue{
+		attribute.String("operation", "set_slot_class"),
+		attribute.String("slot_class", string(class)),
+	}
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		span.SetAttributes(errorTypeAttr(err))
+		labels = append(labels, attribute.String("status", "failed"), errorTypeAttr(err))
+	} else {
+		labels = append(labels, attribute.String("status", "success"))
+		span.SetAttributes(attribute.String("slot.previous_class", string(previous)))
+		if previous != class {
+			ipl.totalSlotsGauge.Add(ctx, -1, slotClassAttr(previous))
+			ipl.totalSlotsGauge.Add(ctx, 1, slotClassAttr(class))
+			if ipl.slots[slotNumber-1].IsOccupied {
+				ipl.occupancyGauge.Add(ctx, -1, slotClassAttr(previous))
+				ipl.occupancyGauge.Add(ctx, 1, slotClassAttr(class))
+			}
+		}
+	}
+
+	ipl.operationDuration.Record(ctx, duration, metric.WithAttributes(labels...))
+
 	return err
 }
 
@@ -232,7 +396,8 @@ func (ipl *InstrumentedParkingLot) GetSlotByRegistrationNumber(ctx context.Conte
 
 	if err != nil {
 		span.AddEvent("vehicle_not_found")
-		labels = append(labels, attribute.String("status", "not_found"))
+		span.SetAttributes(errorTypeAttr(err))
+		labels = append(labels, attribute.String("status", "not_found"), errorTypeAttr(err))
 	} else {
 		span.SetAttributes(attribute.Int("found_slot_number", slotNumber))
 		span.AddEvent("vehicle_found", trace.WithAttributes(
@@ -248,3 +413,13 @@ func (ipl *InstrumentedParkingLot) GetSlotByRegistrationNumber(ctx context.Conte
 
 	return slotNumber, err
 }
+
+func slotClassAttr(class SlotClass) metric.AddOption {
+	return metric.WithAttributes(attribute.String("slot_class", string(class)))
+}
+
+// errorTypeAttr labels a failed operation's span and metrics with the kind
+// of parking error behind it.
+func errorTypeAttr(err error) attribute.KeyValue {
+	return attribute.String("error.type", ErrorType(err))
+}
@@ -2,6 +2,7 @@ package parking
 
 import (
 	"context"
+	"errors"
 	"os"
 	"testing"
 )
@@ -42,6 +43,15 @@ func TestInstrumentedParkingLotIntegration(t *testing.T) {
 		t.Errorf("Expected slot number 1, got %d", slotNumber)
 	}
 
+	// Parking the same registration again returns the existing slot
+	slotNumber, err = ipl.Park(ctx, "KA01HH1234", "White")
+	if !errors.Is(err, ErrDuplicateVehicle) {
+		t.Errorf("Expected ErrDuplicateVehicle, got %v", err)
+	}
+	if slotNumber != 1 {
+		t.Errorf("Expected existing slot 1, got %d", slotNumber)
+	}
+
 	// Test status
 	status := ipl.GetStatus(ctx)
 	if len(status) != 1 {
@@ -58,10 +68,13 @@ func TestInstrumentedParkingLotIntegration(t *testing.T) {
 	}
 
 	// Test leaving
-	err = ipl.Leave(ctx, 1)
+	charge, err := ipl.Leave(ctx, 1)
 	if err != nil {
 		t.Errorf("Unexpected error: %s", err.Error())
 	}
+	if charge.BilledHours != 1 || charge.Fee != defaultBaseRate {
+		t.Errorf("Expected one hour at the base rate, got %+v", charge)
+	}
 
 	// Verify slot is free
 	status = ipl.GetStatus(ctx)
@@ -3,6 +3,7 @@ package parking
 import (
 	"bufio"
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"strconv"
@@ -144,6 +145,13 @@ func (s *InstrumentedShell) handlePark(ctx context.Context, parts []string) {
 	)
 
 	slotNumber, err := s.instrumentedParkingLot.Park(ctx, registrationNumber, color)
+	if errors.Is(err, ErrDuplicateVehicle) {
+		span.AddEvent("already_parked", trace.WithAttributes(
+			attribute.Int("allocated_slot", slotNumber),
+		))
+		fmt.Printf("Already parked at slot number: %d\n", slotNumber)
+		return
+	}
 	if err != nil {
 		span.AddEvent("parking_failed")
 		fmt.Println("Sorry, parking lot is full")
@@ -183,7 +191,7 @@ func (s *InstrumentedShell) handleLeave(ctx context.Context, parts []string) {
 
 	span.SetAttributes(attribute.Int("slot_number", slotNumber))
 
-	err = s.instrumentedParkingLot.Leave(ctx, slotNumber)
+	charge, err := s.instrumentedParkingLot.Leave(ctx, slotNumber)
 	if err != nil {
 		span.AddEvent("leave_failed")
 		fmt.Printf("Error: %s\n", err.Error())
@@ -191,7 +199,7 @@ func (s *InstrumentedShell) handleLeave(ctx context.Context, parts []string) {
 	}
 
 	span.AddEvent("leave_successful")
-	fmt.Printf("Slot number %d is free\n", slotNumber)
+	fmt.Printf("Slot number %d is free (fee %.2f %s)\n", slotNumber, charge.Fee, charge.Currency)
 }
 
 func (s *InstrumentedShell) handleStatus(ctx context.Context) {
@@ -1,9 +1,6 @@
 package parking
 
-import (
-	"fmt"
-	"sort"
-)
+import "sort"
 
 type ParkingLot struct {
 	capacity int
@@ -23,24 +20,66 @@ func NewParkingLot(capacity int) *ParkingLot {
 }
 
 func (pl *ParkingLot) Park(registrationNumber, color string) (int, error) {
+	return pl.ParkWithPermit(registrationNumber, color, false)
+}
+
+// ParkWithPermit allocates the nearest slot the vehicle may use. Permit
+// holders are placed in a reserved slot when one is free so standard slots
+// stay available for everyone else.
+func (pl *ParkingLot) ParkWithPermit(registrationNumber, color string, disabledPermit bool) (int, error) {
+	if existing, err := pl.GetSlotByRegistrationNumber(registrationNumber); err == nil {
+		return existing, ErrDuplicateVehicle
+	}
+
+	slot := pl.findSlot(disabledPermit)
+	if slot == nil {
+		return 0, ErrLotFull
+	}
+
+	slot.Park(NewVehicle(registrationNumber, color))
+	return slot.Number, nil
+}
+
+func (pl *ParkingLot) findSlot(disabledPermit bool) *Slot {
+	if disabledPermit {
+		for _, slot := range pl.slots {
+			if slot.Class == SlotClassDisabled && slot.Accepts(true) {
+				return slot
+			}
+		}
+	}
 	for _, slot := range pl.slots {
-		if !slot.IsOccupied {
-			vehicle := NewVehicle(registrationNumber, color)
-			slot.Park(vehicle)
-			return slot.Number, nil
+		if slot.Accepts(disabledPermit) {
+			return slot
 		}
 	}
-	return 0, fmt.Errorf("parking lot is full")
+	return nil
+}
+
+// SetSlotClass changes a slot's class. An occupied slot cannot be taken
+// into maintenance until its vehicle leaves.
+func (pl *ParkingLot) SetSlotClass(slotNumber int, class SlotClass) error {
+	if slotNumber < 1 || slotNumber > pl.capacity {
+		return ErrInvalidSlot
+	}
+
+	slot := pl.slots[slotNumber-1]
+	if class == SlotClassMaintenance && slot.IsOccupied {
+		return ErrSlotOccupied
+	}
+
+	slot.Class = class
+	return nil
 }
 
 func (pl *ParkingLot) Leave(slotNumber int) error {
 	if slotNumber < 1 || slotNumber > pl.capacity {
-		return fmt.Errorf("invalid slot number")
+		return ErrInvalidSlot
 	}
 
 	slot := pl.slots[slotNumber-1]
 	if !slot.IsOccupied {
-		return fmt.Errorf("slot is already empty")
+		return ErrSlotEmpty
 	}
 
 	slot.Leave()
@@ -68,7 +107,12 @@ func (pl *ParkingLot) GetSlotByRegistrationNumber(registrationNumber string) (in
 			return slot.Number, nil
 		}
 	}
-	return 0, fmt.Errorf("not found")
+	return 0, ErrVehicleNotFound
+}
+
+// GetSlots returns every slot in slot-number order.
+func (pl *ParkingLot) GetSlots() []*Slot {
+	return pl.slots
 }
 
 func (pl *ParkingLot) GetCapacity() int {
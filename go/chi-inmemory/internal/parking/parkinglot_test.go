@@ -1,6 +1,9 @@
 package parking
 
-import "testing"
+import (
+	"errors"
+	"testing"
+)
 
 func TestNewParkingLot(t *testing.T) {
 	capacity := 6
@@ -123,3 +126,126 @@ func TestParkingLotGetStatus(t *testing.T) {
 		}
 	}
 }
+
+func TestParkingLotSlotClasses(t *testing.T) {
+	pl := NewParkingLot(4)
+
+	if err := pl.SetSlotClass(1, SlotClassDisabled); err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+	if err := pl.SetSlotClass(2, SlotClassMaintenance); err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+
+	slotNumber, err := pl.Park("KA01HH1234", "White")
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err.Error())
+	}
+	if slotNumber != 3 {
+		t.Errorf("Expected standard vehicle in slot 3, got %d", slotNumber)
+	}
+
+	slotNumber, err = pl.ParkWithPermit("KA01HH9999", "Black", true)
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err.Error())
+	}
+	if slotNumber != 1 {
+		t.Errorf("Expected permit holder in reserved slot 1, got %d", slotNumber)
+	}
+
+	if _, err := pl.Park("KA01BB0001", "Red"); err != nil {
+		t.Errorf("Unexpected error: %s", err.Error())
+	}
+
+	// Only maintenance remains free
+	if _, err := pl.Park("KA01BB0002", "Blue"); err == nil {
+		t.Error("Expected error when only reserved or maintenance slots remain")
+	}
+
+	if err := pl.SetSlotClass(3, SlotClassMaintenance); err == nil {
+		t.Error("Expected error when taking an occupied slot into maintenance")
+	}
+}
+
+func TestParkingLotParkDuplicateRegistration(t *testing.T) {
+	pl := NewParkingLot(3)
+
+	if _, err := pl.Park("KA01HH1234", "White"); err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+
+	slotNumber, err := pl.Park("KA01HH1234", "White")
+	if !errors.Is(err, ErrDuplicateVehicle) {
+		t.Errorf("Expected ErrDuplicateVehicle, got %v", err)
+	}
+	if slotNumber != 1 {
+		t.Errorf("Expected existing slot 1, got %d", slotNumber)
+	}
+	if len(pl.GetStatus()) != 1 {
+		t.Errorf("Expected 1 occupied slot, got %d", len(pl.GetStatus()))
+	}
+
+	// Once the vehicle leaves it can park again
+	pl.Leave(1)
+	if _, err := pl.Park("KA01HH1234", "White"); err != nil {
+		t.Errorf("Unexpected error: %s", err.Error())
+	}
+}
+
+func TestParkingLotPermitFallsBackToStandard(t *testing.T) {
+	pl := NewParkingLot(2)
+	pl.SetSlotClass(2, SlotClassDisabled)
+
+	pl.ParkWithPermit("KA01HH1234", "White", true)
+	slotNumber, err := pl.ParkWithPermit("KA01HH9999", "Black", true)
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err.Error())
+	}
+	if slotNumber != 1 {
+		t.Errorf("Expected permit holder in standard slot 1, got %d", slotNumber)
+	}
+}
+
+func TestParkingLotTypedErrors(t *testing.T) {
+	pl := NewParkingLot(1)
+	if _, err := pl.Park("KA01HH1234", "White"); err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+
+	_, errFull := pl.Park("KA01HH9999", "Red")
+	_, errDuplicate := pl.Park("KA01HH1234", "White")
+	_, errNotFound := pl.GetSlotByRegistrationNumber("KA01HH9999")
+	errOccupied := pl.SetSlotClass(1, SlotClassMaintenance)
+	errInvalid := pl.Leave(5)
+	pl.Leave(1)
+	errEmpty := pl.Leave(1)
+
+	tests := []struct {
+		name     string
+		err      error
+		target   error
+		wantType string
+	}{
+		{"lot full", errFull, ErrLotFull, "lot_full"},
+		{"duplicate", errDuplicate, ErrDuplicateVehicle, "duplicate_vehicle"},
+		{"not found", errNotFound, ErrVehicleNotFound, "vehicle_not_found"},
+		{"occupied", errOccupied, ErrSlotOccupied, "slot_occupied"},
+		{"invalid slot", errInvalid, ErrInvalidSlot, "invalid_slot"},
+		{"empty slot", errEmpty, ErrSlotEmpty, "slot_empty"},
+	}
+	for _, tt := range tests {
+		if !errors.Is(tt.err, tt.target) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.target, tt.err)
+		}
+		if got := ErrorType(tt.err); got != tt.wantType {
+			t.Errorf("%s: expected error type %q, got %q", tt.name, tt.wantType, got)
+		}
+	}
+
+	if got := ErrorType(errors.New("boom")); got != "_OTHER" {
+		t.Errorf("Expected error type _OTHER, got %q", got)
+	}
+	if got := ErrorType(nil); got != "" {
+		t.Errorf("Expected empty error type for nil, got %q", got)
+	}
+}
@@ -0,0 +1,312 @@
+package parking
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"os"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	defaultPricingReloadInterval = 5 * time.Second
+	defaultBaseRate              = 2.0
+	defaultCurrency              = "USD"
+)
+
+// PricingConfig is the YAML pricing file. The multiplier for an arrival is
+// the first matching time-of-day rule times the highest matching occupancy
+// rule; with no match either factor is 1.
+type PricingConfig struct {
+	Currency string `yaml:"currency"`
+	// BaseRate is charged per started hour.
+	BaseRate  float64         `yaml:"base_rate"`
+	Timezone  string          `yaml:"timezone"`
+	TimeOfDay []TimeOfDayRule `yaml:"time_of_day"`
+	Occupancy []OccupancyRule `yaml:"occupancy"`
+
+	location *time.Location
+}
+
+// TimeOfDayRule applies from Start up to End ("HH:MM"). A window whose end
+// is before its start wraps past midnight.
+type TimeOfDayRule struct {
+	Name       string  `yaml:"name"`
+	Start      string  `yaml:"start"`
+	End        string  `yaml:"end"`
+	Multiplier float64 `yaml:"multiplier"`
+
+	startMin, endMin int
+}
+
+// OccupancyRule applies once occupancy reaches MinOccupancy percent.
+type OccupancyRule struct {
+	Name         string  `yaml:"name"`
+	MinOccupancy float64 `yaml:"min_occupancy"`
+	Multiplier   float64 `yaml:"multiplier"`
+}
+
+// DefaultPricingConfig is used when no pricing file is configured: a flat
+// hourly rate with a surge once the lot is 90% full.
+func DefaultPricingConfig() PricingConfig {
+	return PricingConfig{
+		Currency: defaultCurrency,
+		BaseRate: defaultBaseRate,
+		Occupancy: []OccupancyRule{
+			{Name: "surge", MinOccupancy: 90, Multiplier: 1.5},
+		},
+		location: time.Local,
+	}
+}
+
+// ParsePricingConfig decodes and validates a pricing file.
+func ParsePricingConfig(data []byte) (PricingConfig, error) {
+	cfg := PricingConfig{Currency: defaultCurrency}
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return PricingConfig{}, fmt.Errorf("invalid pricing config: %w", err)
+	}
+	if err := cfg.validate(); err != nil {
+		return PricingConfig{}, fmt.Errorf("invalid pricing config: %w", err)
+	}
+	return cfg, nil
+}
+
+func (c *PricingConfig) validate() error {
+	if c.BaseRate < 0 {
+		return fmt.Errorf("base_rate must not be negative")
+	}
+
+	c.location = time.Local
+	if c.Timezone != "" {
+		loc, err := time.LoadLocation(c.Timezone)
+		if err != nil {
+			return fmt.Errorf("timezone %q: %w", c.Timezone, err)
+		}
+		c.location = loc
+	}
+
+	for i := range c.TimeOfDay {
+		rule := &c.TimeOfDay[i]
+		if rule.Multiplier <= 0 {
+			return fmt.Errorf("time_of_day %q: multiplier must be greater than 0", rule.Name)
+		}
+		var err error
+		if rule.startMin, err = parseClock(rule.Start); err != nil {
+			return fmt.Errorf("time_of_day %q: start: %w", rule.Name, err)
+		}
+		if rule.endMin, err = parseClock(rule.End); err != nil {
+			return fmt.Errorf("time_of_day %q: end: %w", rule.Name, err)
+		}
+		if rule.startMin == rule.endMin {
+			return fmt.Errorf("time_of_day %q: start and end must differ", rule.Name)
+		}
+	}
+
+	for _, rule := range c.Occupancy {
+		if rule.Multiplier <= 0 {
+			return fmt.Errorf("occupancy %q: multiplier must be greater than 0", rule.Name)
+		}
+		if rule.MinOccupancy < 0 || rule.MinOccupancy > 100 {
+			return fmt.Errorf("occupancy %q: min_occupancy must be a percentage in [0, 100]", rule.Name)
+		}
+	}
+	return nil
+}
+
+func parseClock(value string) (int, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not HH:MM", value)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+func (r TimeOfDayRule) matches(minute int) bool {
+	if r.startMin < r.endMin {
+		return minute >= r.startMin && minute < r.endMin
+	}
+	return minute >= r.startMin || minute < r.endMin
+}
+
+// PricingQuote is the multiplier offered to a vehicle arriving now and the
+// rules that produced it.
+type PricingQuote struct {
+	Multiplier float64
+	Rules      []string
+}
+
+// Quote prices an arrival at now with occupied of capacity slots taken.
+func (c PricingConfig) Quote(now time.Time, occupied, capacity int) PricingQuote {
+	quote := PricingQuote{Multiplier: 1}
+
+	local := now.In(c.location)
+	minute := local.Hour()*60 + local.Minute()
+	for _, rule := range c.TimeOfDay {
+		if rule.matches(minute) {
+			quote.Multiplier *= rule.Multiplier
+			quote.Rules = append(quote.Rules, rule.Name)
+			break
+		}
+	}
+
+	if capacity > 0 {
+		percent := float64(occupied) / float64(capacity) * 100
+		var best *OccupancyRule
+		for i, rule := range c.Occupancy {
+			if percent >= rule.MinOccupancy && (best == nil || rule.MinOccupancy > best.MinOccupancy) {
+				best = &c.Occupancy[i]
+			}
+		}
+		if best != nil {
+			quote.Multiplier *= best.Multiplier
+			quote.Rules = append(quote.Rules, best.Name)
+		}
+	}
+	return quote
+}
+
+// ParkingCharge is the fee for one stay.
+type ParkingCharge struct {
+	Fee         float64
+	Currency    string
+	Multiplier  float64
+	BilledHours int
+	Duration    time.Duration
+}
+
+// Charge bills every started hour between parkedAt and leftAt, with a
+// minimum of one, at the base rate times multiplier.
+func (c PricingConfig) Charge(parkedAt, leftAt time.Time, multiplier float64) ParkingCharge {
+	duration := max(leftAt.Sub(parkedAt), 0)
+	hours := max(int(math.Ceil(duration.Hours())), 1)
+	fee := float64(hours) * c.BaseRate * multiplier
+	return ParkingCharge{
+		Fee:         math.Round(fee*100) / 100,
+		Currency:    c.Currency,
+		Multiplier:  multiplier,
+		BilledHours: hours,
+		Duration:    duration,
+	}
+}
+
+// Pricing serves the current PricingConfig and hot-reloads it from disk.
+// Instead of a watcher goroutine, reads stat the file at most once per
+// reload interval and re-parse it when its modification time changes. A
+// file that fails to parse is logged and the previous config kept.
+type Pricing struct {
+	path     string
+	interval time.Duration
+	logger   *slog.Logger
+
+	mu        sync.Mutex
+	config    PricingConfig
+	modTime   time.Time
+	lastCheck time.Time
+}
+
+// NewPricing returns a Pricing with a fixed config that never reloads.
+func NewPricing(cfg PricingConfig) *Pricing {
+	if cfg.location == nil {
+		cfg.location = time.Local
+	}
+	return &Pricing{config: cfg, logger: slog.New(slog.NewJSONHandler(os.Stdout, nil))}
+}
+
+// LoadPricing reads path and re-reads it when it changes, checking at most
+// once per interval.
+func LoadPricing(path string, interval time.Duration) (*Pricing, error) {
+	p := &Pricing{
+		path:     path,
+		interval: interval,
+		logger:   slog.New(slog.NewJSONHandler(os.Stdout, nil)),
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("pricing config: %w", err)
+	}
+	if err := p.load(info.ModTime()); err != nil {
+		return nil, err
+	}
+	p.lastCheck = time.Now()
+	return p, nil
+}
+
+// NewPricingFromEnv reads PARKING_PRICING_FILE and
+// PARKING_PRICING_RELOAD_INTERVAL (default 5s). Without a file the default
+// pricing applies.
+func NewPricingFromEnv() (*Pricing, error) {
+	path := os.Getenv("PARKING_PRICING_FILE")
+	if path == "" {
+		return NewPricing(DefaultPricingConfig()), nil
+	}
+
+	interval := defaultPricingReloadInterval
+	if raw := os.Getenv("PARKING_PRICING_RELOAD_INTERVAL"); raw != "" {
+		d, err := time.ParseDuration(raw)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid PARKING_PRICING_RELOAD_INTERVAL %q", raw)
+		}
+		interval = d
+	}
+	return LoadPricing(path, interval)
+}
+
+// Config returns the current config, reloading it first if the file has
+// changed since the last check.
+func (p *Pricing) Config() PricingConfig {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.path != "" && time.Since(p.lastCheck) >= p.interval {
+		p.lastCheck = time.Now()
+		p.reload()
+	}
+	return p.config
+}
+
+func (p *Pricing) reload() {
+	info, err := os.Stat(p.path)
+	if err != nil {
+		p.logger.Error("pricing config unreadable, keeping previous config",
+			slog.String("path", p.path),
+			slog.String("error", err.Error()),
+		)
+		return
+	}
+	if info.ModTime().Equal(p.modTime) {
+		return
+	}
+	if err := p.load(info.ModTime()); err != nil {
+		p.logger.Error("pricing config reload failed, keeping previous config",
+			slog.String("path", p.path),
+			slog.String("error", err.Error()),
+		)
+		// Don't retry the same broken file on every check.
+		p.modTime = info.ModTime()
+		return
+	}
+	p.logger.Info("pricing config reloaded",
+		slog.String("event", "pricing_reload"),
+		slog.String("path", p.path),
+		slog.Float64("base_rate", p.config.BaseRate),
+		slog.Int("time_of_day_rules", len(p.config.TimeOfDay)),
+		slog.Int("occupancy_rules", len(p.config.Occupancy)),
+	)
+}
+
+func (p *Pricing) load(modTime time.Time) error {
+	data, err := os.ReadFile(p.path)
+	if err != nil {
+		return fmt.Errorf("pricing config: %w", err)
+	}
+	cfg, err := ParsePricingConfig(data)
+	if err != nil {
+		return err
+	}
+	p.config = cfg
+	p.modTime = modTime
+	return nil
+}
@@ -0,0 +1,149 @@
+package parking
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+const testPricingYAML = `
+currency: EUR
+base_rate: 2.00
+timezone: UTC
+time_of_day:
+  - name: peak
+    start: "07:00"
+    end: "10:00"
+    multiplier: 1.5
+  - name: overnight
+    start: "22:00"
+    end: "06:00"
+    multiplier: 0.5
+occupancy:
+  - name: busy
+    min_occupancy: 75
+    multiplier: 1.2
+  - name: surge
+    min_occupancy: 90
+    multiplier: 2
+`
+
+func TestPricingQuote(t *testing.T) {
+	cfg, err := ParsePricingConfig([]byte(testPricingYAML))
+	if err != nil {
+		t.Fatalf("Failed to parse pricing config: %v", err)
+	}
+
+	at := func(clock string) time.Time {
+		ts, _ := time.Parse(time.RFC3339, "2026-03-02T"+clock+":00Z")
+		return ts
+	}
+
+	tests := []struct {
+		name     string
+		now      time.Time
+		occupied int
+		want     PricingQuote
+	}{
+		{"off peak, quiet", at("12:00"), 10, PricingQuote{Multiplier: 1}},
+		{"peak", at("07:00"), 10, PricingQuote{Multiplier: 1.5, Rules: []string{"peak"}}},
+		{"peak ends at end", at("10:00"), 10, PricingQuote{Multiplier: 1}},
+		{"overnight before midnight", at("23:30"), 0, PricingQuote{Multiplier: 0.5, Rules: []string{"overnight"}}},
+		{"overnight after midnight", at("05:59"), 0, PricingQuote{Multiplier: 0.5, Rules: []string{"overnight"}}},
+		{"busy", at("12:00"), 80, PricingQuote{Multiplier: 1.2, Rules: []string{"busy"}}},
+		{"surge wins over busy", at("12:00"), 90, PricingQuote{Multiplier: 2, Rules: []string{"surge"}}},
+		{"peak and surge", at("08:00"), 95, PricingQuote{Multiplier: 3, Rules: []string{"peak", "surge"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := cfg.Quote(tt.now, tt.occupied, 100)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Quote() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPricingCharge(t *testing.T) {
+	cfg := DefaultPricingConfig()
+	parkedAt := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		stay       time.Duration
+		multiplier float64
+		wantHours  int
+		wantFee    float64
+	}{
+		{stay: 5 * time.Minute, multiplier: 1, wantHours: 1, wantFee: 2},
+		{stay: time.Hour, multiplier: 1, wantHours: 1, wantFee: 2},
+		{stay: 61 * time.Minute, multiplier: 1, wantHours: 2, wantFee: 4},
+		{stay: 3 * time.Hour, multiplier: 1.5, wantHours: 3, wantFee: 9},
+	}
+
+	for _, tt := range tests {
+		charge := cfg.Charge(parkedAt, parkedAt.Add(tt.stay), tt.multiplier)
+		if charge.BilledHours != tt.wantHours || charge.Fee != tt.wantFee {
+			t.Errorf("Charge(%s, x%v) = %d hours, %.2f; want %d hours, %.2f",
+				tt.stay, tt.multiplier, charge.BilledHours, charge.Fee, tt.wantHours, tt.wantFee)
+		}
+		if charge.Currency != "USD" {
+			t.Errorf("Expected currency USD, got %q", charge.Currency)
+		}
+	}
+}
+
+func TestParsePricingConfigRejectsInvalid(t *testing.T) {
+	invalid := map[string]string{
+		"negative base rate":  "base_rate: -1",
+		"zero multiplier":     "occupancy: [{name: surge, min_occupancy: 90, multiplier: 0}]",
+		"occupancy above 100": "occupancy: [{name: surge, min_occupancy: 120, multiplier: 2}]",
+		"bad clock":           `time_of_day: [{name: peak, start: "7am", end: "10:00", multiplier: 2}]`,
+		"empty window":        `time_of_day: [{name: peak, start: "07:00", end: "07:00", multiplier: 2}]`,
+		"unknown timezone":    "timezone: Mars/Olympus",
+	}
+	for name, raw := range invalid {
+		if _, err := ParsePricingConfig([]byte(raw)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestPricingHotReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pricing.yaml")
+	if err := os.WriteFile(path, []byte("base_rate: 2"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	pricing, err := LoadPricing(path, time.Nanosecond)
+	if err != nil {
+		t.Fatalf("Failed to load pricing: %v", err)
+	}
+	if got := pricing.Config().BaseRate; got != 2 {
+		t.Fatalf("Expected base rate 2, got %v", got)
+	}
+
+	write := func(content string, modTime time.Time) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		// Filesystems with coarse timestamps may not move mtime between
+		// two quick writes.
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write("base_rate: 3", time.Now().Add(time.Minute))
+	if got := pricing.Config().BaseRate; got != 3 {
+		t.Errorf("Expected reloaded base rate 3, got %v", got)
+	}
+
+	write("base_rate: -1", time.Now().Add(2*time.Minute))
+	if got := pricing.Config().BaseRate; got != 3 {
+		t.Errorf("Expected invalid file to keep base rate 3, got %v", got)
+	}
+}
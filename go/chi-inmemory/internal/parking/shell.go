@@ -2,6 +2,7 @@ package parking
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"os"
 	"strconv"
@@ -89,6 +90,10 @@ func (s *Shell) handlePark(parts []string) {
 	color := parts[2]
 
 	slotNumber, err := s.parkingLot.Park(registrationNumber, color)
+	if errors.Is(err, ErrDuplicateVehicle) {
+		fmt.Printf("Already parked at slot number: %d\n", slotNumber)
+		return
+	}
 	if err != nil {
 		fmt.Println("Sorry, parking lot is full")
 		return
@@ -1,7 +1,26 @@
 package parking
 
+import "fmt"
+
+type SlotClass string
+
+const (
+	SlotClassStandard    SlotClass = "standard"
+	SlotClassDisabled    SlotClass = "disabled"
+	SlotClassMaintenance SlotClass = "maintenance"
+)
+
+func ParseSlotClass(value string) (SlotClass, error) {
+	switch class := SlotClass(value); class {
+	case SlotClassStandard, SlotClassDisabled, SlotClassMaintenance:
+		return class, nil
+	}
+	return "", fmt.Errorf("invalid slot class %q", value)
+}
+
 type Slot struct {
 	Number     int
+	Class      SlotClass
 	IsOccupied bool
 	Vehicle    *Vehicle
 }
@@ -9,11 +28,28 @@ type Slot struct {
 func NewSlot(number int) *Slot {
 	return &Slot{
 		Number:     number,
+		Class:      SlotClassStandard,
 		IsOccupied: false,
 		Vehicle:    nil,
 	}
 }
 
+// Accepts reports whether a vehicle may be allocated this slot. Slots
+// reserved for disabled drivers require a permit; slots under maintenance
+// accept nobody.
+func (s *Slot) Accepts(disabledPermit bool) bool {
+	if s.IsOccupied {
+		return false
+	}
+	switch s.Class {
+	case SlotClassMaintenance:
+		return false
+	case SlotClassDisabled:
+		return disabledPermit
+	}
+	return true
+}
+
 func (s *Slot) Park(vehicle *Vehicle) {
 	s.Vehicle = vehicle
 	s.IsOccupied = true
@@ -15,11 +15,12 @@ import (
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
 	"go.opentelemetry.io/otel/trace"
+
+	"parking-lot/internal/buildinfo"
 )
 
 const (
 	defaultServiceName  = "parking-lot-service"
-	serviceVersion      = "1.0.0"
 	defaultOTLPEndpoint = "http://localhost:4318"
 )
 
@@ -46,7 +47,8 @@ func NewTelemetryProvider() (*TelemetryProvider, error) {
 	resAttrs := []resource.Option{
 		resource.WithAttributes(
 			semconv.ServiceName(serviceName),
-			semconv.ServiceVersion(serviceVersion),
+			semconv.ServiceVersion(buildinfo.Version),
+			semconv.ServiceInstanceID(buildinfo.InstanceID),
 		),
 	}
 
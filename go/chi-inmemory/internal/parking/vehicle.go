@@ -1,13 +1,21 @@
 package parking
 
+import "time"
+
 type Vehicle struct {
 	RegistrationNumber string
 	Color              string
+	ParkedAt           time.Time
+	// PricingMultiplier is quoted on arrival and applied to the fee on
+	// departure, so a surge that starts mid-stay doesn't change the price.
+	PricingMultiplier float64
 }
 
 func NewVehicle(registrationNumber, color string) *Vehicle {
 	return &Vehicle{
 		RegistrationNumber: registrationNumber,
 		Color:              color,
+		ParkedAt:           time.Now(),
+		PricingMultiplier:  1,
 	}
 }
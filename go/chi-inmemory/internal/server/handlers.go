@@ -2,9 +2,12 @@ package server
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"os"
+	"parking-lot/internal/buildinfo"
 	"parking-lot/internal/parking"
+	"strconv"
 	"sync"
 
 	"github.com/go-chi/chi/v5"
@@ -34,6 +37,10 @@ func (h *Handler) HealthCheck(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+func (h *Handler) Version(w http.ResponseWriter, r *http.Request) {
+	WriteJSON(w, http.StatusOK, buildinfo.Get())
+}
+
 func (h *Handler) CreateParkingLot(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	var req ParkingLotCreateRequest
@@ -90,16 +97,28 @@ func (h *Handler) ParkVehicle(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	slotNumber, err := h.parkingLot.Park(ctx, req.Registration, req.Color)
+	slotNumber, err := h.parkingLot.ParkWithPermit(ctx, req.Registration, req.Color, req.DisabledPermit)
+	if errors.Is(err, parking.ErrDuplicateVehicle) {
+		// Retried or duplicated requests get the slot already held rather
+		// than a second one.
+		WriteSuccess(ctx, w, "Vehicle already parked", map[string]any{
+			"slot_number":  slotNumber,
+			"registration": req.Registration,
+			"duplicate":    true,
+		})
+		return
+	}
 	if err != nil {
-		WriteError(ctx, w, http.StatusConflict, err.Error())
+		WriteParkingError(ctx, w, err)
 		return
 	}
 
 	WriteSuccess(ctx, w, "Vehicle parked successfully", map[string]any{
-		"slot_number":  slotNumber,
-		"registration": req.Registration,
-		"color":        req.Color,
+		"slot_number":     slotNumber,
+		"registration":    req.Registration,
+		"color":           req.Color,
+		"disabled_permit": req.DisabledPermit,
+		"duplicate":       false,
 	})
 }
 
@@ -124,14 +143,19 @@ func (h *Handler) LeaveSlot(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err := h.parkingLot.Leave(ctx, req.SlotNumber)
+	charge, err := h.parkingLot.Leave(ctx, req.SlotNumber)
 	if err != nil {
-		WriteError(ctx, w, http.StatusBadRequest, err.Error())
+		WriteParkingError(ctx, w, err)
 		return
 	}
 
 	WriteSuccess(ctx, w, "Slot vacated successfully", map[string]any{
-		"slot_number": req.SlotNumber,
+		"slot_number":        req.SlotNumber,
+		"fee":                charge.Fee,
+		"currency":           charge.Currency,
+		"pricing_multiplier": charge.Multiplier,
+		"billed_hours":       charge.BilledHours,
+		"duration_seconds":   int(charge.Duration.Seconds()),
 	})
 }
 
@@ -148,36 +172,81 @@ func (h *Handler) GetStatus(w http.ResponseWriter, r *http.Request) {
 	occupiedSlots := h.parkingLot.GetStatus(ctx)
 
 	var slots []SlotStatus
-	capacity := h.parkingLot.ParkingLot.GetCapacity()
+	classes := map[string]ClassStatus{}
+	available := 0
 
-	for i := 1; i <= capacity; i++ {
+	for _, lotSlot := range h.parkingLot.ParkingLot.GetSlots() {
 		slot := SlotStatus{
-			SlotNumber: i,
-			Occupied:   false,
+			SlotNumber: lotSlot.Number,
+			Class:      string(lotSlot.Class),
+			Occupied:   lotSlot.IsOccupied,
 		}
 
-		for _, occupiedSlot := range occupiedSlots {
-			if occupiedSlot.Number == i {
-				slot.Occupied = true
-				slot.Registration = occupiedSlot.Vehicle.RegistrationNumber
-				slot.Color = occupiedSlot.Vehicle.Color
-				break
-			}
+		class := classes[slot.Class]
+		class.Total++
+		if lotSlot.IsOccupied {
+			slot.Registration = lotSlot.Vehicle.RegistrationNumber
+			slot.Color = lotSlot.Vehicle.Color
+			class.Occupied++
+		} else if lotSlot.Class != parking.SlotClassMaintenance {
+			class.Available++
+			available++
 		}
+		classes[slot.Class] = class
 
 		slots = append(slots, slot)
 	}
 
 	response := StatusResponse{
-		Capacity:  capacity,
+		Capacity:  h.parkingLot.ParkingLot.GetCapacity(),
 		Occupied:  len(occupiedSlots),
-		Available: capacity - len(occupiedSlots),
+		Available: available,
+		Classes:   classes,
 		Slots:     slots,
 	}
 
 	WriteSuccess(ctx, w, "Status retrieved successfully", response)
 }
 
+func (h *Handler) SetSlotClass(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	h.mu.RLock()
+	if h.parkingLot == nil {
+		h.mu.RUnlock()
+		WriteError(ctx, w, http.StatusBadRequest, "Parking lot not created. Create parking lot first")
+		return
+	}
+	h.mu.RUnlock()
+
+	slotNumber, err := strconv.Atoi(chi.URLParam(r, "number"))
+	if err != nil || slotNumber <= 0 {
+		WriteError(ctx, w, http.StatusBadRequest, "Slot number must be greater than 0")
+		return
+	}
+
+	var req SetSlotClassRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(ctx, w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	class, err := parking.ParseSlotClass(req.Class)
+	if err != nil {
+		WriteError(ctx, w, http.StatusBadRequest, "Class must be one of standard, disabled, maintenance")
+		return
+	}
+
+	if err := h.parkingLot.SetSlotClass(ctx, slotNumber, class); err != nil {
+		WriteParkingError(ctx, w, err)
+		return
+	}
+
+	WriteSuccess(ctx, w, "Slot updated successfully", map[string]any{
+		"slot_number": slotNumber,
+		"class":       class,
+	})
+}
+
 func (h *Handler) FindByRegistration(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	h.mu.RLock()
@@ -196,7 +265,7 @@ func (h *Handler) FindByRegistration(w http.ResponseWriter, r *http.Request) {
 
 	slotNumber, err := h.parkingLot.GetSlotByRegistrationNumber(ctx, registration)
 	if err != nil {
-		WriteError(ctx, w, http.StatusNotFound, "Vehicle not found")
+		WriteParkingError(ctx, w, err)
 		return
 	}
 
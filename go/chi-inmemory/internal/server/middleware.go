@@ -2,14 +2,17 @@ package server
 
 import (
 	"context"
+	"crypto/subtle"
 	"log"
 	"net/http"
+	"os"
 	"time"
 
 	"github.com/google/uuid"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/trace"
 )
 
@@ -35,7 +38,10 @@ func TracingMiddleware(next http.Handler) http.Handler {
 			return
 		}
 
-		ctx, span := tracer.Start(r.Context(), r.Method+" "+r.URL.Path,
+		// Continue the caller's trace, such as a gate calling the lot.
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracer.Start(ctx, r.Method+" "+r.URL.Path,
+			trace.WithSpanKind(trace.SpanKindServer),
 			trace.WithAttributes(
 				attribute.String("http.method", r.Method),
 				attribute.String("http.url", r.URL.String()),
@@ -91,11 +97,25 @@ func RecoveryMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// AdminMiddleware guards admin routes with the PARKING_ADMIN_TOKEN shared
+// secret, sent as X-Admin-Token. Without the variable the routes stay open,
+// which is only suitable for local demos.
+func AdminMiddleware(next http.Handler) http.Handler {
+	token := os.Getenv("PARKING_ADMIN_TOKEN")
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token != "" && subtle.ConstantTimeCompare([]byte(r.Header.Get("X-Admin-Token")), []byte(token)) != 1 {
+			WriteError(r.Context(), w, http.StatusUnauthorized, "Invalid admin token")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
 func CORSMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Admin-Token")
 
 		if r.Method == "OPTIONS" {
 			w.WriteHeader(http.StatusOK)
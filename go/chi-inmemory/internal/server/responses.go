@@ -3,8 +3,11 @@ package server
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
+	"parking-lot/internal/parking"
 
+	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
 )
 
@@ -14,11 +17,12 @@ type Meta struct {
 }
 
 type Response struct {
-	Success bool   `json:"success"`
-	Message string `json:"message,omitempty"`
-	Data    any    `json:"data,omitempty"`
-	Error   string `json:"error,omitempty"`
-	Meta    *Meta  `json:"meta,omitempty"`
+	Success   bool   `json:"success"`
+	Message   string `json:"message,omitempty"`
+	Data      any    `json:"data,omitempty"`
+	Error     string `json:"error,omitempty"`
+	ErrorType string `json:"error_type,omitempty"`
+	Meta      *Meta  `json:"meta,omitempty"`
 }
 
 type HealthResponse struct {
@@ -31,8 +35,13 @@ type ParkingLotCreateRequest struct {
 }
 
 type ParkVehicleRequest struct {
-	Registration string `json:"registration"`
-	Color        string `json:"color"`
+	Registration   string `json:"registration"`
+	Color          string `json:"color"`
+	DisabledPermit bool   `json:"disabled_permit"`
+}
+
+type SetSlotClassRequest struct {
+	Class string `json:"class"`
 }
 
 type LeaveSlotRequest struct {
@@ -49,14 +58,22 @@ type SlotStatus struct {
 	SlotNumber   int    `json:"slot_number"`
 	Registration string `json:"registration,omitempty"`
 	Color        string `json:"color,omitempty"`
+	Class        string `json:"class"`
 	Occupied     bool   `json:"occupied"`
 }
 
+type ClassStatus struct {
+	Total     int `json:"total"`
+	Occupied  int `json:"occupied"`
+	Available int `json:"available"`
+}
+
 type StatusResponse struct {
-	Capacity  int          `json:"capacity"`
-	Occupied  int          `json:"occupied"`
-	Available int          `json:"available"`
-	Slots     []SlotStatus `json:"slots"`
+	Capacity  int                    `json:"capacity"`
+	Occupied  int                    `json:"occupied"`
+	Available int                    `json:"available"`
+	Classes   map[string]ClassStatus `json:"classes"`
+	Slots     []SlotStatus           `json:"slots"`
 }
 
 func WriteJSON(w http.ResponseWriter, status int, data any) {
@@ -96,3 +113,32 @@ func WriteError(ctx context.Context, w http.ResponseWriter, status int, message
 		Meta:    extractMeta(ctx),
 	})
 }
+
+// parkingErrorStatus maps a parking lot error to its HTTP status: a slot or
+// vehicle that does not exist is 404, a request the lot's current state
+// refuses is 409, and anything unrecognised is 500.
+func parkingErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, parking.ErrInvalidSlot), errors.Is(err, parking.ErrVehicleNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, parking.ErrLotFull), errors.Is(err, parking.ErrSlotEmpty),
+		errors.Is(err, parking.ErrSlotOccupied), errors.Is(err, parking.ErrDuplicateVehicle):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// WriteParkingError writes err with the status for its type and tags the
+// request span with error.type, so failures can be grouped by cause.
+func WriteParkingError(ctx context.Context, w http.ResponseWriter, err error) {
+	errorType := parking.ErrorType(err)
+	trace.SpanFromContext(ctx).SetAttributes(attribute.String("error.type", errorType))
+
+	WriteJSON(w, parkingErrorStatus(err), Response{
+		Success:   false,
+		Error:     err.Error(),
+		ErrorType: errorType,
+		Meta:      extractMeta(ctx),
+	})
+}
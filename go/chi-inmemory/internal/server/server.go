@@ -28,6 +28,7 @@ func NewServer(port string) *Server {
 	r.Use(CORSMiddleware)
 
 	r.Get("/health", handler.HealthCheck)
+	r.Get("/version", handler.Version)
 	r.Get("/metrics", promhttp.Handler().ServeHTTP)
 
 	r.Route("/api/parking-lot", func(r chi.Router) {
@@ -36,6 +37,11 @@ func NewServer(port string) *Server {
 		r.Post("/leave", handler.LeaveSlot)
 		r.Get("/status", handler.GetStatus)
 		r.Get("/find/{registration}", handler.FindByRegistration)
+
+		r.Route("/admin", func(r chi.Router) {
+			r.Use(AdminMiddleware)
+			r.Put("/slots/{number}", handler.SetSlotClass)
+		})
 	})
 
 	httpServer := &http.Server{
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"go-echo-postgres/config"
+	"go-echo-postgres/internal/database"
+	"go-echo-postgres/internal/handlers"
+	"go-echo-postgres/internal/jobs"
+	"go-echo-postgres/internal/logging"
+	"go-echo-postgres/internal/middleware"
+	"go-echo-postgres/internal/services"
+	"go-echo-postgres/internal/telemetry"
+
+	"github.com/base-14/examples/go/pkg/depwait"
+	"github.com/base-14/examples/go/pkg/healthcheck"
+	"github.com/base-14/examples/go/pkg/shutdown"
+
+	"github.com/labstack/echo/v4"
+	echomiddleware "github.com/labstack/echo/v4/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/labstack/echo/otelecho"
+)
+
+func main() {
+	ctx := context.Background()
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load config: %v\n", err)
+		os.Exit(1)
+	}
+
+	healthcheck.Main(depwait.Dependency{Name: "api", Check: healthcheck.HTTP(healthcheck.Local(cfg.Port, "/api/health"))})
+
+	logging.Init(cfg.IsDevelopment())
+
+	telemetryOpts := []telemetry.Option{telemetry.WithMetricIDSalt(cfg.MetricIDSalt)}
+	var promRegistry *prometheus.Registry
+	if cfg.PrometheusEnabled {
+		promRegistry = prometheus.NewRegistry()
+		telemetryOpts = append(telemetryOpts, telemetry.WithPrometheus(promRegistry))
+	}
+
+	shutdownTelemetry, err := telemetry.Init(ctx, cfg.OTelServiceName, cfg.OTelEndpoint, telemetryOpts...)
+	if err != nil {
+		logging.Logger().Fatal().Err(err).Msg("failed to initialize telemetry")
+	}
+	sd := shutdown.New(shutdown.Config{OnClose: logShutdown})
+	sd.Add(shutdown.PhaseTelemetry, "telemetry", shutdownTelemetry)
+
+	if err := middleware.InitMetrics(); err != nil {
+		logging.Logger().Fatal().Err(err).Msg("failed to initialize metrics")
+	}
+
+	redisAddr := parseRedisAddr(cfg.RedisURL)
+	if err := depwait.Wait(ctx, depwait.Config{Timeout: cfg.StartupTimeout, OnAttempt: logDependencyAttempt},
+		depwait.Dependency{Name: "postgres", Check: depwait.SQL("pgx", cfg.DatabaseURL)},
+		depwait.Dependency{Name: "redis", Check: depwait.Redis(redisAddr)},
+	); err != nil {
+		logging.Logger().Fatal().Err(err).Msg("dependencies not ready")
+	}
+
+	if err := database.Connect(cfg.DatabaseURL, cfg.IsDevelopment()); err != nil {
+		logging.Logger().Fatal().Err(err).Msg("failed to initialize database")
+	}
+	sd.Add(shutdown.PhaseClients, "postgres", shutdown.ErrFunc(database.Close))
+
+	if err := database.Migrate(); err != nil {
+		logging.Logger().Fatal().Err(err).Msg("failed to run database migrations")
+	}
+
+	jobClient, err := jobs.NewClient(redisAddr, jobs.ClientConfig{
+		DedupWindow:         cfg.NotificationDedupWindow,
+		NotificationQueue:   cfg.NotificationQueue,
+		LinkExtractionQueue: cfg.LinkExtractionQueue,
+		MaxRetry:            cfg.JobMaxRetry,
+	})
+	if err != nil {
+		logging.Logger().Fatal().Err(err).Msg("failed to create job client")
+	}
+	sd.Add(shutdown.PhaseClients, "job client", shutdown.ErrFunc(jobClient.Close))
+
+	outboxRelay := jobs.NewOutboxRelay(jobClient, cfg.OutboxSweepInterval)
+	relayCtx, stopRelay := context.WithCancel(ctx)
+	var relay sync.WaitGroup
+	relay.Go(func() { outboxRelay.Run(relayCtx) })
+	sd.Add(shutdown.PhaseWorkers, "outbox relay", func(context.Context) error {
+		stopRelay()
+		relay.Wait()
+		return nil
+	})
+
+	userService := services.NewUserService()
+	authService := services.NewAuthService(cfg.JWTSecret, cfg.JWTExpiresIn)
+	articleService := services.NewArticleService()
+	moderationService := services.NewModerationService()
+
+	healthHandler := handlers.NewHealthHandler(redisAddr)
+	authHandler := handlers.NewAuthHandler(authService, userService)
+	articleHandler := handlers.NewArticleHandler(articleService, jobClient, outboxRelay)
+	moderationHandler := handlers.NewModerationHandler(moderationService)
+
+	e := echo.New()
+	e.HideBanner = true
+
+	e.Use(echomiddleware.Recover())
+	e.Use(echomiddleware.RequestID())
+	e.Use(otelecho.Middleware(cfg.OTelServiceName, otelecho.WithSkipper(func(c echo.Context) bool {
+		return c.Path() == "/api/health" || c.Path() == "/version" || c.Path() == "/metrics"
+	})))
+	e.Use(middleware.Metrics())
+	e.Use(middleware.QueryCount(cfg.DBQueryWarnThreshold))
+
+	articleCache := middleware.CachePolicy{
+		Visibility:               middleware.CachePublic,
+		MaxAge:                   cfg.CacheMaxAge,
+		SurrogateMaxAge:          cfg.CacheSurrogateMaxAge,
+		StaleWhileRevalidate:     cfg.CacheMaxAge,
+		PrivateWhenAuthenticated: true,
+	}
+	e.Use(middleware.CacheControl(middleware.CachePolicies{
+		"GET /api/health":         {Visibility: middleware.CacheNoStore},
+		"GET /version":            {Visibility: middleware.CacheNoStore},
+		"GET /metrics":            {Visibility: middleware.CacheNoStore},
+		"GET /api/articles":       articleCache,
+		"GET /api/articles/:slug": articleCache,
+		"GET /api/user":           {Visibility: middleware.CachePrivate},
+	}))
+	e.Use(middleware.Security(middleware.SecurityPolicy{
+		AllowedOrigins:        cfg.CORSAllowedOrigins,
+		AllowedMethods:        cfg.CORSAllowedMethods,
+		AllowedHeaders:        cfg.CORSAllowedHeaders,
+		AllowCredentials:      cfg.CORSAllowCredentials,
+		CORSMaxAge:            cfg.CORSMaxAge,
+		HSTSMaxAge:            cfg.HSTSMaxAge,
+		ContentSecurityPolicy: cfg.ContentSecurityPolicy,
+	}))
+	e.Use(middleware.Timeout(cfg.ReadTimeout, cfg.WriteTimeout))
+	e.HTTPErrorHandler = middleware.ErrorHandler
+
+	if cfg.IsDevelopment() {
+		e.Use(echomiddleware.Logger())
+	}
+
+	e.GET("/version", handlers.Version)
+	if promRegistry != nil {
+		e.GET("/metrics", echo.WrapHandler(promhttp.HandlerFor(promRegistry, promhttp.HandlerOpts{})))
+	}
+
+	api := e.Group("/api")
+
+	api.GET("/health", healthHandler.Check)
+
+	api.POST("/register", authHandler.Register)
+	api.POST("/login", authHandler.Login)
+
+	auth := api.Group("")
+	auth.Use(middleware.JWTAuth(cfg.JWTSecret))
+	auth.GET("/user", authHandler.GetCurrentUser)
+	auth.POST("/logout", authHandler.Logout)
+
+	api.GET("/articles", articleHandler.List, middleware.OptionalJWTAuth(cfg.JWTSecret))
+	api.GET("/articles/:slug", articleHandler.Get, middleware.OptionalJWTAuth(cfg.JWTSecret))
+	api.GET("/articles/:slug/links", articleHandler.Links)
+
+	authArticles := api.Group("/articles")
+	authArticles.Use(middleware.JWTAuth(cfg.JWTSecret))
+	authArticles.POST("", articleHandler.Create)
+	authArticles.PUT("/:slug", articleHandler.Update)
+	authArticles.DELETE("/:slug", articleHandler.Delete)
+	authArticles.POST("/:slug/favorite", articleHandler.Favorite)
+	authArticles.DELETE("/:slug/favorite", articleHandler.Unfavorite)
+	authArticles.POST("/:slug/report", moderationHandler.Report)
+
+	reports := api.Group("/admin/reports")
+	reports.Use(middleware.JWTAuth(cfg.JWTSecret), middleware.RequireAdmin(cfg.AdminEmails))
+	reports.GET("", moderationHandler.List)
+	reports.GET("/:id", moderationHandler.Get)
+	reports.POST("/:id/resolve", moderationHandler.Resolve)
+	reports.POST("/:id/dismiss", moderationHandler.Dismiss)
+
+	sd.Add(shutdown.PhaseServer, "http", e.Shutdown)
+
+	go func() {
+		addr := fmt.Sprintf(":%s", cfg.Port)
+		logging.Logger().Info().Str("port", cfg.Port).Msg("starting server")
+		if err := e.Start(addr); err != nil && err != http.ErrServerClosed {
+			logging.Logger().Fatal().Err(err).Msg("server error")
+		}
+	}()
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+
+	logging.Logger().Info().Msg("shutting down server")
+	if err := sd.Shutdown(context.Background()); err != nil {
+		logging.Logger().Error().Err(err).Msg("shutdown finished with errors")
+	}
+}
+
+func parseRedisAddr(redisURL string) string {
+	if len(redisURL) > 8 && redisURL[:8] == "redis://" {
+		return redisURL[8:]
+	}
+	return redisURL
+}
+
+// logShutdown reports each step of shutdown.
+func logShutdown(ev shutdown.Event) {
+	if ev.Err != nil {
+		logging.Logger().Error().Err(ev.Err).
+			Str("closer", ev.Name).
+			Stringer("phase", ev.Phase).
+			Dur("duration", ev.Duration).
+			Msg("shutdown step failed")
+		return
+	}
+	logging.Logger().Info().
+		Str("closer", ev.Name).
+		Stringer("phase", ev.Phase).
+		Dur("duration", ev.Duration).
+		Msg("shutdown step done")
+}
+
+// logDependencyAttempt reports startup dependency checks while the service
+// waits for Postgres and Redis.
+func logDependencyAttempt(ev depwait.Event) {
+	if ev.Err == nil {
+		logging.Logger().Info().
+			Str("dependency", ev.Dependency).
+			Int("attempt", ev.Attempt).
+			Dur("waited", ev.Elapsed).
+			Msg("dependency ready")
+		return
+	}
+	logging.Logger().Warn().Err(ev.Err).
+		Str("dependency", ev.Dependency).
+		Int("attempt", ev.Attempt).
+		Dur("retry_in", ev.RetryIn).
+		Msg("waiting for dependency")
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"go-echo-postgres/config"
+	"go-echo-postgres/internal/database"
+	"go-echo-postgres/internal/jobs"
+	"go-echo-postgres/internal/logging"
+	"go-echo-postgres/internal/telemetry"
+
+	"github.com/base-14/examples/go/pkg/depwait"
+	"github.com/base-14/examples/go/pkg/healthcheck"
+	"github.com/base-14/examples/go/pkg/shutdown"
+)
+
+func main() {
+	ctx := context.Background()
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load config: %v\n", err)
+		os.Exit(1)
+	}
+
+	// The worker serves no HTTP, so its probe pings what it consumes from.
+	redisAddr := parseRedisAddr(cfg.RedisURL)
+	healthcheck.Main(
+		depwait.Dependency{Name: "postgres", Check: depwait.SQL("pgx", cfg.DatabaseURL)},
+		depwait.Dependency{Name: "redis", Check: depwait.Redis(redisAddr)},
+	)
+
+	logging.Init(cfg.IsDevelopment())
+
+	serviceName := cfg.OTelServiceName + "-worker"
+	shutdownTelemetry, err := telemetry.Init(ctx, serviceName, cfg.OTelEndpoint, telemetry.WithMetricIDSalt(cfg.MetricIDSalt))
+	if err != nil {
+		logging.Logger().Fatal().Err(err).Msg("failed to initialize telemetry")
+	}
+	sd := shutdown.New(shutdown.Config{OnClose: logShutdown})
+	sd.Add(shutdown.PhaseTelemetry, "telemetry", shutdownTelemetry)
+
+	if err := depwait.Wait(ctx, depwait.Config{Timeout: cfg.StartupTimeout, OnAttempt: logDependencyAttempt},
+		depwait.Dependency{Name: "postgres", Check: depwait.SQL("pgx", cfg.DatabaseURL)},
+		depwait.Dependency{Name: "redis", Check: depwait.Redis(redisAddr)},
+	); err != nil {
+		logging.Logger().Fatal().Err(err).Msg("dependencies not ready")
+	}
+
+	if err := database.Connect(cfg.DatabaseURL, cfg.IsDevelopment()); err != nil {
+		logging.Logger().Fatal().Err(err).Msg("failed to initialize database")
+	}
+	sd.Add(shutdown.PhaseClients, "postgres", shutdown.ErrFunc(database.Close))
+
+	server := jobs.NewServer(redisAddr, jobs.ServerConfig{
+		Concurrency:    cfg.WorkerConcurrency,
+		Queues:         cfg.WorkerQueues,
+		StrictPriority: cfg.WorkerStrictPriority,
+		RetryBaseDelay: cfg.JobRetryBaseDelay,
+		RetryMaxDelay:  cfg.JobRetryMaxDelay,
+	})
+
+	sd.Add(shutdown.PhaseWorkers, "job server", shutdown.Func(server.Shutdown))
+
+	go func() {
+		if err := server.Start(); err != nil {
+			logging.Logger().Fatal().Err(err).Msg("failed to start worker")
+		}
+	}()
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+
+	logging.Logger().Info().Msg("shutting down worker")
+	if err := sd.Shutdown(context.Background()); err != nil {
+		logging.Logger().Error().Err(err).Msg("shutdown finished with errors")
+	}
+}
+
+func parseRedisAddr(redisURL string) string {
+	if len(redisURL) > 8 && redisURL[:8] == "redis://" {
+		return redisURL[8:]
+	}
+	return redisURL
+}
+
+// logShutdown reports each step of shutdown.
+func logShutdown(ev shutdown.Event) {
+	if ev.Err != nil {
+		logging.Logger().Error().Err(ev.Err).
+			Str("closer", ev.Name).
+			Stringer("phase", ev.Phase).
+			Dur("duration", ev.Duration).
+			Msg("shutdown step failed")
+		return
+	}
+	logging.Logger().Info().
+		Str("closer", ev.Name).
+		Stringer("phase", ev.Phase).
+		Dur("duration", ev.Duration).
+		Msg("shutdown step done")
+}
+
+// logDependencyAttempt reports startup dependency checks while the service
+// waits for Postgres and Redis.
+func logDependencyAttempt(ev depwait.Event) {
+	if ev.Err == nil {
+		logging.Logger().Info().
+			Str("dependency", ev.Dependency).
+			Int("attempt", ev.Attempt).
+			Dur("waited", ev.Elapsed).
+			Msg("dependency ready")
+		return
+	}
+	logging.Logger().Warn().Err(ev.Err).
+		Str("dependency", ev.Dependency).
+		Int("attempt", ev.Attempt).
+		Dur("retry_in", ev.RetryIn).
+		Msg("waiting for dependency")
+}
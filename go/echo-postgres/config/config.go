@@ -3,6 +3,8 @@ package config
 import (
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -17,8 +19,57 @@ type Config struct {
 	JWTSecret    string
 	JWTExpiresIn time.Duration
 
+	AdminEmails []string
+
+	CacheMaxAge          time.Duration
+	CacheSurrogateMaxAge time.Duration
+
+	NotificationDedupWindow time.Duration
+	OutboxSweepInterval     time.Duration
+
+	// WorkerConcurrency is shared by the WorkerQueues, each pulled from in
+	// proportion to its weight (or in weight order with
+	// WorkerStrictPriority). Jobs are routed to queues by type.
+	WorkerConcurrency    int
+	WorkerQueues         map[string]int
+	WorkerStrictPriority bool
+	NotificationQueue    string
+	LinkExtractionQueue  string
+
+	// A failed job is retried up to JobMaxRetry times, after
+	// JobRetryBaseDelay doubling up to JobRetryMaxDelay.
+	JobMaxRetry       int
+	JobRetryBaseDelay time.Duration
+	JobRetryMaxDelay  time.Duration
+
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+
+	StartupTimeout time.Duration
+
+	// DBQueryWarnThreshold is the number of queries a single request may run
+	// before it is logged as a likely N+1; 0 disables the warning.
+	DBQueryWarnThreshold int
+
+	// With no CORSAllowedOrigins CORS is off: no CORS headers are sent and
+	// cross-origin requests are left to the browser's same-origin policy.
+	CORSAllowedOrigins   []string
+	CORSAllowedMethods   []string
+	CORSAllowedHeaders   []string
+	CORSAllowCredentials bool
+	CORSMaxAge           time.Duration
+
+	// HSTSMaxAge enables Strict-Transport-Security when positive.
+	HSTSMaxAge            time.Duration
+	ContentSecurityPolicy string
+
 	OTelServiceName string
 	OTelEndpoint    string
+
+	// MetricIDSalt keys the hash that buckets user IDs on metrics.
+	MetricIDSalt string
+
+	PrometheusEnabled bool
 }
 
 func Load() (*Config, error) {
@@ -30,6 +81,7 @@ func Load() (*Config, error) {
 		JWTSecret:       getEnv("JWT_SECRET", ""),
 		OTelServiceName: getEnv("OTEL_SERVICE_NAME", "go-echo-postgres-api"),
 		OTelEndpoint:    getEnv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://localhost:4318"),
+		MetricIDSalt:    getEnv("METRIC_ID_SALT", ""),
 	}
 
 	expiresIn := getEnv("JWT_EXPIRES_IN", "168h")
@@ -39,6 +91,98 @@ func Load() (*Config, error) {
 	}
 	cfg.JWTExpiresIn = duration
 
+	cfg.CacheMaxAge, err = time.ParseDuration(getEnv("CACHE_MAX_AGE", "30s"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid CACHE_MAX_AGE: %w", err)
+	}
+	cfg.CacheSurrogateMaxAge, err = time.ParseDuration(getEnv("CACHE_SURROGATE_MAX_AGE", "5m"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid CACHE_SURROGATE_MAX_AGE: %w", err)
+	}
+	cfg.NotificationDedupWindow, err = time.ParseDuration(getEnv("NOTIFICATION_DEDUP_WINDOW", "1m"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid NOTIFICATION_DEDUP_WINDOW: %w", err)
+	}
+	cfg.OutboxSweepInterval, err = time.ParseDuration(getEnv("OUTBOX_SWEEP_INTERVAL", "30s"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid OUTBOX_SWEEP_INTERVAL: %w", err)
+	}
+
+	cfg.WorkerConcurrency, err = strconv.Atoi(getEnv("WORKER_CONCURRENCY", "10"))
+	if err != nil || cfg.WorkerConcurrency <= 0 {
+		return nil, fmt.Errorf("invalid WORKER_CONCURRENCY: %q", getEnv("WORKER_CONCURRENCY", "10"))
+	}
+	cfg.WorkerQueues, err = parseQueues(getEnvList("WORKER_QUEUES", "notifications:6,links:3,default:1"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid WORKER_QUEUES: %w", err)
+	}
+	cfg.WorkerStrictPriority, err = strconv.ParseBool(getEnv("WORKER_STRICT_PRIORITY", "false"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid WORKER_STRICT_PRIORITY: %w", err)
+	}
+	cfg.NotificationQueue = getEnv("JOBS_NOTIFICATION_QUEUE", "notifications")
+	cfg.LinkExtractionQueue = getEnv("JOBS_LINK_QUEUE", "links")
+
+	cfg.JobMaxRetry, err = strconv.Atoi(getEnv("JOBS_MAX_RETRY", "5"))
+	if err != nil || cfg.JobMaxRetry < 0 {
+		return nil, fmt.Errorf("invalid JOBS_MAX_RETRY: %q", getEnv("JOBS_MAX_RETRY", "5"))
+	}
+	cfg.JobRetryBaseDelay, err = time.ParseDuration(getEnv("JOBS_RETRY_BASE_DELAY", "10s"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid JOBS_RETRY_BASE_DELAY: %w", err)
+	}
+	cfg.JobRetryMaxDelay, err = time.ParseDuration(getEnv("JOBS_RETRY_MAX_DELAY", "10m"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid JOBS_RETRY_MAX_DELAY: %w", err)
+	}
+
+	cfg.ReadTimeout, err = time.ParseDuration(getEnv("REQUEST_TIMEOUT_READ", "2s"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid REQUEST_TIMEOUT_READ: %w", err)
+	}
+	cfg.WriteTimeout, err = time.ParseDuration(getEnv("REQUEST_TIMEOUT_WRITE", "5s"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid REQUEST_TIMEOUT_WRITE: %w", err)
+	}
+
+	cfg.StartupTimeout, err = time.ParseDuration(getEnv("STARTUP_TIMEOUT", "60s"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid STARTUP_TIMEOUT: %w", err)
+	}
+
+	cfg.DBQueryWarnThreshold, err = strconv.Atoi(getEnv("DB_QUERY_WARN_THRESHOLD", "10"))
+	if err != nil || cfg.DBQueryWarnThreshold < 0 {
+		return nil, fmt.Errorf("invalid DB_QUERY_WARN_THRESHOLD: %q", getEnv("DB_QUERY_WARN_THRESHOLD", "10"))
+	}
+
+	cfg.CORSAllowedOrigins = getEnvList("CORS_ALLOWED_ORIGINS", "")
+	cfg.CORSAllowedMethods = getEnvList("CORS_ALLOWED_METHODS", "GET,POST,PUT,DELETE")
+	cfg.CORSAllowedHeaders = getEnvList("CORS_ALLOWED_HEADERS", "Authorization,Content-Type")
+	cfg.CORSAllowCredentials, err = strconv.ParseBool(getEnv("CORS_ALLOW_CREDENTIALS", "false"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid CORS_ALLOW_CREDENTIALS: %w", err)
+	}
+	cfg.CORSMaxAge, err = time.ParseDuration(getEnv("CORS_MAX_AGE", "10m"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid CORS_MAX_AGE: %w", err)
+	}
+	cfg.HSTSMaxAge, err = time.ParseDuration(getEnv("HSTS_MAX_AGE", "0s"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid HSTS_MAX_AGE: %w", err)
+	}
+	cfg.ContentSecurityPolicy = getEnv("CONTENT_SECURITY_POLICY", "default-src 'none'; frame-ancestors 'none'")
+
+	cfg.PrometheusEnabled, err = strconv.ParseBool(getEnv("PROMETHEUS_ENABLED", "false"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid PROMETHEUS_ENABLED: %w", err)
+	}
+
+	for _, email := range strings.Split(getEnv("ADMIN_EMAILS", ""), ",") {
+		if email = strings.TrimSpace(strings.ToLower(email)); email != "" {
+			cfg.AdminEmails = append(cfg.AdminEmails, email)
+		}
+	}
+
 	if err := cfg.validate(); err != nil {
 		return nil, err
 	}
@@ -53,6 +197,21 @@ func (c *Config) validate() error {
 	if c.JWTSecret == "" {
 		return fmt.Errorf("JWT_SECRET is required")
 	}
+	if c.OutboxSweepInterval <= 0 {
+		return fmt.Errorf("OUTBOX_SWEEP_INTERVAL must be positive")
+	}
+	// A job routed to a queue the worker does not serve would never run.
+	for env, queue := range map[string]string{
+		"JOBS_NOTIFICATION_QUEUE": c.NotificationQueue,
+		"JOBS_LINK_QUEUE":         c.LinkExtractionQueue,
+	} {
+		if _, ok := c.WorkerQueues[queue]; !ok {
+			return fmt.Errorf("%s %q is not in WORKER_QUEUES", env, queue)
+		}
+	}
+	if c.JobRetryBaseDelay <= 0 || c.JobRetryMaxDelay < c.JobRetryBaseDelay {
+		return fmt.Errorf("JOBS_RETRY_BASE_DELAY must be positive and at most JOBS_RETRY_MAX_DELAY")
+	}
 	return nil
 }
 
@@ -66,3 +225,35 @@ func getEnv(key, fallback string) string {
 	}
 	return fallback
 }
+
+// getEnvList splits a comma-separated variable, dropping empty entries.
+func getEnvList(key, fallback string) []string {
+	var out []string
+	for _, v := range strings.Split(getEnv(key, fallback), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// parseQueues reads name:weight pairs.
+func parseQueues(entries []string) (map[string]int, error) {
+	queues := map[string]int{}
+	for _, entry := range entries {
+		name, weight, ok := strings.Cut(entry, ":")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("%q is not name:weight", entry)
+		}
+		w, err := strconv.Atoi(strings.TrimSpace(weight))
+		if err != nil || w <= 0 {
+			return nil, fmt.Errorf("queue %q needs a positive weight", name)
+		}
+		queues[name] = w
+	}
+	if len(queues) == 0 {
+		return nil, fmt.Errorf("at least one queue is required")
+	}
+	return queues, nil
+}
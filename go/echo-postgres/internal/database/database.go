@@ -1,6 +1,7 @@
 package database
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/uptrace/opentelemetry-go-extra/otelgorm"
@@ -18,7 +19,8 @@ func Connect(databaseURL string, isDevelopment bool) error {
 	}
 
 	db, err := gorm.Open(postgres.Open(databaseURL), &gorm.Config{
-		Logger: logger.Default.LogMode(logLevel),
+		Logger:         logger.Default.LogMode(logLevel),
+		TranslateError: true,
 	})
 	if err != nil {
 		return fmt.Errorf("failed to connect to database: %w", err)
@@ -27,6 +29,9 @@ func Connect(databaseURL string, isDevelopment bool) error {
 	if err := db.Use(otelgorm.NewPlugin()); err != nil {
 		return fmt.Errorf("failed to setup otel plugin: %w", err)
 	}
+	if err := registerQueryCounter(db); err != nil {
+		return fmt.Errorf("failed to register query counter: %w", err)
+	}
 
 	sqlDB, err := db.DB()
 	if err != nil {
@@ -40,12 +45,12 @@ func Connect(databaseURL string, isDevelopment bool) error {
 	return nil
 }
 
-func CheckHealth() error {
+func CheckHealth(ctx context.Context) error {
 	sqlDB, err := DB.DB()
 	if err != nil {
 		return err
 	}
-	return sqlDB.Ping()
+	return sqlDB.PingContext(ctx)
 }
 
 func Close() error {
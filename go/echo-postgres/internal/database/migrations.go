@@ -8,6 +8,12 @@ func Migrate() error {
 	return DB.AutoMigrate(
 		&models.User{},
 		&models.Article{},
+		&models.SlugHistory{},
 		&models.Favorite{},
+		&models.Report{},
+		&models.AuditEntry{},
+		&models.NotificationOutbox{},
+		&models.ArticleLink{},
+		&models.LinkExtractionJob{},
 	)
 }
@@ -0,0 +1,59 @@
+package database
+
+import (
+	"context"
+	"sync/atomic"
+
+	"gorm.io/gorm"
+)
+
+type queryCounterKey struct{}
+
+// QueryCounter counts the statements GORM runs with a context carrying it.
+type QueryCounter struct {
+	n atomic.Int64
+}
+
+func (c *QueryCounter) Count() int64 {
+	return c.n.Load()
+}
+
+// WithQueryCounter returns a context whose queries are counted by the
+// returned counter. Queries only count when they run through
+// DB.WithContext(ctx).
+func WithQueryCounter(ctx context.Context) (context.Context, *QueryCounter) {
+	c := &QueryCounter{}
+	return context.WithValue(ctx, queryCounterKey{}, c), c
+}
+
+func countQuery(db *gorm.DB) {
+	if db.Statement == nil || db.Statement.Context == nil {
+		return
+	}
+	if c, ok := db.Statement.Context.Value(queryCounterKey{}).(*QueryCounter); ok {
+		c.n.Add(1)
+	}
+}
+
+// registerQueryCounter hooks countQuery in after every statement-executing
+// callback. Preloads run as their own query callbacks, so an N+1 shows up as
+// N+1 counts.
+func registerQueryCounter(db *gorm.DB) error {
+	cb := db.Callback()
+	if err := cb.Create().After("gorm:create").Register("querycount:create", countQuery); err != nil {
+		return err
+	}
+	if err := cb.Query().After("gorm:query").Register("querycount:query", countQuery); err != nil {
+		return err
+	}
+	if err := cb.Update().After("gorm:update").Register("querycount:update", countQuery); err != nil {
+		return err
+	}
+	if err := cb.Delete().After("gorm:delete").Register("querycount:delete", countQuery); err != nil {
+		return err
+	}
+	if err := cb.Row().After("gorm:row").Register("querycount:row", countQuery); err != nil {
+		return err
+	}
+	return cb.Raw().After("gorm:raw").Register("querycount:raw", countQuery)
+}
@@ -4,23 +4,27 @@ import (
 	"errors"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"go-echo-postgres/internal/jobs"
 	"go-echo-postgres/internal/middleware"
 	"go-echo-postgres/internal/services"
 
+	"github.com/base-14/examples/go/pkg/response"
 	"github.com/labstack/echo/v4"
 )
 
 type ArticleHandler struct {
 	articleService *services.ArticleService
 	jobClient      *jobs.Client
+	outbox         *jobs.OutboxRelay
 }
 
-func NewArticleHandler(articleService *services.ArticleService, jobClient *jobs.Client) *ArticleHandler {
+func NewArticleHandler(articleService *services.ArticleService, jobClient *jobs.Client, outbox *jobs.OutboxRelay) *ArticleHandler {
 	return &ArticleHandler{
 		articleService: articleService,
 		jobClient:      jobClient,
+		outbox:         outbox,
 	}
 }
 
@@ -56,7 +60,7 @@ func (h *ArticleHandler) List(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusInternalServerError, "failed to list articles")
 	}
 
-	return c.JSON(http.StatusOK, result)
+	return c.JSON(http.StatusOK, response.Page(ctx, result.Articles, response.PageOf(result.Page, result.PerPage, result.TotalCount)))
 }
 
 func (h *ArticleHandler) Create(c echo.Context) error {
@@ -81,14 +85,14 @@ func (h *ArticleHandler) Create(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusInternalServerError, "failed to create article")
 	}
 
-	if h.jobClient != nil {
-		h.jobClient.EnqueueNotification(ctx, article.ID, article.Title)
+	// The notification was written to the outbox with the article; have the
+	// relay send it now rather than at the next sweep.
+	if h.outbox != nil {
+		h.outbox.Kick()
 	}
 
 	favorited := false
-	return c.JSON(http.StatusCreated, map[string]interface{}{
-		"article": article.ToResponse(favorited),
-	})
+	return c.JSON(http.StatusCreated, response.Data(ctx, article.ToResponse(favorited)))
 }
 
 func (h *ArticleHandler) Get(c echo.Context) error {
@@ -98,7 +102,7 @@ func (h *ArticleHandler) Get(c echo.Context) error {
 	article, err := h.articleService.GetBySlug(ctx, slug)
 	if err != nil {
 		if errors.Is(err, services.ErrArticleNotFound) {
-			return echo.NewHTTPError(http.StatusNotFound, "article not found")
+			return h.redirectRetiredSlug(c, slug)
 		}
 		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get article")
 	}
@@ -108,9 +112,7 @@ func (h *ArticleHandler) Get(c echo.Context) error {
 		favorited = h.articleService.IsFavorited(ctx, article.ID, userID)
 	}
 
-	return c.JSON(http.StatusOK, map[string]interface{}{
-		"article": article.ToResponse(favorited),
-	})
+	return c.JSON(http.StatusOK, response.Data(ctx, article.ToResponse(favorited)))
 }
 
 func (h *ArticleHandler) Update(c echo.Context) error {
@@ -138,10 +140,53 @@ func (h *ArticleHandler) Update(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusInternalServerError, "failed to update article")
 	}
 
+	if h.jobClient != nil {
+		h.jobClient.EnqueueNotification(ctx, article.ID, article.Title)
+	}
+
+	// A body edit journals a link extraction with the update.
+	if input.Body != nil && h.outbox != nil {
+		h.outbox.Kick()
+	}
+
 	favorited := h.articleService.IsFavorited(ctx, article.ID, userID)
-	return c.JSON(http.StatusOK, map[string]interface{}{
-		"article": article.ToResponse(favorited),
-	})
+	return c.JSON(http.StatusOK, response.Data(ctx, article.ToResponse(favorited)))
+}
+
+// Links returns the links and images extracted from an article and the
+// status of the latest extraction.
+func (h *ArticleHandler) Links(c echo.Context) error {
+	ctx := c.Request().Context()
+	slug := c.Param("slug")
+
+	links, err := h.articleService.Links(ctx, slug)
+	if err != nil {
+		if errors.Is(err, services.ErrArticleNotFound) {
+			return h.redirectRetiredSlug(c, slug)
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get article links")
+	}
+
+	return c.JSON(http.StatusOK, response.Data(ctx, links))
+}
+
+// redirectRetiredSlug answers a read of a slug that no article has with a
+// permanent redirect to the same route under the current slug of the article
+// that retired it, or 404 if none did.
+func (h *ArticleHandler) redirectRetiredSlug(c echo.Context, slug string) error {
+	current, err := h.articleService.CurrentSlug(c.Request().Context(), slug)
+	if err != nil {
+		if errors.Is(err, services.ErrArticleNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, "article not found")
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get article")
+	}
+
+	location := strings.Replace(c.Path(), ":slug", current, 1)
+	if query := c.QueryString(); query != "" {
+		location += "?" + query
+	}
+	return c.Redirect(http.StatusMovedPermanently, location)
 }
 
 func (h *ArticleHandler) Delete(c echo.Context) error {
@@ -187,9 +232,7 @@ func (h *ArticleHandler) Favorite(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusInternalServerError, "failed to favorite article")
 	}
 
-	return c.JSON(http.StatusOK, map[string]interface{}{
-		"article": article.ToResponse(true),
-	})
+	return c.JSON(http.StatusOK, response.Data(ctx, article.ToResponse(true)))
 }
 
 func (h *ArticleHandler) Unfavorite(c echo.Context) error {
@@ -212,7 +255,5 @@ func (h *ArticleHandler) Unfavorite(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusInternalServerError, "failed to unfavorite article")
 	}
 
-	return c.JSON(http.StatusOK, map[string]interface{}{
-		"article": article.ToResponse(false),
-	})
+	return c.JSON(http.StatusOK, response.Data(ctx, article.ToResponse(false)))
 }
@@ -7,6 +7,7 @@ import (
 	"go-echo-postgres/internal/middleware"
 	"go-echo-postgres/internal/services"
 
+	"github.com/base-14/examples/go/pkg/response"
 	"github.com/labstack/echo/v4"
 )
 
@@ -46,7 +47,7 @@ func (h *AuthHandler) Register(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusInternalServerError, "failed to register user")
 	}
 
-	return c.JSON(http.StatusCreated, result)
+	return c.JSON(http.StatusCreated, response.Data(ctx, result))
 }
 
 func (h *AuthHandler) Login(c echo.Context) error {
@@ -69,7 +70,7 @@ func (h *AuthHandler) Login(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusInternalServerError, "failed to login")
 	}
 
-	return c.JSON(http.StatusOK, result)
+	return c.JSON(http.StatusOK, response.Data(ctx, result))
 }
 
 func (h *AuthHandler) GetCurrentUser(c echo.Context) error {
@@ -88,13 +89,11 @@ func (h *AuthHandler) GetCurrentUser(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get user")
 	}
 
-	return c.JSON(http.StatusOK, map[string]interface{}{
-		"user": user.ToResponse(),
-	})
+	return c.JSON(http.StatusOK, response.Data(ctx, user.ToResponse()))
 }
 
 func (h *AuthHandler) Logout(c echo.Context) error {
-	return c.JSON(http.StatusOK, map[string]string{
+	return c.JSON(http.StatusOK, response.Data(c.Request().Context(), map[string]string{
 		"message": "logged out successfully",
-	})
+	}))
 }
@@ -7,6 +7,7 @@ import (
 
 	"go-echo-postgres/internal/database"
 
+	"github.com/base-14/examples/go/pkg/response"
 	"github.com/hibiken/asynq"
 	"github.com/labstack/echo/v4"
 )
@@ -30,7 +31,7 @@ func (h *HealthHandler) Check(c echo.Context) error {
 	ctx := c.Request().Context()
 
 	dbStatus := "healthy"
-	if err := database.CheckHealth(); err != nil {
+	if err := database.CheckHealth(ctx); err != nil {
 		dbStatus = "unhealthy"
 	}
 
@@ -46,13 +47,11 @@ func (h *HealthHandler) Check(c echo.Context) error {
 		statusCode = http.StatusServiceUnavailable
 	}
 
-	response := HealthResponse{
+	return c.JSON(statusCode, response.Data(ctx, HealthResponse{
 		Status:   overallStatus,
 		Database: dbStatus,
 		Redis:    redisStatus,
-	}
-
-	return c.JSON(statusCode, response)
+	}))
 }
 
 func (h *HealthHandler) checkRedis(ctx context.Context) error {
@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"go-echo-postgres/internal/middleware"
+	"go-echo-postgres/internal/models"
+	"go-echo-postgres/internal/services"
+
+	"github.com/base-14/examples/go/pkg/response"
+	"github.com/labstack/echo/v4"
+)
+
+type ModerationHandler struct {
+	moderationService *services.ModerationService
+}
+
+func NewModerationHandler(moderationService *services.ModerationService) *ModerationHandler {
+	return &ModerationHandler{
+		moderationService: moderationService,
+	}
+}
+
+type ReportArticleRequest struct {
+	Reason string `json:"reason"`
+}
+
+// ReportDetail is a report with its audit trail, oldest entry first.
+type ReportDetail struct {
+	Report models.ReportResponse       `json:"report"`
+	Audit  []models.AuditEntryResponse `json:"audit"`
+}
+
+func (h *ModerationHandler) Report(c echo.Context) error {
+	ctx := c.Request().Context()
+	slug := c.Param("slug")
+
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
+	var req ReportArticleRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+
+	report, err := h.moderationService.Report(ctx, slug, userID, req.Reason)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidReportReason) {
+			return echo.NewHTTPError(http.StatusBadRequest, "reason is required")
+		}
+		if errors.Is(err, services.ErrArticleNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, "article not found")
+		}
+		if errors.Is(err, services.ErrAlreadyReported) {
+			return echo.NewHTTPError(http.StatusConflict, "you have already reported this article")
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to report article")
+	}
+
+	return c.JSON(http.StatusCreated, response.Data(ctx, report.ToResponse()))
+}
+
+func (h *ModerationHandler) List(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	page, _ := strconv.Atoi(c.QueryParam("page"))
+	perPage, _ := strconv.Atoi(c.QueryParam("per_page"))
+
+	status := models.ReportStatus(c.QueryParam("status"))
+	switch status {
+	case "":
+		status = models.ReportStatusOpen
+	case "all":
+		status = ""
+	case models.ReportStatusOpen, models.ReportStatusResolved, models.ReportStatusDismissed:
+	default:
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid status filter")
+	}
+
+	result, err := h.moderationService.List(ctx, services.ListReportsInput{
+		Page:    page,
+		PerPage: perPage,
+		Status:  status,
+	})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to list reports")
+	}
+
+	return c.JSON(http.StatusOK, response.Page(ctx, result.Reports, response.PageOf(result.Page, result.PerPage, result.TotalCount)))
+}
+
+func (h *ModerationHandler) Get(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	reportID, err := parseReportID(c)
+	if err != nil {
+		return err
+	}
+
+	report, audit, err := h.moderationService.Get(ctx, reportID)
+	if err != nil {
+		if errors.Is(err, services.ErrReportNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, "report not found")
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get report")
+	}
+
+	detail := ReportDetail{
+		Report: report.ToResponse(),
+		Audit:  make([]models.AuditEntryResponse, len(audit)),
+	}
+	for i := range audit {
+		detail.Audit[i] = audit[i].ToResponse()
+	}
+
+	return c.JSON(http.StatusOK, response.Data(ctx, detail))
+}
+
+func (h *ModerationHandler) Resolve(c echo.Context) error {
+	return h.close(c, models.ReportStatusResolved)
+}
+
+func (h *ModerationHandler) Dismiss(c echo.Context) error {
+	return h.close(c, models.ReportStatusDismissed)
+}
+
+func (h *ModerationHandler) close(c echo.Context, to models.ReportStatus) error {
+	ctx := c.Request().Context()
+
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
+	reportID, err := parseReportID(c)
+	if err != nil {
+		return err
+	}
+
+	var input services.ResolveReportInput
+	if err := c.Bind(&input); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+
+	report, err := h.moderationService.Close(ctx, reportID, userID, to, input)
+	if err != nil {
+		if errors.Is(err, services.ErrReportNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, "report not found")
+		}
+		if errors.Is(err, services.ErrReportClosed) {
+			return echo.NewHTTPError(http.StatusConflict, "report is already closed")
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to update report")
+	}
+
+	return c.JSON(http.StatusOK, response.Data(ctx, report.ToResponse()))
+}
+
+func parseReportID(c echo.Context) (uint, error) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return 0, echo.NewHTTPError(http.StatusBadRequest, "invalid report id")
+	}
+	return uint(id), nil
+}
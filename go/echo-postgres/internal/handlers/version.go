@@ -0,0 +1,13 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/base-14/examples/go/pkg/buildinfo"
+	"github.com/base-14/examples/go/pkg/response"
+	"github.com/labstack/echo/v4"
+)
+
+func Version(c echo.Context) error {
+	return c.JSON(http.StatusOK, response.Data(c.Request().Context(), buildinfo.Get()))
+}
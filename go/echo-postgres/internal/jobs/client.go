@@ -3,6 +3,9 @@ package jobs
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
 
 	"go-echo-postgres/internal/logging"
 
@@ -14,14 +17,28 @@ import (
 )
 
 const (
-	TypeNotification = "notification:article"
-	DefaultQueue     = "default"
+	TypeNotification   = "notification:article"
+	TypeLinkExtraction = "links:extract"
+	DefaultQueue       = "default"
 )
 
+// ClientConfig routes each task type to a queue and sets how often a
+// failed task is retried.
+type ClientConfig struct {
+	// Notifications for the same article enqueued within DedupWindow of
+	// each other collapse into a single job; zero disables deduplication.
+	DedupWindow time.Duration
+
+	NotificationQueue   string
+	LinkExtractionQueue string
+	MaxRetry            int
+}
+
 var (
-	tracer       = otel.Tracer("go-echo-postgres")
-	meter        = otel.Meter("go-echo-postgres")
-	jobsEnqueued metric.Int64Counter
+	tracer           = otel.Tracer("go-echo-postgres")
+	meter            = otel.Meter("go-echo-postgres")
+	jobsEnqueued     metric.Int64Counter
+	jobsDeduplicated metric.Int64Counter
 )
 
 type NotificationPayload struct {
@@ -30,11 +47,19 @@ type NotificationPayload struct {
 	TraceContext map[string]string `json:"trace_context"`
 }
 
+type LinkExtractionPayload struct {
+	JobID        uint              `json:"job_id"`
+	ArticleID    uint              `json:"article_id"`
+	TraceContext map[string]string `json:"trace_context"`
+}
+
 type Client struct {
 	client *asynq.Client
+	cfg    ClientConfig
 }
 
-func NewClient(redisAddr string) (*Client, error) {
+// NewClient creates a job client. Empty queue names use DefaultQueue.
+func NewClient(redisAddr string, cfg ClientConfig) (*Client, error) {
 	client := asynq.NewClient(asynq.RedisClientOpt{Addr: redisAddr})
 
 	var err error
@@ -46,7 +71,21 @@ func NewClient(redisAddr string) (*Client, error) {
 		logging.Logger().Error().Err(err).Msg("failed to create jobs enqueued counter")
 	}
 
-	return &Client{client: client}, nil
+	jobsDeduplicated, err = meter.Int64Counter(
+		"jobs.deduplicated",
+		metric.WithDescription("Jobs dropped because an identical job was already queued or recently completed"),
+	)
+	if err != nil {
+		logging.Logger().Error().Err(err).Msg("failed to create jobs deduplicated counter")
+	}
+
+	if cfg.NotificationQueue == "" {
+		cfg.NotificationQueue = DefaultQueue
+	}
+	if cfg.LinkExtractionQueue == "" {
+		cfg.LinkExtractionQueue = DefaultQueue
+	}
+	return &Client{client: client, cfg: cfg}, nil
 }
 
 func (c *Client) Close() error {
@@ -78,7 +117,20 @@ func (c *Client) EnqueueNotification(ctx context.Context, articleID uint, articl
 	}
 
 	task := asynq.NewTask(TypeNotification, payloadBytes)
-	info, err := c.client.EnqueueContext(ctx, task)
+	info, err := c.client.EnqueueContext(ctx, task, c.notificationOpts(articleID)...)
+	if errors.Is(err, asynq.ErrTaskIDConflict) {
+		span.SetAttributes(attribute.Bool("job.deduplicated", true))
+		if jobsDeduplicated != nil {
+			jobsDeduplicated.Add(ctx, 1, metric.WithAttributes(
+				attribute.String("job.type", TypeNotification),
+			))
+		}
+		logging.Info(ctx).
+			Str("job_type", TypeNotification).
+			Uint("article_id", articleID).
+			Msg("job deduplicated")
+		return nil
+	}
 	if err != nil {
 		span.RecordError(err)
 		return err
@@ -103,3 +155,87 @@ func (c *Client) EnqueueNotification(ctx context.Context, articleID uint, articl
 
 	return nil
 }
+
+// EnqueueLinkExtraction enqueues the link extraction journalled as jobID.
+// The task ID is the journal row's, so a row relayed twice (the relay
+// crashed between enqueueing and stamping it) is still processed once.
+func (c *Client) EnqueueLinkExtraction(ctx context.Context, jobID, articleID uint) error {
+	ctx, span := tracer.Start(ctx, "job.enqueue.link_extraction")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.Int64("article.id", int64(articleID)),
+		attribute.Int64("link_job.id", int64(jobID)),
+		attribute.String("job.type", TypeLinkExtraction),
+	)
+
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+
+	payloadBytes, err := json.Marshal(LinkExtractionPayload{
+		JobID:        jobID,
+		ArticleID:    articleID,
+		TraceContext: carrier,
+	})
+	if err != nil {
+		return err
+	}
+
+	task := asynq.NewTask(TypeLinkExtraction, payloadBytes)
+	info, err := c.client.EnqueueContext(ctx, task,
+		asynq.TaskID(fmt.Sprintf("%s:%d", TypeLinkExtraction, jobID)),
+		asynq.Queue(c.cfg.LinkExtractionQueue),
+		asynq.MaxRetry(c.cfg.MaxRetry),
+	)
+	if errors.Is(err, asynq.ErrTaskIDConflict) {
+		span.SetAttributes(attribute.Bool("job.deduplicated", true))
+		if jobsDeduplicated != nil {
+			jobsDeduplicated.Add(ctx, 1, metric.WithAttributes(
+				attribute.String("job.type", TypeLinkExtraction),
+			))
+		}
+		return nil
+	}
+	if err != nil {
+		span.RecordError(err)
+		return err
+	}
+
+	if jobsEnqueued != nil {
+		jobsEnqueued.Add(ctx, 1, metric.WithAttributes(
+			attribute.String("job.type", TypeLinkExtraction),
+		))
+	}
+
+	span.SetAttributes(
+		attribute.String("job.id", info.ID),
+		attribute.String("job.queue", info.Queue),
+	)
+
+	logging.Info(ctx).
+		Str("job_id", info.ID).
+		Str("job_type", TypeLinkExtraction).
+		Uint("article_id", articleID).
+		Uint("link_job_id", jobID).
+		Msg("job enqueued")
+
+	return nil
+}
+
+// notificationOpts gives each article's notification a fixed task ID. asynq
+// rejects an enqueue while a task with the same ID is pending, running or
+// retrying, and Retention keeps the completed task (and its ID) for the rest
+// of the window, so rapid edits produce one notification rather than a storm.
+func (c *Client) notificationOpts(articleID uint) []asynq.Option {
+	opts := []asynq.Option{
+		asynq.Queue(c.cfg.NotificationQueue),
+		asynq.MaxRetry(c.cfg.MaxRetry),
+	}
+	if c.cfg.DedupWindow <= 0 {
+		return opts
+	}
+	return append(opts,
+		asynq.TaskID(fmt.Sprintf("%s:%d", TypeNotification, articleID)),
+		asynq.Retention(c.cfg.DedupWindow),
+	)
+}
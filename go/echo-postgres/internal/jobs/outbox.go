@@ -0,0 +1,199 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"go-echo-postgres/internal/database"
+	"go-echo-postgres/internal/logging"
+	"go-echo-postgres/internal/models"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// outboxBatchSize caps how many rows one dispatch pass enqueues.
+const outboxBatchSize = 50
+
+var (
+	outboxDispatched  metric.Int64Counter
+	outboxDispatchLag metric.Float64Histogram
+)
+
+// OutboxRelay enqueues notifications recorded in notification_outbox and
+// link extractions journalled in link_extraction_jobs. It runs a pass
+// whenever Kick is called, normally right after an article is created or
+// edited, and on a slower sweep that picks up rows left behind when Redis
+// was down or the process stopped before dispatching.
+type OutboxRelay struct {
+	client   *Client
+	interval time.Duration
+	kick     chan struct{}
+}
+
+func NewOutboxRelay(client *Client, sweepInterval time.Duration) *OutboxRelay {
+	var err error
+	outboxDispatched, err = meter.Int64Counter(
+		"outbox.dispatched",
+		metric.WithDescription("Outbox rows handed to the job queue, by result"),
+	)
+	if err != nil {
+		logging.Logger().Error().Err(err).Msg("failed to create outbox dispatched counter")
+	}
+
+	outboxDispatchLag, err = meter.Float64Histogram(
+		"outbox.dispatch.lag",
+		metric.WithDescription("Time from an outbox row being written to its job being enqueued"),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		logging.Logger().Error().Err(err).Msg("failed to create outbox dispatch lag histogram")
+	}
+
+	return &OutboxRelay{
+		client:   client,
+		interval: sweepInterval,
+		kick:     make(chan struct{}, 1),
+	}
+}
+
+// Kick asks for a dispatch pass without waiting for it.
+func (r *OutboxRelay) Kick() {
+	select {
+	case r.kick <- struct{}{}:
+	default:
+	}
+}
+
+// Run dispatches until ctx is cancelled.
+func (r *OutboxRelay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-r.kick:
+		case <-ticker.C:
+		}
+		if err := r.dispatch(ctx); err != nil && ctx.Err() == nil {
+			logging.Logger().Error().Err(err).Msg("outbox dispatch failed")
+		}
+	}
+}
+
+// dispatch enqueues pending notifications, then pending link extractions.
+func (r *OutboxRelay) dispatch(ctx context.Context) error {
+	if err := r.dispatchNotifications(ctx); err != nil {
+		return err
+	}
+	return r.dispatchLinkExtractions(ctx)
+}
+
+// dispatchNotifications enqueues pending rows. Rows are locked with SKIP
+// LOCKED so several API instances can relay the same outbox without double
+// sends.
+func (r *OutboxRelay) dispatchNotifications(ctx context.Context) error {
+	return database.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var pending []models.NotificationOutbox
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("dispatched_at IS NULL").
+			Order("id").
+			Limit(outboxBatchSize).
+			Find(&pending).Error; err != nil {
+			return err
+		}
+
+		for _, row := range pending {
+			// Enqueue under the trace of the request that created the
+			// article, so the notification job joins that trace.
+			jobCtx := extractTraceContext(ctx, row.TraceContext)
+
+			err := r.client.EnqueueNotification(jobCtx, row.ArticleID, row.ArticleTitle)
+			if err != nil {
+				logging.Error(jobCtx).Err(err).
+					Uint("outbox_id", row.ID).
+					Uint("article_id", row.ArticleID).
+					Msg("outbox notification not enqueued")
+			}
+			if err := r.markDispatched(ctx, tx, &models.NotificationOutbox{}, row.ID, row.CreatedAt, TypeNotification, err); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// dispatchLinkExtractions enqueues pending link_extraction_jobs rows, locked
+// the same way as the notification outbox.
+func (r *OutboxRelay) dispatchLinkExtractions(ctx context.Context) error {
+	return database.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var pending []models.LinkExtractionJob
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("dispatched_at IS NULL").
+			Order("id").
+			Limit(outboxBatchSize).
+			Find(&pending).Error; err != nil {
+			return err
+		}
+
+		for _, row := range pending {
+			jobCtx := extractTraceContext(ctx, row.TraceContext)
+
+			err := r.client.EnqueueLinkExtraction(jobCtx, row.ID, row.ArticleID)
+			if err != nil {
+				logging.Error(jobCtx).Err(err).
+					Uint("link_job_id", row.ID).
+					Uint("article_id", row.ArticleID).
+					Msg("link extraction not enqueued")
+			}
+			if err := r.markDispatched(ctx, tx, &models.LinkExtractionJob{}, row.ID, row.CreatedAt, TypeLinkExtraction, err); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// markDispatched records the result of enqueueing one journal row: the
+// dispatch time on success, the error otherwise.
+func (r *OutboxRelay) markDispatched(ctx context.Context, tx *gorm.DB, model interface{}, id uint, createdAt time.Time, jobType string, enqueueErr error) error {
+	updates := map[string]interface{}{"attempts": gorm.Expr("attempts + 1")}
+	result := "success"
+	if enqueueErr != nil {
+		result = "failed"
+		updates["last_error"] = enqueueErr.Error()
+	} else {
+		now := time.Now()
+		updates["dispatched_at"] = now
+		updates["last_error"] = ""
+		if outboxDispatchLag != nil {
+			outboxDispatchLag.Record(ctx, now.Sub(createdAt).Seconds(), metric.WithAttributes(
+				attribute.String("job.type", jobType),
+			))
+		}
+	}
+
+	if err := tx.Model(model).Where("id = ?", id).Updates(updates).Error; err != nil {
+		return err
+	}
+	if outboxDispatched != nil {
+		outboxDispatched.Add(ctx, 1, metric.WithAttributes(
+			attribute.String("outbox.result", result),
+			attribute.String("job.type", jobType),
+		))
+	}
+	return nil
+}
+
+func extractTraceContext(ctx context.Context, traceContext string) context.Context {
+	carrier := propagation.MapCarrier{}
+	_ = json.Unmarshal([]byte(traceContext), &carrier)
+	return otel.GetTextMapPropagator().Extract(ctx, carrier)
+}
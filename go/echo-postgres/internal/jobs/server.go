@@ -2,25 +2,46 @@ package jobs
 
 import (
 	"context"
+	"sync"
+	"time"
 
 	"go-echo-postgres/internal/jobs/tasks"
 	"go-echo-postgres/internal/logging"
 
 	"github.com/hibiken/asynq"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
 )
 
+// ServerConfig sizes the worker. Concurrency is shared by every queue; a
+// queue's weight is its share of the pulls, or its rank with
+// StrictPriority, where a queue is only served while those above it are
+// empty.
+type ServerConfig struct {
+	Concurrency    int
+	Queues         map[string]int
+	StrictPriority bool
+
+	// A failed task is retried after RetryBaseDelay, doubling on each
+	// retry up to RetryMaxDelay.
+	RetryBaseDelay time.Duration
+	RetryMaxDelay  time.Duration
+}
+
 type Server struct {
 	server *asynq.Server
 	mux    *asynq.ServeMux
 }
 
-func NewServer(redisAddr string, concurrency int) *Server {
+func NewServer(redisAddr string, cfg ServerConfig) *Server {
 	server := asynq.NewServer(
 		asynq.RedisClientOpt{Addr: redisAddr},
 		asynq.Config{
-			Concurrency: concurrency,
-			Queues: map[string]int{
-				DefaultQueue: 10,
+			Concurrency:    cfg.Concurrency,
+			Queues:         cfg.Queues,
+			StrictPriority: cfg.StrictPriority,
+			RetryDelayFunc: func(n int, _ error, _ *asynq.Task) time.Duration {
+				return retryDelay(n, cfg.RetryBaseDelay, cfg.RetryMaxDelay)
 			},
 			ErrorHandler: asynq.ErrorHandlerFunc(func(ctx context.Context, task *asynq.Task, err error) {
 				logging.Error(ctx).
@@ -31,8 +52,19 @@ func NewServer(redisAddr string, concurrency int) *Server {
 		},
 	)
 
+	load := newQueueLoad(cfg)
+	load.register()
+
 	mux := asynq.NewServeMux()
+	mux.Use(load.middleware)
 	mux.HandleFunc(TypeNotification, tasks.HandleNotification)
+	mux.HandleFunc(TypeLinkExtraction, tasks.HandleLinkExtraction)
+
+	logging.Logger().Info().
+		Int("concurrency", cfg.Concurrency).
+		Interface("queues", cfg.Queues).
+		Bool("strict_priority", cfg.StrictPriority).
+		Msg("asynq worker configured")
 
 	return &Server{
 		server: server,
@@ -40,6 +72,86 @@ func NewServer(redisAddr string, concurrency int) *Server {
 	}
 }
 
+// retryDelay is base doubled n times, capped at maxDelay.
+func retryDelay(n int, base, maxDelay time.Duration) time.Duration {
+	d := base
+	for i := 0; i < n && d < maxDelay; i++ {
+		d *= 2
+	}
+	return min(d, maxDelay)
+}
+
+// queueLoad counts the tasks each queue has running on this worker, for the
+// utilization gauges.
+type queueLoad struct {
+	concurrency int
+
+	mu   sync.Mutex
+	busy map[string]int
+}
+
+func newQueueLoad(cfg ServerConfig) *queueLoad {
+	busy := make(map[string]int, len(cfg.Queues))
+	for q := range cfg.Queues {
+		busy[q] = 0
+	}
+	return &queueLoad{concurrency: cfg.Concurrency, busy: busy}
+}
+
+func (l *queueLoad) middleware(next asynq.Handler) asynq.Handler {
+	return asynq.HandlerFunc(func(ctx context.Context, task *asynq.Task) error {
+		queue, _ := asynq.GetQueueName(ctx)
+		l.add(queue, 1)
+		defer l.add(queue, -1)
+		return next.ProcessTask(ctx, task)
+	})
+}
+
+func (l *queueLoad) add(queue string, n int) {
+	l.mu.Lock()
+	l.busy[queue] += n
+	l.mu.Unlock()
+}
+
+// register reports, per queue, the tasks running and the share of the
+// worker's concurrency they hold. A queue near 1 while others idle is
+// starving them; all queues low with work pending means the weights, not
+// the concurrency, need tuning.
+func (l *queueLoad) register() {
+	busy, err := meter.Int64ObservableGauge(
+		"jobs.worker.busy",
+		metric.WithDescription("Tasks running on this worker, by queue"),
+		metric.WithUnit("{task}"),
+	)
+	if err != nil {
+		logging.Logger().Error().Err(err).Msg("failed to create worker busy gauge")
+		return
+	}
+	utilization, err := meter.Float64ObservableGauge(
+		"jobs.worker.utilization",
+		metric.WithDescription("Share of the worker's concurrency in use, by queue"),
+		metric.WithUnit("1"),
+	)
+	if err != nil {
+		logging.Logger().Error().Err(err).Msg("failed to create worker utilization gauge")
+		return
+	}
+
+	_, err = meter.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		for queue, n := range l.busy {
+			attrs := metric.WithAttributes(attribute.String("job.queue", queue))
+			o.ObserveInt64(busy, int64(n), attrs)
+			o.ObserveFloat64(utilization, float64(n)/float64(l.concurrency), attrs)
+		}
+		return nil
+	}, busy, utilization)
+	if err != nil {
+		logging.Logger().Error().Err(err).Msg("failed to register worker utilization callback")
+	}
+}
+
 func (s *Server) Start() error {
 	logging.Logger().Info().Msg("starting asynq worker")
 	return s.server.Start(s.mux)
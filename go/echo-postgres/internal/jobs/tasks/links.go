@@ -0,0 +1,283 @@
+package tasks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"go-echo-postgres/internal/database"
+	"go-echo-postgres/internal/logging"
+	"go-echo-postgres/internal/models"
+
+	"github.com/hibiken/asynq"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
+	"gorm.io/gorm"
+)
+
+const (
+	// maxLinksPerArticle bounds how many links one article can make the
+	// worker check.
+	maxLinksPerArticle = 100
+	// linkCheckTimeout bounds each HEAD request.
+	linkCheckTimeout = 5 * time.Second
+	// linkCheckConcurrency is how many links of one article are checked at
+	// once.
+	linkCheckConcurrency = 4
+)
+
+var (
+	// Markdown images must be matched before links, which they contain.
+	markdownImage = regexp.MustCompile(`!\[[^\]]*\]\(\s*<?([^)\s>]+)>?(?:\s+"[^"]*")?\s*\)`)
+	markdownLink  = regexp.MustCompile(`\[[^\]]*\]\(\s*<?([^)\s>]+)>?(?:\s+"[^"]*")?\s*\)`)
+	htmlImage     = regexp.MustCompile(`(?i)<img\b[^>]*\bsrc\s*=\s*["']([^"']+)["']`)
+	htmlLink      = regexp.MustCompile(`(?i)<a\b[^>]*\bhref\s*=\s*["']([^"']+)["']`)
+	bareURL       = regexp.MustCompile(`https?://[^\s<>"'()\[\]]+`)
+)
+
+var (
+	linkClient = &http.Client{
+		Transport: otelhttp.NewTransport(http.DefaultTransport),
+		Timeout:   linkCheckTimeout,
+	}
+	linksChecked metric.Int64Counter
+)
+
+func init() {
+	var err error
+	linksChecked, err = meter.Int64Counter(
+		"article_links.checked",
+		metric.WithDescription("Article links checked, by kind and result"),
+	)
+	if err != nil {
+		logging.Logger().Error().Err(err).Msg("failed to create article links checked counter")
+	}
+}
+
+type LinkExtractionPayload struct {
+	JobID        uint              `json:"job_id"`
+	ArticleID    uint              `json:"article_id"`
+	TraceContext map[string]string `json:"trace_context"`
+}
+
+// HandleLinkExtraction extracts the links and images in an article's body,
+// replaces its article_links rows with them, checks each with a HEAD request
+// and completes the journal row. The body is read when the job runs, so a
+// job behind a newer edit still records the current links.
+func HandleLinkExtraction(ctx context.Context, task *asynq.Task) error {
+	start := time.Now()
+
+	var payload LinkExtractionPayload
+	if err := json.Unmarshal(task.Payload(), &payload); err != nil {
+		recordJobMetrics(ctx, "links:extract", false, time.Since(start))
+		return fmt.Errorf("%w: %v", asynq.SkipRetry, err)
+	}
+
+	parentCtx := otel.GetTextMapPropagator().Extract(
+		context.Background(),
+		propagation.MapCarrier(payload.TraceContext),
+	)
+
+	ctx, span := tracer.Start(parentCtx, "job.link_extraction")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.Int64("article.id", int64(payload.ArticleID)),
+		attribute.Int64("link_job.id", int64(payload.JobID)),
+		attribute.String("job.type", "links:extract"),
+	)
+
+	fail := func(err error) error {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		database.DB.WithContext(ctx).Model(&models.LinkExtractionJob{}).
+			Where("id = ?", payload.JobID).
+			Update("last_error", err.Error())
+		recordJobMetrics(ctx, "links:extract", false, time.Since(start))
+		return err
+	}
+
+	var article models.Article
+	err := database.DB.WithContext(ctx).Select("id", "body").First(&article, payload.ArticleID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// The article was deleted before its links were checked; there is
+		// nothing left to do.
+		span.SetAttributes(attribute.Bool("article.deleted", true))
+		completeLinkJob(ctx, payload.JobID, 0, 0)
+		recordJobMetrics(ctx, "links:extract", true, time.Since(start))
+		return nil
+	}
+	if err != nil {
+		return fail(err)
+	}
+
+	links := ExtractLinks(article.Body)
+	span.SetAttributes(attribute.Int("article.links", len(links)))
+
+	checkLinks(ctx, links)
+
+	broken := 0
+	for i := range links {
+		links[i].ArticleID = article.ID
+		if links[i].Broken {
+			broken++
+		}
+	}
+	span.SetAttributes(attribute.Int("article.links.broken", broken))
+
+	err = database.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("article_id = ?", article.ID).Delete(&models.ArticleLink{}).Error; err != nil {
+			return err
+		}
+		if len(links) == 0 {
+			return nil
+		}
+		return tx.Create(&links).Error
+	})
+	if err != nil {
+		return fail(err)
+	}
+
+	completeLinkJob(ctx, payload.JobID, len(links), broken)
+
+	span.SetStatus(codes.Ok, "links extracted")
+	logging.Info(ctx).
+		Uint("article_id", payload.ArticleID).
+		Uint("link_job_id", payload.JobID).
+		Int("links", len(links)).
+		Int("broken", broken).
+		Msg("article links extracted")
+
+	recordJobMetrics(ctx, "links:extract", true, time.Since(start))
+
+	return nil
+}
+
+// ExtractLinks returns the distinct absolute http(s) links and images in an
+// article body, written as Markdown, HTML or bare URLs, up to
+// maxLinksPerArticle of them.
+func ExtractLinks(body string) []models.ArticleLink {
+	var links []models.ArticleLink
+	seen := map[string]bool{}
+	add := func(raw, kind string) {
+		u, err := url.Parse(html.UnescapeString(strings.TrimSpace(raw)))
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return
+		}
+		u.Fragment = ""
+		key := kind + " " + u.String()
+		if seen[key] || len(links) >= maxLinksPerArticle {
+			return
+		}
+		seen[key] = true
+		links = append(links, models.ArticleLink{URL: u.String(), Kind: kind})
+	}
+
+	// Each pass blanks what it matched so later, looser patterns do not
+	// find the same URL again as a different kind.
+	rest := body
+	for _, p := range []struct {
+		re   *regexp.Regexp
+		kind string
+	}{
+		{markdownImage, models.LinkKindImage},
+		{htmlImage, models.LinkKindImage},
+		{markdownLink, models.LinkKindLink},
+		{htmlLink, models.LinkKindLink},
+	} {
+		for _, m := range p.re.FindAllStringSubmatch(rest, -1) {
+			add(m[1], p.kind)
+		}
+		rest = p.re.ReplaceAllString(rest, " ")
+	}
+	for _, m := range bareURL.FindAllString(rest, -1) {
+		add(strings.TrimRight(m, ".,;:!?"), models.LinkKindLink)
+	}
+	return links
+}
+
+// checkLinks fills in the status of each link, a few at a time.
+func checkLinks(ctx context.Context, links []models.ArticleLink) {
+	sem := make(chan struct{}, linkCheckConcurrency)
+	var wg sync.WaitGroup
+	for i := range links {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(link *models.ArticleLink) {
+			defer func() { <-sem; wg.Done() }()
+			checkLink(ctx, link)
+		}(&links[i])
+	}
+	wg.Wait()
+}
+
+// checkLink sends a HEAD request for link and marks it broken on a
+// transport error or a 4xx/5xx status. Servers that refuse HEAD are asked
+// again with GET before the link is called broken.
+func checkLink(ctx context.Context, link *models.ArticleLink) {
+	status, err := requestStatus(ctx, http.MethodHead, link.URL)
+	if err == nil && (status == http.StatusMethodNotAllowed || status == http.StatusNotImplemented) {
+		status, err = requestStatus(ctx, http.MethodGet, link.URL)
+	}
+
+	now := time.Now()
+	link.CheckedAt = &now
+	link.StatusCode = status
+	result := "ok"
+	switch {
+	case err != nil:
+		link.Broken = true
+		link.CheckError = err.Error()
+		result = "error"
+	case status >= http.StatusBadRequest:
+		link.Broken = true
+		result = "broken"
+	}
+
+	if linksChecked != nil {
+		linksChecked.Add(ctx, 1, metric.WithAttributes(
+			attribute.String("link.kind", link.Kind),
+			attribute.String("link.result", result),
+		))
+	}
+}
+
+func requestStatus(ctx context.Context, method, rawURL string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, rawURL, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("User-Agent", "go-echo-postgres-link-checker")
+	resp, err := linkClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+func completeLinkJob(ctx context.Context, jobID uint, found, broken int) {
+	err := database.DB.WithContext(ctx).Model(&models.LinkExtractionJob{}).
+		Where("id = ?", jobID).
+		Updates(map[string]interface{}{
+			"completed_at": time.Now(),
+			"links_found":  found,
+			"broken_links": broken,
+			"last_error":   "",
+		}).Error
+	if err != nil {
+		logging.Error(ctx).Err(err).Uint("link_job_id", jobID).Msg("failed to complete link extraction job")
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"os"
 	"time"
 
+	"github.com/base-14/examples/go/pkg/milestone"
 	"github.com/rs/zerolog"
 	"go.opentelemetry.io/otel/trace"
 )
@@ -63,3 +64,10 @@ func Warn(ctx context.Context) *zerolog.Event {
 	l := WithContext(ctx)
 	return l.Warn()
 }
+
+// Milestone logs a business milestone under the name and attributes of its
+// span event.
+func Milestone(ctx context.Context, ev milestone.Event) {
+	l := WithContext(ctx)
+	l.Info().Fields(ev.Fields()).Msg(ev.Name)
+}
@@ -16,7 +16,10 @@ type JWTClaims struct {
 
 type contextKey string
 
-const UserIDKey contextKey = "user_id"
+const (
+	UserIDKey    contextKey = "user_id"
+	UserEmailKey contextKey = "user_email"
+)
 
 func JWTAuth(secret string) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
@@ -46,6 +49,7 @@ func JWTAuth(secret string) echo.MiddlewareFunc {
 			}
 
 			c.Set(string(UserIDKey), claims.UserID)
+			c.Set(string(UserEmailKey), claims.Email)
 			return next(c)
 		}
 	}
@@ -76,6 +80,7 @@ func OptionalJWTAuth(secret string) echo.MiddlewareFunc {
 
 			if err == nil && token.Valid {
 				c.Set(string(UserIDKey), claims.UserID)
+				c.Set(string(UserEmailKey), claims.Email)
 			}
 
 			return next(c)
@@ -87,3 +92,22 @@ func GetUserID(c echo.Context) (uint, bool) {
 	userID, ok := c.Get(string(UserIDKey)).(uint)
 	return userID, ok
 }
+
+// RequireAdmin must run after JWTAuth. Admins are identified by the email in
+// their token matching one of the configured ADMIN_EMAILS.
+func RequireAdmin(adminEmails []string) echo.MiddlewareFunc {
+	admins := make(map[string]bool, len(adminEmails))
+	for _, email := range adminEmails {
+		admins[strings.ToLower(email)] = true
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			email, _ := c.Get(string(UserEmailKey)).(string)
+			if !admins[strings.ToLower(email)] {
+				return echo.NewHTTPError(http.StatusForbidden, "admin access required")
+			}
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+type CacheVisibility string
+
+const (
+	CachePublic  CacheVisibility = "public"
+	CachePrivate CacheVisibility = "private"
+	CacheNoStore CacheVisibility = "no-store"
+)
+
+// CachePolicy describes the Cache-Control and Surrogate-Control headers for a
+// route. MaxAge applies to browsers; SurrogateMaxAge to CDNs and shared caches.
+type CachePolicy struct {
+	Visibility           CacheVisibility
+	MaxAge               time.Duration
+	SurrogateMaxAge      time.Duration
+	StaleWhileRevalidate time.Duration
+	// PrivateWhenAuthenticated downgrades the policy to private when the
+	// request carries a token, for responses that embed per-user fields such
+	// as "favorited".
+	PrivateWhenAuthenticated bool
+}
+
+// CachePolicies maps "METHOD /route/pattern" (as registered with echo) to a
+// policy. Unlisted GETs are private; everything else is no-store.
+type CachePolicies map[string]CachePolicy
+
+var cacheResponses metric.Int64Counter
+
+func initCacheMetrics() error {
+	var err error
+	cacheResponses, err = meter.Int64Counter(
+		"http.server.cache.responses",
+		metric.WithDescription("Responses by cacheability and Cache-Control visibility"),
+		metric.WithUnit("{response}"),
+	)
+	return err
+}
+
+func CacheControl(policies CachePolicies) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			policy, ok := policies[req.Method+" "+c.Path()]
+			if !ok {
+				policy = CachePolicy{Visibility: CacheNoStore}
+				if req.Method == http.MethodGet {
+					policy.Visibility = CachePrivate
+				}
+			}
+			if policy.PrivateWhenAuthenticated && req.Header.Get("Authorization") != "" {
+				policy = CachePolicy{Visibility: CachePrivate}
+			}
+
+			res := c.Response()
+			res.Before(func() {
+				// Never let a shared cache hold on to an error.
+				applied := policy
+				if res.Status >= http.StatusBadRequest {
+					applied = CachePolicy{Visibility: CacheNoStore}
+				}
+
+				h := res.Header()
+				h.Set("Cache-Control", applied.cacheControl())
+				if applied.Visibility == CachePublic && applied.SurrogateMaxAge > 0 {
+					h.Set("Surrogate-Control", fmt.Sprintf("max-age=%d", int(applied.SurrogateMaxAge.Seconds())))
+				}
+				if policy.PrivateWhenAuthenticated {
+					h.Add("Vary", "Authorization")
+				}
+
+				if cacheResponses != nil {
+					cacheResponses.Add(req.Context(), 1, metric.WithAttributes(
+						attribute.String("http.route", c.Path()),
+						attribute.Bool("cache.cacheable", applied.Visibility == CachePublic),
+						attribute.String("cache.visibility", string(applied.Visibility)),
+					))
+				}
+			})
+
+			return next(c)
+		}
+	}
+}
+
+func (p CachePolicy) cacheControl() string {
+	switch p.Visibility {
+	case CachePublic:
+		parts := []string{"public", fmt.Sprintf("max-age=%d", int(p.MaxAge.Seconds()))}
+		if p.StaleWhileRevalidate > 0 {
+			parts = append(parts, fmt.Sprintf("stale-while-revalidate=%d", int(p.StaleWhileRevalidate.Seconds())))
+		}
+		return strings.Join(parts, ", ")
+	case CachePrivate:
+		if p.MaxAge > 0 {
+			return fmt.Sprintf("private, max-age=%d", int(p.MaxAge.Seconds()))
+		}
+		return "private, no-cache"
+	default:
+		return "no-store"
+	}
+}
@@ -5,17 +5,13 @@ import (
 
 	"go-echo-postgres/internal/logging"
 
+	"github.com/base-14/examples/go/pkg/response"
 	"github.com/labstack/echo/v4"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
 	"go.opentelemetry.io/otel/trace"
 )
 
-type ErrorResponse struct {
-	Error   string `json:"error"`
-	TraceID string `json:"trace_id,omitempty"`
-}
-
 func ErrorHandler(err error, c echo.Context) {
 	if c.Response().Committed {
 		return
@@ -44,22 +40,12 @@ func ErrorHandler(err error, c echo.Context) {
 
 	span.SetAttributes(attribute.Int("http.response.status_code", code))
 
-	var traceID string
-	if span.SpanContext().HasTraceID() {
-		traceID = span.SpanContext().TraceID().String()
-	}
-
 	logging.Error(ctx).
 		Err(err).
 		Int("status", code).
 		Msg("request error")
 
-	response := ErrorResponse{
-		Error:   message,
-		TraceID: traceID,
-	}
-
-	if err := c.JSON(code, response); err != nil {
+	if err := c.JSON(code, response.Fail(ctx, code, message)); err != nil {
 		logging.Error(ctx).Err(err).Msg("failed to write error response")
 	}
 }
@@ -46,7 +46,16 @@ func InitMetrics() error {
 		return err
 	}
 
-	return nil
+	if err := initCacheMetrics(); err != nil {
+		return err
+	}
+	if err := initSecurityMetrics(); err != nil {
+		return err
+	}
+	if err := initQueryCountMetrics(); err != nil {
+		return err
+	}
+	return initTimeoutMetrics()
 }
 
 func Metrics() echo.MiddlewareFunc {
@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+
+	"go-echo-postgres/internal/database"
+	"go-echo-postgres/internal/logging"
+)
+
+var (
+	queriesPerRequest metric.Int64Histogram
+	queryThresholdHit metric.Int64Counter
+)
+
+func initQueryCountMetrics() error {
+	var err error
+	queriesPerRequest, err = meter.Int64Histogram(
+		"http.server.db.queries",
+		metric.WithDescription("Database queries executed per HTTP request"),
+		metric.WithUnit("{query}"),
+		metric.WithExplicitBucketBoundaries(0, 1, 2, 3, 5, 8, 13, 21, 34, 55, 100),
+	)
+	if err != nil {
+		return err
+	}
+
+	queryThresholdHit, err = meter.Int64Counter(
+		"http.server.db.query_threshold_exceeded",
+		metric.WithDescription("Requests that ran more database queries than the warning threshold"),
+		metric.WithUnit("{request}"),
+	)
+	return err
+}
+
+// QueryCount counts the database queries each request runs and records them
+// by route. A request running more than threshold queries, usually an N+1
+// loop, is logged as a warning and counted; a threshold of 0 disables the
+// warning but still records the histogram.
+func QueryCount(threshold int) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			ctx, counter := database.WithQueryCounter(c.Request().Context())
+			c.SetRequest(c.Request().WithContext(ctx))
+
+			err := next(c)
+
+			n := counter.Count()
+			attrs := metric.WithAttributes(
+				attribute.String("http.method", c.Request().Method),
+				attribute.String("http.route", c.Path()),
+			)
+			queriesPerRequest.Record(ctx, n, attrs)
+			trace.SpanFromContext(ctx).SetAttributes(attribute.Int64("db.query_count", n))
+
+			if threshold > 0 && n > int64(threshold) {
+				queryThresholdHit.Add(ctx, 1, attrs)
+				logging.Warn(ctx).
+					Str("method", c.Request().Method).
+					Str("route", c.Path()).
+					Int64("queries", n).
+					Int("threshold", threshold).
+					Msg("request exceeded database query threshold")
+			}
+
+			return err
+		}
+	}
+}
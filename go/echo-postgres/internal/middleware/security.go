@@ -0,0 +1,178 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// SecurityPolicy configures the CORS policy and the security headers sent on
+// every response. With no AllowedOrigins CORS is off.
+type SecurityPolicy struct {
+	// AllowedOrigins lists exact origins such as "https://app.example.com";
+	// "*" allows any origin, without credentials.
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	AllowCredentials bool
+	// CORSMaxAge is how long browsers may cache a preflight response.
+	CORSMaxAge time.Duration
+	// HSTSMaxAge enables Strict-Transport-Security when positive. Leave it
+	// off unless the API is only reachable over HTTPS.
+	HSTSMaxAge            time.Duration
+	ContentSecurityPolicy string
+}
+
+// Reasons a cross-origin request is rejected, recorded as cors.reason.
+const (
+	corsRejectOrigin  = "origin"
+	corsRejectMethod  = "method"
+	corsRejectHeaders = "headers"
+)
+
+var corsRejected metric.Int64Counter
+
+func initSecurityMetrics() error {
+	var err error
+	corsRejected, err = meter.Int64Counter(
+		"http.server.cors.rejected",
+		metric.WithDescription("Cross-origin requests rejected by the CORS policy"),
+		metric.WithUnit("{request}"),
+	)
+	return err
+}
+
+type corsPolicy struct {
+	anyOrigin bool
+	origins   map[string]bool
+	methods   map[string]bool
+	anyHeader bool
+	headers   map[string]bool
+}
+
+func newCORSPolicy(p SecurityPolicy) corsPolicy {
+	cp := corsPolicy{
+		origins: make(map[string]bool),
+		methods: make(map[string]bool),
+		headers: make(map[string]bool),
+	}
+	for _, o := range p.AllowedOrigins {
+		if o == "*" {
+			cp.anyOrigin = true
+		}
+		cp.origins[strings.TrimSuffix(o, "/")] = true
+	}
+	for _, m := range p.AllowedMethods {
+		cp.methods[strings.ToUpper(m)] = true
+	}
+	for _, h := range p.AllowedHeaders {
+		if h == "*" {
+			cp.anyHeader = true
+		}
+		cp.headers[strings.ToLower(h)] = true
+	}
+	return cp
+}
+
+func (p corsPolicy) enabled() bool {
+	return len(p.origins) > 0
+}
+
+// reject returns why a cross-origin request fails the policy, or "" if it
+// passes. Method and headers are only checked on preflight.
+func (p corsPolicy) reject(origin string, preflight bool, method, headers string) string {
+	if !p.anyOrigin && !p.origins[origin] {
+		return corsRejectOrigin
+	}
+	if !preflight {
+		return ""
+	}
+	if !p.methods[strings.ToUpper(method)] {
+		return corsRejectMethod
+	}
+	if !p.anyHeader {
+		for _, h := range strings.Split(headers, ",") {
+			if h = strings.TrimSpace(h); h != "" && !p.headers[strings.ToLower(h)] {
+				return corsRejectHeaders
+			}
+		}
+	}
+	return ""
+}
+
+// Security sets X-Content-Type-Options, Content-Security-Policy and, when
+// configured, Strict-Transport-Security on every response, and enforces the
+// CORS policy. Preflights are answered here with 204; a cross-origin request
+// the policy doesn't allow gets a 403 and is counted in
+// http.server.cors.rejected. Same-origin requests and requests without an
+// Origin header (curl, server-to-server) are not subject to CORS.
+func Security(policy SecurityPolicy) echo.MiddlewareFunc {
+	cors := newCORSPolicy(policy)
+	allowMethods := strings.Join(policy.AllowedMethods, ", ")
+	allowHeaders := strings.Join(policy.AllowedHeaders, ", ")
+	maxAge := strconv.Itoa(int(policy.CORSMaxAge.Seconds()))
+
+	var hsts string
+	if policy.HSTSMaxAge > 0 {
+		hsts = fmt.Sprintf("max-age=%d; includeSubDomains", int(policy.HSTSMaxAge.Seconds()))
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			h := c.Response().Header()
+
+			h.Set(echo.HeaderXContentTypeOptions, "nosniff")
+			if policy.ContentSecurityPolicy != "" {
+				h.Set(echo.HeaderContentSecurityPolicy, policy.ContentSecurityPolicy)
+			}
+			if hsts != "" {
+				h.Set(echo.HeaderStrictTransportSecurity, hsts)
+			}
+
+			origin := req.Header.Get(echo.HeaderOrigin)
+			if !cors.enabled() || origin == "" || origin == c.Scheme()+"://"+req.Host {
+				return next(c)
+			}
+			h.Add(echo.HeaderVary, echo.HeaderOrigin)
+
+			requestMethod := req.Header.Get(echo.HeaderAccessControlRequestMethod)
+			preflight := req.Method == http.MethodOptions && requestMethod != ""
+			if reason := cors.reject(origin, preflight, requestMethod, req.Header.Get(echo.HeaderAccessControlRequestHeaders)); reason != "" {
+				if corsRejected != nil {
+					corsRejected.Add(req.Context(), 1, metric.WithAttributes(
+						attribute.String("cors.reason", reason),
+						attribute.Bool("cors.preflight", preflight),
+					))
+				}
+				return echo.NewHTTPError(http.StatusForbidden, "cross-origin request not allowed")
+			}
+
+			// A wildcard policy never shares credentials, so "*" is safe to send.
+			if cors.anyOrigin {
+				h.Set(echo.HeaderAccessControlAllowOrigin, "*")
+			} else {
+				h.Set(echo.HeaderAccessControlAllowOrigin, origin)
+				if policy.AllowCredentials {
+					h.Set(echo.HeaderAccessControlAllowCredentials, "true")
+				}
+			}
+
+			if preflight {
+				h.Set(echo.HeaderAccessControlAllowMethods, allowMethods)
+				h.Set(echo.HeaderAccessControlAllowHeaders, allowHeaders)
+				if policy.CORSMaxAge > 0 {
+					h.Set(echo.HeaderAccessControlMaxAge, maxAge)
+				}
+				return c.NoContent(http.StatusNoContent)
+			}
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var deadlineExceeded metric.Int64Counter
+
+func initTimeoutMetrics() error {
+	var err error
+	deadlineExceeded, err = meter.Int64Counter(
+		"http.server.request.deadline_exceeded",
+		metric.WithDescription("Requests whose context deadline expired before the handler finished"),
+		metric.WithUnit("{request}"),
+	)
+	return err
+}
+
+// Timeout puts a deadline on the request context: reads (GET, HEAD) get
+// readTimeout, everything else writeTimeout. Queries run through
+// DB.WithContext(ctx), so GORM cancels them when the deadline passes. A
+// handler that fails because of the deadline is answered with 504 and counted
+// by route.
+func Timeout(readTimeout, writeTimeout time.Duration) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			timeout := writeTimeout
+			if req.Method == http.MethodGet || req.Method == http.MethodHead {
+				timeout = readTimeout
+			}
+
+			ctx, cancel := context.WithTimeout(req.Context(), timeout)
+			defer cancel()
+			c.SetRequest(req.WithContext(ctx))
+
+			err := next(c)
+			if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return err
+			}
+
+			trace.SpanFromContext(ctx).SetAttributes(attribute.Bool("http.request.deadline_exceeded", true))
+			deadlineExceeded.Add(ctx, 1, metric.WithAttributes(
+				attribute.String("http.method", req.Method),
+				attribute.String("http.route", c.Path()),
+				attribute.String("timeout", timeout.String()),
+			))
+
+			if err == nil || c.Response().Committed {
+				return err
+			}
+			return echo.NewHTTPError(http.StatusGatewayTimeout, "request timed out")
+		}
+	}
+}
@@ -2,6 +2,8 @@ package models
 
 import (
 	"time"
+
+	"github.com/base-14/examples/go/pkg/response"
 )
 
 type Article struct {
@@ -19,17 +21,33 @@ type Article struct {
 	Favorites []Favorite `gorm:"foreignKey:ArticleID" json:"-"`
 }
 
+// SlugHistory records a slug an article gave up when its title changed, so
+// links to the old slug still find it. A slug retired twice belongs to the
+// article that gave it up last.
+type SlugHistory struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Slug      string    `gorm:"uniqueIndex;not null" json:"slug"`
+	ArticleID uint      `gorm:"not null;index" json:"article_id"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+
+	Article Article `gorm:"foreignKey:ArticleID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+func (SlugHistory) TableName() string {
+	return "slug_history"
+}
+
 type ArticleResponse struct {
-	ID             uint         `json:"id"`
-	Slug           string       `json:"slug"`
-	Title          string       `json:"title"`
-	Description    string       `json:"description"`
-	Body           string       `json:"body"`
-	FavoritesCount int          `json:"favorites_count"`
-	Favorited      bool         `json:"favorited"`
-	Author         UserResponse `json:"author"`
-	CreatedAt      time.Time    `json:"created_at"`
-	UpdatedAt      time.Time    `json:"updated_at"`
+	ID             uint          `json:"id"`
+	Slug           string        `json:"slug"`
+	Title          string        `json:"title"`
+	Description    string        `json:"description"`
+	Body           string        `json:"body"`
+	FavoritesCount int           `json:"favorites_count"`
+	Favorited      bool          `json:"favorited"`
+	Author         UserResponse  `json:"author"`
+	CreatedAt      response.Time `json:"created_at"`
+	UpdatedAt      response.Time `json:"updated_at"`
 }
 
 func (a *Article) ToResponse(favorited bool) ArticleResponse {
@@ -42,8 +60,8 @@ func (a *Article) ToResponse(favorited bool) ArticleResponse {
 		FavoritesCount: a.FavoritesCount,
 		Favorited:      favorited,
 		Author:         a.Author.ToResponse(),
-		CreatedAt:      a.CreatedAt,
-		UpdatedAt:      a.UpdatedAt,
+		CreatedAt:      response.NewTime(a.CreatedAt),
+		UpdatedAt:      response.NewTime(a.UpdatedAt),
 	}
 }
 
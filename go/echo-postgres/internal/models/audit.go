@@ -0,0 +1,47 @@
+package models
+
+import (
+	"time"
+
+	"github.com/base-14/examples/go/pkg/response"
+)
+
+// AuditEntry records a state change made by a user, e.g. a moderator
+// resolving a report. Entries are append-only.
+type AuditEntry struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	ActorID    uint      `gorm:"not null;index" json:"actor_id"`
+	Action     string    `gorm:"type:varchar(50);not null" json:"action"`
+	EntityType string    `gorm:"type:varchar(50);not null;index:idx_audit_entity" json:"entity_type"`
+	EntityID   uint      `gorm:"not null;index:idx_audit_entity" json:"entity_id"`
+	FromStatus string    `gorm:"type:varchar(20)" json:"from_status,omitempty"`
+	ToStatus   string    `gorm:"type:varchar(20)" json:"to_status,omitempty"`
+	Note       string    `gorm:"type:text" json:"note,omitempty"`
+	CreatedAt  time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+type AuditEntryResponse struct {
+	ID         uint          `json:"id"`
+	ActorID    uint          `json:"actor_id"`
+	Action     string        `json:"action"`
+	EntityType string        `json:"entity_type"`
+	EntityID   uint          `json:"entity_id"`
+	FromStatus string        `json:"from_status,omitempty"`
+	ToStatus   string        `json:"to_status,omitempty"`
+	Note       string        `json:"note,omitempty"`
+	CreatedAt  response.Time `json:"created_at"`
+}
+
+func (a *AuditEntry) ToResponse() AuditEntryResponse {
+	return AuditEntryResponse{
+		ID:         a.ID,
+		ActorID:    a.ActorID,
+		Action:     a.Action,
+		EntityType: a.EntityType,
+		EntityID:   a.EntityID,
+		FromStatus: a.FromStatus,
+		ToStatus:   a.ToStatus,
+		Note:       a.Note,
+		CreatedAt:  response.NewTime(a.CreatedAt),
+	}
+}
@@ -0,0 +1,94 @@
+package models
+
+import (
+	"time"
+
+	"github.com/base-14/examples/go/pkg/response"
+)
+
+// Kinds of reference found in an article body.
+const (
+	LinkKindLink  = "link"
+	LinkKindImage = "image"
+)
+
+// ArticleLink is an outbound link or image referenced by an article body.
+// Rows are replaced wholesale each time the article's links are extracted.
+type ArticleLink struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	ArticleID  uint       `gorm:"not null;uniqueIndex:idx_article_links_url" json:"article_id"`
+	URL        string     `gorm:"type:text;not null;uniqueIndex:idx_article_links_url" json:"url"`
+	Kind       string     `gorm:"not null;uniqueIndex:idx_article_links_url" json:"kind"`
+	StatusCode int        `json:"status_code,omitempty"`
+	Broken     bool       `gorm:"not null;default:false;index" json:"broken"`
+	CheckError string     `gorm:"type:text" json:"check_error,omitempty"`
+	CheckedAt  *time.Time `json:"checked_at,omitempty"`
+}
+
+// LinkExtractionJob journals a request to extract and check an article's
+// links. Rows are written in the same transaction as the article create or
+// body edit; the outbox relay enqueues pending rows and stamps DispatchedAt,
+// and the worker stamps CompletedAt with what it found.
+type LinkExtractionJob struct {
+	ID           uint       `gorm:"primaryKey" json:"id"`
+	ArticleID    uint       `gorm:"not null;index" json:"article_id"`
+	TraceContext string     `gorm:"type:jsonb;not null;default:'{}'" json:"-"`
+	Attempts     int        `gorm:"not null;default:0" json:"attempts"`
+	LastError    string     `gorm:"type:text" json:"last_error,omitempty"`
+	CreatedAt    time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	DispatchedAt *time.Time `gorm:"index" json:"dispatched_at,omitempty"`
+	CompletedAt  *time.Time `json:"completed_at,omitempty"`
+	LinksFound   int        `gorm:"not null;default:0" json:"links_found"`
+	BrokenLinks  int        `gorm:"not null;default:0" json:"broken_links"`
+}
+
+// ArticleLinksResponse is an article's extracted links with the journal row
+// of the most recent extraction.
+type ArticleLinksResponse struct {
+	Links []ArticleLinkResponse      `json:"links"`
+	Job   *LinkExtractionJobResponse `json:"job,omitempty"`
+}
+
+type ArticleLinkResponse struct {
+	URL        string         `json:"url"`
+	Kind       string         `json:"kind"`
+	StatusCode int            `json:"status_code,omitempty"`
+	Broken     bool           `json:"broken"`
+	CheckError string         `json:"check_error,omitempty"`
+	CheckedAt  *response.Time `json:"checked_at,omitempty"`
+}
+
+func (l *ArticleLink) ToResponse() ArticleLinkResponse {
+	return ArticleLinkResponse{
+		URL:        l.URL,
+		Kind:       l.Kind,
+		StatusCode: l.StatusCode,
+		Broken:     l.Broken,
+		CheckError: l.CheckError,
+		CheckedAt:  response.NewTimePtr(l.CheckedAt),
+	}
+}
+
+type LinkExtractionJobResponse struct {
+	ID           uint           `json:"id"`
+	Attempts     int            `json:"attempts"`
+	LastError    string         `json:"last_error,omitempty"`
+	CreatedAt    response.Time  `json:"created_at"`
+	DispatchedAt *response.Time `json:"dispatched_at,omitempty"`
+	CompletedAt  *response.Time `json:"completed_at,omitempty"`
+	LinksFound   int            `json:"links_found"`
+	BrokenLinks  int            `json:"broken_links"`
+}
+
+func (j *LinkExtractionJob) ToResponse() LinkExtractionJobResponse {
+	return LinkExtractionJobResponse{
+		ID:           j.ID,
+		Attempts:     j.Attempts,
+		LastError:    j.LastError,
+		CreatedAt:    response.NewTime(j.CreatedAt),
+		DispatchedAt: response.NewTimePtr(j.DispatchedAt),
+		CompletedAt:  response.NewTimePtr(j.CompletedAt),
+		LinksFound:   j.LinksFound,
+		BrokenLinks:  j.BrokenLinks,
+	}
+}
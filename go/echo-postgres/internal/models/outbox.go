@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+)
+
+// NotificationOutbox holds a notification that must be sent for an article.
+// Rows are written in the same transaction as the article, so an article
+// never exists without its notification intent; the outbox relay enqueues
+// pending rows and stamps DispatchedAt.
+type NotificationOutbox struct {
+	ID           uint       `gorm:"primaryKey" json:"id"`
+	ArticleID    uint       `gorm:"not null;index" json:"article_id"`
+	ArticleTitle string     `gorm:"not null" json:"article_title"`
+	TraceContext string     `gorm:"type:jsonb;not null;default:'{}'" json:"-"`
+	Attempts     int        `gorm:"not null;default:0" json:"attempts"`
+	LastError    string     `gorm:"type:text" json:"last_error,omitempty"`
+	CreatedAt    time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	DispatchedAt *time.Time `gorm:"index" json:"dispatched_at,omitempty"`
+}
+
+func (NotificationOutbox) TableName() string {
+	return "notification_outbox"
+}
@@ -0,0 +1,68 @@
+package models
+
+import (
+	"time"
+
+	"github.com/base-14/examples/go/pkg/response"
+)
+
+type ReportStatus string
+
+const (
+	ReportStatusOpen      ReportStatus = "open"
+	ReportStatusResolved  ReportStatus = "resolved"
+	ReportStatusDismissed ReportStatus = "dismissed"
+)
+
+type Report struct {
+	ID         uint         `gorm:"primaryKey" json:"id"`
+	ArticleID  uint         `gorm:"not null;index" json:"article_id"`
+	ReporterID uint         `gorm:"not null;index" json:"reporter_id"`
+	Reason     string       `gorm:"type:text;not null" json:"reason"`
+	Status     ReportStatus `gorm:"type:varchar(20);not null;default:'open';index" json:"status"`
+	ResolvedBy *uint        `json:"resolved_by,omitempty"`
+	Resolution string       `gorm:"type:text" json:"resolution,omitempty"`
+	ResolvedAt *time.Time   `json:"resolved_at,omitempty"`
+	CreatedAt  time.Time    `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt  time.Time    `gorm:"autoUpdateTime" json:"updated_at"`
+
+	Article  Article `gorm:"foreignKey:ArticleID;constraint:OnDelete:CASCADE" json:"-"`
+	Reporter User    `gorm:"foreignKey:ReporterID" json:"-"`
+}
+
+type ReportResponse struct {
+	ID           uint           `json:"id"`
+	ArticleID    uint           `json:"article_id"`
+	ArticleSlug  string         `json:"article_slug,omitempty"`
+	ArticleTitle string         `json:"article_title,omitempty"`
+	Reporter     UserResponse   `json:"reporter"`
+	Reason       string         `json:"reason"`
+	Status       ReportStatus   `json:"status"`
+	ResolvedBy   *uint          `json:"resolved_by,omitempty"`
+	Resolution   string         `json:"resolution,omitempty"`
+	ResolvedAt   *response.Time `json:"resolved_at,omitempty"`
+	CreatedAt    response.Time  `json:"created_at"`
+}
+
+func (r *Report) ToResponse() ReportResponse {
+	return ReportResponse{
+		ID:           r.ID,
+		ArticleID:    r.ArticleID,
+		ArticleSlug:  r.Article.Slug,
+		ArticleTitle: r.Article.Title,
+		Reporter:     r.Reporter.ToResponse(),
+		Reason:       r.Reason,
+		Status:       r.Status,
+		ResolvedBy:   r.ResolvedBy,
+		Resolution:   r.Resolution,
+		ResolvedAt:   response.NewTimePtr(r.ResolvedAt),
+		CreatedAt:    response.NewTime(r.CreatedAt),
+	}
+}
+
+type ReportsResponse struct {
+	Reports    []ReportResponse `json:"reports"`
+	TotalCount int64            `json:"total_count"`
+	Page       int              `json:"page"`
+	PerPage    int              `json:"per_page"`
+}
@@ -2,6 +2,8 @@ package models
 
 import (
 	"time"
+
+	"github.com/base-14/examples/go/pkg/response"
 )
 
 type User struct {
@@ -19,12 +21,12 @@ type User struct {
 }
 
 type UserResponse struct {
-	ID        uint      `json:"id"`
-	Email     string    `json:"email"`
-	Name      string    `json:"name"`
-	Bio       string    `json:"bio,omitempty"`
-	Image     string    `json:"image,omitempty"`
-	CreatedAt time.Time `json:"created_at"`
+	ID        uint          `json:"id"`
+	Email     string        `json:"email"`
+	Name      string        `json:"name"`
+	Bio       string        `json:"bio,omitempty"`
+	Image     string        `json:"image,omitempty"`
+	CreatedAt response.Time `json:"created_at"`
 }
 
 func (u *User) ToResponse() UserResponse {
@@ -34,6 +36,6 @@ func (u *User) ToResponse() UserResponse {
 		Name:      u.Name,
 		Bio:       u.Bio,
 		Image:     u.Image,
-		CreatedAt: u.CreatedAt,
+		CreatedAt: response.NewTime(u.CreatedAt),
 	}
 }
@@ -2,6 +2,7 @@ package services
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"regexp"
@@ -11,10 +12,16 @@ import (
 	"go-echo-postgres/internal/database"
 	"go-echo-postgres/internal/logging"
 	"go-echo-postgres/internal/models"
+	"go-echo-postgres/internal/telemetry"
 
+	"github.com/base-14/examples/go/pkg/milestone"
+	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 var (
@@ -24,7 +31,20 @@ var (
 	ErrNotFavorited     = errors.New("article not favorited")
 )
 
-var articlesCreatedCounter metric.Int64Counter
+// How a request for a retired slug reached its article: a redirect to the
+// current slug for reads, or a transparent lookup for writes.
+const (
+	SlugResolutionRedirect = "redirect"
+	SlugResolutionLookup   = "lookup"
+)
+
+// maxSlugAttempts bounds how many times Create retries after a slug clash.
+const maxSlugAttempts = 3
+
+var (
+	articlesCreatedCounter metric.Int64Counter
+	slugRedirectCounter    metric.Int64Counter
+)
 
 type ArticleService struct{}
 
@@ -38,6 +58,15 @@ func NewArticleService() *ArticleService {
 		logging.Logger().Error().Err(err).Msg("failed to create articles counter")
 	}
 
+	slugRedirectCounter, err = meter.Int64Counter(
+		"article.slug.redirects",
+		metric.WithDescription("Requests that found an article by a slug retired by a title change, by slug.resolution"),
+		metric.WithUnit("{request}"),
+	)
+	if err != nil {
+		logging.Logger().Error().Err(err).Msg("failed to create slug redirect counter")
+	}
+
 	return &ArticleService{}
 }
 
@@ -69,32 +98,63 @@ func (s *ArticleService) Create(ctx context.Context, authorID uint, input Create
 		attribute.String("article.title", input.Title),
 	)
 
-	slug := generateSlug(input.Title)
-
-	var existingCount int64
-	database.DB.WithContext(ctx).Model(&models.Article{}).Where("slug LIKE ?", slug+"%").Count(&existingCount)
-	if existingCount > 0 {
-		slug = fmt.Sprintf("%s-%d", slug, time.Now().UnixNano())
+	traceContext, err := traceContextJSON(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	article := models.Article{
-		Slug:        slug,
-		Title:       input.Title,
-		Description: input.Description,
-		Body:        input.Body,
-		AuthorID:    authorID,
-	}
+	// The slug's unique index decides conflicts: a clash rolls the whole
+	// transaction back and the next attempt uses a suffixed slug.
+	baseSlug := generateSlug(input.Title)
+	var article models.Article
+	for attempt := 1; ; attempt++ {
+		slug := baseSlug
+		if attempt > 1 {
+			slug = fmt.Sprintf("%s-%d", baseSlug, time.Now().UnixNano())
+		}
 
-	if err := database.DB.WithContext(ctx).Create(&article).Error; err != nil {
-		return nil, err
-	}
+		article = models.Article{
+			Slug:        slug,
+			Title:       input.Title,
+			Description: input.Description,
+			Body:        input.Body,
+			AuthorID:    authorID,
+		}
 
-	if err := database.DB.WithContext(ctx).Preload("Author").First(&article, article.ID).Error; err != nil {
+		err = database.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&article).Error; err != nil {
+				return err
+			}
+			if err := tx.Create(&models.NotificationOutbox{
+				ArticleID:    article.ID,
+				ArticleTitle: article.Title,
+				TraceContext: traceContext,
+			}).Error; err != nil {
+				return err
+			}
+			if err := tx.Create(&models.LinkExtractionJob{
+				ArticleID:    article.ID,
+				TraceContext: traceContext,
+			}).Error; err != nil {
+				return err
+			}
+			return tx.Preload("Author").First(&article, article.ID).Error
+		})
+		if !errors.Is(err, gorm.ErrDuplicatedKey) || attempt == maxSlugAttempts {
+			break
+		}
+		span.AddEvent("slug_conflict", trace.WithAttributes(
+			attribute.String("article.slug", slug),
+			attribute.Int("attempt", attempt),
+		))
+	}
+	if err != nil {
+		span.RecordError(err)
 		return nil, err
 	}
 
 	if articlesCreatedCounter != nil {
-		articlesCreatedCounter.Add(ctx, 1)
+		articlesCreatedCounter.Add(ctx, 1, metric.WithAttributes(telemetry.UserBucket(authorID)))
 	}
 
 	span.SetAttributes(
@@ -102,11 +162,11 @@ func (s *ArticleService) Create(ctx context.Context, authorID uint, input Create
 		attribute.String("article.slug", article.Slug),
 	)
 
-	logging.Info(ctx).
-		Uint("article_id", article.ID).
-		Str("slug", article.Slug).
-		Uint("author_id", authorID).
-		Msg("article created")
+	logging.Milestone(ctx, milestone.Record(ctx, milestone.ArticlePublished,
+		milestone.ArticleID.Int64(int64(article.ID)),
+		milestone.ArticleSlug.String(article.Slug),
+		milestone.UserID.Int64(int64(authorID)),
+	))
 
 	return &article, nil
 }
@@ -128,6 +188,71 @@ func (s *ArticleService) GetBySlug(ctx context.Context, slug string) (*models.Ar
 	return &article, nil
 }
 
+// CurrentSlug returns the slug of the article that gave up slug in a title
+// change, so a read of an old link can be redirected to it.
+func (s *ArticleService) CurrentSlug(ctx context.Context, slug string) (string, error) {
+	ctx, span := tracer.Start(ctx, "article.current_slug")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("article.slug", slug))
+
+	article, err := findByRetiredSlug(ctx, slug)
+	if err != nil {
+		return "", err
+	}
+
+	recordSlugRedirect(ctx, SlugResolutionRedirect)
+	span.SetAttributes(attribute.String("article.current_slug", article.Slug))
+
+	logging.Info(ctx).
+		Str("slug", slug).
+		Str("current_slug", article.Slug).
+		Msg("redirecting retired slug")
+
+	return article.Slug, nil
+}
+
+// findBySlug finds an article by its current slug, falling back to slugs it
+// has retired so writes through an old link still reach it.
+func findBySlug(ctx context.Context, slug string) (*models.Article, error) {
+	var article models.Article
+	err := database.DB.WithContext(ctx).Preload("Author").Where("slug = ?", slug).First(&article).Error
+	if err == nil {
+		return &article, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	retired, err := findByRetiredSlug(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+	recordSlugRedirect(ctx, SlugResolutionLookup)
+	return retired, nil
+}
+
+func findByRetiredSlug(ctx context.Context, slug string) (*models.Article, error) {
+	var history models.SlugHistory
+	if err := database.DB.WithContext(ctx).
+		Preload("Article.Author").
+		Where("slug = ?", slug).
+		First(&history).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrArticleNotFound
+		}
+		return nil, err
+	}
+	return &history.Article, nil
+}
+
+func recordSlugRedirect(ctx context.Context, resolution string) {
+	if slugRedirectCounter != nil {
+		slugRedirectCounter.Add(ctx, 1, metric.WithAttributes(attribute.String("slug.resolution", resolution)))
+	}
+	trace.SpanFromContext(ctx).SetAttributes(attribute.String("article.slug.resolution", resolution))
+}
+
 func (s *ArticleService) List(ctx context.Context, input ListArticlesInput) (*models.ArticlesResponse, error) {
 	ctx, span := tracer.Start(ctx, "article.list")
 	defer span.End()
@@ -270,7 +395,7 @@ func (s *ArticleService) Update(ctx context.Context, slug string, userID uint, i
 		attribute.Int64("user.id", int64(userID)),
 	)
 
-	article, err := s.GetBySlug(ctx, slug)
+	article, err := findBySlug(ctx, slug)
 	if err != nil {
 		return nil, err
 	}
@@ -280,9 +405,11 @@ func (s *ArticleService) Update(ctx context.Context, slug string, userID uint, i
 	}
 
 	updates := make(map[string]interface{})
+	oldSlug, newSlug := article.Slug, article.Slug
 	if input.Title != nil {
+		newSlug = generateSlug(*input.Title)
 		updates["title"] = *input.Title
-		updates["slug"] = generateSlug(*input.Title)
+		updates["slug"] = newSlug
 	}
 	if input.Description != nil {
 		updates["description"] = *input.Description
@@ -291,14 +418,41 @@ func (s *ArticleService) Update(ctx context.Context, slug string, userID uint, i
 		updates["body"] = *input.Body
 	}
 
-	if len(updates) > 0 {
-		if err := database.DB.WithContext(ctx).Model(article).Updates(updates).Error; err != nil {
+	// A changed body needs its links extracted again; the journal row is
+	// written with the edit so the two cannot disagree.
+	bodyChanged := input.Body != nil && *input.Body != article.Body
+	var traceContext string
+	if bodyChanged {
+		if traceContext, err = traceContextJSON(ctx); err != nil {
 			return nil, err
 		}
-		if err := database.DB.WithContext(ctx).Preload("Author").First(article, article.ID).Error; err != nil {
+	}
+
+	if len(updates) > 0 {
+		err := database.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(article).Updates(updates).Error; err != nil {
+				return err
+			}
+			if newSlug != oldSlug {
+				if err := retireSlug(tx, article.ID, oldSlug, newSlug); err != nil {
+					return err
+				}
+			}
+			if bodyChanged {
+				if err := tx.Create(&models.LinkExtractionJob{
+					ArticleID:    article.ID,
+					TraceContext: traceContext,
+				}).Error; err != nil {
+					return err
+				}
+			}
+			return tx.Preload("Author").First(article, article.ID).Error
+		})
+		if err != nil {
 			return nil, err
 		}
 	}
+	span.SetAttributes(attribute.Bool("article.body_changed", bodyChanged))
 
 	logging.Info(ctx).
 		Uint("article_id", article.ID).
@@ -308,6 +462,50 @@ func (s *ArticleService) Update(ctx context.Context, slug string, userID uint, i
 	return article, nil
 }
 
+// Links returns the links extracted from an article's body and the journal
+// row of the latest extraction, which is still pending if CompletedAt is
+// unset.
+func (s *ArticleService) Links(ctx context.Context, slug string) (*models.ArticleLinksResponse, error) {
+	ctx, span := tracer.Start(ctx, "article.links")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("article.slug", slug))
+
+	article, err := s.GetBySlug(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+
+	links := make([]models.ArticleLink, 0)
+	if err := database.DB.WithContext(ctx).
+		Where("article_id = ?", article.ID).
+		Order("kind, url").
+		Find(&links).Error; err != nil {
+		return nil, err
+	}
+
+	resp := &models.ArticleLinksResponse{Links: make([]models.ArticleLinkResponse, len(links))}
+	for i := range links {
+		resp.Links[i] = links[i].ToResponse()
+	}
+	var job models.LinkExtractionJob
+	err = database.DB.WithContext(ctx).
+		Where("article_id = ?", article.ID).
+		Order("id DESC").
+		First(&job).Error
+	switch {
+	case err == nil:
+		jobResponse := job.ToResponse()
+		resp.Job = &jobResponse
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return nil, err
+	}
+
+	span.SetAttributes(attribute.Int("article.links", len(links)))
+
+	return resp, nil
+}
+
 func (s *ArticleService) Delete(ctx context.Context, slug string, userID uint) error {
 	ctx, span := tracer.Start(ctx, "article.delete")
 	defer span.End()
@@ -317,7 +515,7 @@ func (s *ArticleService) Delete(ctx context.Context, slug string, userID uint) e
 		attribute.Int64("user.id", int64(userID)),
 	)
 
-	article, err := s.GetBySlug(ctx, slug)
+	article, err := findBySlug(ctx, slug)
 	if err != nil {
 		return err
 	}
@@ -347,7 +545,7 @@ func (s *ArticleService) Favorite(ctx context.Context, slug string, userID uint)
 		attribute.Int64("user.id", int64(userID)),
 	)
 
-	article, err := s.GetBySlug(ctx, slug)
+	article, err := findBySlug(ctx, slug)
 	if err != nil {
 		return nil, err
 	}
@@ -378,10 +576,12 @@ func (s *ArticleService) Favorite(ctx context.Context, slug string, userID uint)
 		return nil, err
 	}
 
-	logging.Info(ctx).
-		Uint("article_id", article.ID).
-		Uint("user_id", userID).
-		Msg("article favorited")
+	logging.Milestone(ctx, milestone.Record(ctx, milestone.FavoriteAdded,
+		milestone.ArticleID.Int64(int64(article.ID)),
+		milestone.ArticleSlug.String(article.Slug),
+		milestone.UserID.Int64(int64(userID)),
+		milestone.ArticleFavoritesCount.Int(article.FavoritesCount),
+	))
 
 	return article, nil
 }
@@ -395,7 +595,7 @@ func (s *ArticleService) Unfavorite(ctx context.Context, slug string, userID uin
 		attribute.Int64("user.id", int64(userID)),
 	)
 
-	article, err := s.GetBySlug(ctx, slug)
+	article, err := findBySlug(ctx, slug)
 	if err != nil {
 		return nil, err
 	}
@@ -438,6 +638,19 @@ func (s *ArticleService) IsFavorited(ctx context.Context, articleID, userID uint
 	return count > 0
 }
 
+// retireSlug records oldSlug in the slug history of articleID, taking it
+// over if another article retired it first, and drops any history row for
+// newSlug because the live article now owns it.
+func retireSlug(tx *gorm.DB, articleID uint, oldSlug, newSlug string) error {
+	if err := tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "slug"}},
+		DoUpdates: clause.AssignmentColumns([]string{"article_id", "created_at"}),
+	}).Create(&models.SlugHistory{Slug: oldSlug, ArticleID: articleID}).Error; err != nil {
+		return err
+	}
+	return tx.Where("slug = ?", newSlug).Delete(&models.SlugHistory{}).Error
+}
+
 func generateSlug(title string) string {
 	slug := strings.ToLower(title)
 	reg := regexp.MustCompile(`[^a-z0-9]+`)
@@ -445,3 +658,15 @@ func generateSlug(title string) string {
 	slug = strings.Trim(slug, "-")
 	return slug
 }
+
+// traceContextJSON serialises the trace in ctx for a journal row, so the job
+// it becomes joins the trace of the request that wrote it.
+func traceContextJSON(ctx context.Context) (string, error) {
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	b, err := json.Marshal(carrier)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
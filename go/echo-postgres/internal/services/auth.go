@@ -10,6 +10,7 @@ import (
 	"go-echo-postgres/internal/middleware"
 	"go-echo-postgres/internal/models"
 
+	"github.com/base-14/examples/go/pkg/milestone"
 	"github.com/golang-jwt/jwt/v5"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
@@ -23,6 +24,7 @@ var (
 	meter               = otel.Meter("go-echo-postgres")
 	registrationCounter metric.Int64Counter
 	loginCounter        metric.Int64Counter
+	loginDuration       metric.Float64Histogram
 )
 
 var (
@@ -31,6 +33,16 @@ var (
 	ErrUserNotFound       = errors.New("user not found")
 )
 
+// Outcomes recorded on the auth counters and login duration histogram as
+// auth.outcome.
+const (
+	AuthOutcomeSuccess      = "success"
+	AuthOutcomeBadPassword  = "bad_password"
+	AuthOutcomeUnknownEmail = "unknown_email"
+	AuthOutcomeEmailTaken   = "email_taken"
+	AuthOutcomeError        = "error"
+)
+
 type AuthService struct {
 	jwtSecret    string
 	jwtExpiresIn time.Duration
@@ -40,7 +52,7 @@ func NewAuthService(jwtSecret string, jwtExpiresIn time.Duration) *AuthService {
 	var err error
 	registrationCounter, err = meter.Int64Counter(
 		"auth.registration.total",
-		metric.WithDescription("Total number of user registrations"),
+		metric.WithDescription("Total number of user registrations, by outcome"),
 	)
 	if err != nil {
 		logging.Logger().Error().Err(err).Msg("failed to create registration counter")
@@ -48,12 +60,21 @@ func NewAuthService(jwtSecret string, jwtExpiresIn time.Duration) *AuthService {
 
 	loginCounter, err = meter.Int64Counter(
 		"auth.login.attempts",
-		metric.WithDescription("Total number of login attempts"),
+		metric.WithDescription("Total number of login attempts, by outcome"),
 	)
 	if err != nil {
 		logging.Logger().Error().Err(err).Msg("failed to create login counter")
 	}
 
+	loginDuration, err = meter.Float64Histogram(
+		"auth.login.duration",
+		metric.WithDescription("Login duration in milliseconds, including password hashing, by outcome"),
+		metric.WithUnit("ms"),
+	)
+	if err != nil {
+		logging.Logger().Error().Err(err).Msg("failed to create login duration histogram")
+	}
+
 	return &AuthService{
 		jwtSecret:    jwtSecret,
 		jwtExpiresIn: jwtExpiresIn,
@@ -82,9 +103,18 @@ func (s *AuthService) Register(ctx context.Context, input RegisterInput) (*AuthR
 
 	span.SetAttributes(attribute.String("user.email", input.Email))
 
+	outcome := AuthOutcomeError
+	defer func() {
+		span.SetAttributes(attribute.String("auth.outcome", outcome))
+		if registrationCounter != nil {
+			registrationCounter.Add(ctx, 1, metric.WithAttributes(attribute.String("auth.outcome", outcome)))
+		}
+	}()
+
 	var existingUser models.User
 	if err := database.DB.WithContext(ctx).Where("email = ?", input.Email).First(&existingUser).Error; err == nil {
 		span.SetAttributes(attribute.Bool("user.exists", true))
+		outcome = AuthOutcomeEmailTaken
 		return nil, ErrUserExists
 	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
 		return nil, err
@@ -105,26 +135,20 @@ func (s *AuthService) Register(ctx context.Context, input RegisterInput) (*AuthR
 		return nil, err
 	}
 
-	if registrationCounter != nil {
-		registrationCounter.Add(ctx, 1, metric.WithAttributes(
-			attribute.Bool("success", true),
-		))
-	}
-
 	token, err := s.generateToken(&user)
 	if err != nil {
 		return nil, err
 	}
+	outcome = AuthOutcomeSuccess
 
 	span.SetAttributes(
 		attribute.Int64("user.id", int64(user.ID)),
 		attribute.Bool("registration.success", true),
 	)
 
-	logging.Info(ctx).
-		Uint("user_id", user.ID).
-		Str("email", user.Email).
-		Msg("user registered successfully")
+	logging.Milestone(ctx, milestone.Record(ctx, milestone.UserRegistered,
+		milestone.UserID.Int64(int64(user.ID)),
+	))
 
 	return &AuthResponse{
 		User:  user.ToResponse(),
@@ -138,14 +162,24 @@ func (s *AuthService) Login(ctx context.Context, input LoginInput) (*AuthRespons
 
 	span.SetAttributes(attribute.String("user.email", input.Email))
 
-	if loginCounter != nil {
-		loginCounter.Add(ctx, 1)
-	}
+	start := time.Now()
+	outcome := AuthOutcomeError
+	defer func() {
+		span.SetAttributes(attribute.String("auth.outcome", outcome))
+		attrs := metric.WithAttributes(attribute.String("auth.outcome", outcome))
+		if loginCounter != nil {
+			loginCounter.Add(ctx, 1, attrs)
+		}
+		if loginDuration != nil {
+			loginDuration.Record(ctx, float64(time.Since(start).Milliseconds()), attrs)
+		}
+	}()
 
 	var user models.User
 	if err := database.DB.WithContext(ctx).Where("email = ?", input.Email).First(&user).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			span.SetAttributes(attribute.Bool("login.success", false))
+			outcome = AuthOutcomeUnknownEmail
 			return nil, ErrInvalidCredentials
 		}
 		return nil, err
@@ -153,6 +187,7 @@ func (s *AuthService) Login(ctx context.Context, input LoginInput) (*AuthRespons
 
 	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(input.Password)); err != nil {
 		span.SetAttributes(attribute.Bool("login.success", false))
+		outcome = AuthOutcomeBadPassword
 		return nil, ErrInvalidCredentials
 	}
 
@@ -160,6 +195,7 @@ func (s *AuthService) Login(ctx context.Context, input LoginInput) (*AuthRespons
 	if err != nil {
 		return nil, err
 	}
+	outcome = AuthOutcomeSuccess
 
 	span.SetAttributes(
 		attribute.Int64("user.id", int64(user.ID)),
@@ -0,0 +1,279 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"go-echo-postgres/internal/database"
+	"go-echo-postgres/internal/logging"
+	"go-echo-postgres/internal/models"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrReportNotFound      = errors.New("report not found")
+	ErrAlreadyReported     = errors.New("article already reported by this user")
+	ErrReportClosed        = errors.New("report is already closed")
+	ErrInvalidReportReason = errors.New("reason is required")
+)
+
+const maxReportReasonLength = 1000
+
+var reportTransitionCounter metric.Int64Counter
+
+type ModerationService struct{}
+
+func NewModerationService() *ModerationService {
+	var err error
+	reportTransitionCounter, err = meter.Int64Counter(
+		"moderation.report.transitions",
+		metric.WithDescription("Report state transitions, by from/to status"),
+		metric.WithUnit("{transition}"),
+	)
+	if err != nil {
+		logging.Logger().Error().Err(err).Msg("failed to create report transition counter")
+	}
+
+	return &ModerationService{}
+}
+
+type ListReportsInput struct {
+	Page    int
+	PerPage int
+	Status  models.ReportStatus
+}
+
+type ResolveReportInput struct {
+	Note string `json:"note"`
+}
+
+func (s *ModerationService) Report(ctx context.Context, slug string, reporterID uint, reason string) (*models.Report, error) {
+	ctx, span := tracer.Start(ctx, "moderation.report")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("article.slug", slug),
+		attribute.Int64("user.id", int64(reporterID)),
+	)
+
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, ErrInvalidReportReason
+	}
+	if len(reason) > maxReportReasonLength {
+		reason = reason[:maxReportReasonLength]
+	}
+
+	article, err := findBySlug(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+
+	report := models.Report{
+		ArticleID:  article.ID,
+		ReporterID: reporterID,
+		Reason:     reason,
+		Status:     models.ReportStatusOpen,
+	}
+
+	err = database.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var open int64
+		if err := tx.Model(&models.Report{}).
+			Where("article_id = ? AND reporter_id = ? AND status = ?", article.ID, reporterID, models.ReportStatusOpen).
+			Count(&open).Error; err != nil {
+			return err
+		}
+		if open > 0 {
+			return ErrAlreadyReported
+		}
+
+		if err := tx.Create(&report).Error; err != nil {
+			return err
+		}
+		return recordTransition(tx, reporterID, "report.created", report.ID, "", models.ReportStatusOpen, reason)
+	})
+	if err != nil {
+		return nil, err
+	}
+	countTransition(ctx, "", models.ReportStatusOpen)
+
+	// The report is saved; a failed reporter lookup only leaves it out of
+	// the response.
+	report.Article = *article
+	if err := database.DB.WithContext(ctx).First(&report.Reporter, reporterID).Error; err != nil {
+		logging.Warn(ctx).Err(err).Uint("report_id", report.ID).Msg("failed to load reporter")
+	}
+
+	span.SetAttributes(attribute.Int64("report.id", int64(report.ID)))
+
+	logging.Info(ctx).
+		Uint("report_id", report.ID).
+		Uint("article_id", article.ID).
+		Uint("reporter_id", reporterID).
+		Msg("article reported")
+
+	return &report, nil
+}
+
+func (s *ModerationService) List(ctx context.Context, input ListReportsInput) (*models.ReportsResponse, error) {
+	ctx, span := tracer.Start(ctx, "moderation.list")
+	defer span.End()
+
+	if input.Page < 1 {
+		input.Page = 1
+	}
+	if input.PerPage < 1 || input.PerPage > 100 {
+		input.PerPage = 20
+	}
+
+	query := database.DB.WithContext(ctx).Model(&models.Report{})
+	if input.Status != "" {
+		query = query.Where("status = ?", input.Status)
+		span.SetAttributes(attribute.String("filter.status", string(input.Status)))
+	}
+
+	var totalCount int64
+	if err := query.Count(&totalCount).Error; err != nil {
+		return nil, err
+	}
+
+	var reports []models.Report
+	if err := query.
+		Preload("Article").
+		Preload("Reporter").
+		Order("created_at ASC").
+		Offset((input.Page - 1) * input.PerPage).
+		Limit(input.PerPage).
+		Find(&reports).Error; err != nil {
+		return nil, err
+	}
+
+	span.SetAttributes(attribute.Int64("result.total_count", totalCount))
+
+	responses := make([]models.ReportResponse, len(reports))
+	for i := range reports {
+		responses[i] = reports[i].ToResponse()
+	}
+
+	return &models.ReportsResponse{
+		Reports:    responses,
+		TotalCount: totalCount,
+		Page:       input.Page,
+		PerPage:    input.PerPage,
+	}, nil
+}
+
+func (s *ModerationService) Get(ctx context.Context, reportID uint) (*models.Report, []models.AuditEntry, error) {
+	ctx, span := tracer.Start(ctx, "moderation.get")
+	defer span.End()
+
+	span.SetAttributes(attribute.Int64("report.id", int64(reportID)))
+
+	var report models.Report
+	if err := database.DB.WithContext(ctx).Preload("Article").Preload("Reporter").First(&report, reportID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrReportNotFound
+		}
+		return nil, nil, err
+	}
+
+	var audit []models.AuditEntry
+	if err := database.DB.WithContext(ctx).
+		Where("entity_type = ? AND entity_id = ?", "report", reportID).
+		Order("created_at ASC").
+		Find(&audit).Error; err != nil {
+		return nil, nil, err
+	}
+
+	return &report, audit, nil
+}
+
+// Close moves an open report to resolved or dismissed. The status update and
+// its audit entry are written in one transaction.
+func (s *ModerationService) Close(ctx context.Context, reportID, moderatorID uint, to models.ReportStatus, input ResolveReportInput) (*models.Report, error) {
+	ctx, span := tracer.Start(ctx, "moderation.close")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.Int64("report.id", int64(reportID)),
+		attribute.Int64("user.id", int64(moderatorID)),
+		attribute.String("report.status", string(to)),
+	)
+
+	var report models.Report
+	err := database.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&report, reportID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrReportNotFound
+			}
+			return err
+		}
+		if report.Status != models.ReportStatusOpen {
+			return ErrReportClosed
+		}
+
+		now := time.Now()
+		result := tx.Model(&report).
+			Where("status = ?", models.ReportStatusOpen).
+			Updates(map[string]interface{}{
+				"status":      to,
+				"resolved_by": moderatorID,
+				"resolution":  input.Note,
+				"resolved_at": now,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrReportClosed
+		}
+
+		return recordTransition(tx, moderatorID, "report."+string(to), report.ID, models.ReportStatusOpen, to, input.Note)
+	})
+	if err != nil {
+		return nil, err
+	}
+	countTransition(ctx, models.ReportStatusOpen, to)
+
+	if err := database.DB.WithContext(ctx).Preload("Article").Preload("Reporter").First(&report, reportID).Error; err != nil {
+		return nil, err
+	}
+
+	logging.Info(ctx).
+		Uint("report_id", report.ID).
+		Uint("moderator_id", moderatorID).
+		Str("status", string(to)).
+		Msg("report closed")
+
+	return &report, nil
+}
+
+func recordTransition(tx *gorm.DB, actorID uint, action string, reportID uint, from, to models.ReportStatus, note string) error {
+	return tx.Create(&models.AuditEntry{
+		ActorID:    actorID,
+		Action:     action,
+		EntityType: "report",
+		EntityID:   reportID,
+		FromStatus: string(from),
+		ToStatus:   string(to),
+		Note:       note,
+	}).Error
+}
+
+func countTransition(ctx context.Context, from, to models.ReportStatus) {
+	if reportTransitionCounter == nil {
+		return
+	}
+	if from == "" {
+		from = "none"
+	}
+	reportTransitionCounter.Add(ctx, 1, metric.WithAttributes(
+		attribute.String("report.from_status", string(from)),
+		attribute.String("report.to_status", string(to)),
+	))
+}
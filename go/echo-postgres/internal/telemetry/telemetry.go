@@ -6,20 +6,68 @@ import (
 	"os"
 	"time"
 
+	"github.com/base-14/examples/go/pkg/buildinfo"
+	"github.com/base-14/examples/go/pkg/metricattr"
+	"github.com/prometheus/client_golang/prometheus"
+
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	otelprometheus "go.opentelemetry.io/otel/exporters/prometheus"
 	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/sdk/metric"
 	"go.opentelemetry.io/otel/sdk/resource"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+
+	"go-echo-postgres/internal/logging"
 )
 
 type ShutdownFunc func(context.Context) error
 
-func Init(ctx context.Context, serviceName, endpoint string) (ShutdownFunc, error) {
+type options struct {
+	promRegistry *prometheus.Registry
+	metricIDSalt string
+}
+
+type Option func(*options)
+
+// WithPrometheus adds a Prometheus exporter as a second metric reader,
+// registering the same instruments in registry for scraping. OTLP export is
+// unchanged, so both pipelines can be compared side by side.
+func WithPrometheus(registry *prometheus.Registry) Option {
+	return func(o *options) {
+		o.promRegistry = registry
+	}
+}
+
+// WithMetricIDSalt keys the hash behind UserBucket. Use the same salt on
+// every replica so their buckets line up.
+func WithMetricIDSalt(salt string) Option {
+	return func(o *options) {
+		o.metricIDSalt = salt
+	}
+}
+
+// metricIDs turns user IDs into metric attributes; Init rekeys it.
+var metricIDs = metricattr.New("", 0)
+
+// UserBucket is the attribute for a user on a metric: one of
+// metricattr.DefaultBuckets buckets, never the raw ID, which belongs on
+// spans and logs only.
+func UserBucket(userID uint) attribute.KeyValue {
+	return metricIDs.Bucket("user.bucket", fmt.Sprint(userID))
+}
+
+func Init(ctx context.Context, serviceName, endpoint string, opts ...Option) (ShutdownFunc, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	metricIDs = metricattr.New(o.metricIDSalt, 0)
+
 	res, err := newResource(ctx, serviceName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create resource: %w", err)
@@ -30,7 +78,7 @@ func Init(ctx context.Context, serviceName, endpoint string) (ShutdownFunc, erro
 		return nil, fmt.Errorf("failed to create tracer provider: %w", err)
 	}
 
-	meterProvider, err := newMeterProvider(ctx, res, endpoint)
+	meterProvider, err := newMeterProvider(ctx, res, endpoint, o.promRegistry)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create meter provider: %w", err)
 	}
@@ -68,7 +116,8 @@ func newResource(ctx context.Context, serviceName string) (*resource.Resource, e
 	return resource.New(ctx,
 		resource.WithAttributes(
 			semconv.ServiceName(serviceName),
-			semconv.ServiceVersion("1.0.0"),
+			semconv.ServiceVersion(buildinfo.Version),
+			semconv.ServiceInstanceID(buildinfo.InstanceID),
 			attribute.String("deployment.environment", environment),
 			attribute.String("environment", environment),
 			attribute.String("service.namespace", "examples"),
@@ -94,7 +143,7 @@ func newTracerProvider(ctx context.Context, res *resource.Resource, endpoint str
 	return tp, nil
 }
 
-func newMeterProvider(ctx context.Context, res *resource.Resource, endpoint string) (*metric.MeterProvider, error) {
+func newMeterProvider(ctx context.Context, res *resource.Resource, endpoint string, promRegistry *prometheus.Registry) (*metric.MeterProvider, error) {
 	exporter, err := otlpmetrichttp.New(ctx,
 		otlpmetrichttp.WithEndpoint(trimProtocol(endpoint)),
 		otlpmetrichttp.WithInsecure(),
@@ -103,12 +152,30 @@ func newMeterProvider(ctx context.Context, res *resource.Resource, endpoint stri
 		return nil, err
 	}
 
-	mp := metric.NewMeterProvider(
+	mpOpts := []metric.Option{
 		metric.WithReader(metric.NewPeriodicReader(exporter, metric.WithInterval(60*time.Second))),
 		metric.WithResource(res),
-	)
+		// Warn, once per instrument and key, about user IDs and other
+		// high-cardinality attributes recorded on a metric.
+		metric.WithView(metricattr.Guard(metricattr.GuardConfig{OnWarning: func(w metricattr.Warning) {
+			logging.Logger().Warn().
+				Str("instrument", w.Instrument).
+				Str("key", w.Key).
+				Str("reason", w.Reason).
+				Int("values", w.Values).
+				Msg("high-cardinality metric attribute")
+		}})),
+	}
+
+	if promRegistry != nil {
+		promExporter, err := otelprometheus.New(otelprometheus.WithRegisterer(promRegistry))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create prometheus exporter: %w", err)
+		}
+		mpOpts = append(mpOpts, metric.WithReader(promExporter))
+	}
 
-	return mp, nil
+	return metric.NewMeterProvider(mpOpts...), nil
 }
 
 func trimProtocol(endpoint string) string {
@@ -144,6 +144,20 @@ UPDATED_SLUG=$(cat /tmp/response.json | grep -o '"slug":"[^"]*"' | cut -d'"' -f4
 test_endpoint PUT "/api/articles/$UPDATED_SLUG" 401 "Reject update without auth" \
     "{\"title\":\"Should fail\"}"
 
+test_endpoint GET "/api/articles/$ARTICLE_SLUG" 301 "Redirect retired slug to the renamed article"
+
+test_endpoint GET "/api/articles/$ARTICLE_SLUG/links" 301 "Redirect links of retired slug"
+
+echo ""
+echo "--- Article Links ---"
+test_endpoint PUT "/api/articles/$UPDATED_SLUG" 200 "Edit body with links" \
+    "{\"body\":\"See [OpenTelemetry](https://opentelemetry.io) and ![logo](https://example.invalid/logo.png).\"}" "$TOKEN"
+
+sleep 3
+test_endpoint GET "/api/articles/$UPDATED_SLUG/links" 200 "Get extracted links"
+
+test_endpoint GET "/api/articles/non-existent-slug/links" 404 "Return 404 for links of non-existent article"
+
 echo ""
 echo "--- Article Favorites ---"
 test_endpoint POST "/api/articles/$UPDATED_SLUG/favorite" 200 "Favorite article" "" "$TOKEN"
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/base-14/examples/go/pkg/depwait"
+	"github.com/base-14/examples/go/pkg/healthcheck"
+	"github.com/base-14/examples/go/pkg/shutdown"
+	"github.com/gofiber/contrib/otelfiber/v2"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/gofiber/fiber/v2/middleware/recover"
+	"github.com/gofiber/fiber/v2/middleware/requestid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"go-fiber-postgres/config"
+	"go-fiber-postgres/internal/database"
+	"go-fiber-postgres/internal/handlers"
+	"go-fiber-postgres/internal/jobs"
+	"go-fiber-postgres/internal/logging"
+	"go-fiber-postgres/internal/middleware"
+	"go-fiber-postgres/internal/repository"
+	"go-fiber-postgres/internal/services"
+	"go-fiber-postgres/internal/telemetry"
+)
+
+func main() {
+	ctx := context.Background()
+
+	cfg := config.Load()
+
+	healthcheck.Main(depwait.Dependency{Name: "api", Check: healthcheck.HTTP(healthcheck.Local(cfg.Port, "/api/health"))})
+
+	telemetryOpts := []telemetry.Option{telemetry.WithMetricIDSalt(cfg.OTelConfig.MetricIDSalt)}
+	var promRegistry *prometheus.Registry
+	if cfg.OTelConfig.PrometheusEnabled {
+		promRegistry = prometheus.NewRegistry()
+		telemetryOpts = append(telemetryOpts, telemetry.WithPrometheus(promRegistry))
+	}
+
+	tel, err := telemetry.Init(ctx, cfg.OTelConfig.ServiceName, cfg.OTelConfig.OTLPEndpoint, telemetryOpts...)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to initialize telemetry: %v\n", err)
+		os.Exit(1)
+	}
+	sd := shutdown.New(shutdown.Config{OnClose: logShutdown})
+	sd.Add(shutdown.PhaseTelemetry, "telemetry", tel.Shutdown)
+
+	logging.Init(cfg.OTelConfig.ServiceName, cfg.Environment)
+
+	if err := depwait.Wait(ctx, depwait.Config{Timeout: cfg.StartupTimeout, OnAttempt: logDependencyAttempt},
+		depwait.Dependency{Name: "postgres", Check: depwait.SQL("pgx", cfg.DatabaseURL)},
+	); err != nil {
+		logging.Error(ctx, "dependencies not ready", "error", err)
+		os.Exit(1)
+	}
+
+	db, err := database.Connect(ctx, cfg.DatabaseURL)
+	if err != nil {
+		logging.Error(ctx, "failed to connect to database", "error", err)
+		os.Exit(1)
+	}
+	sd.Add(shutdown.PhaseClients, "postgres", shutdown.ErrFunc(db.Close))
+
+	pool, err := pgxpool.New(ctx, cfg.DatabaseURL)
+	if err != nil {
+		logging.Error(ctx, "failed to create pgxpool", "error", err)
+		os.Exit(1)
+	}
+	sd.Add(shutdown.PhaseClients, "pgxpool", shutdown.Func(pool.Close))
+
+	// The API and worker replicas share one schema; the lock keeps them
+	// from migrating it at the same time.
+	if err := database.WithMigrationLock(ctx, db, func(ctx context.Context) error {
+		if err := database.RunMigrations(ctx, db); err != nil {
+			return err
+		}
+		if err := database.RunRiverMigrations(ctx, pool); err != nil {
+			return fmt.Errorf("river migrations: %w", err)
+		}
+		return nil
+	}); err != nil {
+		logging.Error(ctx, "failed to run migrations", "error", err)
+		os.Exit(1)
+	}
+
+	jobClient, err := jobs.NewClient(ctx, pool)
+	if err != nil {
+		logging.Error(ctx, "failed to create job client", "error", err)
+		os.Exit(1)
+	}
+
+	userRepo := repository.NewUserRepository(db)
+	articleRepo := repository.NewArticleRepository(db)
+	favoriteRepo := repository.NewFavoriteRepository(db)
+	followRepo := repository.NewFollowRepository(db)
+
+	authService := services.NewAuthService(userRepo, cfg.JWTSecret, cfg.JWTExpiry, cfg.JWTCacheSize)
+	articleService := services.NewArticleService(articleRepo, favoriteRepo)
+	followService := services.NewFollowService(followRepo)
+
+	healthHandler := handlers.NewHealthHandler(db)
+	authHandler := handlers.NewAuthHandler(authService)
+	articleHandler := handlers.NewArticleHandler(articleService, jobClient)
+	followHandler := handlers.NewFollowHandler(followService)
+
+	authMiddleware := middleware.NewAuthMiddleware(authService)
+
+	app := fiber.New(fiber.Config{
+		DisableStartupMessage: true,
+		ErrorHandler:          middleware.ErrorHandler,
+	})
+
+	app.Use(recover.New())
+	app.Use(requestid.New())
+	app.Use(otelfiber.Middleware(otelfiber.WithNext(func(c *fiber.Ctx) bool {
+		return c.Path() == "/api/health" || c.Path() == "/version" || c.Path() == "/metrics"
+	})))
+	app.Use(middleware.Metrics())
+	app.Use(middleware.QueryCount(cfg.DBQueryWarnThreshold))
+
+	articleCache := middleware.CachePolicy{
+		Visibility:               middleware.CachePublic,
+		MaxAge:                   cfg.Cache.MaxAge,
+		SurrogateMaxAge:          cfg.Cache.SurrogateMaxAge,
+		StaleWhileRevalidate:     cfg.Cache.MaxAge,
+		PrivateWhenAuthenticated: true,
+	}
+	app.Use(middleware.CacheControl(middleware.CachePolicies{
+		"GET /api/health":          {Visibility: middleware.CacheNoStore},
+		"GET /version":             {Visibility: middleware.CacheNoStore},
+		"GET /metrics":             {Visibility: middleware.CacheNoStore},
+		"GET /api/articles":        articleCache,
+		"GET /api/articles/search": articleCache,
+		"GET /api/articles/feed":   {Visibility: middleware.CachePrivate},
+		"GET /api/articles/:slug":  articleCache,
+		"GET /api/user":            {Visibility: middleware.CachePrivate},
+	}))
+	app.Use(middleware.Security(cfg.Security))
+
+	if cfg.RateLimit.Enabled {
+		app.Use(middleware.NewRateLimiter(cfg.RateLimit, authService).Handler())
+	}
+	app.Use(middleware.Timeout(cfg.Timeouts.Read, cfg.Timeouts.Write))
+
+	app.Get("/version", handlers.Version)
+	if promRegistry != nil {
+		app.Get("/metrics", adaptor.HTTPHandler(promhttp.HandlerFor(promRegistry, promhttp.HandlerOpts{})))
+	}
+
+	api := app.Group("/api")
+
+	api.Get("/health", healthHandler.Check)
+
+	api.Post("/register", authHandler.Register)
+	api.Post("/login", authHandler.Login)
+
+	api.Get("/user", authMiddleware.Required(), authHandler.GetUser)
+	api.Post("/logout", authMiddleware.Required(), authHandler.Logout)
+
+	api.Get("/articles", authMiddleware.Optional(), articleHandler.List)
+	api.Get("/articles/search", authMiddleware.Optional(), articleHandler.Search)
+	api.Get("/articles/feed", authMiddleware.Required(), articleHandler.Feed)
+	api.Get("/articles/:slug", authMiddleware.Optional(), articleHandler.Get)
+	api.Post("/articles", authMiddleware.Required(), articleHandler.Create)
+	api.Put("/articles/:slug", authMiddleware.Required(), articleHandler.Update)
+	api.Delete("/articles/:slug", authMiddleware.Required(), articleHandler.Delete)
+	api.Post("/articles/:slug/favorite", authMiddleware.Required(), articleHandler.Favorite)
+	api.Delete("/articles/:slug/favorite", authMiddleware.Required(), articleHandler.Unfavorite)
+
+	api.Post("/users/:id/follow", authMiddleware.Required(), followHandler.Follow)
+	api.Delete("/users/:id/follow", authMiddleware.Required(), followHandler.Unfollow)
+
+	sd.Add(shutdown.PhaseServer, "http", app.ShutdownWithContext)
+
+	go func() {
+		addr := fmt.Sprintf(":%s", cfg.Port)
+		logging.Info(ctx, "starting server", "port", cfg.Port)
+		if err := app.Listen(addr); err != nil {
+			logging.Error(ctx, "server error", "error", err)
+		}
+	}()
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+
+	logging.Info(ctx, "shutting down server")
+	if err := sd.Shutdown(context.Background()); err != nil {
+		logging.Error(ctx, "shutdown finished with errors", "error", err)
+	}
+}
+
+// logShutdown reports each step of shutdown.
+func logShutdown(ev shutdown.Event) {
+	ctx := context.Background()
+	if ev.Err != nil {
+		logging.Error(ctx, "shutdown step failed",
+			"closer", ev.Name,
+			"phase", ev.Phase.String(),
+			"duration", ev.Duration.String(),
+			"error", ev.Err,
+		)
+		return
+	}
+	logging.Info(ctx, "shutdown step done",
+		"closer", ev.Name,
+		"phase", ev.Phase.String(),
+		"duration", ev.Duration.String(),
+	)
+}
+
+// logDependencyAttempt reports startup dependency checks while the service
+// waits for Postgres.
+func logDependencyAttempt(ev depwait.Event) {
+	ctx := context.Background()
+	if ev.Err == nil {
+		logging.Info(ctx, "dependency ready",
+			"dependency", ev.Dependency,
+			"attempt", ev.Attempt,
+			"waited", ev.Elapsed.String(),
+		)
+		return
+	}
+	logging.Warn(ctx, "waiting for dependency",
+		"dependency", ev.Dependency,
+		"attempt", ev.Attempt,
+		"retryIn", ev.RetryIn.String(),
+		"error", ev.Err,
+	)
+}
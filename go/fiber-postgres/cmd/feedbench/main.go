@@ -0,0 +1,271 @@
+// Command feedbench compares the keyset feed query GET /api/articles/feed
+// serves with the naive approach it replaced: load the followed author IDs,
+// then page through articles WHERE author_id IN (...) with OFFSET.
+//
+// It seeds a reader following -followed of -authors authors with
+// -articles articles each, times -pages pages of both strategies -runs
+// times, prints the results and records every page in
+// article.feed.duration, by feed.strategy and feed.page, so the same delta
+// shows up in Scout. The seeded users, and with them their articles and
+// follows, are deleted before it exits.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"go.opentelemetry.io/otel/attribute"
+
+	"go-fiber-postgres/config"
+	"go-fiber-postgres/internal/database"
+	"go-fiber-postgres/internal/models"
+	"go-fiber-postgres/internal/repository"
+	"go-fiber-postgres/internal/services"
+	"go-fiber-postgres/internal/telemetry"
+)
+
+func main() {
+	authors := flag.Int("authors", 500, "authors to seed")
+	followed := flag.Int("followed", 100, "authors the reader follows")
+	articles := flag.Int("articles", 100, "articles per author")
+	pages := flag.Int("pages", 20, "feed pages to read per run")
+	limit := flag.Int("limit", 20, "articles per page")
+	runs := flag.Int("runs", 5, "times to read the pages with each strategy")
+	flag.Parse()
+
+	if *followed > *authors {
+		fmt.Fprintln(os.Stderr, "-followed cannot exceed -authors")
+		os.Exit(2)
+	}
+
+	ctx := context.Background()
+	cfg := config.Load()
+
+	tel, err := telemetry.Init(ctx, cfg.OTelConfig.ServiceName+"-feedbench", cfg.OTelConfig.OTLPEndpoint,
+		telemetry.WithMetricIDSalt(cfg.OTelConfig.MetricIDSalt))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to initialize telemetry: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := run(ctx, cfg, *authors, *followed, *articles, *pages, *limit, *runs); err != nil {
+		fmt.Fprintf(os.Stderr, "feedbench: %v\n", err)
+		os.Exit(1)
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := tel.Shutdown(shutdownCtx); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to flush telemetry: %v\n", err)
+	}
+}
+
+func run(ctx context.Context, cfg *config.Config, authors, followed, articles, pages, limit, runs int) error {
+	db, err := database.Connect(ctx, cfg.DatabaseURL)
+	if err != nil {
+		return fmt.Errorf("connect: %w", err)
+	}
+	defer db.Close()
+
+	if err := database.WithMigrationLock(ctx, db, func(ctx context.Context) error {
+		return database.RunMigrations(ctx, db)
+	}); err != nil {
+		return fmt.Errorf("migrate: %w", err)
+	}
+
+	prefix := "feedbench-" + strconv.FormatInt(time.Now().UnixNano(), 36) + "-"
+	defer func() {
+		if _, err := db.ExecContext(context.WithoutCancel(ctx),
+			`DELETE FROM users WHERE email LIKE $1`, prefix+"%"); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to delete seeded users with prefix %s: %v\n", prefix, err)
+		}
+	}()
+
+	fmt.Printf("Seeding %d authors x %d articles, reader follows %d...\n", authors, articles, followed)
+	readerID, err := seed(ctx, db, prefix, authors, followed, articles)
+	if err != nil {
+		return fmt.Errorf("seed: %w", err)
+	}
+
+	articleRepo := repository.NewArticleRepository(db)
+	followRepo := repository.NewFollowRepository(db)
+
+	strategies := []struct {
+		name string
+		page func(ctx context.Context, page int, cursor *models.FeedCursor) ([]*models.Article, error)
+	}{
+		{services.FeedStrategyKeyset, func(ctx context.Context, _ int, cursor *models.FeedCursor) ([]*models.Article, error) {
+			return articleRepo.Feed(ctx, readerID, cursor, limit)
+		}},
+		{services.FeedStrategyInQuery, func(ctx context.Context, page int, _ *models.FeedCursor) ([]*models.Article, error) {
+			return inQueryFeed(ctx, db, followRepo, readerID, limit, page*limit)
+		}},
+	}
+
+	// timings[strategy][page] holds one duration per run.
+	timings := make([][][]time.Duration, len(strategies))
+	for s := range strategies {
+		timings[s] = make([][]time.Duration, pages)
+	}
+
+	for r := 0; r < runs; r++ {
+		for s, strategy := range strategies {
+			var cursor *models.FeedCursor
+			for page := 0; page < pages; page++ {
+				start := time.Now()
+				rows, err := strategy.page(ctx, page, cursor)
+				elapsed := time.Since(start)
+				if err != nil {
+					return fmt.Errorf("%s page %d: %w", strategy.name, page+1, err)
+				}
+
+				pageAttr := "next"
+				if page == 0 {
+					pageAttr = "first"
+				}
+				telemetry.ArticleFeedDuration.Record(ctx, float64(elapsed.Microseconds())/1000,
+					telemetry.WithAttributes(
+						attribute.String("feed.strategy", strategy.name),
+						attribute.String("feed.page", pageAttr),
+					))
+				timings[s][page] = append(timings[s][page], elapsed)
+
+				if len(rows) < limit {
+					break
+				}
+				last := rows[len(rows)-1]
+				cursor = &models.FeedCursor{CreatedAt: last.CreatedAt, ID: last.ID}
+			}
+		}
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "\nstrategy\tpage 1 (median)\tpage %d (median)\tall pages (median)\n", pages)
+	medians := make([]time.Duration, len(strategies))
+	for s, strategy := range strategies {
+		var all []time.Duration
+		for _, page := range timings[s] {
+			all = append(all, page...)
+		}
+		medians[s] = median(timings[s][pages-1])
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", strategy.name,
+			median(timings[s][0]), medians[s], median(all))
+	}
+	w.Flush()
+
+	if medians[0] > 0 && medians[1] > 0 {
+		fmt.Printf("\nAt page %d the keyset feed is %.1fx faster than the IN-query feed.\n",
+			pages, float64(medians[1])/float64(medians[0]))
+	}
+	return nil
+}
+
+// seed creates the authors with their articles, spread over the past year,
+// and a reader following the first followed authors. It returns the
+// reader's ID.
+func seed(ctx context.Context, db *sqlx.DB, prefix string, authors, followed, articles int) (int, error) {
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var readerID int
+	if err := tx.GetContext(ctx, &readerID, `
+		INSERT INTO users (email, password_hash, name)
+		VALUES ($1, 'x', 'Feed Reader')
+		RETURNING id`, prefix+"reader@example.com"); err != nil {
+		return 0, err
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO users (email, password_hash, name)
+		SELECT $1 || n || '@example.com', 'x', 'Author ' || n
+		FROM generate_series(1, $2) n`, prefix, authors); err != nil {
+		return 0, err
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO articles (slug, title, body, author_id, created_at)
+		SELECT $1 || u.id || '-' || n, 'Article ' || n, 'Seeded by feedbench.', u.id,
+			NOW() - random() * INTERVAL '365 days'
+		FROM users u, generate_series(1, $2) n
+		WHERE u.email LIKE $1 || '%' AND u.id <> $3`, prefix, articles, readerID); err != nil {
+		return 0, err
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		WITH followed AS (
+			INSERT INTO follows (follower_id, followee_id)
+			SELECT $1, id FROM users
+			WHERE email LIKE $2 || '%' AND id <> $1
+			ORDER BY id
+			LIMIT $3
+			RETURNING followee_id
+		)
+		UPDATE users SET followers_count = followers_count + 1
+		WHERE id IN (SELECT followee_id FROM followed)`, readerID, prefix, followed); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	// Fresh statistics, so both strategies get the plans they would in a
+	// database that has grown to this size.
+	if _, err := db.ExecContext(ctx, `ANALYZE users, articles, follows`); err != nil {
+		return 0, err
+	}
+	return readerID, nil
+}
+
+// inQueryFeed is the approach the keyset feed replaced: fetch the followed
+// IDs, then sort every one of their articles to skip offset of them.
+func inQueryFeed(ctx context.Context, db *sqlx.DB, followRepo *repository.FollowRepository, userID, limit, offset int) ([]*models.Article, error) {
+	ids, err := followRepo.FolloweeIDs(ctx, userID)
+	if err != nil || len(ids) == 0 {
+		return nil, err
+	}
+
+	query, args, err := sqlx.In(`
+		SELECT
+			a.id, a.slug, a.title, a.description, a.body, a.author_id,
+			a.favorites_count, a.created_at, a.updated_at,
+			u.name as author_name, u.email as author_email, u.bio as author_bio, u.image as author_image
+		FROM articles a
+		JOIN users u ON a.author_id = u.id
+		WHERE a.author_id IN (?)
+		ORDER BY a.created_at DESC, a.id DESC
+		LIMIT ? OFFSET ?`, ids, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []models.ArticleWithAuthor
+	if err := db.SelectContext(ctx, &rows, db.Rebind(query), args...); err != nil {
+		return nil, err
+	}
+
+	articles := make([]*models.Article, len(rows))
+	for i, row := range rows {
+		articles[i] = row.ToArticle()
+	}
+	return articles, nil
+}
+
+func median(ds []time.Duration) time.Duration {
+	if len(ds) == 0 {
+		return 0
+	}
+	sorted := slices.Clone(ds)
+	slices.Sort(sorted)
+	return sorted[len(sorted)/2]
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/base-14/examples/go/pkg/depwait"
+	"github.com/base-14/examples/go/pkg/healthcheck"
+	"github.com/base-14/examples/go/pkg/shutdown"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"go-fiber-postgres/config"
+	"go-fiber-postgres/internal/database"
+	"go-fiber-postgres/internal/jobs"
+	"go-fiber-postgres/internal/logging"
+	"go-fiber-postgres/internal/telemetry"
+)
+
+func main() {
+	ctx := context.Background()
+
+	cfg := config.Load()
+
+	// The worker serves no HTTP, so its probe pings the database its jobs
+	// are queued in.
+	healthcheck.Main(depwait.Dependency{Name: "postgres", Check: depwait.SQL("pgx", cfg.DatabaseURL)})
+
+	serviceName := cfg.OTelConfig.ServiceName + "-worker"
+	tel, err := telemetry.Init(ctx, serviceName, cfg.OTelConfig.OTLPEndpoint,
+		telemetry.WithMetricIDSalt(cfg.OTelConfig.MetricIDSalt))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to initialize telemetry: %v\n", err)
+		os.Exit(1)
+	}
+	sd := shutdown.New(shutdown.Config{OnClose: logShutdown})
+	sd.Add(shutdown.PhaseTelemetry, "telemetry", tel.Shutdown)
+
+	logging.Init(serviceName, cfg.Environment)
+
+	if err := depwait.Wait(ctx, depwait.Config{Timeout: cfg.StartupTimeout, OnAttempt: logDependencyAttempt},
+		depwait.Dependency{Name: "postgres", Check: depwait.SQL("pgx", cfg.DatabaseURL)},
+	); err != nil {
+		logging.Error(ctx, "dependencies not ready", "error", err)
+		os.Exit(1)
+	}
+
+	db, err := database.Connect(ctx, cfg.DatabaseURL)
+	if err != nil {
+		logging.Error(ctx, "failed to connect to database", "error", err)
+		os.Exit(1)
+	}
+	sd.Add(shutdown.PhaseClients, "postgres", shutdown.ErrFunc(db.Close))
+
+	pool, err := pgxpool.New(ctx, cfg.DatabaseURL)
+	if err != nil {
+		logging.Error(ctx, "failed to create pgxpool", "error", err)
+		os.Exit(1)
+	}
+	sd.Add(shutdown.PhaseClients, "pgxpool", shutdown.Func(pool.Close))
+
+	// The API and worker replicas share one schema; the lock keeps them
+	// from migrating it at the same time.
+	if err := database.WithMigrationLock(ctx, db, func(ctx context.Context) error {
+		if err := database.RunMigrations(ctx, db); err != nil {
+			return err
+		}
+		if err := database.RunRiverMigrations(ctx, pool); err != nil {
+			return fmt.Errorf("river migrations: %w", err)
+		}
+		return nil
+	}); err != nil {
+		logging.Error(ctx, "failed to run migrations", "error", err)
+		os.Exit(1)
+	}
+
+	worker, err := jobs.NewWorker(ctx, pool, cfg.Jobs)
+	if err != nil {
+		logging.Error(ctx, "failed to create worker", "error", err)
+		os.Exit(1)
+	}
+
+	sd.Add(shutdown.PhaseWorkers, "river", worker.Stop)
+
+	go func() {
+		if err := worker.Start(ctx); err != nil {
+			logging.Error(ctx, "worker error", "error", err)
+		}
+	}()
+
+	logging.Info(ctx, "worker started")
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+
+	logging.Info(ctx, "shutting down worker")
+	if err := sd.Shutdown(context.Background()); err != nil {
+		logging.Error(ctx, "shutdown finished with errors", "error", err)
+	}
+}
+
+// logShutdown reports each step of shutdown.
+func logShutdown(ev shutdown.Event) {
+	ctx := context.Background()
+	if ev.Err != nil {
+		logging.Error(ctx, "shutdown step failed",
+			"closer", ev.Name,
+			"phase", ev.Phase.String(),
+			"duration", ev.Duration.String(),
+			"error", ev.Err,
+		)
+		return
+	}
+	logging.Info(ctx, "shutdown step done",
+		"closer", ev.Name,
+		"phase", ev.Phase.String(),
+		"duration", ev.Duration.String(),
+	)
+}
+
+// logDependencyAttempt reports startup dependency checks while the service
+// waits for Postgres.
+func logDependencyAttempt(ev depwait.Event) {
+	ctx := context.Background()
+	if ev.Err == nil {
+		logging.Info(ctx, "dependency ready",
+			"dependency", ev.Dependency,
+			"attempt", ev.Attempt,
+			"waited", ev.Elapsed.String(),
+		)
+		return
+	}
+	logging.Warn(ctx, "waiting for dependency",
+		"dependency", ev.Dependency,
+		"attempt", ev.Attempt,
+		"retryIn", ev.RetryIn.String(),
+		"error", ev.Err,
+	)
+}
@@ -2,6 +2,8 @@ package config
 
 import (
 	"os"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -12,11 +14,86 @@ type Config struct {
 	JWTSecret   string
 	JWTExpiry   time.Duration
 	OTelConfig  OTelConfig
+	RateLimit   RateLimitConfig
+	Cache       CacheConfig
+	Jobs        JobsConfig
+	Timeouts    TimeoutConfig
+	Security    SecurityConfig
+	// JWTCacheSize caps how many validated tokens are cached in memory; 0
+	// disables the cache.
+	JWTCacheSize int
+	// StartupTimeout bounds how long the api and worker wait for Postgres
+	// before giving up.
+	StartupTimeout time.Duration
+	// DBQueryWarnThreshold is the number of queries a single request may run
+	// before it is logged as a likely N+1; 0 disables the warning.
+	DBQueryWarnThreshold int
 }
 
 type OTelConfig struct {
 	ServiceName  string
 	OTLPEndpoint string
+	// PrometheusEnabled also serves the API's metrics at /metrics for
+	// scraping, alongside OTLP export.
+	PrometheusEnabled bool
+	// MetricIDSalt keys the hash that buckets user IDs on metrics.
+	MetricIDSalt string
+}
+
+type CacheConfig struct {
+	MaxAge          time.Duration
+	SurrogateMaxAge time.Duration
+}
+
+// TimeoutConfig sets the request context deadline per route class: reads are
+// GET and HEAD, writes everything else.
+type TimeoutConfig struct {
+	Read  time.Duration
+	Write time.Duration
+}
+
+// SecurityConfig sets the CORS policy and the security headers sent on every
+// response. With no AllowedOrigins CORS is off: no CORS headers are sent and
+// cross-origin requests are left to the browser's same-origin policy.
+type SecurityConfig struct {
+	// AllowedOrigins lists exact origins such as "https://app.example.com";
+	// "*" allows any origin, without credentials.
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	AllowCredentials bool
+	// CORSMaxAge is how long browsers may cache a preflight response.
+	CORSMaxAge time.Duration
+	// HSTSMaxAge enables Strict-Transport-Security when positive. Leave it
+	// off unless the API is only reachable over HTTPS.
+	HSTSMaxAge            time.Duration
+	ContentSecurityPolicy string
+}
+
+// JobsConfig sizes the River worker pools. Each queue gets its own worker
+// count so a burst of low-priority digests cannot starve notifications.
+type JobsConfig struct {
+	NotificationWorkers int
+	DigestWorkers       int
+	DigestInterval      time.Duration
+}
+
+// RateLimitConfig sizes the priority-aware limiter. Each client class gets its
+// own per-client bucket and queue; GlobalRPS is the shared capacity that queued
+// requests compete for by weight.
+type RateLimitConfig struct {
+	Enabled   bool
+	GlobalRPS float64
+	Auth      RateLimitClass
+	Anonymous RateLimitClass
+	MaxWait   time.Duration
+}
+
+type RateLimitClass struct {
+	RPS    float64
+	Burst  int
+	Queue  int
+	Weight int
 }
 
 func Load() *Config {
@@ -27,8 +104,52 @@ func Load() *Config {
 		JWTSecret:   getEnv("JWT_SECRET", "your-super-secret-jwt-key-change-in-production"),
 		JWTExpiry:   parseDuration(getEnv("JWT_EXPIRES_IN", "168h")),
 		OTelConfig: OTelConfig{
-			ServiceName:  getEnv("OTEL_SERVICE_NAME", "go-fiber-postgres-api"),
-			OTLPEndpoint: getEnv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://localhost:4318"),
+			ServiceName:       getEnv("OTEL_SERVICE_NAME", "go-fiber-postgres-api"),
+			OTLPEndpoint:      getEnv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://localhost:4318"),
+			PrometheusEnabled: getEnv("PROMETHEUS_ENABLED", "false") == "true",
+			MetricIDSalt:      getEnv("METRIC_ID_SALT", ""),
+		},
+		Cache: CacheConfig{
+			MaxAge:          parseDurationOr(getEnv("CACHE_MAX_AGE", "30s"), 30*time.Second),
+			SurrogateMaxAge: parseDurationOr(getEnv("CACHE_SURROGATE_MAX_AGE", "5m"), 5*time.Minute),
+		},
+		Timeouts: TimeoutConfig{
+			Read:  parseDurationOr(getEnv("REQUEST_TIMEOUT_READ", "2s"), 2*time.Second),
+			Write: parseDurationOr(getEnv("REQUEST_TIMEOUT_WRITE", "5s"), 5*time.Second),
+		},
+		Security: SecurityConfig{
+			AllowedOrigins:        getEnvList("CORS_ALLOWED_ORIGINS", ""),
+			AllowedMethods:        getEnvList("CORS_ALLOWED_METHODS", "GET,POST,PUT,DELETE"),
+			AllowedHeaders:        getEnvList("CORS_ALLOWED_HEADERS", "Authorization,Content-Type"),
+			AllowCredentials:      getEnv("CORS_ALLOW_CREDENTIALS", "false") == "true",
+			CORSMaxAge:            parseDurationOr(getEnv("CORS_MAX_AGE", "10m"), 10*time.Minute),
+			HSTSMaxAge:            parseDurationOr(getEnv("HSTS_MAX_AGE", "0s"), 0),
+			ContentSecurityPolicy: getEnv("CONTENT_SECURITY_POLICY", "default-src 'none'; frame-ancestors 'none'"),
+		},
+		JWTCacheSize:         getEnvInt("JWT_CACHE_SIZE", 10000),
+		StartupTimeout:       parseDurationOr(getEnv("STARTUP_TIMEOUT", "60s"), time.Minute),
+		DBQueryWarnThreshold: getEnvInt("DB_QUERY_WARN_THRESHOLD", 10),
+		Jobs: JobsConfig{
+			NotificationWorkers: getEnvInt("JOBS_NOTIFICATION_WORKERS", 10),
+			DigestWorkers:       getEnvInt("JOBS_DIGEST_WORKERS", 2),
+			DigestInterval:      parseDurationOr(getEnv("JOBS_DIGEST_INTERVAL", "1h"), time.Hour),
+		},
+		RateLimit: RateLimitConfig{
+			Enabled:   getEnv("RATE_LIMIT_ENABLED", "true") == "true",
+			GlobalRPS: getEnvFloat("RATE_LIMIT_GLOBAL_RPS", 200),
+			Auth: RateLimitClass{
+				RPS:    getEnvFloat("RATE_LIMIT_AUTH_RPS", 20),
+				Burst:  getEnvInt("RATE_LIMIT_AUTH_BURST", 40),
+				Queue:  getEnvInt("RATE_LIMIT_AUTH_QUEUE", 20),
+				Weight: 3,
+			},
+			Anonymous: RateLimitClass{
+				RPS:    getEnvFloat("RATE_LIMIT_ANON_RPS", 5),
+				Burst:  getEnvInt("RATE_LIMIT_ANON_BURST", 10),
+				Queue:  getEnvInt("RATE_LIMIT_ANON_QUEUE", 5),
+				Weight: 1,
+			},
+			MaxWait: parseDurationOr(getEnv("RATE_LIMIT_MAX_WAIT", "2s"), 2*time.Second),
 		},
 	}
 }
@@ -40,10 +161,39 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
+// getEnvList splits a comma-separated variable, dropping empty entries.
+func getEnvList(key, defaultValue string) []string {
+	var out []string
+	for _, v := range strings.Split(getEnv(key, defaultValue), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	if v, err := strconv.Atoi(os.Getenv(key)); err == nil {
+		return v
+	}
+	return defaultValue
+}
+
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if v, err := strconv.ParseFloat(os.Getenv(key), 64); err == nil {
+		return v
+	}
+	return defaultValue
+}
+
 func parseDuration(s string) time.Duration {
+	return parseDurationOr(s, 168*time.Hour)
+}
+
+func parseDurationOr(s string, fallback time.Duration) time.Duration {
 	d, err := time.ParseDuration(s)
 	if err != nil {
-		return 168 * time.Hour
+		return fallback
 	}
 	return d
 }
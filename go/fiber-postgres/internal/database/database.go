@@ -5,19 +5,24 @@ import (
 	"database/sql"
 
 	"github.com/XSAM/otelsql"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
 	"github.com/jmoiron/sqlx"
 	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
-
-	_ "github.com/jackc/pgx/v5/stdlib"
 )
 
+// Connect opens the API's pool. Queries run through it are counted per
+// request by the query counter tracer (see WithQueryCounter).
 func Connect(ctx context.Context, databaseURL string) (*sqlx.DB, error) {
-	db, err := otelsql.Open("pgx", databaseURL,
-		otelsql.WithAttributes(semconv.DBSystemPostgreSQL),
-	)
+	connConfig, err := pgx.ParseConfig(databaseURL)
 	if err != nil {
 		return nil, err
 	}
+	connConfig.Tracer = queryCountTracer{}
+
+	db := otelsql.OpenDB(stdlib.GetConnector(*connConfig),
+		otelsql.WithAttributes(semconv.DBSystemPostgreSQL),
+	)
 
 	if _, err := otelsql.RegisterDBStatsMetrics(db, otelsql.WithAttributes(
 		semconv.DBSystemPostgreSQL,
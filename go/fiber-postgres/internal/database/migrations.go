@@ -2,12 +2,18 @@ package database
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
+	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/jmoiron/sqlx"
 	"github.com/riverqueue/river/riverdriver/riverpgxv5"
 	"github.com/riverqueue/river/rivermigrate"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+
+	"go-fiber-postgres/internal/telemetry"
 )
 
 var migrations = []string{
@@ -37,6 +43,16 @@ var migrations = []string{
 	`CREATE INDEX IF NOT EXISTS idx_articles_author_id ON articles(author_id)`,
 	`CREATE INDEX IF NOT EXISTS idx_articles_created_at ON articles(created_at DESC)`,
 
+	// Weighted search document: title matches outrank description matches,
+	// which outrank body matches.
+	`ALTER TABLE articles ADD COLUMN IF NOT EXISTS search_vector tsvector
+		GENERATED ALWAYS AS (
+			setweight(to_tsvector('english', coalesce(title, '')), 'A') ||
+			setweight(to_tsvector('english', coalesce(description, '')), 'B') ||
+			setweight(to_tsvector('english', coalesce(body, '')), 'C')
+		) STORED`,
+	`CREATE INDEX IF NOT EXISTS idx_articles_search_vector ON articles USING GIN (search_vector)`,
+
 	`CREATE TABLE IF NOT EXISTS favorites (
 		id SERIAL PRIMARY KEY,
 		user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
@@ -47,6 +63,34 @@ var migrations = []string{
 
 	`CREATE INDEX IF NOT EXISTS idx_favorites_user_id ON favorites(user_id)`,
 	`CREATE INDEX IF NOT EXISTS idx_favorites_article_id ON favorites(article_id)`,
+
+	// Slugs retired by title changes, so old links still find the article.
+	`CREATE TABLE IF NOT EXISTS slug_history (
+		id SERIAL PRIMARY KEY,
+		slug VARCHAR(255) UNIQUE NOT NULL,
+		article_id INTEGER NOT NULL REFERENCES articles(id) ON DELETE CASCADE,
+		created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+	)`,
+
+	`CREATE INDEX IF NOT EXISTS idx_slug_history_article_id ON slug_history(article_id)`,
+
+	`CREATE TABLE IF NOT EXISTS follows (
+		follower_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		followee_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (follower_id, followee_id),
+		CHECK (follower_id <> followee_id)
+	)`,
+
+	`CREATE INDEX IF NOT EXISTS idx_follows_followee_id ON follows(followee_id)`,
+
+	// Kept in step with follows by the follow and unfollow writes, so a
+	// profile never counts its followers.
+	`ALTER TABLE users ADD COLUMN IF NOT EXISTS followers_count INTEGER NOT NULL DEFAULT 0`,
+
+	// The feed walks each followed author's articles newest first and pages
+	// by (created_at, id), which this index answers without a sort.
+	`CREATE INDEX IF NOT EXISTS idx_articles_author_created ON articles(author_id, created_at DESC, id DESC)`,
 }
 
 func RunMigrations(ctx context.Context, db *sqlx.DB) error {
@@ -74,3 +118,64 @@ func RunRiverMigrations(ctx context.Context, pool *pgxpool.Pool) error {
 	slog.Info("river migrations completed")
 	return nil
 }
+
+// migrationLockKey is the advisory lock held while migrating. Any constant
+// works as long as every replica uses the same one.
+const migrationLockKey int64 = 0x66626d6967 // "fbmig"
+
+// WithMigrationLock runs migrate while holding a Postgres advisory lock, so
+// when several replicas start together only one migrates and the others
+// wait, then find nothing left to do. The lock belongs to a dedicated
+// connection and is released when migrate returns, or by Postgres if the
+// process dies first.
+func WithMigrationLock(ctx context.Context, db *sqlx.DB, migrate func(context.Context) error) error {
+	ctx, span := telemetry.Tracer().Start(ctx, "db.migrate")
+	defer span.End()
+
+	conn, err := db.Connx(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("migration lock connection: %w", err)
+	}
+	defer conn.Close()
+
+	start := time.Now()
+	var acquired bool
+	if err := conn.GetContext(ctx, &acquired, "SELECT pg_try_advisory_lock($1)", migrationLockKey); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("acquire migration lock: %w", err)
+	}
+	if !acquired {
+		slog.Info("another instance is running migrations, waiting for the migration lock")
+		if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", migrationLockKey); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			return fmt.Errorf("wait for migration lock: %w", err)
+		}
+	}
+	wait := time.Since(start)
+
+	waited := attribute.Bool("waited", !acquired)
+	telemetry.MigrationLockWait.Record(ctx, float64(wait.Milliseconds()), telemetry.WithAttributes(waited))
+	span.SetAttributes(
+		attribute.Bool("db.migration.lock.waited", !acquired),
+		attribute.Int64("db.migration.lock.wait_ms", wait.Milliseconds()),
+	)
+	slog.Info("migration lock acquired", "waited", !acquired, "wait_ms", wait.Milliseconds())
+
+	defer func() {
+		// The request context may be done by now; the unlock must still run.
+		if _, err := conn.ExecContext(context.WithoutCancel(ctx), "SELECT pg_advisory_unlock($1)", migrationLockKey); err != nil {
+			slog.Warn("failed to release migration lock", "error", err)
+		}
+	}()
+
+	if err := migrate(ctx); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+	return nil
+}
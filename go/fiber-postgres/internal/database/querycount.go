@@ -0,0 +1,40 @@
+package database
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type queryCounterKey struct{}
+
+// QueryCounter counts the statements pgx runs with a context carrying it.
+type QueryCounter struct {
+	n atomic.Int64
+}
+
+func (c *QueryCounter) Count() int64 {
+	return c.n.Load()
+}
+
+// WithQueryCounter returns a context whose queries are counted by the
+// returned counter. Repositories already pass the request context to sqlx,
+// which hands it down to pgx.
+func WithQueryCounter(ctx context.Context) (context.Context, *QueryCounter) {
+	c := &QueryCounter{}
+	return context.WithValue(ctx, queryCounterKey{}, c), c
+}
+
+// queryCountTracer is a pgx.QueryTracer that counts each Query, QueryRow and
+// Exec against the counter in its context, if any.
+type queryCountTracer struct{}
+
+func (queryCountTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, _ pgx.TraceQueryStartData) context.Context {
+	if c, ok := ctx.Value(queryCounterKey{}).(*QueryCounter); ok {
+		c.n.Add(1)
+	}
+	return ctx
+}
+
+func (queryCountTracer) TraceQueryEnd(context.Context, *pgx.Conn, pgx.TraceQueryEndData) {}
@@ -3,15 +3,21 @@ package handlers
 import (
 	"errors"
 	"strconv"
+	"strings"
 
+	"github.com/base-14/examples/go/pkg/response"
 	"github.com/gofiber/fiber/v2"
 
 	"go-fiber-postgres/internal/jobs"
 	"go-fiber-postgres/internal/logging"
 	"go-fiber-postgres/internal/middleware"
+	"go-fiber-postgres/internal/models"
 	"go-fiber-postgres/internal/services"
 )
 
+// maxSearchQueryLength bounds the text handed to websearch_to_tsquery.
+const maxSearchQueryLength = 200
+
 type ArticleHandler struct {
 	articleService *services.ArticleService
 	jobClient      *jobs.Client
@@ -28,9 +34,15 @@ func (h *ArticleHandler) List(c *fiber.Ctx) error {
 	limit, _ := strconv.Atoi(c.Query("limit", "20"))
 	offset, _ := strconv.Atoi(c.Query("offset", "0"))
 
+	if limit <= 0 {
+		limit = 20
+	}
 	if limit > 100 {
 		limit = 100
 	}
+	if offset < 0 {
+		offset = 0
+	}
 
 	ctx := c.UserContext()
 	userID := middleware.GetUserIDPtr(c)
@@ -40,7 +52,62 @@ func (h *ArticleHandler) List(c *fiber.Ctx) error {
 		return middleware.ErrorResponse(c, fiber.StatusInternalServerError, "failed to list articles")
 	}
 
-	return c.JSON(result)
+	return c.JSON(response.Page(ctx, articleResponses(result.Articles), response.OffsetOf(offset, limit, int64(result.TotalCount))))
+}
+
+func (h *ArticleHandler) Search(c *fiber.Ctx) error {
+	query := strings.TrimSpace(c.Query("q"))
+	if query == "" {
+		return middleware.ErrorResponse(c, fiber.StatusBadRequest, "query parameter q is required")
+	}
+	if len(query) > maxSearchQueryLength {
+		return middleware.ErrorResponse(c, fiber.StatusBadRequest, "query is too long")
+	}
+
+	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+	offset, _ := strconv.Atoi(c.Query("offset", "0"))
+
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	ctx := c.UserContext()
+	userID := middleware.GetUserIDPtr(c)
+
+	result, err := h.articleService.Search(ctx, query, limit, offset, userID)
+	if err != nil {
+		return middleware.ErrorResponse(c, fiber.StatusInternalServerError, "failed to search articles")
+	}
+
+	results := make([]models.ArticleSearchResultResponse, len(result.Articles))
+	for i, r := range result.Articles {
+		results[i] = r.ToResponse()
+	}
+
+	return c.JSON(response.Page(ctx, results, response.OffsetOf(offset, limit, int64(result.TotalCount))))
+}
+
+func (h *ArticleHandler) Feed(c *fiber.Ctx) error {
+	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+
+	ctx := c.UserContext()
+	userID := middleware.GetUserID(c)
+
+	result, err := h.articleService.Feed(ctx, userID, c.Query("cursor"), limit)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidCursor) {
+			return middleware.ErrorResponse(c, fiber.StatusBadRequest, "invalid cursor")
+		}
+		return middleware.ErrorResponse(c, fiber.StatusInternalServerError, "failed to load feed")
+	}
+
+	return c.JSON(response.Page(ctx, articleResponses(result.Articles), response.CursorOf(limit, result.NextCursor)))
 }
 
 func (h *ArticleHandler) Get(c *fiber.Ctx) error {
@@ -49,6 +116,17 @@ func (h *ArticleHandler) Get(c *fiber.Ctx) error {
 	userID := middleware.GetUserIDPtr(c)
 
 	article, err := h.articleService.GetBySlug(ctx, slug, userID)
+	if errors.Is(err, services.ErrArticleNotFound) {
+		// An old link from before a title change moves permanently to the
+		// article's current slug.
+		current, lookupErr := h.articleService.CurrentSlug(ctx, slug)
+		if lookupErr == nil {
+			return c.Redirect("/api/articles/"+current, fiber.StatusMovedPermanently)
+		}
+		if !errors.Is(lookupErr, services.ErrArticleNotFound) {
+			err = lookupErr
+		}
+	}
 	if err != nil {
 		if errors.Is(err, services.ErrArticleNotFound) {
 			return middleware.ErrorResponse(c, fiber.StatusNotFound, "article not found")
@@ -56,9 +134,7 @@ func (h *ArticleHandler) Get(c *fiber.Ctx) error {
 		return middleware.ErrorResponse(c, fiber.StatusInternalServerError, "failed to get article")
 	}
 
-	return c.JSON(fiber.Map{
-		"article": article,
-	})
+	return c.JSON(response.Data(ctx, article.ToResponse()))
 }
 
 func (h *ArticleHandler) Create(c *fiber.Ctx) error {
@@ -88,9 +164,7 @@ func (h *ArticleHandler) Create(c *fiber.Ctx) error {
 		}
 	}
 
-	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
-		"article": article,
-	})
+	return c.Status(fiber.StatusCreated).JSON(response.Data(ctx, article.ToResponse()))
 }
 
 func (h *ArticleHandler) Update(c *fiber.Ctx) error {
@@ -114,9 +188,7 @@ func (h *ArticleHandler) Update(c *fiber.Ctx) error {
 		return middleware.ErrorResponse(c, fiber.StatusInternalServerError, "failed to update article")
 	}
 
-	return c.JSON(fiber.Map{
-		"article": article,
-	})
+	return c.JSON(response.Data(ctx, article.ToResponse()))
 }
 
 func (h *ArticleHandler) Delete(c *fiber.Ctx) error {
@@ -154,9 +226,7 @@ func (h *ArticleHandler) Favorite(c *fiber.Ctx) error {
 		return middleware.ErrorResponse(c, fiber.StatusInternalServerError, "failed to favorite article")
 	}
 
-	return c.JSON(fiber.Map{
-		"article": article,
-	})
+	return c.JSON(response.Data(ctx, article.ToResponse()))
 }
 
 func (h *ArticleHandler) Unfavorite(c *fiber.Ctx) error {
@@ -175,7 +245,13 @@ func (h *ArticleHandler) Unfavorite(c *fiber.Ctx) error {
 		return middleware.ErrorResponse(c, fiber.StatusInternalServerError, "failed to unfavorite article")
 	}
 
-	return c.JSON(fiber.Map{
-		"article": article,
-	})
+	return c.JSON(response.Data(ctx, article.ToResponse()))
+}
+
+func articleResponses(articles []*models.Article) []models.ArticleResponse {
+	resp := make([]models.ArticleResponse, len(articles))
+	for i, a := range articles {
+		resp[i] = a.ToResponse()
+	}
+	return resp
 }
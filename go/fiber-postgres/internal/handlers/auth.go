@@ -3,6 +3,7 @@ package handlers
 import (
 	"errors"
 
+	"github.com/base-14/examples/go/pkg/response"
 	"github.com/gofiber/fiber/v2"
 	"go-fiber-postgres/internal/middleware"
 	"go-fiber-postgres/internal/services"
@@ -27,7 +28,7 @@ func (h *AuthHandler) Register(c *fiber.Ctx) error {
 	}
 
 	ctx := c.UserContext()
-	response, err := h.authService.Register(ctx, input)
+	result, err := h.authService.Register(ctx, input)
 	if err != nil {
 		if errors.Is(err, services.ErrEmailTaken) {
 			return middleware.ErrorResponse(c, fiber.StatusConflict, "email already taken")
@@ -35,7 +36,7 @@ func (h *AuthHandler) Register(c *fiber.Ctx) error {
 		return middleware.ErrorResponse(c, fiber.StatusInternalServerError, "failed to register user")
 	}
 
-	return c.Status(fiber.StatusCreated).JSON(response)
+	return c.Status(fiber.StatusCreated).JSON(response.Data(ctx, result))
 }
 
 func (h *AuthHandler) Login(c *fiber.Ctx) error {
@@ -49,7 +50,7 @@ func (h *AuthHandler) Login(c *fiber.Ctx) error {
 	}
 
 	ctx := c.UserContext()
-	response, err := h.authService.Login(ctx, input)
+	result, err := h.authService.Login(ctx, input)
 	if err != nil {
 		if errors.Is(err, services.ErrInvalidCredentials) {
 			return middleware.ErrorResponse(c, fiber.StatusUnauthorized, "invalid email or password")
@@ -57,7 +58,7 @@ func (h *AuthHandler) Login(c *fiber.Ctx) error {
 		return middleware.ErrorResponse(c, fiber.StatusInternalServerError, "failed to login")
 	}
 
-	return c.JSON(response)
+	return c.JSON(response.Data(ctx, result))
 }
 
 func (h *AuthHandler) GetUser(c *fiber.Ctx) error {
@@ -72,13 +73,15 @@ func (h *AuthHandler) GetUser(c *fiber.Ctx) error {
 		return middleware.ErrorResponse(c, fiber.StatusInternalServerError, "failed to get user")
 	}
 
-	return c.JSON(fiber.Map{
-		"user": user.ToResponse(),
-	})
+	return c.JSON(response.Data(ctx, user.ToResponse()))
 }
 
 func (h *AuthHandler) Logout(c *fiber.Ctx) error {
-	return c.JSON(fiber.Map{
+	if err := h.authService.Logout(c.UserContext(), middleware.GetToken(c)); err != nil {
+		return middleware.ErrorResponse(c, fiber.StatusUnauthorized, "invalid or expired token")
+	}
+
+	return c.JSON(response.Data(c.UserContext(), map[string]string{
 		"message": "logged out successfully",
-	})
+	}))
 }
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/base-14/examples/go/pkg/response"
+	"github.com/gofiber/fiber/v2"
+
+	"go-fiber-postgres/internal/middleware"
+	"go-fiber-postgres/internal/services"
+)
+
+type FollowHandler struct {
+	followService *services.FollowService
+}
+
+func NewFollowHandler(followService *services.FollowService) *FollowHandler {
+	return &FollowHandler{followService: followService}
+}
+
+func (h *FollowHandler) Follow(c *fiber.Ctx) error {
+	followeeID, err := c.ParamsInt("id")
+	if err != nil || followeeID <= 0 {
+		return middleware.ErrorResponse(c, fiber.StatusBadRequest, "invalid user id")
+	}
+
+	result, err := h.followService.Follow(c.UserContext(), middleware.GetUserID(c), followeeID)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrFollowSelf):
+			return middleware.ErrorResponse(c, fiber.StatusBadRequest, "cannot follow yourself")
+		case errors.Is(err, services.ErrUserNotFound):
+			return middleware.ErrorResponse(c, fiber.StatusNotFound, "user not found")
+		}
+		return middleware.ErrorResponse(c, fiber.StatusInternalServerError, "failed to follow user")
+	}
+
+	return c.JSON(response.Data(c.UserContext(), result))
+}
+
+func (h *FollowHandler) Unfollow(c *fiber.Ctx) error {
+	followeeID, err := c.ParamsInt("id")
+	if err != nil || followeeID <= 0 {
+		return middleware.ErrorResponse(c, fiber.StatusBadRequest, "invalid user id")
+	}
+
+	result, err := h.followService.Unfollow(c.UserContext(), middleware.GetUserID(c), followeeID)
+	if err != nil {
+		if errors.Is(err, services.ErrUserNotFound) {
+			return middleware.ErrorResponse(c, fiber.StatusNotFound, "user not found")
+		}
+		return middleware.ErrorResponse(c, fiber.StatusInternalServerError, "failed to unfollow user")
+	}
+
+	return c.JSON(response.Data(c.UserContext(), result))
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"github.com/base-14/examples/go/pkg/response"
 	"github.com/gofiber/fiber/v2"
 	"github.com/jmoiron/sqlx"
 )
@@ -13,18 +14,23 @@ func NewHealthHandler(db *sqlx.DB) *HealthHandler {
 	return &HealthHandler{db: db}
 }
 
+type HealthResponse struct {
+	Status   string `json:"status"`
+	Database string `json:"database"`
+}
+
 func (h *HealthHandler) Check(c *fiber.Ctx) error {
 	ctx := c.UserContext()
 
 	if err := h.db.PingContext(ctx); err != nil {
-		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
-			"status":   "unhealthy",
-			"database": "disconnected",
-		})
+		return c.Status(fiber.StatusServiceUnavailable).JSON(response.Data(ctx, HealthResponse{
+			Status:   "unhealthy",
+			Database: "disconnected",
+		}))
 	}
 
-	return c.JSON(fiber.Map{
-		"status":   "healthy",
-		"database": "connected",
-	})
+	return c.JSON(response.Data(ctx, HealthResponse{
+		Status:   "healthy",
+		Database: "connected",
+	}))
 }
@@ -0,0 +1,11 @@
+package handlers
+
+import (
+	"github.com/base-14/examples/go/pkg/buildinfo"
+	"github.com/base-14/examples/go/pkg/response"
+	"github.com/gofiber/fiber/v2"
+)
+
+func Version(c *fiber.Ctx) error {
+	return c.JSON(response.Data(c.UserContext(), buildinfo.Get()))
+}
@@ -10,6 +10,7 @@ import (
 	"go-fiber-postgres/internal/logging"
 	"go-fiber-postgres/internal/telemetry"
 	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/propagation"
 )
 
@@ -39,13 +40,14 @@ func (c *Client) EnqueueNotification(ctx context.Context, articleID int, title s
 		TraceContext: carrier,
 	}, nil)
 
+	queue := telemetry.WithAttributes(attribute.String("job.queue", QueueNotifications))
 	if err != nil {
 		logging.Error(ctx, "failed to enqueue notification", "error", err)
-		telemetry.JobsFailed.Add(ctx, 1)
+		telemetry.JobsFailed.Add(ctx, 1, queue)
 		return err
 	}
 
-	telemetry.JobsEnqueued.Add(ctx, 1)
+	telemetry.JobsEnqueued.Add(ctx, 1, queue)
 	logging.Info(ctx, "notification job enqueued", "articleId", articleID)
 
 	return nil
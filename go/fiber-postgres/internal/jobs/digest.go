@@ -0,0 +1,57 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/riverqueue/river"
+	"go-fiber-postgres/internal/logging"
+	"go-fiber-postgres/internal/telemetry"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// DigestArgs summarises articles published since the given time. Digests are
+// enqueued periodically by the worker and run on the low-priority queue.
+type DigestArgs struct {
+	Since time.Time `json:"since"`
+}
+
+func (DigestArgs) Kind() string { return "digest" }
+
+func (DigestArgs) InsertOpts() river.InsertOpts {
+	return river.InsertOpts{Queue: QueueDigests, Priority: PriorityLow}
+}
+
+type DigestWorker struct {
+	river.WorkerDefaults[DigestArgs]
+	pool *pgxpool.Pool
+}
+
+func (w *DigestWorker) Work(ctx context.Context, job *river.Job[DigestArgs]) error {
+	ctx, span := telemetry.Tracer().Start(ctx, "job.digest")
+	defer span.End()
+
+	var articles, favorites int
+	err := w.pool.QueryRow(ctx,
+		`SELECT COUNT(*), COALESCE(SUM(favorites_count), 0) FROM articles WHERE created_at >= $1`,
+		job.Args.Since,
+	).Scan(&articles, &favorites)
+	if err != nil {
+		span.RecordError(err)
+		return err
+	}
+
+	span.SetAttributes(
+		attribute.Int("digest.articles", articles),
+		attribute.Int("digest.favorites", favorites),
+	)
+
+	logging.Info(ctx, "digest generated",
+		"since", job.Args.Since,
+		"articles", articles,
+		"favorites", favorites,
+	)
+
+	return nil
+}
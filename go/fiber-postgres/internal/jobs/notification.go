@@ -19,6 +19,10 @@ type NotificationArgs struct {
 
 func (NotificationArgs) Kind() string { return "notification" }
 
+func (NotificationArgs) InsertOpts() river.InsertOpts {
+	return river.InsertOpts{Queue: QueueNotifications, Priority: PriorityHigh}
+}
+
 type NotificationWorker struct {
 	river.WorkerDefaults[NotificationArgs]
 }
@@ -43,7 +47,5 @@ func (w *NotificationWorker) Work(ctx context.Context, job *river.Job[Notificati
 		"articleId", job.Args.ArticleID,
 	)
 
-	telemetry.JobsCompleted.Add(ctx, 1)
-
 	return nil
 }
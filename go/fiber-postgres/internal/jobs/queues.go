@@ -0,0 +1,51 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/riverqueue/river"
+	"github.com/riverqueue/river/rivertype"
+	"go-fiber-postgres/internal/telemetry"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Queues separate work by urgency. Each queue has its own worker pool, so
+// slow digests never delay notifications; priority orders jobs within a queue
+// (1 is highest).
+const (
+	QueueNotifications = "notifications"
+	QueueDigests       = "digests"
+
+	PriorityHigh = 1
+	PriorityLow  = 4
+)
+
+// metricsMiddleware records per-queue throughput, pickup latency and
+// execution time for every job the worker runs.
+type metricsMiddleware struct {
+	river.MiddlewareDefaults
+}
+
+func (m *metricsMiddleware) Work(ctx context.Context, job *rivertype.JobRow, doInner func(context.Context) error) error {
+	attrs := telemetry.WithAttributes(
+		attribute.String("job.queue", job.Queue),
+		attribute.String("job.kind", job.Kind),
+	)
+
+	if job.AttemptedAt != nil {
+		wait := job.AttemptedAt.Sub(job.ScheduledAt)
+		telemetry.JobsQueueLatency.Record(ctx, float64(wait.Milliseconds()), attrs)
+	}
+
+	start := time.Now()
+	err := doInner(ctx)
+	telemetry.JobsDuration.Record(ctx, float64(time.Since(start).Milliseconds()), attrs)
+
+	if err != nil {
+		telemetry.JobsFailed.Add(ctx, 1, attrs)
+	} else {
+		telemetry.JobsCompleted.Add(ctx, 1, attrs)
+	}
+	return err
+}
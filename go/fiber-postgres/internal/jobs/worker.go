@@ -2,11 +2,14 @@ package jobs
 
 import (
 	"context"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/riverqueue/river"
 	"github.com/riverqueue/river/riverdriver/riverpgxv5"
+	"github.com/riverqueue/river/rivertype"
+	"go-fiber-postgres/config"
 	"go-fiber-postgres/internal/logging"
 )
 
@@ -14,20 +17,39 @@ type Worker struct {
 	client *river.Client[pgx.Tx]
 }
 
-func NewWorker(ctx context.Context, pool *pgxpool.Pool) (*Worker, error) {
+func NewWorker(ctx context.Context, pool *pgxpool.Pool, cfg config.JobsConfig) (*Worker, error) {
 	workers := river.NewWorkers()
 	river.AddWorker(workers, &NotificationWorker{})
+	river.AddWorker(workers, &DigestWorker{pool: pool})
 
+	interval := cfg.DigestInterval
 	client, err := river.NewClient(riverpgxv5.New(pool), &river.Config{
 		Queues: map[string]river.QueueConfig{
-			river.QueueDefault: {MaxWorkers: 10},
+			QueueNotifications: {MaxWorkers: cfg.NotificationWorkers},
+			QueueDigests:       {MaxWorkers: cfg.DigestWorkers},
 		},
-		Workers: workers,
+		PeriodicJobs: []*river.PeriodicJob{
+			river.NewPeriodicJob(
+				river.PeriodicInterval(interval),
+				func() (river.JobArgs, *river.InsertOpts) {
+					return DigestArgs{Since: time.Now().Add(-interval)}, nil
+				},
+				&river.PeriodicJobOpts{RunOnStart: true},
+			),
+		},
+		Middleware: []rivertype.Middleware{&metricsMiddleware{}},
+		Workers:    workers,
 	})
 	if err != nil {
 		return nil, err
 	}
 
+	logging.Info(ctx, "river queues configured",
+		"notificationWorkers", cfg.NotificationWorkers,
+		"digestWorkers", cfg.DigestWorkers,
+		"digestInterval", interval.String(),
+	)
+
 	return &Worker{client: client}, nil
 }
 
@@ -5,6 +5,7 @@ import (
 	"log/slog"
 	"os"
 
+	"github.com/base-14/examples/go/pkg/milestone"
 	"go.opentelemetry.io/contrib/bridges/otelslog"
 	"go.opentelemetry.io/otel/log/global"
 	"go.opentelemetry.io/otel/trace"
@@ -58,6 +59,12 @@ func Error(ctx context.Context, msg string, args ...any) {
 	Logger().ErrorContext(ctx, msg, args...)
 }
 
+// Milestone logs a business milestone under the name and attributes of its
+// span event.
+func Milestone(ctx context.Context, ev milestone.Event) {
+	Logger().InfoContext(ctx, ev.Name, ev.Args()...)
+}
+
 // traceContextHandler enriches stdout JSON records with trace_id/span_id from
 // context. It wraps the JSON handler only — otelslog already populates these
 // fields on the OTLP LogRecord envelope from context, so wrapping that side
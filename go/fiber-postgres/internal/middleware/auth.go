@@ -27,12 +27,13 @@ func (m *AuthMiddleware) Required() fiber.Handler {
 			return ErrorResponse(c, fiber.StatusUnauthorized, "invalid authorization header format")
 		}
 
-		userID, err := m.authService.ValidateToken(parts[1])
+		userID, err := m.authService.ValidateToken(c.UserContext(), parts[1])
 		if err != nil {
 			return ErrorResponse(c, fiber.StatusUnauthorized, "invalid or expired token")
 		}
 
 		c.Locals("userID", userID)
+		c.Locals("token", parts[1])
 		return c.Next()
 	}
 }
@@ -49,7 +50,7 @@ func (m *AuthMiddleware) Optional() fiber.Handler {
 			return c.Next()
 		}
 
-		userID, err := m.authService.ValidateToken(parts[1])
+		userID, err := m.authService.ValidateToken(c.UserContext(), parts[1])
 		if err == nil {
 			c.Locals("userID", userID)
 		}
@@ -66,6 +67,12 @@ func GetUserID(c *fiber.Ctx) int {
 	return userID
 }
 
+// GetToken returns the bearer token Required authenticated the request with.
+func GetToken(c *fiber.Ctx) string {
+	token, _ := c.Locals("token").(string)
+	return token
+}
+
 func GetUserIDPtr(c *fiber.Ctx) *int {
 	userID, ok := c.Locals("userID").(int)
 	if !ok {
@@ -0,0 +1,95 @@
+package middleware
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel/attribute"
+
+	"go-fiber-postgres/internal/telemetry"
+)
+
+type CacheVisibility string
+
+const (
+	CachePublic  CacheVisibility = "public"
+	CachePrivate CacheVisibility = "private"
+	CacheNoStore CacheVisibility = "no-store"
+)
+
+// CachePolicy describes the Cache-Control and Surrogate-Control headers for a
+// route. MaxAge applies to browsers; SurrogateMaxAge to CDNs and shared caches.
+type CachePolicy struct {
+	Visibility           CacheVisibility
+	MaxAge               time.Duration
+	SurrogateMaxAge      time.Duration
+	StaleWhileRevalidate time.Duration
+	// PrivateWhenAuthenticated downgrades the policy to private when the
+	// request carries a token, for responses that embed per-user fields such
+	// as "favorited".
+	PrivateWhenAuthenticated bool
+}
+
+// CachePolicies maps "METHOD /route/pattern" (as registered with fiber) to a
+// policy. Unlisted GETs are private; everything else is no-store.
+type CachePolicies map[string]CachePolicy
+
+// CacheControl must be registered before any middleware that can short-circuit
+// with an error (e.g. the rate limiter) so those responses get no-store too.
+func CacheControl(policies CachePolicies) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		err := c.Next()
+
+		route := c.Route().Path
+		policy, ok := policies[c.Method()+" "+route]
+		if !ok {
+			policy = CachePolicy{Visibility: CacheNoStore}
+			if c.Method() == fiber.MethodGet {
+				policy.Visibility = CachePrivate
+			}
+		}
+		if policy.PrivateWhenAuthenticated {
+			c.Vary(fiber.HeaderAuthorization)
+			if c.Get(fiber.HeaderAuthorization) != "" {
+				policy = CachePolicy{Visibility: CachePrivate}
+			}
+		}
+		// Never let a shared cache hold on to an error.
+		if err != nil || c.Response().StatusCode() >= fiber.StatusBadRequest {
+			policy = CachePolicy{Visibility: CacheNoStore}
+		}
+
+		c.Set(fiber.HeaderCacheControl, policy.cacheControl())
+		if policy.Visibility == CachePublic && policy.SurrogateMaxAge > 0 {
+			c.Set("Surrogate-Control", fmt.Sprintf("max-age=%d", int(policy.SurrogateMaxAge.Seconds())))
+		}
+
+		telemetry.CacheResponses.Add(c.UserContext(), 1, telemetry.WithAttributes(
+			attribute.String("http.route", route),
+			attribute.Bool("cache.cacheable", policy.Visibility == CachePublic),
+			attribute.String("cache.visibility", string(policy.Visibility)),
+		))
+
+		return err
+	}
+}
+
+func (p CachePolicy) cacheControl() string {
+	switch p.Visibility {
+	case CachePublic:
+		parts := []string{"public", fmt.Sprintf("max-age=%d", int(p.MaxAge.Seconds()))}
+		if p.StaleWhileRevalidate > 0 {
+			parts = append(parts, fmt.Sprintf("stale-while-revalidate=%d", int(p.StaleWhileRevalidate.Seconds())))
+		}
+		return strings.Join(parts, ", ")
+	case CachePrivate:
+		if p.MaxAge > 0 {
+			return fmt.Sprintf("private, max-age=%d", int(p.MaxAge.Seconds()))
+		}
+		return "private, no-cache"
+	default:
+		return "no-store"
+	}
+}
@@ -1,8 +1,8 @@
 package middleware
 
 import (
+	"github.com/base-14/examples/go/pkg/response"
 	"github.com/gofiber/fiber/v2"
-	"go.opentelemetry.io/otel/trace"
 )
 
 func ErrorHandler(c *fiber.Ctx, err error) error {
@@ -12,27 +12,9 @@ func ErrorHandler(c *fiber.Ctx, err error) error {
 		code = e.Code
 	}
 
-	response := fiber.Map{
-		"error": err.Error(),
-	}
-
-	span := trace.SpanFromContext(c.UserContext())
-	if span.SpanContext().IsValid() {
-		response["trace_id"] = span.SpanContext().TraceID().String()
-	}
-
-	return c.Status(code).JSON(response)
+	return ErrorResponse(c, code, err.Error())
 }
 
 func ErrorResponse(c *fiber.Ctx, status int, message string) error {
-	response := fiber.Map{
-		"error": message,
-	}
-
-	span := trace.SpanFromContext(c.UserContext())
-	if span.SpanContext().IsValid() {
-		response["trace_id"] = span.SpanContext().TraceID().String()
-	}
-
-	return c.Status(status).JSON(response)
+	return c.Status(status).JSON(response.Fail(c.UserContext(), status, message))
 }
@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"go-fiber-postgres/internal/database"
+	"go-fiber-postgres/internal/logging"
+	"go-fiber-postgres/internal/telemetry"
+)
+
+// QueryCount counts the database queries each request runs and records them
+// by route. A request running more than threshold queries, usually an N+1
+// loop, is logged as a warning and counted; a threshold of 0 disables the
+// warning but still records the histogram.
+func QueryCount(threshold int) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, counter := database.WithQueryCounter(c.UserContext())
+		c.SetUserContext(ctx)
+
+		err := c.Next()
+
+		n := counter.Count()
+		attrs := telemetry.WithAttributes(
+			attribute.String("http.method", c.Method()),
+			attribute.String("http.route", c.Route().Path),
+		)
+		telemetry.DBQueriesPerRequest.Record(ctx, n, attrs)
+		trace.SpanFromContext(ctx).SetAttributes(attribute.Int64("db.query_count", n))
+
+		if threshold > 0 && n > int64(threshold) {
+			telemetry.DBQueryThresholdExceeded.Add(ctx, 1, attrs)
+			logging.Warn(ctx, "request exceeded database query threshold",
+				"method", c.Method(),
+				"route", c.Route().Path,
+				"queries", n,
+				"threshold", threshold,
+			)
+		}
+
+		return err
+	}
+}
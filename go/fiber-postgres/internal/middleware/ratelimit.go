@@ -0,0 +1,270 @@
+package middleware
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel/attribute"
+
+	"go-fiber-postgres/config"
+	"go-fiber-postgres/internal/services"
+	"go-fiber-postgres/internal/telemetry"
+)
+
+type priority int
+
+const (
+	priorityAuth priority = iota
+	priorityAnonymous
+)
+
+func (p priority) String() string {
+	if p == priorityAuth {
+		return "authenticated"
+	}
+	return "anonymous"
+}
+
+const (
+	dispatchInterval = 5 * time.Millisecond
+	idleBucketTTL    = 10 * time.Minute
+)
+
+type bucket struct {
+	tokens   float64
+	rate     float64
+	burst    float64
+	last     time.Time
+	queued   int
+	lastSeen time.Time
+}
+
+func newBucket(rate float64, burst int, now time.Time) *bucket {
+	return &bucket{tokens: float64(burst), rate: rate, burst: float64(burst), last: now, lastSeen: now}
+}
+
+func (b *bucket) refill(now time.Time) {
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+}
+
+func (b *bucket) available(now time.Time) bool {
+	b.refill(now)
+	return b.tokens >= 1
+}
+
+type waiter struct {
+	bucket *bucket
+	ready  chan struct{}
+}
+
+// RateLimiter throttles per client, with authenticated users on a larger
+// bucket than anonymous traffic. A request over its client's rate, or arriving
+// while the shared global capacity is exhausted, waits in its class's queue
+// instead of failing; queued requests are released by weighted round robin so
+// authenticated traffic drains faster under contention. Only a full queue or a
+// wait longer than MaxWait produces a 429.
+type RateLimiter struct {
+	authService *services.AuthService
+	classes     [2]config.RateLimitClass
+	maxWait     time.Duration
+
+	mu      sync.Mutex
+	global  *bucket
+	buckets map[string]*bucket
+	queues  [2][]*waiter
+	credits [2]int
+	wake    chan struct{}
+}
+
+func NewRateLimiter(cfg config.RateLimitConfig, authService *services.AuthService) *RateLimiter {
+	now := time.Now()
+	rl := &RateLimiter{
+		authService: authService,
+		classes:     [2]config.RateLimitClass{cfg.Auth, cfg.Anonymous},
+		maxWait:     cfg.MaxWait,
+		global:      newBucket(cfg.GlobalRPS, max(1, int(cfg.GlobalRPS)), now),
+		buckets:     make(map[string]*bucket),
+		wake:        make(chan struct{}, 1),
+	}
+	go rl.dispatch()
+	return rl
+}
+
+// Handler must be registered after otelfiber so queue time lands on the
+// request span. /api/health is always exempt.
+func (rl *RateLimiter) Handler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if c.Path() == "/api/health" {
+			return c.Next()
+		}
+
+		key, prio := rl.classify(c)
+		start := time.Now()
+
+		outcome, ready := rl.acquire(key, prio, start)
+		if ready != nil {
+			timer := time.NewTimer(rl.maxWait)
+			select {
+			case <-ready.ready:
+				timer.Stop()
+				outcome = "queued"
+			case <-timer.C:
+				if rl.abandon(prio, ready) {
+					outcome = "timeout"
+				} else {
+					// Admitted just as the timer fired.
+					outcome = "queued"
+				}
+			}
+		}
+
+		ctx := c.UserContext()
+		attrs := telemetry.WithAttributes(
+			attribute.String("ratelimit.priority", prio.String()),
+			attribute.String("ratelimit.outcome", outcome),
+		)
+		telemetry.RateLimitQueueWait.Record(ctx, float64(time.Since(start).Microseconds())/1000, attrs)
+
+		if outcome == "rejected" || outcome == "timeout" {
+			telemetry.RateLimitRejected.Add(ctx, 1, attrs)
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(rl.maxWait.Seconds())+1))
+			return ErrorResponse(c, fiber.StatusTooManyRequests, "rate limit exceeded")
+		}
+
+		return c.Next()
+	}
+}
+
+func (rl *RateLimiter) classify(c *fiber.Ctx) (string, priority) {
+	parts := strings.Split(c.Get("Authorization"), " ")
+	if len(parts) == 2 && strings.ToLower(parts[0]) == "bearer" {
+		if userID, err := rl.authService.ValidateToken(c.UserContext(), parts[1]); err == nil {
+			return "user:" + strconv.Itoa(userID), priorityAuth
+		}
+	}
+	return "ip:" + c.IP(), priorityAnonymous
+}
+
+// acquire admits the request immediately when both the client and global
+// buckets have a token and nothing of equal priority is already waiting.
+// Otherwise it enqueues a waiter, or reports "rejected" if the class queue is
+// full for this client.
+func (rl *RateLimiter) acquire(key string, prio priority, now time.Time) (string, *waiter) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	class := rl.classes[prio]
+	b, ok := rl.buckets[key]
+	if !ok {
+		b = newBucket(class.RPS, class.Burst, now)
+		rl.buckets[key] = b
+	}
+	b.lastSeen = now
+
+	if len(rl.queues[prio]) == 0 && b.available(now) && rl.global.available(now) {
+		b.tokens--
+		rl.global.tokens--
+		return "immediate", nil
+	}
+
+	if b.queued >= class.Queue {
+		return "rejected", nil
+	}
+
+	w := &waiter{bucket: b, ready: make(chan struct{})}
+	b.queued++
+	rl.queues[prio] = append(rl.queues[prio], w)
+
+	select {
+	case rl.wake <- struct{}{}:
+	default:
+	}
+	return "", w
+}
+
+// abandon removes a timed-out waiter. It returns false if the dispatcher
+// admitted the waiter first, in which case the request should proceed.
+func (rl *RateLimiter) abandon(prio priority, w *waiter) bool {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	for i, q := range rl.queues[prio] {
+		if q == w {
+			rl.queues[prio] = append(rl.queues[prio][:i], rl.queues[prio][i+1:]...)
+			w.bucket.queued--
+			return true
+		}
+	}
+	return false
+}
+
+func (rl *RateLimiter) dispatch() {
+	ticker := time.NewTicker(dispatchInterval)
+	defer ticker.Stop()
+
+	lastSweep := time.Now()
+	for {
+		select {
+		case <-rl.wake:
+		case <-ticker.C:
+		}
+
+		now := time.Now()
+		rl.mu.Lock()
+		for rl.admitNext(now) {
+		}
+		if now.Sub(lastSweep) > idleBucketTTL {
+			for key, b := range rl.buckets {
+				if b.queued == 0 && now.Sub(b.lastSeen) > idleBucketTTL {
+					delete(rl.buckets, key)
+				}
+			}
+			lastSweep = now
+		}
+		rl.mu.Unlock()
+	}
+}
+
+// admitNext releases one waiter, choosing the class by weighted round robin.
+// Callers must hold rl.mu.
+func (rl *RateLimiter) admitNext(now time.Time) bool {
+	if len(rl.queues[priorityAuth]) == 0 && len(rl.queues[priorityAnonymous]) == 0 {
+		return false
+	}
+	if !rl.global.available(now) {
+		return false
+	}
+
+	if rl.credits[priorityAuth] <= 0 && rl.credits[priorityAnonymous] <= 0 {
+		rl.credits[priorityAuth] = rl.classes[priorityAuth].Weight
+		rl.credits[priorityAnonymous] = rl.classes[priorityAnonymous].Weight
+	}
+
+	order := [2]priority{priorityAuth, priorityAnonymous}
+	if rl.credits[priorityAuth] <= 0 {
+		order = [2]priority{priorityAnonymous, priorityAuth}
+	}
+
+	for _, prio := range order {
+		for i, w := range rl.queues[prio] {
+			if !w.bucket.available(now) {
+				continue
+			}
+			w.bucket.tokens--
+			w.bucket.queued--
+			rl.global.tokens--
+			rl.credits[prio]--
+			rl.queues[prio] = append(rl.queues[prio][:i], rl.queues[prio][i+1:]...)
+			close(w.ready)
+			return true
+		}
+	}
+	return false
+}
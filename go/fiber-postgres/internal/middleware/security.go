@@ -0,0 +1,142 @@
+package middleware
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel/attribute"
+
+	"go-fiber-postgres/config"
+	"go-fiber-postgres/internal/telemetry"
+)
+
+// Reasons a cross-origin request is rejected, recorded as cors.reason.
+const (
+	corsRejectOrigin  = "origin"
+	corsRejectMethod  = "method"
+	corsRejectHeaders = "headers"
+)
+
+type corsPolicy struct {
+	anyOrigin bool
+	origins   map[string]bool
+	methods   map[string]bool
+	anyHeader bool
+	headers   map[string]bool
+}
+
+func newCORSPolicy(cfg config.SecurityConfig) corsPolicy {
+	p := corsPolicy{
+		origins: make(map[string]bool),
+		methods: make(map[string]bool),
+		headers: make(map[string]bool),
+	}
+	for _, o := range cfg.AllowedOrigins {
+		if o == "*" {
+			p.anyOrigin = true
+		}
+		p.origins[strings.TrimSuffix(o, "/")] = true
+	}
+	for _, m := range cfg.AllowedMethods {
+		p.methods[strings.ToUpper(m)] = true
+	}
+	for _, h := range cfg.AllowedHeaders {
+		if h == "*" {
+			p.anyHeader = true
+		}
+		p.headers[strings.ToLower(h)] = true
+	}
+	return p
+}
+
+func (p corsPolicy) enabled() bool {
+	return len(p.origins) > 0
+}
+
+// reject returns why a cross-origin request fails the policy, or "" if it
+// passes. Method and headers are only checked on preflight.
+func (p corsPolicy) reject(origin string, preflight bool, method, headers string) string {
+	if !p.anyOrigin && !p.origins[origin] {
+		return corsRejectOrigin
+	}
+	if !preflight {
+		return ""
+	}
+	if !p.methods[strings.ToUpper(method)] {
+		return corsRejectMethod
+	}
+	if !p.anyHeader {
+		for _, h := range strings.Split(headers, ",") {
+			if h = strings.TrimSpace(h); h != "" && !p.headers[strings.ToLower(h)] {
+				return corsRejectHeaders
+			}
+		}
+	}
+	return ""
+}
+
+// Security sets X-Content-Type-Options, Content-Security-Policy and, when
+// configured, Strict-Transport-Security on every response, and enforces the
+// CORS policy. Preflights are answered here with 204; a cross-origin request
+// the policy doesn't allow gets a 403 and is counted in
+// http.server.cors.rejected. Same-origin requests and requests without an
+// Origin header (curl, server-to-server) are not subject to CORS.
+func Security(cfg config.SecurityConfig) fiber.Handler {
+	policy := newCORSPolicy(cfg)
+	allowMethods := strings.Join(cfg.AllowedMethods, ", ")
+	allowHeaders := strings.Join(cfg.AllowedHeaders, ", ")
+	maxAge := strconv.Itoa(int(cfg.CORSMaxAge.Seconds()))
+
+	var hsts string
+	if cfg.HSTSMaxAge > 0 {
+		hsts = fmt.Sprintf("max-age=%d; includeSubDomains", int(cfg.HSTSMaxAge.Seconds()))
+	}
+
+	return func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderXContentTypeOptions, "nosniff")
+		if cfg.ContentSecurityPolicy != "" {
+			c.Set(fiber.HeaderContentSecurityPolicy, cfg.ContentSecurityPolicy)
+		}
+		if hsts != "" {
+			c.Set(fiber.HeaderStrictTransportSecurity, hsts)
+		}
+
+		origin := c.Get(fiber.HeaderOrigin)
+		if !policy.enabled() || origin == "" || origin == c.BaseURL() {
+			return c.Next()
+		}
+		c.Vary(fiber.HeaderOrigin)
+
+		requestMethod := c.Get(fiber.HeaderAccessControlRequestMethod)
+		preflight := c.Method() == fiber.MethodOptions && requestMethod != ""
+		if reason := policy.reject(origin, preflight, requestMethod, c.Get(fiber.HeaderAccessControlRequestHeaders)); reason != "" {
+			telemetry.CORSRejected.Add(c.UserContext(), 1, telemetry.WithAttributes(
+				attribute.String("cors.reason", reason),
+				attribute.Bool("cors.preflight", preflight),
+			))
+			return ErrorResponse(c, fiber.StatusForbidden, "cross-origin request not allowed")
+		}
+
+		// A wildcard policy never shares credentials, so "*" is safe to send.
+		if policy.anyOrigin {
+			c.Set(fiber.HeaderAccessControlAllowOrigin, "*")
+		} else {
+			c.Set(fiber.HeaderAccessControlAllowOrigin, origin)
+			if cfg.AllowCredentials {
+				c.Set(fiber.HeaderAccessControlAllowCredentials, "true")
+			}
+		}
+
+		if preflight {
+			c.Set(fiber.HeaderAccessControlAllowMethods, allowMethods)
+			c.Set(fiber.HeaderAccessControlAllowHeaders, allowHeaders)
+			if cfg.CORSMaxAge > 0 {
+				c.Set(fiber.HeaderAccessControlMaxAge, maxAge)
+			}
+			return c.SendStatus(fiber.StatusNoContent)
+		}
+		return c.Next()
+	}
+}
@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"go-fiber-postgres/internal/telemetry"
+)
+
+// Timeout puts a deadline on the request's user context: reads (GET, HEAD)
+// get readTimeout, everything else writeTimeout. Repositories pass that
+// context to sqlx, so pgx cancels a query still running at the deadline.
+// Server errors caused by the deadline are rewritten to 504 and counted by
+// route.
+func Timeout(readTimeout, writeTimeout time.Duration) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		timeout := writeTimeout
+		if c.Method() == fiber.MethodGet || c.Method() == fiber.MethodHead {
+			timeout = readTimeout
+		}
+
+		ctx, cancel := context.WithTimeout(c.UserContext(), timeout)
+		defer cancel()
+		c.SetUserContext(ctx)
+
+		err := c.Next()
+		if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return err
+		}
+
+		trace.SpanFromContext(ctx).SetAttributes(attribute.Bool("http.request.deadline_exceeded", true))
+		telemetry.HTTPDeadlineExceeded.Add(ctx, 1, telemetry.WithAttributes(
+			attribute.String("http.method", c.Method()),
+			attribute.String("http.route", c.Route().Path),
+			attribute.String("timeout", timeout.String()),
+		))
+
+		if err != nil || c.Response().StatusCode() >= fiber.StatusInternalServerError {
+			return ErrorResponse(c, fiber.StatusGatewayTimeout, "request timed out")
+		}
+		return nil
+	}
+}
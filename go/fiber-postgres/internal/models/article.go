@@ -1,6 +1,10 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"github.com/base-14/examples/go/pkg/response"
+)
 
 type Article struct {
 	ID             int       `db:"id" json:"id"`
@@ -17,6 +21,47 @@ type Article struct {
 	Favorited bool  `db:"-" json:"favorited"`
 }
 
+type ArticleResponse struct {
+	ID             int           `json:"id"`
+	Slug           string        `json:"slug"`
+	Title          string        `json:"title"`
+	Description    string        `json:"description"`
+	Body           string        `json:"body"`
+	AuthorID       int           `json:"author_id"`
+	FavoritesCount int           `json:"favorites_count"`
+	Favorited      bool          `json:"favorited"`
+	Author         *UserResponse `json:"author,omitempty"`
+	CreatedAt      response.Time `json:"created_at"`
+	UpdatedAt      response.Time `json:"updated_at"`
+}
+
+func (a *Article) ToResponse() ArticleResponse {
+	resp := ArticleResponse{
+		ID:             a.ID,
+		Slug:           a.Slug,
+		Title:          a.Title,
+		Description:    a.Description,
+		Body:           a.Body,
+		AuthorID:       a.AuthorID,
+		FavoritesCount: a.FavoritesCount,
+		Favorited:      a.Favorited,
+		CreatedAt:      response.NewTime(a.CreatedAt),
+		UpdatedAt:      response.NewTime(a.UpdatedAt),
+	}
+	if a.Author != nil {
+		author := a.Author.ToResponse()
+		resp.Author = &author
+	}
+	return resp
+}
+
+// FeedCursor is the position after the last article of a feed page: feeds
+// are ordered by created_at then id, both descending.
+type FeedCursor struct {
+	CreatedAt time.Time
+	ID        int
+}
+
 type ArticleWithAuthor struct {
 	ID             int       `db:"id"`
 	Slug           string    `db:"slug"`
@@ -53,3 +98,39 @@ func (a *ArticleWithAuthor) ToArticle() *Article {
 		},
 	}
 }
+
+// ArticleSearchResult is an article matched by full-text search, with its
+// rank and a highlighted snippet of the matching text.
+type ArticleSearchResult struct {
+	*Article
+	Rank    float64 `json:"rank"`
+	Snippet string  `json:"snippet"`
+}
+
+type ArticleSearchResultResponse struct {
+	ArticleResponse
+	Rank    float64 `json:"rank"`
+	Snippet string  `json:"snippet"`
+}
+
+func (r *ArticleSearchResult) ToResponse() ArticleSearchResultResponse {
+	return ArticleSearchResultResponse{
+		ArticleResponse: r.Article.ToResponse(),
+		Rank:            r.Rank,
+		Snippet:         r.Snippet,
+	}
+}
+
+type ArticleSearchRow struct {
+	ArticleWithAuthor
+	Rank    float64 `db:"rank"`
+	Snippet string  `db:"snippet"`
+}
+
+func (r *ArticleSearchRow) ToSearchResult() *ArticleSearchResult {
+	return &ArticleSearchResult{
+		Article: r.ToArticle(),
+		Rank:    r.Rank,
+		Snippet: r.Snippet,
+	}
+}
@@ -1,6 +1,10 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"github.com/base-14/examples/go/pkg/response"
+)
 
 type User struct {
 	ID           int       `db:"id" json:"id"`
@@ -11,15 +15,19 @@ type User struct {
 	Image        string    `db:"image" json:"image"`
 	CreatedAt    time.Time `db:"created_at" json:"created_at"`
 	UpdatedAt    time.Time `db:"updated_at" json:"updated_at"`
+
+	FollowersCount int `db:"followers_count" json:"followers_count"`
 }
 
 type UserResponse struct {
-	ID        int       `json:"id"`
-	Email     string    `json:"email"`
-	Name      string    `json:"name"`
-	Bio       string    `json:"bio"`
-	Image     string    `json:"image"`
-	CreatedAt time.Time `json:"created_at"`
+	ID        int           `json:"id"`
+	Email     string        `json:"email"`
+	Name      string        `json:"name"`
+	Bio       string        `json:"bio"`
+	Image     string        `json:"image"`
+	CreatedAt response.Time `json:"created_at"`
+
+	FollowersCount int `json:"followers_count"`
 }
 
 func (u *User) ToResponse() UserResponse {
@@ -29,6 +37,8 @@ func (u *User) ToResponse() UserResponse {
 		Name:      u.Name,
 		Bio:       u.Bio,
 		Image:     u.Image,
-		CreatedAt: u.CreatedAt,
+		CreatedAt: response.NewTime(u.CreatedAt),
+
+		FollowersCount: u.FollowersCount,
 	}
 }
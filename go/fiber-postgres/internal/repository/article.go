@@ -43,6 +43,26 @@ func (r *ArticleRepository) FindBySlug(ctx context.Context, slug string) (*model
 	return row.ToArticle(), nil
 }
 
+// FindByRetiredSlug finds the article that used slug before a title change
+// gave it a new one.
+func (r *ArticleRepository) FindByRetiredSlug(ctx context.Context, slug string) (*models.Article, error) {
+	query := `
+		SELECT
+			a.id, a.slug, a.title, a.description, a.body, a.author_id,
+			a.favorites_count, a.created_at, a.updated_at,
+			u.name as author_name, u.email as author_email, u.bio as author_bio, u.image as author_image
+		FROM slug_history h
+		JOIN articles a ON h.article_id = a.id
+		JOIN users u ON a.author_id = u.id
+		WHERE h.slug = $1`
+
+	var row models.ArticleWithAuthor
+	if err := r.db.GetContext(ctx, &row, query, slug); err != nil {
+		return nil, err
+	}
+	return row.ToArticle(), nil
+}
+
 func (r *ArticleRepository) FindByID(ctx context.Context, id int) (*models.Article, error) {
 	query := `
 		SELECT
@@ -83,6 +103,51 @@ func (r *ArticleRepository) List(ctx context.Context, limit, offset int) ([]*mod
 	return articles, nil
 }
 
+// Feed returns up to limit articles by authors userID follows, newest
+// first, starting after cursor (from the beginning when nil). Each followed
+// author contributes at most limit rows through a LATERAL scan of
+// idx_articles_author_created, so the cost grows with the number of
+// followed authors and the page size, not with how many articles they have
+// written or how deep the page is.
+func (r *ArticleRepository) Feed(ctx context.Context, userID int, cursor *models.FeedCursor, limit int) ([]*models.Article, error) {
+	after := ""
+	args := []any{userID, limit}
+	if cursor != nil {
+		after = "AND (a.created_at, a.id) < ($3, $4)"
+		args = append(args, cursor.CreatedAt, cursor.ID)
+	}
+
+	query := `
+		SELECT
+			a.id, a.slug, a.title, a.description, a.body, a.author_id,
+			a.favorites_count, a.created_at, a.updated_at,
+			u.name as author_name, u.email as author_email, u.bio as author_bio, u.image as author_image
+		FROM follows f
+		CROSS JOIN LATERAL (
+			SELECT a.id, a.slug, a.title, a.description, a.body, a.author_id,
+				a.favorites_count, a.created_at, a.updated_at
+			FROM articles a
+			WHERE a.author_id = f.followee_id ` + after + `
+			ORDER BY a.created_at DESC, a.id DESC
+			LIMIT $2
+		) a
+		JOIN users u ON a.author_id = u.id
+		WHERE f.follower_id = $1
+		ORDER BY a.created_at DESC, a.id DESC
+		LIMIT $2`
+
+	var rows []models.ArticleWithAuthor
+	if err := r.db.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, err
+	}
+
+	articles := make([]*models.Article, len(rows))
+	for i, row := range rows {
+		articles[i] = row.ToArticle()
+	}
+	return articles, nil
+}
+
 func (r *ArticleRepository) Count(ctx context.Context) (int, error) {
 	var count int
 	query := `SELECT COUNT(*) FROM articles`
@@ -93,15 +158,89 @@ func (r *ArticleRepository) Count(ctx context.Context) (int, error) {
 	return count, nil
 }
 
+// Search ranks articles matching query by ts_rank_cd over the weighted
+// search_vector and returns a ts_headline snippet for each. query uses
+// websearch syntax: quoted phrases, OR and -exclusions.
+func (r *ArticleRepository) Search(ctx context.Context, query string, limit, offset int) ([]*models.ArticleSearchResult, error) {
+	sqlQuery := `
+		SELECT
+			a.id, a.slug, a.title, a.description, a.body, a.author_id,
+			a.favorites_count, a.created_at, a.updated_at,
+			u.name as author_name, u.email as author_email, u.bio as author_bio, u.image as author_image,
+			ts_rank_cd(a.search_vector, q) as rank,
+			ts_headline('english', coalesce(a.description, '') || ' ' || a.body, q,
+				'StartSel=<mark>, StopSel=</mark>, MaxWords=35, MinWords=15, MaxFragments=2') as snippet
+		FROM articles a
+		JOIN users u ON a.author_id = u.id,
+			websearch_to_tsquery('english', $1) q
+		WHERE a.search_vector @@ q
+		ORDER BY rank DESC, a.created_at DESC
+		LIMIT $2 OFFSET $3`
+
+	var rows []models.ArticleSearchRow
+	if err := r.db.SelectContext(ctx, &rows, sqlQuery, query, limit, offset); err != nil {
+		return nil, err
+	}
+
+	results := make([]*models.ArticleSearchResult, len(rows))
+	for i := range rows {
+		results[i] = rows[i].ToSearchResult()
+	}
+	return results, nil
+}
+
+func (r *ArticleRepository) CountSearch(ctx context.Context, query string) (int, error) {
+	var count int
+	sqlQuery := `
+		SELECT COUNT(*) FROM articles
+		WHERE search_vector @@ websearch_to_tsquery('english', $1)`
+
+	if err := r.db.GetContext(ctx, &count, sqlQuery, query); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// Update saves article. When its slug changes, the old slug is recorded in
+// slug_history in the same transaction, and a history row for the new slug
+// is dropped because the live slug now owns it.
 func (r *ArticleRepository) Update(ctx context.Context, article *models.Article) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var oldSlug string
+	if err := tx.GetContext(ctx, &oldSlug, `SELECT slug FROM articles WHERE id = $1 FOR UPDATE`, article.ID); err != nil {
+		return err
+	}
+
 	query := `
 		UPDATE articles SET title = $1, description = $2, body = $3, slug = $4, updated_at = NOW()
 		WHERE id = $5
 		RETURNING updated_at`
 
-	return r.db.QueryRowContext(ctx, query,
+	if err := tx.QueryRowContext(ctx, query,
 		article.Title, article.Description, article.Body, article.Slug, article.ID,
-	).Scan(&article.UpdatedAt)
+	).Scan(&article.UpdatedAt); err != nil {
+		return err
+	}
+
+	if oldSlug != article.Slug {
+		// A slug retired twice points at whichever article gave it up last.
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO slug_history (slug, article_id) VALUES ($1, $2)
+			ON CONFLICT (slug) DO UPDATE SET article_id = EXCLUDED.article_id, created_at = NOW()`,
+			oldSlug, article.ID); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM slug_history WHERE slug = $1`, article.Slug); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
 }
 
 func (r *ArticleRepository) Delete(ctx context.Context, id int) error {
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type FollowRepository struct {
+	db *sqlx.DB
+}
+
+func NewFollowRepository(db *sqlx.DB) *FollowRepository {
+	return &FollowRepository{db: db}
+}
+
+// Follow makes followerID follow followeeID and returns the followee's
+// followers_count. The count only moves when the follow is new, in the
+// same transaction as the insert. It returns sql.ErrNoRows when the
+// followee does not exist.
+func (r *FollowRepository) Follow(ctx context.Context, followerID, followeeID int) (int, error) {
+	return r.change(ctx, followeeID, `
+		INSERT INTO follows (follower_id, followee_id)
+		SELECT $1, id FROM users WHERE id = $2
+		ON CONFLICT DO NOTHING`, followerID, 1)
+}
+
+// Unfollow removes the follow, if any, and returns the followee's
+// followers_count.
+func (r *FollowRepository) Unfollow(ctx context.Context, followerID, followeeID int) (int, error) {
+	return r.change(ctx, followeeID, `
+		DELETE FROM follows WHERE follower_id = $1 AND followee_id = $2`, followerID, -1)
+}
+
+func (r *FollowRepository) change(ctx context.Context, followeeID int, query string, followerID, delta int) (int, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	result, err := tx.ExecContext(ctx, query, followerID, followeeID)
+	if err != nil {
+		return 0, err
+	}
+	changed, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if changed == 0 {
+		delta = 0
+	}
+
+	var count int
+	if err := tx.GetContext(ctx, &count, `
+		UPDATE users SET followers_count = GREATEST(followers_count + $1, 0)
+		WHERE id = $2
+		RETURNING followers_count`, delta, followeeID); err != nil {
+		return 0, err
+	}
+
+	return count, tx.Commit()
+}
+
+// FolloweeIDs lists the users followerID follows.
+func (r *FollowRepository) FolloweeIDs(ctx context.Context, followerID int) ([]int, error) {
+	ids := []int{}
+	query := `SELECT followee_id FROM follows WHERE follower_id = $1`
+
+	if err := r.db.SelectContext(ctx, &ids, query, followerID); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
@@ -3,12 +3,17 @@ package services
 import (
 	"context"
 	"database/sql"
+	"encoding/base64"
 	"errors"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/base-14/examples/go/pkg/milestone"
+	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 
 	"go-fiber-postgres/internal/logging"
 	"go-fiber-postgres/internal/models"
@@ -16,7 +21,23 @@ import (
 	"go-fiber-postgres/internal/telemetry"
 )
 
+// How a request for a retired slug reached its article: a redirect to the
+// current slug for reads, or a transparent lookup for writes.
+const (
+	SlugResolutionRedirect = "redirect"
+	SlugResolutionLookup   = "lookup"
+)
+
+// Feed strategies recorded as feed.strategy on article.feed.duration: the
+// keyset query the API serves, and the IN-list query cmd/feedbench
+// compares it with.
+const (
+	FeedStrategyKeyset  = "keyset"
+	FeedStrategyInQuery = "in_query"
+)
+
 var (
+	ErrInvalidCursor    = errors.New("invalid feed cursor")
 	ErrArticleNotFound  = errors.New("article not found")
 	ErrNotAuthor        = errors.New("not the author of this article")
 	ErrAlreadyFavorited = errors.New("article already favorited")
@@ -52,6 +73,18 @@ type ArticleListResult struct {
 	TotalCount int               `json:"total_count"`
 }
 
+type ArticleFeedResult struct {
+	Articles []*models.Article `json:"articles"`
+	// NextCursor fetches the following page; it is empty on the last one.
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+type ArticleSearchResult struct {
+	Query      string                        `json:"query"`
+	Articles   []*models.ArticleSearchResult `json:"articles"`
+	TotalCount int                           `json:"total_count"`
+}
+
 func (s *ArticleService) Create(ctx context.Context, authorID int, input CreateArticleInput) (*models.Article, error) {
 	ctx, span := telemetry.Tracer().Start(ctx, "article.create")
 	defer span.End()
@@ -84,9 +117,13 @@ func (s *ArticleService) Create(ctx context.Context, authorID int, input CreateA
 		return nil, err
 	}
 
-	telemetry.ArticlesCreated.Add(ctx, 1)
+	telemetry.ArticlesCreated.Add(ctx, 1, telemetry.WithAttributes(telemetry.UserBucket(authorID)))
 	span.SetStatus(codes.Ok, "article created")
-	logging.Info(ctx, "article created", "articleId", article.ID, "slug", slug)
+	logging.Milestone(ctx, milestone.Record(ctx, milestone.ArticlePublished,
+		milestone.ArticleID.Int(article.ID),
+		milestone.ArticleSlug.String(slug),
+		milestone.UserID.Int(authorID),
+	))
 
 	return s.articleRepo.FindByID(ctx, article.ID)
 }
@@ -110,6 +147,44 @@ func (s *ArticleService) GetBySlug(ctx context.Context, slug string, userID *int
 	return article, nil
 }
 
+// CurrentSlug returns the slug of the article that gave up slug in a title
+// change, so a read of an old link can be redirected to it.
+func (s *ArticleService) CurrentSlug(ctx context.Context, slug string) (string, error) {
+	article, err := s.articleRepo.FindByRetiredSlug(ctx, slug)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", ErrArticleNotFound
+		}
+		return "", err
+	}
+
+	recordSlugRedirect(ctx, SlugResolutionRedirect)
+	logging.Info(ctx, "redirecting retired slug", "slug", slug, "currentSlug", article.Slug)
+	return article.Slug, nil
+}
+
+// findBySlug finds an article by its current slug, falling back to slugs it
+// has retired so writes through an old link still reach it.
+func (s *ArticleService) findBySlug(ctx context.Context, slug string) (*models.Article, error) {
+	article, err := s.articleRepo.FindBySlug(ctx, slug)
+	if !errors.Is(err, sql.ErrNoRows) {
+		return article, err
+	}
+
+	article, err = s.articleRepo.FindByRetiredSlug(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+	recordSlugRedirect(ctx, SlugResolutionLookup)
+	return article, nil
+}
+
+func recordSlugRedirect(ctx context.Context, resolution string) {
+	telemetry.ArticleSlugRedirects.Add(ctx, 1,
+		telemetry.WithAttributes(attribute.String("slug.resolution", resolution)))
+	trace.SpanFromContext(ctx).SetAttributes(attribute.String("article.slug.resolution", resolution))
+}
+
 func (s *ArticleService) List(ctx context.Context, limit, offset int, userID *int) (*ArticleListResult, error) {
 	articles, err := s.articleRepo.List(ctx, limit, offset)
 	if err != nil {
@@ -140,11 +215,174 @@ func (s *ArticleService) List(ctx context.Context, limit, offset int, userID *in
 	}, nil
 }
 
+// Feed returns a page of articles by the authors userID follows, newest
+// first. cursor is the NextCursor of the previous page, or empty for the
+// first. Unlike List it pages by position rather than offset and returns no
+// total, so a deep page costs the same as the first.
+func (s *ArticleService) Feed(ctx context.Context, userID int, cursor string, limit int) (*ArticleFeedResult, error) {
+	ctx, span := telemetry.Tracer().Start(ctx, "article.feed")
+	defer span.End()
+
+	after, err := DecodeFeedCursor(cursor)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	page := "first"
+	if after != nil {
+		page = "next"
+	}
+	span.SetAttributes(
+		attribute.String("feed.strategy", FeedStrategyKeyset),
+		attribute.String("feed.page", page),
+		attribute.Int("feed.limit", limit),
+	)
+
+	start := time.Now()
+	// One extra row says whether there is a next page.
+	articles, err := s.articleRepo.Feed(ctx, userID, after, limit+1)
+	telemetry.ArticleFeedDuration.Record(ctx, float64(time.Since(start).Milliseconds()),
+		telemetry.WithAttributes(
+			attribute.String("feed.strategy", FeedStrategyKeyset),
+			attribute.String("feed.page", page),
+		))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to load feed")
+		logging.Error(ctx, "failed to load feed", "error", err)
+		return nil, err
+	}
+
+	result := &ArticleFeedResult{Articles: articles}
+	if len(articles) > limit {
+		result.Articles = articles[:limit]
+		last := result.Articles[limit-1]
+		result.NextCursor = EncodeFeedCursor(models.FeedCursor{CreatedAt: last.CreatedAt, ID: last.ID})
+	}
+
+	favoriteIDs, err := s.favoriteRepo.FindByUserID(ctx, userID)
+	if err == nil {
+		favoriteSet := make(map[int]bool)
+		for _, id := range favoriteIDs {
+			favoriteSet[id] = true
+		}
+		for _, article := range result.Articles {
+			article.Favorited = favoriteSet[article.ID]
+		}
+	}
+
+	span.SetAttributes(
+		attribute.Int("feed.results.returned", len(result.Articles)),
+		attribute.Bool("feed.has_next", result.NextCursor != ""),
+	)
+	span.SetStatus(codes.Ok, "feed loaded")
+
+	return result, nil
+}
+
+// EncodeFeedCursor makes the opaque next_cursor for a feed page ending at c.
+func EncodeFeedCursor(c models.FeedCursor) string {
+	raw := c.CreatedAt.UTC().Format(time.RFC3339Nano) + "," + strconv.Itoa(c.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeFeedCursor reads a cursor from EncodeFeedCursor. An empty cursor is
+// the start of the feed and decodes to nil.
+func DecodeFeedCursor(cursor string) (*models.FeedCursor, error) {
+	if cursor == "" {
+		return nil, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	ts, id, ok := strings.Cut(string(raw), ",")
+	if !ok {
+		return nil, ErrInvalidCursor
+	}
+	createdAt, err := time.Parse(time.RFC3339Nano, ts)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	articleID, err := strconv.Atoi(id)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return &models.FeedCursor{CreatedAt: createdAt, ID: articleID}, nil
+}
+
+func (s *ArticleService) Search(ctx context.Context, query string, limit, offset int, userID *int) (*ArticleSearchResult, error) {
+	ctx, span := telemetry.Tracer().Start(ctx, "article.search")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.Int("search.query.length", len(query)),
+		attribute.Int("search.limit", limit),
+		attribute.Int("search.offset", offset),
+	)
+
+	start := time.Now()
+	outcome := "error"
+	defer func() {
+		telemetry.ArticleSearchDuration.Record(ctx, float64(time.Since(start).Milliseconds()),
+			telemetry.WithAttributes(attribute.String("search.outcome", outcome)))
+	}()
+
+	articles, err := s.articleRepo.Search(ctx, query, limit, offset)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to search articles")
+		logging.Error(ctx, "failed to search articles", "error", err)
+		return nil, err
+	}
+
+	count, err := s.articleRepo.CountSearch(ctx, query)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to count search results")
+		logging.Error(ctx, "failed to count search results", "error", err)
+		return nil, err
+	}
+
+	if userID != nil {
+		favoriteIDs, err := s.favoriteRepo.FindByUserID(ctx, *userID)
+		if err == nil {
+			favoriteSet := make(map[int]bool)
+			for _, id := range favoriteIDs {
+				favoriteSet[id] = true
+			}
+			for _, article := range articles {
+				article.Favorited = favoriteSet[article.ID]
+			}
+		}
+	}
+
+	outcome = "hit"
+	if count == 0 {
+		outcome = "empty"
+	}
+	telemetry.ArticleSearchResults.Record(ctx, int64(count),
+		telemetry.WithAttributes(attribute.String("search.outcome", outcome)))
+	span.SetAttributes(
+		attribute.Int("search.results.total", count),
+		attribute.Int("search.results.returned", len(articles)),
+	)
+	span.SetStatus(codes.Ok, "articles searched")
+
+	return &ArticleSearchResult{
+		Query:      query,
+		Articles:   articles,
+		TotalCount: count,
+	}, nil
+}
+
 func (s *ArticleService) Update(ctx context.Context, slug string, userID int, input UpdateArticleInput) (*models.Article, error) {
 	ctx, span := telemetry.Tracer().Start(ctx, "article.update")
 	defer span.End()
 
-	article, err := s.articleRepo.FindBySlug(ctx, slug)
+	article, err := s.findBySlug(ctx, slug)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			span.RecordError(ErrArticleNotFound)
@@ -190,7 +428,7 @@ func (s *ArticleService) Delete(ctx context.Context, slug string, userID int) er
 	ctx, span := telemetry.Tracer().Start(ctx, "article.delete")
 	defer span.End()
 
-	article, err := s.articleRepo.FindBySlug(ctx, slug)
+	article, err := s.findBySlug(ctx, slug)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			span.RecordError(ErrArticleNotFound)
@@ -226,7 +464,7 @@ func (s *ArticleService) Favorite(ctx context.Context, slug string, userID int)
 	ctx, span := telemetry.Tracer().Start(ctx, "article.favorite")
 	defer span.End()
 
-	article, err := s.articleRepo.FindBySlug(ctx, slug)
+	article, err := s.findBySlug(ctx, slug)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			span.RecordError(ErrArticleNotFound)
@@ -269,18 +507,27 @@ func (s *ArticleService) Favorite(ctx context.Context, slug string, userID int)
 		return nil, err
 	}
 
-	telemetry.FavoritesAdded.Add(ctx, 1)
+	telemetry.FavoritesAdded.Add(ctx, 1, telemetry.WithAttributes(telemetry.UserBucket(userID)))
 	span.SetStatus(codes.Ok, "article favorited")
-	logging.Info(ctx, "article favorited", "articleId", article.ID, "userId", userID)
 
-	return s.articleRepo.FindByID(ctx, article.ID)
+	updated, err := s.articleRepo.FindByID(ctx, article.ID)
+	if err != nil {
+		return nil, err
+	}
+	logging.Milestone(ctx, milestone.Record(ctx, milestone.FavoriteAdded,
+		milestone.ArticleID.Int(article.ID),
+		milestone.ArticleSlug.String(article.Slug),
+		milestone.UserID.Int(userID),
+		milestone.ArticleFavoritesCount.Int(updated.FavoritesCount),
+	))
+	return updated, nil
 }
 
 func (s *ArticleService) Unfavorite(ctx context.Context, slug string, userID int) (*models.Article, error) {
 	ctx, span := telemetry.Tracer().Start(ctx, "article.unfavorite")
 	defer span.End()
 
-	article, err := s.articleRepo.FindBySlug(ctx, slug)
+	article, err := s.findBySlug(ctx, slug)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			span.RecordError(ErrArticleNotFound)
@@ -6,7 +6,9 @@ import (
 	"errors"
 	"time"
 
+	"github.com/base-14/examples/go/pkg/milestone"
 	"github.com/golang-jwt/jwt/v5"
+	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
 	"golang.org/x/crypto/bcrypt"
 
@@ -20,19 +22,24 @@ var (
 	ErrInvalidCredentials = errors.New("invalid credentials")
 	ErrEmailTaken         = errors.New("email already taken")
 	ErrUserNotFound       = errors.New("user not found")
+	ErrTokenRevoked       = errors.New("token revoked")
 )
 
 type AuthService struct {
 	userRepo  *repository.UserRepository
 	jwtSecret string
 	jwtExpiry time.Duration
+	tokens    *tokenCache
 }
 
-func NewAuthService(userRepo *repository.UserRepository, jwtSecret string, jwtExpiry time.Duration) *AuthService {
+// NewAuthService caches up to tokenCacheSize validated tokens; zero disables
+// the cache, though logout still revokes tokens.
+func NewAuthService(userRepo *repository.UserRepository, jwtSecret string, jwtExpiry time.Duration, tokenCacheSize int) *AuthService {
 	return &AuthService{
 		userRepo:  userRepo,
 		jwtSecret: jwtSecret,
 		jwtExpiry: jwtExpiry,
+		tokens:    newTokenCache(tokenCacheSize),
 	}
 }
 
@@ -99,7 +106,9 @@ func (s *AuthService) Register(ctx context.Context, input RegisterInput) (*AuthR
 	}
 
 	span.SetStatus(codes.Ok, "user registered")
-	logging.Info(ctx, "user registered", "userId", user.ID, "email", user.Email)
+	logging.Milestone(ctx, milestone.Record(ctx, milestone.UserRegistered,
+		milestone.UserID.Int(user.ID),
+	))
 
 	return &AuthResponse{
 		User:  user.ToResponse(),
@@ -169,22 +178,78 @@ func (s *AuthService) generateToken(userID int) (string, error) {
 	return token.SignedString([]byte(s.jwtSecret))
 }
 
-func (s *AuthService) ValidateToken(tokenString string) (int, error) {
+// ValidateToken returns the user a token belongs to. Tokens seen before are
+// answered from the cache until they expire; a logged-out token fails with
+// ErrTokenRevoked.
+func (s *AuthService) ValidateToken(ctx context.Context, tokenString string) (int, error) {
+	now := time.Now()
+	if entry, ok := s.tokens.get(tokenString, now); ok {
+		if entry.revoked {
+			recordTokenCache(ctx, "revoked")
+			return 0, ErrTokenRevoked
+		}
+		recordTokenCache(ctx, "hit")
+		return entry.userID, nil
+	}
+	recordTokenCache(ctx, "miss")
+
+	userID, expiresAt, err := s.parseToken(tokenString)
+	if err != nil {
+		return 0, err
+	}
+	s.tokens.put(tokenString, userID, expiresAt, now)
+	return userID, nil
+}
+
+// Logout revokes a token for the rest of its lifetime.
+func (s *AuthService) Logout(ctx context.Context, tokenString string) error {
+	ctx, span := telemetry.Tracer().Start(ctx, "user.logout")
+	defer span.End()
+
+	userID, expiresAt, err := s.parseToken(tokenString)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "invalid token")
+		return err
+	}
+	s.tokens.revoke(tokenString, userID, expiresAt, time.Now())
+
+	span.SetStatus(codes.Ok, "user logged out")
+	logging.Info(ctx, "user logged out", "userId", userID)
+	return nil
+}
+
+// parseToken verifies a token's signature and expiry and returns its user
+// and expiry time.
+func (s *AuthService) parseToken(tokenString string) (int, time.Time, error) {
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, errors.New("unexpected signing method")
 		}
 		return []byte(s.jwtSecret), nil
-	})
+	}, jwt.WithExpirationRequired())
 
 	if err != nil {
-		return 0, err
+		return 0, time.Time{}, err
 	}
 
-	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		userID := int(claims["user_id"].(float64))
-		return userID, nil
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return 0, time.Time{}, errors.New("invalid token")
+	}
+	userID, ok := claims["user_id"].(float64)
+	if !ok {
+		return 0, time.Time{}, errors.New("invalid token")
 	}
+	exp, err := claims.GetExpirationTime()
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	return int(userID), exp.Time, nil
+}
 
-	return 0, errors.New("invalid token")
+func recordTokenCache(ctx context.Context, result string) {
+	telemetry.AuthTokenCache.Add(ctx, 1, telemetry.WithAttributes(
+		attribute.String("cache.result", result),
+	))
 }
@@ -0,0 +1,91 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+
+	"go-fiber-postgres/internal/logging"
+	"go-fiber-postgres/internal/repository"
+	"go-fiber-postgres/internal/telemetry"
+)
+
+var ErrFollowSelf = errors.New("cannot follow yourself")
+
+type FollowService struct {
+	followRepo *repository.FollowRepository
+}
+
+func NewFollowService(followRepo *repository.FollowRepository) *FollowService {
+	return &FollowService{followRepo: followRepo}
+}
+
+// FollowResult is the followed user's state after a follow or unfollow.
+type FollowResult struct {
+	UserID         int  `json:"user_id"`
+	Following      bool `json:"following"`
+	FollowersCount int  `json:"followers_count"`
+}
+
+// Follow adds followeeID to the authors whose articles are in
+// followerID's feed. Following someone twice is not an error.
+func (s *FollowService) Follow(ctx context.Context, followerID, followeeID int) (*FollowResult, error) {
+	ctx, span := telemetry.Tracer().Start(ctx, "user.follow")
+	defer span.End()
+
+	span.SetAttributes(attribute.Int("user.followee_id", followeeID))
+
+	if followerID == followeeID {
+		span.RecordError(ErrFollowSelf)
+		span.SetStatus(codes.Error, ErrFollowSelf.Error())
+		return nil, ErrFollowSelf
+	}
+
+	count, err := s.followRepo.Follow(ctx, followerID, followeeID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			span.RecordError(ErrUserNotFound)
+			span.SetStatus(codes.Error, ErrUserNotFound.Error())
+			return nil, ErrUserNotFound
+		}
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to follow user")
+		logging.Error(ctx, "failed to follow user", "error", err)
+		return nil, err
+	}
+
+	telemetry.FollowsAdded.Add(ctx, 1)
+	span.SetStatus(codes.Ok, "user followed")
+	logging.Info(ctx, "user followed", "userId", followerID, "followeeId", followeeID)
+
+	return &FollowResult{UserID: followeeID, Following: true, FollowersCount: count}, nil
+}
+
+func (s *FollowService) Unfollow(ctx context.Context, followerID, followeeID int) (*FollowResult, error) {
+	ctx, span := telemetry.Tracer().Start(ctx, "user.unfollow")
+	defer span.End()
+
+	span.SetAttributes(attribute.Int("user.followee_id", followeeID))
+
+	count, err := s.followRepo.Unfollow(ctx, followerID, followeeID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			span.RecordError(ErrUserNotFound)
+			span.SetStatus(codes.Error, ErrUserNotFound.Error())
+			return nil, ErrUserNotFound
+		}
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to unfollow user")
+		logging.Error(ctx, "failed to unfollow user", "error", err)
+		return nil, err
+	}
+
+	telemetry.FollowsRemoved.Add(ctx, 1)
+	span.SetStatus(codes.Ok, "user unfollowed")
+	logging.Info(ctx, "user unfollowed", "userId", followerID, "followeeId", followeeID)
+
+	return &FollowResult{UserID: followeeID, Following: false, FollowersCount: count}, nil
+}
@@ -0,0 +1,140 @@
+package services
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"sync"
+	"time"
+)
+
+type tokenKey [sha256.Size]byte
+
+type tokenEntry struct {
+	userID    int
+	expiresAt time.Time
+	revoked   bool
+}
+
+type cachedToken struct {
+	key tokenKey
+	tokenEntry
+}
+
+// tokenCache remembers validated tokens until they expire, so a token is
+// parsed and its signature checked once rather than on every request. It
+// also holds revoked tokens until their expiry, which is what makes logout
+// stick. Keys are token hashes, so the cache never holds a usable token.
+//
+// Validated tokens are kept in least-recently-used order and the oldest is
+// evicted when the cache is full. Revocations are kept apart in the order
+// they were made and dropped from the front once expired; no token outlives
+// the JWT expiry, so they are bounded by the logouts within one expiry.
+//
+// The cache is per process: a token revoked on one API instance is still
+// accepted by the others.
+type tokenCache struct {
+	maxEntries int
+
+	mu      sync.Mutex
+	entries map[tokenKey]*list.Element
+	lru     *list.List // of *cachedToken, most recently used first
+	revoked map[tokenKey]*list.Element
+	revokes *list.List // of *cachedToken, oldest revocation first
+}
+
+func newTokenCache(maxEntries int) *tokenCache {
+	return &tokenCache{
+		maxEntries: maxEntries,
+		entries:    make(map[tokenKey]*list.Element),
+		lru:        list.New(),
+		revoked:    make(map[tokenKey]*list.Element),
+		revokes:    list.New(),
+	}
+}
+
+// get returns the entry for token if it has one that has not expired.
+// Revocations are checked first.
+func (tc *tokenCache) get(token string, now time.Time) (tokenEntry, bool) {
+	key := tokenKey(sha256.Sum256([]byte(token)))
+
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+
+	tc.expireRevocations(now)
+	if el, ok := tc.revoked[key]; ok {
+		return el.Value.(*cachedToken).tokenEntry, true
+	}
+
+	el, ok := tc.entries[key]
+	if !ok {
+		return tokenEntry{}, false
+	}
+	entry := el.Value.(*cachedToken)
+	if !now.Before(entry.expiresAt) {
+		tc.lru.Remove(el)
+		delete(tc.entries, key)
+		return tokenEntry{}, false
+	}
+	tc.lru.MoveToFront(el)
+	return entry.tokenEntry, true
+}
+
+// put caches a validated token, evicting the least recently used one when
+// the cache is full.
+func (tc *tokenCache) put(token string, userID int, expiresAt, now time.Time) {
+	if tc.maxEntries <= 0 || !now.Before(expiresAt) {
+		return
+	}
+	key := tokenKey(sha256.Sum256([]byte(token)))
+
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+
+	if _, ok := tc.entries[key]; ok {
+		return
+	}
+	if tc.lru.Len() >= tc.maxEntries {
+		oldest := tc.lru.Back()
+		tc.lru.Remove(oldest)
+		delete(tc.entries, oldest.Value.(*cachedToken).key)
+	}
+	tc.entries[key] = tc.lru.PushFront(&cachedToken{
+		key:        key,
+		tokenEntry: tokenEntry{userID: userID, expiresAt: expiresAt},
+	})
+}
+
+// revoke marks token as logged out until it expires. Revocations do not
+// count against maxEntries, since evicting one would undo the logout.
+func (tc *tokenCache) revoke(token string, userID int, expiresAt, now time.Time) {
+	key := tokenKey(sha256.Sum256([]byte(token)))
+
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+
+	tc.expireRevocations(now)
+	if el, ok := tc.entries[key]; ok {
+		tc.lru.Remove(el)
+		delete(tc.entries, key)
+	}
+	if _, ok := tc.revoked[key]; ok {
+		return
+	}
+	tc.revoked[key] = tc.revokes.PushBack(&cachedToken{
+		key:        key,
+		tokenEntry: tokenEntry{userID: userID, expiresAt: expiresAt, revoked: true},
+	})
+}
+
+// expireRevocations drops expired revocations from the front of the list.
+// One that expires before an older one waits until it reaches the front.
+func (tc *tokenCache) expireRevocations(now time.Time) {
+	for el := tc.revokes.Front(); el != nil; el = tc.revokes.Front() {
+		entry := el.Value.(*cachedToken)
+		if now.Before(entry.expiresAt) {
+			return
+		}
+		tc.revokes.Remove(el)
+		delete(tc.revoked, entry.key)
+	}
+}
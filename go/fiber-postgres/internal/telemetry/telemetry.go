@@ -2,13 +2,19 @@ package telemetry
 
 import (
 	"context"
+	"fmt"
+	"log/slog"
 	"time"
 
+	"github.com/base-14/examples/go/pkg/buildinfo"
+	"github.com/base-14/examples/go/pkg/metricattr"
+	"github.com/prometheus/client_golang/prometheus"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploghttp"
 	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	otelprometheus "go.opentelemetry.io/otel/exporters/prometheus"
 	"go.opentelemetry.io/otel/log/global"
 	"go.opentelemetry.io/otel/metric"
 	"go.opentelemetry.io/otel/propagation"
@@ -31,9 +37,33 @@ var (
 	JobsEnqueued     metric.Int64Counter
 	JobsCompleted    metric.Int64Counter
 	JobsFailed       metric.Int64Counter
+	JobsQueueLatency metric.Float64Histogram
+	JobsDuration     metric.Float64Histogram
 
-	HTTPRequestsTotal   metric.Int64Counter
-	HTTPRequestDuration metric.Float64Histogram
+	ArticleSearchDuration metric.Float64Histogram
+	ArticleSearchResults  metric.Int64Histogram
+	ArticleSlugRedirects  metric.Int64Counter
+	ArticleFeedDuration   metric.Float64Histogram
+	FollowsAdded          metric.Int64Counter
+	FollowsRemoved        metric.Int64Counter
+
+	HTTPRequestsTotal    metric.Int64Counter
+	HTTPRequestDuration  metric.Float64Histogram
+	HTTPDeadlineExceeded metric.Int64Counter
+
+	DBQueriesPerRequest      metric.Int64Histogram
+	DBQueryThresholdExceeded metric.Int64Counter
+
+	CacheResponses metric.Int64Counter
+
+	RateLimitQueueWait metric.Float64Histogram
+	RateLimitRejected  metric.Int64Counter
+
+	CORSRejected metric.Int64Counter
+
+	AuthTokenCache metric.Int64Counter
+
+	MigrationLockWait metric.Float64Histogram
 )
 
 type Telemetry struct {
@@ -42,11 +72,52 @@ type Telemetry struct {
 	LoggerProvider *sdklog.LoggerProvider
 }
 
-func Init(ctx context.Context, serviceName, otlpEndpoint string) (*Telemetry, error) {
+type options struct {
+	promRegistry *prometheus.Registry
+	metricIDSalt string
+}
+
+type Option func(*options)
+
+// WithPrometheus adds a Prometheus exporter as a second metric reader,
+// registering the same instruments in registry for scraping. OTLP export is
+// unchanged, so both pipelines can be compared side by side.
+func WithPrometheus(registry *prometheus.Registry) Option {
+	return func(o *options) {
+		o.promRegistry = registry
+	}
+}
+
+// WithMetricIDSalt keys the hash behind UserBucket. Use the same salt on
+// every replica so their buckets line up.
+func WithMetricIDSalt(salt string) Option {
+	return func(o *options) {
+		o.metricIDSalt = salt
+	}
+}
+
+// metricIDs turns user IDs into metric attributes; Init rekeys it.
+var metricIDs = metricattr.New("", 0)
+
+// UserBucket is the attribute for a user on a metric: one of
+// metricattr.DefaultBuckets buckets, never the raw ID, which belongs on
+// spans and logs only.
+func UserBucket(userID int) attribute.KeyValue {
+	return metricIDs.Bucket("user.bucket", fmt.Sprint(userID))
+}
+
+func Init(ctx context.Context, serviceName, otlpEndpoint string, opts ...Option) (*Telemetry, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	metricIDs = metricattr.New(o.metricIDSalt, 0)
+
 	res, err := resource.New(ctx,
 		resource.WithAttributes(
 			semconv.ServiceNameKey.String(serviceName),
-			semconv.ServiceVersionKey.String("1.0.0"),
+			semconv.ServiceVersionKey.String(buildinfo.Version),
+			semconv.ServiceInstanceIDKey.String(buildinfo.InstanceID),
 		),
 	)
 	if err != nil {
@@ -79,10 +150,25 @@ func Init(ctx context.Context, serviceName, otlpEndpoint string) (*Telemetry, er
 		return nil, err
 	}
 
-	mp := sdkmetric.NewMeterProvider(
+	mpOpts := []sdkmetric.Option{
 		sdkmetric.WithReader(sdkmetric.NewPeriodicReader(metricExporter, sdkmetric.WithInterval(15*time.Second))),
 		sdkmetric.WithResource(res),
-	)
+		// Warn, once per instrument and key, about user IDs and other
+		// high-cardinality attributes recorded on a metric.
+		sdkmetric.WithView(metricattr.Guard(metricattr.GuardConfig{OnWarning: func(w metricattr.Warning) {
+			slog.Warn("high-cardinality metric attribute",
+				"instrument", w.Instrument, "key", w.Key, "reason", w.Reason, "values", w.Values)
+		}})),
+	}
+	if o.promRegistry != nil {
+		promExporter, err := otelprometheus.New(otelprometheus.WithRegisterer(o.promRegistry))
+		if err != nil {
+			return nil, err
+		}
+		mpOpts = append(mpOpts, sdkmetric.WithReader(promExporter))
+	}
+
+	mp := sdkmetric.NewMeterProvider(mpOpts...)
 	otel.SetMeterProvider(mp)
 
 	logExporter, err := otlploghttp.New(ctx,
@@ -158,6 +244,65 @@ func initMetrics() error {
 		return err
 	}
 
+	JobsQueueLatency, err = meter.Float64Histogram("jobs.queue.latency",
+		metric.WithDescription("Time jobs waited between becoming available and being picked up"),
+		metric.WithUnit("ms"),
+		metric.WithExplicitBucketBoundaries(5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000, 30000, 60000))
+	if err != nil {
+		return err
+	}
+
+	JobsDuration, err = meter.Float64Histogram("jobs.duration",
+		metric.WithDescription("Job execution time"),
+		metric.WithUnit("ms"),
+		metric.WithExplicitBucketBoundaries(1, 5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000))
+	if err != nil {
+		return err
+	}
+
+	ArticleSearchDuration, err = meter.Float64Histogram("article.search.duration",
+		metric.WithDescription("Full-text article search latency, including the count query"),
+		metric.WithUnit("ms"),
+		metric.WithExplicitBucketBoundaries(1, 2, 5, 10, 25, 50, 100, 250, 500, 1000, 2500))
+	if err != nil {
+		return err
+	}
+
+	ArticleSearchResults, err = meter.Int64Histogram("article.search.results",
+		metric.WithDescription("Number of articles matching a search query"),
+		metric.WithUnit("{article}"),
+		metric.WithExplicitBucketBoundaries(0, 1, 5, 10, 25, 50, 100, 250, 1000))
+	if err != nil {
+		return err
+	}
+
+	ArticleSlugRedirects, err = meter.Int64Counter("article.slug.redirects",
+		metric.WithDescription("Requests that found an article by a slug retired by a title change, by slug.resolution"),
+		metric.WithUnit("{request}"))
+	if err != nil {
+		return err
+	}
+
+	ArticleFeedDuration, err = meter.Float64Histogram("article.feed.duration",
+		metric.WithDescription("Followed-authors feed query latency, by feed.strategy and feed.page"),
+		metric.WithUnit("ms"),
+		metric.WithExplicitBucketBoundaries(1, 2, 5, 10, 25, 50, 100, 250, 500, 1000, 2500))
+	if err != nil {
+		return err
+	}
+
+	FollowsAdded, err = meter.Int64Counter("follows.added",
+		metric.WithDescription("Total number of follows added"))
+	if err != nil {
+		return err
+	}
+
+	FollowsRemoved, err = meter.Int64Counter("follows.removed",
+		metric.WithDescription("Total number of follows removed"))
+	if err != nil {
+		return err
+	}
+
 	HTTPRequestsTotal, err = meter.Int64Counter("http.requests.total",
 		metric.WithDescription("Total number of HTTP requests"),
 		metric.WithUnit("{request}"))
@@ -173,6 +318,72 @@ func initMetrics() error {
 		return err
 	}
 
+	HTTPDeadlineExceeded, err = meter.Int64Counter("http.server.request.deadline_exceeded",
+		metric.WithDescription("Requests whose context deadline expired before the handler finished"),
+		metric.WithUnit("{request}"))
+	if err != nil {
+		return err
+	}
+
+	DBQueriesPerRequest, err = meter.Int64Histogram("http.server.db.queries",
+		metric.WithDescription("Database queries executed per HTTP request"),
+		metric.WithUnit("{query}"),
+		metric.WithExplicitBucketBoundaries(0, 1, 2, 3, 5, 8, 13, 21, 34, 55, 100))
+	if err != nil {
+		return err
+	}
+
+	DBQueryThresholdExceeded, err = meter.Int64Counter("http.server.db.query_threshold_exceeded",
+		metric.WithDescription("Requests that ran more database queries than the warning threshold"),
+		metric.WithUnit("{request}"))
+	if err != nil {
+		return err
+	}
+
+	CacheResponses, err = meter.Int64Counter("http.server.cache.responses",
+		metric.WithDescription("Responses by cacheability and Cache-Control visibility"),
+		metric.WithUnit("{response}"))
+	if err != nil {
+		return err
+	}
+
+	RateLimitQueueWait, err = meter.Float64Histogram("ratelimit.queue.wait",
+		metric.WithDescription("Time requests spent queued by the rate limiter"),
+		metric.WithUnit("ms"),
+		metric.WithExplicitBucketBoundaries(1, 5, 10, 25, 50, 100, 250, 500, 1000, 2000, 5000))
+	if err != nil {
+		return err
+	}
+
+	RateLimitRejected, err = meter.Int64Counter("ratelimit.rejected",
+		metric.WithDescription("Requests rejected because the queue was full or the wait timed out"),
+		metric.WithUnit("{request}"))
+	if err != nil {
+		return err
+	}
+
+	CORSRejected, err = meter.Int64Counter("http.server.cors.rejected",
+		metric.WithDescription("Cross-origin requests rejected by the CORS policy"),
+		metric.WithUnit("{request}"))
+	if err != nil {
+		return err
+	}
+
+	AuthTokenCache, err = meter.Int64Counter("auth.token.cache",
+		metric.WithDescription("JWT validations by token cache result: hit, miss or revoked"),
+		metric.WithUnit("{lookup}"))
+	if err != nil {
+		return err
+	}
+
+	MigrationLockWait, err = meter.Float64Histogram("db.migration.lock.wait",
+		metric.WithDescription("Time spent waiting for the migration advisory lock at startup"),
+		metric.WithUnit("ms"),
+		metric.WithExplicitBucketBoundaries(1, 10, 100, 500, 1000, 2500, 5000, 10000, 30000, 60000))
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
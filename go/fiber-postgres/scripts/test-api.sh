@@ -123,6 +123,12 @@ print_result "GET /api/articles" "200" "$STATUS"
 STATUS=$(curl -s -o /dev/null -w "%{http_code}" "$BASE_URL/api/articles?limit=5&offset=0")
 print_result "GET /api/articles (with pagination)" "200" "$STATUS"
 
+STATUS=$(curl -s -o /dev/null -w "%{http_code}" "$BASE_URL/api/articles/search?q=test")
+print_result "GET /api/articles/search" "200" "$STATUS"
+
+STATUS=$(curl -s -o /dev/null -w "%{http_code}" "$BASE_URL/api/articles/search")
+print_result "GET /api/articles/search (missing q)" "400" "$STATUS"
+
 echo ""
 echo "7. Get Single Article"
 
@@ -143,8 +149,12 @@ STATUS=$(echo "$RESPONSE" | tail -1)
 BODY=$(echo "$RESPONSE" | sed '$d')
 print_result "PUT /api/articles/:slug (owner)" "200" "$STATUS"
 
+OLD_SLUG=$SLUG
 SLUG=$(echo "$BODY" | grep -o '"slug":"[^"]*"' | cut -d'"' -f4)
 
+STATUS=$(curl -s -o /dev/null -w "%{http_code}" "$BASE_URL/api/articles/$OLD_SLUG")
+print_result "GET /api/articles/:slug (retired slug redirects)" "301" "$STATUS"
+
 STATUS=$(curl -s -o /dev/null -w "%{http_code}" -X PUT "$BASE_URL/api/articles/$SLUG" \
     -H "Content-Type: application/json" \
     -d '{"title":"Should Fail"}')
@@ -173,7 +183,43 @@ STATUS=$(curl -s -o /dev/null -w "%{http_code}" -X DELETE "$BASE_URL/api/article
 print_result "DELETE /api/articles/:slug/favorite (not favorited)" "409" "$STATUS"
 
 echo ""
-echo "11. Delete Article"
+echo "11. Follows and Feed"
+
+AUTHOR_ID=$(curl -s "$BASE_URL/api/user" -H "Authorization: Bearer $TOKEN" | grep -o '"id":[0-9]*' | head -1 | cut -d: -f2)
+
+RESPONSE=$(curl -s -w "\n%{http_code}" -X POST "$BASE_URL/api/register" \
+    -H "Content-Type: application/json" \
+    -d "{\"email\":\"reader${TIMESTAMP}@example.com\",\"password\":\"password123\",\"name\":\"Reader\"}")
+READER_TOKEN=$(echo "$RESPONSE" | sed '$d' | grep -o '"token":"[^"]*"' | cut -d'"' -f4)
+
+STATUS=$(curl -s -o /dev/null -w "%{http_code}" -X POST "$BASE_URL/api/users/$AUTHOR_ID/follow" \
+    -H "Authorization: Bearer $READER_TOKEN")
+print_result "POST /api/users/:id/follow" "200" "$STATUS"
+
+STATUS=$(curl -s -o /dev/null -w "%{http_code}" -X POST "$BASE_URL/api/users/$AUTHOR_ID/follow" \
+    -H "Authorization: Bearer $TOKEN")
+print_result "POST /api/users/:id/follow (self)" "400" "$STATUS"
+
+FEED=$(curl -s "$BASE_URL/api/articles/feed" -H "Authorization: Bearer $READER_TOKEN")
+if echo "$FEED" | grep -q "\"slug\":\"$SLUG\""; then
+    print_result "GET /api/articles/feed (followed author's article)" "present" "present"
+else
+    print_result "GET /api/articles/feed (followed author's article)" "present" "missing"
+fi
+
+STATUS=$(curl -s -o /dev/null -w "%{http_code}" "$BASE_URL/api/articles/feed?cursor=not-a-cursor" \
+    -H "Authorization: Bearer $READER_TOKEN")
+print_result "GET /api/articles/feed (invalid cursor)" "400" "$STATUS"
+
+STATUS=$(curl -s -o /dev/null -w "%{http_code}" "$BASE_URL/api/articles/feed")
+print_result "GET /api/articles/feed (no token)" "401" "$STATUS"
+
+STATUS=$(curl -s -o /dev/null -w "%{http_code}" -X DELETE "$BASE_URL/api/users/$AUTHOR_ID/follow" \
+    -H "Authorization: Bearer $READER_TOKEN")
+print_result "DELETE /api/users/:id/follow" "200" "$STATUS"
+
+echo ""
+echo "12. Delete Article"
 
 STATUS=$(curl -s -o /dev/null -w "%{http_code}" -X DELETE "$BASE_URL/api/articles/$SLUG" \
     -H "Authorization: Bearer $TOKEN")
@@ -183,12 +229,16 @@ STATUS=$(curl -s -o /dev/null -w "%{http_code}" "$BASE_URL/api/articles/$SLUG")
 print_result "GET /api/articles/:slug (after delete)" "404" "$STATUS"
 
 echo ""
-echo "12. Logout"
+echo "13. Logout"
 
 STATUS=$(curl -s -o /dev/null -w "%{http_code}" -X POST "$BASE_URL/api/logout" \
     -H "Authorization: Bearer $TOKEN")
 print_result "POST /api/logout" "200" "$STATUS"
 
+STATUS=$(curl -s -o /dev/null -w "%{http_code}" "$BASE_URL/api/user" \
+    -H "Authorization: Bearer $TOKEN")
+print_result "GET /api/user (after logout)" "401" "$STATUS"
+
 echo ""
 echo -e "${YELLOW}=== Test Summary ===${NC}"
 echo -e "Passed: ${GREEN}$PASSED${NC}"
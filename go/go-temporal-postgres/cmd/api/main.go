@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	echomiddleware "github.com/labstack/echo/v4/middleware"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/labstack/echo/otelecho"
+
+	"github.com/base-14/examples/go/go-temporal-postgres/config"
+	"github.com/base-14/examples/go/go-temporal-postgres/internal/database"
+	"github.com/base-14/examples/go/go-temporal-postgres/internal/handlers"
+	"github.com/base-14/examples/go/go-temporal-postgres/internal/telemetry"
+	pkgtemporal "github.com/base-14/examples/go/go-temporal-postgres/pkg/temporal"
+	"github.com/base-14/examples/go/pkg/buildinfo"
+	"github.com/base-14/examples/go/pkg/depwait"
+	"github.com/base-14/examples/go/pkg/healthcheck"
+	"github.com/base-14/examples/go/pkg/shutdown"
+)
+
+func main() {
+	if err := run(); err != nil {
+		slog.Error("application error", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	ctx := context.Background()
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	healthcheck.Main(depwait.Dependency{Name: "api", Check: healthcheck.HTTP(healthcheck.Local(cfg.Port, "/api/health"))})
+
+	shutdownTelemetry, err := telemetry.Init(ctx, telemetry.Config{
+		ServiceName:    cfg.OTelServiceName,
+		ServiceVersion: buildinfo.Version,
+		Environment:    cfg.Environment,
+		Endpoint:       cfg.OTelEndpoint,
+		Export:         telemetry.ExportConfigFromEnv(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to initialize telemetry: %w", err)
+	}
+	// Everything registered below is stopped in phase order on return,
+	// with telemetry flushed last.
+	sd := shutdown.New(shutdown.Config{OnClose: logShutdown})
+	sd.Add(shutdown.PhaseTelemetry, "telemetry", shutdownTelemetry)
+	defer func() {
+		if err := sd.Shutdown(context.Background()); err != nil {
+			slog.Error("shutdown finished with errors", slog.String("error", err.Error()))
+		}
+	}()
+
+	db, err := database.New(database.Config{DatabaseURL: cfg.DatabaseURL})
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	sd.Add(shutdown.PhaseClients, "postgres", func(context.Context) error { return database.Close(db) })
+	if err := database.Migrate(db); err != nil {
+		return fmt.Errorf("failed to migrate database: %w", err)
+	}
+	if err := database.Seed(db); err != nil {
+		return fmt.Errorf("failed to seed database: %w", err)
+	}
+
+	temporalCfg := pkgtemporal.ClientConfig{
+		HostPort: cfg.TemporalHost,
+		Retry:    pkgtemporal.RetryConfigFromEnv(),
+	}
+	temporalClient, err := pkgtemporal.NewClient(ctx, temporalCfg)
+	if err != nil {
+		return fmt.Errorf("failed to create Temporal client: %w", err)
+	}
+	sd.Add(shutdown.PhaseClients, "temporal client", shutdown.Func(temporalClient.Close))
+	stopHealth := pkgtemporal.WatchHealth(temporalClient, temporalCfg)
+	sd.Add(shutdown.PhaseWorkers, "temporal health", shutdown.Func(stopHealth))
+
+	healthHandler := handlers.NewHealthHandler()
+	productHandler := handlers.NewProductHandler(db)
+	orderHandler := handlers.NewOrderHandler(db, temporalClient, cfg.TemporalTaskQueue).
+		WithDuplicateWindow(cfg.DuplicateOrderWindow)
+	searchHandler := handlers.NewOrderSearchHandler(db)
+	inventoryHandler := handlers.NewInventoryHandler(db)
+	customerHandler := handlers.NewCustomerHandler(db, temporalClient)
+
+	e := echo.New()
+	e.HideBanner = true
+	e.HidePort = true
+
+	e.Use(echomiddleware.Recover())
+	e.Use(echomiddleware.RequestID())
+	e.Use(otelecho.Middleware(cfg.OTelServiceName, otelecho.WithSkipper(func(c echo.Context) bool {
+		return c.Path() == "/api/health" || c.Path() == "/version"
+	})))
+
+	e.GET("/api/health", healthHandler.Check)
+	e.GET("/version", handlers.Version)
+
+	api := e.Group("/api")
+	api.GET("/products", productHandler.List)
+	api.GET("/products/:id", productHandler.Get)
+
+	// /orders/search is registered before /orders/:id so it is not read as
+	// an order ID.
+	api.GET("/orders/search", searchHandler.Search)
+	api.POST("/orders", orderHandler.Create)
+	api.GET("/orders", orderHandler.List)
+	api.GET("/orders/:id", orderHandler.Get)
+	api.DELETE("/orders/:id", orderHandler.Cancel)
+	api.GET("/orders/:id/status", orderHandler.Status)
+	api.POST("/orders/:id/notes", orderHandler.AddNote)
+	api.GET("/orders/:id/notes", orderHandler.Notes)
+	api.GET("/orders/:id/notifications", orderHandler.Notifications)
+
+	api.PUT("/customers/:customer_id/notification-preferences", customerHandler.SetNotificationPreference)
+	api.GET("/customers/:customer_id/notification-preferences", customerHandler.NotificationPreferences)
+	api.POST("/customers/:customer_id/purge", customerHandler.Purge)
+	api.GET("/customers/:customer_id/purge", customerHandler.PurgeStatus)
+
+	api.GET("/admin/reorder-suggestions", inventoryHandler.ReorderSuggestions)
+
+	serverErr := make(chan error, 1)
+	go func() {
+		if err := e.Start(":" + cfg.Port); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+		}
+	}()
+	sd.Add(shutdown.PhaseWorkers, "http server", func(ctx context.Context) error {
+		ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		defer cancel()
+		return e.Shutdown(ctx)
+	})
+
+	slog.Info("api server is running",
+		slog.String("port", cfg.Port),
+		slog.String("temporal_host", cfg.TemporalHost),
+		slog.String("task_queue", cfg.TemporalTaskQueue),
+		slog.String("environment", cfg.Environment),
+	)
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+
+	select {
+	case err := <-serverErr:
+		return fmt.Errorf("server error: %w", err)
+	case <-sigCh:
+	}
+
+	slog.Info("shutting down api server")
+	return nil
+}
+
+func logShutdown(ev shutdown.Event) {
+	attrs := []any{
+		slog.String("closer", ev.Name),
+		slog.String("phase", ev.Phase.String()),
+		slog.Duration("duration", ev.Duration),
+	}
+	if ev.Err != nil {
+		slog.Error("shutdown step failed", append(attrs, slog.String("error", ev.Err.Error()))...)
+		return
+	}
+	slog.Info("shutdown step done", attrs...)
+}
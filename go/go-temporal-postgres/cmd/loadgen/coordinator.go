@@ -0,0 +1,374 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// startDelay gives every worker time to fetch its assignment, so all
+	// instances begin together.
+	startDelay = 2 * time.Second
+
+	// resultGrace is how long past the expected end of the run the
+	// coordinator waits for missing results before reporting without them.
+	resultGrace = time.Minute
+
+	assignmentPoll  = 500 * time.Millisecond
+	registerTimeout = time.Minute
+)
+
+// assignment is a worker's share of the run.
+type assignment struct {
+	Worker  int        `json:"worker"`
+	Of      int        `json:"of"`
+	Config  loadConfig `json:"config"`
+	StartAt time.Time  `json:"start_at"`
+}
+
+// coordinator splits a run across the instances that join it and merges
+// their results. It generates no load itself.
+type coordinator struct {
+	cfg    loadConfig
+	expect int
+
+	mu      sync.Mutex
+	names   []string
+	startAt time.Time
+	results map[int]loadResult
+	started chan struct{}
+	done    chan struct{}
+}
+
+func runCoordinator(addr string, cfg loadConfig, expect int) error {
+	if expect < 1 {
+		return fmt.Errorf("--expect-workers must be at least 1")
+	}
+	if cfg.Count > 0 && cfg.Count < expect {
+		return fmt.Errorf("--count %d is smaller than --expect-workers %d", cfg.Count, expect)
+	}
+
+	c := &coordinator{
+		cfg:     cfg,
+		expect:  expect,
+		results: make(map[int]loadResult),
+		started: make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /register", c.register)
+	mux.HandleFunc("GET /assignment", c.assignment)
+	mux.HandleFunc("POST /results", c.collect)
+	mux.HandleFunc("GET /status", c.status)
+
+	srv := &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("coordinator server error", slog.String("error", err.Error()))
+			os.Exit(1)
+		}
+	}()
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(ctx)
+	}()
+
+	slog.Info("coordinator waiting for workers",
+		slog.String("addr", addr),
+		slog.Int("expect", expect),
+		slog.Float64("rps", cfg.RPS),
+		slog.Int("count", cfg.Count),
+		slog.Duration("duration", cfg.Duration),
+	)
+
+	<-c.started
+	select {
+	case <-c.done:
+	case <-time.After(time.Until(c.startAt) + c.expectedRunTime() + resultGrace):
+		slog.Warn("not every worker reported; results are partial")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var merged loadResult
+	for i, name := range c.names {
+		res, ok := c.results[i]
+		if !ok {
+			continue
+		}
+		slog.Info("worker result",
+			slog.Int("worker", i),
+			slog.String("name", name),
+			slog.Int64("success", res.Success),
+			slog.Int64("failure", res.Failure),
+			slog.Duration("elapsed", res.Elapsed),
+		)
+		merged.merge(res)
+	}
+	merged.report(cfg.Track, slog.Int("instances", len(c.results)))
+	return nil
+}
+
+// expectedRunTime bounds how long workers should take: the duration, or the
+// time the count takes at the target rate, plus tracking.
+func (c *coordinator) expectedRunTime() time.Duration {
+	run := c.cfg.Duration
+	if c.cfg.Count > 0 {
+		byCount := time.Duration(float64(c.cfg.Count) / c.cfg.RPS * float64(time.Second))
+		if run == 0 || byCount < run {
+			run = byCount
+		}
+	}
+	if c.cfg.Track {
+		run += c.cfg.TrackTimeout
+	}
+	return run
+}
+
+// share is worker i's part of the run: an equal slice of the rate and of
+// the count, with any remainder going to the first workers.
+func (c *coordinator) share(i int) loadConfig {
+	cfg := c.cfg
+	cfg.RPS = c.cfg.RPS / float64(c.expect)
+	if c.cfg.Count > 0 {
+		cfg.Count = c.cfg.Count / c.expect
+		if i < c.cfg.Count%c.expect {
+			cfg.Count++
+		}
+	}
+	return cfg
+}
+
+func (c *coordinator) register(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name string `json:"name"`
+	}
+	_ = json.NewDecoder(r.Body).Decode(&req)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.names) >= c.expect {
+		http.Error(w, "run already has all its workers", http.StatusConflict)
+		return
+	}
+	id := len(c.names)
+	c.names = append(c.names, req.Name)
+	slog.Info("worker registered", slog.Int("worker", id), slog.String("name", req.Name),
+		slog.Int("registered", len(c.names)), slog.Int("expect", c.expect))
+
+	if len(c.names) == c.expect {
+		c.startAt = time.Now().Add(startDelay)
+		close(c.started)
+		slog.Info("all workers registered, starting", slog.Time("start_at", c.startAt))
+	}
+
+	writeJSON(w, http.StatusOK, map[string]int{"worker": id})
+}
+
+// assignment answers 204 until every worker has registered.
+func (c *coordinator) assignment(w http.ResponseWriter, r *http.Request) {
+	id, ok := c.workerID(w, r)
+	if !ok {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.startAt.IsZero() {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	writeJSON(w, http.StatusOK, assignment{
+		Worker:  id,
+		Of:      c.expect,
+		Config:  c.share(id),
+		StartAt: c.startAt,
+	})
+}
+
+func (c *coordinator) collect(w http.ResponseWriter, r *http.Request) {
+	id, ok := c.workerID(w, r)
+	if !ok {
+		return
+	}
+
+	var res loadResult
+	if err := json.NewDecoder(r.Body).Decode(&res); err != nil {
+		http.Error(w, "invalid result", http.StatusBadRequest)
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, dup := c.results[id]; dup {
+		http.Error(w, "result already reported", http.StatusConflict)
+		return
+	}
+	c.results[id] = res
+	slog.Info("worker reported", slog.Int("worker", id),
+		slog.Int("reported", len(c.results)), slog.Int("expect", c.expect))
+	if len(c.results) == c.expect {
+		close(c.done)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// status reports progress and the results merged so far.
+func (c *coordinator) status(w http.ResponseWriter, _ *http.Request) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var merged loadResult
+	for _, res := range c.results {
+		merged.merge(res)
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"expect":     c.expect,
+		"registered": len(c.names),
+		"reported":   len(c.results),
+		"start_at":   c.startAt,
+		"success":    merged.Success,
+		"failure":    merged.Failure,
+	})
+}
+
+func (c *coordinator) workerID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(r.URL.Query().Get("worker"))
+
+	c.mu.Lock()
+	registered := len(c.names)
+	c.mu.Unlock()
+
+	if err != nil || id < 0 || id >= registered {
+		http.Error(w, "unknown worker", http.StatusNotFound)
+		return 0, false
+	}
+	return id, true
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// runWorker registers with the coordinator, runs the share it is handed and
+// reports the result back.
+func runWorker(coordinatorURL string) error {
+	if err := validateTargetURL(coordinatorURL); err != nil {
+		return fmt.Errorf("invalid coordinator URL: %w", err)
+	}
+	base := strings.TrimRight(coordinatorURL, "/")
+	client := &http.Client{Timeout: 10 * time.Second}
+
+	name, _ := os.Hostname()
+	name = fmt.Sprintf("%s-%d", name, os.Getpid())
+
+	// The coordinator may still be starting: keep trying for a while.
+	var registered struct {
+		Worker int `json:"worker"`
+	}
+	deadline := time.Now().Add(registerTimeout)
+	for {
+		err := postJSON(client, base+"/register", map[string]string{"name": name}, &registered)
+		if err == nil {
+			break
+		}
+		var rejected *coordinatorError
+		if errors.As(err, &rejected) || time.Now().After(deadline) {
+			return fmt.Errorf("register: %w", err)
+		}
+		slog.Debug("coordinator not ready", slog.String("error", err.Error()))
+		time.Sleep(time.Second)
+	}
+	slog.Info("registered with coordinator", slog.String("coordinator", base), slog.Int("worker", registered.Worker))
+
+	query := "?worker=" + strconv.Itoa(registered.Worker)
+	var a assignment
+	for {
+		// #nosec G704 -- base is the operator-supplied coordinator URL, scheme-validated above.
+		resp, err := client.Get(base + "/assignment" + query)
+		if err != nil {
+			return fmt.Errorf("fetch assignment: %w", err)
+		}
+		if resp.StatusCode == http.StatusOK {
+			err = json.NewDecoder(resp.Body).Decode(&a)
+		} else if resp.StatusCode != http.StatusNoContent {
+			err = &coordinatorError{status: resp.StatusCode}
+		}
+		_ = resp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("fetch assignment: %w", err)
+		}
+		if resp.StatusCode == http.StatusOK {
+			break
+		}
+		time.Sleep(assignmentPoll)
+	}
+	if err := a.Config.validate(); err != nil {
+		return fmt.Errorf("assignment: %w", err)
+	}
+
+	slog.Info("received assignment",
+		slog.Int("worker", a.Worker),
+		slog.Int("of", a.Of),
+		slog.Float64("rps", a.Config.RPS),
+		slog.Int("count", a.Config.Count),
+		slog.Time("start_at", a.StartAt),
+	)
+	time.Sleep(time.Until(a.StartAt))
+
+	res := generate(a.Config)
+	res.report(a.Config.Track, slog.Int("worker", a.Worker))
+
+	if err := postJSON(client, base+"/results"+query, res, nil); err != nil {
+		return fmt.Errorf("report results: %w", err)
+	}
+	return nil
+}
+
+// coordinatorError is a request the coordinator answered but refused.
+type coordinatorError struct {
+	status int
+}
+
+func (e *coordinatorError) Error() string {
+	return fmt.Sprintf("coordinator error: status %d", e.status)
+}
+
+func postJSON(client *http.Client, url string, body, out any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	// #nosec G704 -- url is derived from the validated operator-supplied coordinator URL.
+	resp, err := client.Post(url, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode >= 400 {
+		return &coordinatorError{status: resp.StatusCode}
+	}
+	if out != nil {
+		return json.NewDecoder(resp.Body).Decode(out)
+	}
+	return nil
+}
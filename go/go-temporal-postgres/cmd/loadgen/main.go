@@ -8,10 +8,14 @@ import (
 	"flag"
 	"fmt"
 	"log/slog"
+	"maps"
 	"math/big"
 	"net/http"
 	"net/url"
 	"os"
+	"slices"
+	"sort"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -40,6 +44,7 @@ func cryptoRandFloat64() float64 {
 type OrderRequest struct {
 	CustomerID   string      `json:"customer_id"`
 	CustomerTier string      `json:"customer_tier"`
+	Currency     string      `json:"currency"`
 	Items        []OrderItem `json:"items"`
 }
 
@@ -58,6 +63,8 @@ type product struct {
 var (
 	customerTiers = []string{"standard", "silver", "gold", "platinum"}
 
+	orderCurrency = "INR"
+
 	// Products with INR prices (₹50,000 to ₹25,00,000 range)
 	// Weights control frequency: higher weight = more common
 	products = []product{
@@ -95,6 +102,32 @@ func init() {
 	}
 }
 
+// loadConfig is one run's settings. In coordinator mode it is the whole
+// run, and each worker is handed a share of it.
+type loadConfig struct {
+	URL          string        `json:"url"`
+	Count        int           `json:"count"`
+	RPS          float64       `json:"rps"`
+	Duration     time.Duration `json:"duration"`
+	Workers      int           `json:"workers"`
+	Track        bool          `json:"track"`
+	PollInterval time.Duration `json:"poll_interval"`
+	TrackTimeout time.Duration `json:"track_timeout"`
+}
+
+func (c loadConfig) validate() error {
+	if c.Count == 0 && c.Duration == 0 {
+		return fmt.Errorf("must specify either --count or --duration")
+	}
+	if c.RPS <= 0 {
+		return fmt.Errorf("--rps must be positive")
+	}
+	if err := validateTargetURL(c.URL); err != nil {
+		return fmt.Errorf("invalid target URL: %w", err)
+	}
+	return nil
+}
+
 func main() {
 	defaultURL := os.Getenv("API_URL")
 	if defaultURL == "" {
@@ -107,25 +140,127 @@ func main() {
 		rps      = flag.Float64("rps", 1, "Requests per second")
 		duration = flag.Duration("duration", 0, "Duration to run (0 = until count reached or forever)")
 		workers  = flag.Int("workers", 5, "Number of concurrent workers")
+		track    = flag.Bool("track", false, "Poll each order's status until it reaches a terminal state")
+		poll     = flag.Duration("poll-interval", time.Second, "Status poll interval when --track is set")
+		timeout  = flag.Duration("track-timeout", 2*time.Minute, "Give up tracking an order after this long")
+
+		coordinatorAddr = flag.String("coordinator", "", "Serve the coordinator control endpoint on this address (e.g. :9090) and split the run across joining instances")
+		expect          = flag.Int("expect-workers", 2, "Instances to wait for in coordinator mode before starting")
+		join            = flag.String("join", "", "Coordinator URL to join as a worker instance; the coordinator supplies every other setting")
 	)
 	flag.Parse()
 
-	if *count == 0 && *duration == 0 {
-		slog.Error("must specify either --count or --duration")
-		os.Exit(1)
+	if *join != "" {
+		if err := runWorker(*join); err != nil {
+			slog.Error("worker failed", slog.String("error", err.Error()))
+			os.Exit(1)
+		}
+		return
 	}
 
-	if err := validateTargetURL(*apiURL); err != nil {
-		slog.Error("invalid target URL", slog.String("error", err.Error()))
+	cfg := loadConfig{
+		URL:          *apiURL,
+		Count:        *count,
+		RPS:          *rps,
+		Duration:     *duration,
+		Workers:      *workers,
+		Track:        *track,
+		PollInterval: *poll,
+		TrackTimeout: *timeout,
+	}
+	if err := cfg.validate(); err != nil {
+		slog.Error("invalid configuration", slog.String("error", err.Error()))
 		os.Exit(1)
 	}
 
+	if *coordinatorAddr != "" {
+		if err := runCoordinator(*coordinatorAddr, cfg, *expect); err != nil {
+			slog.Error("coordinator failed", slog.String("error", err.Error()))
+			os.Exit(1)
+		}
+		return
+	}
+
+	generate(cfg).report(cfg.Track)
+}
+
+// loadResult is the outcome of a run. Workers send theirs to the coordinator,
+// which merges them.
+type loadResult struct {
+	Success   int64           `json:"success"`
+	Failure   int64           `json:"failure"`
+	Elapsed   time.Duration   `json:"elapsed"`
+	Latencies []time.Duration `json:"latencies,omitempty"`
+	Statuses  map[string]int  `json:"statuses,omitempty"`
+}
+
+// merge adds other's counts. Instances run side by side, so the merged run
+// took as long as the slowest.
+func (r *loadResult) merge(other loadResult) {
+	r.Success += other.Success
+	r.Failure += other.Failure
+	r.Elapsed = max(r.Elapsed, other.Elapsed)
+	r.Latencies = append(r.Latencies, other.Latencies...)
+	if len(other.Statuses) > 0 && r.Statuses == nil {
+		r.Statuses = make(map[string]int)
+	}
+	for status, n := range other.Statuses {
+		r.Statuses[status] += n
+	}
+}
+
+func (r loadResult) report(track bool, attrs ...any) {
+	total := r.Success + r.Failure
+	summary := append([]any{
+		slog.Int64("total", total),
+		slog.Int64("success", r.Success),
+		slog.Int64("failure", r.Failure),
+		slog.Float64("success_rate", float64(r.Success)/float64(total)*100),
+		slog.Duration("elapsed", r.Elapsed),
+		slog.Float64("actual_rps", float64(total)/r.Elapsed.Seconds()),
+	}, attrs...)
+	slog.Info("load generation complete", summary...)
+
+	if !track {
+		return
+	}
+
+	latencies := slices.Clone(r.Latencies)
+	slices.Sort(latencies)
+
+	latencyAttrs := []any{slog.Int("tracked", len(latencies))}
+	if len(latencies) > 0 {
+		latencyAttrs = append(latencyAttrs,
+			slog.Duration("p50", percentile(latencies, 50)),
+			slog.Duration("p90", percentile(latencies, 90)),
+			slog.Duration("p99", percentile(latencies, 99)),
+			slog.Duration("max", latencies[len(latencies)-1]),
+		)
+	}
+	slog.Info("end-to-end fulfillment latency", latencyAttrs...)
+
+	statuses := make([]string, 0, len(r.Statuses))
+	for s := range r.Statuses {
+		statuses = append(statuses, s)
+	}
+	sort.Strings(statuses)
+	dist := make([]any, 0, len(statuses))
+	for _, s := range statuses {
+		dist = append(dist, slog.Int(s, r.Statuses[s]))
+	}
+	slog.Info("terminal status distribution", dist...)
+}
+
+// generate submits orders at cfg.RPS until cfg.Count orders or cfg.Duration,
+// whichever comes first, then waits for tracked orders to finish.
+func generate(cfg loadConfig) loadResult {
 	slog.Info("starting load generator",
-		slog.String("url", *apiURL),
-		slog.Int("count", *count),
-		slog.Float64("rps", *rps),
-		slog.Duration("duration", *duration),
-		slog.Int("workers", *workers),
+		slog.String("url", cfg.URL),
+		slog.Int("count", cfg.Count),
+		slog.Float64("rps", cfg.RPS),
+		slog.Duration("duration", cfg.Duration),
+		slog.Int("workers", cfg.Workers),
+		slog.Bool("track", cfg.Track),
 	)
 
 	var (
@@ -134,18 +269,21 @@ func main() {
 		totalCount   int64
 		startTime    = time.Now()
 		stopCh       = make(chan struct{})
-		orderCh      = make(chan OrderRequest, *workers*2)
+		orderCh      = make(chan OrderRequest, cfg.Workers*2)
 		wg           sync.WaitGroup
+		trackWg      sync.WaitGroup
+		tracker      = newTracker()
 	)
 
-	for i := 0; i < *workers; i++ {
+	for i := 0; i < cfg.Workers; i++ {
 		wg.Add(1)
 		go func(workerID int) {
 			defer wg.Done()
 			client := &http.Client{Timeout: 30 * time.Second}
 
 			for order := range orderCh {
-				if err := submitOrder(context.Background(), client, *apiURL, order); err != nil {
+				orderID, submittedAt, err := submitOrder(context.Background(), client, cfg.URL, order)
+				if err != nil {
 					atomic.AddInt64(&failureCount, 1)
 					slog.Error("order failed",
 						slog.Int("worker", workerID),
@@ -158,19 +296,26 @@ func main() {
 						slog.Int("worker", workerID),
 						slog.String("customer_id", order.CustomerID),
 					)
+					if cfg.Track && orderID != "" {
+						trackWg.Add(1)
+						go func() {
+							defer trackWg.Done()
+							tracker.follow(client, cfg.URL, orderID, submittedAt, cfg.PollInterval, cfg.TrackTimeout)
+						}()
+					}
 				}
 			}
 		}(i)
 	}
 
-	if *duration > 0 {
+	if cfg.Duration > 0 {
 		go func() {
-			time.Sleep(*duration)
+			time.Sleep(cfg.Duration)
 			close(stopCh)
 		}()
 	}
 
-	interval := time.Duration(float64(time.Second) / *rps)
+	interval := time.Duration(float64(time.Second) / cfg.RPS)
 	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
@@ -179,7 +324,7 @@ func main() {
 		case <-stopCh:
 			goto done
 		case <-ticker.C:
-			if *count > 0 && atomic.LoadInt64(&totalCount) >= int64(*count) {
+			if cfg.Count > 0 && atomic.LoadInt64(&totalCount) >= int64(cfg.Count) {
 				goto done
 			}
 
@@ -192,20 +337,19 @@ func main() {
 done:
 	close(orderCh)
 	wg.Wait()
+	if cfg.Track {
+		slog.Info("waiting for tracked orders to finish")
+		trackWg.Wait()
+	}
 
-	elapsed := time.Since(startTime)
-	success := atomic.LoadInt64(&successCount)
-	failure := atomic.LoadInt64(&failureCount)
-	total := success + failure
-
-	slog.Info("load generation complete",
-		slog.Int64("total", total),
-		slog.Int64("success", success),
-		slog.Int64("failure", failure),
-		slog.Float64("success_rate", float64(success)/float64(total)*100),
-		slog.Duration("elapsed", elapsed),
-		slog.Float64("actual_rps", float64(total)/elapsed.Seconds()),
-	)
+	latencies, statuses := tracker.results()
+	return loadResult{
+		Success:   atomic.LoadInt64(&successCount),
+		Failure:   atomic.LoadInt64(&failureCount),
+		Elapsed:   time.Since(startTime),
+		Latencies: latencies,
+		Statuses:  statuses,
+	}
 }
 
 func generateOrder(seq int64) OrderRequest {
@@ -226,6 +370,7 @@ func generateOrder(seq int64) OrderRequest {
 	return OrderRequest{
 		CustomerID:   customerID,
 		CustomerTier: tier,
+		Currency:     orderCurrency,
 		Items:        items,
 	}
 }
@@ -256,30 +401,122 @@ func validateTargetURL(raw string) error {
 	return nil
 }
 
-func submitOrder(ctx context.Context, client *http.Client, url string, order OrderRequest) error {
+// submitOrder posts the order and returns its ID along with the time it was
+// sent, which is the start of the end-to-end fulfillment measurement.
+func submitOrder(ctx context.Context, client *http.Client, url string, order OrderRequest) (string, time.Time, error) {
 	body, err := json.Marshal(order)
 	if err != nil {
-		return fmt.Errorf("marshal error: %w", err)
+		return "", time.Time{}, fmt.Errorf("marshal error: %w", err)
 	}
 
 	// #nosec G704 -- url is operator-supplied loadgen config (--url/API_URL),
 	// scheme-validated at startup; targeting an operator-chosen endpoint is the tool's purpose.
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
 	if err != nil {
-		return fmt.Errorf("request creation error: %w", err)
+		return "", time.Time{}, fmt.Errorf("request creation error: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
 
+	submittedAt := time.Now()
 	// #nosec G704 -- see above; request issued to the validated operator-supplied target.
 	resp, err := client.Do(req)
 	if err != nil {
-		return fmt.Errorf("request error: %w", err)
+		return "", time.Time{}, fmt.Errorf("request error: %w", err)
 	}
 	defer func() { _ = resp.Body.Close() }()
 
 	if resp.StatusCode >= 400 {
-		return fmt.Errorf("API error: status %d", resp.StatusCode)
+		return "", time.Time{}, fmt.Errorf("API error: status %d", resp.StatusCode)
 	}
 
-	return nil
+	var created struct {
+		Order struct {
+			ID string `json:"id"`
+		} `json:"order"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&created)
+
+	return created.Order.ID, submittedAt, nil
+}
+
+type statusResponse struct {
+	Status   string `json:"status"`
+	Terminal bool   `json:"terminal"`
+}
+
+// tracker collects end-to-end fulfillment latency and the terminal status of
+// every order followed with --track.
+type tracker struct {
+	mu        sync.Mutex
+	latencies []time.Duration
+	statuses  map[string]int
+}
+
+func newTracker() *tracker {
+	return &tracker{statuses: make(map[string]int)}
+}
+
+func (t *tracker) follow(client *http.Client, ordersURL, orderID string, submittedAt time.Time, interval, timeout time.Duration) {
+	statusURL := strings.TrimRight(ordersURL, "/") + "/" + orderID + "/status"
+	deadline := submittedAt.Add(timeout)
+
+	for time.Now().Before(deadline) {
+		time.Sleep(interval)
+
+		status, err := fetchStatus(client, statusURL)
+		if err != nil {
+			slog.Debug("status poll failed", slog.String("order_id", orderID), slog.String("error", err.Error()))
+			continue
+		}
+		if status.Terminal {
+			t.record(status.Status, time.Since(submittedAt))
+			return
+		}
+	}
+
+	slog.Warn("order did not reach a terminal state", slog.String("order_id", orderID), slog.Duration("timeout", timeout))
+	t.record("tracking_timeout", 0)
+}
+
+func (t *tracker) record(status string, latency time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.statuses[status]++
+	if latency > 0 {
+		t.latencies = append(t.latencies, latency)
+	}
+}
+
+func (t *tracker) results() ([]time.Duration, map[string]int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return slices.Clone(t.latencies), maps.Clone(t.statuses)
+}
+
+// percentile expects sorted input and uses the nearest-rank method.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	idx := (p*len(sorted)+99)/100 - 1
+	if idx < 0 {
+		idx = 0
+	}
+	return sorted[idx]
+}
+
+func fetchStatus(client *http.Client, statusURL string) (*statusResponse, error) {
+	// #nosec G704 -- derived from the validated operator-supplied target.
+	resp, err := client.Get(statusURL)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("API error: status %d", resp.StatusCode)
+	}
+
+	var status statusResponse
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		return nil, err
+	}
+	return &status, nil
 }
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/base-14/examples/go/go-temporal-postgres/internal/handlers"
+)
+
+// scenario is one decision path through OrderFulfillmentWorkflow, driven by
+// the same inputs as scripts/test-workflows.sh.
+type scenario struct {
+	Name string
+	// Order builds the request; run makes customer IDs unique so the API's
+	// duplicate detection never returns an earlier order.
+	Order func(run string) handlers.CreateOrderRequest
+	// Review is signalled once the order is held for manual review.
+	Review       string
+	WantStatus   string
+	WantDecision string
+	// Metrics are the minimum increases each passing run adds on the
+	// worker's debug endpoint.
+	Metrics []metricCheck
+}
+
+type metricCheck struct {
+	Name  string
+	Attrs map[string]string
+	// Count checks a histogram's recordings rather than a counter's total.
+	Count bool
+	Delta float64
+}
+
+func (m metricCheck) String() string {
+	if len(m.Attrs) == 0 {
+		return m.Name
+	}
+	return fmt.Sprintf("%s%v", m.Name, m.Attrs)
+}
+
+func durationCheck(decisionPath string) metricCheck {
+	return metricCheck{Name: "orders.processing_duration", Attrs: map[string]string{"decision_path": decisionPath}, Count: true, Delta: 1}
+}
+
+var scenarios = []scenario{
+	{
+		Name: "approve",
+		Order: func(run string) handlers.CreateOrderRequest {
+			return handlers.CreateOrderRequest{
+				CustomerID:   "smoke-" + run + "-approve",
+				CustomerTier: "premium",
+				Items:        []handlers.CreateOrderItem{{ProductID: "prod-1", Quantity: 1, Price: 50}},
+			}
+		},
+		WantStatus:   "completed",
+		WantDecision: "auto_approved",
+		Metrics: []metricCheck{
+			{Name: "orders.processed", Attrs: map[string]string{"customer_tier": "premium"}, Delta: 1},
+			{Name: "orders.approved", Attrs: map[string]string{"customer_tier": "premium"}, Delta: 1},
+			durationCheck("auto_approved"),
+		},
+	},
+	{
+		Name: "decline",
+		Order: func(run string) handlers.CreateOrderRequest {
+			return handlers.CreateOrderRequest{
+				CustomerID:    "smoke-" + run + "-decline",
+				CustomerTier:  "standard",
+				Items:         []handlers.CreateOrderItem{{ProductID: "prod-1", Quantity: 1, Price: 50}},
+				PaymentMethod: "test_decline",
+			}
+		},
+		WantStatus:   "payment_failed",
+		WantDecision: "payment_declined",
+		Metrics: []metricCheck{
+			{Name: "orders.payment_failed", Attrs: map[string]string{"reason": "test_decline"}, Delta: 1},
+			durationCheck("payment_declined"),
+		},
+	},
+	{
+		Name: "manual_review",
+		Order: func(run string) handlers.CreateOrderRequest {
+			// The "new-" prefix, the new tier and the amount push the fraud
+			// score over the review threshold.
+			return handlers.CreateOrderRequest{
+				CustomerID:   "new-smoke-" + run,
+				CustomerTier: "new",
+				Items:        []handlers.CreateOrderItem{{ProductID: "prod-1", Quantity: 100, Price: 5000}},
+			}
+		},
+		Review:       "approved",
+		WantStatus:   "approved",
+		WantDecision: "manual_approved",
+		Metrics: []metricCheck{
+			{Name: "orders.manual_review", Delta: 1},
+			{Name: "orders.approved", Attrs: map[string]string{"customer_tier": "new"}, Delta: 1},
+			{Name: "orders.processed", Attrs: map[string]string{"customer_tier": "new"}, Delta: 2},
+			durationCheck("manual_approved"),
+		},
+	},
+	{
+		Name: "backorder",
+		Order: func(run string) handlers.CreateOrderRequest {
+			return handlers.CreateOrderRequest{
+				CustomerID:   "smoke-" + run + "-backorder",
+				CustomerTier: "standard",
+				Items:        []handlers.CreateOrderItem{{ProductID: "out-of-stock-item", Quantity: 1000, Price: 1}},
+			}
+		},
+		WantStatus:   "backordered",
+		WantDecision: "backorder",
+		Metrics: []metricCheck{
+			{Name: "orders.backordered", Delta: 1},
+			durationCheck("backorder"),
+		},
+	},
+}
@@ -15,6 +15,8 @@ type Config struct {
 	TemporalHost      string
 	TemporalTaskQueue string
 
+	DuplicateOrderWindow time.Duration
+
 	JWTSecret    string
 	JWTExpiresIn time.Duration
 
@@ -41,6 +43,13 @@ func Load() (*Config, error) {
 	}
 	cfg.JWTExpiresIn = duration
 
+	dupWindow := getEnv("DUPLICATE_ORDER_WINDOW", "5m")
+	window, err := time.ParseDuration(dupWindow)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DUPLICATE_ORDER_WINDOW: %w", err)
+	}
+	cfg.DuplicateOrderWindow = window
+
 	if err := cfg.validate(); err != nil {
 		return nil, err
 	}
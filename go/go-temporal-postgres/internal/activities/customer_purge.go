@@ -0,0 +1,200 @@
+package activities
+
+import (
+	"context"
+	"errors"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.temporal.io/api/serviceerror"
+	"go.temporal.io/sdk/client"
+	"gorm.io/gorm"
+
+	"github.com/base-14/examples/go/go-temporal-postgres/internal/models"
+	"github.com/base-14/examples/go/go-temporal-postgres/internal/telemetry"
+)
+
+// RedactedNoteBody replaces the body of every note on a purged customer's
+// orders, since support notes often quote personal details.
+const RedactedNoteBody = "[redacted: customer data purged]"
+
+// PurgeActivities holds the activities of the customer data purge workflow.
+// The purge worker registers an instance; the Temporal client is used to
+// signal the customer's running order workflows.
+type PurgeActivities struct {
+	DB       *gorm.DB
+	Temporal client.Client
+}
+
+type SignalCustomerWorkflowsInput struct {
+	CustomerID string `json:"customer_id"`
+	Signal     string `json:"signal"`
+}
+
+type AnonymizeCustomerInput struct {
+	CustomerID   string `json:"customer_id"`
+	AnonymizedID string `json:"anonymized_id"`
+}
+
+// AnonymizeCustomerResult counts the records that now carry the pseudonym.
+// The counts are taken after the update, so a retried activity reports the
+// same totals as the attempt that did the work.
+type AnonymizeCustomerResult struct {
+	Orders     int64 `json:"orders"`
+	Notes      int64 `json:"notes"`
+	Events     int64 `json:"events"`
+	SearchRows int64 `json:"search_rows"`
+}
+
+type DeletePreferencesInput struct {
+	CustomerID string `json:"customer_id"`
+}
+
+type RecordPurgeMetricsInput struct {
+	Outcome      string                `json:"outcome"`
+	DurationSecs float64               `json:"duration_secs"`
+	Report       *models.CustomerPurge `json:"report,omitempty"`
+}
+
+// SignalCustomerWorkflows sends the signal to the workflow of every order the
+// customer has placed and returns how many were still running to receive it.
+func (a *PurgeActivities) SignalCustomerWorkflows(ctx context.Context, input SignalCustomerWorkflowsInput) (int, error) {
+	ctx, span := otel.Tracer("activities").Start(ctx, "signal_customer_workflows")
+	defer span.End()
+
+	var workflowIDs []string
+	if err := a.DB.WithContext(ctx).Model(&models.Order{}).
+		Where("customer_id = ? AND workflow_id <> ''", input.CustomerID).
+		Pluck("workflow_id", &workflowIDs).Error; err != nil {
+		span.RecordError(err)
+		return 0, err
+	}
+
+	signalled := 0
+	for _, id := range workflowIDs {
+		err := a.Temporal.SignalWorkflow(ctx, id, "", input.Signal, nil)
+		var notFound *serviceerror.NotFound
+		switch {
+		case err == nil:
+			signalled++
+		case errors.As(err, &notFound):
+			// Already finished: nothing to stop.
+		default:
+			span.RecordError(err)
+			return signalled, err
+		}
+	}
+
+	span.SetAttributes(
+		attribute.Int("purge.workflows_found", len(workflowIDs)),
+		attribute.Int("purge.workflows_signalled", signalled),
+	)
+	return signalled, nil
+}
+
+// AnonymizeCustomer rewrites the customer's ID to the pseudonym on their
+// orders, order events, search rows and notification log, clears the cart fingerprints derived
+// from it, and redacts the notes on their orders, all in one transaction.
+func (a *PurgeActivities) AnonymizeCustomer(ctx context.Context, input AnonymizeCustomerInput) (*AnonymizeCustomerResult, error) {
+	ctx, span := otel.Tracer("activities").Start(ctx, "anonymize_customer",
+		trace.WithAttributes(attribute.String("purge.anonymized_id", input.AnonymizedID)),
+	)
+	defer span.End()
+
+	var result AnonymizeCustomerResult
+	err := a.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.OrderNote{}).
+			Where("order_id IN (?)", tx.Model(&models.Order{}).Select("id").Where("customer_id = ?", input.CustomerID)).
+			Update("body", RedactedNoteBody).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Order{}).Where("customer_id = ?", input.CustomerID).
+			Updates(map[string]interface{}{"customer_id": input.AnonymizedID, "cart_hash": ""}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.OrderEvent{}).Where("customer_id = ?", input.CustomerID).
+			Update("customer_id", input.AnonymizedID).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.OrderSearch{}).Where("customer_id = ?", input.CustomerID).
+			Update("customer_id", input.AnonymizedID).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.NotificationLog{}).Where("customer_id = ?", input.CustomerID).
+			Update("customer_id", input.AnonymizedID).Error; err != nil {
+			return err
+		}
+
+		orders := tx.Model(&models.Order{}).Select("id").Where("customer_id = ?", input.AnonymizedID)
+		if err := tx.Model(&models.Order{}).Where("customer_id = ?", input.AnonymizedID).Count(&result.Orders).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.OrderNote{}).Where("order_id IN (?)", orders).Count(&result.Notes).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.OrderEvent{}).Where("customer_id = ?", input.AnonymizedID).Count(&result.Events).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.OrderSearch{}).Where("customer_id = ?", input.AnonymizedID).Count(&result.SearchRows).Error
+	})
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	span.SetAttributes(
+		attribute.Int64("purge.orders", result.Orders),
+		attribute.Int64("purge.notes", result.Notes),
+		attribute.Int64("purge.events", result.Events),
+		attribute.Int64("purge.search_rows", result.SearchRows),
+	)
+	return &result, nil
+}
+
+// DeleteNotificationPreferences removes the customer's notification
+// preferences and returns how many were deleted.
+func (a *PurgeActivities) DeleteNotificationPreferences(ctx context.Context, input DeletePreferencesInput) (int64, error) {
+	ctx, span := otel.Tracer("activities").Start(ctx, "delete_notification_preferences")
+	defer span.End()
+
+	res := a.DB.WithContext(ctx).Where("customer_id = ?", input.CustomerID).Delete(&models.NotificationPreference{})
+	if res.Error != nil {
+		span.RecordError(res.Error)
+		return 0, res.Error
+	}
+
+	span.SetAttributes(attribute.Int64("purge.preferences_deleted", res.RowsAffected))
+	return res.RowsAffected, nil
+}
+
+// SavePurgeReport stores the completion report, replacing any earlier one
+// for the same purge.
+func (a *PurgeActivities) SavePurgeReport(ctx context.Context, report models.CustomerPurge) error {
+	ctx, span := otel.Tracer("activities").Start(ctx, "save_purge_report",
+		trace.WithAttributes(attribute.String("purge.workflow_id", report.WorkflowID)),
+	)
+	defer span.End()
+
+	if err := a.DB.WithContext(ctx).Save(&report).Error; err != nil {
+		span.RecordError(err)
+		return err
+	}
+	return nil
+}
+
+// RecordPurgeMetrics records a finished purge and, when it completed, the
+// records it touched by type.
+func (a *PurgeActivities) RecordPurgeMetrics(ctx context.Context, input RecordPurgeMetricsInput) error {
+	telemetry.RecordCustomerPurge(ctx, input.Outcome, input.DurationSecs)
+
+	if r := input.Report; r != nil {
+		telemetry.RecordCustomerPurgeRecords(ctx, "order", r.OrdersAnonymized)
+		telemetry.RecordCustomerPurgeRecords(ctx, "order_note", r.NotesRedacted)
+		telemetry.RecordCustomerPurgeRecords(ctx, "order_event", r.EventsAnonymized)
+		telemetry.RecordCustomerPurgeRecords(ctx, "order_search", r.SearchRowsAnonymized)
+		telemetry.RecordCustomerPurgeRecords(ctx, "notification_preference", r.PreferencesDeleted)
+		telemetry.RecordCustomerPurgeRecords(ctx, "workflow_signal", int64(r.WorkflowsSignalled))
+	}
+	return nil
+}
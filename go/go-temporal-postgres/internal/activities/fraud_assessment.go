@@ -15,7 +15,8 @@ func FraudAssessment(ctx context.Context, input FraudAssessmentInput) (*FraudAss
 			attribute.String("order.id", input.OrderID),
 			attribute.String("customer.id", input.CustomerID),
 			attribute.String("customer.tier", input.CustomerTier),
-			attribute.Float64("order.amount", input.TotalAmount),
+			attribute.Int64("order.amount_minor", input.TotalAmount.Amount),
+			attribute.String("order.currency", string(input.TotalAmount.Currency)),
 		),
 	)
 	defer span.End()
@@ -33,12 +34,12 @@ func FraudAssessment(ctx context.Context, input FraudAssessmentInput) (*FraudAss
 		reasons = append(reasons, "non_premium_tier")
 	}
 
-	if input.TotalAmount > 1000 {
+	if input.TotalAmount.Major() > 1000 {
 		riskScore += 25
 		reasons = append(reasons, "high_value_order")
 	}
 
-	if input.TotalAmount > 5000 {
+	if input.TotalAmount.Major() > 5000 {
 		riskScore += 30
 		reasons = append(reasons, "very_high_value_order")
 	}
@@ -51,3 +51,20 @@ func InventoryCheck(ctx context.Context, input InventoryCheckInput) (*InventoryC
 		UnavailableItems: unavailable,
 	}, nil
 }
+
+// ReleaseInventory gives back the stock reserved for an order. The order
+// workflow runs it as a compensation when the order stops after its items
+// were reserved. The mock stock is never drawn down, so there is nothing to
+// return here beyond recording the release.
+func ReleaseInventory(ctx context.Context, input ReleaseInventoryInput) error {
+	_, span := otel.Tracer("activities").Start(ctx, "release_inventory",
+		trace.WithAttributes(
+			attribute.String("order.id", input.OrderID),
+			attribute.Int("order.item_count", len(input.Items)),
+			attribute.String("inventory.release_reason", input.Reason),
+		),
+	)
+	defer span.End()
+
+	return nil
+}
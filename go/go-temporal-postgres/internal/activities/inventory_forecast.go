@@ -0,0 +1,118 @@
+package activities
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+
+	"github.com/base-14/examples/go/go-temporal-postgres/internal/models"
+)
+
+// ForecastActivities holds the database-backed activities for the inventory
+// forecast workflow. Register an instance with the worker; its methods become
+// the activity names.
+type ForecastActivities struct {
+	DB *gorm.DB
+}
+
+type AnalyzeDemandInput struct {
+	LookbackDays int `json:"lookback_days"`
+}
+
+type SKUDemand struct {
+	SKU          string  `json:"sku"`
+	CurrentStock int     `json:"current_stock"`
+	TotalUnits   int     `json:"total_units"`
+	AvgDaily     float64 `json:"avg_daily"`
+	StdDevDaily  float64 `json:"stddev_daily"`
+}
+
+type SaveReorderSuggestionsInput struct {
+	RunID       string                     `json:"run_id"`
+	GeneratedAt time.Time                  `json:"generated_at"`
+	Suggestions []models.ReorderSuggestion `json:"suggestions"`
+}
+
+// demandSQL spreads each SKU's units over every day in the window, including
+// days with no orders, so the mean and deviation reflect real daily demand.
+const demandSQL = `
+WITH days AS (
+	SELECT generate_series(
+		date_trunc('day', NOW()) - (@lookback - 1) * INTERVAL '1 day',
+		date_trunc('day', NOW()),
+		INTERVAL '1 day'
+	) AS day
+),
+daily AS (
+	SELECT oi.product_id AS sku, date_trunc('day', o.created_at) AS day, SUM(oi.quantity) AS units
+	FROM order_items oi
+	JOIN orders o ON o.id = oi.order_id
+	WHERE o.created_at >= date_trunc('day', NOW()) - (@lookback - 1) * INTERVAL '1 day'
+	  AND o.status <> 'cancelled'
+	GROUP BY 1, 2
+),
+skus AS (
+	SELECT DISTINCT sku FROM daily
+)
+SELECT
+	s.sku,
+	COALESCE(p.stock, 0) AS current_stock,
+	SUM(COALESCE(d.units, 0))::int AS total_units,
+	AVG(COALESCE(d.units, 0))::float8 AS avg_daily,
+	COALESCE(STDDEV_SAMP(COALESCE(d.units, 0)), 0)::float8 AS std_dev_daily
+FROM skus s
+CROSS JOIN days
+LEFT JOIN daily d ON d.sku = s.sku AND d.day = days.day
+LEFT JOIN products p ON p.sku = s.sku
+GROUP BY s.sku, p.stock
+ORDER BY s.sku`
+
+func (a *ForecastActivities) AnalyzeDemand(ctx context.Context, input AnalyzeDemandInput) ([]SKUDemand, error) {
+	ctx, span := otel.Tracer("activities").Start(ctx, "analyze_demand",
+		trace.WithAttributes(attribute.Int("forecast.lookback_days", input.LookbackDays)),
+	)
+	defer span.End()
+
+	var demand []SKUDemand
+	if err := a.DB.WithContext(ctx).
+		Raw(demandSQL, map[string]interface{}{"lookback": input.LookbackDays}).
+		Scan(&demand).Error; err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	span.SetAttributes(attribute.Int("forecast.sku_count", len(demand)))
+	return demand, nil
+}
+
+func (a *ForecastActivities) SaveReorderSuggestions(ctx context.Context, input SaveReorderSuggestionsInput) error {
+	ctx, span := otel.Tracer("activities").Start(ctx, "save_reorder_suggestions",
+		trace.WithAttributes(
+			attribute.String("forecast.run_id", input.RunID),
+			attribute.Int("forecast.suggestion_count", len(input.Suggestions)),
+		),
+	)
+	defer span.End()
+
+	if len(input.Suggestions) == 0 {
+		return nil
+	}
+
+	// Activity retries must not duplicate a run's rows.
+	return a.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("run_id = ?", input.RunID).Delete(&models.ReorderSuggestion{}).Error; err != nil {
+			return err
+		}
+		rows := make([]models.ReorderSuggestion, len(input.Suggestions))
+		for i, s := range input.Suggestions {
+			s.RunID = input.RunID
+			s.GeneratedAt = input.GeneratedAt
+			rows[i] = s
+		}
+		return tx.Create(&rows).Error
+	})
+}
@@ -41,6 +41,14 @@ func RecordOrderMetrics(ctx context.Context, input RecordMetricsInput) error {
 		telemetry.RecordOrderRejected(ctx, "fraud_check_error")
 	case "inventory_error":
 		telemetry.RecordOrderRejected(ctx, "inventory_check_error")
+	case "customer_purged":
+		telemetry.RecordOrderRejected(ctx, "customer_purged")
+	case "compensated_refund":
+		telemetry.RecordOrderRejected(ctx, "shipping_failed_refunded")
+	case "compensation_failed":
+		telemetry.RecordOrderRejected(ctx, "compensation_failed")
+	case "order_cancelled", "cancelled_refunded":
+		telemetry.RecordOrderRejected(ctx, "order_cancelled")
 	}
 
 	if input.DurationSecs > 0 {
@@ -49,3 +57,15 @@ func RecordOrderMetrics(ctx context.Context, input RecordMetricsInput) error {
 
 	return nil
 }
+
+type ShipmentStageInput struct {
+	OrderID        string  `json:"order_id"`
+	From           string  `json:"from,omitempty"`
+	To             string  `json:"to"`
+	SecondsInStage float64 `json:"seconds_in_stage,omitempty"`
+}
+
+func RecordShipmentStage(ctx context.Context, input ShipmentStageInput) error {
+	telemetry.RecordShipmentStage(ctx, input.From, input.To, input.SecondsInStage)
+	return nil
+}
@@ -0,0 +1,125 @@
+package activities
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+
+	"github.com/base-14/examples/go/go-temporal-postgres/internal/models"
+	"github.com/base-14/examples/go/go-temporal-postgres/internal/telemetry"
+)
+
+// LogNotificationAttempt appends a delivery attempt to notification_log,
+// numbered after the earlier attempts of the same notification, and counts
+// it in notifications.deliveries.
+func LogNotificationAttempt(ctx context.Context, db *gorm.DB, entry *models.NotificationLog) error {
+	if entry.Channel == "" {
+		entry.Channel = models.DefaultNotificationChannel
+	}
+	if entry.Source == "" {
+		entry.Source = models.NotificationSourceWorkflow
+	}
+
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var earlier int64
+		if err := tx.Model(&models.NotificationLog{}).
+			Where("order_id = ? AND type = ? AND channel = ?", entry.OrderID, entry.Type, entry.Channel).
+			Count(&earlier).Error; err != nil {
+			return err
+		}
+		entry.Attempt = int(earlier) + 1
+		return tx.Create(entry).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	telemetry.RecordNotificationDelivery(ctx, entry.Channel, entry.Type, entry.Status, entry.Source)
+	return nil
+}
+
+// RedeliveryActivities holds the database activities of the notification
+// redelivery workflow.
+type RedeliveryActivities struct {
+	DB *gorm.DB
+}
+
+type FindUndeliveredInput struct {
+	MaxAttempts int `json:"max_attempts"`
+	MinAgeSecs  int `json:"min_age_secs"`
+	Limit       int `json:"limit"`
+}
+
+// UndeliveredNotification is a notification whose latest attempt failed.
+type UndeliveredNotification struct {
+	OrderID    string `json:"order_id"`
+	CustomerID string `json:"customer_id"`
+	Type       string `json:"type"`
+	Channel    string `json:"channel"`
+	Message    string `json:"message"`
+	Attempts   int    `json:"attempts"`
+}
+
+// undeliveredSQL selects the latest attempt of every notification, keeping
+// those that failed. Purged customers' notifications are never retried.
+const undeliveredSQL = `
+WITH latest AS (
+	SELECT order_id, customer_id, type, channel, message, status, created_at,
+		COUNT(*) OVER w AS attempts,
+		ROW_NUMBER() OVER (w ORDER BY created_at DESC, id DESC) AS rn
+	FROM notification_log
+	WINDOW w AS (PARTITION BY order_id, type, channel)
+)
+SELECT order_id, customer_id, type, channel, message, attempts, created_at
+FROM latest
+WHERE rn = 1 AND status = 'failed' AND customer_id NOT LIKE 'anon-%'`
+
+// FindUndeliveredNotifications returns up to Limit notifications, oldest
+// first, whose latest attempt failed at least MinAgeSecs ago and that have
+// had fewer than MaxAttempts tries. The wait leaves in-flight workflow
+// retries alone. The pending and exhausted totals are recorded in the
+// notifications.undelivered gauge.
+func (a *RedeliveryActivities) FindUndeliveredNotifications(ctx context.Context, input FindUndeliveredInput) ([]UndeliveredNotification, error) {
+	ctx, span := otel.Tracer("activities").Start(ctx, "find_undelivered_notifications",
+		trace.WithAttributes(attribute.Int("notification.max_attempts", input.MaxAttempts)),
+	)
+	defer span.End()
+
+	db := a.DB.WithContext(ctx)
+
+	var totals struct {
+		Pending   int64
+		Exhausted int64
+	}
+	if err := db.Raw(`SELECT
+			COUNT(*) FILTER (WHERE attempts < @max) AS pending,
+			COUNT(*) FILTER (WHERE attempts >= @max) AS exhausted
+		FROM (`+undeliveredSQL+`) u`,
+		map[string]interface{}{"max": input.MaxAttempts}).Scan(&totals).Error; err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	telemetry.RecordNotificationsUndelivered(ctx, totals.Pending, totals.Exhausted)
+
+	var found []UndeliveredNotification
+	if err := db.Raw(`SELECT order_id, customer_id, type, channel, message, attempts
+		FROM (`+undeliveredSQL+`) u
+		WHERE attempts < @max AND created_at <= NOW() - @min_age * INTERVAL '1 second'
+		ORDER BY created_at
+		LIMIT @limit`,
+		map[string]interface{}{"max": input.MaxAttempts, "min_age": input.MinAgeSecs, "limit": input.Limit},
+	).Scan(&found).Error; err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	span.SetAttributes(
+		attribute.Int64("notification.undelivered", totals.Pending),
+		attribute.Int64("notification.exhausted", totals.Exhausted),
+		attribute.Int("notification.redelivery_batch", len(found)),
+	)
+	return found, nil
+}
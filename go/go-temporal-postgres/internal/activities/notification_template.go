@@ -0,0 +1,69 @@
+package activities
+
+import (
+	"context"
+	"strings"
+	"text/template"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/base-14/examples/go/go-temporal-postgres/internal/models"
+)
+
+// Stages at which a notification template can fail.
+const (
+	RenderStageParse   = "parse"
+	RenderStageExecute = "execute"
+)
+
+// RenderError is a notification template that failed to parse or render.
+type RenderError struct {
+	Stage string
+	Err   error
+}
+
+func (e *RenderError) Error() string {
+	return "notification template " + e.Stage + ": " + e.Err.Error()
+}
+
+func (e *RenderError) Unwrap() error {
+	return e.Err
+}
+
+// FindNotificationTemplate returns the template for a notification type,
+// preferring the given locale over the default one and then the given
+// channel over the default one. It returns gorm.ErrRecordNotFound when
+// neither locale has a template on either channel.
+func FindNotificationTemplate(ctx context.Context, db *gorm.DB, notificationType, channel, locale string) (*models.NotificationTemplate, error) {
+	var tmpl models.NotificationTemplate
+	err := db.WithContext(ctx).
+		Where("type = ? AND channel IN ? AND locale IN ?", notificationType,
+			[]string{channel, models.DefaultNotificationChannel},
+			[]string{locale, models.DefaultNotificationLocale}).
+		Order(clause.OrderBy{Expression: clause.Expr{
+			SQL:                "locale = ? DESC, channel = ? DESC",
+			Vars:               []interface{}{locale, channel},
+			WithoutParentheses: true,
+		}}).
+		First(&tmpl).Error
+	if err != nil {
+		return nil, err
+	}
+	return &tmpl, nil
+}
+
+// RenderNotification renders a template body with data. A key the body names
+// but data lacks is an error, so a typo in a stored template is caught
+// rather than sent as "<no value>".
+func RenderNotification(body string, data map[string]string) (string, error) {
+	tmpl, err := template.New("notification").Option("missingkey=error").Parse(body)
+	if err != nil {
+		return "", &RenderError{Stage: RenderStageParse, Err: err}
+	}
+	var out strings.Builder
+	if err := tmpl.Execute(&out, data); err != nil {
+		return "", &RenderError{Stage: RenderStageExecute, Err: err}
+	}
+	return out.String(), nil
+}
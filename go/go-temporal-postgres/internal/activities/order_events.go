@@ -0,0 +1,66 @@
+package activities
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+
+	"github.com/base-14/examples/go/go-temporal-postgres/internal/models"
+)
+
+// OrderEventActivities appends workflow outcomes to the order event log.
+// The order projector registers an instance on the projection queue.
+type OrderEventActivities struct {
+	DB *gorm.DB
+}
+
+type OrderEventInput struct {
+	OrderID      string    `json:"order_id"`
+	Status       string    `json:"status"`
+	DecisionPath string    `json:"decision_path"`
+	RiskScore    int       `json:"risk_score,omitempty"`
+	Message      string    `json:"message,omitempty"`
+	OccurredAt   time.Time `json:"occurred_at"`
+}
+
+// RecordOrderEvent appends an order.decided event. A retried attempt may
+// append the same decision twice, which projects to the same row.
+func (a *OrderEventActivities) RecordOrderEvent(ctx context.Context, input OrderEventInput) error {
+	ctx, span := otel.Tracer("activities").Start(ctx, "record_order_event",
+		trace.WithAttributes(
+			attribute.String("order.id", input.OrderID),
+			attribute.String("order.status", input.Status),
+			attribute.String("order.decision_path", input.DecisionPath),
+		),
+	)
+	defer span.End()
+
+	orderID, err := uuid.Parse(input.OrderID)
+	if err != nil {
+		span.RecordError(err)
+		return fmt.Errorf("invalid order id %q: %w", input.OrderID, err)
+	}
+
+	event := models.OrderEvent{
+		OrderID:      orderID,
+		Type:         models.OrderEventDecided,
+		Status:       input.Status,
+		DecisionPath: input.DecisionPath,
+		RiskScore:    input.RiskScore,
+		Message:      input.Message,
+		OccurredAt:   input.OccurredAt,
+	}
+	if err := a.DB.WithContext(ctx).Create(&event).Error; err != nil {
+		span.RecordError(err)
+		return err
+	}
+
+	span.SetAttributes(attribute.Int64("order_event.id", event.ID))
+	return nil
+}
@@ -0,0 +1,85 @@
+package activities
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+
+	"github.com/base-14/examples/go/go-temporal-postgres/internal/models"
+	"github.com/base-14/examples/go/go-temporal-postgres/internal/telemetry"
+)
+
+// OrderItemActivities records per-item fulfillment state in order_items.
+// Like OrderEventActivities, the order projector registers an instance on
+// the projection queue.
+type OrderItemActivities struct {
+	DB *gorm.DB
+}
+
+type ItemStatusUpdate struct {
+	ProductID string                 `json:"product_id"`
+	Status    models.OrderItemStatus `json:"status"`
+}
+
+type UpdateItemStatusInput struct {
+	OrderID    string             `json:"order_id"`
+	Items      []ItemStatusUpdate `json:"items"`
+	OccurredAt time.Time          `json:"occurred_at"`
+}
+
+// UpdateItemStatus moves the order's items for each product to its status.
+// An item only moves from a status listed by Sources, so a retried attempt
+// changes nothing and is not counted twice in orders.items.status_changes.
+func (a *OrderItemActivities) UpdateItemStatus(ctx context.Context, input UpdateItemStatusInput) error {
+	ctx, span := otel.Tracer("activities").Start(ctx, "update_item_status",
+		trace.WithAttributes(
+			attribute.String("order.id", input.OrderID),
+			attribute.Int("order.item_count", len(input.Items)),
+		),
+	)
+	defer span.End()
+
+	orderID, err := uuid.Parse(input.OrderID)
+	if err != nil {
+		span.RecordError(err)
+		return fmt.Errorf("invalid order id %q: %w", input.OrderID, err)
+	}
+
+	changed := make(map[models.OrderItemStatus]int64)
+	err = a.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, item := range input.Items {
+			sources := item.Status.Sources()
+			if len(sources) == 0 {
+				return fmt.Errorf("no item moves to status %q", item.Status)
+			}
+			res := tx.Model(&models.OrderItem{}).
+				Where("order_id = ? AND product_id = ? AND status IN ?", orderID, item.ProductID, sources).
+				Updates(map[string]any{"status": item.Status, "status_updated_at": input.OccurredAt})
+			if res.Error != nil {
+				return res.Error
+			}
+			changed[item.Status] += res.RowsAffected
+		}
+		return nil
+	})
+	if err != nil {
+		span.RecordError(err)
+		return err
+	}
+
+	var total int64
+	for status, n := range changed {
+		if n > 0 {
+			telemetry.RecordOrderItemStatusChanges(ctx, string(status), n)
+		}
+		total += n
+	}
+	span.SetAttributes(attribute.Int64("order.items_changed", total))
+	return nil
+}
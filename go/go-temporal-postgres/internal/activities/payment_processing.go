@@ -19,8 +19,9 @@ var (
 	paymentAttemptsCount metric.Int64Counter
 	paymentFailuresCount metric.Int64Counter
 	paymentSuccessCount  metric.Int64Counter
-	paymentAmountTotal   metric.Float64Counter
+	paymentAmountTotal   metric.Int64Counter
 	paymentLatency       metric.Float64Histogram
+	paymentRefundsCount  metric.Int64Counter
 )
 
 func init() {
@@ -50,9 +51,9 @@ func init() {
 		panic(err)
 	}
 
-	paymentAmountTotal, err = paymentMeter.Float64Counter("payment.amount.total",
-		metric.WithDescription("Total payment amount processed"),
-		metric.WithUnit("{USD}"),
+	paymentAmountTotal, err = paymentMeter.Int64Counter("payment.amount.total",
+		metric.WithDescription("Total payment amount processed, in minor units of the currency attribute"),
+		metric.WithUnit("{minor_unit}"),
 	)
 	if err != nil {
 		panic(err)
@@ -66,6 +67,14 @@ func init() {
 	if err != nil {
 		panic(err)
 	}
+
+	paymentRefundsCount, err = paymentMeter.Int64Counter("payment.refunds",
+		metric.WithDescription("Payments refunded by order workflow compensation"),
+		metric.WithUnit("{refund}"),
+	)
+	if err != nil {
+		panic(err)
+	}
 }
 
 func ProcessPayment(ctx context.Context, input PaymentInput) (*PaymentResult, error) {
@@ -76,7 +85,8 @@ func ProcessPayment(ctx context.Context, input PaymentInput) (*PaymentResult, er
 		trace.WithAttributes(
 			attribute.String("order.id", input.OrderID),
 			attribute.String("customer.id", input.CustomerID),
-			attribute.Float64("payment.amount", input.Amount),
+			attribute.Int64("payment.amount_minor", input.Amount.Amount),
+			attribute.String("payment.currency", string(input.Amount.Currency)),
 			attribute.String("temporal.activity_id", activityInfo.ActivityID),
 			attribute.String("temporal.workflow_id", activityInfo.WorkflowExecution.ID),
 		),
@@ -111,7 +121,8 @@ func ProcessPayment(ctx context.Context, input PaymentInput) (*PaymentResult, er
 				attribute.String("workflow_id", activityInfo.WorkflowExecution.ID),
 				attribute.String("trace_id", traceID),
 				attribute.String("decline_reason", "test_decline"),
-				attribute.Float64("amount", input.Amount),
+				attribute.Int64("amount_minor", input.Amount.Amount),
+				attribute.String("currency", string(input.Amount.Currency)),
 			),
 		)
 
@@ -126,7 +137,7 @@ func ProcessPayment(ctx context.Context, input PaymentInput) (*PaymentResult, er
 		slog.ErrorContext(ctx, "payment declined",
 			slog.String("order_id", input.OrderID),
 			slog.String("customer_id", input.CustomerID),
-			slog.Float64("amount", input.Amount),
+			slog.String("amount", input.Amount.String()),
 			slog.String("decline_reason", "test_decline"),
 			slog.String("workflow_id", activityInfo.WorkflowExecution.ID),
 			slog.String("trace_id", traceID),
@@ -148,7 +159,9 @@ func ProcessPayment(ctx context.Context, input PaymentInput) (*PaymentResult, er
 	)
 
 	paymentSuccessCount.Add(ctx, 1, commonAttrs)
-	paymentAmountTotal.Add(ctx, input.Amount, commonAttrs)
+	paymentAmountTotal.Add(ctx, input.Amount.Amount, commonAttrs,
+		metric.WithAttributes(attribute.String("currency", string(input.Amount.Currency))),
+	)
 
 	latencyMs := float64(activity.GetInfo(ctx).StartedTime.Sub(startTime).Milliseconds())
 	paymentLatency.Record(ctx, latencyMs,
@@ -161,7 +174,7 @@ func ProcessPayment(ctx context.Context, input PaymentInput) (*PaymentResult, er
 	slog.InfoContext(ctx, "payment processed successfully",
 		slog.String("order_id", input.OrderID),
 		slog.String("customer_id", input.CustomerID),
-		slog.Float64("amount", input.Amount),
+		slog.String("amount", input.Amount.String()),
 		slog.String("transaction_id", transactionID),
 		slog.String("workflow_id", activityInfo.WorkflowExecution.ID),
 		slog.String("trace_id", traceID),
@@ -173,3 +186,48 @@ func ProcessPayment(ctx context.Context, input PaymentInput) (*PaymentResult, er
 		TransactionID: transactionID,
 	}, nil
 }
+
+// RefundPayment reverses a payment taken by ProcessPayment. The order workflow
+// runs it as a compensation when a step after payment fails, so it is retried
+// until it succeeds and a repeated call for the same transaction must be safe.
+func RefundPayment(ctx context.Context, input RefundInput) (*RefundResult, error) {
+	activityInfo := activity.GetInfo(ctx)
+
+	ctx, span := otel.Tracer("activities").Start(ctx, "refund_payment",
+		trace.WithAttributes(
+			attribute.String("order.id", input.OrderID),
+			attribute.String("customer.id", input.CustomerID),
+			attribute.String("payment.transaction_id", input.TransactionID),
+			attribute.Int64("payment.amount_minor", input.Amount.Amount),
+			attribute.String("payment.currency", string(input.Amount.Currency)),
+			attribute.String("refund.reason", input.Reason),
+			attribute.String("temporal.workflow_id", activityInfo.WorkflowExecution.ID),
+		),
+	)
+	defer span.End()
+
+	refundID := fmt.Sprintf("rfd-%s", uuid.New().String()[:8])
+
+	span.SetStatus(codes.Ok, "payment refunded")
+	span.SetAttributes(attribute.String("refund.id", refundID))
+
+	paymentRefundsCount.Add(ctx, 1, metric.WithAttributes(
+		attribute.String("reason", input.Reason),
+		attribute.String("currency", string(input.Amount.Currency)),
+	))
+
+	slog.InfoContext(ctx, "payment refunded",
+		slog.String("order_id", input.OrderID),
+		slog.String("customer_id", input.CustomerID),
+		slog.String("amount", input.Amount.String()),
+		slog.String("transaction_id", input.TransactionID),
+		slog.String("refund_id", refundID),
+		slog.String("reason", input.Reason),
+		slog.String("workflow_id", activityInfo.WorkflowExecution.ID),
+	)
+
+	return &RefundResult{
+		Refunded: true,
+		RefundID: refundID,
+	}, nil
+}
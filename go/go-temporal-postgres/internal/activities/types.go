@@ -1,15 +1,17 @@
 package activities
 
+import "github.com/base-14/examples/go/go-temporal-postgres/pkg/money"
+
 type OrderItem struct {
-	ProductID string  `json:"product_id"`
-	Quantity  int     `json:"quantity"`
-	Price     float64 `json:"price"`
+	ProductID string      `json:"product_id"`
+	Quantity  int         `json:"quantity"`
+	Price     money.Money `json:"price"`
 }
 
 type ValidateOrderInput struct {
 	OrderID     string      `json:"order_id"`
 	CustomerID  string      `json:"customer_id"`
-	TotalAmount float64     `json:"total_amount"`
+	TotalAmount money.Money `json:"total_amount"`
 	Items       []OrderItem `json:"items"`
 }
 
@@ -19,10 +21,10 @@ type ValidateOrderResult struct {
 }
 
 type FraudAssessmentInput struct {
-	OrderID      string  `json:"order_id"`
-	CustomerID   string  `json:"customer_id"`
-	CustomerTier string  `json:"customer_tier"`
-	TotalAmount  float64 `json:"total_amount"`
+	OrderID      string      `json:"order_id"`
+	CustomerID   string      `json:"customer_id"`
+	CustomerTier string      `json:"customer_tier"`
+	TotalAmount  money.Money `json:"total_amount"`
 }
 
 type FraudAssessmentResult struct {
@@ -47,9 +49,9 @@ type UnavailableItem struct {
 }
 
 type PaymentInput struct {
-	OrderID    string  `json:"order_id"`
-	CustomerID string  `json:"customer_id"`
-	Amount     float64 `json:"amount"`
+	OrderID    string      `json:"order_id"`
+	CustomerID string      `json:"customer_id"`
+	Amount     money.Money `json:"amount"`
 }
 
 type PaymentResult struct {
@@ -58,6 +60,28 @@ type PaymentResult struct {
 	Reason        string `json:"reason,omitempty"`
 }
 
+// RefundInput reverses a captured payment. Reason names the step whose
+// failure made the workflow compensate.
+type RefundInput struct {
+	OrderID       string      `json:"order_id"`
+	CustomerID    string      `json:"customer_id"`
+	TransactionID string      `json:"transaction_id"`
+	Amount        money.Money `json:"amount"`
+	Reason        string      `json:"reason"`
+}
+
+type RefundResult struct {
+	Refunded bool   `json:"refunded"`
+	RefundID string `json:"refund_id,omitempty"`
+}
+
+// ReleaseInventoryInput gives back the stock an order reserved.
+type ReleaseInventoryInput struct {
+	OrderID string      `json:"order_id"`
+	Items   []OrderItem `json:"items"`
+	Reason  string      `json:"reason"`
+}
+
 type ShippingInput struct {
 	OrderID    string      `json:"order_id"`
 	CustomerID string      `json:"customer_id"`
@@ -69,9 +93,51 @@ type ShippingResult struct {
 	TrackingID string `json:"tracking_id,omitempty"`
 }
 
+// Shipment statuses a carrier reports, in the order a parcel reaches them.
+const (
+	ShipmentAwaitingPickup = "awaiting_pickup"
+	ShipmentPickedUp       = "picked_up"
+	ShipmentInTransit      = "in_transit"
+	ShipmentDelivered      = "delivered"
+)
+
+// NextShipmentStatus is the status a parcel moves to from status, or status
+// itself once delivered.
+func NextShipmentStatus(status string) string {
+	switch status {
+	case ShipmentAwaitingPickup:
+		return ShipmentPickedUp
+	case ShipmentPickedUp:
+		return ShipmentInTransit
+	case ShipmentInTransit:
+		return ShipmentDelivered
+	}
+	return status
+}
+
+type CarrierStatusInput struct {
+	OrderID    string `json:"order_id"`
+	TrackingID string `json:"tracking_id"`
+	// Status is the last status the tracker saw.
+	Status string `json:"status"`
+}
+
+type CarrierStatusResult struct {
+	Status string `json:"status"`
+}
+
 type NotificationInput struct {
 	OrderID    string `json:"order_id"`
 	CustomerID string `json:"customer_id"`
 	Type       string `json:"type"`
-	Message    string `json:"message"`
+	// Message, when set, is sent as is, as on a redelivery of a logged
+	// message. Otherwise the worker renders the type's template in Locale
+	// (default en) with Data.
+	Message string            `json:"message,omitempty"`
+	Locale  string            `json:"locale,omitempty"`
+	Data    map[string]string `json:"data,omitempty"`
+
+	// Channel defaults to email; Source to order_workflow.
+	Channel string `json:"channel,omitempty"`
+	Source  string `json:"source,omitempty"`
 }
@@ -13,7 +13,8 @@ func ValidateOrder(ctx context.Context, input ValidateOrderInput) (*ValidateOrde
 		trace.WithAttributes(
 			attribute.String("order.id", input.OrderID),
 			attribute.String("customer.id", input.CustomerID),
-			attribute.Float64("order.amount", input.TotalAmount),
+			attribute.Int64("order.amount_minor", input.TotalAmount.Amount),
+			attribute.String("order.currency", string(input.TotalAmount.Currency)),
 			attribute.Int("order.item_count", len(input.Items)),
 		),
 	)
@@ -35,7 +36,15 @@ func ValidateOrder(ctx context.Context, input ValidateOrderInput) (*ValidateOrde
 		}, nil
 	}
 
-	if input.TotalAmount <= 0 {
+	if !input.TotalAmount.Currency.Valid() {
+		span.SetAttributes(attribute.String("validation.failure", "invalid_currency"))
+		return &ValidateOrderResult{
+			Valid:  false,
+			Reason: "order currency is not supported",
+		}, nil
+	}
+
+	if !input.TotalAmount.IsPositive() {
 		span.SetAttributes(attribute.String("validation.failure", "invalid_amount"))
 		return &ValidateOrderResult{
 			Valid:  false,
@@ -51,6 +60,13 @@ func ValidateOrder(ctx context.Context, input ValidateOrderInput) (*ValidateOrde
 				Reason: "item quantity must be greater than zero",
 			}, nil
 		}
+		if item.Price.Currency != input.TotalAmount.Currency {
+			span.SetAttributes(attribute.String("validation.failure", "currency_mismatch"))
+			return &ValidateOrderResult{
+				Valid:  false,
+				Reason: "item currency must match order currency",
+			}, nil
+		}
 	}
 
 	span.SetAttributes(attribute.Bool("validation.passed", true))
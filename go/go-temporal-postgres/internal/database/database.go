@@ -31,3 +31,12 @@ func New(cfg Config) (*gorm.DB, error) {
 
 	return db, nil
 }
+
+// Close closes the connection pool behind db.
+func Close(db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
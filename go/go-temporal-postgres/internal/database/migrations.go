@@ -2,25 +2,78 @@ package database
 
 import (
 	"errors"
+	"fmt"
 
 	"github.com/base-14/examples/go/go-temporal-postgres/internal/models"
 	"gorm.io/gorm"
 )
 
 func Migrate(db *gorm.DB) error {
-	return db.AutoMigrate(
+	if err := migrateMinorUnits(db); err != nil {
+		return err
+	}
+	if err := db.AutoMigrate(
 		&models.Product{},
 		&models.Order{},
 		&models.OrderItem{},
-	)
+		&models.OrderNote{},
+		&models.ReorderSuggestion{},
+		&models.OrderEvent{},
+		&models.OrderSearch{},
+		&models.ProjectorCheckpoint{},
+		&models.NotificationPreference{},
+		&models.CustomerPurge{},
+		&models.NotificationLog{},
+		&models.NotificationTemplate{},
+	); err != nil {
+		return err
+	}
+
+	// Projectors read events in commit order, which sequential IDs alone do
+	// not give: a transaction can commit a later ID before an earlier one.
+	return db.Exec(`ALTER TABLE order_events
+		ADD COLUMN IF NOT EXISTS tx_id xid8 NOT NULL DEFAULT pg_current_xact_id()`).Error
+}
+
+// minorUnitColumns held major-unit floats before amounts moved to int64
+// minor units.
+var minorUnitColumns = []struct{ table, column string }{
+	{"products", "price"},
+	{"orders", "total_amount"},
+	{"order_items", "price"},
+}
+
+// migrateMinorUnits converts float amount columns to bigint cents. Every
+// amount stored as a float predates the currency column and is USD, so it
+// is scaled by 100; AutoMigrate alone would cast 29.99 to 30. Columns that
+// are missing or already integers are left to AutoMigrate.
+func migrateMinorUnits(db *gorm.DB) error {
+	for _, c := range minorUnitColumns {
+		var dataType string
+		if err := db.Raw(`SELECT data_type FROM information_schema.columns
+			WHERE table_schema = current_schema() AND table_name = ? AND column_name = ?`,
+			c.table, c.column).Scan(&dataType).Error; err != nil {
+			return err
+		}
+		switch dataType {
+		case "double precision", "real", "numeric":
+		default:
+			continue
+		}
+		if err := db.Exec(fmt.Sprintf(`ALTER TABLE %s ALTER COLUMN %s TYPE bigint USING round(%s * 100)::bigint`,
+			c.table, c.column, c.column)).Error; err != nil {
+			return fmt.Errorf("convert %s.%s to minor units: %w", c.table, c.column, err)
+		}
+	}
+	return nil
 }
 
 func Seed(db *gorm.DB) error {
 	products := []models.Product{
-		{SKU: "prod-1", Name: "Widget A", Description: "Standard widget", Price: 29.99, Stock: 100},
-		{SKU: "prod-2", Name: "Widget B", Description: "Premium widget", Price: 49.99, Stock: 50},
-		{SKU: "prod-3", Name: "Widget C", Description: "Enterprise widget", Price: 99.99, Stock: 25},
-		{SKU: "out-of-stock-item", Name: "Rare Widget", Description: "Very rare widget", Price: 199.99, Stock: 0},
+		{SKU: "prod-1", Name: "Widget A", Description: "Standard widget", Price: 2999, Currency: "USD", Stock: 100},
+		{SKU: "prod-2", Name: "Widget B", Description: "Premium widget", Price: 4999, Currency: "USD", Stock: 50},
+		{SKU: "prod-3", Name: "Widget C", Description: "Enterprise widget", Price: 9999, Currency: "USD", Stock: 25},
+		{SKU: "out-of-stock-item", Name: "Rare Widget", Description: "Very rare widget", Price: 19999, Currency: "USD", Stock: 0},
 	}
 
 	for _, p := range products {
@@ -34,3 +87,16 @@ func Seed(db *gorm.DB) error {
 
 	return nil
 }
+
+// SeedNotificationTemplates inserts the default notification templates that
+// are not in notification_templates yet. Rows already there, edited or not,
+// are left alone.
+func SeedNotificationTemplates(db *gorm.DB) error {
+	for _, t := range models.DefaultNotificationTemplates {
+		if err := db.Where(models.NotificationTemplate{Type: t.Type, Channel: t.Channel, Locale: t.Locale}).
+			FirstOrCreate(&t).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
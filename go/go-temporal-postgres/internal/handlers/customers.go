@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	enums "go.temporal.io/api/enums/v1"
+	"go.temporal.io/api/serviceerror"
+	"go.temporal.io/sdk/client"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/base-14/examples/go/go-temporal-postgres/internal/models"
+	"github.com/base-14/examples/go/go-temporal-postgres/internal/workflows"
+)
+
+var notificationChannels = map[string]bool{"email": true, "sms": true, "push": true}
+
+type CustomerHandler struct {
+	db             *gorm.DB
+	temporalClient client.Client
+}
+
+func NewCustomerHandler(db *gorm.DB, temporalClient client.Client) *CustomerHandler {
+	return &CustomerHandler{db: db, temporalClient: temporalClient}
+}
+
+type SetPreferenceRequest struct {
+	Channel string `json:"channel"`
+	Enabled bool   `json:"enabled"`
+}
+
+// SetNotificationPreference turns one notification channel on or off for a
+// customer.
+func (h *CustomerHandler) SetNotificationPreference(c echo.Context) error {
+	customerID := strings.TrimSpace(c.Param("customer_id"))
+	if customerID == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "customer_id is required")
+	}
+
+	var req SetPreferenceRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	if !notificationChannels[req.Channel] {
+		return echo.NewHTTPError(http.StatusBadRequest, "channel must be email, sms or push")
+	}
+
+	pref := models.NotificationPreference{
+		CustomerID: customerID,
+		Channel:    req.Channel,
+		Enabled:    req.Enabled,
+	}
+	if err := h.db.WithContext(c.Request().Context()).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "customer_id"}, {Name: "channel"}},
+		DoUpdates: clause.AssignmentColumns([]string{"enabled", "updated_at"}),
+	}).Create(&pref).Error; err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to save preference")
+	}
+
+	return c.JSON(http.StatusOK, pref)
+}
+
+// NotificationPreferences lists a customer's notification preferences.
+func (h *CustomerHandler) NotificationPreferences(c echo.Context) error {
+	customerID := c.Param("customer_id")
+
+	prefs := []models.NotificationPreference{}
+	if err := h.db.WithContext(c.Request().Context()).
+		Where("customer_id = ?", customerID).Order("channel").Find(&prefs).Error; err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to fetch preferences")
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"customer_id": customerID,
+		"preferences": prefs,
+	})
+}
+
+// Purge starts the customer's data purge. Requesting it again while it runs
+// returns the running purge rather than starting a second one.
+func (h *CustomerHandler) Purge(c echo.Context) error {
+	customerID := strings.TrimSpace(c.Param("customer_id"))
+	if customerID == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "customer_id is required")
+	}
+
+	run, err := h.temporalClient.ExecuteWorkflow(c.Request().Context(), client.StartWorkflowOptions{
+		ID:        workflows.CustomerPurgeWorkflowID(customerID),
+		TaskQueue: workflows.CustomerPurgeQueue,
+	}, workflows.CustomerPurgeWorkflow, workflows.CustomerPurgeInput{CustomerID: customerID})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to start purge workflow")
+	}
+
+	return c.JSON(http.StatusAccepted, map[string]interface{}{
+		"workflow_id": run.GetID(),
+		"run_id":      run.GetRunID(),
+		"status":      "running",
+	})
+}
+
+// PurgeStatus returns the completion report of the customer's latest purge,
+// or its workflow state while it has not finished.
+func (h *CustomerHandler) PurgeStatus(c echo.Context) error {
+	workflowID := workflows.CustomerPurgeWorkflowID(c.Param("customer_id"))
+	ctx := c.Request().Context()
+
+	desc, err := h.temporalClient.DescribeWorkflowExecution(ctx, workflowID, "")
+	var notFound *serviceerror.NotFound
+	switch {
+	case errors.As(err, &notFound):
+		// Temporal may have dropped the history; the report may remain.
+	case err != nil:
+		return echo.NewHTTPError(http.StatusBadGateway, "failed to describe purge workflow")
+	case desc.GetWorkflowExecutionInfo().GetStatus() != enums.WORKFLOW_EXECUTION_STATUS_COMPLETED:
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"workflow_id": workflowID,
+			"status":      workflowStatusName(desc.GetWorkflowExecutionInfo().GetStatus()),
+		})
+	}
+
+	var report models.CustomerPurge
+	if err := h.db.WithContext(ctx).Where("workflow_id = ?", workflowID).First(&report).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, "no purge for this customer")
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to fetch purge report")
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"workflow_id": workflowID,
+		"status":      "completed",
+		"report":      report,
+	})
+}
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+
+	"github.com/base-14/examples/go/go-temporal-postgres/internal/models"
+)
+
+type InventoryHandler struct {
+	db *gorm.DB
+}
+
+func NewInventoryHandler(db *gorm.DB) *InventoryHandler {
+	return &InventoryHandler{db: db}
+}
+
+// ReorderSuggestions returns the output of the most recent forecast run,
+// largest reorder quantity first.
+func (h *InventoryHandler) ReorderSuggestions(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	var latest models.ReorderSuggestion
+	if err := h.db.WithContext(ctx).Order("generated_at DESC").First(&latest).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.JSON(http.StatusOK, map[string]interface{}{
+				"suggestions": []models.ReorderSuggestion{},
+			})
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to fetch reorder suggestions")
+	}
+
+	query := h.db.WithContext(ctx).Where("run_id = ?", latest.RunID)
+	if sku := c.QueryParam("sku"); sku != "" {
+		query = query.Where("sku = ?", sku)
+	}
+
+	var suggestions []models.ReorderSuggestion
+	if err := query.Order("reorder_qty DESC").Find(&suggestions).Error; err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to fetch reorder suggestions")
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"run_id":       latest.RunID,
+		"generated_at": latest.GeneratedAt,
+		"suggestions":  suggestions,
+	})
+}
@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+
+	"github.com/base-14/examples/go/go-temporal-postgres/internal/models"
+	"github.com/base-14/examples/go/go-temporal-postgres/internal/projector"
+	"github.com/base-14/examples/go/go-temporal-postgres/pkg/money"
+)
+
+const (
+	defaultSearchLimit = 50
+	maxSearchLimit     = 500
+)
+
+// OrderSearchHandler serves order search from the order_search read model,
+// never from the orders table or Temporal.
+type OrderSearchHandler struct {
+	db *gorm.DB
+}
+
+func NewOrderSearchHandler(db *gorm.DB) *OrderSearchHandler {
+	return &OrderSearchHandler{db: db}
+}
+
+// Search filters orders by status, customer_tier, customer_id, decision,
+// placed_after/placed_before (RFC 3339) and min_total/max_total. Totals are
+// in major units of `currency` (default USD) and only match orders in that
+// currency. Results are newest first, paged with limit and offset.
+func (h *OrderSearchHandler) Search(c echo.Context) error {
+	ctx := c.Request().Context()
+	query := h.db.WithContext(ctx).Model(&models.OrderSearch{})
+
+	for param, column := range map[string]string{
+		"status":        "status",
+		"customer_tier": "customer_tier",
+		"customer_id":   "customer_id",
+		"decision":      "latest_decision",
+	} {
+		if v := c.QueryParam(param); v != "" {
+			query = query.Where(column+" = ?", v)
+		}
+	}
+
+	for param, op := range map[string]string{"placed_after": ">=", "placed_before": "<"} {
+		if raw := c.QueryParam(param); raw != "" {
+			t, err := time.Parse(time.RFC3339, raw)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, "invalid "+param)
+			}
+			query = query.Where("placed_at "+op+" ?", t)
+		}
+	}
+
+	minTotal, maxTotal := c.QueryParam("min_total"), c.QueryParam("max_total")
+	if minTotal != "" || maxTotal != "" {
+		currency, err := money.ParseCurrency(c.QueryParam("currency"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		query = query.Where("currency = ?", string(currency))
+		for _, bound := range []struct{ raw, op string }{{minTotal, ">="}, {maxTotal, "<="}} {
+			if bound.raw == "" {
+				continue
+			}
+			amount, err := strconv.ParseFloat(bound.raw, 64)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, "invalid total")
+			}
+			m, err := money.FromMajor(amount, currency)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+			}
+			query = query.Where("total_amount "+bound.op+" ?", m.Amount)
+		}
+	}
+
+	limit, offset := defaultSearchLimit, 0
+	if raw := c.QueryParam("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 || n > maxSearchLimit {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid limit")
+		}
+		limit = n
+	}
+	if raw := c.QueryParam("offset"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 0 {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid offset")
+		}
+		offset = n
+	}
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to search orders")
+	}
+
+	orders := []models.OrderSearch{}
+	if err := query.Order("placed_at DESC").Limit(limit).Offset(offset).Find(&orders).Error; err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to search orders")
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"orders":     orders,
+		"count":      len(orders),
+		"total":      total,
+		"limit":      limit,
+		"offset":     offset,
+		"projection": h.projection(c),
+	})
+}
+
+// projection reports how far the read model has caught up, so callers can
+// tell a missing order from one that has not been projected yet.
+func (h *OrderSearchHandler) projection(c echo.Context) map[string]interface{} {
+	var checkpoint models.ProjectorCheckpoint
+	if err := h.db.WithContext(c.Request().Context()).
+		Where("name = ?", projector.OrderSearchName).First(&checkpoint).Error; err != nil {
+		return map[string]interface{}{"position": 0}
+	}
+
+	info := map[string]interface{}{
+		"position":      checkpoint.Position,
+		"last_event_at": checkpoint.LastEventAt,
+		"updated_at":    checkpoint.UpdatedAt,
+	}
+	// Lag is measured when the last event was applied, not now: an idle
+	// projector is caught up, not falling behind.
+	if !checkpoint.LastEventAt.IsZero() {
+		info["lag_seconds"] = checkpoint.UpdatedAt.Sub(checkpoint.LastEventAt).Seconds()
+	}
+	return info
+}
@@ -1,40 +1,70 @@
 package handlers
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
+	enums "go.temporal.io/api/enums/v1"
+	"go.temporal.io/api/serviceerror"
 	"go.temporal.io/sdk/client"
 	"gorm.io/gorm"
 
 	"github.com/base-14/examples/go/go-temporal-postgres/internal/models"
+	"github.com/base-14/examples/go/go-temporal-postgres/internal/telemetry"
 	"github.com/base-14/examples/go/go-temporal-postgres/internal/workflows"
+	"github.com/base-14/examples/go/go-temporal-postgres/pkg/money"
+)
+
+const (
+	defaultDuplicateWindow = 5 * time.Minute
+	defaultItemPrice       = 10.00
+	defaultNotesLimit      = 50
+	maxNoteLength          = 4000
+	maxNoteAuthorLength    = 100
 )
 
 type OrderHandler struct {
-	db             *gorm.DB
-	temporalClient client.Client
-	taskQueue      string
+	db              *gorm.DB
+	temporalClient  client.Client
+	taskQueue       string
+	duplicateWindow time.Duration
 }
 
 func NewOrderHandler(db *gorm.DB, temporalClient client.Client, taskQueue string) *OrderHandler {
 	return &OrderHandler{
-		db:             db,
-		temporalClient: temporalClient,
-		taskQueue:      taskQueue,
+		db:              db,
+		temporalClient:  temporalClient,
+		taskQueue:       taskQueue,
+		duplicateWindow: defaultDuplicateWindow,
 	}
 }
 
+// WithDuplicateWindow sets how far back Create looks for an identical cart from
+// the same customer. A zero window disables duplicate detection.
+func (h *OrderHandler) WithDuplicateWindow(window time.Duration) *OrderHandler {
+	h.duplicateWindow = window
+	return h
+}
+
 type CreateOrderRequest struct {
 	CustomerID    string            `json:"customer_id"`
 	CustomerTier  string            `json:"customer_tier"`
+	Currency      string            `json:"currency,omitempty"`
 	Items         []CreateOrderItem `json:"items"`
 	PaymentMethod string            `json:"payment_method,omitempty"`
+	// Locale picks the language of the order's notifications (e.g. "es").
+	Locale string `json:"locale,omitempty"`
 }
 
+// CreateOrderItem prices are given in major units (e.g. 29.99) and converted to
+// minor units at the API boundary.
 type CreateOrderItem struct {
 	ProductID string  `json:"product_id"`
 	Quantity  int     `json:"quantity"`
@@ -54,24 +84,31 @@ func (h *OrderHandler) Create(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, "at least one item is required")
 	}
 
-	var totalAmount float64
+	currency, err := money.ParseCurrency(req.Currency)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	totalAmount := money.New(0, currency)
 	orderItems := make([]models.OrderItem, 0, len(req.Items))
 	workflowItems := make([]workflows.OrderItemInput, 0, len(req.Items))
 	for _, item := range req.Items {
-		price := item.Price
-		if price == 0 {
-			var product models.Product
-			if err := h.db.WithContext(c.Request().Context()).Where("sku = ?", item.ProductID).First(&product).Error; err == nil {
-				price = product.Price
-			} else {
-				price = 10.00
-			}
+		price, err := h.resolvePrice(c, item, currency)
+		if err != nil {
+			return err
+		}
+		lineAmount, err := price.Mul(item.Quantity)
+		if err == nil {
+			totalAmount, err = totalAmount.Add(lineAmount)
+		}
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 		}
-		totalAmount += price * float64(item.Quantity)
 		orderItems = append(orderItems, models.OrderItem{
 			ProductID: item.ProductID,
 			Quantity:  item.Quantity,
-			Price:     price,
+			Price:     price.Amount,
+			Status:    models.OrderItemStatusPending,
 		})
 		workflowItems = append(workflowItems, workflows.OrderItemInput{
 			ProductID: item.ProductID,
@@ -80,6 +117,18 @@ func (h *OrderHandler) Create(c echo.Context) error {
 		})
 	}
 
+	cartHash := models.ComputeCartHash(req.CustomerID, string(currency), orderItems)
+	if existing, found, err := h.findDuplicate(c, req.CustomerID, cartHash); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to check for duplicate order")
+	} else if found {
+		telemetry.RecordOrderDeduplicated(c.Request().Context(), existing.CustomerTier)
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"order":       existing,
+			"workflow_id": existing.WorkflowID,
+			"duplicate":   true,
+		})
+	}
+
 	customerID := req.CustomerID
 	if req.PaymentMethod == "test_decline" {
 		customerID = "test_decline"
@@ -89,7 +138,9 @@ func (h *OrderHandler) Create(c echo.Context) error {
 		CustomerID:   req.CustomerID,
 		CustomerTier: req.CustomerTier,
 		Status:       models.OrderStatusPending,
-		TotalAmount:  totalAmount,
+		TotalAmount:  totalAmount.Amount,
+		Currency:     string(currency),
+		CartHash:     cartHash,
 		Items:        orderItems,
 	}
 
@@ -97,7 +148,16 @@ func (h *OrderHandler) Create(c echo.Context) error {
 		order.CustomerTier = "standard"
 	}
 
-	if err := h.db.WithContext(c.Request().Context()).Create(&order).Error; err != nil {
+	// The placed event is written with the order so the search read model
+	// never misses one.
+	ctx := c.Request().Context()
+	if err := h.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&order).Error; err != nil {
+			return err
+		}
+		event := models.PlacedEvent(&order, order.CreatedAt)
+		return tx.Create(&event).Error
+	}); err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, "failed to create order")
 	}
 
@@ -108,6 +168,7 @@ func (h *OrderHandler) Create(c echo.Context) error {
 		CustomerTier: order.CustomerTier,
 		TotalAmount:  totalAmount,
 		Items:        workflowItems,
+		Locale:       strings.ToLower(strings.TrimSpace(req.Locale)),
 	}
 
 	workflowOptions := client.StartWorkflowOptions{
@@ -115,16 +176,26 @@ func (h *OrderHandler) Create(c echo.Context) error {
 		TaskQueue: h.taskQueue,
 	}
 
-	_, err := h.temporalClient.ExecuteWorkflow(c.Request().Context(), workflowOptions, workflows.OrderFulfillmentWorkflow, workflowInput)
+	_, err = h.temporalClient.ExecuteWorkflow(ctx, workflowOptions, workflows.OrderFulfillmentWorkflow, workflowInput)
 	if err != nil {
 		order.Status = models.OrderStatusCancelled
-		h.db.WithContext(c.Request().Context()).Save(&order)
+		h.saveWithEvent(ctx, &order, models.OrderEvent{
+			OrderID: order.ID,
+			Type:    models.OrderEventCancelled,
+			Status:  string(order.Status),
+			Message: "workflow failed to start",
+		})
 		return echo.NewHTTPError(http.StatusInternalServerError, "failed to start workflow: "+err.Error())
 	}
 
 	order.WorkflowID = workflowID
 	order.Status = models.OrderStatusProcessing
-	h.db.WithContext(c.Request().Context()).Save(&order)
+	h.saveWithEvent(ctx, &order, models.OrderEvent{
+		OrderID:    order.ID,
+		Type:       models.OrderEventStarted,
+		Status:     string(order.Status),
+		WorkflowID: workflowID,
+	})
 
 	return c.JSON(http.StatusCreated, map[string]interface{}{
 		"order":       order,
@@ -132,6 +203,67 @@ func (h *OrderHandler) Create(c echo.Context) error {
 	})
 }
 
+// saveWithEvent saves an order's new state and the event describing it in
+// one transaction. Errors are not reported to the client: by then the
+// workflow has already been started or refused, which the response reflects.
+func (h *OrderHandler) saveWithEvent(ctx context.Context, order *models.Order, event models.OrderEvent) {
+	event.OccurredAt = time.Now()
+	_ = h.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(order).Error; err != nil {
+			return err
+		}
+		return tx.Create(&event).Error
+	})
+}
+
+// resolvePrice converts a client-supplied price, or falls back to the catalogue
+// price for the SKU. Catalogue prices in another currency are rejected rather
+// than converted.
+func (h *OrderHandler) resolvePrice(c echo.Context, item CreateOrderItem, currency money.Currency) (money.Money, error) {
+	if item.Price != 0 {
+		price, err := money.FromMajor(item.Price, currency)
+		if err != nil {
+			return money.Money{}, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("invalid price for %s: %v", item.ProductID, err))
+		}
+		return price, nil
+	}
+
+	var product models.Product
+	if err := h.db.WithContext(c.Request().Context()).Where("sku = ?", item.ProductID).First(&product).Error; err != nil {
+		price, _ := money.FromMajor(defaultItemPrice, currency)
+		return price, nil
+	}
+	if product.Currency != string(currency) {
+		return money.Money{}, echo.NewHTTPError(http.StatusBadRequest,
+			fmt.Sprintf("product %s is priced in %s, order currency is %s", item.ProductID, product.Currency, currency))
+	}
+	return money.New(product.Price, currency), nil
+}
+
+// findDuplicate looks for an order from the same customer with an identical
+// cart placed within the duplicate window. Orders whose workflow failed to
+// start are ignored so the customer can retry.
+func (h *OrderHandler) findDuplicate(c echo.Context, customerID, cartHash string) (*models.Order, bool, error) {
+	if h.duplicateWindow <= 0 {
+		return nil, false, nil
+	}
+
+	var existing models.Order
+	err := h.db.WithContext(c.Request().Context()).
+		Preload("Items").
+		Where("customer_id = ? AND cart_hash = ? AND created_at >= ?", customerID, cartHash, time.Now().Add(-h.duplicateWindow)).
+		Where("status <> ?", models.OrderStatusCancelled).
+		Order("created_at DESC").
+		First(&existing).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	return &existing, true, nil
+}
+
 func (h *OrderHandler) List(c echo.Context) error {
 	var orders []models.Order
 	if err := h.db.WithContext(c.Request().Context()).Preload("Items").Find(&orders).Error; err != nil {
@@ -156,7 +288,321 @@ func (h *OrderHandler) Get(c echo.Context) error {
 		}
 		return echo.NewHTTPError(http.StatusInternalServerError, "failed to fetch order")
 	}
+
+	itemStatuses := make(map[models.OrderItemStatus]int)
+	for _, item := range order.Items {
+		itemStatuses[item.Status]++
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"order":         order,
+		"item_statuses": itemStatuses,
+	})
+}
+
+// Status reports where the order's fulfillment workflow is. The orders table
+// only tracks submission, so the workflow's own state and result are the
+// source of truth once it has started.
+func (h *OrderHandler) Status(c echo.Context) error {
+	parsedID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid order id")
+	}
+
+	ctx := c.Request().Context()
+	var order models.Order
+	if err := h.db.WithContext(ctx).Where("id = ?", parsedID).First(&order).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, "order not found")
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to fetch order")
+	}
+
+	if order.WorkflowID == "" {
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"order_id": order.ID,
+			"status":   order.Status,
+			"terminal": order.Status == models.OrderStatusCancelled,
+		})
+	}
+
+	desc, err := h.temporalClient.DescribeWorkflowExecution(ctx, order.WorkflowID, "")
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadGateway, "failed to describe workflow")
+	}
+	info := desc.GetWorkflowExecutionInfo()
+
+	resp := map[string]interface{}{
+		"order_id":        order.ID,
+		"workflow_id":     order.WorkflowID,
+		"workflow_status": workflowStatusName(info.GetStatus()),
+		"started_at":      info.GetStartTime().AsTime(),
+	}
+
+	switch info.GetStatus() {
+	case enums.WORKFLOW_EXECUTION_STATUS_RUNNING:
+		resp["status"] = models.OrderStatusProcessing
+		resp["terminal"] = false
+		// The stage is informational; a workflow too busy to answer the
+		// query is still reported as processing.
+		if encoded, err := h.temporalClient.QueryWorkflow(ctx, order.WorkflowID, "", workflows.OrderStageQuery); err == nil {
+			var stage string
+			if encoded.Get(&stage) == nil {
+				resp["stage"] = stage
+			}
+		}
+		// A completed order keeps its workflow running while the shipment
+		// is tracked; its outcome is already final.
+		if resp["stage"] == workflows.StageTracking {
+			if encoded, err := h.temporalClient.QueryWorkflow(ctx, order.WorkflowID, "", workflows.OrderStatusQuery); err == nil {
+				var progress workflows.OrderProgress
+				if encoded.Get(&progress) == nil && progress.Terminal {
+					resp["status"] = progress.Status
+					resp["decision_path"] = progress.DecisionPath
+					resp["shipment"] = progress.Shipment
+					resp["terminal"] = true
+				}
+			}
+		}
+	case enums.WORKFLOW_EXECUTION_STATUS_COMPLETED:
+		var result workflows.OrderResult
+		if err := h.temporalClient.GetWorkflow(ctx, order.WorkflowID, "").Get(ctx, &result); err != nil {
+			return echo.NewHTTPError(http.StatusBadGateway, "failed to fetch workflow result")
+		}
+		resp["status"] = result.Status
+		resp["decision_path"] = result.DecisionPath
+		resp["terminal"] = true
+		resp["closed_at"] = info.GetCloseTime().AsTime()
+	default:
+		resp["status"] = workflowStatusName(info.GetStatus())
+		resp["terminal"] = true
+		resp["closed_at"] = info.GetCloseTime().AsTime()
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// Cancel asks the order's workflow to stop (`?reason=` is passed along).
+// The workflow stops at its next step and rolls back what the order has done,
+// refunding a payment already taken. Orders past payment and into shipping,
+// or already finished, can no longer be cancelled.
+func (h *OrderHandler) Cancel(c echo.Context) error {
+	parsedID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid order id")
+	}
+
+	ctx := c.Request().Context()
+	var order models.Order
+	if err := h.db.WithContext(ctx).Where("id = ?", parsedID).First(&order).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, "order not found")
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to fetch order")
+	}
+	if order.WorkflowID == "" {
+		return echo.NewHTTPError(http.StatusConflict, "order has no running workflow")
+	}
+
+	var notFound *serviceerror.NotFound
+	encoded, err := h.temporalClient.QueryWorkflow(ctx, order.WorkflowID, "", workflows.OrderStageQuery)
+	if errors.As(err, &notFound) {
+		return echo.NewHTTPError(http.StatusConflict, "order has already finished")
+	}
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadGateway, "failed to query workflow")
+	}
+	var stage string
+	if err := encoded.Get(&stage); err != nil {
+		return echo.NewHTTPError(http.StatusBadGateway, "failed to query workflow")
+	}
+	if !workflows.Cancellable(stage) {
+		return echo.NewHTTPError(http.StatusConflict, fmt.Sprintf("order can no longer be cancelled (stage %s)", stage))
+	}
+
+	reason := strings.TrimSpace(c.QueryParam("reason"))
+	if reason == "" {
+		reason = "customer_request"
+	}
+	err = h.temporalClient.SignalWorkflow(ctx, order.WorkflowID, "", workflows.CancelOrderSignal, workflows.CancelRequest{Reason: reason})
+	if errors.As(err, &notFound) {
+		return echo.NewHTTPError(http.StatusConflict, "order has already finished")
+	}
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadGateway, "failed to signal workflow")
+	}
+
+	return c.JSON(http.StatusAccepted, map[string]interface{}{
+		"order_id":         order.ID,
+		"workflow_id":      order.WorkflowID,
+		"stage":            stage,
+		"cancel_requested": true,
+	})
+}
+
+type AddNoteRequest struct {
+	Author string `json:"author"`
+	Body   string `json:"body"`
+}
+
+// AddNote stores a support note on an order. If the order's workflow is
+// still running the note is also signalled to it, so an order held for
+// manual review exposes it through the review-notes query.
+func (h *OrderHandler) AddNote(c echo.Context) error {
+	parsedID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid order id")
+	}
+
+	var req AddNoteRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	req.Author = strings.TrimSpace(req.Author)
+	req.Body = strings.TrimSpace(req.Body)
+	if req.Author == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "author is required")
+	}
+	if req.Body == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "body is required")
+	}
+	if len(req.Author) > maxNoteAuthorLength {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("author exceeds %d characters", maxNoteAuthorLength))
+	}
+	if len(req.Body) > maxNoteLength {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("body exceeds %d characters", maxNoteLength))
+	}
+
+	ctx := c.Request().Context()
+	var order models.Order
+	if err := h.db.WithContext(ctx).Where("id = ?", parsedID).First(&order).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, "order not found")
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to fetch order")
+	}
+
+	note := models.OrderNote{
+		OrderID: order.ID,
+		Author:  req.Author,
+		Body:    req.Body,
+	}
+	if err := h.db.WithContext(ctx).Create(&note).Error; err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to save note")
+	}
+
+	// The note is already saved, so a closed workflow is not an error; it
+	// just has no one left to tell.
+	signalled := false
+	if order.WorkflowID != "" {
+		err := h.temporalClient.SignalWorkflow(ctx, order.WorkflowID, "", workflows.OrderNoteSignal, workflows.ReviewNote{
+			Author:    note.Author,
+			Body:      note.Body,
+			CreatedAt: note.CreatedAt,
+		})
+		var notFound *serviceerror.NotFound
+		switch {
+		case err == nil:
+			signalled = true
+		case !errors.As(err, &notFound):
+			return echo.NewHTTPError(http.StatusBadGateway, "note saved but failed to signal workflow")
+		}
+	}
+
+	return c.JSON(http.StatusCreated, map[string]interface{}{
+		"note":      note,
+		"signalled": signalled,
+	})
+}
+
+// Notes lists an order's notes, newest first (`?limit=`, default 50).
+func (h *OrderHandler) Notes(c echo.Context) error {
+	parsedID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid order id")
+	}
+
+	limit := defaultNotesLimit
+	if raw := c.QueryParam("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid limit")
+		}
+		limit = n
+	}
+
+	ctx := c.Request().Context()
+	var count int64
+	if err := h.db.WithContext(ctx).Model(&models.Order{}).Where("id = ?", parsedID).Count(&count).Error; err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to fetch order")
+	}
+	if count == 0 {
+		return echo.NewHTTPError(http.StatusNotFound, "order not found")
+	}
+
+	notes := []models.OrderNote{}
+	if err := h.db.WithContext(ctx).Where("order_id = ?", parsedID).
+		Order("created_at DESC").Limit(limit).Find(&notes).Error; err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to fetch notes")
+	}
+
 	return c.JSON(http.StatusOK, map[string]interface{}{
-		"order": order,
+		"order_id": parsedID,
+		"notes":    notes,
 	})
 }
+
+// Notifications lists every delivery attempt for an order, oldest first,
+// and whether each notification (type and channel) was eventually delivered.
+func (h *OrderHandler) Notifications(c echo.Context) error {
+	parsedID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid order id")
+	}
+
+	ctx := c.Request().Context()
+	var count int64
+	if err := h.db.WithContext(ctx).Model(&models.Order{}).Where("id = ?", parsedID).Count(&count).Error; err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to fetch order")
+	}
+	if count == 0 {
+		return echo.NewHTTPError(http.StatusNotFound, "order not found")
+	}
+
+	attempts := []models.NotificationLog{}
+	if err := h.db.WithContext(ctx).Where("order_id = ?", parsedID).
+		Order("created_at, id").Find(&attempts).Error; err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to fetch notifications")
+	}
+
+	type summary struct {
+		Type      string `json:"type"`
+		Channel   string `json:"channel"`
+		Status    string `json:"status"`
+		Attempts  int    `json:"attempts"`
+		Delivered bool   `json:"delivered"`
+	}
+	summaries := []*summary{}
+	byKey := map[string]*summary{}
+	for _, a := range attempts {
+		key := a.Type + "/" + a.Channel
+		s, ok := byKey[key]
+		if !ok {
+			s = &summary{Type: a.Type, Channel: a.Channel}
+			byKey[key] = s
+			summaries = append(summaries, s)
+		}
+		s.Status = a.Status
+		s.Attempts++
+		s.Delivered = a.Status == models.NotificationStatusSent
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"order_id":      parsedID,
+		"notifications": summaries,
+		"attempts":      attempts,
+	})
+}
+
+func workflowStatusName(s enums.WorkflowExecutionStatus) string {
+	return strings.ToLower(strings.TrimPrefix(s.String(), "WorkflowExecutionStatus"))
+}
@@ -0,0 +1,13 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/base-14/examples/go/pkg/buildinfo"
+)
+
+func Version(c echo.Context) error {
+	return c.JSON(http.StatusOK, buildinfo.Get())
+}
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// NotificationPreference is whether a customer receives notifications on
+// one channel (email, sms, push).
+type NotificationPreference struct {
+	ID         uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	CustomerID string    `gorm:"not null;uniqueIndex:idx_notification_preferences_customer_channel" json:"customer_id"`
+	Channel    string    `gorm:"type:varchar(20);not null;uniqueIndex:idx_notification_preferences_customer_channel" json:"channel"`
+	Enabled    bool      `gorm:"not null" json:"enabled"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+func (p *NotificationPreference) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	return nil
+}
+
+// CustomerPurge is the completion report of a customer data purge, keyed by
+// the purge workflow's ID. It names the customer only by the pseudonym their
+// records were rewritten to.
+type CustomerPurge struct {
+	WorkflowID           string    `gorm:"type:varchar(100);primaryKey" json:"workflow_id"`
+	AnonymizedID         string    `gorm:"type:varchar(64);not null" json:"anonymized_id"`
+	OrdersAnonymized     int64     `json:"orders_anonymized"`
+	NotesRedacted        int64     `json:"notes_redacted"`
+	EventsAnonymized     int64     `json:"events_anonymized"`
+	SearchRowsAnonymized int64     `json:"search_rows_anonymized"`
+	PreferencesDeleted   int64     `json:"preferences_deleted"`
+	WorkflowsSignalled   int       `json:"workflows_signalled"`
+	RequestedAt          time.Time `gorm:"not null" json:"requested_at"`
+	CompletedAt          time.Time `gorm:"not null" json:"completed_at"`
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Notification delivery outcomes and where an attempt came from.
+const (
+	NotificationStatusSent   = "sent"
+	NotificationStatusFailed = "failed"
+
+	NotificationSourceWorkflow   = "order_workflow"
+	NotificationSourceRedelivery = "redelivery"
+
+	DefaultNotificationChannel = "email"
+)
+
+// NotificationLog is one attempt to deliver an order notification. Rows are
+// only ever appended; a notification, identified by order, type and channel,
+// is delivered once its latest attempt was sent. Attempt counts every try of
+// that notification, across workflow retries and redeliveries.
+type NotificationLog struct {
+	ID         int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	OrderID    uuid.UUID `gorm:"type:uuid;not null;index:idx_notification_log_order_created" json:"order_id"`
+	CustomerID string    `gorm:"not null;index" json:"customer_id"`
+	Type       string    `gorm:"type:varchar(50);not null" json:"type"`
+	Channel    string    `gorm:"type:varchar(20);not null" json:"channel"`
+	Message    string    `gorm:"type:text" json:"message"`
+	Status     string    `gorm:"type:varchar(20);not null" json:"status"`
+	Error      string    `gorm:"type:text" json:"error,omitempty"`
+	Attempt    int       `gorm:"not null" json:"attempt"`
+	Source     string    `gorm:"type:varchar(20);not null" json:"source"`
+	CreatedAt  time.Time `gorm:"not null;index:idx_notification_log_order_created" json:"created_at"`
+}
+
+func (NotificationLog) TableName() string {
+	return "notification_log"
+}
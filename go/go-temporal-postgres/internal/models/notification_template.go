@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+// DefaultNotificationLocale is the locale used when an order names none, and
+// the one a template falls back to when its locale has no row.
+const DefaultNotificationLocale = "en"
+
+// GenericNotificationBody is sent when nothing better renders. It names only
+// the order, which every notification has.
+const GenericNotificationBody = "There is an update on your order {{.order_id}}."
+
+// NotificationTemplate is the body of one notification type on one channel
+// in one locale, a text/template rendered with the order data the workflow
+// sends (order_id, customer_id, total, item_count).
+type NotificationTemplate struct {
+	ID        int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	Type      string    `gorm:"type:varchar(50);not null;uniqueIndex:idx_notification_templates_key" json:"type"`
+	Channel   string    `gorm:"type:varchar(20);not null;uniqueIndex:idx_notification_templates_key" json:"channel"`
+	Locale    string    `gorm:"type:varchar(10);not null;uniqueIndex:idx_notification_templates_key" json:"locale"`
+	Body      string    `gorm:"type:text;not null" json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// DefaultNotificationTemplates are seeded into notification_templates when
+// missing. The default-locale email bodies also serve as the built-in
+// fallback when a stored template cannot be found or rendered.
+var DefaultNotificationTemplates = []NotificationTemplate{
+	{Type: "order_confirmed", Channel: "email", Locale: "en", Body: "Your order {{.order_id}} for {{.total}} has been confirmed and is being processed."},
+	{Type: "order_confirmed", Channel: "sms", Locale: "en", Body: "Order {{.order_id}} confirmed ({{.total}})."},
+	{Type: "order_confirmed", Channel: "email", Locale: "es", Body: "Tu pedido {{.order_id}} por {{.total}} ha sido confirmado y se está procesando."},
+	{Type: "manual_review", Channel: "email", Locale: "en", Body: "Your order {{.order_id}} is under review."},
+	{Type: "manual_review", Channel: "email", Locale: "es", Body: "Tu pedido {{.order_id}} está en revisión."},
+	{Type: "backorder", Channel: "email", Locale: "en", Body: "Some items in your order {{.order_id}} are currently out of stock. We'll notify you when they become available."},
+	{Type: "backorder", Channel: "email", Locale: "es", Body: "Algunos artículos de tu pedido {{.order_id}} están agotados. Te avisaremos cuando vuelvan a estar disponibles."},
+}
+
+// DefaultNotificationBody is the built-in body for a notification type, or a
+// generic one for a type with no default.
+func DefaultNotificationBody(notificationType string) string {
+	for _, t := range DefaultNotificationTemplates {
+		if t.Type == notificationType && t.Channel == DefaultNotificationChannel && t.Locale == DefaultNotificationLocale {
+			return t.Body
+		}
+	}
+	return GenericNotificationBody
+}
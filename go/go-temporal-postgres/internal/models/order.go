@@ -1,6 +1,11 @@
 package models
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -25,10 +30,12 @@ type Order struct {
 	CustomerID   string      `gorm:"not null;index" json:"customer_id"`
 	CustomerTier string      `gorm:"default:'standard'" json:"customer_tier"`
 	Status       OrderStatus `gorm:"type:varchar(50);default:'pending';index" json:"status"`
-	TotalAmount  float64     `gorm:"not null" json:"total_amount"`
+	TotalAmount  int64       `gorm:"not null" json:"total_amount"`
+	Currency     string      `gorm:"type:char(3);not null;default:'USD'" json:"currency"`
 	RiskScore    int         `gorm:"default:0" json:"risk_score"`
 	DecisionPath string      `gorm:"type:varchar(50)" json:"decision_path,omitempty"`
 	WorkflowID   string      `gorm:"index" json:"workflow_id,omitempty"`
+	CartHash     string      `gorm:"type:varchar(64);index" json:"-"`
 	Items        []OrderItem `gorm:"foreignKey:OrderID" json:"items"`
 	CreatedAt    time.Time   `json:"created_at"`
 	UpdatedAt    time.Time   `json:"updated_at"`
@@ -41,13 +48,65 @@ func (o *Order) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
 
+// ComputeCartHash returns a stable fingerprint of a customer's cart. Item order
+// does not matter, so the same basket submitted twice hashes identically.
+func ComputeCartHash(customerID, currency string, items []OrderItem) string {
+	lines := make([]string, 0, len(items))
+	for _, item := range items {
+		lines = append(lines, fmt.Sprintf("%s:%d:%d", item.ProductID, item.Quantity, item.Price))
+	}
+	sort.Strings(lines)
+
+	sum := sha256.Sum256([]byte(customerID + "|" + currency + "|" + strings.Join(lines, ",")))
+	return hex.EncodeToString(sum[:])
+}
+
+// OrderItemStatus is where one line of an order stands in fulfillment. The
+// order's own status says how the order was decided; item statuses say what
+// happened to the goods.
+type OrderItemStatus string
+
+const (
+	OrderItemStatusPending     OrderItemStatus = "pending"
+	OrderItemStatusReserved    OrderItemStatus = "reserved"
+	OrderItemStatusBackordered OrderItemStatus = "backordered"
+	// OrderItemStatusReleased is a reservation given back because the order
+	// stopped before the customer was charged.
+	OrderItemStatusReleased OrderItemStatus = "released"
+	OrderItemStatusShipped  OrderItemStatus = "shipped"
+	// OrderItemStatusDelivered is an item the carrier reports delivered.
+	OrderItemStatusDelivered OrderItemStatus = "delivered"
+	// OrderItemStatusRefunded is an item paid for that could not be shipped.
+	OrderItemStatusRefunded OrderItemStatus = "refunded"
+)
+
+// orderItemTransitions lists the statuses each status can be reached from.
+var orderItemTransitions = map[OrderItemStatus][]OrderItemStatus{
+	OrderItemStatusReserved:    {OrderItemStatusPending, OrderItemStatusBackordered},
+	OrderItemStatusBackordered: {OrderItemStatusPending},
+	OrderItemStatusReleased:    {OrderItemStatusReserved},
+	OrderItemStatusShipped:     {OrderItemStatusReserved},
+	OrderItemStatusDelivered:   {OrderItemStatusShipped},
+	OrderItemStatusRefunded:    {OrderItemStatusReserved, OrderItemStatusShipped},
+}
+
+// Sources returns the statuses an item may move to s from. It is empty for
+// pending and for unknown statuses, which no item moves to.
+func (s OrderItemStatus) Sources() []OrderItemStatus {
+	return orderItemTransitions[s]
+}
+
 type OrderItem struct {
-	ID        uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
-	OrderID   uuid.UUID `gorm:"type:uuid;not null;index" json:"order_id"`
-	ProductID string    `gorm:"not null" json:"product_id"`
-	Quantity  int       `gorm:"not null" json:"quantity"`
-	Price     float64   `gorm:"not null" json:"price"`
-	CreatedAt time.Time `json:"created_at"`
+	ID        uuid.UUID       `gorm:"type:uuid;primaryKey" json:"id"`
+	OrderID   uuid.UUID       `gorm:"type:uuid;not null;index" json:"order_id"`
+	ProductID string          `gorm:"not null" json:"product_id"`
+	Quantity  int             `gorm:"not null" json:"quantity"`
+	Price     int64           `gorm:"not null" json:"price"`
+	Status    OrderItemStatus `gorm:"type:varchar(20);not null;default:'pending';index" json:"status"`
+	// StatusUpdatedAt is when the workflow moved the item to Status, unset
+	// while it is pending.
+	StatusUpdatedAt *time.Time `json:"status_updated_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
 }
 
 func (oi *OrderItem) BeforeCreate(tx *gorm.DB) error {
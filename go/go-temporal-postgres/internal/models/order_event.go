@@ -0,0 +1,52 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Order event types. Placed, started and cancelled are written by the API;
+// decided is written by the fulfillment workflow at each outcome.
+const (
+	OrderEventPlaced    = "order.placed"
+	OrderEventStarted   = "order.workflow_started"
+	OrderEventCancelled = "order.cancelled"
+	OrderEventDecided   = "order.decided"
+)
+
+// OrderEvent is an append-only record of something that happened to an
+// order. Projectors checkpoint against the writing transaction's ID (the
+// tx_id column added in Migrate) and then the sequential ID.
+// Only placed events carry the order's customer and totals.
+type OrderEvent struct {
+	ID           int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	OrderID      uuid.UUID `gorm:"type:uuid;not null;index" json:"order_id"`
+	Type         string    `gorm:"type:varchar(50);not null" json:"type"`
+	Status       string    `gorm:"type:varchar(50);not null" json:"status"`
+	DecisionPath string    `gorm:"type:varchar(50)" json:"decision_path,omitempty"`
+	RiskScore    int       `json:"risk_score,omitempty"`
+	Message      string    `gorm:"type:text" json:"message,omitempty"`
+	CustomerID   string    `json:"customer_id,omitempty"`
+	CustomerTier string    `json:"customer_tier,omitempty"`
+	TotalAmount  int64     `json:"total_amount,omitempty"`
+	Currency     string    `gorm:"type:char(3)" json:"currency,omitempty"`
+	ItemCount    int       `json:"item_count,omitempty"`
+	WorkflowID   string    `json:"workflow_id,omitempty"`
+	OccurredAt   time.Time `gorm:"not null" json:"occurred_at"`
+}
+
+// PlacedEvent records a newly created order, before its workflow starts.
+func PlacedEvent(o *Order, at time.Time) OrderEvent {
+	return OrderEvent{
+		OrderID:      o.ID,
+		Type:         OrderEventPlaced,
+		Status:       string(o.Status),
+		CustomerID:   o.CustomerID,
+		CustomerTier: o.CustomerTier,
+		TotalAmount:  o.TotalAmount,
+		Currency:     o.Currency,
+		ItemCount:    len(o.Items),
+		OccurredAt:   at,
+	}
+}
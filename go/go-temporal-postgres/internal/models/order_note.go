@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// OrderNote is a support agent's annotation on an order.
+type OrderNote struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	OrderID   uuid.UUID `gorm:"type:uuid;not null;index:idx_order_notes_order_created" json:"order_id"`
+	Author    string    `gorm:"type:varchar(100);not null" json:"author"`
+	Body      string    `gorm:"type:text;not null" json:"body"`
+	CreatedAt time.Time `gorm:"index:idx_order_notes_order_created" json:"created_at"`
+}
+
+func (n *OrderNote) BeforeCreate(tx *gorm.DB) error {
+	if n.ID == uuid.Nil {
+		n.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OrderSearch is the denormalized read model behind order search: one row
+// per order, built only from order events by the projector. It is
+// eventually consistent with the orders table and the workflows.
+type OrderSearch struct {
+	OrderID        uuid.UUID  `gorm:"type:uuid;primaryKey" json:"order_id"`
+	CustomerID     string     `gorm:"index" json:"customer_id"`
+	CustomerTier   string     `gorm:"type:varchar(50);index:idx_order_search_tier_placed" json:"customer_tier"`
+	Status         string     `gorm:"type:varchar(50);index:idx_order_search_status_placed" json:"status"`
+	TotalAmount    int64      `gorm:"index" json:"total_amount"`
+	Currency       string     `gorm:"type:char(3)" json:"currency"`
+	ItemCount      int        `json:"item_count"`
+	RiskScore      int        `json:"risk_score"`
+	LatestDecision string     `gorm:"type:varchar(50);index" json:"latest_decision,omitempty"`
+	DecisionReason string     `gorm:"type:text" json:"decision_reason,omitempty"`
+	DecidedAt      *time.Time `json:"decided_at,omitempty"`
+	WorkflowID     string     `json:"workflow_id,omitempty"`
+	PlacedAt       time.Time  `gorm:"index:idx_order_search_status_placed;index:idx_order_search_tier_placed" json:"placed_at"`
+	LastEventID    int64      `gorm:"not null" json:"last_event_id"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+func (OrderSearch) TableName() string { return "order_search" }
+
+// Apply folds an event into the row. Events are applied in commit order, but the
+// API's workflow_started event can land after the workflow's first decision,
+// so a lifecycle event never overwrites a status set by a decision.
+func (s *OrderSearch) Apply(ev OrderEvent) {
+	s.OrderID = ev.OrderID
+	s.LastEventID = ev.ID
+
+	switch ev.Type {
+	case OrderEventPlaced:
+		s.CustomerID = ev.CustomerID
+		s.CustomerTier = ev.CustomerTier
+		s.TotalAmount = ev.TotalAmount
+		s.Currency = ev.Currency
+		s.ItemCount = ev.ItemCount
+		s.PlacedAt = ev.OccurredAt
+		if s.DecidedAt == nil {
+			s.Status = ev.Status
+		}
+	case OrderEventStarted:
+		s.WorkflowID = ev.WorkflowID
+		if s.DecidedAt == nil {
+			s.Status = ev.Status
+		}
+	case OrderEventCancelled:
+		s.Status = ev.Status
+	case OrderEventDecided:
+		decidedAt := ev.OccurredAt
+		s.Status = ev.Status
+		s.LatestDecision = ev.DecisionPath
+		s.DecisionReason = ev.Message
+		s.DecidedAt = &decidedAt
+		if ev.RiskScore > 0 {
+			s.RiskScore = ev.RiskScore
+		}
+	}
+}
+
+// ProjectorCheckpoint is the last event a projector has applied, by the
+// writing transaction's ID and then the event ID.
+type ProjectorCheckpoint struct {
+	Name        string    `gorm:"type:varchar(100);primaryKey" json:"name"`
+	TxID        int64     `gorm:"not null;default:0" json:"tx_id"`
+	Position    int64     `gorm:"not null" json:"position"`
+	LastEventAt time.Time `json:"last_event_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
@@ -12,7 +12,8 @@ type Product struct {
 	SKU         string    `gorm:"uniqueIndex;not null" json:"sku"`
 	Name        string    `gorm:"not null" json:"name"`
 	Description string    `json:"description,omitempty"`
-	Price       float64   `gorm:"not null" json:"price"`
+	Price       int64     `gorm:"not null" json:"price"`
+	Currency    string    `gorm:"type:char(3);not null;default:'USD'" json:"currency"`
 	Stock       int       `gorm:"default:0" json:"stock"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ReorderSuggestion is one SKU's output from an inventory forecast run. Rows
+// from older runs are kept so suggestions can be compared over time.
+type ReorderSuggestion struct {
+	ID             uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	RunID          string    `gorm:"type:varchar(100);not null;index" json:"run_id"`
+	SKU            string    `gorm:"not null;index" json:"sku"`
+	CurrentStock   int       `gorm:"not null" json:"current_stock"`
+	AvgDailyDemand float64   `gorm:"not null" json:"avg_daily_demand"`
+	ForecastDemand float64   `gorm:"not null" json:"forecast_demand"`
+	SafetyStock    int       `gorm:"not null" json:"safety_stock"`
+	ReorderPoint   int       `gorm:"not null" json:"reorder_point"`
+	ReorderQty     int       `gorm:"not null" json:"reorder_qty"`
+	GeneratedAt    time.Time `gorm:"not null;index" json:"generated_at"`
+}
+
+func (r *ReorderSuggestion) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
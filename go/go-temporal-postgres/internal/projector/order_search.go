@@ -0,0 +1,187 @@
+// Package projector builds read models from the order event log.
+package projector
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/base-14/examples/go/go-temporal-postgres/internal/models"
+	"github.com/base-14/examples/go/go-temporal-postgres/internal/telemetry"
+)
+
+// OrderSearchName is the order search projector's checkpoint name.
+const OrderSearchName = "order_search"
+
+const (
+	defaultBatchSize = 200
+	defaultInterval  = time.Second
+)
+
+// Events are read in commit order: by writing transaction, and only from
+// transactions older than every one still in flight, so an event committed
+// late is never skipped.
+const pendingEventsSQL = `
+SELECT id, order_id, type, status, decision_path, risk_score, message,
+	customer_id, customer_tier, total_amount, currency, item_count, workflow_id,
+	occurred_at, tx_id::text::bigint AS tx_id
+FROM order_events
+WHERE (tx_id, id) > (?::text::xid8, ?)
+  AND tx_id < pg_snapshot_xmin(pg_current_snapshot())
+ORDER BY tx_id, id
+LIMIT ?`
+
+const backlogSQL = `SELECT COUNT(*) FROM order_events WHERE (tx_id, id) > (?::text::xid8, ?)`
+
+type eventRow struct {
+	models.OrderEvent
+	TxID int64
+}
+
+// OrderSearchProjector keeps the order_search table up to date from
+// order_events.
+type OrderSearchProjector struct {
+	DB        *gorm.DB
+	BatchSize int
+	Interval  time.Duration
+}
+
+func (p *OrderSearchProjector) batchSize() int {
+	if p.BatchSize > 0 {
+		return p.BatchSize
+	}
+	return defaultBatchSize
+}
+
+// Run projects events until ctx is cancelled. A full batch is followed
+// immediately by the next, so a backlog drains without waiting for Interval.
+func (p *OrderSearchProjector) Run(ctx context.Context) error {
+	interval := p.Interval
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+
+	for {
+		n, err := p.ProjectBatch(ctx)
+		if err != nil {
+			slog.Error("order search projection failed", slog.String("error", err.Error()))
+		}
+		if err == nil && n == p.batchSize() {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
+
+// ProjectBatch applies the next batch of events and advances the checkpoint
+// in the same transaction. It returns the number of events applied.
+func (p *OrderSearchProjector) ProjectBatch(ctx context.Context) (int, error) {
+	ctx, span := otel.Tracer("projector").Start(ctx, "project_order_search")
+	defer span.End()
+
+	var events []eventRow
+	var checkpoint models.ProjectorCheckpoint
+	err := p.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// The row lock keeps two projector replicas from applying the same
+		// batch.
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where(models.ProjectorCheckpoint{Name: OrderSearchName}).
+			FirstOrCreate(&checkpoint).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Raw(pendingEventsSQL, checkpoint.TxID, checkpoint.Position, p.batchSize()).
+			Scan(&events).Error; err != nil {
+			return err
+		}
+		if len(events) == 0 {
+			return nil
+		}
+
+		rows, err := p.apply(tx, events)
+		if err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(&rows).Error; err != nil {
+			return err
+		}
+
+		last := events[len(events)-1]
+		checkpoint.TxID = last.TxID
+		checkpoint.Position = last.ID
+		checkpoint.LastEventAt = last.OccurredAt
+		return tx.Save(&checkpoint).Error
+	})
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return 0, err
+	}
+
+	now := time.Now()
+	for _, ev := range events {
+		telemetry.RecordProjectedEvent(ctx, OrderSearchName, ev.Type, now.Sub(ev.OccurredAt).Seconds())
+	}
+
+	var backlog int64
+	if err := p.DB.WithContext(ctx).Raw(backlogSQL, checkpoint.TxID, checkpoint.Position).Scan(&backlog).Error; err == nil {
+		telemetry.RecordProjectorBacklog(ctx, OrderSearchName, backlog)
+	}
+
+	span.SetAttributes(
+		attribute.Int("projector.events", len(events)),
+		attribute.Int64("projector.position", checkpoint.Position),
+		attribute.Int64("projector.backlog", backlog),
+	)
+	return len(events), nil
+}
+
+// apply folds events into the current rows for their orders and returns the
+// rows to write.
+func (p *OrderSearchProjector) apply(tx *gorm.DB, events []eventRow) ([]models.OrderSearch, error) {
+	ids := make([]uuid.UUID, 0, len(events))
+	for _, ev := range events {
+		ids = append(ids, ev.OrderID)
+	}
+
+	var existing []models.OrderSearch
+	if err := tx.Where("order_id IN ?", ids).Find(&existing).Error; err != nil {
+		return nil, err
+	}
+	byOrder := make(map[uuid.UUID]*models.OrderSearch, len(existing))
+	for i := range existing {
+		byOrder[existing[i].OrderID] = &existing[i]
+	}
+
+	var order []uuid.UUID
+	for _, ev := range events {
+		row, ok := byOrder[ev.OrderID]
+		if !ok {
+			row = &models.OrderSearch{}
+			byOrder[ev.OrderID] = row
+		}
+		if !slices.Contains(order, ev.OrderID) {
+			order = append(order, ev.OrderID)
+		}
+		row.Apply(ev.OrderEvent)
+	}
+
+	rows := make([]models.OrderSearch, 0, len(order))
+	for _, id := range order {
+		rows = append(rows, *byOrder[id])
+	}
+	return rows, nil
+}
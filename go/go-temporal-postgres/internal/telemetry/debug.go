@@ -0,0 +1,174 @@
+package telemetry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// DebugMetricsPath is where the debug listener serves the process's metrics.
+const DebugMetricsPath = "/debug/metrics"
+
+// DebugSnapshot is the JSON body of DebugMetricsPath: every metric the
+// process has recorded, cumulative since it started.
+type DebugSnapshot struct {
+	Metrics []DebugMetric `json:"metrics"`
+}
+
+type DebugMetric struct {
+	Name   string       `json:"name"`
+	Points []DebugPoint `json:"points"`
+}
+
+// DebugPoint is one attribute set of a metric. Value is the running total
+// of a counter, the last reading of a gauge, or the sum of a histogram,
+// whose Count is the number of recordings.
+type DebugPoint struct {
+	Attributes map[string]string `json:"attributes"`
+	Value      float64           `json:"value"`
+	Count      uint64            `json:"count,omitempty"`
+}
+
+// Sum adds up Value over the points of name whose attributes include attrs.
+func (s DebugSnapshot) Sum(name string, attrs map[string]string) float64 {
+	var total float64
+	for _, p := range s.points(name, attrs) {
+		total += p.Value
+	}
+	return total
+}
+
+// Count adds up Count over the histogram points of name whose attributes
+// include attrs.
+func (s DebugSnapshot) Count(name string, attrs map[string]string) uint64 {
+	var total uint64
+	for _, p := range s.points(name, attrs) {
+		total += p.Count
+	}
+	return total
+}
+
+func (s DebugSnapshot) points(name string, attrs map[string]string) []DebugPoint {
+	var matched []DebugPoint
+	for _, m := range s.Metrics {
+		if m.Name != name {
+			continue
+		}
+	points:
+		for _, p := range m.Points {
+			for k, v := range attrs {
+				if p.Attributes[k] != v {
+					continue points
+				}
+			}
+			matched = append(matched, p)
+		}
+	}
+	return matched
+}
+
+// debugMetrics serves DebugMetricsPath from a reader of its own, so the
+// smoke test and anyone poking at a running stack can read what the process
+// recorded without going through the collector.
+type debugMetrics struct {
+	reader *metric.ManualReader
+	server *http.Server
+}
+
+func newDebugMetrics(addr string) *debugMetrics {
+	reader := metric.NewManualReader()
+	mux := http.NewServeMux()
+	mux.Handle("GET "+DebugMetricsPath, DebugMetricsHandler(reader))
+	return &debugMetrics{
+		reader: reader,
+		server: &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 5 * time.Second},
+	}
+}
+
+func (d *debugMetrics) start() error {
+	ln, err := net.Listen("tcp", d.server.Addr)
+	if err != nil {
+		return err
+	}
+	go func() {
+		if err := d.server.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("debug metrics server failed", slog.String("error", err.Error()))
+		}
+	}()
+	return nil
+}
+
+func (d *debugMetrics) shutdown(ctx context.Context) error {
+	if d == nil {
+		return nil
+	}
+	return d.server.Shutdown(ctx)
+}
+
+// DebugMetricsHandler answers with a DebugSnapshot collected from reader.
+func DebugMetricsHandler(reader metric.Reader) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var rm metricdata.ResourceMetrics
+		if err := reader.Collect(r.Context(), &rm); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(snapshot(&rm))
+	})
+}
+
+func snapshot(rm *metricdata.ResourceMetrics) DebugSnapshot {
+	s := DebugSnapshot{Metrics: []DebugMetric{}}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			dm := DebugMetric{Name: m.Name, Points: []DebugPoint{}}
+			switch data := m.Data.(type) {
+			case metricdata.Sum[int64]:
+				for _, p := range data.DataPoints {
+					dm.Points = append(dm.Points, DebugPoint{Attributes: attributes(p.Attributes), Value: float64(p.Value)})
+				}
+			case metricdata.Sum[float64]:
+				for _, p := range data.DataPoints {
+					dm.Points = append(dm.Points, DebugPoint{Attributes: attributes(p.Attributes), Value: p.Value})
+				}
+			case metricdata.Gauge[int64]:
+				for _, p := range data.DataPoints {
+					dm.Points = append(dm.Points, DebugPoint{Attributes: attributes(p.Attributes), Value: float64(p.Value)})
+				}
+			case metricdata.Gauge[float64]:
+				for _, p := range data.DataPoints {
+					dm.Points = append(dm.Points, DebugPoint{Attributes: attributes(p.Attributes), Value: p.Value})
+				}
+			case metricdata.Histogram[int64]:
+				for _, p := range data.DataPoints {
+					dm.Points = append(dm.Points, DebugPoint{Attributes: attributes(p.Attributes), Value: float64(p.Sum), Count: p.Count})
+				}
+			case metricdata.Histogram[float64]:
+				for _, p := range data.DataPoints {
+					dm.Points = append(dm.Points, DebugPoint{Attributes: attributes(p.Attributes), Value: p.Sum, Count: p.Count})
+				}
+			default:
+				continue
+			}
+			s.Metrics = append(s.Metrics, dm)
+		}
+	}
+	return s
+}
+
+func attributes(set attribute.Set) map[string]string {
+	m := make(map[string]string, set.Len())
+	for _, kv := range set.ToSlice() {
+		m[string(kv.Key)] = kv.Value.Emit()
+	}
+	return m
+}
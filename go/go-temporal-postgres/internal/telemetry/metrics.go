@@ -19,9 +19,27 @@ var (
 	ordersManualReview  metric.Int64Counter
 	ordersBackordered   metric.Int64Counter
 	ordersPaymentFailed metric.Int64Counter
+	ordersDeduplicated  metric.Int64Counter
 
 	orderProcessingDuration metric.Float64Histogram
 	fraudRiskScore          metric.Int64Histogram
+
+	projectorEvents  metric.Int64Counter
+	projectorLag     metric.Float64Histogram
+	projectorBacklog metric.Int64Gauge
+
+	customerPurges        metric.Int64Counter
+	customerPurgeDuration metric.Float64Histogram
+	customerPurgeRecords  metric.Int64Counter
+
+	notificationDeliveries   metric.Int64Counter
+	notificationUndelivered  metric.Int64Gauge
+	notificationRenderErrors metric.Int64Counter
+
+	orderItemStatusChanges metric.Int64Counter
+
+	shipmentStageTransitions metric.Int64Counter
+	shipmentStageDuration    metric.Float64Histogram
 )
 
 func initMetrics() {
@@ -77,6 +95,14 @@ func initMetrics() {
 		panic(err)
 	}
 
+	ordersDeduplicated, err = meter.Int64Counter("orders.deduplicated",
+		metric.WithDescription("Number of duplicate order submissions answered with an existing order"),
+		metric.WithUnit("{order}"),
+	)
+	if err != nil {
+		panic(err)
+	}
+
 	orderProcessingDuration, err = meter.Float64Histogram("orders.processing_duration",
 		metric.WithDescription("Order processing duration in seconds"),
 		metric.WithUnit("s"),
@@ -94,6 +120,105 @@ func initMetrics() {
 	if err != nil {
 		panic(err)
 	}
+
+	projectorEvents, err = meter.Int64Counter("orders.projector.events",
+		metric.WithDescription("Order events applied to a read model"),
+		metric.WithUnit("{event}"),
+	)
+	if err != nil {
+		panic(err)
+	}
+
+	projectorLag, err = meter.Float64Histogram("orders.projector.lag",
+		metric.WithDescription("Time from an order event occurring to its projection into a read model"),
+		metric.WithUnit("s"),
+		metric.WithExplicitBucketBoundaries(0.05, 0.1, 0.25, 0.5, 1, 2, 5, 10, 30, 60),
+	)
+	if err != nil {
+		panic(err)
+	}
+
+	projectorBacklog, err = meter.Int64Gauge("orders.projector.backlog",
+		metric.WithDescription("Order events not yet applied to a read model"),
+		metric.WithUnit("{event}"),
+	)
+	if err != nil {
+		panic(err)
+	}
+
+	customerPurges, err = meter.Int64Counter("customers.purges",
+		metric.WithDescription("Customer data purges finished"),
+		metric.WithUnit("{purge}"),
+	)
+	if err != nil {
+		panic(err)
+	}
+
+	customerPurgeDuration, err = meter.Float64Histogram("customers.purge.duration",
+		metric.WithDescription("Time from a customer data purge being requested to it finishing"),
+		metric.WithUnit("s"),
+		metric.WithExplicitBucketBoundaries(0.1, 0.5, 1, 2, 5, 10, 30, 60, 300),
+	)
+	if err != nil {
+		panic(err)
+	}
+
+	customerPurgeRecords, err = meter.Int64Counter("customers.purge.records",
+		metric.WithDescription("Records anonymized, redacted or deleted by customer data purges"),
+		metric.WithUnit("{record}"),
+	)
+	if err != nil {
+		panic(err)
+	}
+
+	notificationDeliveries, err = meter.Int64Counter("notifications.deliveries",
+		metric.WithDescription("Notification delivery attempts, by outcome; the sent share is the delivery success rate"),
+		metric.WithUnit("{attempt}"),
+	)
+	if err != nil {
+		panic(err)
+	}
+
+	notificationUndelivered, err = meter.Int64Gauge("notifications.undelivered",
+		metric.WithDescription("Notifications whose latest delivery attempt failed, pending redelivery or out of attempts"),
+		metric.WithUnit("{notification}"),
+	)
+	if err != nil {
+		panic(err)
+	}
+
+	notificationRenderErrors, err = meter.Int64Counter("notifications.template.render_errors",
+		metric.WithDescription("Notification templates that failed to parse or render; the built-in default was sent instead"),
+		metric.WithUnit("{error}"),
+	)
+	if err != nil {
+		panic(err)
+	}
+
+	orderItemStatusChanges, err = meter.Int64Counter("orders.items.status_changes",
+		metric.WithDescription("Order items moved to a fulfillment status, by the status reached"),
+		metric.WithUnit("{item}"),
+	)
+	if err != nil {
+		panic(err)
+	}
+
+	shipmentStageTransitions, err = meter.Int64Counter("shipments.stage_transitions",
+		metric.WithDescription("Shipments reaching a carrier status, by the status reached"),
+		metric.WithUnit("{shipment}"),
+	)
+	if err != nil {
+		panic(err)
+	}
+
+	shipmentStageDuration, err = meter.Float64Histogram("shipments.stage.duration",
+		metric.WithDescription("Time a shipment spent in a carrier status before moving on, by the status left"),
+		metric.WithUnit("s"),
+		metric.WithExplicitBucketBoundaries(60, 300, 900, 1800, 3600, 7200, 21600, 86400),
+	)
+	if err != nil {
+		panic(err)
+	}
 }
 
 func ensureMetrics() {
@@ -140,6 +265,13 @@ func RecordOrderPaymentFailed(ctx context.Context, reason string) {
 	))
 }
 
+func RecordOrderDeduplicated(ctx context.Context, customerTier string) {
+	ensureMetrics()
+	ordersDeduplicated.Add(ctx, 1, metric.WithAttributes(
+		attribute.String("customer_tier", customerTier),
+	))
+}
+
 func RecordOrderProcessingDuration(ctx context.Context, durationSeconds float64, decisionPath string) {
 	ensureMetrics()
 	orderProcessingDuration.Record(ctx, durationSeconds, metric.WithAttributes(
@@ -153,3 +285,82 @@ func RecordFraudRiskScore(ctx context.Context, score int, customerTier string) {
 		attribute.String("customer_tier", customerTier),
 	))
 }
+
+func RecordProjectedEvent(ctx context.Context, projector, eventType string, lagSeconds float64) {
+	ensureMetrics()
+	attrs := metric.WithAttributes(
+		attribute.String("projector", projector),
+		attribute.String("event_type", eventType),
+	)
+	projectorEvents.Add(ctx, 1, attrs)
+	projectorLag.Record(ctx, lagSeconds, attrs)
+}
+
+func RecordProjectorBacklog(ctx context.Context, projector string, backlog int64) {
+	ensureMetrics()
+	projectorBacklog.Record(ctx, backlog, metric.WithAttributes(
+		attribute.String("projector", projector),
+	))
+}
+
+func RecordCustomerPurge(ctx context.Context, outcome string, durationSeconds float64) {
+	ensureMetrics()
+	attrs := metric.WithAttributes(attribute.String("outcome", outcome))
+	customerPurges.Add(ctx, 1, attrs)
+	customerPurgeDuration.Record(ctx, durationSeconds, attrs)
+}
+
+func RecordCustomerPurgeRecords(ctx context.Context, recordType string, count int64) {
+	ensureMetrics()
+	customerPurgeRecords.Add(ctx, count, metric.WithAttributes(
+		attribute.String("record_type", recordType),
+	))
+}
+
+func RecordNotificationDelivery(ctx context.Context, channel, notificationType, status, source string) {
+	ensureMetrics()
+	notificationDeliveries.Add(ctx, 1, metric.WithAttributes(
+		attribute.String("channel", channel),
+		attribute.String("notification_type", notificationType),
+		attribute.String("status", status),
+		attribute.String("source", source),
+	))
+}
+
+func RecordNotificationsUndelivered(ctx context.Context, pending, exhausted int64) {
+	ensureMetrics()
+	notificationUndelivered.Record(ctx, pending, metric.WithAttributes(attribute.String("state", "pending")))
+	notificationUndelivered.Record(ctx, exhausted, metric.WithAttributes(attribute.String("state", "exhausted")))
+}
+
+func RecordNotificationRenderError(ctx context.Context, notificationType, channel, locale, stage string) {
+	ensureMetrics()
+	notificationRenderErrors.Add(ctx, 1, metric.WithAttributes(
+		attribute.String("notification_type", notificationType),
+		attribute.String("channel", channel),
+		attribute.String("locale", locale),
+		attribute.String("stage", stage),
+	))
+}
+
+func RecordOrderItemStatusChanges(ctx context.Context, status string, count int64) {
+	ensureMetrics()
+	orderItemStatusChanges.Add(ctx, count, metric.WithAttributes(
+		attribute.String("status", status),
+	))
+}
+
+// RecordShipmentStage records a shipment moving from one carrier status to
+// another after secondsInStage in the first. from is empty for the first
+// status seen, which has no duration to record.
+func RecordShipmentStage(ctx context.Context, from, to string, secondsInStage float64) {
+	ensureMetrics()
+	shipmentStageTransitions.Add(ctx, 1, metric.WithAttributes(
+		attribute.String("stage", to),
+	))
+	if from != "" {
+		shipmentStageDuration.Record(ctx, secondsInStage, metric.WithAttributes(
+			attribute.String("stage", from),
+		))
+	}
+}
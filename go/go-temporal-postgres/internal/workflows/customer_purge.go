@@ -0,0 +1,126 @@
+package workflows
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/google/uuid"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+
+	"github.com/base-14/examples/go/go-temporal-postgres/internal/activities"
+	"github.com/base-14/examples/go/go-temporal-postgres/internal/models"
+)
+
+const (
+	CustomerPurgeQueue = "customer-purge-queue"
+
+	// CustomerPurgeSignal asks an order workflow to stop: its customer's data
+	// is being purged. It carries no payload.
+	CustomerPurgeSignal = "customer-purge"
+)
+
+type CustomerPurgeInput struct {
+	CustomerID string `json:"customer_id"`
+}
+
+// CustomerPurgeWorkflowID is the workflow ID of a customer's purge. It is
+// derived from a hash, so the ID itself does not name the customer, and is
+// stable, so a second request while a purge runs joins it.
+func CustomerPurgeWorkflowID(customerID string) string {
+	sum := sha256.Sum256([]byte(customerID))
+	return "customer-purge-" + hex.EncodeToString(sum[:8])
+}
+
+// CustomerPurgeWorkflow removes a customer's personal data. It signals the
+// workflows of their orders to stop, rewrites their customer ID on orders,
+// order events and search rows to a random pseudonym, redacts the support
+// notes on those orders, and deletes their notification preferences. The
+// completion report is stored in customer_purges and returned.
+func CustomerPurgeWorkflow(ctx workflow.Context, input CustomerPurgeInput) (*models.CustomerPurge, error) {
+	logger := workflow.GetLogger(ctx)
+	requestedAt := workflow.Now(ctx)
+
+	ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: time.Minute,
+		RetryPolicy: &temporal.RetryPolicy{
+			InitialInterval:    time.Second,
+			BackoffCoefficient: 2.0,
+			MaximumInterval:    time.Minute,
+			MaximumAttempts:    10,
+		},
+	})
+
+	var a *activities.PurgeActivities
+
+	recordMetrics := func(outcome string, report *models.CustomerPurge) {
+		_ = workflow.ExecuteActivity(ctx, a.RecordPurgeMetrics, activities.RecordPurgeMetricsInput{
+			Outcome:      outcome,
+			DurationSecs: workflow.Now(ctx).Sub(requestedAt).Seconds(),
+			Report:       report,
+		}).Get(ctx, nil)
+	}
+	fail := func(step string, err error) (*models.CustomerPurge, error) {
+		logger.Error("Customer purge failed", "step", step, "error", err)
+		recordMetrics("failed", nil)
+		return nil, err
+	}
+
+	// The pseudonym must survive replay, and must not be derivable from
+	// the customer ID.
+	var anonymizedID string
+	if err := workflow.SideEffect(ctx, func(workflow.Context) interface{} {
+		return "anon-" + uuid.NewString()
+	}).Get(&anonymizedID); err != nil {
+		return nil, err
+	}
+
+	var signalled int
+	if err := workflow.ExecuteActivity(ctx, a.SignalCustomerWorkflows, activities.SignalCustomerWorkflowsInput{
+		CustomerID: input.CustomerID,
+		Signal:     CustomerPurgeSignal,
+	}).Get(ctx, &signalled); err != nil {
+		return fail("signal_workflows", err)
+	}
+
+	var anonymized activities.AnonymizeCustomerResult
+	if err := workflow.ExecuteActivity(ctx, a.AnonymizeCustomer, activities.AnonymizeCustomerInput{
+		CustomerID:   input.CustomerID,
+		AnonymizedID: anonymizedID,
+	}).Get(ctx, &anonymized); err != nil {
+		return fail("anonymize", err)
+	}
+
+	var deleted int64
+	if err := workflow.ExecuteActivity(ctx, a.DeleteNotificationPreferences, activities.DeletePreferencesInput{
+		CustomerID: input.CustomerID,
+	}).Get(ctx, &deleted); err != nil {
+		return fail("delete_preferences", err)
+	}
+
+	report := &models.CustomerPurge{
+		WorkflowID:           workflow.GetInfo(ctx).WorkflowExecution.ID,
+		AnonymizedID:         anonymizedID,
+		OrdersAnonymized:     anonymized.Orders,
+		NotesRedacted:        anonymized.Notes,
+		EventsAnonymized:     anonymized.Events,
+		SearchRowsAnonymized: anonymized.SearchRows,
+		PreferencesDeleted:   deleted,
+		WorkflowsSignalled:   signalled,
+		RequestedAt:          requestedAt,
+		CompletedAt:          workflow.Now(ctx),
+	}
+	if err := workflow.ExecuteActivity(ctx, a.SavePurgeReport, *report).Get(ctx, nil); err != nil {
+		return fail("save_report", err)
+	}
+	recordMetrics("completed", report)
+
+	logger.Info("Customer purge complete",
+		"anonymized_id", anonymizedID,
+		"orders", anonymized.Orders,
+		"preferences", deleted,
+		"workflows_signalled", signalled,
+	)
+	return report, nil
+}
@@ -0,0 +1,136 @@
+package workflows
+
+import (
+	"math"
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+
+	"github.com/base-14/examples/go/go-temporal-postgres/internal/activities"
+	"github.com/base-14/examples/go/go-temporal-postgres/internal/models"
+)
+
+const (
+	InventoryForecastQueue      = "inventory-forecast-queue"
+	InventoryForecastScheduleID = "inventory-forecast"
+
+	// serviceLevelZ is the one-sided z-score for a 95% service level.
+	serviceLevelZ = 1.65
+)
+
+type InventoryForecastInput struct {
+	LookbackDays int `json:"lookback_days"`
+	LeadTimeDays int `json:"lead_time_days"`
+	CoverageDays int `json:"coverage_days"`
+}
+
+type InventoryForecastResult struct {
+	RunID        string    `json:"run_id"`
+	SKUsAnalyzed int       `json:"skus_analyzed"`
+	ReorderCount int       `json:"reorder_count"`
+	LookbackDays int       `json:"lookback_days"`
+	GeneratedAt  time.Time `json:"generated_at"`
+}
+
+func DefaultInventoryForecastInput() InventoryForecastInput {
+	return InventoryForecastInput{LookbackDays: 30, LeadTimeDays: 7, CoverageDays: 14}
+}
+
+// InventoryForecastWorkflow reads recent demand per SKU, projects it over the
+// supplier lead time plus a coverage period, and stores a reorder suggestion
+// for every SKU whose stock is at or below its reorder point. It is normally
+// started by the inventory-forecast schedule.
+func InventoryForecastWorkflow(ctx workflow.Context, input InventoryForecastInput) (*InventoryForecastResult, error) {
+	logger := workflow.GetLogger(ctx)
+
+	defaults := DefaultInventoryForecastInput()
+	if input.LookbackDays <= 0 {
+		input.LookbackDays = defaults.LookbackDays
+	}
+	if input.LeadTimeDays <= 0 {
+		input.LeadTimeDays = defaults.LeadTimeDays
+	}
+	if input.CoverageDays <= 0 {
+		input.CoverageDays = defaults.CoverageDays
+	}
+
+	ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: 5 * time.Minute,
+		RetryPolicy: &temporal.RetryPolicy{
+			InitialInterval:    5 * time.Second,
+			BackoffCoefficient: 2.0,
+			MaximumInterval:    time.Minute,
+			MaximumAttempts:    5,
+		},
+	})
+
+	var a *activities.ForecastActivities
+
+	var demand []activities.SKUDemand
+	if err := workflow.ExecuteActivity(ctx, a.AnalyzeDemand, activities.AnalyzeDemandInput{
+		LookbackDays: input.LookbackDays,
+	}).Get(ctx, &demand); err != nil {
+		return nil, err
+	}
+
+	suggestions := BuildReorderSuggestions(demand, input.LeadTimeDays, input.CoverageDays)
+
+	info := workflow.GetInfo(ctx)
+	runID := info.WorkflowExecution.RunID
+	generatedAt := workflow.Now(ctx)
+
+	if err := workflow.ExecuteActivity(ctx, a.SaveReorderSuggestions, activities.SaveReorderSuggestionsInput{
+		RunID:       runID,
+		GeneratedAt: generatedAt,
+		Suggestions: suggestions,
+	}).Get(ctx, nil); err != nil {
+		return nil, err
+	}
+
+	logger.Info("Inventory forecast complete", "skus", len(demand), "reorders", len(suggestions))
+
+	return &InventoryForecastResult{
+		RunID:        runID,
+		SKUsAnalyzed: len(demand),
+		ReorderCount: len(suggestions),
+		LookbackDays: input.LookbackDays,
+		GeneratedAt:  generatedAt,
+	}, nil
+}
+
+// BuildReorderSuggestions applies a reorder-point policy: safety stock covers
+// demand variability over the lead time, and SKUs at or below the reorder
+// point are topped up to cover lead time plus the coverage period.
+func BuildReorderSuggestions(demand []activities.SKUDemand, leadTimeDays, coverageDays int) []models.ReorderSuggestion {
+	var out []models.ReorderSuggestion
+	for _, d := range demand {
+		if d.AvgDaily <= 0 {
+			continue
+		}
+
+		safety := int(math.Ceil(serviceLevelZ * d.StdDevDaily * math.Sqrt(float64(leadTimeDays))))
+		leadTimeDemand := d.AvgDaily * float64(leadTimeDays)
+		reorderPoint := int(math.Ceil(leadTimeDemand)) + safety
+		if d.CurrentStock > reorderPoint {
+			continue
+		}
+
+		forecast := d.AvgDaily * float64(leadTimeDays+coverageDays)
+		qty := int(math.Ceil(forecast)) + safety - d.CurrentStock
+		if qty <= 0 {
+			continue
+		}
+
+		out = append(out, models.ReorderSuggestion{
+			SKU:            d.SKU,
+			CurrentStock:   d.CurrentStock,
+			AvgDailyDemand: math.Round(d.AvgDaily*100) / 100,
+			ForecastDemand: math.Round(forecast*100) / 100,
+			SafetyStock:    safety,
+			ReorderPoint:   reorderPoint,
+			ReorderQty:     qty,
+		})
+	}
+	return out
+}
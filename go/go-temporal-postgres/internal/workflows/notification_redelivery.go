@@ -0,0 +1,103 @@
+package workflows
+
+import (
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+
+	"github.com/base-14/examples/go/go-temporal-postgres/internal/activities"
+	"github.com/base-14/examples/go/go-temporal-postgres/internal/models"
+)
+
+const NotificationRedeliveryScheduleID = "notification-redelivery"
+
+type NotificationRedeliveryInput struct {
+	MaxAttempts int `json:"max_attempts"`
+	MinAgeSecs  int `json:"min_age_secs"`
+	BatchSize   int `json:"batch_size"`
+}
+
+type NotificationRedeliveryResult struct {
+	Found     int `json:"found"`
+	Delivered int `json:"delivered"`
+	Failed    int `json:"failed"`
+}
+
+func DefaultNotificationRedeliveryInput() NotificationRedeliveryInput {
+	return NotificationRedeliveryInput{MaxAttempts: 5, MinAgeSecs: 300, BatchSize: 100}
+}
+
+// NotificationRedeliveryWorkflow retries notifications whose latest delivery
+// attempt failed, once each per run, up to MaxAttempts tries in total. Every
+// try is logged to notification_log by SendConfirmation, so a notification
+// that fails again is picked up by a later run. It is normally started by
+// the notification-redelivery schedule.
+func NotificationRedeliveryWorkflow(ctx workflow.Context, input NotificationRedeliveryInput) (*NotificationRedeliveryResult, error) {
+	logger := workflow.GetLogger(ctx)
+
+	defaults := DefaultNotificationRedeliveryInput()
+	if input.MaxAttempts <= 0 {
+		input.MaxAttempts = defaults.MaxAttempts
+	}
+	if input.MinAgeSecs <= 0 {
+		input.MinAgeSecs = defaults.MinAgeSecs
+	}
+	if input.BatchSize <= 0 {
+		input.BatchSize = defaults.BatchSize
+	}
+
+	dbCtx := workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: time.Minute,
+		RetryPolicy: &temporal.RetryPolicy{
+			InitialInterval:    5 * time.Second,
+			BackoffCoefficient: 2.0,
+			MaximumInterval:    time.Minute,
+			MaximumAttempts:    5,
+		},
+	})
+	// One try per run: the schedule is the retry loop, and each try is
+	// counted against MaxAttempts.
+	notifyCtx := workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		TaskQueue:           NotificationQueue,
+		StartToCloseTimeout: time.Minute,
+		RetryPolicy:         &temporal.RetryPolicy{MaximumAttempts: 1},
+	})
+
+	var a *activities.RedeliveryActivities
+	var undelivered []activities.UndeliveredNotification
+	if err := workflow.ExecuteActivity(dbCtx, a.FindUndeliveredNotifications, activities.FindUndeliveredInput{
+		MaxAttempts: input.MaxAttempts,
+		MinAgeSecs:  input.MinAgeSecs,
+		Limit:       input.BatchSize,
+	}).Get(ctx, &undelivered); err != nil {
+		return nil, err
+	}
+
+	futures := make([]workflow.Future, len(undelivered))
+	for i, n := range undelivered {
+		futures[i] = workflow.ExecuteActivity(notifyCtx, "SendConfirmation", activities.NotificationInput{
+			OrderID:    n.OrderID,
+			CustomerID: n.CustomerID,
+			Type:       n.Type,
+			Message:    n.Message,
+			Channel:    n.Channel,
+			Source:     models.NotificationSourceRedelivery,
+		})
+	}
+
+	result := &NotificationRedeliveryResult{Found: len(undelivered)}
+	for i, f := range futures {
+		if err := f.Get(ctx, nil); err != nil {
+			result.Failed++
+			logger.Warn("Notification redelivery failed",
+				"order_id", undelivered[i].OrderID, "type", undelivered[i].Type, "error", err)
+			continue
+		}
+		result.Delivered++
+	}
+
+	logger.Info("Notification redelivery complete",
+		"found", result.Found, "delivered", result.Delivered, "failed", result.Failed)
+	return result, nil
+}
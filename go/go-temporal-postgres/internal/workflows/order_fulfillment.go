@@ -1,26 +1,34 @@
 package workflows
 
 import (
+	"errors"
+	"strconv"
 	"time"
 
+	enums "go.temporal.io/api/enums/v1"
 	"go.temporal.io/sdk/temporal"
 	"go.temporal.io/sdk/workflow"
 
 	"github.com/base-14/examples/go/go-temporal-postgres/internal/activities"
+	"github.com/base-14/examples/go/go-temporal-postgres/internal/models"
+	"github.com/base-14/examples/go/go-temporal-postgres/pkg/money"
 )
 
 type OrderInput struct {
 	OrderID      string           `json:"order_id"`
 	CustomerID   string           `json:"customer_id"`
 	CustomerTier string           `json:"customer_tier"`
-	TotalAmount  float64          `json:"total_amount"`
+	TotalAmount  money.Money      `json:"total_amount"`
 	Items        []OrderItemInput `json:"items"`
+	// Locale picks the language of the customer's notifications; empty
+	// means the default, en.
+	Locale string `json:"locale,omitempty"`
 }
 
 type OrderItemInput struct {
-	ProductID string  `json:"product_id"`
-	Quantity  int     `json:"quantity"`
-	Price     float64 `json:"price"`
+	ProductID string      `json:"product_id"`
+	Quantity  int         `json:"quantity"`
+	Price     money.Money `json:"price"`
 }
 
 type OrderResult struct {
@@ -37,9 +45,107 @@ const (
 	PaymentQueue         = "payment-queue"
 	ShippingQueue        = "shipping-queue"
 	NotificationQueue    = "notification-queue"
+	// ProjectionQueue is served by the order projector, which records
+	// workflow outcomes as order events.
+	ProjectionQueue = "order-projection-queue"
 )
 
+const (
+	// ManualReviewSignal carries "approved" or "rejected" for an order held
+	// for review.
+	ManualReviewSignal = "manual-review-decision"
+	// OrderNoteSignal delivers a support note (ReviewNote) to the workflow.
+	OrderNoteSignal = "order-note"
+	// ReviewNotesQuery returns the latest notes while an order is in review.
+	ReviewNotesQuery = "review-notes"
+	// OrderStatusQuery returns the order's OrderProgress.
+	OrderStatusQuery = "order-status"
+	// CancelOrderSignal carries a CancelRequest. It stops the order at the
+	// next step boundary and rolls back what the order has done so far; once
+	// shipping is under way it is ignored.
+	CancelOrderSignal = "cancel-order"
+	// OrderStageQuery returns the step the workflow is at, one of the Stage
+	// constants.
+	OrderStageQuery = "order-stage"
+
+	maxReviewNotes = 20
+)
+
+// Stages of an order's fulfillment, in order. Manual review replaces
+// inventory through shipping for high-risk orders. An order is tracking
+// once it is completed, until the carrier delivers it.
+const (
+	StageValidating   = "validating"
+	StageFraudCheck   = "fraud_check"
+	StageManualReview = "manual_review"
+	StageInventory    = "inventory"
+	StagePayment      = "payment"
+	StageShipping     = "shipping"
+	StageNotifying    = "notifying"
+	StageTracking     = "tracking"
+	StageDone         = "done"
+)
+
+// Cancellable reports whether a cancel-order signal can still stop an
+// order at stage.
+func Cancellable(stage string) bool {
+	switch stage {
+	case StageValidating, StageFraudCheck, StageManualReview, StageInventory, StagePayment:
+		return true
+	}
+	return false
+}
+
+// CancelRequest is the payload of CancelOrderSignal.
+type CancelRequest struct {
+	Reason string `json:"reason"`
+}
+
+// ReviewNote is a support note as seen by the workflow. The orders database
+// keeps the full history; the workflow holds only the latest few.
+type ReviewNote struct {
+	Author    string    `json:"author"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// OrderProgress is where an order's fulfillment stands: processing, then
+// manual_review while it waits for a decision, then the result's status once
+// Terminal. A completed order is Terminal while its shipment is still
+// tracked, with Shipment holding the last carrier status.
+type OrderProgress struct {
+	OrderID      string `json:"order_id"`
+	Status       string `json:"status"`
+	DecisionPath string `json:"decision_path,omitempty"`
+	Stage        string `json:"stage"`
+	Shipment     string `json:"shipment,omitempty"`
+	Terminal     bool   `json:"terminal"`
+}
+
 func OrderFulfillmentWorkflow(ctx workflow.Context, input OrderInput) (*OrderResult, error) {
+	progress := &OrderProgress{OrderID: input.OrderID, Status: "processing", Stage: StageValidating}
+	if err := workflow.SetQueryHandler(ctx, OrderStatusQuery, func() (OrderProgress, error) {
+		return *progress, nil
+	}); err != nil {
+		return nil, err
+	}
+	if err := workflow.SetQueryHandler(ctx, OrderStageQuery, func() (string, error) {
+		return progress.Stage, nil
+	}); err != nil {
+		return nil, err
+	}
+
+	result, err := fulfillOrder(ctx, input, progress)
+	if result != nil {
+		progress.Status = result.Status
+		progress.DecisionPath = result.DecisionPath
+		progress.Stage = StageDone
+		progress.Terminal = true
+	}
+	return result, err
+}
+
+func fulfillOrder(ctx workflow.Context, input OrderInput, progress *OrderProgress) (*OrderResult, error) {
 	logger := workflow.GetLogger(ctx)
 	logger.Info("Starting order fulfillment workflow", "order_id", input.OrderID)
 
@@ -94,6 +200,7 @@ func OrderFulfillmentWorkflow(ctx workflow.Context, input OrderInput) (*OrderRes
 	notificationCtx := workflow.WithActivityOptions(ctx, notificationAO)
 
 	recordMetrics := func(result *OrderResult, riskScore int, failureReason string) {
+		recordOrderEvent(ctx, input.OrderID, result.Status, result.DecisionPath, riskScore, result.Message)
 		duration := workflow.Now(ctx).Sub(startTime).Seconds()
 		_ = workflow.ExecuteActivity(ctx, activities.RecordOrderMetrics, activities.RecordMetricsInput{
 			OrderID:       input.OrderID,
@@ -105,6 +212,18 @@ func OrderFulfillmentWorkflow(ctx workflow.Context, input OrderInput) (*OrderRes
 		}).Get(ctx, nil)
 	}
 
+	// A cancellation is picked up between steps; the step in flight
+	// finishes first.
+	cancelChannel := workflow.GetSignalChannel(ctx, CancelOrderSignal)
+	cancelRequested := func() (string, bool) {
+		var req CancelRequest
+		if !cancelChannel.ReceiveAsync(&req) {
+			return "", false
+		}
+		logger.Info("Order cancellation requested", "order_id", input.OrderID, "stage", progress.Stage, "reason", req.Reason)
+		return req.Reason, true
+	}
+
 	var validateResult activities.ValidateOrderResult
 	if err := workflow.ExecuteActivity(ctx, activities.ValidateOrder, activities.ValidateOrderInput{
 		OrderID:     input.OrderID,
@@ -133,6 +252,13 @@ func OrderFulfillmentWorkflow(ctx workflow.Context, input OrderInput) (*OrderRes
 		return result, nil
 	}
 
+	progress.Stage = StageFraudCheck
+	if reason, ok := cancelRequested(); ok {
+		result := orderCancelledResult(input.OrderID, 0, reason)
+		recordMetrics(result, 0, "order_cancelled")
+		return result, nil
+	}
+
 	var fraudResult activities.FraudAssessmentResult
 	if err := workflow.ExecuteActivity(fraudCtx, "FraudAssessment", activities.FraudAssessmentInput{
 		OrderID:      input.OrderID,
@@ -152,7 +278,14 @@ func OrderFulfillmentWorkflow(ctx workflow.Context, input OrderInput) (*OrderRes
 
 	if fraudResult.RiskScore > 80 {
 		logger.Info("High risk order, requiring manual review", "risk_score", fraudResult.RiskScore)
-		return handleManualReview(ctx, input, fraudResult.RiskScore, startTime)
+		return handleManualReview(ctx, input, fraudResult.RiskScore, startTime, progress)
+	}
+
+	progress.Stage = StageInventory
+	if reason, ok := cancelRequested(); ok {
+		result := orderCancelledResult(input.OrderID, fraudResult.RiskScore, reason)
+		recordMetrics(result, fraudResult.RiskScore, "order_cancelled")
+		return result, nil
 	}
 
 	var inventoryResult activities.InventoryCheckResult
@@ -174,6 +307,39 @@ func OrderFulfillmentWorkflow(ctx workflow.Context, input OrderInput) (*OrderRes
 		logger.Info("Items not available, creating backorder")
 		return handleBackorder(ctx, input, inventoryResult, fraudResult.RiskScore, startTime)
 	}
+	recordItemStatus(ctx, input.OrderID, itemStatuses(input.Items, models.OrderItemStatusReserved))
+
+	// From here on each side effect registers its undo step, so a failure
+	// further along gives back what the order has taken so far.
+	var orderSaga saga
+	orderSaga.add("ReleaseInventory", func(ctx workflow.Context, reason string) error {
+		releaseCtx := workflow.WithActivityOptions(ctx, compensationOptions(InventoryQueue))
+		return workflow.ExecuteActivity(releaseCtx, "ReleaseInventory", activities.ReleaseInventoryInput{
+			OrderID: input.OrderID,
+			Items:   toActivityItems(input.Items),
+			Reason:  reason,
+		}).Get(ctx, nil)
+	})
+
+	// A purge signalled while the order was being checked stops it before
+	// the customer is charged.
+	if workflow.GetSignalChannel(ctx, CustomerPurgeSignal).ReceiveAsync(nil) {
+		logger.Info("Customer data purge requested, cancelling order", "order_id", input.OrderID)
+		result := customerPurgedResult(input.OrderID, fraudResult.RiskScore)
+		_ = orderSaga.compensate(ctx, "customer_purged")
+		recordItemStatus(ctx, input.OrderID, itemStatuses(input.Items, models.OrderItemStatusReleased))
+		recordMetrics(result, fraudResult.RiskScore, "customer_purged")
+		return result, nil
+	}
+
+	progress.Stage = StagePayment
+	if reason, ok := cancelRequested(); ok {
+		result := orderCancelledResult(input.OrderID, fraudResult.RiskScore, reason)
+		_ = orderSaga.compensate(ctx, "order_cancelled")
+		recordItemStatus(ctx, input.OrderID, itemStatuses(input.Items, models.OrderItemStatusReleased))
+		recordMetrics(result, fraudResult.RiskScore, "order_cancelled")
+		return result, nil
+	}
 
 	var paymentResult activities.PaymentResult
 	if err := workflow.ExecuteActivity(paymentCtx, "ProcessPayment", activities.PaymentInput{
@@ -187,6 +353,8 @@ func OrderFulfillmentWorkflow(ctx workflow.Context, input OrderInput) (*OrderRes
 			DecisionPath: "payment_error",
 			Message:      err.Error(),
 		}
+		_ = orderSaga.compensate(ctx, "payment_failed")
+		recordItemStatus(ctx, input.OrderID, itemStatuses(input.Items, models.OrderItemStatusReleased))
 		recordMetrics(result, fraudResult.RiskScore, err.Error())
 		return result, nil
 	}
@@ -199,24 +367,65 @@ func OrderFulfillmentWorkflow(ctx workflow.Context, input OrderInput) (*OrderRes
 			DecisionPath: "payment_declined",
 			Message:      paymentResult.Reason,
 		}
+		_ = orderSaga.compensate(ctx, "payment_declined")
+		recordItemStatus(ctx, input.OrderID, itemStatuses(input.Items, models.OrderItemStatusReleased))
 		recordMetrics(result, fraudResult.RiskScore, paymentResult.Reason)
 		return result, nil
 	}
 
+	orderSaga.add("RefundPayment", func(ctx workflow.Context, reason string) error {
+		refundCtx := workflow.WithActivityOptions(ctx, compensationOptions(PaymentQueue))
+		return workflow.ExecuteActivity(refundCtx, "RefundPayment", activities.RefundInput{
+			OrderID:       input.OrderID,
+			CustomerID:    input.CustomerID,
+			TransactionID: paymentResult.TransactionID,
+			Amount:        input.TotalAmount,
+			Reason:        reason,
+		}).Get(ctx, nil)
+	})
+
+	// Cancelling after payment refunds the customer, as a failed shipping
+	// reservation does.
+	if reason, ok := cancelRequested(); ok {
+		return compensateAfterPayment(ctx, input, &orderSaga, "order_cancelled", &OrderResult{
+			OrderID:      input.OrderID,
+			Status:       "cancelled",
+			DecisionPath: "cancelled_refunded",
+			RiskScore:    fraudResult.RiskScore,
+			Message:      "Order cancelled after payment; payment refunded: " + reason,
+		}, "order_cancelled", recordMetrics)
+	}
+
+	progress.Stage = StageShipping
 	var shippingResult activities.ShippingResult
-	if err := workflow.ExecuteActivity(shippingCtx, "ReserveShipping", activities.ShippingInput{
+	shippingErr := workflow.ExecuteActivity(shippingCtx, "ReserveShipping", activities.ShippingInput{
 		OrderID:    input.OrderID,
 		CustomerID: input.CustomerID,
 		Items:      toActivityItems(input.Items),
-	}).Get(ctx, &shippingResult); err != nil {
-		logger.Warn("Shipping reservation failed, but continuing", "error", err)
+	}).Get(ctx, &shippingResult)
+	if shippingErr == nil && !shippingResult.Reserved {
+		shippingErr = errors.New("shipping could not be reserved")
 	}
+	if shippingErr != nil {
+		logger.Warn("Shipping reservation failed, compensating", "error", shippingErr)
+		return compensateAfterPayment(ctx, input, &orderSaga, "shipping_failed", &OrderResult{
+			OrderID:      input.OrderID,
+			Status:       "cancelled",
+			DecisionPath: "compensated_refund",
+			RiskScore:    fraudResult.RiskScore,
+			Message:      "Shipping could not be reserved; payment refunded",
+		}, shippingErr.Error(), recordMetrics)
+	}
+	recordItemStatus(ctx, input.OrderID, itemStatuses(input.Items, models.OrderItemStatusShipped))
+
+	progress.Stage = StageNotifying
 
 	_ = workflow.ExecuteActivity(notificationCtx, "SendConfirmation", activities.NotificationInput{
 		OrderID:    input.OrderID,
 		CustomerID: input.CustomerID,
 		Type:       "order_confirmed",
-		Message:    "Your order has been confirmed and is being processed.",
+		Locale:     input.Locale,
+		Data:       notificationData(input),
 	}).Get(ctx, nil)
 
 	logger.Info("Order fulfillment completed successfully", "order_id", input.OrderID)
@@ -228,10 +437,110 @@ func OrderFulfillmentWorkflow(ctx workflow.Context, input OrderInput) (*OrderRes
 		Message:      "Order processed successfully",
 	}
 	recordMetrics(result, fraudResult.RiskScore, "")
+
+	progress.Status = result.Status
+	progress.DecisionPath = result.DecisionPath
+	progress.Stage = StageTracking
+	progress.Terminal = true
+	trackShipment(ctx, input, shippingResult.TrackingID, progress)
 	return result, nil
 }
 
-func handleManualReview(ctx workflow.Context, input OrderInput, riskScore int, startTime time.Time) (*OrderResult, error) {
+// trackShipment runs ShipmentTrackingWorkflow as a child and follows its
+// status signals for up to shipmentTrackingWait. The child is abandoned
+// rather than cancelled if the order closes first, and marks the items
+// delivered itself, so a slow carrier does not keep the order open. The
+// order is already completed, so a tracking failure is only logged.
+func trackShipment(ctx workflow.Context, input OrderInput, trackingID string, progress *OrderProgress) {
+	logger := workflow.GetLogger(ctx)
+
+	tracking := DefaultShipmentTrackingInput()
+	tracking.OrderID = input.OrderID
+	tracking.TrackingID = trackingID
+	tracking.ProductIDs = make([]string, len(input.Items))
+	for i, item := range input.Items {
+		tracking.ProductIDs[i] = item.ProductID
+	}
+	childCtx := workflow.WithChildOptions(ctx, workflow.ChildWorkflowOptions{
+		WorkflowID:        "shipment-" + input.OrderID,
+		ParentClosePolicy: enums.PARENT_CLOSE_POLICY_ABANDON,
+	})
+	child := workflow.ExecuteChildWorkflow(childCtx, ShipmentTrackingWorkflow, tracking)
+	// An abandoned child only outlives the order once it has started.
+	if err := child.GetChildWorkflowExecution().Get(ctx, nil); err != nil {
+		logger.Warn("Shipment tracking did not start", "order_id", input.OrderID, "error", err)
+		return
+	}
+
+	updates := workflow.GetSignalChannel(ctx, ShipmentStatusSignal)
+	onUpdate := func(update ShipmentUpdate) {
+		progress.Shipment = update.Status
+		logger.Info("Shipment status", "order_id", input.OrderID, "tracking_id", update.TrackingID, "status", update.Status)
+	}
+
+	timerCtx, cancelTimer := workflow.WithCancel(ctx)
+	defer cancelTimer()
+	var result ShipmentTrackingResult
+	var trackingErr error
+	done, timedOut := false, false
+	selector := workflow.NewSelector(ctx)
+	selector.AddReceive(updates, func(c workflow.ReceiveChannel, _ bool) {
+		var update ShipmentUpdate
+		c.Receive(ctx, &update)
+		onUpdate(update)
+	})
+	selector.AddFuture(child, func(f workflow.Future) {
+		trackingErr = f.Get(ctx, &result)
+		done = true
+	})
+	selector.AddFuture(workflow.NewTimer(timerCtx, shipmentTrackingWait), func(workflow.Future) {
+		timedOut = true
+	})
+	for !done && !timedOut {
+		selector.Select(ctx)
+	}
+	// The child signals before it returns, so a last update may still be
+	// queued behind its completion.
+	var update ShipmentUpdate
+	for updates.ReceiveAsync(&update) {
+		onUpdate(update)
+	}
+
+	switch {
+	case timedOut:
+		logger.Info("Shipment still in transit; tracking continues without the order",
+			"order_id", input.OrderID, "status", progress.Shipment)
+	case trackingErr != nil:
+		logger.Warn("Shipment tracking failed", "order_id", input.OrderID, "error", trackingErr)
+	case !result.Delivered:
+		logger.Warn("Shipment not delivered when tracking ended", "order_id", input.OrderID, "status", result.Status)
+	}
+}
+
+// compensateAfterPayment rolls back an order that stops after the customer
+// was charged: the payment is refunded, the stock released, and refunded is
+// returned as the outcome. An order whose compensation fails ends as
+// compensation_failed so it can be settled by hand.
+func compensateAfterPayment(ctx workflow.Context, input OrderInput, orderSaga *saga, reason string, refunded *OrderResult, failureReason string, recordMetrics func(*OrderResult, int, string)) (*OrderResult, error) {
+	if err := orderSaga.compensate(ctx, reason); err != nil {
+		result := &OrderResult{
+			OrderID:      input.OrderID,
+			Status:       "compensation_failed",
+			DecisionPath: "compensation_failed",
+			RiskScore:    refunded.RiskScore,
+			Message:      "The order could not be rolled back after " + reason + ": " + err.Error(),
+		}
+		recordMetrics(result, refunded.RiskScore, err.Error())
+		return result, nil
+	}
+
+	// The customer paid, so items that will not ship are refunded.
+	recordItemStatus(ctx, input.OrderID, itemStatuses(input.Items, models.OrderItemStatusRefunded))
+	recordMetrics(refunded, refunded.RiskScore, failureReason)
+	return refunded, nil
+}
+
+func handleManualReview(ctx workflow.Context, input OrderInput, riskScore int, startTime time.Time, progress *OrderProgress) (*OrderResult, error) {
 	logger := workflow.GetLogger(ctx)
 
 	notifyCtx := workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
@@ -242,9 +551,13 @@ func handleManualReview(ctx workflow.Context, input OrderInput, riskScore int, s
 		OrderID:    input.OrderID,
 		CustomerID: input.CustomerID,
 		Type:       "manual_review",
-		Message:    "Your order is under review.",
+		Locale:     input.Locale,
+		Data:       notificationData(input),
 	}).Get(ctx, nil)
 
+	recordOrderEvent(ctx, input.OrderID, "manual_review", "manual_review", riskScore, "Order held for manual review")
+	progress.Status, progress.DecisionPath = "manual_review", "manual_review"
+	progress.Stage = StageManualReview
 	duration := workflow.Now(ctx).Sub(startTime).Seconds()
 	_ = workflow.ExecuteActivity(ctx, activities.RecordOrderMetrics, activities.RecordMetricsInput{
 		OrderID:      input.OrderID,
@@ -254,21 +567,56 @@ func handleManualReview(ctx workflow.Context, input OrderInput, riskScore int, s
 		DurationSecs: duration,
 	}).Get(ctx, nil)
 
-	reviewChannel := workflow.GetSignalChannel(ctx, "manual-review-decision")
+	// Notes signalled before the order reached review are still buffered on
+	// the channel and are picked up by the loop below.
+	var notes []ReviewNote
+	if err := workflow.SetQueryHandler(ctx, ReviewNotesQuery, func() ([]ReviewNote, error) {
+		return notes, nil
+	}); err != nil {
+		return nil, err
+	}
+
+	reviewChannel := workflow.GetSignalChannel(ctx, ManualReviewSignal)
+	noteChannel := workflow.GetSignalChannel(ctx, OrderNoteSignal)
+	purgeChannel := workflow.GetSignalChannel(ctx, CustomerPurgeSignal)
+	cancelChannel := workflow.GetSignalChannel(ctx, CancelOrderSignal)
 	reviewTimeout := workflow.NewTimer(ctx, 24*time.Hour)
 
-	var decision string
+	var decision, cancelReason string
 	selector := workflow.NewSelector(ctx)
 
 	selector.AddReceive(reviewChannel, func(c workflow.ReceiveChannel, more bool) {
 		c.Receive(ctx, &decision)
 	})
 
+	selector.AddReceive(noteChannel, func(c workflow.ReceiveChannel, more bool) {
+		var note ReviewNote
+		c.Receive(ctx, &note)
+		notes = append(notes, note)
+		if len(notes) > maxReviewNotes {
+			notes = notes[len(notes)-maxReviewNotes:]
+		}
+	})
+
+	selector.AddReceive(purgeChannel, func(c workflow.ReceiveChannel, more bool) {
+		c.Receive(ctx, nil)
+		decision = "purged"
+	})
+
+	selector.AddReceive(cancelChannel, func(c workflow.ReceiveChannel, more bool) {
+		var req CancelRequest
+		c.Receive(ctx, &req)
+		cancelReason = req.Reason
+		decision = "cancelled"
+	})
+
 	selector.AddFuture(reviewTimeout, func(f workflow.Future) {
 		decision = "timeout"
 	})
 
-	selector.Select(ctx)
+	for decision == "" {
+		selector.Select(ctx)
+	}
 
 	finalDuration := workflow.Now(ctx).Sub(startTime).Seconds()
 
@@ -281,6 +629,7 @@ func handleManualReview(ctx workflow.Context, input OrderInput, riskScore int, s
 			RiskScore:    riskScore,
 			Message:      "Order approved after manual review",
 		}
+		recordOrderEvent(ctx, input.OrderID, result.Status, result.DecisionPath, riskScore, result.Message)
 		_ = workflow.ExecuteActivity(ctx, activities.RecordOrderMetrics, activities.RecordMetricsInput{
 			OrderID:      input.OrderID,
 			CustomerTier: input.CustomerTier,
@@ -291,6 +640,36 @@ func handleManualReview(ctx workflow.Context, input OrderInput, riskScore int, s
 		return result, nil
 	}
 
+	if decision == "purged" {
+		logger.Info("Customer data purge requested during review, cancelling order", "order_id", input.OrderID)
+		result := customerPurgedResult(input.OrderID, riskScore)
+		recordOrderEvent(ctx, input.OrderID, result.Status, result.DecisionPath, riskScore, result.Message)
+		_ = workflow.ExecuteActivity(ctx, activities.RecordOrderMetrics, activities.RecordMetricsInput{
+			OrderID:       input.OrderID,
+			CustomerTier:  input.CustomerTier,
+			DecisionPath:  result.DecisionPath,
+			RiskScore:     riskScore,
+			DurationSecs:  finalDuration,
+			FailureReason: "customer_purged",
+		}).Get(ctx, nil)
+		return result, nil
+	}
+
+	if decision == "cancelled" {
+		logger.Info("Order cancelled during review", "order_id", input.OrderID, "reason", cancelReason)
+		result := orderCancelledResult(input.OrderID, riskScore, cancelReason)
+		recordOrderEvent(ctx, input.OrderID, result.Status, result.DecisionPath, riskScore, result.Message)
+		_ = workflow.ExecuteActivity(ctx, activities.RecordOrderMetrics, activities.RecordMetricsInput{
+			OrderID:       input.OrderID,
+			CustomerTier:  input.CustomerTier,
+			DecisionPath:  result.DecisionPath,
+			RiskScore:     riskScore,
+			DurationSecs:  finalDuration,
+			FailureReason: "order_cancelled",
+		}).Get(ctx, nil)
+		return result, nil
+	}
+
 	logger.Info("Manual review rejected or timed out", "order_id", input.OrderID, "decision", decision)
 	result := &OrderResult{
 		OrderID:      input.OrderID,
@@ -299,6 +678,7 @@ func handleManualReview(ctx workflow.Context, input OrderInput, riskScore int, s
 		RiskScore:    riskScore,
 		Message:      "Order rejected during manual review",
 	}
+	recordOrderEvent(ctx, input.OrderID, result.Status, result.DecisionPath, riskScore, result.Message)
 	_ = workflow.ExecuteActivity(ctx, activities.RecordOrderMetrics, activities.RecordMetricsInput{
 		OrderID:       input.OrderID,
 		CustomerTier:  input.CustomerTier,
@@ -311,6 +691,21 @@ func handleManualReview(ctx workflow.Context, input OrderInput, riskScore int, s
 }
 
 func handleBackorder(ctx workflow.Context, input OrderInput, inventoryResult activities.InventoryCheckResult, riskScore int, startTime time.Time) (*OrderResult, error) {
+	// The items in stock stay reserved for when the rest arrive.
+	unavailable := make(map[string]bool, len(inventoryResult.UnavailableItems))
+	for _, item := range inventoryResult.UnavailableItems {
+		unavailable[item.ProductID] = true
+	}
+	updates := make([]activities.ItemStatusUpdate, 0, len(input.Items))
+	for _, item := range input.Items {
+		status := models.OrderItemStatusReserved
+		if unavailable[item.ProductID] {
+			status = models.OrderItemStatusBackordered
+		}
+		updates = append(updates, activities.ItemStatusUpdate{ProductID: item.ProductID, Status: status})
+	}
+	recordItemStatus(ctx, input.OrderID, updates)
+
 	notifyCtx := workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
 		TaskQueue:           NotificationQueue,
 		StartToCloseTimeout: time.Minute,
@@ -319,7 +714,8 @@ func handleBackorder(ctx workflow.Context, input OrderInput, inventoryResult act
 		OrderID:    input.OrderID,
 		CustomerID: input.CustomerID,
 		Type:       "backorder",
-		Message:    "Some items in your order are currently out of stock. We'll notify you when they become available.",
+		Locale:     input.Locale,
+		Data:       notificationData(input),
 	}).Get(ctx, nil)
 
 	duration := workflow.Now(ctx).Sub(startTime).Seconds()
@@ -329,6 +725,7 @@ func handleBackorder(ctx workflow.Context, input OrderInput, inventoryResult act
 		DecisionPath: "backorder",
 		Message:      "Order placed on backorder due to insufficient stock",
 	}
+	recordOrderEvent(ctx, input.OrderID, result.Status, result.DecisionPath, riskScore, result.Message)
 	_ = workflow.ExecuteActivity(ctx, activities.RecordOrderMetrics, activities.RecordMetricsInput{
 		OrderID:      input.OrderID,
 		CustomerTier: input.CustomerTier,
@@ -339,6 +736,87 @@ func handleBackorder(ctx workflow.Context, input OrderInput, inventoryResult act
 	return result, nil
 }
 
+// customerPurgedResult ends an order whose customer's data is being purged.
+func customerPurgedResult(orderID string, riskScore int) *OrderResult {
+	return &OrderResult{
+		OrderID:      orderID,
+		Status:       "cancelled",
+		DecisionPath: "customer_purged",
+		RiskScore:    riskScore,
+		Message:      "Order cancelled: customer data purge requested",
+	}
+}
+
+// orderCancelledResult ends an order stopped by a cancel-order signal before
+// the customer was charged.
+func orderCancelledResult(orderID string, riskScore int, reason string) *OrderResult {
+	message := "Order cancelled on request"
+	if reason != "" {
+		message += ": " + reason
+	}
+	return &OrderResult{
+		OrderID:      orderID,
+		Status:       "cancelled",
+		DecisionPath: "order_cancelled",
+		RiskScore:    riskScore,
+		Message:      message,
+	}
+}
+
+// recordOrderEvent hands an outcome to the order projector for the search
+// read model. It is best effort: with the projector down the order still
+// completes, and its search row keeps the previous status.
+func recordOrderEvent(ctx workflow.Context, orderID, status, decisionPath string, riskScore int, message string) {
+	eventCtx := workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		TaskQueue:              ProjectionQueue,
+		ScheduleToCloseTimeout: time.Minute,
+		StartToCloseTimeout:    10 * time.Second,
+	})
+	_ = workflow.ExecuteActivity(eventCtx, "RecordOrderEvent", activities.OrderEventInput{
+		OrderID:      orderID,
+		Status:       status,
+		DecisionPath: decisionPath,
+		RiskScore:    riskScore,
+		Message:      message,
+		OccurredAt:   workflow.Now(ctx),
+	}).Get(ctx, nil)
+}
+
+// recordItemStatus hands item status changes to the order projector, which
+// writes them to order_items. Like recordOrderEvent it is best effort.
+func recordItemStatus(ctx workflow.Context, orderID string, updates []activities.ItemStatusUpdate) {
+	itemCtx := workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		TaskQueue:              ProjectionQueue,
+		ScheduleToCloseTimeout: time.Minute,
+		StartToCloseTimeout:    10 * time.Second,
+	})
+	_ = workflow.ExecuteActivity(itemCtx, "UpdateItemStatus", activities.UpdateItemStatusInput{
+		OrderID:    orderID,
+		Items:      updates,
+		OccurredAt: workflow.Now(ctx),
+	}).Get(ctx, nil)
+}
+
+// itemStatuses moves every item to status.
+func itemStatuses(items []OrderItemInput, status models.OrderItemStatus) []activities.ItemStatusUpdate {
+	updates := make([]activities.ItemStatusUpdate, len(items))
+	for i, item := range items {
+		updates[i] = activities.ItemStatusUpdate{ProductID: item.ProductID, Status: status}
+	}
+	return updates
+}
+
+// notificationData is the order data notification templates are rendered
+// with.
+func notificationData(input OrderInput) map[string]string {
+	return map[string]string{
+		"order_id":    input.OrderID,
+		"customer_id": input.CustomerID,
+		"total":       input.TotalAmount.String(),
+		"item_count":  strconv.Itoa(len(input.Items)),
+	}
+}
+
 func toActivityItems(items []OrderItemInput) []activities.OrderItem {
 	result := make([]activities.OrderItem, len(items))
 	for i, item := range items {
@@ -0,0 +1,68 @@
+package workflows
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+// compensationRetryPolicy keeps retrying an undo step for much longer than
+// the forward steps: giving up would leave the customer charged, or stock
+// held, for an order that will never ship.
+var compensationRetryPolicy = &temporal.RetryPolicy{
+	InitialInterval:    time.Second,
+	BackoffCoefficient: 2.0,
+	MaximumInterval:    5 * time.Minute,
+	MaximumAttempts:    10,
+}
+
+// compensationOptions runs an undo step on the worker that owns the step it
+// reverses.
+func compensationOptions(taskQueue string) workflow.ActivityOptions {
+	return workflow.ActivityOptions{
+		TaskQueue:           taskQueue,
+		StartToCloseTimeout: time.Minute,
+		RetryPolicy:         compensationRetryPolicy,
+	}
+}
+
+// compensation undoes one side effect. reason names the failure that made
+// the workflow roll back, for the undo step's own records.
+type compensation struct {
+	name string
+	run  func(ctx workflow.Context, reason string) error
+}
+
+// saga collects the undo step for each side effect the order workflow has
+// made, so a later failure can roll them back in reverse order.
+type saga struct {
+	compensations []compensation
+}
+
+// add registers the undo step for a side effect that has just succeeded.
+func (s *saga) add(name string, run func(ctx workflow.Context, reason string) error) {
+	s.compensations = append(s.compensations, compensation{name: name, run: run})
+}
+
+// compensate runs the registered steps newest first. A failed step does not
+// stop the ones before it; their errors are joined and returned. It runs on a
+// disconnected context so a cancelled workflow still rolls back.
+func (s *saga) compensate(ctx workflow.Context, reason string) error {
+	logger := workflow.GetLogger(ctx)
+	ctx, _ = workflow.NewDisconnectedContext(ctx)
+
+	var errs []error
+	for i := len(s.compensations) - 1; i >= 0; i-- {
+		c := s.compensations[i]
+		logger.Info("Running compensation", "step", c.name, "reason", reason)
+		if err := c.run(ctx, reason); err != nil {
+			logger.Error("Compensation failed", "step", c.name, "error", err)
+			errs = append(errs, fmt.Errorf("%s: %w", c.name, err))
+		}
+	}
+	s.compensations = nil
+	return errors.Join(errs...)
+}
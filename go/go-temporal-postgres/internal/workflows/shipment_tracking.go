@@ -0,0 +1,141 @@
+package workflows
+
+import (
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+
+	"github.com/base-14/examples/go/go-temporal-postgres/internal/activities"
+	"github.com/base-14/examples/go/go-temporal-postgres/internal/models"
+)
+
+// ShipmentStatusSignal carries a ShipmentUpdate from ShipmentTrackingWorkflow
+// to the order workflow that started it, once per carrier status reached.
+const ShipmentStatusSignal = "shipment-status"
+
+// shipmentTrackingWait bounds how long an order follows its shipment. Past
+// it the order closes and the abandoned tracking child carries on alone.
+const shipmentTrackingWait = 15 * time.Minute
+
+type ShipmentTrackingInput struct {
+	OrderID          string `json:"order_id"`
+	TrackingID       string `json:"tracking_id"`
+	PollIntervalSecs int    `json:"poll_interval_secs"`
+	// MaxPolls bounds how long a shipment is tracked; a parcel the carrier
+	// has not delivered by then is left to support.
+	MaxPolls int `json:"max_polls"`
+	// ProductIDs are the order items marked delivered when the carrier
+	// delivers the parcel.
+	ProductIDs []string `json:"product_ids,omitempty"`
+}
+
+type ShipmentTrackingResult struct {
+	OrderID    string `json:"order_id"`
+	TrackingID string `json:"tracking_id"`
+	Status     string `json:"status"`
+	Polls      int    `json:"polls"`
+	Delivered  bool   `json:"delivered"`
+}
+
+// ShipmentUpdate is the payload of ShipmentStatusSignal.
+type ShipmentUpdate struct {
+	TrackingID string    `json:"tracking_id"`
+	Status     string    `json:"status"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+func DefaultShipmentTrackingInput() ShipmentTrackingInput {
+	return ShipmentTrackingInput{PollIntervalSecs: 30, MaxPolls: 120}
+}
+
+// ShipmentTrackingWorkflow polls the carrier every PollIntervalSecs until the
+// parcel is delivered or MaxPolls is reached. Each status change is recorded
+// as a shipment-stage metric and, when the workflow runs as a child, signalled
+// to its parent. Delivery marks ProductIDs delivered here rather than in the
+// parent, which may have closed by then. A failed poll is skipped; the next
+// one asks again.
+func ShipmentTrackingWorkflow(ctx workflow.Context, input ShipmentTrackingInput) (*ShipmentTrackingResult, error) {
+	logger := workflow.GetLogger(ctx)
+
+	defaults := DefaultShipmentTrackingInput()
+	if input.PollIntervalSecs <= 0 {
+		input.PollIntervalSecs = defaults.PollIntervalSecs
+	}
+	if input.MaxPolls <= 0 {
+		input.MaxPolls = defaults.MaxPolls
+	}
+
+	carrierCtx := workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		TaskQueue:           ShippingQueue,
+		StartToCloseTimeout: time.Minute,
+		RetryPolicy: &temporal.RetryPolicy{
+			InitialInterval:    time.Second,
+			BackoffCoefficient: 2.0,
+			MaximumInterval:    10 * time.Second,
+			MaximumAttempts:    3,
+		},
+	})
+	metricsCtx := workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: 10 * time.Second,
+	})
+	parent := workflow.GetInfo(ctx).ParentWorkflowExecution
+
+	result := &ShipmentTrackingResult{OrderID: input.OrderID, TrackingID: input.TrackingID}
+	var since time.Time
+	for result.Polls < input.MaxPolls {
+		if err := workflow.Sleep(ctx, time.Duration(input.PollIntervalSecs)*time.Second); err != nil {
+			return nil, err
+		}
+		result.Polls++
+
+		var carrier activities.CarrierStatusResult
+		if err := workflow.ExecuteActivity(carrierCtx, "CheckCarrierStatus", activities.CarrierStatusInput{
+			OrderID:    input.OrderID,
+			TrackingID: input.TrackingID,
+			Status:     result.Status,
+		}).Get(ctx, &carrier); err != nil {
+			logger.Warn("Carrier status check failed", "tracking_id", input.TrackingID, "poll", result.Polls, "error", err)
+			continue
+		}
+		if carrier.Status == result.Status {
+			continue
+		}
+
+		now := workflow.Now(ctx)
+		stage := activities.ShipmentStageInput{OrderID: input.OrderID, From: result.Status, To: carrier.Status}
+		if result.Status != "" {
+			stage.SecondsInStage = now.Sub(since).Seconds()
+		}
+		_ = workflow.ExecuteActivity(metricsCtx, activities.RecordShipmentStage, stage).Get(ctx, nil)
+		logger.Info("Shipment status changed", "tracking_id", input.TrackingID, "from", result.Status, "to", carrier.Status)
+		result.Status = carrier.Status
+		since = now
+
+		if parent != nil {
+			if err := workflow.SignalExternalWorkflow(ctx, parent.ID, parent.RunID, ShipmentStatusSignal, ShipmentUpdate{
+				TrackingID: input.TrackingID,
+				Status:     carrier.Status,
+				OccurredAt: now,
+			}).Get(ctx, nil); err != nil {
+				logger.Warn("Failed to signal shipment status to order", "order_id", input.OrderID, "error", err)
+			}
+		}
+
+		if carrier.Status == activities.ShipmentDelivered {
+			result.Delivered = true
+			if len(input.ProductIDs) > 0 {
+				updates := make([]activities.ItemStatusUpdate, len(input.ProductIDs))
+				for i, id := range input.ProductIDs {
+					updates[i] = activities.ItemStatusUpdate{ProductID: id, Status: models.OrderItemStatusDelivered}
+				}
+				recordItemStatus(ctx, input.OrderID, updates)
+			}
+			return result, nil
+		}
+	}
+
+	logger.Warn("Shipment tracking gave up before delivery",
+		"tracking_id", input.TrackingID, "status", result.Status, "polls", result.Polls)
+	return result, nil
+}
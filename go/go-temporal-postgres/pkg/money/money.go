@@ -0,0 +1,125 @@
+package money
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+type Currency string
+
+const (
+	USD Currency = "USD"
+	EUR Currency = "EUR"
+	GBP Currency = "GBP"
+	INR Currency = "INR"
+	JPY Currency = "JPY"
+)
+
+const DefaultCurrency = USD
+
+var (
+	ErrUnknownCurrency  = errors.New("unknown currency")
+	ErrCurrencyMismatch = errors.New("currency mismatch")
+	ErrInvalidAmount    = errors.New("invalid amount")
+	ErrTooPrecise       = errors.New("amount has more decimal places than the currency allows")
+	ErrOverflow         = errors.New("amount out of range")
+)
+
+// exponents holds the number of minor-unit digits for each supported ISO 4217 code.
+var exponents = map[Currency]int{
+	USD: 2,
+	EUR: 2,
+	GBP: 2,
+	INR: 2,
+	JPY: 0,
+}
+
+func ParseCurrency(code string) (Currency, error) {
+	if code == "" {
+		return DefaultCurrency, nil
+	}
+	c := Currency(strings.ToUpper(strings.TrimSpace(code)))
+	if _, ok := exponents[c]; !ok {
+		return "", fmt.Errorf("%w: %q", ErrUnknownCurrency, code)
+	}
+	return c, nil
+}
+
+func (c Currency) Valid() bool {
+	_, ok := exponents[c]
+	return ok
+}
+
+func (c Currency) Exponent() int {
+	return exponents[c]
+}
+
+// Money is an amount in the currency's minor units (cents, paise, ...).
+type Money struct {
+	Amount   int64    `json:"amount"`
+	Currency Currency `json:"currency"`
+}
+
+func New(minor int64, currency Currency) Money {
+	return Money{Amount: minor, Currency: currency}
+}
+
+// FromMajor converts a major-unit amount such as 29.99 into Money. It rejects
+// negative, non-finite, and over-precise values rather than silently rounding.
+func FromMajor(amount float64, currency Currency) (Money, error) {
+	if !currency.Valid() {
+		return Money{}, fmt.Errorf("%w: %q", ErrUnknownCurrency, currency)
+	}
+	if math.IsNaN(amount) || math.IsInf(amount, 0) || amount < 0 {
+		return Money{}, fmt.Errorf("%w: %v", ErrInvalidAmount, amount)
+	}
+
+	scaled := amount * math.Pow10(currency.Exponent())
+	minor := math.Round(scaled)
+	if math.Abs(scaled-minor) > 1e-6 {
+		return Money{}, fmt.Errorf("%w: %v %s", ErrTooPrecise, amount, currency)
+	}
+	if minor >= math.MaxInt64 {
+		return Money{}, fmt.Errorf("%w: %v", ErrInvalidAmount, amount)
+	}
+
+	return Money{Amount: int64(minor), Currency: currency}, nil
+}
+
+// Major returns the amount in major units. Use it for display, span attributes,
+// and business thresholds, never for arithmetic.
+func (m Money) Major() float64 {
+	return float64(m.Amount) / math.Pow10(m.Currency.Exponent())
+}
+
+// Mul returns m times quantity, or ErrOverflow if the product does not fit
+// in an int64.
+func (m Money) Mul(quantity int) (Money, error) {
+	q := int64(quantity)
+	amount := m.Amount * q
+	if q != 0 && (amount/q != m.Amount || (m.Amount == -1 && q == math.MinInt64)) {
+		return Money{}, fmt.Errorf("%w: %s x %d", ErrOverflow, m, quantity)
+	}
+	return Money{Amount: amount, Currency: m.Currency}, nil
+}
+
+func (m Money) Add(other Money) (Money, error) {
+	if m.Currency != other.Currency {
+		return Money{}, fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, other.Currency)
+	}
+	amount := m.Amount + other.Amount
+	if (other.Amount > 0 && amount < m.Amount) || (other.Amount < 0 && amount > m.Amount) {
+		return Money{}, fmt.Errorf("%w: %s + %s", ErrOverflow, m, other)
+	}
+	return Money{Amount: amount, Currency: m.Currency}, nil
+}
+
+func (m Money) IsPositive() bool {
+	return m.Amount > 0
+}
+
+func (m Money) String() string {
+	return fmt.Sprintf("%.*f %s", m.Currency.Exponent(), m.Major(), m.Currency)
+}
@@ -5,11 +5,21 @@ import (
 	"strconv"
 )
 
+// Config holds the fault-injection knobs for one activity, read from
+// <PREFIX>_* environment variables so a demo operator can degrade a
+// dependency without a code change.
 type Config struct {
 	FailureRate  float64
 	MinLatencyMs int
 	MaxLatencyMs int
-	Enabled      bool
+	// P95LatencyMs switches latency to a long-tailed distribution starting
+	// at MinLatencyMs whose 95th percentile is P95LatencyMs; MaxLatencyMs is
+	// then ignored.
+	P95LatencyMs int
+	// TimeoutRate is the fraction of calls that hang until the activity's
+	// context ends, as a dependency that stops responding would.
+	TimeoutRate float64
+	Enabled     bool
 }
 
 func LoadConfig(prefix string) Config {
@@ -17,6 +27,8 @@ func LoadConfig(prefix string) Config {
 		FailureRate:  getEnvFloat(prefix+"_FAILURE_RATE", 0.0),
 		MinLatencyMs: getEnvInt(prefix+"_LATENCY_MIN_MS", 0),
 		MaxLatencyMs: getEnvInt(prefix+"_LATENCY_MAX_MS", 0),
+		P95LatencyMs: getEnvInt(prefix+"_P95_LATENCY_MS", 0),
+		TimeoutRate:  getEnvFloat(prefix+"_TIMEOUT_RATE", 0.0),
 		Enabled:      getEnvBool(prefix+"_SIMULATION_ENABLED", true),
 	}
 }
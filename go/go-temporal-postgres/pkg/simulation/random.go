@@ -4,11 +4,19 @@ import (
 	"context"
 	"crypto/rand"
 	"errors"
+	"fmt"
+	"math"
 	"math/big"
 	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
-var ErrSimulatedFailure = errors.New("simulated failure")
+var (
+	ErrSimulatedFailure = errors.New("simulated failure")
+	ErrSimulatedTimeout = errors.New("simulated timeout")
+)
 
 func cryptoRandFloat64() float64 {
 	max := big.NewInt(1 << 53)
@@ -62,16 +70,57 @@ func SimulateLatency(ctx context.Context, minMs, maxMs int) error {
 	}
 }
 
+// TailLatency draws a delay of minMs plus an exponential tail scaled so
+// that 95% of draws fall at or below p95Ms. A p95Ms at or below minMs
+// returns minMs.
+func TailLatency(minMs, p95Ms int) time.Duration {
+	minMs = max(minMs, 0)
+	if p95Ms <= minMs {
+		return time.Duration(minMs) * time.Millisecond
+	}
+	// For an exponential tail, P(X <= x) = 0.95 at x = mean * ln 20.
+	mean := float64(p95Ms-minMs) / math.Log(20)
+	tail := -math.Log(1-cryptoRandFloat64()) * mean
+	return time.Duration((float64(minMs) + tail) * float64(time.Millisecond))
+}
+
+// MaybeFailWithLatency applies cfg to one activity call: it may hang until
+// ctx ends, then delays, then may fail with ErrSimulatedFailure. What was
+// injected is recorded on the span in ctx as simulation.* attributes so
+// injected faults can be told apart from real ones.
 func MaybeFailWithLatency(ctx context.Context, cfg Config) error {
 	if !cfg.Enabled {
 		return nil
 	}
+	span := trace.SpanFromContext(ctx)
+
+	if ShouldFail(cfg.TimeoutRate) {
+		span.SetAttributes(attribute.String("simulation.fault", "timeout"))
+		<-ctx.Done()
+		return fmt.Errorf("%w: %w", ErrSimulatedTimeout, ctx.Err())
+	}
 
-	if err := SimulateLatency(ctx, cfg.MinLatencyMs, cfg.MaxLatencyMs); err != nil {
-		return err
+	var delay time.Duration
+	if cfg.P95LatencyMs > 0 {
+		delay = TailLatency(cfg.MinLatencyMs, cfg.P95LatencyMs)
+	} else if cfg.MinLatencyMs > 0 || cfg.MaxLatencyMs > 0 {
+		delayMs := cfg.MinLatencyMs
+		if cfg.MaxLatencyMs > cfg.MinLatencyMs {
+			delayMs += cryptoRandIntn(cfg.MaxLatencyMs - cfg.MinLatencyMs)
+		}
+		delay = time.Duration(delayMs) * time.Millisecond
+	}
+	if delay > 0 {
+		span.SetAttributes(attribute.Int64("simulation.latency_ms", delay.Milliseconds()))
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
 	}
 
 	if ShouldFail(cfg.FailureRate) {
+		span.SetAttributes(attribute.String("simulation.fault", "error"))
 		return ErrSimulatedFailure
 	}
 
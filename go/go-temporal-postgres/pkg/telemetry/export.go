@@ -0,0 +1,196 @@
+package telemetry
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	otelmetric "go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/sdk/trace"
+)
+
+// ExportConfig tunes the batch span processor, the periodic metric reader
+// and the OTLP exporters. Zero values keep the SDK defaults, which already
+// honour the standard OTEL_BSP_* and OTEL_METRIC_EXPORT_* variables.
+type ExportConfig struct {
+	// MaxQueueSize is the number of ended spans buffered for export; spans
+	// ending while the queue is full are dropped.
+	MaxQueueSize       int
+	MaxExportBatchSize int
+	// BatchTimeout is the longest a partial span batch waits before export.
+	BatchTimeout time.Duration
+	// ExportTimeout bounds a single export call, including its retries.
+	ExportTimeout  time.Duration
+	MetricInterval time.Duration
+	// RetryMaxElapsed bounds how long an export is retried with exponential
+	// backoff before its batch is discarded. OTLP retries are limited by
+	// elapsed time rather than attempt count. Negative disables retries.
+	RetryMaxElapsed time.Duration
+}
+
+// ExportConfigFromEnv reads the standard OTEL_BSP_*, OTEL_METRIC_EXPORT_INTERVAL
+// and OTEL_EXPORTER_OTLP_TIMEOUT variables (milliseconds) plus
+// OTEL_EXPORTER_OTLP_RETRY_MAX_ELAPSED (a Go duration such as "30s" or "-1s").
+func ExportConfigFromEnv() ExportConfig {
+	return ExportConfig{
+		MaxQueueSize:       envInt("OTEL_BSP_MAX_QUEUE_SIZE"),
+		MaxExportBatchSize: envInt("OTEL_BSP_MAX_EXPORT_BATCH_SIZE"),
+		BatchTimeout:       envMillis("OTEL_BSP_SCHEDULE_DELAY"),
+		ExportTimeout:      envMillis("OTEL_EXPORTER_OTLP_TIMEOUT"),
+		MetricInterval:     envMillis("OTEL_METRIC_EXPORT_INTERVAL"),
+		RetryMaxElapsed:    envDuration("OTEL_EXPORTER_OTLP_RETRY_MAX_ELAPSED"),
+	}
+}
+
+func (c ExportConfig) batchOptions() []trace.BatchSpanProcessorOption {
+	var opts []trace.BatchSpanProcessorOption
+	if c.MaxQueueSize > 0 {
+		opts = append(opts, trace.WithMaxQueueSize(c.MaxQueueSize))
+	}
+	if c.MaxExportBatchSize > 0 {
+		opts = append(opts, trace.WithMaxExportBatchSize(c.MaxExportBatchSize))
+	}
+	if c.BatchTimeout > 0 {
+		opts = append(opts, trace.WithBatchTimeout(c.BatchTimeout))
+	}
+	if c.ExportTimeout > 0 {
+		opts = append(opts, trace.WithExportTimeout(c.ExportTimeout))
+	}
+	return opts
+}
+
+func (c ExportConfig) readerOptions() []metric.PeriodicReaderOption {
+	var opts []metric.PeriodicReaderOption
+	if c.MetricInterval > 0 {
+		opts = append(opts, metric.WithInterval(c.MetricInterval))
+	}
+	if c.ExportTimeout > 0 {
+		opts = append(opts, metric.WithTimeout(c.ExportTimeout))
+	}
+	return opts
+}
+
+// exportStats counts items that reached the exporters and items lost because
+// an export failed after exhausting its retries. Spans dropped earlier, on a
+// full batch queue, never reach the exporter; the SDK reports those itself as
+// otel.sdk.processor.span.processed{error.type="queue_full"} when
+// OTEL_GO_X_OBSERVABILITY=true.
+type exportStats struct {
+	spansExported   atomic.Int64
+	spansDropped    atomic.Int64
+	metricsExported atomic.Int64
+	metricsDropped  atomic.Int64
+}
+
+// register reports the counters as observable counters on the SDK's own
+// meter provider. While the collector is down these readings fail to export
+// too, but they are cumulative, so the loss shows up once it recovers.
+func (s *exportStats) register(mp otelmetric.MeterProvider) error {
+	meter := mp.Meter("otel-export-self-telemetry")
+
+	spans, err := meter.Int64ObservableCounter("otel.exporter.spans",
+		otelmetric.WithDescription("Spans handed to the OTLP exporter, by outcome"),
+		otelmetric.WithUnit("{span}"),
+	)
+	if err != nil {
+		return err
+	}
+	points, err := meter.Int64ObservableCounter("otel.exporter.metric_data_points",
+		otelmetric.WithDescription("Metric data points handed to the OTLP exporter, by outcome"),
+		otelmetric.WithUnit("{data_point}"),
+	)
+	if err != nil {
+		return err
+	}
+
+	exported := otelmetric.WithAttributes(attribute.String("outcome", "exported"))
+	dropped := otelmetric.WithAttributes(attribute.String("outcome", "dropped"))
+
+	_, err = meter.RegisterCallback(func(_ context.Context, o otelmetric.Observer) error {
+		o.ObserveInt64(spans, s.spansExported.Load(), exported)
+		o.ObserveInt64(spans, s.spansDropped.Load(), dropped)
+		o.ObserveInt64(points, s.metricsExported.Load(), exported)
+		o.ObserveInt64(points, s.metricsDropped.Load(), dropped)
+		return nil
+	}, spans, points)
+	return err
+}
+
+type countingSpanExporter struct {
+	trace.SpanExporter
+	stats *exportStats
+}
+
+func (e countingSpanExporter) ExportSpans(ctx context.Context, spans []trace.ReadOnlySpan) error {
+	err := e.SpanExporter.ExportSpans(ctx, spans)
+	if err != nil {
+		e.stats.spansDropped.Add(int64(len(spans)))
+	} else {
+		e.stats.spansExported.Add(int64(len(spans)))
+	}
+	return err
+}
+
+type countingMetricExporter struct {
+	metric.Exporter
+	stats *exportStats
+}
+
+func (e countingMetricExporter) Export(ctx context.Context, rm *metricdata.ResourceMetrics) error {
+	n := countDataPoints(rm)
+	err := e.Exporter.Export(ctx, rm)
+	if err != nil {
+		e.stats.metricsDropped.Add(n)
+	} else {
+		e.stats.metricsExported.Add(n)
+	}
+	return err
+}
+
+func countDataPoints(rm *metricdata.ResourceMetrics) int64 {
+	var n int
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			switch data := m.Data.(type) {
+			case metricdata.Gauge[int64]:
+				n += len(data.DataPoints)
+			case metricdata.Gauge[float64]:
+				n += len(data.DataPoints)
+			case metricdata.Sum[int64]:
+				n += len(data.DataPoints)
+			case metricdata.Sum[float64]:
+				n += len(data.DataPoints)
+			case metricdata.Histogram[int64]:
+				n += len(data.DataPoints)
+			case metricdata.Histogram[float64]:
+				n += len(data.DataPoints)
+			case metricdata.ExponentialHistogram[int64]:
+				n += len(data.DataPoints)
+			case metricdata.ExponentialHistogram[float64]:
+				n += len(data.DataPoints)
+			case metricdata.Summary:
+				n += len(data.DataPoints)
+			}
+		}
+	}
+	return int64(n)
+}
+
+func envInt(key string) int {
+	n, _ := strconv.Atoi(os.Getenv(key))
+	return n
+}
+
+func envMillis(key string) time.Duration {
+	return time.Duration(envInt(key)) * time.Millisecond
+}
+
+func envDuration(key string) time.Duration {
+	d, _ := time.ParseDuration(os.Getenv(key))
+	return d
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"log/slog"
 	"strings"
+	"time"
 
 	"go.opentelemetry.io/contrib/bridges/otelslog"
 	"go.opentelemetry.io/otel"
@@ -18,6 +19,8 @@ import (
 	"go.opentelemetry.io/otel/sdk/resource"
 	"go.opentelemetry.io/otel/sdk/trace"
 	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+
+	"github.com/base-14/examples/go/pkg/buildinfo"
 )
 
 type Config struct {
@@ -25,8 +28,16 @@ type Config struct {
 	ServiceVersion string
 	Environment    string
 	Endpoint       string
+	Export         ExportConfig
 }
 
+// Backoff bounds used when RetryMaxElapsed overrides the exporter retry
+// policy; they match the OTLP exporter defaults.
+const (
+	retryInitialInterval = 5 * time.Second
+	retryMaxInterval     = 30 * time.Second
+)
+
 var logger *slog.Logger
 
 func Init(ctx context.Context, cfg Config) (shutdown func(context.Context) error, err error) {
@@ -34,6 +45,7 @@ func Init(ctx context.Context, cfg Config) (shutdown func(context.Context) error
 		resource.WithAttributes(
 			semconv.ServiceName(cfg.ServiceName),
 			semconv.ServiceVersion(cfg.ServiceVersion),
+			semconv.ServiceInstanceID(buildinfo.InstanceID),
 			semconv.DeploymentEnvironment(cfg.Environment),
 		),
 	)
@@ -44,40 +56,59 @@ func Init(ctx context.Context, cfg Config) (shutdown func(context.Context) error
 	endpoint := strings.TrimPrefix(cfg.Endpoint, "http://")
 	endpoint = strings.TrimPrefix(endpoint, "https://")
 
-	traceExporter, err := otlptracehttp.New(ctx,
-		otlptracehttp.WithEndpoint(endpoint),
-		otlptracehttp.WithInsecure(),
-	)
+	exp := cfg.Export
+	traceOpts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(endpoint), otlptracehttp.WithInsecure()}
+	metricOpts := []otlpmetrichttp.Option{otlpmetrichttp.WithEndpoint(endpoint), otlpmetrichttp.WithInsecure()}
+	logOpts := []otlploghttp.Option{otlploghttp.WithEndpoint(endpoint), otlploghttp.WithInsecure()}
+	if exp.ExportTimeout > 0 {
+		traceOpts = append(traceOpts, otlptracehttp.WithTimeout(exp.ExportTimeout))
+		metricOpts = append(metricOpts, otlpmetrichttp.WithTimeout(exp.ExportTimeout))
+		logOpts = append(logOpts, otlploghttp.WithTimeout(exp.ExportTimeout))
+	}
+	if exp.RetryMaxElapsed != 0 {
+		enabled := exp.RetryMaxElapsed > 0
+		traceOpts = append(traceOpts, otlptracehttp.WithRetry(otlptracehttp.RetryConfig{
+			Enabled: enabled, InitialInterval: retryInitialInterval, MaxInterval: retryMaxInterval, MaxElapsedTime: exp.RetryMaxElapsed,
+		}))
+		metricOpts = append(metricOpts, otlpmetrichttp.WithRetry(otlpmetrichttp.RetryConfig{
+			Enabled: enabled, InitialInterval: retryInitialInterval, MaxInterval: retryMaxInterval, MaxElapsedTime: exp.RetryMaxElapsed,
+		}))
+		logOpts = append(logOpts, otlploghttp.WithRetry(otlploghttp.RetryConfig{
+			Enabled: enabled, InitialInterval: retryInitialInterval, MaxInterval: retryMaxInterval, MaxElapsedTime: exp.RetryMaxElapsed,
+		}))
+	}
+
+	traceExporter, err := otlptracehttp.New(ctx, traceOpts...)
 	if err != nil {
 		return nil, err
 	}
 
-	metricExporter, err := otlpmetrichttp.New(ctx,
-		otlpmetrichttp.WithEndpoint(endpoint),
-		otlpmetrichttp.WithInsecure(),
-	)
+	metricExporter, err := otlpmetrichttp.New(ctx, metricOpts...)
 	if err != nil {
 		return nil, err
 	}
 
-	logExporter, err := otlploghttp.New(ctx,
-		otlploghttp.WithEndpoint(endpoint),
-		otlploghttp.WithInsecure(),
-	)
+	logExporter, err := otlploghttp.New(ctx, logOpts...)
 	if err != nil {
 		return nil, err
 	}
 
+	stats := &exportStats{}
+
 	tp := trace.NewTracerProvider(
-		trace.WithBatcher(traceExporter),
+		trace.WithBatcher(countingSpanExporter{traceExporter, stats}, exp.batchOptions()...),
 		trace.WithResource(res),
 	)
 
 	mp := metric.NewMeterProvider(
-		metric.WithReader(metric.NewPeriodicReader(metricExporter)),
+		metric.WithReader(metric.NewPeriodicReader(countingMetricExporter{metricExporter, stats}, exp.readerOptions()...)),
 		metric.WithResource(res),
 	)
 
+	if err := stats.register(mp); err != nil {
+		return nil, err
+	}
+
 	lp := sdklog.NewLoggerProvider(
 		sdklog.WithProcessor(sdklog.NewBatchProcessor(logExporter)),
 		sdklog.WithResource(res),
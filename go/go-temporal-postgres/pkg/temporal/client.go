@@ -1,15 +1,102 @@
 package temporal
 
 import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
 	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/log"
 )
 
 type ClientConfig struct {
 	HostPort  string
 	Namespace string
+	Retry     RetryConfig
+}
+
+// RetryConfig bounds the connection attempts made at startup and sets how
+// often a connected client is health-checked. Zero values use the defaults.
+type RetryConfig struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// AttemptTimeout bounds a single dial, including its health check.
+	AttemptTimeout time.Duration
+	HealthInterval time.Duration
+}
+
+const (
+	defaultMaxAttempts    = 10
+	defaultInitialBackoff = time.Second
+	defaultMaxBackoff     = 30 * time.Second
+	defaultAttemptTimeout = 10 * time.Second
+	defaultHealthInterval = 15 * time.Second
+)
+
+// RetryConfigFromEnv reads TEMPORAL_CONNECT_MAX_ATTEMPTS and the Go durations
+// TEMPORAL_CONNECT_INITIAL_BACKOFF, TEMPORAL_CONNECT_MAX_BACKOFF,
+// TEMPORAL_CONNECT_TIMEOUT and TEMPORAL_HEALTH_CHECK_INTERVAL.
+func RetryConfigFromEnv() RetryConfig {
+	attempts, _ := strconv.Atoi(os.Getenv("TEMPORAL_CONNECT_MAX_ATTEMPTS"))
+	return RetryConfig{
+		MaxAttempts:    attempts,
+		InitialBackoff: envDuration("TEMPORAL_CONNECT_INITIAL_BACKOFF"),
+		MaxBackoff:     envDuration("TEMPORAL_CONNECT_MAX_BACKOFF"),
+		AttemptTimeout: envDuration("TEMPORAL_CONNECT_TIMEOUT"),
+		HealthInterval: envDuration("TEMPORAL_HEALTH_CHECK_INTERVAL"),
+	}
 }
 
-func NewClient(cfg ClientConfig) (client.Client, error) {
+func (c RetryConfig) withDefaults() RetryConfig {
+	if c.MaxAttempts <= 0 {
+		c.MaxAttempts = defaultMaxAttempts
+	}
+	if c.InitialBackoff <= 0 {
+		c.InitialBackoff = defaultInitialBackoff
+	}
+	if c.MaxBackoff <= 0 {
+		c.MaxBackoff = defaultMaxBackoff
+	}
+	if c.AttemptTimeout <= 0 {
+		c.AttemptTimeout = defaultAttemptTimeout
+	}
+	if c.HealthInterval <= 0 {
+		c.HealthInterval = defaultHealthInterval
+	}
+	return c
+}
+
+// Backoff returns the wait after failed attempt n (1-based): InitialBackoff
+// doubled per attempt and capped at MaxBackoff, with equal jitter so the
+// result lies in [d/2, d]. Jitter keeps a fleet of workers that lost Temporal
+// together from reconnecting in lockstep.
+func (c RetryConfig) Backoff(attempt int) time.Duration {
+	c = c.withDefaults()
+	d := c.InitialBackoff
+	for i := 1; i < attempt && d < c.MaxBackoff; i++ {
+		d *= 2
+	}
+	d = min(d, c.MaxBackoff)
+	half := d / 2
+	return half + rand.N(d-half+1)
+}
+
+// NewClient dials Temporal, retrying with backoff until the server answers,
+// MaxAttempts is reached or ctx is cancelled, so a worker started alongside
+// Temporal waits for it instead of crashing. temporal_client_connected reads
+// 0 while retrying.
+func NewClient(ctx context.Context, cfg ClientConfig) (client.Client, error) {
 	opts := client.Options{
 		HostPort:  cfg.HostPort,
 		Namespace: cfg.Namespace,
@@ -19,5 +106,139 @@ func NewClient(cfg ClientConfig) (client.Client, error) {
 		opts.Namespace = "default"
 	}
 
-	return client.Dial(opts)
+	if err := registerConnectedGauge(opts.HostPort, opts.Namespace); err != nil {
+		return nil, fmt.Errorf("register temporal_client_connected: %w", err)
+	}
+
+	retry := cfg.Retry.withDefaults()
+	for attempt := 1; ; attempt++ {
+		attemptCtx, cancel := context.WithTimeout(ctx, retry.AttemptTimeout)
+		c, err := client.DialContext(attemptCtx, opts)
+		cancel()
+		if err == nil {
+			connected.Store(true)
+			slog.Info("connected to Temporal",
+				slog.String("temporal_host", opts.HostPort),
+				slog.Int("attempt", attempt),
+			)
+			return c, nil
+		}
+		if attempt >= retry.MaxAttempts {
+			return nil, fmt.Errorf("connect to Temporal at %s after %d attempts: %w", opts.HostPort, attempt, err)
+		}
+
+		wait := retry.Backoff(attempt)
+		slog.Warn("Temporal not reachable, retrying",
+			slog.String("temporal_host", opts.HostPort),
+			slog.Int("attempt", attempt),
+			slog.Int("max_attempts", retry.MaxAttempts),
+			slog.Duration("retry_in", wait),
+			slog.String("error", err.Error()),
+		)
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("connect to Temporal at %s: %w", opts.HostPort, ctx.Err())
+		case <-time.After(wait):
+		}
+	}
+}
+
+// HealthCheck returns a check that dials Temporal at hostPort, which
+// includes a health check of the frontend, and closes the client again. It
+// is the worker binaries' --healthcheck probe, so it logs nothing.
+func HealthCheck(hostPort string) func(context.Context) error {
+	return func(ctx context.Context) error {
+		c, err := client.DialContext(ctx, client.Options{
+			HostPort: hostPort,
+			Logger:   log.NewStructuredLogger(slog.New(slog.NewTextHandler(io.Discard, nil))),
+		})
+		if err != nil {
+			return err
+		}
+		c.Close()
+		return nil
+	}
+}
+
+// WatchHealth checks c every HealthInterval until stop is called. The SDK
+// re-establishes its gRPC connection on its own; the check logs outages and
+// recoveries and keeps temporal_client_connected current. Call stop before
+// closing the client so shutdown is not reported as an outage.
+func WatchHealth(c client.Client, cfg ClientConfig) (stop func()) {
+	interval := cfg.Retry.withDefaults().HealthInterval
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			checkCtx, cancelCheck := context.WithTimeout(ctx, interval)
+			_, err := c.CheckHealth(checkCtx, &client.CheckHealthRequest{})
+			cancelCheck()
+			if ctx.Err() != nil {
+				return
+			}
+			if was := connected.Swap(err == nil); was && err != nil {
+				slog.Warn("lost connection to Temporal, waiting for reconnect",
+					slog.String("temporal_host", cfg.HostPort),
+					slog.String("error", err.Error()),
+				)
+			} else if !was && err == nil {
+				slog.Info("reconnected to Temporal", slog.String("temporal_host", cfg.HostPort))
+			}
+		}
+	}()
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// connected backs temporal_client_connected. Each worker process holds a
+// single client, so one process-wide reading is enough.
+var (
+	connected     atomic.Bool
+	registerGauge sync.Once
+)
+
+func registerConnectedGauge(hostPort, namespace string) error {
+	var err error
+	registerGauge.Do(func() {
+		meter := otel.Meter("temporal-client")
+		var gauge metric.Int64ObservableGauge
+		gauge, err = meter.Int64ObservableGauge("temporal_client_connected",
+			metric.WithDescription("Whether the Temporal client can reach the server (1) or not (0)"),
+		)
+		if err != nil {
+			return
+		}
+
+		attrs := metric.WithAttributes(
+			attribute.String("server.address", hostPort),
+			attribute.String("temporal.namespace", namespace),
+		)
+		_, err = meter.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+			var v int64
+			if connected.Load() {
+				v = 1
+			}
+			o.ObserveInt64(gauge, v, attrs)
+			return nil
+		}, gauge)
+	})
+	return err
+}
+
+func envDuration(key string) time.Duration {
+	d, _ := time.ParseDuration(os.Getenv(key))
+	return d
 }
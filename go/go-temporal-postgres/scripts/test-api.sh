@@ -97,6 +97,23 @@ run_test "Create Order (payment fail)" "POST" "/api/orders" \
 # List Orders (should have 4 now)
 run_test "List Orders (populated)" "GET" "/api/orders" "" "orders"
 
+# Order search read model
+run_test "Search Orders" "GET" "/api/orders/search?customer_tier=premium" "" "projection"
+run_test "Search Orders (bad total)" "GET" "/api/orders/search?min_total=abc" "" "invalid total"
+
+# Notification delivery log
+run_test "Order Notifications (bad id)" "GET" "/api/orders/not-a-uuid/notifications" "" "invalid order id"
+run_test "Order Notifications (unknown order)" "GET" "/api/orders/00000000-0000-0000-0000-000000000000/notifications" "" "order not found"
+
+# Customer data purge
+run_test "Set Notification Preference" "PUT" "/api/customers/purge-test-customer/notification-preferences" \
+    '{"channel": "email", "enabled": true}' \
+    "\"channel\":\"email\""
+run_test "Set Notification Preference (bad channel)" "PUT" "/api/customers/purge-test-customer/notification-preferences" \
+    '{"channel": "fax", "enabled": true}' \
+    "channel must be"
+run_test "Purge Customer" "POST" "/api/customers/purge-test-customer/purge" "" "customer-purge-"
+
 echo ""
 echo "============================================"
 echo "Test Results: $PASS passed, $FAIL failed"
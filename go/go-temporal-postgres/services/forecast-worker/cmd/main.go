@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/temporal"
+
+	"github.com/base-14/examples/go/go-temporal-postgres/internal/activities"
+	"github.com/base-14/examples/go/go-temporal-postgres/internal/database"
+	"github.com/base-14/examples/go/go-temporal-postgres/internal/workflows"
+	"github.com/base-14/examples/go/go-temporal-postgres/pkg/telemetry"
+	pkgtemporal "github.com/base-14/examples/go/go-temporal-postgres/pkg/temporal"
+	"github.com/base-14/examples/go/pkg/buildinfo"
+	"github.com/base-14/examples/go/pkg/depwait"
+	"github.com/base-14/examples/go/pkg/healthcheck"
+	"github.com/base-14/examples/go/pkg/shutdown"
+)
+
+func main() {
+	if err := run(); err != nil {
+		slog.Error("application error", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	ctx := context.Background()
+
+	serviceName := getEnv("OTEL_SERVICE_NAME", "forecast-worker")
+	environment := getEnv("ENVIRONMENT", "development")
+	otelEndpoint := getEnv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://otel-collector:4318")
+	temporalHost := getEnv("TEMPORAL_HOST", "temporal:7233")
+	taskQueue := getEnv("TASK_QUEUE", workflows.InventoryForecastQueue)
+	databaseURL := getEnv("DATABASE_URL", "")
+
+	interval, err := time.ParseDuration(getEnv("FORECAST_INTERVAL", "1h"))
+	if err != nil {
+		return fmt.Errorf("invalid FORECAST_INTERVAL: %w", err)
+	}
+	if databaseURL == "" {
+		return errors.New("DATABASE_URL is required")
+	}
+
+	healthcheck.Main(
+		depwait.Dependency{Name: "temporal", Check: pkgtemporal.HealthCheck(temporalHost)},
+		depwait.Dependency{Name: "postgres", Check: depwait.SQL("pgx", databaseURL)},
+	)
+
+	shutdownTelemetry, err := telemetry.Init(ctx, telemetry.Config{
+		ServiceName:    serviceName,
+		ServiceVersion: buildinfo.Version,
+		Environment:    environment,
+		Endpoint:       otelEndpoint,
+		Export:         telemetry.ExportConfigFromEnv(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to initialize telemetry: %w", err)
+	}
+	// Everything registered below is stopped in phase order on return,
+	// with telemetry flushed last.
+	sd := shutdown.New(shutdown.Config{OnClose: logShutdown})
+	sd.Add(shutdown.PhaseTelemetry, "telemetry", shutdownTelemetry)
+	defer func() {
+		if err := sd.Shutdown(context.Background()); err != nil {
+			slog.Error("shutdown finished with errors", slog.String("error", err.Error()))
+		}
+	}()
+
+	db, err := database.New(database.Config{DatabaseURL: databaseURL})
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	sd.Add(shutdown.PhaseClients, "postgres", func(context.Context) error { return database.Close(db) })
+	if err := database.Migrate(db); err != nil {
+		return fmt.Errorf("failed to migrate database: %w", err)
+	}
+
+	temporalCfg := pkgtemporal.ClientConfig{
+		HostPort: temporalHost,
+		Retry:    pkgtemporal.RetryConfigFromEnv(),
+	}
+	temporalClient, err := pkgtemporal.NewClient(ctx, temporalCfg)
+	if err != nil {
+		return fmt.Errorf("failed to create Temporal client: %w", err)
+	}
+	sd.Add(shutdown.PhaseClients, "temporal client", shutdown.Func(temporalClient.Close))
+	stopHealth := pkgtemporal.WatchHealth(temporalClient, temporalCfg)
+	sd.Add(shutdown.PhaseWorkers, "temporal health", shutdown.Func(stopHealth))
+
+	w, err := pkgtemporal.NewWorker(temporalClient, pkgtemporal.WorkerConfig{
+		TaskQueue: taskQueue,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create Temporal worker: %w", err)
+	}
+	sd.Add(shutdown.PhaseWorkers, "temporal worker", shutdown.Func(w.Stop))
+
+	w.RegisterWorkflow(workflows.InventoryForecastWorkflow)
+	w.RegisterActivity(&activities.ForecastActivities{DB: db})
+
+	if err := ensureSchedule(ctx, temporalClient, taskQueue, interval); err != nil {
+		return fmt.Errorf("failed to create forecast schedule: %w", err)
+	}
+
+	slog.Info("starting Forecast worker",
+		slog.String("temporal_host", temporalHost),
+		slog.String("task_queue", taskQueue),
+		slog.Duration("interval", interval),
+		slog.String("environment", environment),
+	)
+
+	workerErr := make(chan error, 1)
+	go func() {
+		if err := w.Run(nil); err != nil {
+			workerErr <- err
+		}
+	}()
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+
+	slog.Info("forecast worker is running, waiting for tasks...")
+
+	select {
+	case err := <-workerErr:
+		return fmt.Errorf("worker error: %w", err)
+	case <-sigCh:
+	}
+
+	slog.Info("shutting down forecast worker")
+	return nil
+}
+
+// ensureSchedule creates the forecast schedule on first start. An existing
+// schedule is left alone so intervals changed in the Temporal UI survive
+// worker restarts.
+func ensureSchedule(ctx context.Context, c client.Client, taskQueue string, interval time.Duration) error {
+	_, err := c.ScheduleClient().Create(ctx, client.ScheduleOptions{
+		ID: workflows.InventoryForecastScheduleID,
+		Spec: client.ScheduleSpec{
+			Intervals: []client.ScheduleIntervalSpec{{Every: interval}},
+		},
+		Action: &client.ScheduleWorkflowAction{
+			ID:        "inventory-forecast-run",
+			Workflow:  workflows.InventoryForecastWorkflow,
+			Args:      []interface{}{workflows.DefaultInventoryForecastInput()},
+			TaskQueue: taskQueue,
+		},
+		TriggerImmediately: true,
+	})
+	if errors.Is(err, temporal.ErrScheduleAlreadyRunning) {
+		slog.Info("forecast schedule already exists", slog.String("schedule_id", workflows.InventoryForecastScheduleID))
+		return nil
+	}
+	return err
+}
+
+func logShutdown(ev shutdown.Event) {
+	attrs := []any{
+		slog.String("closer", ev.Name),
+		slog.String("phase", ev.Phase.String()),
+		slog.Duration("duration", ev.Duration),
+	}
+	if ev.Err != nil {
+		slog.Error("shutdown step failed", append(attrs, slog.String("error", ev.Err.Error()))...)
+		return
+	}
+	slog.Info("shutdown step done", attrs...)
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
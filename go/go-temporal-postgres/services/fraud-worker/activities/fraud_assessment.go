@@ -19,25 +19,22 @@ func InitSimulation() {
 }
 
 func FraudAssessment(ctx context.Context, input sharedactivities.FraudAssessmentInput) (*sharedactivities.FraudAssessmentResult, error) {
-	_, span := otel.Tracer("fraud-worker").Start(ctx, "fraud_assessment",
+	ctx, span := otel.Tracer("fraud-worker").Start(ctx, "fraud_assessment",
 		trace.WithAttributes(
 			attribute.String("order.id", input.OrderID),
 			attribute.String("customer.id", input.CustomerID),
 			attribute.String("customer.tier", input.CustomerTier),
-			attribute.Float64("order.amount", input.TotalAmount),
+			attribute.Int64("order.amount_minor", input.TotalAmount.Amount),
+			attribute.String("order.currency", string(input.TotalAmount.Currency)),
 		),
 	)
 	defer span.End()
 
-	if err := simulation.SimulateLatency(ctx, simConfig.MinLatencyMs, simConfig.MaxLatencyMs); err != nil {
+	if err := simulation.MaybeFailWithLatency(ctx, simConfig); err != nil {
+		span.RecordError(err)
 		return nil, err
 	}
 
-	if simulation.ShouldFail(simConfig.FailureRate) {
-		span.RecordError(simulation.ErrSimulatedFailure)
-		return nil, simulation.ErrSimulatedFailure
-	}
-
 	riskScore := 0
 	var reasons []string
 
@@ -51,12 +48,12 @@ func FraudAssessment(ctx context.Context, input sharedactivities.FraudAssessment
 		reasons = append(reasons, "non_premium_tier")
 	}
 
-	if input.TotalAmount > 1000 {
+	if input.TotalAmount.Major() > 1000 {
 		riskScore += 25
 		reasons = append(reasons, "high_value_order")
 	}
 
-	if input.TotalAmount > 5000 {
+	if input.TotalAmount.Major() > 5000 {
 		riskScore += 30
 		reasons = append(reasons, "very_high_value_order")
 	}
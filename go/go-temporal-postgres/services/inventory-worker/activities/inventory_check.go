@@ -32,7 +32,7 @@ func InitSimulation() {
 }
 
 func InventoryCheck(ctx context.Context, input sharedactivities.InventoryCheckInput) (*sharedactivities.InventoryCheckResult, error) {
-	_, span := otel.Tracer("inventory-worker").Start(ctx, "inventory_check",
+	ctx, span := otel.Tracer("inventory-worker").Start(ctx, "inventory_check",
 		trace.WithAttributes(
 			attribute.String("order.id", input.OrderID),
 			attribute.Int("order.item_count", len(input.Items)),
@@ -40,15 +40,11 @@ func InventoryCheck(ctx context.Context, input sharedactivities.InventoryCheckIn
 	)
 	defer span.End()
 
-	if err := simulation.SimulateLatency(ctx, simConfig.MinLatencyMs, simConfig.MaxLatencyMs); err != nil {
+	if err := simulation.MaybeFailWithLatency(ctx, simConfig); err != nil {
+		span.RecordError(err)
 		return nil, err
 	}
 
-	if simulation.ShouldFail(simConfig.FailureRate) {
-		span.RecordError(simulation.ErrSimulatedFailure)
-		return nil, simulation.ErrSimulatedFailure
-	}
-
 	var unavailable []sharedactivities.UnavailableItem
 	for _, item := range input.Items {
 		available, exists := mockInventory[item.ProductID]
@@ -80,3 +76,25 @@ func InventoryCheck(ctx context.Context, input sharedactivities.InventoryCheckIn
 		UnavailableItems: unavailable,
 	}, nil
 }
+
+// ReleaseInventory gives back the stock reserved for an order. The order
+// workflow runs it as a compensation when the order stops after its items
+// were reserved. The mock stock is never drawn down, so there is nothing to
+// return here beyond recording the release.
+func ReleaseInventory(ctx context.Context, input sharedactivities.ReleaseInventoryInput) error {
+	ctx, span := otel.Tracer("inventory-worker").Start(ctx, "release_inventory",
+		trace.WithAttributes(
+			attribute.String("order.id", input.OrderID),
+			attribute.Int("order.item_count", len(input.Items)),
+			attribute.String("inventory.release_reason", input.Reason),
+		),
+	)
+	defer span.End()
+
+	if err := simulation.MaybeFailWithLatency(ctx, simConfig); err != nil {
+		span.RecordError(err)
+		return err
+	}
+
+	return nil
+}
@@ -4,10 +4,14 @@ import (
 	"context"
 	"log/slog"
 
+	"github.com/google/uuid"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
 
+	internalactivities "github.com/base-14/examples/go/go-temporal-postgres/internal/activities"
+	"github.com/base-14/examples/go/go-temporal-postgres/internal/models"
 	sharedactivities "github.com/base-14/examples/go/go-temporal-postgres/pkg/activities"
 	"github.com/base-14/examples/go/go-temporal-postgres/pkg/simulation"
 )
@@ -18,32 +22,79 @@ func InitSimulation() {
 	simConfig = simulation.LoadConfig("NOTIFICATION")
 }
 
-func SendConfirmation(ctx context.Context, input sharedactivities.NotificationInput) error {
-	_, span := otel.Tracer("notification-worker").Start(ctx, "send_notification",
+// NotificationActivities sends order notifications and logs every attempt,
+// sent or failed, to notification_log.
+type NotificationActivities struct {
+	DB *gorm.DB
+}
+
+func (a *NotificationActivities) SendConfirmation(ctx context.Context, input sharedactivities.NotificationInput) error {
+	channel := input.Channel
+	if channel == "" {
+		channel = models.DefaultNotificationChannel
+	}
+
+	ctx, span := otel.Tracer("notification-worker").Start(ctx, "send_notification",
 		trace.WithAttributes(
 			attribute.String("order.id", input.OrderID),
 			attribute.String("customer.id", input.CustomerID),
 			attribute.String("notification.type", input.Type),
+			attribute.String("notification.channel", channel),
 		),
 	)
 	defer span.End()
 
-	if err := simulation.SimulateLatency(ctx, simConfig.MinLatencyMs, simConfig.MaxLatencyMs); err != nil {
-		return err
+	message := a.renderMessage(ctx, span, input, channel)
+
+	sendErr := simulation.MaybeFailWithLatency(ctx, simConfig)
+	if sendErr != nil {
+		span.RecordError(sendErr)
+	} else {
+		slog.Info("notification sent",
+			slog.String("order_id", input.OrderID),
+			slog.String("customer_id", input.CustomerID),
+			slog.String("type", input.Type),
+			slog.String("channel", channel),
+			slog.String("message", message),
+		)
 	}
 
-	if simulation.ShouldFail(simConfig.FailureRate) {
-		span.RecordError(simulation.ErrSimulatedFailure)
-		return simulation.ErrSimulatedFailure
+	a.logAttempt(ctx, span, input, channel, message, sendErr)
+
+	span.SetAttributes(attribute.Bool("notification.sent", sendErr == nil))
+	return sendErr
+}
+
+// logAttempt records the attempt in the ledger. A ledger failure is only
+// reported: failing the activity would resend a notification already sent.
+func (a *NotificationActivities) logAttempt(ctx context.Context, span trace.Span, input sharedactivities.NotificationInput, channel, message string, sendErr error) {
+	orderID, err := uuid.Parse(input.OrderID)
+	if err != nil {
+		slog.Warn("notification not logged: invalid order id", slog.String("order_id", input.OrderID))
+		return
 	}
 
-	slog.Info("notification sent",
-		slog.String("order_id", input.OrderID),
-		slog.String("customer_id", input.CustomerID),
-		slog.String("type", input.Type),
-		slog.String("message", input.Message),
-	)
+	entry := &models.NotificationLog{
+		OrderID:    orderID,
+		CustomerID: input.CustomerID,
+		Type:       input.Type,
+		Channel:    channel,
+		Message:    message,
+		Status:     models.NotificationStatusSent,
+		Source:     input.Source,
+	}
+	if sendErr != nil {
+		entry.Status = models.NotificationStatusFailed
+		entry.Error = sendErr.Error()
+	}
 
-	span.SetAttributes(attribute.Bool("notification.sent", true))
-	return nil
+	if err := internalactivities.LogNotificationAttempt(ctx, a.DB, entry); err != nil {
+		span.RecordError(err)
+		slog.Error("failed to log notification attempt",
+			slog.String("order_id", input.OrderID),
+			slog.String("error", err.Error()),
+		)
+		return
+	}
+	span.SetAttributes(attribute.Int("notification.attempt", entry.Attempt))
 }
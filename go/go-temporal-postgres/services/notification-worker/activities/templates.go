@@ -0,0 +1,84 @@
+package activities
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+
+	internalactivities "github.com/base-14/examples/go/go-temporal-postgres/internal/activities"
+	"github.com/base-14/examples/go/go-temporal-postgres/internal/models"
+	"github.com/base-14/examples/go/go-temporal-postgres/internal/telemetry"
+	sharedactivities "github.com/base-14/examples/go/go-temporal-postgres/pkg/activities"
+)
+
+// renderMessage returns the text to send. A message set by the caller is
+// sent as is. Otherwise the stored template for the type, channel and locale
+// is rendered with the order data; if there is none, or it fails to render,
+// the built-in default for the type is used, and failing that a generic
+// body. Render failures are counted in notifications.template.render_errors.
+func (a *NotificationActivities) renderMessage(ctx context.Context, span trace.Span, input sharedactivities.NotificationInput, channel string) string {
+	if input.Message != "" {
+		return input.Message
+	}
+
+	locale := input.Locale
+	if locale == "" {
+		locale = models.DefaultNotificationLocale
+	}
+
+	data := map[string]string{"order_id": input.OrderID, "customer_id": input.CustomerID}
+	for k, v := range input.Data {
+		data[k] = v
+	}
+
+	source := "builtin"
+	bodies := []string{models.DefaultNotificationBody(input.Type), models.GenericNotificationBody}
+	tmpl, err := internalactivities.FindNotificationTemplate(ctx, a.DB, input.Type, channel, locale)
+	switch {
+	case err == nil:
+		source = tmpl.Channel + "/" + tmpl.Locale
+		bodies = append([]string{tmpl.Body}, bodies...)
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		span.RecordError(err)
+		slog.Warn("notification template lookup failed, using built-in default",
+			slog.String("order_id", input.OrderID),
+			slog.String("type", input.Type),
+			slog.String("error", err.Error()),
+		)
+	}
+
+	for i, body := range bodies {
+		message, err := internalactivities.RenderNotification(body, data)
+		if err == nil {
+			span.SetAttributes(
+				attribute.String("notification.locale", locale),
+				attribute.String("notification.template", source),
+				attribute.Bool("notification.template_fallback", i > 0),
+			)
+			return message
+		}
+
+		stage := internalactivities.RenderStageExecute
+		var renderErr *internalactivities.RenderError
+		if errors.As(err, &renderErr) {
+			stage = renderErr.Stage
+		}
+		telemetry.RecordNotificationRenderError(ctx, input.Type, channel, locale, stage)
+		span.RecordError(err)
+		slog.Warn("notification template failed to render, falling back",
+			slog.String("order_id", input.OrderID),
+			slog.String("type", input.Type),
+			slog.String("channel", channel),
+			slog.String("locale", locale),
+			slog.String("error", err.Error()),
+		)
+		source = "builtin"
+	}
+
+	// Not reached: the generic body names only order_id, which data always has.
+	return "There is an update on your order " + input.OrderID + "."
+}
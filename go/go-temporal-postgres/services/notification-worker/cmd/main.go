@@ -2,15 +2,27 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/temporal"
+
+	internalactivities "github.com/base-14/examples/go/go-temporal-postgres/internal/activities"
+	"github.com/base-14/examples/go/go-temporal-postgres/internal/database"
+	"github.com/base-14/examples/go/go-temporal-postgres/internal/workflows"
 	"github.com/base-14/examples/go/go-temporal-postgres/pkg/telemetry"
 	pkgtemporal "github.com/base-14/examples/go/go-temporal-postgres/pkg/temporal"
 	"github.com/base-14/examples/go/go-temporal-postgres/services/notification-worker/activities"
+	"github.com/base-14/examples/go/pkg/buildinfo"
+	"github.com/base-14/examples/go/pkg/depwait"
+	"github.com/base-14/examples/go/pkg/healthcheck"
+	"github.com/base-14/examples/go/pkg/shutdown"
 )
 
 func main() {
@@ -27,30 +39,65 @@ func run() error {
 	environment := getEnv("ENVIRONMENT", "development")
 	otelEndpoint := getEnv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://otel-collector:4318")
 	temporalHost := getEnv("TEMPORAL_HOST", "temporal:7233")
-	taskQueue := getEnv("TASK_QUEUE", "notification-queue")
+	taskQueue := getEnv("TASK_QUEUE", workflows.NotificationQueue)
+	databaseURL := getEnv("DATABASE_URL", "")
+
+	interval, err := time.ParseDuration(getEnv("NOTIFICATION_REDELIVERY_INTERVAL", "5m"))
+	if err != nil {
+		return fmt.Errorf("invalid NOTIFICATION_REDELIVERY_INTERVAL: %w", err)
+	}
+	if databaseURL == "" {
+		return errors.New("DATABASE_URL is required")
+	}
+
+	healthcheck.Main(
+		depwait.Dependency{Name: "temporal", Check: pkgtemporal.HealthCheck(temporalHost)},
+		depwait.Dependency{Name: "postgres", Check: depwait.SQL("pgx", databaseURL)},
+	)
 
 	shutdownTelemetry, err := telemetry.Init(ctx, telemetry.Config{
 		ServiceName:    serviceName,
-		ServiceVersion: "1.0.0",
+		ServiceVersion: buildinfo.Version,
 		Environment:    environment,
 		Endpoint:       otelEndpoint,
+		Export:         telemetry.ExportConfigFromEnv(),
 	})
 	if err != nil {
 		return fmt.Errorf("failed to initialize telemetry: %w", err)
 	}
+	// Everything registered below is stopped in phase order on return,
+	// with telemetry flushed last.
+	sd := shutdown.New(shutdown.Config{OnClose: logShutdown})
+	sd.Add(shutdown.PhaseTelemetry, "telemetry", shutdownTelemetry)
 	defer func() {
-		if err := shutdownTelemetry(ctx); err != nil {
-			slog.Error("failed to shutdown telemetry", slog.String("error", err.Error()))
+		if err := sd.Shutdown(context.Background()); err != nil {
+			slog.Error("shutdown finished with errors", slog.String("error", err.Error()))
 		}
 	}()
 
-	temporalClient, err := pkgtemporal.NewClient(pkgtemporal.ClientConfig{
+	db, err := database.New(database.Config{DatabaseURL: databaseURL})
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	sd.Add(shutdown.PhaseClients, "postgres", func(context.Context) error { return database.Close(db) })
+	if err := database.Migrate(db); err != nil {
+		return fmt.Errorf("failed to migrate database: %w", err)
+	}
+	if err := database.SeedNotificationTemplates(db); err != nil {
+		return fmt.Errorf("failed to seed notification templates: %w", err)
+	}
+
+	temporalCfg := pkgtemporal.ClientConfig{
 		HostPort: temporalHost,
-	})
+		Retry:    pkgtemporal.RetryConfigFromEnv(),
+	}
+	temporalClient, err := pkgtemporal.NewClient(ctx, temporalCfg)
 	if err != nil {
 		return fmt.Errorf("failed to create Temporal client: %w", err)
 	}
-	defer temporalClient.Close()
+	sd.Add(shutdown.PhaseClients, "temporal client", shutdown.Func(temporalClient.Close))
+	stopHealth := pkgtemporal.WatchHealth(temporalClient, temporalCfg)
+	sd.Add(shutdown.PhaseWorkers, "temporal health", shutdown.Func(stopHealth))
 
 	w, err := pkgtemporal.NewWorker(temporalClient, pkgtemporal.WorkerConfig{
 		TaskQueue: taskQueue,
@@ -58,13 +105,21 @@ func run() error {
 	if err != nil {
 		return fmt.Errorf("failed to create Temporal worker: %w", err)
 	}
+	sd.Add(shutdown.PhaseWorkers, "temporal worker", shutdown.Func(w.Stop))
 
 	activities.InitSimulation()
-	w.RegisterActivity(activities.SendConfirmation)
+	w.RegisterActivity(&activities.NotificationActivities{DB: db})
+	w.RegisterWorkflow(workflows.NotificationRedeliveryWorkflow)
+	w.RegisterActivity(&internalactivities.RedeliveryActivities{DB: db})
+
+	if err := ensureSchedule(ctx, temporalClient, taskQueue, interval); err != nil {
+		return fmt.Errorf("failed to create notification redelivery schedule: %w", err)
+	}
 
 	slog.Info("starting Notification worker",
 		slog.String("temporal_host", temporalHost),
 		slog.String("task_queue", taskQueue),
+		slog.Duration("redelivery_interval", interval),
 		slog.String("environment", environment),
 	)
 
@@ -87,11 +142,45 @@ func run() error {
 	}
 
 	slog.Info("shutting down notification worker")
-	w.Stop()
-
 	return nil
 }
 
+// ensureSchedule creates the redelivery schedule on first start. An existing
+// schedule is left alone so intervals changed in the Temporal UI survive
+// worker restarts.
+func ensureSchedule(ctx context.Context, c client.Client, taskQueue string, interval time.Duration) error {
+	_, err := c.ScheduleClient().Create(ctx, client.ScheduleOptions{
+		ID: workflows.NotificationRedeliveryScheduleID,
+		Spec: client.ScheduleSpec{
+			Intervals: []client.ScheduleIntervalSpec{{Every: interval}},
+		},
+		Action: &client.ScheduleWorkflowAction{
+			ID:        "notification-redelivery-run",
+			Workflow:  workflows.NotificationRedeliveryWorkflow,
+			Args:      []interface{}{workflows.DefaultNotificationRedeliveryInput()},
+			TaskQueue: taskQueue,
+		},
+	})
+	if errors.Is(err, temporal.ErrScheduleAlreadyRunning) {
+		slog.Info("notification redelivery schedule already exists", slog.String("schedule_id", workflows.NotificationRedeliveryScheduleID))
+		return nil
+	}
+	return err
+}
+
+func logShutdown(ev shutdown.Event) {
+	attrs := []any{
+		slog.String("closer", ev.Name),
+		slog.String("phase", ev.Phase.String()),
+		slog.Duration("duration", ev.Duration),
+	}
+	if ev.Err != nil {
+		slog.Error("shutdown step failed", append(attrs, slog.String("error", ev.Err.Error()))...)
+		return
+	}
+	slog.Info("shutdown step done", attrs...)
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
@@ -22,8 +22,9 @@ var (
 	paymentAttemptsCount metric.Int64Counter
 	paymentFailuresCount metric.Int64Counter
 	paymentSuccessCount  metric.Int64Counter
-	paymentAmountTotal   metric.Float64Counter
+	paymentAmountTotal   metric.Int64Counter
 	paymentLatency       metric.Float64Histogram
+	paymentRefundsCount  metric.Int64Counter
 
 	simConfig   simulation.Config
 	declineRate float64
@@ -64,9 +65,9 @@ func init() {
 		panic(err)
 	}
 
-	paymentAmountTotal, err = paymentMeter.Float64Counter("payment.amount.total",
-		metric.WithDescription("Total payment amount processed"),
-		metric.WithUnit("{USD}"),
+	paymentAmountTotal, err = paymentMeter.Int64Counter("payment.amount.total",
+		metric.WithDescription("Total payment amount processed, in minor units of the currency attribute"),
+		metric.WithUnit("{minor_unit}"),
 	)
 	if err != nil {
 		panic(err)
@@ -80,6 +81,14 @@ func init() {
 	if err != nil {
 		panic(err)
 	}
+
+	paymentRefundsCount, err = paymentMeter.Int64Counter("payment.refunds",
+		metric.WithDescription("Payments refunded by order workflow compensation"),
+		metric.WithUnit("{refund}"),
+	)
+	if err != nil {
+		panic(err)
+	}
 }
 
 func ProcessPayment(ctx context.Context, input sharedactivities.PaymentInput) (*sharedactivities.PaymentResult, error) {
@@ -90,7 +99,8 @@ func ProcessPayment(ctx context.Context, input sharedactivities.PaymentInput) (*
 		trace.WithAttributes(
 			attribute.String("order.id", input.OrderID),
 			attribute.String("customer.id", input.CustomerID),
-			attribute.Float64("payment.amount", input.Amount),
+			attribute.Int64("payment.amount_minor", input.Amount.Amount),
+			attribute.String("payment.currency", string(input.Amount.Currency)),
 			attribute.String("temporal.activity_id", activityInfo.ActivityID),
 			attribute.String("temporal.workflow_id", activityInfo.WorkflowExecution.ID),
 		),
@@ -111,18 +121,14 @@ func ProcessPayment(ctx context.Context, input sharedactivities.PaymentInput) (*
 
 	paymentAttemptsCount.Add(ctx, 1, commonAttrs)
 
-	if err := simulation.SimulateLatency(ctx, simConfig.MinLatencyMs, simConfig.MaxLatencyMs); err != nil {
-		return nil, err
-	}
-
-	if simulation.ShouldFail(simConfig.FailureRate) {
+	if err := simulation.MaybeFailWithLatency(ctx, simConfig); err != nil {
 		span.SetStatus(codes.Error, "simulated payment gateway error")
-		span.RecordError(simulation.ErrSimulatedFailure)
+		span.RecordError(err)
 		paymentFailuresCount.Add(ctx, 1, metric.WithAttributes(
 			attribute.String("order_id", input.OrderID),
 			attribute.String("decline_reason", "simulated_error"),
 		))
-		return nil, fmt.Errorf("payment gateway error: %w", simulation.ErrSimulatedFailure)
+		return nil, fmt.Errorf("payment gateway error: %w", err)
 	}
 
 	declineReason := ""
@@ -147,7 +153,8 @@ func ProcessPayment(ctx context.Context, input sharedactivities.PaymentInput) (*
 				attribute.String("workflow_id", activityInfo.WorkflowExecution.ID),
 				attribute.String("trace_id", traceID),
 				attribute.String("decline_reason", declineReason),
-				attribute.Float64("amount", input.Amount),
+				attribute.Int64("amount_minor", input.Amount.Amount),
+				attribute.String("currency", string(input.Amount.Currency)),
 			),
 		)
 
@@ -162,7 +169,7 @@ func ProcessPayment(ctx context.Context, input sharedactivities.PaymentInput) (*
 		slog.ErrorContext(ctx, "payment declined",
 			slog.String("order_id", input.OrderID),
 			slog.String("customer_id", input.CustomerID),
-			slog.Float64("amount", input.Amount),
+			slog.String("amount", input.Amount.String()),
 			slog.String("decline_reason", declineReason),
 			slog.String("workflow_id", activityInfo.WorkflowExecution.ID),
 			slog.String("trace_id", traceID),
@@ -184,7 +191,9 @@ func ProcessPayment(ctx context.Context, input sharedactivities.PaymentInput) (*
 	)
 
 	paymentSuccessCount.Add(ctx, 1, commonAttrs)
-	paymentAmountTotal.Add(ctx, input.Amount, commonAttrs)
+	paymentAmountTotal.Add(ctx, input.Amount.Amount, commonAttrs,
+		metric.WithAttributes(attribute.String("currency", string(input.Amount.Currency))),
+	)
 
 	latencyMs := float64(activity.GetInfo(ctx).StartedTime.Sub(startTime).Milliseconds())
 	paymentLatency.Record(ctx, latencyMs,
@@ -197,7 +206,7 @@ func ProcessPayment(ctx context.Context, input sharedactivities.PaymentInput) (*
 	slog.InfoContext(ctx, "payment processed successfully",
 		slog.String("order_id", input.OrderID),
 		slog.String("customer_id", input.CustomerID),
-		slog.Float64("amount", input.Amount),
+		slog.String("amount", input.Amount.String()),
 		slog.String("transaction_id", transactionID),
 		slog.String("workflow_id", activityInfo.WorkflowExecution.ID),
 		slog.String("trace_id", traceID),
@@ -209,3 +218,54 @@ func ProcessPayment(ctx context.Context, input sharedactivities.PaymentInput) (*
 		TransactionID: transactionID,
 	}, nil
 }
+
+// RefundPayment reverses a payment taken by ProcessPayment. The order workflow
+// runs it as a compensation when a step after payment fails, so it is retried
+// until it succeeds and a repeated call for the same transaction must be safe.
+func RefundPayment(ctx context.Context, input sharedactivities.RefundInput) (*sharedactivities.RefundResult, error) {
+	activityInfo := activity.GetInfo(ctx)
+
+	ctx, span := otel.Tracer("payment-worker").Start(ctx, "refund_payment",
+		trace.WithAttributes(
+			attribute.String("order.id", input.OrderID),
+			attribute.String("customer.id", input.CustomerID),
+			attribute.String("payment.transaction_id", input.TransactionID),
+			attribute.Int64("payment.amount_minor", input.Amount.Amount),
+			attribute.String("payment.currency", string(input.Amount.Currency)),
+			attribute.String("refund.reason", input.Reason),
+			attribute.String("temporal.workflow_id", activityInfo.WorkflowExecution.ID),
+		),
+	)
+	defer span.End()
+
+	if err := simulation.MaybeFailWithLatency(ctx, simConfig); err != nil {
+		span.SetStatus(codes.Error, "simulated payment gateway error")
+		span.RecordError(err)
+		return nil, fmt.Errorf("refund gateway error: %w", err)
+	}
+
+	refundID := fmt.Sprintf("rfd-%s", uuid.New().String()[:8])
+
+	span.SetStatus(codes.Ok, "payment refunded")
+	span.SetAttributes(attribute.String("refund.id", refundID))
+
+	paymentRefundsCount.Add(ctx, 1, metric.WithAttributes(
+		attribute.String("reason", input.Reason),
+		attribute.String("currency", string(input.Amount.Currency)),
+	))
+
+	slog.InfoContext(ctx, "payment refunded",
+		slog.String("order_id", input.OrderID),
+		slog.String("customer_id", input.CustomerID),
+		slog.String("amount", input.Amount.String()),
+		slog.String("transaction_id", input.TransactionID),
+		slog.String("refund_id", refundID),
+		slog.String("reason", input.Reason),
+		slog.String("workflow_id", activityInfo.WorkflowExecution.ID),
+	)
+
+	return &sharedactivities.RefundResult{
+		Refunded: true,
+		RefundID: refundID,
+	}, nil
+}
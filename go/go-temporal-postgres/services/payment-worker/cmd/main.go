@@ -11,6 +11,10 @@ import (
 	"github.com/base-14/examples/go/go-temporal-postgres/pkg/telemetry"
 	pkgtemporal "github.com/base-14/examples/go/go-temporal-postgres/pkg/temporal"
 	"github.com/base-14/examples/go/go-temporal-postgres/services/payment-worker/activities"
+	"github.com/base-14/examples/go/pkg/buildinfo"
+	"github.com/base-14/examples/go/pkg/depwait"
+	"github.com/base-14/examples/go/pkg/healthcheck"
+	"github.com/base-14/examples/go/pkg/shutdown"
 )
 
 func main() {
@@ -29,28 +33,39 @@ func run() error {
 	temporalHost := getEnv("TEMPORAL_HOST", "temporal:7233")
 	taskQueue := getEnv("TASK_QUEUE", "payment-queue")
 
+	healthcheck.Main(depwait.Dependency{Name: "temporal", Check: pkgtemporal.HealthCheck(temporalHost)})
+
 	shutdownTelemetry, err := telemetry.Init(ctx, telemetry.Config{
 		ServiceName:    serviceName,
-		ServiceVersion: "1.0.0",
+		ServiceVersion: buildinfo.Version,
 		Environment:    environment,
 		Endpoint:       otelEndpoint,
+		Export:         telemetry.ExportConfigFromEnv(),
 	})
 	if err != nil {
 		return fmt.Errorf("failed to initialize telemetry: %w", err)
 	}
+	// Everything registered below is stopped in phase order on return,
+	// with telemetry flushed last.
+	sd := shutdown.New(shutdown.Config{OnClose: logShutdown})
+	sd.Add(shutdown.PhaseTelemetry, "telemetry", shutdownTelemetry)
 	defer func() {
-		if err := shutdownTelemetry(ctx); err != nil {
-			slog.Error("failed to shutdown telemetry", slog.String("error", err.Error()))
+		if err := sd.Shutdown(context.Background()); err != nil {
+			slog.Error("shutdown finished with errors", slog.String("error", err.Error()))
 		}
 	}()
 
-	temporalClient, err := pkgtemporal.NewClient(pkgtemporal.ClientConfig{
+	temporalCfg := pkgtemporal.ClientConfig{
 		HostPort: temporalHost,
-	})
+		Retry:    pkgtemporal.RetryConfigFromEnv(),
+	}
+	temporalClient, err := pkgtemporal.NewClient(ctx, temporalCfg)
 	if err != nil {
 		return fmt.Errorf("failed to create Temporal client: %w", err)
 	}
-	defer temporalClient.Close()
+	sd.Add(shutdown.PhaseClients, "temporal client", shutdown.Func(temporalClient.Close))
+	stopHealth := pkgtemporal.WatchHealth(temporalClient, temporalCfg)
+	sd.Add(shutdown.PhaseWorkers, "temporal health", shutdown.Func(stopHealth))
 
 	w, err := pkgtemporal.NewWorker(temporalClient, pkgtemporal.WorkerConfig{
 		TaskQueue: taskQueue,
@@ -58,9 +73,11 @@ func run() error {
 	if err != nil {
 		return fmt.Errorf("failed to create Temporal worker: %w", err)
 	}
+	sd.Add(shutdown.PhaseWorkers, "temporal worker", shutdown.Func(w.Stop))
 
 	activities.InitSimulation()
 	w.RegisterActivity(activities.ProcessPayment)
+	w.RegisterActivity(activities.RefundPayment)
 
 	slog.Info("starting Payment worker",
 		slog.String("temporal_host", temporalHost),
@@ -87,11 +104,22 @@ func run() error {
 	}
 
 	slog.Info("shutting down payment worker")
-	w.Stop()
-
 	return nil
 }
 
+func logShutdown(ev shutdown.Event) {
+	attrs := []any{
+		slog.String("closer", ev.Name),
+		slog.String("phase", ev.Phase.String()),
+		slog.Duration("duration", ev.Duration),
+	}
+	if ev.Err != nil {
+		slog.Error("shutdown step failed", append(attrs, slog.String("error", ev.Err.Error()))...)
+		return
+	}
+	slog.Info("shutdown step done", attrs...)
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/base-14/examples/go/go-temporal-postgres/internal/activities"
+	"github.com/base-14/examples/go/go-temporal-postgres/internal/database"
+	"github.com/base-14/examples/go/go-temporal-postgres/internal/workflows"
+	"github.com/base-14/examples/go/go-temporal-postgres/pkg/telemetry"
+	pkgtemporal "github.com/base-14/examples/go/go-temporal-postgres/pkg/temporal"
+	"github.com/base-14/examples/go/pkg/buildinfo"
+	"github.com/base-14/examples/go/pkg/depwait"
+	"github.com/base-14/examples/go/pkg/healthcheck"
+	"github.com/base-14/examples/go/pkg/shutdown"
+)
+
+func main() {
+	if err := run(); err != nil {
+		slog.Error("application error", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	ctx := context.Background()
+
+	serviceName := getEnv("OTEL_SERVICE_NAME", "purge-worker")
+	environment := getEnv("ENVIRONMENT", "development")
+	otelEndpoint := getEnv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://otel-collector:4318")
+	temporalHost := getEnv("TEMPORAL_HOST", "temporal:7233")
+	taskQueue := getEnv("TASK_QUEUE", workflows.CustomerPurgeQueue)
+	databaseURL := getEnv("DATABASE_URL", "")
+
+	if databaseURL == "" {
+		return errors.New("DATABASE_URL is required")
+	}
+
+	healthcheck.Main(
+		depwait.Dependency{Name: "temporal", Check: pkgtemporal.HealthCheck(temporalHost)},
+		depwait.Dependency{Name: "postgres", Check: depwait.SQL("pgx", databaseURL)},
+	)
+
+	shutdownTelemetry, err := telemetry.Init(ctx, telemetry.Config{
+		ServiceName:    serviceName,
+		ServiceVersion: buildinfo.Version,
+		Environment:    environment,
+		Endpoint:       otelEndpoint,
+		Export:         telemetry.ExportConfigFromEnv(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to initialize telemetry: %w", err)
+	}
+	// Everything registered below is stopped in phase order on return,
+	// with telemetry flushed last.
+	sd := shutdown.New(shutdown.Config{OnClose: logShutdown})
+	sd.Add(shutdown.PhaseTelemetry, "telemetry", shutdownTelemetry)
+	defer func() {
+		if err := sd.Shutdown(context.Background()); err != nil {
+			slog.Error("shutdown finished with errors", slog.String("error", err.Error()))
+		}
+	}()
+
+	db, err := database.New(database.Config{DatabaseURL: databaseURL})
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	sd.Add(shutdown.PhaseClients, "postgres", func(context.Context) error { return database.Close(db) })
+	if err := database.Migrate(db); err != nil {
+		return fmt.Errorf("failed to migrate database: %w", err)
+	}
+
+	temporalCfg := pkgtemporal.ClientConfig{
+		HostPort: temporalHost,
+		Retry:    pkgtemporal.RetryConfigFromEnv(),
+	}
+	temporalClient, err := pkgtemporal.NewClient(ctx, temporalCfg)
+	if err != nil {
+		return fmt.Errorf("failed to create Temporal client: %w", err)
+	}
+	sd.Add(shutdown.PhaseClients, "temporal client", shutdown.Func(temporalClient.Close))
+	stopHealth := pkgtemporal.WatchHealth(temporalClient, temporalCfg)
+	sd.Add(shutdown.PhaseWorkers, "temporal health", shutdown.Func(stopHealth))
+
+	w, err := pkgtemporal.NewWorker(temporalClient, pkgtemporal.WorkerConfig{
+		TaskQueue: taskQueue,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create Temporal worker: %w", err)
+	}
+	sd.Add(shutdown.PhaseWorkers, "temporal worker", shutdown.Func(w.Stop))
+
+	w.RegisterWorkflow(workflows.CustomerPurgeWorkflow)
+	w.RegisterActivity(&activities.PurgeActivities{DB: db, Temporal: temporalClient})
+
+	slog.Info("starting Purge worker",
+		slog.String("temporal_host", temporalHost),
+		slog.String("task_queue", taskQueue),
+		slog.String("environment", environment),
+	)
+
+	workerErr := make(chan error, 1)
+	go func() {
+		if err := w.Run(nil); err != nil {
+			workerErr <- err
+		}
+	}()
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+
+	slog.Info("purge worker is running, waiting for tasks...")
+
+	select {
+	case err := <-workerErr:
+		return fmt.Errorf("worker error: %w", err)
+	case <-sigCh:
+	}
+
+	slog.Info("shutting down purge worker")
+	return nil
+}
+
+func logShutdown(ev shutdown.Event) {
+	attrs := []any{
+		slog.String("closer", ev.Name),
+		slog.String("phase", ev.Phase.String()),
+		slog.Duration("duration", ev.Duration),
+	}
+	if ev.Err != nil {
+		slog.Error("shutdown step failed", append(attrs, slog.String("error", ev.Err.Error()))...)
+		return
+	}
+	slog.Info("shutdown step done", attrs...)
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
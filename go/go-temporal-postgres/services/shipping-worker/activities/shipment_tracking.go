@@ -0,0 +1,55 @@
+package activities
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	sharedactivities "github.com/base-14/examples/go/go-temporal-postgres/pkg/activities"
+	"github.com/base-14/examples/go/go-temporal-postgres/pkg/simulation"
+)
+
+var (
+	carrierConfig    simulation.Config
+	carrierStallRate float64
+)
+
+func initCarrierSimulation() {
+	carrierConfig = simulation.LoadConfig("CARRIER")
+	carrierStallRate = simulation.LoadConfig("CARRIER_STALL").FailureRate
+	if carrierStallRate == 0 {
+		carrierStallRate = 0.3
+	}
+}
+
+// CheckCarrierStatus asks the simulated carrier where a parcel is. Each call
+// either moves the parcel one status forward or, at CARRIER_STALL_FAILURE_RATE,
+// reports it unchanged, so a shipment takes a few polls to be delivered.
+func CheckCarrierStatus(ctx context.Context, input sharedactivities.CarrierStatusInput) (*sharedactivities.CarrierStatusResult, error) {
+	ctx, span := otel.Tracer("shipping-worker").Start(ctx, "check_carrier_status",
+		trace.WithAttributes(
+			attribute.String("order.id", input.OrderID),
+			attribute.String("shipping.tracking_id", input.TrackingID),
+			attribute.String("shipment.previous_status", input.Status),
+		),
+	)
+	defer span.End()
+
+	if err := simulation.MaybeFailWithLatency(ctx, carrierConfig); err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	status := input.Status
+	if status == "" {
+		status = sharedactivities.ShipmentAwaitingPickup
+	} else if !simulation.ShouldFail(carrierStallRate) {
+		status = sharedactivities.NextShipmentStatus(status)
+	}
+
+	span.SetAttributes(attribute.String("shipment.status", status))
+
+	return &sharedactivities.CarrierStatusResult{Status: status}, nil
+}
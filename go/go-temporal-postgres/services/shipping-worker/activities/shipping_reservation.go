@@ -17,10 +17,11 @@ var simConfig simulation.Config
 
 func InitSimulation() {
 	simConfig = simulation.LoadConfig("SHIPPING")
+	initCarrierSimulation()
 }
 
 func ReserveShipping(ctx context.Context, input sharedactivities.ShippingInput) (*sharedactivities.ShippingResult, error) {
-	_, span := otel.Tracer("shipping-worker").Start(ctx, "reserve_shipping",
+	ctx, span := otel.Tracer("shipping-worker").Start(ctx, "reserve_shipping",
 		trace.WithAttributes(
 			attribute.String("order.id", input.OrderID),
 			attribute.String("customer.id", input.CustomerID),
@@ -29,15 +30,11 @@ func ReserveShipping(ctx context.Context, input sharedactivities.ShippingInput)
 	)
 	defer span.End()
 
-	if err := simulation.SimulateLatency(ctx, simConfig.MinLatencyMs, simConfig.MaxLatencyMs); err != nil {
+	if err := simulation.MaybeFailWithLatency(ctx, simConfig); err != nil {
+		span.RecordError(err)
 		return nil, err
 	}
 
-	if simulation.ShouldFail(simConfig.FailureRate) {
-		span.RecordError(simulation.ErrSimulatedFailure)
-		return nil, simulation.ErrSimulatedFailure
-	}
-
 	trackingID := fmt.Sprintf("TRK-%s", uuid.New().String()[:8])
 
 	span.SetAttributes(
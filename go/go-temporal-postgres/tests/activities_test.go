@@ -8,15 +8,16 @@ import (
 	"go.temporal.io/sdk/testsuite"
 
 	"github.com/base-14/examples/go/go-temporal-postgres/internal/activities"
+	"github.com/base-14/examples/go/go-temporal-postgres/pkg/money"
 )
 
 func TestValidateOrder_Valid(t *testing.T) {
 	input := activities.ValidateOrderInput{
 		OrderID:     "test-order",
 		CustomerID:  "test-customer",
-		TotalAmount: 100.00,
+		TotalAmount: money.New(10000, money.USD),
 		Items: []activities.OrderItem{
-			{ProductID: "prod-1", Quantity: 2, Price: 50.00},
+			{ProductID: "prod-1", Quantity: 2, Price: money.New(5000, money.USD)},
 		},
 	}
 
@@ -29,9 +30,9 @@ func TestValidateOrder_MissingCustomerID(t *testing.T) {
 	input := activities.ValidateOrderInput{
 		OrderID:     "test-order",
 		CustomerID:  "",
-		TotalAmount: 100.00,
+		TotalAmount: money.New(10000, money.USD),
 		Items: []activities.OrderItem{
-			{ProductID: "prod-1", Quantity: 1, Price: 100.00},
+			{ProductID: "prod-1", Quantity: 1, Price: money.New(10000, money.USD)},
 		},
 	}
 
@@ -45,7 +46,7 @@ func TestValidateOrder_NoItems(t *testing.T) {
 	input := activities.ValidateOrderInput{
 		OrderID:     "test-order",
 		CustomerID:  "test-customer",
-		TotalAmount: 100.00,
+		TotalAmount: money.New(10000, money.USD),
 		Items:       []activities.OrderItem{},
 	}
 
@@ -55,12 +56,28 @@ func TestValidateOrder_NoItems(t *testing.T) {
 	require.Contains(t, result.Reason, "at least one item")
 }
 
+func TestValidateOrder_CurrencyMismatch(t *testing.T) {
+	input := activities.ValidateOrderInput{
+		OrderID:     "test-order",
+		CustomerID:  "test-customer",
+		TotalAmount: money.New(10000, money.USD),
+		Items: []activities.OrderItem{
+			{ProductID: "prod-1", Quantity: 1, Price: money.New(10000, money.INR)},
+		},
+	}
+
+	result, err := activities.ValidateOrder(context.Background(), input)
+	require.NoError(t, err)
+	require.False(t, result.Valid)
+	require.Contains(t, result.Reason, "currency")
+}
+
 func TestFraudAssessment_LowRisk(t *testing.T) {
 	input := activities.FraudAssessmentInput{
 		OrderID:      "test-order",
 		CustomerID:   "premium-customer",
 		CustomerTier: "premium",
-		TotalAmount:  50.00,
+		TotalAmount:  money.New(5000, money.USD),
 	}
 
 	result, err := activities.FraudAssessment(context.Background(), input)
@@ -73,7 +90,7 @@ func TestFraudAssessment_HighRisk(t *testing.T) {
 		OrderID:      "test-order",
 		CustomerID:   "new-customer",
 		CustomerTier: "new",
-		TotalAmount:  6000.00,
+		TotalAmount:  money.New(600000, money.USD),
 	}
 
 	result, err := activities.FraudAssessment(context.Background(), input)
@@ -85,7 +102,7 @@ func TestInventoryCheck_AllAvailable(t *testing.T) {
 	input := activities.InventoryCheckInput{
 		OrderID: "test-order",
 		Items: []activities.OrderItem{
-			{ProductID: "prod-1", Quantity: 5, Price: 29.99},
+			{ProductID: "prod-1", Quantity: 5, Price: money.New(2999, money.USD)},
 		},
 	}
 
@@ -98,7 +115,7 @@ func TestInventoryCheck_OutOfStock(t *testing.T) {
 	input := activities.InventoryCheckInput{
 		OrderID: "test-order",
 		Items: []activities.OrderItem{
-			{ProductID: "out-of-stock-item", Quantity: 10, Price: 199.99},
+			{ProductID: "out-of-stock-item", Quantity: 10, Price: money.New(19999, money.USD)},
 		},
 	}
 
@@ -116,7 +133,7 @@ func TestProcessPayment_Success(t *testing.T) {
 	input := activities.PaymentInput{
 		OrderID:    "test-order",
 		CustomerID: "test-customer",
-		Amount:     100.00,
+		Amount:     money.New(10000, money.USD),
 	}
 
 	val, err := env.ExecuteActivity(activities.ProcessPayment, input)
@@ -136,7 +153,7 @@ func TestProcessPayment_Decline(t *testing.T) {
 	input := activities.PaymentInput{
 		OrderID:    "test-order",
 		CustomerID: "test_decline",
-		Amount:     100.00,
+		Amount:     money.New(10000, money.USD),
 	}
 
 	val, err := env.ExecuteActivity(activities.ProcessPayment, input)
@@ -153,7 +170,7 @@ func TestReserveShipping(t *testing.T) {
 		OrderID:    "test-order",
 		CustomerID: "test-customer",
 		Items: []activities.OrderItem{
-			{ProductID: "prod-1", Quantity: 1, Price: 29.99},
+			{ProductID: "prod-1", Quantity: 1, Price: money.New(2999, money.USD)},
 		},
 	}
 
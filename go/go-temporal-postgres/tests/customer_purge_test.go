@@ -0,0 +1,190 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/testsuite"
+
+	"github.com/base-14/examples/go/go-temporal-postgres/internal/activities"
+	"github.com/base-14/examples/go/go-temporal-postgres/internal/models"
+	"github.com/base-14/examples/go/go-temporal-postgres/internal/workflows"
+	"github.com/base-14/examples/go/go-temporal-postgres/pkg/money"
+)
+
+func TestCustomerPurgeWorkflowID(t *testing.T) {
+	id := workflows.CustomerPurgeWorkflowID("customer-42")
+	assert.Equal(t, id, workflows.CustomerPurgeWorkflowID("customer-42"))
+	assert.NotEqual(t, id, workflows.CustomerPurgeWorkflowID("customer-43"))
+	assert.True(t, strings.HasPrefix(id, "customer-purge-"))
+	assert.NotContains(t, id, "customer-42")
+}
+
+func TestCustomerPurgeWorkflow(t *testing.T) {
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
+
+	a := &activities.PurgeActivities{}
+	env.RegisterActivity(a)
+
+	env.OnActivity(a.SignalCustomerWorkflows, mock.Anything, activities.SignalCustomerWorkflowsInput{
+		CustomerID: "customer-42",
+		Signal:     workflows.CustomerPurgeSignal,
+	}).Return(2, nil)
+
+	var anonymizeInput activities.AnonymizeCustomerInput
+	env.OnActivity(a.AnonymizeCustomer, mock.Anything, mock.Anything).Return(
+		func(_ context.Context, input activities.AnonymizeCustomerInput) (*activities.AnonymizeCustomerResult, error) {
+			anonymizeInput = input
+			return &activities.AnonymizeCustomerResult{Orders: 3, Notes: 1, Events: 3, SearchRows: 3}, nil
+		})
+	env.OnActivity(a.DeleteNotificationPreferences, mock.Anything, activities.DeletePreferencesInput{
+		CustomerID: "customer-42",
+	}).Return(int64(2), nil)
+
+	var saved models.CustomerPurge
+	env.OnActivity(a.SavePurgeReport, mock.Anything, mock.Anything).Return(func(_ context.Context, report models.CustomerPurge) error {
+		saved = report
+		return nil
+	})
+	var metrics activities.RecordPurgeMetricsInput
+	env.OnActivity(a.RecordPurgeMetrics, mock.Anything, mock.Anything).Return(func(_ context.Context, input activities.RecordPurgeMetricsInput) error {
+		metrics = input
+		return nil
+	})
+
+	env.ExecuteWorkflow(workflows.CustomerPurgeWorkflow, workflows.CustomerPurgeInput{CustomerID: "customer-42"})
+
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+
+	var report models.CustomerPurge
+	require.NoError(t, env.GetWorkflowResult(&report))
+	assert.Equal(t, "customer-42", anonymizeInput.CustomerID)
+	assert.True(t, strings.HasPrefix(anonymizeInput.AnonymizedID, "anon-"))
+	assert.Equal(t, anonymizeInput.AnonymizedID, report.AnonymizedID)
+	assert.Equal(t, int64(3), report.OrdersAnonymized)
+	assert.Equal(t, int64(1), report.NotesRedacted)
+	assert.Equal(t, int64(2), report.PreferencesDeleted)
+	assert.Equal(t, 2, report.WorkflowsSignalled)
+	assert.Equal(t, report.AnonymizedID, saved.AnonymizedID)
+
+	assert.Equal(t, "completed", metrics.Outcome)
+	require.NotNil(t, metrics.Report)
+	assert.Equal(t, int64(3), metrics.Report.EventsAnonymized)
+}
+
+func TestCustomerPurgeWorkflow_AnonymizeFails(t *testing.T) {
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
+
+	a := &activities.PurgeActivities{}
+	env.RegisterActivity(a)
+
+	env.OnActivity(a.SignalCustomerWorkflows, mock.Anything, mock.Anything).Return(0, nil)
+	env.OnActivity(a.AnonymizeCustomer, mock.Anything, mock.Anything).Return(nil,
+		temporal.NewNonRetryableApplicationError("database unavailable", "db", errors.New("connection refused")))
+
+	var metrics activities.RecordPurgeMetricsInput
+	env.OnActivity(a.RecordPurgeMetrics, mock.Anything, mock.Anything).Return(func(_ context.Context, input activities.RecordPurgeMetricsInput) error {
+		metrics = input
+		return nil
+	})
+
+	env.ExecuteWorkflow(workflows.CustomerPurgeWorkflow, workflows.CustomerPurgeInput{CustomerID: "customer-42"})
+
+	require.True(t, env.IsWorkflowCompleted())
+	require.Error(t, env.GetWorkflowError())
+	assert.Equal(t, "failed", metrics.Outcome)
+	assert.Nil(t, metrics.Report)
+}
+
+func TestOrderFulfillmentWorkflow_CustomerPurgeDuringReview(t *testing.T) {
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
+
+	env.OnActivity(activities.ValidateOrder, mock.Anything, mock.Anything).Return(&activities.ValidateOrderResult{
+		Valid: true,
+	}, nil)
+	env.OnActivity(activities.FraudAssessment, mock.Anything, mock.Anything).Return(&activities.FraudAssessmentResult{
+		RiskScore: 90,
+	}, nil)
+	env.OnActivity(activities.SendConfirmation, mock.Anything, mock.Anything).Return(nil)
+
+	var recorded []activities.RecordMetricsInput
+	env.OnActivity(activities.RecordOrderMetrics, mock.Anything, mock.Anything).Return(
+		func(_ context.Context, input activities.RecordMetricsInput) error {
+			recorded = append(recorded, input)
+			return nil
+		})
+
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow(workflows.CustomerPurgeSignal, nil)
+	}, time.Minute)
+
+	env.ExecuteWorkflow(workflows.OrderFulfillmentWorkflow, workflows.OrderInput{
+		OrderID:      "test-order-purge",
+		CustomerID:   "customer-42",
+		CustomerTier: "new",
+		TotalAmount:  money.New(500000, money.USD),
+		Items: []workflows.OrderItemInput{
+			{ProductID: "prod-1", Quantity: 100, Price: money.New(5000, money.USD)},
+		},
+	})
+
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+
+	var result workflows.OrderResult
+	require.NoError(t, env.GetWorkflowResult(&result))
+	assert.Equal(t, "cancelled", result.Status)
+	assert.Equal(t, "customer_purged", result.DecisionPath)
+
+	require.Len(t, recorded, 2)
+	assert.Equal(t, "customer_purged", recorded[1].FailureReason)
+}
+
+func TestOrderFulfillmentWorkflow_CustomerPurgeBeforePayment(t *testing.T) {
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
+
+	env.OnActivity(activities.ValidateOrder, mock.Anything, mock.Anything).Return(&activities.ValidateOrderResult{
+		Valid: true,
+	}, nil)
+	env.OnActivity(activities.FraudAssessment, mock.Anything, mock.Anything).Return(&activities.FraudAssessmentResult{
+		RiskScore: 20,
+	}, nil)
+	env.OnActivity(activities.InventoryCheck, mock.Anything, mock.Anything).Return(&activities.InventoryCheckResult{
+		AllAvailable: true,
+	}, nil)
+	env.OnActivity(activities.RecordOrderMetrics, mock.Anything, mock.Anything).Return(nil)
+
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow(workflows.CustomerPurgeSignal, nil)
+	}, 0)
+
+	env.ExecuteWorkflow(workflows.OrderFulfillmentWorkflow, workflows.OrderInput{
+		OrderID:      "test-order-purge-early",
+		CustomerID:   "customer-42",
+		CustomerTier: "premium",
+		TotalAmount:  money.New(5000, money.USD),
+		Items: []workflows.OrderItemInput{
+			{ProductID: "prod-1", Quantity: 1, Price: money.New(5000, money.USD)},
+		},
+	})
+
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+
+	var result workflows.OrderResult
+	require.NoError(t, env.GetWorkflowResult(&result))
+	assert.Equal(t, "customer_purged", result.DecisionPath)
+	env.AssertNotCalled(t, "ProcessPayment", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,69 @@
+package tests
+
+import (
+	"context"
+
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/testsuite"
+
+	"github.com/base-14/examples/go/go-temporal-postgres/internal/activities"
+	"github.com/base-14/examples/go/go-temporal-postgres/internal/workflows"
+)
+
+func TestBuildReorderSuggestions(t *testing.T) {
+	demand := []activities.SKUDemand{
+		{SKU: "prod-1", CurrentStock: 100, AvgDaily: 2, StdDevDaily: 1},
+		{SKU: "prod-2", CurrentStock: 10, AvgDaily: 3, StdDevDaily: 1},
+		{SKU: "prod-3", CurrentStock: 0, AvgDaily: 0},
+	}
+
+	got := workflows.BuildReorderSuggestions(demand, 7, 14)
+
+	require.Len(t, got, 1)
+	s := got[0]
+	assert.Equal(t, "prod-2", s.SKU)
+	// safety = ceil(1.65 * 1 * sqrt(7)) = 5; reorder point = 21 + 5
+	assert.Equal(t, 5, s.SafetyStock)
+	assert.Equal(t, 26, s.ReorderPoint)
+	// forecast = 3 * 21 = 63; qty = 63 + 5 - 10
+	assert.Equal(t, 63.0, s.ForecastDemand)
+	assert.Equal(t, 58, s.ReorderQty)
+}
+
+func TestInventoryForecastWorkflow(t *testing.T) {
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
+
+	a := &activities.ForecastActivities{}
+	env.RegisterActivity(a)
+
+	env.OnActivity(a.AnalyzeDemand, mock.Anything, activities.AnalyzeDemandInput{LookbackDays: 30}).Return([]activities.SKUDemand{
+		{SKU: "prod-1", CurrentStock: 1, AvgDaily: 4, StdDevDaily: 2},
+		{SKU: "prod-2", CurrentStock: 500, AvgDaily: 1},
+	}, nil)
+
+	var saved activities.SaveReorderSuggestionsInput
+	env.OnActivity(a.SaveReorderSuggestions, mock.Anything, mock.Anything).Return(func(_ context.Context, input activities.SaveReorderSuggestionsInput) error {
+		saved = input
+		return nil
+	})
+
+	env.ExecuteWorkflow(workflows.InventoryForecastWorkflow, workflows.InventoryForecastInput{})
+
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+
+	var result workflows.InventoryForecastResult
+	require.NoError(t, env.GetWorkflowResult(&result))
+	assert.Equal(t, 2, result.SKUsAnalyzed)
+	assert.Equal(t, 1, result.ReorderCount)
+	assert.Equal(t, 30, result.LookbackDays)
+
+	require.Len(t, saved.Suggestions, 1)
+	assert.Equal(t, "prod-1", saved.Suggestions[0].SKU)
+	assert.Equal(t, result.RunID, saved.RunID)
+}
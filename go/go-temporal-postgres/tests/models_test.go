@@ -0,0 +1,27 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/base-14/examples/go/go-temporal-postgres/internal/models"
+)
+
+func TestComputeCartHash_IgnoresItemOrder(t *testing.T) {
+	a := []models.OrderItem{
+		{ProductID: "prod-1", Quantity: 1, Price: 2999},
+		{ProductID: "prod-2", Quantity: 2, Price: 4999},
+	}
+	b := []models.OrderItem{a[1], a[0]}
+
+	require.Equal(t, models.ComputeCartHash("customer-1", "USD", a), models.ComputeCartHash("customer-1", "USD", b))
+}
+
+func TestComputeCartHash_DiffersByCustomerAndQuantity(t *testing.T) {
+	items := []models.OrderItem{{ProductID: "prod-1", Quantity: 1, Price: 2999}}
+	more := []models.OrderItem{{ProductID: "prod-1", Quantity: 2, Price: 2999}}
+
+	require.NotEqual(t, models.ComputeCartHash("customer-1", "USD", items), models.ComputeCartHash("customer-2", "USD", items))
+	require.NotEqual(t, models.ComputeCartHash("customer-1", "USD", items), models.ComputeCartHash("customer-1", "USD", more))
+}
@@ -0,0 +1,65 @@
+package tests
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/base-14/examples/go/go-temporal-postgres/pkg/money"
+)
+
+func TestFromMajor_ConvertsToMinorUnits(t *testing.T) {
+	m, err := money.FromMajor(29.99, money.USD)
+	require.NoError(t, err)
+	require.Equal(t, int64(2999), m.Amount)
+
+	yen, err := money.FromMajor(1500, money.JPY)
+	require.NoError(t, err)
+	require.Equal(t, int64(1500), yen.Amount)
+}
+
+func TestFromMajor_RejectsInvalidAmounts(t *testing.T) {
+	_, err := money.FromMajor(10.005, money.USD)
+	require.ErrorIs(t, err, money.ErrTooPrecise)
+
+	_, err = money.FromMajor(10.5, money.JPY)
+	require.ErrorIs(t, err, money.ErrTooPrecise)
+
+	_, err = money.FromMajor(-1, money.USD)
+	require.ErrorIs(t, err, money.ErrInvalidAmount)
+}
+
+func TestParseCurrency(t *testing.T) {
+	c, err := money.ParseCurrency("")
+	require.NoError(t, err)
+	require.Equal(t, money.DefaultCurrency, c)
+
+	c, err = money.ParseCurrency("inr")
+	require.NoError(t, err)
+	require.Equal(t, money.INR, c)
+
+	_, err = money.ParseCurrency("XYZ")
+	require.ErrorIs(t, err, money.ErrUnknownCurrency)
+}
+
+func TestMoneyAdd_CurrencyMismatch(t *testing.T) {
+	_, err := money.New(100, money.USD).Add(money.New(100, money.INR))
+	require.ErrorIs(t, err, money.ErrCurrencyMismatch)
+}
+
+func TestMoneyArithmetic_Overflow(t *testing.T) {
+	line, err := money.New(2999, money.USD).Mul(3)
+	require.NoError(t, err)
+	require.Equal(t, int64(8997), line.Amount)
+
+	_, err = money.New(math.MaxInt64/2+1, money.USD).Mul(2)
+	require.ErrorIs(t, err, money.ErrOverflow)
+
+	_, err = money.New(math.MaxInt64, money.USD).Add(money.New(1, money.USD))
+	require.ErrorIs(t, err, money.ErrOverflow)
+
+	total, err := money.New(math.MaxInt64-1, money.USD).Add(money.New(1, money.USD))
+	require.NoError(t, err)
+	require.Equal(t, int64(math.MaxInt64), total.Amount)
+}
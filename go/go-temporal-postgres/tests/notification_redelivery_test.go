@@ -0,0 +1,78 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/testsuite"
+
+	"github.com/base-14/examples/go/go-temporal-postgres/internal/activities"
+	"github.com/base-14/examples/go/go-temporal-postgres/internal/models"
+	"github.com/base-14/examples/go/go-temporal-postgres/internal/workflows"
+)
+
+func TestNotificationRedeliveryWorkflow(t *testing.T) {
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
+
+	a := &activities.RedeliveryActivities{}
+	env.RegisterActivity(a)
+	env.RegisterActivity(activities.SendConfirmation)
+
+	env.OnActivity(a.FindUndeliveredNotifications, mock.Anything, activities.FindUndeliveredInput{
+		MaxAttempts: 5, MinAgeSecs: 300, Limit: 100,
+	}).Return([]activities.UndeliveredNotification{
+		{OrderID: "order-1", CustomerID: "cust-1", Type: "order_completed", Channel: "email", Attempts: 1},
+		{OrderID: "order-2", CustomerID: "cust-2", Type: "order_completed", Channel: "sms", Attempts: 3},
+	}, nil)
+
+	var sent []activities.NotificationInput
+	env.OnActivity(activities.SendConfirmation, mock.Anything, mock.Anything).Return(func(_ context.Context, input activities.NotificationInput) error {
+		sent = append(sent, input)
+		if input.OrderID == "order-2" {
+			return errors.New("sms gateway unavailable")
+		}
+		return nil
+	})
+
+	env.ExecuteWorkflow(workflows.NotificationRedeliveryWorkflow, workflows.NotificationRedeliveryInput{})
+
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+
+	var result workflows.NotificationRedeliveryResult
+	require.NoError(t, env.GetWorkflowResult(&result))
+	assert.Equal(t, workflows.NotificationRedeliveryResult{Found: 2, Delivered: 1, Failed: 1}, result)
+
+	require.Len(t, sent, 2, "a failed redelivery is not retried within the run")
+	for _, input := range sent {
+		assert.Equal(t, models.NotificationSourceRedelivery, input.Source)
+	}
+}
+
+func TestNotificationRedeliveryWorkflowNothingToDo(t *testing.T) {
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
+
+	a := &activities.RedeliveryActivities{}
+	env.RegisterActivity(a)
+	env.RegisterActivity(activities.SendConfirmation)
+
+	env.OnActivity(a.FindUndeliveredNotifications, mock.Anything, activities.FindUndeliveredInput{
+		MaxAttempts: 2, MinAgeSecs: 300, Limit: 100,
+	}).Return([]activities.UndeliveredNotification{}, nil)
+
+	env.ExecuteWorkflow(workflows.NotificationRedeliveryWorkflow, workflows.NotificationRedeliveryInput{MaxAttempts: 2})
+
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+
+	var result workflows.NotificationRedeliveryResult
+	require.NoError(t, env.GetWorkflowResult(&result))
+	assert.Zero(t, result)
+	env.AssertNotCalled(t, "SendConfirmation", mock.Anything, mock.Anything)
+}
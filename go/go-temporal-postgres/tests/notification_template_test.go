@@ -0,0 +1,41 @@
+package tests
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/base-14/examples/go/go-temporal-postgres/internal/activities"
+	"github.com/base-14/examples/go/go-temporal-postgres/internal/models"
+)
+
+func TestRenderNotification_FillsOrderData(t *testing.T) {
+	message, err := activities.RenderNotification(models.DefaultNotificationBody("order_confirmed"), map[string]string{
+		"order_id": "order-1",
+		"total":    "USD 50.00",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "Your order order-1 for USD 50.00 has been confirmed and is being processed.", message)
+}
+
+func TestRenderNotification_ReportsStage(t *testing.T) {
+	_, err := activities.RenderNotification("Order {{.order_id", nil)
+	var renderErr *activities.RenderError
+	require.True(t, errors.As(err, &renderErr))
+	require.Equal(t, activities.RenderStageParse, renderErr.Stage)
+
+	_, err = activities.RenderNotification("Order {{.order_number}}", map[string]string{"order_id": "order-1"})
+	require.True(t, errors.As(err, &renderErr))
+	require.Equal(t, activities.RenderStageExecute, renderErr.Stage)
+}
+
+func TestDefaultNotificationTemplates_RenderInEveryLocale(t *testing.T) {
+	data := map[string]string{"order_id": "order-1", "customer_id": "customer-1", "total": "USD 50.00", "item_count": "2"}
+	for _, tmpl := range models.DefaultNotificationTemplates {
+		_, err := activities.RenderNotification(tmpl.Body, data)
+		require.NoError(t, err, "%s/%s/%s", tmpl.Type, tmpl.Channel, tmpl.Locale)
+	}
+
+	require.Equal(t, models.GenericNotificationBody, models.DefaultNotificationBody("unknown_type"))
+}
@@ -0,0 +1,129 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/testsuite"
+
+	"github.com/base-14/examples/go/go-temporal-postgres/internal/activities"
+	"github.com/base-14/examples/go/go-temporal-postgres/internal/models"
+	"github.com/base-14/examples/go/go-temporal-postgres/internal/workflows"
+	"github.com/base-14/examples/go/go-temporal-postgres/pkg/money"
+)
+
+func TestCancellable(t *testing.T) {
+	require.True(t, workflows.Cancellable(workflows.StageManualReview))
+	require.True(t, workflows.Cancellable(workflows.StagePayment))
+	require.False(t, workflows.Cancellable(workflows.StageShipping))
+	require.False(t, workflows.Cancellable(workflows.StageTracking))
+	require.False(t, workflows.Cancellable(workflows.StageDone))
+}
+
+func TestOrderFulfillmentWorkflow_CancelBeforeChecks(t *testing.T) {
+	env, updates := itemStatusEnv(t, &activities.InventoryCheckResult{AllAvailable: true})
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow(workflows.CancelOrderSignal, workflows.CancelRequest{Reason: "changed_mind"})
+	}, 0)
+
+	env.ExecuteWorkflow(workflows.OrderFulfillmentWorkflow, itemStatusOrder)
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+
+	var result workflows.OrderResult
+	require.NoError(t, env.GetWorkflowResult(&result))
+	require.Equal(t, "cancelled", result.Status)
+	require.Equal(t, "order_cancelled", result.DecisionPath)
+	require.Contains(t, result.Message, "changed_mind")
+	require.Empty(t, *updates)
+	env.AssertNotCalled(t, "ProcessPayment", mock.Anything, mock.Anything)
+}
+
+func TestOrderFulfillmentWorkflow_CancelAfterPaymentRefunds(t *testing.T) {
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
+	env.OnActivity(activities.ValidateOrder, mock.Anything, mock.Anything).Return(&activities.ValidateOrderResult{Valid: true}, nil)
+	env.OnActivity(activities.FraudAssessment, mock.Anything, mock.Anything).Return(&activities.FraudAssessmentResult{RiskScore: 10}, nil)
+	env.OnActivity(activities.InventoryCheck, mock.Anything, mock.Anything).Return(&activities.InventoryCheckResult{AllAvailable: true}, nil)
+	// The first payment attempt fails, so the cancellation lands while the
+	// retry is pending and is picked up once payment goes through.
+	env.OnActivity(activities.ProcessPayment, mock.Anything, mock.Anything).Return(nil, errors.New("gateway timeout")).Once()
+	env.OnActivity(activities.ProcessPayment, mock.Anything, mock.Anything).Return(&activities.PaymentResult{Success: true}, nil).Once()
+	env.OnActivity(activities.RecordOrderMetrics, mock.Anything, mock.Anything).Return(nil)
+
+	items := &activities.OrderItemActivities{}
+	env.RegisterActivity(items)
+	var updates []activities.UpdateItemStatusInput
+	env.OnActivity(items.UpdateItemStatus, mock.Anything, mock.Anything).Return(
+		func(_ context.Context, input activities.UpdateItemStatusInput) error {
+			updates = append(updates, input)
+			return nil
+		})
+	env.OnActivity(activities.RefundPayment, mock.Anything, mock.Anything).Return(&activities.RefundResult{Refunded: true}, nil).Once()
+	env.OnActivity(activities.ReleaseInventory, mock.Anything, mock.Anything).Return(nil).Once()
+
+	var stage string
+	env.RegisterDelayedCallback(func() {
+		encoded, err := env.QueryWorkflow(workflows.OrderStageQuery)
+		require.NoError(t, err)
+		require.NoError(t, encoded.Get(&stage))
+		env.SignalWorkflow(workflows.CancelOrderSignal, workflows.CancelRequest{Reason: "changed_mind"})
+	}, 500*time.Millisecond)
+
+	env.ExecuteWorkflow(workflows.OrderFulfillmentWorkflow, itemStatusOrder)
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+
+	var result workflows.OrderResult
+	require.NoError(t, env.GetWorkflowResult(&result))
+	require.Equal(t, workflows.StagePayment, stage)
+	require.Equal(t, "cancelled", result.Status)
+	require.Equal(t, "cancelled_refunded", result.DecisionPath)
+	require.Len(t, updates, 2)
+	require.Equal(t, models.OrderItemStatusRefunded, statusesOf(updates[1])["prod-1"])
+	env.AssertNotCalled(t, "ReserveShipping", mock.Anything, mock.Anything)
+	env.AssertExpectations(t)
+}
+
+func TestOrderFulfillmentWorkflow_CancelDuringManualReview(t *testing.T) {
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
+	env.OnActivity(activities.ValidateOrder, mock.Anything, mock.Anything).Return(&activities.ValidateOrderResult{Valid: true}, nil)
+	env.OnActivity(activities.FraudAssessment, mock.Anything, mock.Anything).Return(&activities.FraudAssessmentResult{RiskScore: 90}, nil)
+	env.OnActivity(activities.SendConfirmation, mock.Anything, mock.Anything).Return(nil)
+	env.OnActivity(activities.RecordOrderMetrics, mock.Anything, mock.Anything).Return(nil)
+
+	var stage string
+	env.RegisterDelayedCallback(func() {
+		encoded, err := env.QueryWorkflow(workflows.OrderStageQuery)
+		require.NoError(t, err)
+		require.NoError(t, encoded.Get(&stage))
+		env.SignalWorkflow(workflows.CancelOrderSignal, workflows.CancelRequest{Reason: "fraud_suspected"})
+	}, time.Hour)
+
+	env.ExecuteWorkflow(workflows.OrderFulfillmentWorkflow, workflows.OrderInput{
+		OrderID:      "test-order-cancel-review",
+		CustomerID:   "new-customer",
+		CustomerTier: "new",
+		TotalAmount:  money.New(500000, money.USD),
+		Items: []workflows.OrderItemInput{
+			{ProductID: "prod-1", Quantity: 100, Price: money.New(5000, money.USD)},
+		},
+	})
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+
+	var result workflows.OrderResult
+	require.NoError(t, env.GetWorkflowResult(&result))
+	require.Equal(t, workflows.StageManualReview, stage)
+	require.Equal(t, "order_cancelled", result.DecisionPath)
+
+	encoded, err := env.QueryWorkflow(workflows.OrderStageQuery)
+	require.NoError(t, err)
+	require.NoError(t, encoded.Get(&stage))
+	require.Equal(t, workflows.StageDone, stage)
+}
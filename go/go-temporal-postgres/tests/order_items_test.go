@@ -0,0 +1,125 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/testsuite"
+
+	"github.com/base-14/examples/go/go-temporal-postgres/internal/activities"
+	"github.com/base-14/examples/go/go-temporal-postgres/internal/models"
+	"github.com/base-14/examples/go/go-temporal-postgres/internal/workflows"
+	"github.com/base-14/examples/go/go-temporal-postgres/pkg/money"
+)
+
+func TestOrderItemStatusSources(t *testing.T) {
+	require.Equal(t, []models.OrderItemStatus{models.OrderItemStatusPending, models.OrderItemStatusBackordered},
+		models.OrderItemStatusReserved.Sources())
+	require.Equal(t, []models.OrderItemStatus{models.OrderItemStatusReserved}, models.OrderItemStatusShipped.Sources())
+	require.Equal(t, []models.OrderItemStatus{models.OrderItemStatusShipped}, models.OrderItemStatusDelivered.Sources())
+	require.Empty(t, models.OrderItemStatusPending.Sources())
+	require.Empty(t, models.OrderItemStatus("lost").Sources())
+}
+
+// itemStatusEnv mocks every activity up to shipping on the happy path and
+// collects the item status updates the workflow sends.
+func itemStatusEnv(t *testing.T, inventory *activities.InventoryCheckResult) (*testsuite.TestWorkflowEnvironment, *[]activities.UpdateItemStatusInput) {
+	t.Helper()
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
+
+	env.OnActivity(activities.ValidateOrder, mock.Anything, mock.Anything).Return(&activities.ValidateOrderResult{Valid: true}, nil)
+	env.OnActivity(activities.FraudAssessment, mock.Anything, mock.Anything).Return(&activities.FraudAssessmentResult{RiskScore: 10}, nil)
+	env.OnActivity(activities.InventoryCheck, mock.Anything, mock.Anything).Return(inventory, nil)
+	env.OnActivity(activities.ProcessPayment, mock.Anything, mock.Anything).Return(&activities.PaymentResult{Success: true}, nil)
+	env.OnActivity(activities.SendConfirmation, mock.Anything, mock.Anything).Return(nil)
+	env.OnActivity(activities.RecordOrderMetrics, mock.Anything, mock.Anything).Return(nil)
+
+	items := &activities.OrderItemActivities{}
+	env.RegisterActivity(items)
+	var updates []activities.UpdateItemStatusInput
+	env.OnActivity(items.UpdateItemStatus, mock.Anything, mock.Anything).Return(
+		func(_ context.Context, input activities.UpdateItemStatusInput) error {
+			updates = append(updates, input)
+			return nil
+		})
+	return env, &updates
+}
+
+var itemStatusOrder = workflows.OrderInput{
+	OrderID:      "test-order-items",
+	CustomerID:   "customer-1",
+	CustomerTier: "gold",
+	TotalAmount:  money.New(15000, money.USD),
+	Items: []workflows.OrderItemInput{
+		{ProductID: "prod-1", Quantity: 1, Price: money.New(5000, money.USD)},
+		{ProductID: "prod-2", Quantity: 1, Price: money.New(10000, money.USD)},
+	},
+}
+
+func statusesOf(update activities.UpdateItemStatusInput) map[string]models.OrderItemStatus {
+	statuses := make(map[string]models.OrderItemStatus, len(update.Items))
+	for _, item := range update.Items {
+		statuses[item.ProductID] = item.Status
+	}
+	return statuses
+}
+
+func TestOrderFulfillmentWorkflow_ItemsReservedThenShipped(t *testing.T) {
+	env, updates := itemStatusEnv(t, &activities.InventoryCheckResult{AllAvailable: true})
+	env.OnActivity(activities.ReserveShipping, mock.Anything, mock.Anything).Return(&activities.ShippingResult{Reserved: true}, nil)
+	registerShipmentTracking(env)
+
+	env.ExecuteWorkflow(workflows.OrderFulfillmentWorkflow, itemStatusOrder)
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+
+	// The third update marks the items delivered once tracking ends.
+	require.Len(t, *updates, 3)
+	require.Equal(t, "test-order-items", (*updates)[0].OrderID)
+	require.Equal(t, map[string]models.OrderItemStatus{
+		"prod-1": models.OrderItemStatusReserved,
+		"prod-2": models.OrderItemStatusReserved,
+	}, statusesOf((*updates)[0]))
+	require.Equal(t, map[string]models.OrderItemStatus{
+		"prod-1": models.OrderItemStatusShipped,
+		"prod-2": models.OrderItemStatusShipped,
+	}, statusesOf((*updates)[1]))
+}
+
+func TestOrderFulfillmentWorkflow_ItemsRefundedWhenShippingFails(t *testing.T) {
+	env, updates := itemStatusEnv(t, &activities.InventoryCheckResult{AllAvailable: true})
+	env.OnActivity(activities.ReserveShipping, mock.Anything, mock.Anything).Return(nil, errors.New("carrier unavailable"))
+	env.OnActivity(activities.RefundPayment, mock.Anything, mock.Anything).Return(&activities.RefundResult{Refunded: true}, nil)
+	env.OnActivity(activities.ReleaseInventory, mock.Anything, mock.Anything).Return(nil)
+
+	env.ExecuteWorkflow(workflows.OrderFulfillmentWorkflow, itemStatusOrder)
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+
+	require.Len(t, *updates, 2)
+	require.Equal(t, models.OrderItemStatusRefunded, statusesOf((*updates)[1])["prod-2"])
+}
+
+func TestOrderFulfillmentWorkflow_BackorderMarksOnlyUnavailableItems(t *testing.T) {
+	env, updates := itemStatusEnv(t, &activities.InventoryCheckResult{
+		UnavailableItems: []activities.UnavailableItem{{ProductID: "prod-2", Requested: 1}},
+	})
+
+	env.ExecuteWorkflow(workflows.OrderFulfillmentWorkflow, itemStatusOrder)
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+
+	var result workflows.OrderResult
+	require.NoError(t, env.GetWorkflowResult(&result))
+	require.Equal(t, "backordered", result.Status)
+
+	require.Len(t, *updates, 1)
+	require.Equal(t, map[string]models.OrderItemStatus{
+		"prod-1": models.OrderItemStatusReserved,
+		"prod-2": models.OrderItemStatusBackordered,
+	}, statusesOf((*updates)[0]))
+}
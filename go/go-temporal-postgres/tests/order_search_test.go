@@ -0,0 +1,104 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/testsuite"
+
+	"github.com/base-14/examples/go/go-temporal-postgres/internal/activities"
+	"github.com/base-14/examples/go/go-temporal-postgres/internal/models"
+	"github.com/base-14/examples/go/go-temporal-postgres/internal/workflows"
+	"github.com/base-14/examples/go/go-temporal-postgres/pkg/money"
+)
+
+func TestOrderSearchApply_BuildsRowFromEvents(t *testing.T) {
+	orderID := uuid.New()
+	placedAt := time.Date(2026, 1, 2, 10, 0, 0, 0, time.UTC)
+
+	var row models.OrderSearch
+	row.Apply(models.OrderEvent{ID: 1, OrderID: orderID, Type: models.OrderEventPlaced, Status: "pending",
+		CustomerID: "customer-1", CustomerTier: "premium", TotalAmount: 5998, Currency: "USD", ItemCount: 2, OccurredAt: placedAt})
+	row.Apply(models.OrderEvent{ID: 2, OrderID: orderID, Type: models.OrderEventStarted, Status: "processing",
+		WorkflowID: "order-" + orderID.String(), OccurredAt: placedAt.Add(time.Second)})
+	row.Apply(models.OrderEvent{ID: 3, OrderID: orderID, Type: models.OrderEventDecided, Status: "completed",
+		DecisionPath: "auto_approved", RiskScore: 20, Message: "Order completed", OccurredAt: placedAt.Add(5 * time.Second)})
+
+	require.Equal(t, orderID, row.OrderID)
+	require.Equal(t, "completed", row.Status)
+	require.Equal(t, "premium", row.CustomerTier)
+	require.Equal(t, int64(5998), row.TotalAmount)
+	require.Equal(t, "auto_approved", row.LatestDecision)
+	require.Equal(t, 20, row.RiskScore)
+	require.Equal(t, placedAt, row.PlacedAt)
+	require.NotNil(t, row.DecidedAt)
+	require.Equal(t, int64(3), row.LastEventID)
+}
+
+func TestOrderSearchApply_LateStartDoesNotOverrideDecision(t *testing.T) {
+	orderID := uuid.New()
+	now := time.Now()
+
+	var row models.OrderSearch
+	row.Apply(models.OrderEvent{ID: 1, OrderID: orderID, Type: models.OrderEventPlaced, Status: "pending", OccurredAt: now})
+	row.Apply(models.OrderEvent{ID: 2, OrderID: orderID, Type: models.OrderEventDecided, Status: "completed",
+		DecisionPath: "auto_approved", OccurredAt: now})
+	row.Apply(models.OrderEvent{ID: 3, OrderID: orderID, Type: models.OrderEventStarted, Status: "processing",
+		WorkflowID: "order-1", OccurredAt: now})
+
+	require.Equal(t, "completed", row.Status)
+	require.Equal(t, "order-1", row.WorkflowID)
+}
+
+func TestOrderFulfillmentWorkflow_RecordsOrderEvents(t *testing.T) {
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
+
+	env.OnActivity(activities.ValidateOrder, mock.Anything, mock.Anything).Return(&activities.ValidateOrderResult{
+		Valid: true,
+	}, nil)
+	env.OnActivity(activities.FraudAssessment, mock.Anything, mock.Anything).Return(&activities.FraudAssessmentResult{
+		RiskScore: 85,
+	}, nil)
+	env.OnActivity(activities.SendConfirmation, mock.Anything, mock.Anything).Return(nil)
+	env.OnActivity(activities.RecordOrderMetrics, mock.Anything, mock.Anything).Return(nil)
+
+	events := &activities.OrderEventActivities{}
+	env.RegisterActivity(events)
+	var recorded []activities.OrderEventInput
+	env.OnActivity(events.RecordOrderEvent, mock.Anything, mock.Anything).Return(
+		func(_ context.Context, input activities.OrderEventInput) error {
+			recorded = append(recorded, input)
+			return nil
+		})
+
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow(workflows.ManualReviewSignal, "rejected")
+	}, time.Minute)
+
+	input := workflows.OrderInput{
+		OrderID:      "test-order-events",
+		CustomerID:   "new-customer",
+		CustomerTier: "new",
+		TotalAmount:  money.New(500000, money.USD),
+		Items: []workflows.OrderItemInput{
+			{ProductID: "prod-1", Quantity: 100, Price: money.New(5000, money.USD)},
+		},
+	}
+
+	env.ExecuteWorkflow(workflows.OrderFulfillmentWorkflow, input)
+
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+
+	require.Len(t, recorded, 2)
+	require.Equal(t, "manual_review", recorded[0].DecisionPath)
+	require.Equal(t, "rejected", recorded[1].Status)
+	require.Equal(t, "manual_rejected", recorded[1].DecisionPath)
+	require.Equal(t, 85, recorded[1].RiskScore)
+	require.Equal(t, "test-order-events", recorded[1].OrderID)
+}
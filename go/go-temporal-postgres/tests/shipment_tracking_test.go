@@ -0,0 +1,193 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/testsuite"
+
+	"github.com/base-14/examples/go/go-temporal-postgres/internal/activities"
+	"github.com/base-14/examples/go/go-temporal-postgres/internal/models"
+	"github.com/base-14/examples/go/go-temporal-postgres/internal/workflows"
+)
+
+// registerShipmentTracking lets an order workflow run its tracking child
+// against the deterministic carrier, and collects the stages it records.
+func registerShipmentTracking(env *testsuite.TestWorkflowEnvironment) *[]activities.ShipmentStageInput {
+	env.RegisterWorkflow(workflows.ShipmentTrackingWorkflow)
+	env.RegisterActivity(activities.CheckCarrierStatus)
+	var stages []activities.ShipmentStageInput
+	env.OnActivity(activities.RecordShipmentStage, mock.Anything, mock.Anything).Return(
+		func(_ context.Context, input activities.ShipmentStageInput) error {
+			stages = append(stages, input)
+			return nil
+		})
+	return &stages
+}
+
+var trackingInput = workflows.ShipmentTrackingInput{
+	OrderID:          "test-order-tracking",
+	TrackingID:       "TRK-1",
+	PollIntervalSecs: 60,
+	MaxPolls:         10,
+}
+
+func TestShipmentTrackingWorkflow_Delivered(t *testing.T) {
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
+	stages := registerShipmentTracking(env)
+
+	env.ExecuteWorkflow(workflows.ShipmentTrackingWorkflow, trackingInput)
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+
+	var result workflows.ShipmentTrackingResult
+	require.NoError(t, env.GetWorkflowResult(&result))
+	require.True(t, result.Delivered)
+	require.Equal(t, activities.ShipmentDelivered, result.Status)
+	require.Equal(t, 4, result.Polls)
+
+	require.Len(t, *stages, 4)
+	require.Equal(t, activities.ShipmentStageInput{OrderID: "test-order-tracking", To: activities.ShipmentAwaitingPickup}, (*stages)[0])
+	require.Equal(t, activities.ShipmentPickedUp, (*stages)[1].To)
+	require.Equal(t, activities.ShipmentAwaitingPickup, (*stages)[1].From)
+	require.Equal(t, float64(60), (*stages)[1].SecondsInStage)
+	require.Equal(t, activities.ShipmentDelivered, (*stages)[3].To)
+}
+
+func TestShipmentTrackingWorkflow_StalledAndFailedPolls(t *testing.T) {
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
+	stages := registerShipmentTracking(env)
+
+	// The first poll fails on every attempt, then the carrier reports the
+	// parcel picked up twice before it moves on.
+	calls := 0
+	env.OnActivity(activities.CheckCarrierStatus, mock.Anything, mock.Anything).Return(
+		func(_ context.Context, input activities.CarrierStatusInput) (*activities.CarrierStatusResult, error) {
+			calls++
+			switch calls {
+			case 1, 2, 3:
+				return nil, errors.New("carrier unavailable")
+			case 4, 5:
+				return &activities.CarrierStatusResult{Status: activities.ShipmentPickedUp}, nil
+			}
+			return &activities.CarrierStatusResult{Status: activities.NextShipmentStatus(input.Status)}, nil
+		})
+
+	env.ExecuteWorkflow(workflows.ShipmentTrackingWorkflow, trackingInput)
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+
+	var result workflows.ShipmentTrackingResult
+	require.NoError(t, env.GetWorkflowResult(&result))
+	require.True(t, result.Delivered)
+	require.Equal(t, 5, result.Polls)
+
+	require.Len(t, *stages, 3)
+	require.Equal(t, activities.ShipmentInTransit, (*stages)[1].To)
+	require.Equal(t, float64(120), (*stages)[1].SecondsInStage)
+}
+
+func TestShipmentTrackingWorkflow_GivesUpAfterMaxPolls(t *testing.T) {
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
+	registerShipmentTracking(env)
+	env.OnActivity(activities.CheckCarrierStatus, mock.Anything, mock.Anything).Return(
+		&activities.CarrierStatusResult{Status: activities.ShipmentInTransit}, nil)
+
+	env.ExecuteWorkflow(workflows.ShipmentTrackingWorkflow, trackingInput)
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+
+	var result workflows.ShipmentTrackingResult
+	require.NoError(t, env.GetWorkflowResult(&result))
+	require.False(t, result.Delivered)
+	require.Equal(t, activities.ShipmentInTransit, result.Status)
+	require.Equal(t, 10, result.Polls)
+}
+
+func TestOrderFulfillmentWorkflow_TracksShipmentUntilDelivered(t *testing.T) {
+	env, updates := itemStatusEnv(t, &activities.InventoryCheckResult{AllAvailable: true})
+	env.OnActivity(activities.ReserveShipping, mock.Anything, mock.Anything).Return(&activities.ShippingResult{Reserved: true, TrackingID: "TRK-1"}, nil)
+	stages := registerShipmentTracking(env)
+	events := &activities.OrderEventActivities{}
+	env.RegisterActivity(events)
+	env.OnActivity(events.RecordOrderEvent, mock.Anything, mock.Anything).Return(nil)
+
+	// The order polls the carrier every 30s once it completes; between the
+	// second and third poll the parcel has been picked up.
+	var during workflows.OrderProgress
+	env.RegisterDelayedCallback(func() {
+		encoded, err := env.QueryWorkflow(workflows.OrderStatusQuery)
+		require.NoError(t, err)
+		require.NoError(t, encoded.Get(&during))
+	}, 75*time.Second)
+
+	env.ExecuteWorkflow(workflows.OrderFulfillmentWorkflow, itemStatusOrder)
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+
+	require.Equal(t, "completed", during.Status)
+	require.Equal(t, workflows.StageTracking, during.Stage)
+	require.Equal(t, activities.ShipmentPickedUp, during.Shipment)
+	require.True(t, during.Terminal)
+
+	require.Len(t, *stages, 4)
+	require.Len(t, *updates, 3)
+	require.Equal(t, map[string]models.OrderItemStatus{
+		"prod-1": models.OrderItemStatusDelivered,
+		"prod-2": models.OrderItemStatusDelivered,
+	}, statusesOf((*updates)[2]))
+
+	encoded, err := env.QueryWorkflow(workflows.OrderStatusQuery)
+	require.NoError(t, err)
+	var final workflows.OrderProgress
+	require.NoError(t, encoded.Get(&final))
+	require.Equal(t, activities.ShipmentDelivered, final.Shipment)
+	require.Equal(t, workflows.StageDone, final.Stage)
+}
+
+func TestOrderFulfillmentWorkflow_UndeliveredShipmentStillCompletes(t *testing.T) {
+	env, updates := itemStatusEnv(t, &activities.InventoryCheckResult{AllAvailable: true})
+	env.OnActivity(activities.ReserveShipping, mock.Anything, mock.Anything).Return(&activities.ShippingResult{Reserved: true, TrackingID: "TRK-1"}, nil)
+	registerShipmentTracking(env)
+	env.OnActivity(activities.CheckCarrierStatus, mock.Anything, mock.Anything).Return(nil, errors.New("carrier unavailable"))
+
+	// The carrier never answers, so the child would poll for an hour; the
+	// order stops following it well before that.
+	closedEarly := false
+	env.RegisterDelayedCallback(func() {
+		closedEarly = env.IsWorkflowCompleted()
+	}, 30*time.Minute)
+	env.ExecuteWorkflow(workflows.OrderFulfillmentWorkflow, itemStatusOrder)
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+
+	var result workflows.OrderResult
+	require.NoError(t, env.GetWorkflowResult(&result))
+	require.Equal(t, "completed", result.Status)
+	require.Len(t, *updates, 2)
+	require.True(t, closedEarly)
+}
+
+func TestShipmentTrackingWorkflow_MarksItemsDelivered(t *testing.T) {
+	env, updates := itemStatusEnv(t, &activities.InventoryCheckResult{AllAvailable: true})
+	registerShipmentTracking(env)
+
+	input := trackingInput
+	input.ProductIDs = []string{"prod-1", "prod-2"}
+	env.ExecuteWorkflow(workflows.ShipmentTrackingWorkflow, input)
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+
+	require.Len(t, *updates, 1)
+	require.Equal(t, map[string]models.OrderItemStatus{
+		"prod-1": models.OrderItemStatusDelivered,
+		"prod-2": models.OrderItemStatusDelivered,
+	}, statusesOf((*updates)[0]))
+}
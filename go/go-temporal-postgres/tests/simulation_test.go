@@ -0,0 +1,64 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/base-14/examples/go/go-temporal-postgres/pkg/simulation"
+)
+
+func TestSimulationLoadConfig(t *testing.T) {
+	t.Setenv("SHIPPING_FAILURE_RATE", "0.2")
+	t.Setenv("SHIPPING_LATENCY_MIN_MS", "20")
+	t.Setenv("SHIPPING_P95_LATENCY_MS", "800")
+	t.Setenv("SHIPPING_TIMEOUT_RATE", "0.05")
+
+	assert.Equal(t, simulation.Config{
+		FailureRate:  0.2,
+		MinLatencyMs: 20,
+		P95LatencyMs: 800,
+		TimeoutRate:  0.05,
+		Enabled:      true,
+	}, simulation.LoadConfig("SHIPPING"))
+}
+
+func TestTailLatencyP95(t *testing.T) {
+	const samples = 4000
+	draws := make([]time.Duration, samples)
+	for i := range draws {
+		draws[i] = simulation.TailLatency(50, 500)
+	}
+	sort.Slice(draws, func(i, j int) bool { return draws[i] < draws[j] })
+
+	assert.GreaterOrEqual(t, draws[0], 50*time.Millisecond)
+	p95 := draws[samples*95/100]
+	assert.InDelta(t, 500, p95.Milliseconds(), 75, "p95 = %s", p95)
+}
+
+func TestMaybeFailWithLatencyDisabled(t *testing.T) {
+	cfg := simulation.Config{FailureRate: 1, TimeoutRate: 1, Enabled: false}
+	require.NoError(t, simulation.MaybeFailWithLatency(context.Background(), cfg))
+}
+
+func TestMaybeFailWithLatencyFailure(t *testing.T) {
+	cfg := simulation.Config{FailureRate: 1, Enabled: true}
+	err := simulation.MaybeFailWithLatency(context.Background(), cfg)
+	assert.ErrorIs(t, err, simulation.ErrSimulatedFailure)
+}
+
+func TestMaybeFailWithLatencyTimeoutHangsUntilDeadline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := simulation.MaybeFailWithLatency(ctx, simulation.Config{TimeoutRate: 1, Enabled: true})
+	assert.ErrorIs(t, err, simulation.ErrSimulatedTimeout)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+}
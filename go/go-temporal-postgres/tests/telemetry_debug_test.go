@@ -0,0 +1,49 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+
+	"github.com/base-14/examples/go/go-temporal-postgres/internal/telemetry"
+)
+
+func TestDebugMetricsHandler(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	meter := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)).Meter("test")
+
+	processed, err := meter.Int64Counter("orders.processed")
+	require.NoError(t, err)
+	duration, err := meter.Float64Histogram("orders.processing_duration")
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	gold := metric.WithAttributes(attribute.String("customer_tier", "gold"))
+	processed.Add(ctx, 2, gold)
+	processed.Add(ctx, 1, metric.WithAttributes(attribute.String("customer_tier", "standard")))
+	duration.Record(ctx, 1.5, metric.WithAttributes(attribute.String("decision_path", "backorder")))
+	duration.Record(ctx, 0.5, metric.WithAttributes(attribute.String("decision_path", "backorder")))
+
+	rec := httptest.NewRecorder()
+	telemetry.DebugMetricsHandler(reader).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, telemetry.DebugMetricsPath, nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var snapshot telemetry.DebugSnapshot
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&snapshot))
+
+	assert.Equal(t, 3.0, snapshot.Sum("orders.processed", nil))
+	assert.Equal(t, 2.0, snapshot.Sum("orders.processed", map[string]string{"customer_tier": "gold"}))
+	assert.Zero(t, snapshot.Sum("orders.processed", map[string]string{"customer_tier": "platinum"}))
+
+	backorder := map[string]string{"decision_path": "backorder"}
+	assert.Equal(t, uint64(2), snapshot.Count("orders.processing_duration", backorder))
+	assert.Equal(t, 2.0, snapshot.Sum("orders.processing_duration", backorder))
+}
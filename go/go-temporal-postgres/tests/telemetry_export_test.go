@@ -0,0 +1,39 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/base-14/examples/go/go-temporal-postgres/pkg/telemetry"
+)
+
+func TestExportConfigFromEnv(t *testing.T) {
+	t.Setenv("OTEL_BSP_MAX_QUEUE_SIZE", "4096")
+	t.Setenv("OTEL_BSP_MAX_EXPORT_BATCH_SIZE", "256")
+	t.Setenv("OTEL_BSP_SCHEDULE_DELAY", "2000")
+	t.Setenv("OTEL_EXPORTER_OTLP_TIMEOUT", "5000")
+	t.Setenv("OTEL_METRIC_EXPORT_INTERVAL", "15000")
+	t.Setenv("OTEL_EXPORTER_OTLP_RETRY_MAX_ELAPSED", "-1s")
+
+	cfg := telemetry.ExportConfigFromEnv()
+
+	assert.Equal(t, 4096, cfg.MaxQueueSize)
+	assert.Equal(t, 256, cfg.MaxExportBatchSize)
+	assert.Equal(t, 2*time.Second, cfg.BatchTimeout)
+	assert.Equal(t, 5*time.Second, cfg.ExportTimeout)
+	assert.Equal(t, 15*time.Second, cfg.MetricInterval)
+	assert.Equal(t, -time.Second, cfg.RetryMaxElapsed)
+}
+
+func TestExportConfigFromEnvDefaults(t *testing.T) {
+	for _, key := range []string{
+		"OTEL_BSP_MAX_QUEUE_SIZE", "OTEL_BSP_MAX_EXPORT_BATCH_SIZE", "OTEL_BSP_SCHEDULE_DELAY",
+		"OTEL_EXPORTER_OTLP_TIMEOUT", "OTEL_METRIC_EXPORT_INTERVAL", "OTEL_EXPORTER_OTLP_RETRY_MAX_ELAPSED",
+	} {
+		t.Setenv(key, "")
+	}
+
+	assert.Equal(t, telemetry.ExportConfig{}, telemetry.ExportConfigFromEnv())
+}
@@ -0,0 +1,82 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/base-14/examples/go/go-temporal-postgres/internal/activities"
+	"github.com/base-14/examples/go/go-temporal-postgres/internal/models"
+	"github.com/base-14/examples/go/go-temporal-postgres/internal/telemetry"
+	"github.com/base-14/examples/go/pkg/oteltest"
+)
+
+// The order metrics bind to the global meter provider on first use, so every
+// assertion on them lives in this one test.
+func TestRecordOrderMetrics(t *testing.T) {
+	tel := oteltest.New(t)
+	tel.SetGlobal(t)
+
+	ctx := context.Background()
+	for _, input := range []activities.RecordMetricsInput{
+		{OrderID: "o1", CustomerTier: "gold", DecisionPath: "auto_approved", RiskScore: 12},
+		{OrderID: "o2", CustomerTier: "gold", DecisionPath: "auto_approved", RiskScore: 30},
+		{OrderID: "o3", CustomerTier: "standard", DecisionPath: "payment_declined", FailureReason: "insufficient_funds"},
+	} {
+		assert.NoError(t, activities.RecordOrderMetrics(ctx, input))
+	}
+
+	gold := attribute.String("customer_tier", "gold")
+	assert.Equal(t, int64(2), oteltest.Sum[int64](t, tel, "orders.processed", gold))
+	assert.Equal(t, int64(2), oteltest.Sum[int64](t, tel, "orders.approved", gold))
+	assert.Equal(t, int64(1), oteltest.Sum[int64](t, tel, "orders.payment_failed", attribute.String("reason", "insufficient_funds")))
+
+	risk := oteltest.Histogram[int64](t, tel, "orders.fraud_risk_score", gold)
+	assert.Equal(t, uint64(2), risk.Count)
+	assert.Equal(t, int64(42), risk.Sum)
+
+	purges := &activities.PurgeActivities{}
+	assert.NoError(t, purges.RecordPurgeMetrics(ctx, activities.RecordPurgeMetricsInput{
+		Outcome:      "completed",
+		DurationSecs: 1.5,
+		Report:       &models.CustomerPurge{OrdersAnonymized: 3, PreferencesDeleted: 2},
+	}))
+	assert.NoError(t, purges.RecordPurgeMetrics(ctx, activities.RecordPurgeMetricsInput{Outcome: "failed", DurationSecs: 4}))
+
+	assert.Equal(t, int64(1), oteltest.Sum[int64](t, tel, "customers.purges", attribute.String("outcome", "completed")))
+	assert.Equal(t, int64(1), oteltest.Sum[int64](t, tel, "customers.purges", attribute.String("outcome", "failed")))
+	assert.Equal(t, int64(3), oteltest.Sum[int64](t, tel, "customers.purge.records", attribute.String("record_type", "order")))
+	assert.Equal(t, int64(2), oteltest.Sum[int64](t, tel, "customers.purge.records", attribute.String("record_type", "notification_preference")))
+	assert.Equal(t, uint64(1), oteltest.Histogram[float64](t, tel, "customers.purge.duration", attribute.String("outcome", "failed")).Count)
+
+	telemetry.RecordNotificationDelivery(ctx, "email", "order_completed", models.NotificationStatusSent, models.NotificationSourceWorkflow)
+	telemetry.RecordNotificationDelivery(ctx, "email", "order_completed", models.NotificationStatusFailed, models.NotificationSourceWorkflow)
+	telemetry.RecordNotificationDelivery(ctx, "email", "order_completed", models.NotificationStatusSent, models.NotificationSourceRedelivery)
+	telemetry.RecordNotificationsUndelivered(ctx, 4, 1)
+
+	workflow := attribute.String("source", models.NotificationSourceWorkflow)
+	assert.Equal(t, int64(1), oteltest.Sum[int64](t, tel, "notifications.deliveries", workflow, attribute.String("status", models.NotificationStatusSent)))
+	assert.Equal(t, int64(1), oteltest.Sum[int64](t, tel, "notifications.deliveries", workflow, attribute.String("status", models.NotificationStatusFailed)))
+	assert.Equal(t, int64(1), oteltest.Sum[int64](t, tel, "notifications.deliveries",
+		attribute.String("source", models.NotificationSourceRedelivery), attribute.String("status", models.NotificationStatusSent)))
+
+	telemetry.RecordOrderItemStatusChanges(ctx, string(models.OrderItemStatusShipped), 3)
+	telemetry.RecordOrderItemStatusChanges(ctx, string(models.OrderItemStatusShipped), 2)
+	assert.Equal(t, int64(5), oteltest.Sum[int64](t, tel, "orders.items.status_changes",
+		attribute.String("status", string(models.OrderItemStatusShipped))))
+
+	for _, stage := range []activities.ShipmentStageInput{
+		{OrderID: "o1", To: activities.ShipmentAwaitingPickup},
+		{OrderID: "o1", From: activities.ShipmentAwaitingPickup, To: activities.ShipmentPickedUp, SecondsInStage: 600},
+		{OrderID: "o2", To: activities.ShipmentAwaitingPickup},
+		{OrderID: "o2", From: activities.ShipmentAwaitingPickup, To: activities.ShipmentPickedUp, SecondsInStage: 1200},
+	} {
+		assert.NoError(t, activities.RecordShipmentStage(ctx, stage))
+	}
+	assert.Equal(t, int64(2), oteltest.Sum[int64](t, tel, "shipments.stage_transitions", attribute.String("stage", activities.ShipmentPickedUp)))
+	awaiting := oteltest.Histogram[float64](t, tel, "shipments.stage.duration", attribute.String("stage", activities.ShipmentAwaitingPickup))
+	assert.Equal(t, uint64(2), awaiting.Count)
+	assert.Equal(t, float64(1800), awaiting.Sum)
+}
@@ -0,0 +1,84 @@
+package tests
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	pkgtemporal "github.com/base-14/examples/go/go-temporal-postgres/pkg/temporal"
+)
+
+func TestRetryConfigFromEnv(t *testing.T) {
+	t.Setenv("TEMPORAL_CONNECT_MAX_ATTEMPTS", "5")
+	t.Setenv("TEMPORAL_CONNECT_INITIAL_BACKOFF", "500ms")
+	t.Setenv("TEMPORAL_CONNECT_MAX_BACKOFF", "10s")
+	t.Setenv("TEMPORAL_CONNECT_TIMEOUT", "3s")
+	t.Setenv("TEMPORAL_HEALTH_CHECK_INTERVAL", "1m")
+
+	assert.Equal(t, pkgtemporal.RetryConfig{
+		MaxAttempts:    5,
+		InitialBackoff: 500 * time.Millisecond,
+		MaxBackoff:     10 * time.Second,
+		AttemptTimeout: 3 * time.Second,
+		HealthInterval: time.Minute,
+	}, pkgtemporal.RetryConfigFromEnv())
+}
+
+func TestRetryBackoffIsBoundedWithJitter(t *testing.T) {
+	cfg := pkgtemporal.RetryConfig{InitialBackoff: time.Second, MaxBackoff: 8 * time.Second}
+
+	for attempt, want := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 4: 8 * time.Second, 10: 8 * time.Second} {
+		for range 50 {
+			got := cfg.Backoff(attempt)
+			assert.GreaterOrEqual(t, got, want/2, "attempt %d", attempt)
+			assert.LessOrEqual(t, got, want, "attempt %d", attempt)
+		}
+	}
+}
+
+func TestNewClientGivesUpAfterMaxAttempts(t *testing.T) {
+	// A listener that is closed immediately leaves a port that refuses
+	// connections, so every dial fails fast.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := ln.Addr().String()
+	require.NoError(t, ln.Close())
+
+	_, err = pkgtemporal.NewClient(context.Background(), pkgtemporal.ClientConfig{
+		HostPort: addr,
+		Retry: pkgtemporal.RetryConfig{
+			MaxAttempts:    2,
+			InitialBackoff: 10 * time.Millisecond,
+			MaxBackoff:     10 * time.Millisecond,
+			AttemptTimeout: time.Second,
+		},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "after 2 attempts")
+}
+
+func TestNewClientStopsWhenContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := pkgtemporal.NewClient(ctx, pkgtemporal.ClientConfig{
+		HostPort: "127.0.0.1:1",
+		Retry:    pkgtemporal.RetryConfig{MaxAttempts: 100},
+	})
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestHealthCheckFailsWhenTemporalIsDown(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := ln.Addr().String()
+	require.NoError(t, ln.Close())
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.Error(t, pkgtemporal.HealthCheck(addr)(ctx))
+}
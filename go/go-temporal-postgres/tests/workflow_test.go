@@ -1,14 +1,19 @@
 package tests
 
 import (
+	"context"
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/temporal"
 	"go.temporal.io/sdk/testsuite"
 
 	"github.com/base-14/examples/go/go-temporal-postgres/internal/activities"
 	"github.com/base-14/examples/go/go-temporal-postgres/internal/workflows"
+	"github.com/base-14/examples/go/go-temporal-postgres/pkg/money"
 )
 
 func TestOrderFulfillmentWorkflow_AutoApprove(t *testing.T) {
@@ -39,14 +44,15 @@ func TestOrderFulfillmentWorkflow_AutoApprove(t *testing.T) {
 
 	env.OnActivity(activities.SendConfirmation, mock.Anything, mock.Anything).Return(nil)
 	env.OnActivity(activities.RecordOrderMetrics, mock.Anything, mock.Anything).Return(nil)
+	registerShipmentTracking(env)
 
 	input := workflows.OrderInput{
 		OrderID:      "test-order-1",
 		CustomerID:   "premium-customer",
 		CustomerTier: "premium",
-		TotalAmount:  50.00,
+		TotalAmount:  money.New(5000, money.USD),
 		Items: []workflows.OrderItemInput{
-			{ProductID: "prod-1", Quantity: 1, Price: 50.00},
+			{ProductID: "prod-1", Quantity: 1, Price: money.New(5000, money.USD)},
 		},
 	}
 
@@ -84,9 +90,9 @@ func TestOrderFulfillmentWorkflow_ManualReview(t *testing.T) {
 		OrderID:      "test-order-2",
 		CustomerID:   "new-customer",
 		CustomerTier: "new",
-		TotalAmount:  5000.00,
+		TotalAmount:  money.New(500000, money.USD),
 		Items: []workflows.OrderItemInput{
-			{ProductID: "prod-1", Quantity: 100, Price: 50.00},
+			{ProductID: "prod-1", Quantity: 100, Price: money.New(5000, money.USD)},
 		},
 	}
 
@@ -101,6 +107,108 @@ func TestOrderFulfillmentWorkflow_ManualReview(t *testing.T) {
 	require.Equal(t, "manual_approved", result.DecisionPath)
 }
 
+func TestOrderFulfillmentWorkflow_ManualReviewNotes(t *testing.T) {
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
+
+	env.OnActivity(activities.ValidateOrder, mock.Anything, mock.Anything).Return(&activities.ValidateOrderResult{
+		Valid: true,
+	}, nil)
+
+	env.OnActivity(activities.FraudAssessment, mock.Anything, mock.Anything).Return(&activities.FraudAssessmentResult{
+		RiskScore: 85,
+	}, nil)
+
+	env.OnActivity(activities.SendConfirmation, mock.Anything, mock.Anything).Return(nil)
+	env.OnActivity(activities.RecordOrderMetrics, mock.Anything, mock.Anything).Return(nil)
+
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow(workflows.OrderNoteSignal, workflows.ReviewNote{Author: "agent-1", Body: "Customer called to confirm"})
+		env.SignalWorkflow(workflows.OrderNoteSignal, workflows.ReviewNote{Author: "agent-2", Body: "Billing address verified"})
+	}, time.Minute)
+
+	var notes []workflows.ReviewNote
+	env.RegisterDelayedCallback(func() {
+		encoded, err := env.QueryWorkflow(workflows.ReviewNotesQuery)
+		require.NoError(t, err)
+		require.NoError(t, encoded.Get(&notes))
+		env.SignalWorkflow(workflows.ManualReviewSignal, "approved")
+	}, 2*time.Minute)
+
+	input := workflows.OrderInput{
+		OrderID:      "test-order-notes",
+		CustomerID:   "new-customer",
+		CustomerTier: "new",
+		TotalAmount:  money.New(500000, money.USD),
+		Items: []workflows.OrderItemInput{
+			{ProductID: "prod-1", Quantity: 100, Price: money.New(5000, money.USD)},
+		},
+	}
+
+	env.ExecuteWorkflow(workflows.OrderFulfillmentWorkflow, input)
+
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+
+	require.Len(t, notes, 2)
+	require.Equal(t, "agent-1", notes[0].Author)
+	require.Equal(t, "Billing address verified", notes[1].Body)
+
+	var result workflows.OrderResult
+	require.NoError(t, env.GetWorkflowResult(&result))
+	require.Equal(t, "manual_approved", result.DecisionPath)
+}
+
+func TestOrderFulfillmentWorkflow_StatusQuery(t *testing.T) {
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
+
+	env.OnActivity(activities.ValidateOrder, mock.Anything, mock.Anything).Return(&activities.ValidateOrderResult{
+		Valid: true,
+	}, nil)
+
+	env.OnActivity(activities.FraudAssessment, mock.Anything, mock.Anything).Return(&activities.FraudAssessmentResult{
+		RiskScore: 85,
+	}, nil)
+
+	env.OnActivity(activities.SendConfirmation, mock.Anything, mock.Anything).Return(nil)
+	env.OnActivity(activities.RecordOrderMetrics, mock.Anything, mock.Anything).Return(nil)
+
+	var inReview workflows.OrderProgress
+	env.RegisterDelayedCallback(func() {
+		encoded, err := env.QueryWorkflow(workflows.OrderStatusQuery)
+		require.NoError(t, err)
+		require.NoError(t, encoded.Get(&inReview))
+		env.SignalWorkflow(workflows.ManualReviewSignal, "rejected")
+	}, time.Minute)
+
+	input := workflows.OrderInput{
+		OrderID:      "test-order-status",
+		CustomerID:   "new-customer",
+		CustomerTier: "new",
+		TotalAmount:  money.New(500000, money.USD),
+		Items: []workflows.OrderItemInput{
+			{ProductID: "prod-1", Quantity: 100, Price: money.New(5000, money.USD)},
+		},
+	}
+
+	env.ExecuteWorkflow(workflows.OrderFulfillmentWorkflow, input)
+
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+
+	require.Equal(t, "manual_review", inReview.Status)
+	require.False(t, inReview.Terminal)
+
+	encoded, err := env.QueryWorkflow(workflows.OrderStatusQuery)
+	require.NoError(t, err)
+	var final workflows.OrderProgress
+	require.NoError(t, encoded.Get(&final))
+	require.Equal(t, "rejected", final.Status)
+	require.Equal(t, "manual_rejected", final.DecisionPath)
+	require.True(t, final.Terminal)
+}
+
 func TestOrderFulfillmentWorkflow_Backorder(t *testing.T) {
 	testSuite := &testsuite.WorkflowTestSuite{}
 	env := testSuite.NewTestWorkflowEnvironment()
@@ -127,9 +235,9 @@ func TestOrderFulfillmentWorkflow_Backorder(t *testing.T) {
 		OrderID:      "test-order-3",
 		CustomerID:   "test-customer",
 		CustomerTier: "standard",
-		TotalAmount:  100.00,
+		TotalAmount:  money.New(10000, money.USD),
 		Items: []workflows.OrderItemInput{
-			{ProductID: "out-of-stock-item", Quantity: 100, Price: 1.00},
+			{ProductID: "out-of-stock-item", Quantity: 100, Price: money.New(100, money.USD)},
 		},
 	}
 
@@ -165,15 +273,16 @@ func TestOrderFulfillmentWorkflow_PaymentFailed(t *testing.T) {
 		Reason:  "Card declined",
 	}, nil)
 
+	env.OnActivity(activities.ReleaseInventory, mock.Anything, mock.Anything).Return(nil).Once()
 	env.OnActivity(activities.RecordOrderMetrics, mock.Anything, mock.Anything).Return(nil)
 
 	input := workflows.OrderInput{
 		OrderID:      "test-order-4",
 		CustomerID:   "test-customer",
 		CustomerTier: "standard",
-		TotalAmount:  100.00,
+		TotalAmount:  money.New(10000, money.USD),
 		Items: []workflows.OrderItemInput{
-			{ProductID: "prod-1", Quantity: 1, Price: 100.00},
+			{ProductID: "prod-1", Quantity: 1, Price: money.New(10000, money.USD)},
 		},
 	}
 
@@ -186,4 +295,119 @@ func TestOrderFulfillmentWorkflow_PaymentFailed(t *testing.T) {
 	require.NoError(t, env.GetWorkflowResult(&result))
 	require.Equal(t, "payment_failed", result.Status)
 	require.Equal(t, "payment_declined", result.DecisionPath)
+	env.AssertExpectations(t)
+}
+
+func TestOrderFulfillmentWorkflow_ShippingFailedCompensates(t *testing.T) {
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
+
+	env.OnActivity(activities.ValidateOrder, mock.Anything, mock.Anything).Return(&activities.ValidateOrderResult{
+		Valid: true,
+	}, nil)
+
+	env.OnActivity(activities.FraudAssessment, mock.Anything, mock.Anything).Return(&activities.FraudAssessmentResult{
+		RiskScore: 20,
+	}, nil)
+
+	env.OnActivity(activities.InventoryCheck, mock.Anything, mock.Anything).Return(&activities.InventoryCheckResult{
+		AllAvailable: true,
+	}, nil)
+
+	env.OnActivity(activities.ProcessPayment, mock.Anything, mock.Anything).Return(&activities.PaymentResult{
+		Success:       true,
+		TransactionID: "txn-123",
+	}, nil)
+
+	env.OnActivity(activities.ReserveShipping, mock.Anything, mock.Anything).Return(nil, errors.New("carrier unavailable"))
+
+	var compensated []string
+	env.OnActivity(activities.RefundPayment, mock.Anything, mock.Anything).Return(
+		func(_ context.Context, input activities.RefundInput) (*activities.RefundResult, error) {
+			require.Equal(t, "txn-123", input.TransactionID)
+			require.Equal(t, "shipping_failed", input.Reason)
+			compensated = append(compensated, "refund")
+			return &activities.RefundResult{Refunded: true, RefundID: "rfd-123"}, nil
+		}).Once()
+	env.OnActivity(activities.ReleaseInventory, mock.Anything, mock.Anything).Return(
+		func(_ context.Context, _ activities.ReleaseInventoryInput) error {
+			compensated = append(compensated, "release")
+			return nil
+		}).Once()
+	env.OnActivity(activities.RecordOrderMetrics, mock.Anything, mock.Anything).Return(nil)
+
+	input := workflows.OrderInput{
+		OrderID:      "test-order-5",
+		CustomerID:   "test-customer",
+		CustomerTier: "standard",
+		TotalAmount:  money.New(10000, money.USD),
+		Items: []workflows.OrderItemInput{
+			{ProductID: "prod-1", Quantity: 1, Price: money.New(10000, money.USD)},
+		},
+	}
+
+	env.ExecuteWorkflow(workflows.OrderFulfillmentWorkflow, input)
+
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+
+	var result workflows.OrderResult
+	require.NoError(t, env.GetWorkflowResult(&result))
+	require.Equal(t, "cancelled", result.Status)
+	require.Equal(t, "compensated_refund", result.DecisionPath)
+	require.Equal(t, []string{"refund", "release"}, compensated)
+	env.AssertExpectations(t)
+}
+
+func TestOrderFulfillmentWorkflow_RefundFailed(t *testing.T) {
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
+
+	env.OnActivity(activities.ValidateOrder, mock.Anything, mock.Anything).Return(&activities.ValidateOrderResult{
+		Valid: true,
+	}, nil)
+
+	env.OnActivity(activities.FraudAssessment, mock.Anything, mock.Anything).Return(&activities.FraudAssessmentResult{
+		RiskScore: 20,
+	}, nil)
+
+	env.OnActivity(activities.InventoryCheck, mock.Anything, mock.Anything).Return(&activities.InventoryCheckResult{
+		AllAvailable: true,
+	}, nil)
+
+	env.OnActivity(activities.ProcessPayment, mock.Anything, mock.Anything).Return(&activities.PaymentResult{
+		Success:       true,
+		TransactionID: "txn-456",
+	}, nil)
+
+	env.OnActivity(activities.ReserveShipping, mock.Anything, mock.Anything).Return(&activities.ShippingResult{
+		Reserved: false,
+	}, nil)
+
+	env.OnActivity(activities.RefundPayment, mock.Anything, mock.Anything).Return(nil,
+		temporal.NewNonRetryableApplicationError("refund rejected", "RefundRejected", nil))
+	env.OnActivity(activities.ReleaseInventory, mock.Anything, mock.Anything).Return(nil).Once()
+	env.OnActivity(activities.RecordOrderMetrics, mock.Anything, mock.Anything).Return(nil)
+
+	input := workflows.OrderInput{
+		OrderID:      "test-order-6",
+		CustomerID:   "test-customer",
+		CustomerTier: "standard",
+		TotalAmount:  money.New(10000, money.USD),
+		Items: []workflows.OrderItemInput{
+			{ProductID: "prod-1", Quantity: 1, Price: money.New(10000, money.USD)},
+		},
+	}
+
+	env.ExecuteWorkflow(workflows.OrderFulfillmentWorkflow, input)
+
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+
+	var result workflows.OrderResult
+	require.NoError(t, env.GetWorkflowResult(&result))
+	require.Equal(t, "compensation_failed", result.Status)
+	require.Equal(t, "compensation_failed", result.DecisionPath)
+	require.Contains(t, result.Message, "RefundPayment")
+	env.AssertExpectations(t)
 }
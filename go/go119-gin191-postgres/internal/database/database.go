@@ -59,5 +59,6 @@ func Connect() (*gorm.DB, error) {
 func AutoMigrate(db *gorm.DB) error {
 	return db.AutoMigrate(
 		&models.User{},
+		&models.Article{},
 	)
 }
@@ -1,7 +1,12 @@
 package handlers
 
 import (
+	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/base14/examples/go119-gin191-postgres/internal/logging"
 	"github.com/base14/examples/go119-gin191-postgres/internal/models"
@@ -24,14 +29,42 @@ func NewUserHandler(db *gorm.DB) *UserHandler {
 	return &UserHandler{db: db}
 }
 
-// ListUsers returns all users
+// ListUsers returns a page of users, oldest first. The page is chosen with
+// the page and per_page query parameters, and a Link header points to the
+// next and previous pages.
 func (h *UserHandler) ListUsers(c *gin.Context) {
 	ctx, span := tracer.Start(c.Request.Context(), "ListUsers",
 		trace.WithSpanKind(trace.SpanKindServer))
 	defer span.End()
 
+	page, _ := strconv.Atoi(c.Query("page"))
+	if page < 1 {
+		page = 1
+	}
+	perPage, _ := strconv.Atoi(c.Query("per_page"))
+	if perPage < 1 || perPage > 100 {
+		perPage = 20
+	}
+
+	span.SetAttributes(
+		attribute.Int("pagination.page", page),
+		attribute.Int("pagination.per_page", perPage),
+	)
+
+	var total int64
+	if err := h.db.WithContext(ctx).Model(&models.User{}).Count(&total).Error; err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to count users")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
 	var users []models.User
-	result := h.db.WithContext(ctx).Find(&users)
+	result := h.db.WithContext(ctx).
+		Order("created_at, id").
+		Offset((page - 1) * perPage).
+		Limit(perPage).
+		Find(&users)
 	if result.Error != nil {
 		span.RecordError(result.Error)
 		span.SetStatus(codes.Error, "failed to fetch users")
@@ -39,13 +72,42 @@ func (h *UserHandler) ListUsers(c *gin.Context) {
 		return
 	}
 
-	span.SetAttributes(attribute.Int("user.count", len(users)))
+	span.SetAttributes(
+		attribute.Int("user.count", len(users)),
+		attribute.Int64("user.total", total),
+	)
+	if link := paginationLinks(c.Request.URL, page, perPage, total); link != "" {
+		c.Header("Link", link)
+	}
 	c.JSON(http.StatusOK, models.UsersResponse{
-		Users: users,
-		Count: len(users),
+		Users:   users,
+		Count:   len(users),
+		Total:   total,
+		Page:    page,
+		PerPage: perPage,
 	})
 }
 
+// paginationLinks returns an RFC 5988 Link header value with the next and
+// previous pages of a listing at u, or "" when there is only one page.
+func paginationLinks(u *url.URL, page, perPage int, total int64) string {
+	pageURL := func(p int) string {
+		q := u.Query()
+		q.Set("page", strconv.Itoa(p))
+		q.Set("per_page", strconv.Itoa(perPage))
+		return (&url.URL{Path: u.Path, RawQuery: q.Encode()}).String()
+	}
+
+	var links []string
+	if int64(page)*int64(perPage) < total {
+		links = append(links, fmt.Sprintf(`<%s>; rel="next"`, pageURL(page+1)))
+	}
+	if page > 1 {
+		links = append(links, fmt.Sprintf(`<%s>; rel="prev"`, pageURL(page-1)))
+	}
+	return strings.Join(links, ", ")
+}
+
 // GetUser returns a single user by ID
 func (h *UserHandler) GetUser(c *gin.Context) {
 	ctx, span := tracer.Start(c.Request.Context(), "GetUser",
@@ -79,6 +141,84 @@ func (h *UserHandler) GetUser(c *gin.Context) {
 	c.JSON(http.StatusOK, models.UserResponse{User: user})
 }
 
+// recentArticlesLimit is how many of a user's latest articles GetUserStats
+// lists.
+const recentArticlesLimit = 5
+
+// GetUserStats returns a user's article counts and recent activity. The
+// counts come from one aggregate query over users joined to articles, so the
+// whole summary is a single traced statement; the latest articles are a
+// second, index-backed query that is skipped when the user has none.
+func (h *UserHandler) GetUserStats(c *gin.Context) {
+	ctx, span := tracer.Start(c.Request.Context(), "GetUserStats",
+		trace.WithSpanKind(trace.SpanKindServer))
+	defer span.End()
+
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "invalid user ID")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+		return
+	}
+
+	span.SetAttributes(attribute.String("user.id", userID.String()))
+
+	now := time.Now()
+	var stats models.UserStats
+	result := h.db.WithContext(ctx).
+		Model(&models.User{}).
+		Select(`users.id AS user_id,
+			users.created_at AS member_since,
+			COUNT(articles.id) AS article_count,
+			COUNT(articles.id) FILTER (WHERE articles.created_at >= ?) AS articles_last_7_days,
+			COUNT(articles.id) FILTER (WHERE articles.created_at >= ?) AS articles_last_30_days,
+			MAX(articles.created_at) AS last_article_at,
+			GREATEST(users.updated_at, MAX(articles.updated_at)) AS last_active_at`,
+			now.AddDate(0, 0, -7), now.AddDate(0, 0, -30)).
+		Joins("LEFT JOIN articles ON articles.author_id = users.id").
+		Where("users.id = ?", userID).
+		Group("users.id").
+		Take(&stats)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			span.SetStatus(codes.Error, "user not found")
+			c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+			return
+		}
+		span.RecordError(result.Error)
+		span.SetStatus(codes.Error, "failed to aggregate user stats")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return
+	}
+
+	recent := make([]models.ArticleSummary, 0, recentArticlesLimit)
+	if stats.ArticleCount > 0 {
+		result = h.db.WithContext(ctx).
+			Model(&models.Article{}).
+			Select("id, title, created_at").
+			Where("author_id = ?", userID).
+			Order("created_at DESC, id").
+			Limit(recentArticlesLimit).
+			Find(&recent)
+		if result.Error != nil {
+			span.RecordError(result.Error)
+			span.SetStatus(codes.Error, "failed to fetch recent articles")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+			return
+		}
+	}
+
+	span.SetAttributes(
+		attribute.Int64("user.article_count", stats.ArticleCount),
+		attribute.Int64("user.articles_last_30_days", stats.ArticlesLast30Days),
+	)
+	c.JSON(http.StatusOK, models.UserStatsResponse{
+		Stats:          stats,
+		RecentArticles: recent,
+	})
+}
+
 // CreateUser creates a new user
 func (h *UserHandler) CreateUser(c *gin.Context) {
 	ctx, span := tracer.Start(c.Request.Context(), "CreateUser",
@@ -0,0 +1,12 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/base14/examples/go119-gin191-postgres/internal/buildinfo"
+	"github.com/gin-gonic/gin"
+)
+
+func Version(c *gin.Context) {
+	c.JSON(http.StatusOK, buildinfo.Get())
+}
@@ -60,7 +60,8 @@ func WithContext(ctx context.Context) *logrus.Entry {
 		fields["trace_flags"] = spanCtx.TraceFlags().String()
 	}
 
-	return log.WithFields(fields)
+	// The context lets the OTLP hook set trace and span IDs on the record.
+	return log.WithContext(ctx).WithFields(fields)
 }
 
 // Info logs an info message with trace correlation
@@ -0,0 +1,239 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/resource"
+	"go.opentelemetry.io/otel/trace"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	"google.golang.org/grpc"
+)
+
+// The OTel Go logs SDK needs Go 1.21, so on this stack logrus entries are
+// converted to OTLP log records here and sent with the generated collector
+// client from go.opentelemetry.io/proto/otlp.
+
+const (
+	scopeName         = "github.com/base14/examples/go119-gin191-postgres/internal/logging"
+	exportBatchSize   = 512
+	exportInterval    = time.Second
+	exportQueueSize   = 2048
+	exportCallTimeout = 10 * time.Second
+)
+
+// Fields already carried by the record's trace context or resource.
+var skipFields = map[string]bool{
+	"trace_id":     true,
+	"span_id":      true,
+	"trace_flags":  true,
+	"service.name": true,
+}
+
+// OTLPHook is a logrus hook that batches entries and exports them to an
+// OTLP gRPC endpoint. Entries are dropped, not blocked on, when the queue
+// is full so a slow collector never stalls request handling.
+type OTLPHook struct {
+	client   collogspb.LogsServiceClient
+	resource *resourcepb.Resource
+
+	queue chan *logspb.LogRecord
+	flush chan chan struct{}
+	done  chan struct{}
+
+	closeOnce sync.Once
+}
+
+// NewOTLPHook starts the export loop on conn. Call Shutdown to flush
+// pending records before the connection is closed.
+func NewOTLPHook(conn *grpc.ClientConn, res *resource.Resource) *OTLPHook {
+	h := &OTLPHook{
+		client:   collogspb.NewLogsServiceClient(conn),
+		resource: &resourcepb.Resource{Attributes: keyValues(res.Attributes())},
+		queue:    make(chan *logspb.LogRecord, exportQueueSize),
+		flush:    make(chan chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go h.run()
+	return h
+}
+
+// AddHook registers hook on the package logger.
+func AddHook(hook logrus.Hook) {
+	log.AddHook(hook)
+}
+
+func (h *OTLPHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h *OTLPHook) Fire(entry *logrus.Entry) error {
+	select {
+	case h.queue <- toLogRecord(entry):
+	default:
+	}
+	return nil
+}
+
+// Shutdown exports the records queued so far and stops the export loop.
+func (h *OTLPHook) Shutdown(ctx context.Context) error {
+	flushed := make(chan struct{})
+	h.closeOnce.Do(func() {
+		select {
+		case h.flush <- flushed:
+		case <-ctx.Done():
+			return
+		}
+	})
+
+	select {
+	case <-h.done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("log exporter shutdown: %w", ctx.Err())
+	}
+}
+
+func (h *OTLPHook) run() {
+	defer close(h.done)
+
+	ticker := time.NewTicker(exportInterval)
+	defer ticker.Stop()
+
+	batch := make([]*logspb.LogRecord, 0, exportBatchSize)
+	export := func() {
+		if len(batch) == 0 {
+			return
+		}
+		h.export(batch)
+		batch = make([]*logspb.LogRecord, 0, exportBatchSize)
+	}
+
+	for {
+		select {
+		case record := <-h.queue:
+			batch = append(batch, record)
+			if len(batch) >= exportBatchSize {
+				export()
+			}
+		case <-ticker.C:
+			export()
+		case flushed := <-h.flush:
+		drain:
+			for {
+				select {
+				case record := <-h.queue:
+					batch = append(batch, record)
+				default:
+					break drain
+				}
+			}
+			export()
+			close(flushed)
+			return
+		}
+	}
+}
+
+func (h *OTLPHook) export(records []*logspb.LogRecord) {
+	ctx, cancel := context.WithTimeout(context.Background(), exportCallTimeout)
+	defer cancel()
+
+	_, err := h.client.Export(ctx, &collogspb.ExportLogsServiceRequest{
+		ResourceLogs: []*logspb.ResourceLogs{{
+			Resource: h.resource,
+			ScopeLogs: []*logspb.ScopeLogs{{
+				Scope:      &commonpb.InstrumentationScope{Name: scopeName},
+				LogRecords: records,
+			}},
+		}},
+	})
+	if err != nil {
+		// Not logged through logrus: the hook would queue the failure
+		// itself and feed the outage back into the exporter.
+		fmt.Printf("otlp log export failed (%d records dropped): %v\n", len(records), err)
+	}
+}
+
+func toLogRecord(entry *logrus.Entry) *logspb.LogRecord {
+	record := &logspb.LogRecord{
+		TimeUnixNano:         uint64(entry.Time.UnixNano()),
+		ObservedTimeUnixNano: uint64(time.Now().UnixNano()),
+		SeverityNumber:       severityNumber(entry.Level),
+		SeverityText:         entry.Level.String(),
+		Body:                 &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: entry.Message}},
+	}
+
+	if entry.Context != nil {
+		if sc := trace.SpanContextFromContext(entry.Context); sc.IsValid() {
+			traceID := sc.TraceID()
+			spanID := sc.SpanID()
+			record.TraceId = traceID[:]
+			record.SpanId = spanID[:]
+			record.Flags = uint32(sc.TraceFlags())
+		}
+	}
+
+	for key, value := range entry.Data {
+		if skipFields[key] {
+			continue
+		}
+		record.Attributes = append(record.Attributes, &commonpb.KeyValue{Key: key, Value: anyValue(value)})
+	}
+	return record
+}
+
+func severityNumber(level logrus.Level) logspb.SeverityNumber {
+	switch level {
+	case logrus.TraceLevel:
+		return logspb.SeverityNumber_SEVERITY_NUMBER_TRACE
+	case logrus.DebugLevel:
+		return logspb.SeverityNumber_SEVERITY_NUMBER_DEBUG
+	case logrus.InfoLevel:
+		return logspb.SeverityNumber_SEVERITY_NUMBER_INFO
+	case logrus.WarnLevel:
+		return logspb.SeverityNumber_SEVERITY_NUMBER_WARN
+	case logrus.ErrorLevel:
+		return logspb.SeverityNumber_SEVERITY_NUMBER_ERROR
+	default:
+		return logspb.SeverityNumber_SEVERITY_NUMBER_FATAL
+	}
+}
+
+func anyValue(value interface{}) *commonpb.AnyValue {
+	switch v := value.(type) {
+	case string:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: v}}
+	case bool:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_BoolValue{BoolValue: v}}
+	case int:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: int64(v)}}
+	case int32:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: int64(v)}}
+	case int64:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: v}}
+	case float32:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_DoubleValue{DoubleValue: float64(v)}}
+	case float64:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_DoubleValue{DoubleValue: v}}
+	case error:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: v.Error()}}
+	default:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: fmt.Sprint(v)}}
+	}
+}
+
+func keyValues(attrs []attribute.KeyValue) []*commonpb.KeyValue {
+	out := make([]*commonpb.KeyValue, 0, len(attrs))
+	for _, kv := range attrs {
+		out = append(out, &commonpb.KeyValue{Key: string(kv.Key), Value: anyValue(kv.Value.AsInterface())})
+	}
+	return out
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Article is a post written by a user. Articles are deleted with their
+// author.
+type Article struct {
+	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	AuthorID  uuid.UUID `gorm:"type:uuid;not null;index:idx_articles_author_created,priority:1" json:"author_id"`
+	Author    *User     `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+	Title     string    `gorm:"type:varchar(255);not null" json:"title"`
+	Body      string    `gorm:"type:text" json:"body,omitempty"`
+	CreatedAt time.Time `gorm:"index:idx_articles_author_created,priority:2" json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// BeforeCreate will set a UUID rather than numeric ID.
+func (a *Article) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	return nil
+}
+
+// ArticleSummary is an article listed without its body.
+type ArticleSummary struct {
+	ID        uuid.UUID `json:"id"`
+	Title     string    `json:"title"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -30,10 +30,33 @@ type UserResponse struct {
 	User User `json:"user"`
 }
 
-// UsersResponse is the JSON response structure for multiple users
+// UsersResponse is the JSON response structure for one page of users.
+// Count is the number of users on the page, Total the number across all pages.
 type UsersResponse struct {
-	Users []User `json:"users"`
-	Count int    `json:"count"`
+	Users   []User `json:"users"`
+	Count   int    `json:"count"`
+	Total   int64  `json:"total"`
+	Page    int    `json:"page"`
+	PerPage int    `json:"per_page"`
+}
+
+// UserStats is a user's article counts and latest activity.
+type UserStats struct {
+	UserID             uuid.UUID  `json:"user_id"`
+	ArticleCount       int64      `json:"article_count"`
+	ArticlesLast7Days  int64      `gorm:"column:articles_last_7_days" json:"articles_last_7_days"`
+	ArticlesLast30Days int64      `gorm:"column:articles_last_30_days" json:"articles_last_30_days"`
+	LastArticleAt      *time.Time `json:"last_article_at"`
+	MemberSince        time.Time  `json:"member_since"`
+	// LastActiveAt is the latest of the user's profile update and their
+	// articles' creation or update.
+	LastActiveAt time.Time `json:"last_active_at"`
+}
+
+// UserStatsResponse is the JSON response structure for a user's statistics
+type UserStatsResponse struct {
+	Stats          UserStats        `json:"stats"`
+	RecentArticles []ArticleSummary `json:"recent_articles"`
 }
 
 // CreateUserRequest is the request payload for creating a user
@@ -7,6 +7,8 @@ import (
 	"os"
 	"time"
 
+	"github.com/base14/examples/go119-gin191-postgres/internal/buildinfo"
+	"github.com/base14/examples/go119-gin191-postgres/internal/logging"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
@@ -23,6 +25,7 @@ import (
 type TelemetryProvider struct {
 	TracerProvider *sdktrace.TracerProvider
 	MeterProvider  *metric.MeterProvider
+	LogHook        *logging.OTLPHook
 }
 
 func InitTelemetry(ctx context.Context) (*TelemetryProvider, error) {
@@ -45,7 +48,8 @@ func InitTelemetry(ctx context.Context) (*TelemetryProvider, error) {
 	res, err := resource.New(ctx,
 		resource.WithAttributes(
 			semconv.ServiceName(serviceName),
-			semconv.ServiceVersion("1.0.0"),
+			semconv.ServiceVersion(buildinfo.Version),
+			semconv.ServiceInstanceID(buildinfo.InstanceID),
 			attribute.String("deployment.environment", getEnvironment(environment)),
 			attribute.String("environment", getEnvironment(environment)),
 		),
@@ -66,6 +70,12 @@ func InitTelemetry(ctx context.Context) (*TelemetryProvider, error) {
 		return nil, fmt.Errorf("failed to setup meter provider: %w", err)
 	}
 
+	// Setup log export
+	logHook, err := setupLogHook(ctx, endpoint, res)
+	if err != nil {
+		return nil, fmt.Errorf("failed to setup log exporter: %w", err)
+	}
+
 	// Set global propagator
 	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
 		propagation.TraceContext{},
@@ -77,6 +87,7 @@ func InitTelemetry(ctx context.Context) (*TelemetryProvider, error) {
 	return &TelemetryProvider{
 		TracerProvider: traceProvider,
 		MeterProvider:  meterProvider,
+		LogHook:        logHook,
 	}, nil
 }
 
@@ -134,6 +145,25 @@ func setupMeterProvider(ctx context.Context, endpoint string, res *resource.Reso
 	return meterProvider, nil
 }
 
+// setupLogHook bridges the logrus logger to OTLP. The logs SDK needs a newer
+// Go than this stack supports, so the hook speaks the OTLP protocol itself.
+func setupLogHook(ctx context.Context, endpoint string, res *resource.Resource) (*logging.OTLPHook, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	conn, err := grpc.DialContext(ctx, endpoint,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithBlock(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create gRPC connection to collector: %w", err)
+	}
+
+	hook := logging.NewOTLPHook(conn, res)
+	logging.AddHook(hook)
+	return hook, nil
+}
+
 func (tp *TelemetryProvider) Shutdown(ctx context.Context) error {
 	if err := tp.TracerProvider.Shutdown(ctx); err != nil {
 		return fmt.Errorf("error shutting down tracer provider: %w", err)
@@ -143,6 +173,10 @@ func (tp *TelemetryProvider) Shutdown(ctx context.Context) error {
 		return fmt.Errorf("error shutting down meter provider: %w", err)
 	}
 
+	if err := tp.LogHook.Shutdown(ctx); err != nil {
+		return fmt.Errorf("error shutting down log exporter: %w", err)
+	}
+
 	return nil
 }
 
@@ -62,6 +62,20 @@ else
     echo -e "${RED}✗${NC} Failed to list users"
     exit 1
 fi
+if echo "$USERS" | grep -q '"total":' && echo "$USERS" | grep -q '"per_page":20'; then
+    echo -e "${GREEN}✓${NC} Users list includes pagination fields"
+else
+    echo -e "${RED}✗${NC} Users list is missing pagination fields"
+    exit 1
+fi
+LINK=$(curl -s -D - -o /dev/null "$BASE_URL/api/users?page=1&per_page=1" | grep -i '^Link:' || true)
+echo "Link: $LINK"
+if echo "$LINK" | grep -q 'page=2' && echo "$LINK" | grep -q 'rel="next"'; then
+    echo -e "${GREEN}✓${NC} Link header points to the next page"
+else
+    echo -e "${RED}✗${NC} Link header missing next page"
+    exit 1
+fi
 echo ""
 
 echo "5. Getting User 1 by ID..."
@@ -75,7 +89,18 @@ else
 fi
 echo ""
 
-echo "6. Updating User 1..."
+echo "6. Getting User 1 stats..."
+STATS=$(curl -s "$BASE_URL/api/users/$USER1_ID/stats")
+echo "Response: $STATS"
+if echo "$STATS" | grep -q '"article_count":0' && echo "$STATS" | grep -q '"recent_articles":\[\]'; then
+    echo -e "${GREEN}✓${NC} User stats retrieved"
+else
+    echo -e "${RED}✗${NC} Failed to get user stats"
+    exit 1
+fi
+echo ""
+
+echo "7. Updating User 1..."
 USER_UPDATE=$(curl -s -X PUT "$BASE_URL/api/users/$USER1_ID" \
     -H "Content-Type: application/json" \
     -d '{"name": "Alice Cooper", "bio": "Senior Software Engineer"}')
@@ -88,7 +113,7 @@ else
 fi
 echo ""
 
-echo "7. Deleting User 2..."
+echo "8. Deleting User 2..."
 DELETE_RESPONSE=$(curl -s -X DELETE "$BASE_URL/api/users/$USER2_ID")
 echo "Response: $DELETE_RESPONSE"
 if echo "$DELETE_RESPONSE" | grep -q "deleted successfully"; then
@@ -99,7 +124,7 @@ else
 fi
 echo ""
 
-echo "8. Verifying User 2 is deleted..."
+echo "9. Verifying User 2 is deleted..."
 GET_DELETED=$(curl -s "$BASE_URL/api/users/$USER2_ID")
 if echo "$GET_DELETED" | grep -q "not found"; then
     echo -e "${GREEN}✓${NC} User deletion verified"
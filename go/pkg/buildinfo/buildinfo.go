@@ -0,0 +1,59 @@
+// Package buildinfo holds release metadata stamped in at build time:
+//
+//	go build -ldflags "-X github.com/base-14/examples/go/pkg/buildinfo.Version=v1.2.0 \
+//	    -X github.com/base-14/examples/go/pkg/buildinfo.Commit=$(git rev-parse --short HEAD) \
+//	    -X github.com/base-14/examples/go/pkg/buildinfo.Date=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+//
+// Plain `go build` inside a git checkout falls back to the VCS stamp the Go
+// toolchain embeds.
+package buildinfo
+
+import (
+	"runtime"
+	"runtime/debug"
+
+	"github.com/google/uuid"
+)
+
+var (
+	Version = "dev"
+	Commit  = "unknown"
+	Date    = "unknown"
+)
+
+// InstanceID is a random UUID generated at startup, used as
+// service.instance.id so replicas and restarts can be told apart.
+var InstanceID = uuid.NewString()
+
+type Info struct {
+	Version    string `json:"version"`
+	Commit     string `json:"commit"`
+	BuildDate  string `json:"build_date"`
+	GoVersion  string `json:"go_version"`
+	InstanceID string `json:"instance_id"`
+}
+
+func Get() Info {
+	return Info{
+		Version:    Version,
+		Commit:     Commit,
+		BuildDate:  Date,
+		GoVersion:  runtime.Version(),
+		InstanceID: InstanceID,
+	}
+}
+
+func init() {
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return
+	}
+	for _, s := range bi.Settings {
+		switch {
+		case s.Key == "vcs.revision" && Commit == "unknown":
+			Commit = s.Value
+		case s.Key == "vcs.time" && Date == "unknown":
+			Date = s.Value
+		}
+	}
+}
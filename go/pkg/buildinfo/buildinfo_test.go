@@ -0,0 +1,35 @@
+package buildinfo
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestInstanceIDIsUUIDv4(t *testing.T) {
+	id, err := uuid.Parse(InstanceID)
+	if err != nil {
+		t.Fatalf("instance ID %q is not a UUID: %v", InstanceID, err)
+	}
+	if id.Version() != 4 {
+		t.Fatalf("instance ID %q is version %d, want 4", InstanceID, id.Version())
+	}
+}
+
+func TestGetReportsStampedValues(t *testing.T) {
+	defer func(v, c, d string) { Version, Commit, Date = v, c, d }(Version, Commit, Date)
+	Version, Commit, Date = "v1.2.0", "abc1234", "2026-03-04T05:06:07Z"
+
+	info := Get()
+	want := Info{
+		Version:    "v1.2.0",
+		Commit:     "abc1234",
+		BuildDate:  "2026-03-04T05:06:07Z",
+		GoVersion:  runtime.Version(),
+		InstanceID: InstanceID,
+	}
+	if info != want {
+		t.Fatalf("Get() = %+v, want %+v", info, want)
+	}
+}
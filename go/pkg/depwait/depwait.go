@@ -0,0 +1,182 @@
+// Package depwait holds service startup until its dependencies answer,
+// retrying each one with exponential backoff up to an overall deadline. It
+// covers the compose race where an app container starts before Postgres or
+// Redis accepts connections.
+package depwait
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// Dependency is something the service needs before it can serve traffic.
+// Check should make one bounded attempt and return nil once it is usable.
+type Dependency struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+// Event describes one check attempt. Err is nil once the dependency is
+// ready; otherwise RetryIn is the wait before the next attempt.
+type Event struct {
+	Dependency string
+	Attempt    int
+	Elapsed    time.Duration
+	RetryIn    time.Duration
+	Err        error
+}
+
+// Config bounds the wait. Zero durations use the defaults.
+type Config struct {
+	// Timeout is the deadline for all dependencies together.
+	Timeout        time.Duration
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// AttemptTimeout bounds a single Check call.
+	AttemptTimeout time.Duration
+	// OnAttempt, if set, is called after every attempt so the caller can log
+	// progress with its own logger.
+	OnAttempt func(Event)
+	// Meter records app.startup.duration. Defaults to the global provider.
+	Meter metric.Meter
+}
+
+const (
+	defaultTimeout        = time.Minute
+	defaultInitialBackoff = 500 * time.Millisecond
+	defaultMaxBackoff     = 5 * time.Second
+	defaultAttemptTimeout = 3 * time.Second
+)
+
+// Wait checks deps in order, retrying each until it is ready, and returns an
+// error naming the first dependency still unavailable when the deadline
+// passes or ctx is cancelled. The total time spent is recorded as
+// app.startup.duration with outcome "ready" or "timeout".
+func Wait(ctx context.Context, cfg Config, deps ...Dependency) error {
+	cfg = cfg.withDefaults()
+	start := time.Now()
+
+	ctx, cancel := context.WithTimeout(ctx, cfg.Timeout)
+	defer cancel()
+
+	var err error
+	for _, dep := range deps {
+		if err = waitFor(ctx, cfg, dep); err != nil {
+			break
+		}
+	}
+
+	outcome := "ready"
+	if err != nil {
+		outcome = "timeout"
+	}
+	if hist, herr := cfg.Meter.Float64Histogram("app.startup.duration",
+		metric.WithUnit("s"),
+		metric.WithDescription("Time spent waiting for dependencies before serving traffic"),
+	); herr == nil {
+		hist.Record(context.Background(), time.Since(start).Seconds(),
+			metric.WithAttributes(attribute.String("outcome", outcome)))
+	}
+	return err
+}
+
+func waitFor(ctx context.Context, cfg Config, dep Dependency) error {
+	start := time.Now()
+	backoff := cfg.InitialBackoff
+	for attempt := 1; ; attempt++ {
+		attemptCtx, cancel := context.WithTimeout(ctx, cfg.AttemptTimeout)
+		err := dep.Check(attemptCtx)
+		cancel()
+
+		ev := Event{Dependency: dep.Name, Attempt: attempt, Elapsed: time.Since(start), Err: err}
+		if err == nil {
+			cfg.notify(ev)
+			return nil
+		}
+		ev.RetryIn = backoff
+		cfg.notify(ev)
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%s not ready after %s: %w", dep.Name, time.Since(start).Round(time.Millisecond), err)
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, cfg.MaxBackoff)
+	}
+}
+
+func (c Config) withDefaults() Config {
+	if c.Timeout <= 0 {
+		c.Timeout = defaultTimeout
+	}
+	if c.InitialBackoff <= 0 {
+		c.InitialBackoff = defaultInitialBackoff
+	}
+	if c.MaxBackoff <= 0 {
+		c.MaxBackoff = defaultMaxBackoff
+	}
+	if c.AttemptTimeout <= 0 {
+		c.AttemptTimeout = defaultAttemptTimeout
+	}
+	if c.Meter == nil {
+		c.Meter = otel.Meter("github.com/base-14/examples/go/pkg/depwait")
+	}
+	return c
+}
+
+func (c Config) notify(ev Event) {
+	if c.OnAttempt != nil {
+		c.OnAttempt(ev)
+	}
+}
+
+// SQL checks a database/sql driver, which the caller must have registered,
+// by opening a short-lived handle and pinging it.
+func SQL(driverName, dsn string) func(context.Context) error {
+	return func(ctx context.Context) error {
+		db, err := sql.Open(driverName, dsn)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+		return db.PingContext(ctx)
+	}
+}
+
+// Redis checks addr with a PING and expects +PONG. A Redis still loading its
+// dataset accepts connections but answers -LOADING, which counts as not ready.
+func Redis(addr string) func(context.Context) error {
+	return func(ctx context.Context) error {
+		var d net.Dialer
+		conn, err := d.DialContext(ctx, "tcp", addr)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		if deadline, ok := ctx.Deadline(); ok {
+			_ = conn.SetDeadline(deadline)
+		}
+
+		if _, err := conn.Write([]byte("PING\r\n")); err != nil {
+			return err
+		}
+		reply, err := bufio.NewReader(conn).ReadString('\n')
+		if err != nil {
+			return err
+		}
+		if reply = strings.TrimSpace(reply); reply != "+PONG" {
+			return errors.New("unexpected reply: " + reply)
+		}
+		return nil
+	}
+}
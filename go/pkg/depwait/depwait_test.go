@@ -0,0 +1,112 @@
+package depwait
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/base-14/examples/go/pkg/oteltest"
+)
+
+func fastConfig(tel *oteltest.Telemetry, events *[]Event) Config {
+	return Config{
+		Timeout:        time.Second,
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     4 * time.Millisecond,
+		OnAttempt:      func(ev Event) { *events = append(*events, ev) },
+		Meter:          tel.Meter("test"),
+	}
+}
+
+func TestWaitRetriesUntilReady(t *testing.T) {
+	tel := oteltest.New(t)
+	var events []Event
+
+	calls := 0
+	err := Wait(context.Background(), fastConfig(tel, &events), Dependency{
+		Name: "postgres",
+		Check: func(context.Context) error {
+			calls++
+			if calls < 3 {
+				return errors.New("connection refused")
+			}
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("Wait: %v", err)
+	}
+
+	if len(events) != 3 {
+		t.Fatalf("got %d events, want 3", len(events))
+	}
+	if events[0].Err == nil || events[0].RetryIn != time.Millisecond || events[1].RetryIn != 2*time.Millisecond {
+		t.Errorf("unexpected retry events: %+v", events[:2])
+	}
+	if last := events[2]; last.Err != nil || last.Attempt != 3 {
+		t.Errorf("last event = %+v, want ready on attempt 3", last)
+	}
+
+	if hist := oteltest.Histogram[float64](t, tel, "app.startup.duration", attribute.String("outcome", "ready")); hist.Count != 1 {
+		t.Errorf("app.startup.duration count = %d, want 1", hist.Count)
+	}
+}
+
+func TestWaitGivesUpAtDeadline(t *testing.T) {
+	tel := oteltest.New(t)
+	var events []Event
+	cfg := fastConfig(tel, &events)
+	cfg.Timeout = 20 * time.Millisecond
+
+	ready := false
+	err := Wait(context.Background(), cfg,
+		Dependency{Name: "redis", Check: func(context.Context) error { return errors.New("connection refused") }},
+		Dependency{Name: "postgres", Check: func(context.Context) error { ready = true; return nil }},
+	)
+	if err == nil || !strings.HasPrefix(err.Error(), "redis not ready") {
+		t.Fatalf("Wait error = %v, want redis not ready", err)
+	}
+	if ready {
+		t.Error("later dependency checked after an earlier one timed out")
+	}
+
+	oteltest.Histogram[float64](t, tel, "app.startup.duration", attribute.String("outcome", "timeout"))
+}
+
+func TestRedisCheck(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	replies := []string{"-LOADING Redis is loading the dataset in memory\r\n", "+PONG\r\n"}
+	go func() {
+		for _, reply := range replies {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			buf := make([]byte, 16)
+			_, _ = conn.Read(buf)
+			_, _ = conn.Write([]byte(reply))
+			conn.Close()
+		}
+	}()
+
+	check := Redis(ln.Addr().String())
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if err := check(ctx); err == nil {
+		t.Error("expected -LOADING to count as not ready")
+	}
+	if err := check(ctx); err != nil {
+		t.Errorf("expected +PONG to be ready, got %v", err)
+	}
+}
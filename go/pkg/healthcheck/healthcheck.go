@@ -0,0 +1,92 @@
+// Package healthcheck lets a service binary act as its own container health
+// probe. Started with --healthcheck, the binary runs the same check the app
+// relies on for readiness, such as a GET of its health endpoint or a ping of
+// its dependencies, and exits 0 or 1. Images then need no curl or wget for
+// their HEALTHCHECK.
+package healthcheck
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/base-14/examples/go/pkg/depwait"
+)
+
+// Flag is the argument that turns a service binary into a health probe.
+const Flag = "--healthcheck"
+
+// DefaultTimeout bounds a whole probe run, below the usual 5s HEALTHCHECK
+// timeout so a hung check is reported rather than killed.
+const DefaultTimeout = 3 * time.Second
+
+// Requested reports whether args, normally os.Args[1:], ask for a probe.
+// Both -healthcheck and --healthcheck are accepted, like the flag package.
+func Requested(args []string) bool {
+	for _, arg := range args {
+		if arg == Flag || arg == Flag[1:] {
+			return true
+		}
+	}
+	return false
+}
+
+// Run checks deps once each, in order, within timeout, and returns an error
+// naming the first one that fails.
+func Run(ctx context.Context, timeout time.Duration, deps ...depwait.Dependency) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	for _, dep := range deps {
+		if err := dep.Check(ctx); err != nil {
+			return fmt.Errorf("%s: %w", dep.Name, err)
+		}
+	}
+	return nil
+}
+
+// Main returns straight away unless the process was started with
+// --healthcheck. If it was, Main runs deps with DefaultTimeout and exits: 0
+// when all pass, 1 after printing the failure to stderr. Call it at the top
+// of main, once the config is loaded and before telemetry or connections are
+// set up, so a probe does not export spans or hold database connections.
+func Main(deps ...depwait.Dependency) {
+	if !Requested(os.Args[1:]) {
+		return
+	}
+	if err := Run(context.Background(), DefaultTimeout, deps...); err != nil {
+		fmt.Fprintln(os.Stderr, "unhealthy:", err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+// HTTP checks that a GET of url answers with a 2xx status.
+func HTTP(url string) func(context.Context) error {
+	return func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		_, _ = io.Copy(io.Discard, resp.Body)
+
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return fmt.Errorf("GET %s: %s", url, resp.Status)
+		}
+		return nil
+	}
+}
+
+// Local returns the URL of path on a server listening on port of this host,
+// the address a probe running inside the container should use.
+func Local(port, path string) string {
+	return "http://127.0.0.1:" + port + path
+}
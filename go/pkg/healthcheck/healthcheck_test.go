@@ -0,0 +1,77 @@
+package healthcheck
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/base-14/examples/go/pkg/depwait"
+)
+
+func TestRequested(t *testing.T) {
+	cases := []struct {
+		args []string
+		want bool
+	}{
+		{nil, false},
+		{[]string{"--mode=server"}, false},
+		{[]string{"--healthcheck"}, true},
+		{[]string{"-healthcheck"}, true},
+		{[]string{"--port=8080", "--healthcheck"}, true},
+	}
+	for _, c := range cases {
+		if got := Requested(c.args); got != c.want {
+			t.Errorf("Requested(%q) = %v, want %v", c.args, got, c.want)
+		}
+	}
+}
+
+func TestHTTPCheck(t *testing.T) {
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	check := HTTP(srv.URL + "/api/health")
+	if err := check(context.Background()); err != nil {
+		t.Errorf("expected 200 to be healthy, got %v", err)
+	}
+
+	status = http.StatusServiceUnavailable
+	if err := check(context.Background()); err == nil || !strings.Contains(err.Error(), "503") {
+		t.Errorf("expected 503 to be unhealthy, got %v", err)
+	}
+}
+
+func TestRunStopsAtFirstFailure(t *testing.T) {
+	checked := false
+	err := Run(context.Background(), time.Second,
+		depwait.Dependency{Name: "postgres", Check: func(context.Context) error { return nil }},
+		depwait.Dependency{Name: "redis", Check: func(context.Context) error { return errors.New("connection refused") }},
+		depwait.Dependency{Name: "temporal", Check: func(context.Context) error { checked = true; return nil }},
+	)
+	if err == nil || err.Error() != "redis: connection refused" {
+		t.Fatalf("Run error = %v, want redis: connection refused", err)
+	}
+	if checked {
+		t.Error("later dependency checked after an earlier one failed")
+	}
+}
+
+func TestRunTimeout(t *testing.T) {
+	err := Run(context.Background(), 10*time.Millisecond, depwait.Dependency{
+		Name: "http",
+		Check: func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Run error = %v, want deadline exceeded", err)
+	}
+}
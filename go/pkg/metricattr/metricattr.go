@@ -0,0 +1,187 @@
+// Package metricattr keeps user and tenant identifiers out of metric
+// attributes. Spans and logs may carry raw IDs, since each one describes a
+// single request; a metric attribute creates a series per distinct value,
+// so an ID there multiplies storage and exposes the ID to everyone who can
+// read dashboards.
+//
+// A Policy turns an ID into a safe attribute: Hash for identifiers with a
+// bounded number of values, such as tenants, and Bucket for unbounded ones,
+// such as users. Guard is a metric view that warns at runtime when an
+// instrument receives an attribute that looks high-cardinality anyway.
+package metricattr
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"log/slog"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+)
+
+// DefaultBuckets is the bucket count of a Policy created with zero buckets.
+const DefaultBuckets = 16
+
+// Policy derives metric attributes from identifiers with a keyed hash, so
+// the same ID always maps to the same value but the value cannot be
+// reversed without the salt.
+type Policy struct {
+	salt    []byte
+	buckets int
+}
+
+// New returns a Policy keyed with salt. Use the same salt on every replica
+// so their series line up; buckets <= 0 uses DefaultBuckets.
+func New(salt string, buckets int) *Policy {
+	if buckets <= 0 {
+		buckets = DefaultBuckets
+	}
+	return &Policy{salt: []byte(salt), buckets: buckets}
+}
+
+func (p *Policy) sum(id string) []byte {
+	mac := hmac.New(sha256.New, p.salt)
+	mac.Write([]byte(id))
+	return mac.Sum(nil)
+}
+
+// Hash returns key set to a 12-hex-digit digest of id. The attribute still
+// has one value per ID, so use it only where the IDs are few.
+func (p *Policy) Hash(key, id string) attribute.KeyValue {
+	return attribute.String(key, hex.EncodeToString(p.sum(id)[:6]))
+}
+
+// Bucket returns key set to a bucket number in [0, buckets), spreading IDs
+// evenly so per-user load can be compared without a series per user.
+func (p *Policy) Bucket(key, id string) attribute.KeyValue {
+	n := binary.BigEndian.Uint64(p.sum(id)[:8])
+	return attribute.Int(key, int(n%uint64(p.buckets)))
+}
+
+// DefaultIdentifierKeys are attribute keys that hold raw identifiers and
+// should never reach a metric.
+var DefaultIdentifierKeys = []string{
+	"user.id", "user_id", "user.email", "user.name", "enduser.id",
+	"tenant.id", "tenant_id", "session.id", "email",
+}
+
+// DefaultMaxValues is the number of distinct values per instrument and key
+// above which Guard warns.
+const DefaultMaxValues = 100
+
+// Warning reports an attribute that Guard found on an instrument.
+type Warning struct {
+	Instrument string
+	Key        string
+	// Reason is "identifier" for a key in GuardConfig.IdentifierKeys and
+	// "cardinality" for one that passed GuardConfig.MaxValues.
+	Reason string
+	Values int
+}
+
+// GuardConfig tunes Guard. Zero values use the defaults.
+type GuardConfig struct {
+	IdentifierKeys []string
+	MaxValues      int
+	// OnWarning receives each warning once per instrument and key. It
+	// defaults to a slog warning.
+	OnWarning func(Warning)
+}
+
+// Guard returns a view, for sdkmetric.WithView, that watches the attributes
+// recorded on every instrument and warns once per instrument and key about
+// identifier keys and keys with more than MaxValues distinct values.
+// Measurements are recorded unchanged; Guard only reports.
+func Guard(cfg GuardConfig) sdkmetric.View {
+	if cfg.IdentifierKeys == nil {
+		cfg.IdentifierKeys = DefaultIdentifierKeys
+	}
+	if cfg.MaxValues <= 0 {
+		cfg.MaxValues = DefaultMaxValues
+	}
+	if cfg.OnWarning == nil {
+		cfg.OnWarning = func(w Warning) {
+			slog.Warn("high-cardinality metric attribute",
+				slog.String("instrument", w.Instrument),
+				slog.String("key", w.Key),
+				slog.String("reason", w.Reason),
+				slog.Int("values", w.Values),
+			)
+		}
+	}
+
+	identifiers := make(map[attribute.Key]bool, len(cfg.IdentifierKeys))
+	for _, k := range cfg.IdentifierKeys {
+		identifiers[attribute.Key(k)] = true
+	}
+	g := &guard{cfg: cfg, identifiers: identifiers, keys: map[guardKey]*keyState{}}
+
+	return func(i sdkmetric.Instrument) (sdkmetric.Stream, bool) {
+		return sdkmetric.Stream{
+			Name:            i.Name,
+			Description:     i.Description,
+			Unit:            i.Unit,
+			AttributeFilter: g.filter(i.Name),
+		}, true
+	}
+}
+
+type guardKey struct {
+	instrument string
+	key        attribute.Key
+}
+
+type keyState struct {
+	values map[attribute.Value]struct{}
+	warned bool
+}
+
+type guard struct {
+	cfg         GuardConfig
+	identifiers map[attribute.Key]bool
+
+	mu   sync.Mutex
+	keys map[guardKey]*keyState
+}
+
+func (g *guard) filter(instrument string) attribute.Filter {
+	return func(kv attribute.KeyValue) bool {
+		if w, ok := g.observe(instrument, kv); ok {
+			g.cfg.OnWarning(w)
+		}
+		return true
+	}
+}
+
+// observe records kv and returns the warning it triggers, if any.
+func (g *guard) observe(instrument string, kv attribute.KeyValue) (Warning, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	k := guardKey{instrument, kv.Key}
+	s, ok := g.keys[k]
+	if !ok {
+		s = &keyState{values: map[attribute.Value]struct{}{}}
+		g.keys[k] = s
+	}
+	if s.warned {
+		return Warning{}, false
+	}
+
+	s.values[kv.Value] = struct{}{}
+	w := Warning{Instrument: instrument, Key: string(kv.Key), Values: len(s.values)}
+	switch {
+	case g.identifiers[kv.Key]:
+		w.Reason = "identifier"
+	case len(s.values) > g.cfg.MaxValues:
+		w.Reason = "cardinality"
+	default:
+		return Warning{}, false
+	}
+	// Once warned, the values are no longer needed.
+	s.warned, s.values = true, nil
+	return w, true
+}
@@ -0,0 +1,90 @@
+package metricattr
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestHashIsStableAndSalted(t *testing.T) {
+	p := New("salt", 0)
+	a := p.Hash("tenant.hash", "acme")
+	if a != p.Hash("tenant.hash", "acme") {
+		t.Fatal("same ID hashed differently")
+	}
+	if len(a.Value.AsString()) != 12 {
+		t.Fatalf("hash %q is not 12 hex digits", a.Value.AsString())
+	}
+	if a.Value.AsString() == "acme" || a == New("other", 0).Hash("tenant.hash", "acme") {
+		t.Fatal("hash does not depend on the salt")
+	}
+}
+
+func TestBucketRange(t *testing.T) {
+	p := New("salt", 4)
+	seen := map[int64]bool{}
+	for i := range 200 {
+		kv := p.Bucket("user.bucket", fmt.Sprint(i))
+		n := kv.Value.AsInt64()
+		if n < 0 || n >= 4 {
+			t.Fatalf("bucket %d out of range", n)
+		}
+		seen[n] = true
+	}
+	if len(seen) != 4 {
+		t.Fatalf("200 IDs filled %d of 4 buckets", len(seen))
+	}
+	if p.Bucket("user.bucket", "42") != p.Bucket("user.bucket", "42") {
+		t.Fatal("same ID bucketed differently")
+	}
+}
+
+func TestGuardWarnsOncePerInstrumentAndKey(t *testing.T) {
+	var warnings []Warning
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(
+		sdkmetric.WithReader(reader),
+		sdkmetric.WithView(Guard(GuardConfig{MaxValues: 3, OnWarning: func(w Warning) {
+			warnings = append(warnings, w)
+		}})),
+	)
+	counter, err := mp.Meter("test").Int64Counter("articles.created")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	for i := range 5 {
+		counter.Add(ctx, 1, metric.WithAttributes(
+			attribute.String("user.id", "u1"),
+			attribute.Int("page", i),
+			attribute.String("status", "ok"),
+		))
+	}
+
+	want := []Warning{
+		{Instrument: "articles.created", Key: "user.id", Reason: "identifier", Values: 1},
+		{Instrument: "articles.created", Key: "page", Reason: "cardinality", Values: 4},
+	}
+	if fmt.Sprint(warnings) != fmt.Sprint(want) {
+		t.Fatalf("warnings = %+v, want %+v", warnings, want)
+	}
+
+	// Guard only reports: every series is still recorded.
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(ctx, &rm); err != nil {
+		t.Fatal(err)
+	}
+	sum := rm.ScopeMetrics[0].Metrics[0].Data.(metricdata.Sum[int64])
+	if len(sum.DataPoints) != 5 {
+		t.Fatalf("recorded %d series, want 5", len(sum.DataPoints))
+	}
+	if _, ok := sum.DataPoints[0].Attributes.Value("user.id"); !ok {
+		t.Fatal("user.id was dropped")
+	}
+}
@@ -0,0 +1,67 @@
+// Package milestone records business milestones, such as an article being
+// published, as span events, and hands the same name and attributes to the
+// caller's logger. A milestone can then be found in traces and in logs under
+// identical names, whichever backend is being queried.
+package milestone
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Milestone names shared by the articles apps.
+const (
+	ArticlePublished = "article.published"
+	FavoriteAdded    = "favorite.added"
+	UserRegistered   = "user.registered"
+)
+
+// Attribute keys shared by the articles apps. User IDs are fine here: span
+// events and logs are not metrics.
+const (
+	ArticleID             = attribute.Key("article.id")
+	ArticleSlug           = attribute.Key("article.slug")
+	ArticleFavoritesCount = attribute.Key("article.favorites_count")
+	UserID                = attribute.Key("user.id")
+)
+
+// EventName is the log attribute that carries the milestone name, as in the
+// OpenTelemetry event conventions.
+const EventName = "event.name"
+
+// Event is a recorded milestone.
+type Event struct {
+	Name  string
+	Attrs []attribute.KeyValue
+}
+
+// Record adds name as an event on the span in ctx, if it is recording, and
+// returns it for logging.
+func Record(ctx context.Context, name string, attrs ...attribute.KeyValue) Event {
+	trace.SpanFromContext(ctx).AddEvent(name, trace.WithAttributes(attrs...))
+	return Event{Name: name, Attrs: attrs}
+}
+
+// Args returns event.name and the attributes as alternating keys and values,
+// for slog-style loggers.
+func (e Event) Args() []any {
+	args := make([]any, 0, 2+2*len(e.Attrs))
+	args = append(args, EventName, e.Name)
+	for _, kv := range e.Attrs {
+		args = append(args, string(kv.Key), kv.Value.AsInterface())
+	}
+	return args
+}
+
+// Fields returns event.name and the attributes as a map, for loggers such as
+// zerolog that take one.
+func (e Event) Fields() map[string]any {
+	fields := make(map[string]any, 1+len(e.Attrs))
+	fields[EventName] = e.Name
+	for _, kv := range e.Attrs {
+		fields[string(kv.Key)] = kv.Value.AsInterface()
+	}
+	return fields
+}
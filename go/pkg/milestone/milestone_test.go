@@ -0,0 +1,66 @@
+package milestone
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/base-14/examples/go/pkg/oteltest"
+)
+
+func TestRecordAddsSpanEvent(t *testing.T) {
+	tel := oteltest.New(t)
+	ctx, span := tel.Tracer("test").Start(context.Background(), "article.create")
+	ev := Record(ctx, ArticlePublished, ArticleID.Int64(7), ArticleSlug.String("hello"), UserID.Int64(3))
+	span.End()
+
+	events := tel.Span(t, "article.create").Events
+	if len(events) != 1 {
+		t.Fatalf("events = %d, want 1", len(events))
+	}
+	if events[0].Name != ArticlePublished {
+		t.Errorf("event name = %q, want %q", events[0].Name, ArticlePublished)
+	}
+	if !slices.Equal(events[0].Attributes, ev.Attrs) {
+		t.Errorf("event attributes = %v, want %v", events[0].Attributes, ev.Attrs)
+	}
+}
+
+func TestRecordWithoutSpan(t *testing.T) {
+	ev := Record(context.Background(), UserRegistered, UserID.Int64(1))
+	if ev.Name != UserRegistered {
+		t.Errorf("name = %q", ev.Name)
+	}
+}
+
+func TestLogAttributesMatchSpanEvent(t *testing.T) {
+	ev := Event{Name: FavoriteAdded, Attrs: []attribute.KeyValue{
+		ArticleID.Int64(7),
+		UserID.Int64(3),
+		ArticleFavoritesCount.Int64(12),
+	}}
+
+	want := map[string]any{
+		EventName:                 FavoriteAdded,
+		"article.id":              int64(7),
+		"user.id":                 int64(3),
+		"article.favorites_count": int64(12),
+	}
+	if got := ev.Fields(); !maps.Equal(got, want) {
+		t.Errorf("Fields() = %v, want %v", got, want)
+	}
+
+	args := ev.Args()
+	if len(args) != 2*len(want) {
+		t.Fatalf("Args() has %d entries, want %d", len(args), 2*len(want))
+	}
+	for i := 0; i < len(args); i += 2 {
+		key := args[i].(string)
+		if want[key] != args[i+1] {
+			t.Errorf("Args()[%q] = %v, want %v", key, args[i+1], want[key])
+		}
+	}
+}
@@ -0,0 +1,297 @@
+// Package oteltest wires in-memory trace and metric pipelines for tests and
+// provides assertions over the recorded spans and metric data points, so the
+// example apps can check their telemetry without a collector.
+package oteltest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Telemetry holds a tracer provider exporting synchronously to memory and a
+// meter provider backed by a manual reader.
+type Telemetry struct {
+	TracerProvider *sdktrace.TracerProvider
+	MeterProvider  *sdkmetric.MeterProvider
+	Exporter       *tracetest.InMemoryExporter
+	Reader         *sdkmetric.ManualReader
+}
+
+// New creates in-memory providers that are shut down when the test ends.
+func New(t testing.TB) *Telemetry {
+	t.Helper()
+
+	exporter := tracetest.NewInMemoryExporter()
+	reader := sdkmetric.NewManualReader()
+	tel := &Telemetry{
+		TracerProvider: sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)),
+		MeterProvider:  sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)),
+		Exporter:       exporter,
+		Reader:         reader,
+	}
+
+	t.Cleanup(func() {
+		ctx := context.Background()
+		_ = tel.TracerProvider.Shutdown(ctx)
+		_ = tel.MeterProvider.Shutdown(ctx)
+	})
+	return tel
+}
+
+// SetGlobal installs the providers as the otel globals for the duration of
+// the test. Instruments obtained from the global API before the first call
+// to otel.SetMeterProvider in a process bind to it permanently, so code that
+// caches instruments should be exercised by a single test per package.
+func (tel *Telemetry) SetGlobal(t testing.TB) {
+	t.Helper()
+
+	prevTP, prevMP := otel.GetTracerProvider(), otel.GetMeterProvider()
+	otel.SetTracerProvider(tel.TracerProvider)
+	otel.SetMeterProvider(tel.MeterProvider)
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prevTP)
+		otel.SetMeterProvider(prevMP)
+	})
+}
+
+func (tel *Telemetry) Tracer(name string) trace.Tracer {
+	return tel.TracerProvider.Tracer(name)
+}
+
+func (tel *Telemetry) Meter(name string) metric.Meter {
+	return tel.MeterProvider.Meter(name)
+}
+
+// Spans returns every span ended so far.
+func (tel *Telemetry) Spans() tracetest.SpanStubs {
+	return tel.Exporter.GetSpans()
+}
+
+// Reset discards recorded spans. Metrics are cumulative and are not reset.
+func (tel *Telemetry) Reset() {
+	tel.Exporter.Reset()
+}
+
+// Span returns the first ended span with the given name, failing the test if
+// there is none.
+func (tel *Telemetry) Span(t testing.TB, name string) tracetest.SpanStub {
+	t.Helper()
+
+	spans := tel.Spans()
+	for _, s := range spans {
+		if s.Name == name {
+			return s
+		}
+	}
+	t.Fatalf("oteltest: no span named %q; recorded: %s", name, spanNames(spans))
+	return tracetest.SpanStub{}
+}
+
+// SpanTree describes an expected span and, optionally, spans that must be
+// its direct children. Children are matched by name in any order; children
+// not listed are allowed.
+type SpanTree struct {
+	Name     string
+	Children []SpanTree
+}
+
+// AssertSpanTree fails the test unless some recorded span matches want,
+// recursively including its children.
+func (tel *Telemetry) AssertSpanTree(t testing.TB, want SpanTree) {
+	t.Helper()
+
+	spans := tel.Spans()
+	children := make(map[trace.SpanID][]tracetest.SpanStub)
+	for _, s := range spans {
+		if s.Parent.IsValid() {
+			children[s.Parent.SpanID()] = append(children[s.Parent.SpanID()], s)
+		}
+	}
+
+	for _, s := range spans {
+		if s.Name == want.Name && matchTree(s, want, children) {
+			return
+		}
+	}
+	t.Errorf("oteltest: no span tree matching %s; recorded:\n%s", want, renderTree(spans, children))
+}
+
+func matchTree(span tracetest.SpanStub, want SpanTree, children map[trace.SpanID][]tracetest.SpanStub) bool {
+	if span.Name != want.Name {
+		return false
+	}
+
+	used := make(map[int]bool)
+	for _, wc := range want.Children {
+		found := false
+		for i, c := range children[span.SpanContext.SpanID()] {
+			if !used[i] && matchTree(c, wc, children) {
+				used[i] = true
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// AssertSpanAttributes fails the test unless span carries every given
+// attribute with the same value.
+func AssertSpanAttributes(t testing.TB, span tracetest.SpanStub, want ...attribute.KeyValue) {
+	t.Helper()
+
+	set := attribute.NewSet(span.Attributes...)
+	for _, kv := range want {
+		got, ok := set.Value(kv.Key)
+		if !ok {
+			t.Errorf("oteltest: span %q missing attribute %s", span.Name, kv.Key)
+			continue
+		}
+		if got != kv.Value {
+			t.Errorf("oteltest: span %q attribute %s = %s, want %s", span.Name, kv.Key, got.Emit(), kv.Value.Emit())
+		}
+	}
+}
+
+// Collect reads the current state of every instrument.
+func (tel *Telemetry) Collect(t testing.TB) metricdata.ResourceMetrics {
+	t.Helper()
+
+	var rm metricdata.ResourceMetrics
+	if err := tel.Reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatalf("oteltest: collect metrics: %v", err)
+	}
+	return rm
+}
+
+// Metric collects and returns the named metric, failing the test if it has
+// not been recorded.
+func (tel *Telemetry) Metric(t testing.TB, name string) metricdata.Metrics {
+	t.Helper()
+
+	rm := tel.Collect(t)
+	var names []string
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name == name {
+				return m
+			}
+			names = append(names, m.Name)
+		}
+	}
+	t.Fatalf("oteltest: no metric named %q; recorded: [%s]", name, strings.Join(names, ", "))
+	return metricdata.Metrics{}
+}
+
+// Sum returns the value of the counter or up-down counter data point whose
+// attributes include attrs, failing the test if none matches.
+func Sum[N int64 | float64](t testing.TB, tel *Telemetry, name string, attrs ...attribute.KeyValue) N {
+	t.Helper()
+
+	m := tel.Metric(t, name)
+	sum, ok := m.Data.(metricdata.Sum[N])
+	if !ok {
+		t.Fatalf("oteltest: metric %q is %T, not a sum of %T", name, m.Data, *new(N))
+	}
+	for _, dp := range sum.DataPoints {
+		if hasAttributes(dp.Attributes, attrs) {
+			return dp.Value
+		}
+	}
+	t.Fatalf("oteltest: metric %q has no data point with %s", name, formatAttrs(attrs))
+	return 0
+}
+
+// Histogram returns the histogram data point whose attributes include attrs,
+// failing the test if none matches.
+func Histogram[N int64 | float64](t testing.TB, tel *Telemetry, name string, attrs ...attribute.KeyValue) metricdata.HistogramDataPoint[N] {
+	t.Helper()
+
+	m := tel.Metric(t, name)
+	hist, ok := m.Data.(metricdata.Histogram[N])
+	if !ok {
+		t.Fatalf("oteltest: metric %q is %T, not a histogram of %T", name, m.Data, *new(N))
+	}
+	for _, dp := range hist.DataPoints {
+		if hasAttributes(dp.Attributes, attrs) {
+			return dp
+		}
+	}
+	t.Fatalf("oteltest: metric %q has no data point with %s", name, formatAttrs(attrs))
+	return metricdata.HistogramDataPoint[N]{}
+}
+
+func hasAttributes(set attribute.Set, want []attribute.KeyValue) bool {
+	for _, kv := range want {
+		if got, ok := set.Value(kv.Key); !ok || got != kv.Value {
+			return false
+		}
+	}
+	return true
+}
+
+func formatAttrs(attrs []attribute.KeyValue) string {
+	if len(attrs) == 0 {
+		return "{}"
+	}
+	parts := make([]string, len(attrs))
+	for i, kv := range attrs {
+		parts[i] = fmt.Sprintf("%s=%s", kv.Key, kv.Value.Emit())
+	}
+	return "{" + strings.Join(parts, ", ") + "}"
+}
+
+func (s SpanTree) String() string {
+	if len(s.Children) == 0 {
+		return s.Name
+	}
+	children := make([]string, len(s.Children))
+	for i, c := range s.Children {
+		children[i] = c.String()
+	}
+	return s.Name + " > [" + strings.Join(children, ", ") + "]"
+}
+
+func spanNames(spans tracetest.SpanStubs) string {
+	names := make([]string, len(spans))
+	for i, s := range spans {
+		names[i] = s.Name
+	}
+	return "[" + strings.Join(names, ", ") + "]"
+}
+
+func renderTree(spans tracetest.SpanStubs, children map[trace.SpanID][]tracetest.SpanStub) string {
+	recorded := make(map[trace.SpanID]bool, len(spans))
+	for _, s := range spans {
+		recorded[s.SpanContext.SpanID()] = true
+	}
+
+	var b strings.Builder
+	var walk func(s tracetest.SpanStub, depth int)
+	walk = func(s tracetest.SpanStub, depth int) {
+		fmt.Fprintf(&b, "%s- %s\n", strings.Repeat("  ", depth), s.Name)
+		for _, c := range children[s.SpanContext.SpanID()] {
+			walk(c, depth+1)
+		}
+	}
+	for _, s := range spans {
+		if !s.Parent.IsValid() || !recorded[s.Parent.SpanID()] {
+			walk(s, 0)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,71 @@
+package oteltest
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// failRecorder captures an expected assertion failure without failing the
+// enclosing test.
+type failRecorder struct {
+	testing.TB
+	failed bool
+}
+
+func (r *failRecorder) Errorf(string, ...any) { r.failed = true }
+
+func TestAssertSpanTree(t *testing.T) {
+	tel := New(t)
+	tracer := tel.Tracer("test")
+
+	ctx, root := tracer.Start(context.Background(), "request")
+	ctx2, child := tracer.Start(ctx, "db.query")
+	_, grandchild := tracer.Start(ctx2, "db.connect")
+	grandchild.End()
+	child.End()
+	_, sibling := tracer.Start(ctx, "cache.get")
+	sibling.SetAttributes(attribute.String("cache.hit", "false"))
+	sibling.End()
+	root.End()
+
+	tel.AssertSpanTree(t, SpanTree{
+		Name: "request",
+		Children: []SpanTree{
+			{Name: "cache.get"},
+			{Name: "db.query", Children: []SpanTree{{Name: "db.connect"}}},
+		},
+	})
+	AssertSpanAttributes(t, tel.Span(t, "cache.get"), attribute.String("cache.hit", "false"))
+
+	inner := &failRecorder{TB: t}
+	tel.AssertSpanTree(inner, SpanTree{Name: "request", Children: []SpanTree{{Name: "db.connect"}}})
+	if !inner.failed {
+		t.Error("expected a grandchild listed as a direct child not to match")
+	}
+}
+
+func TestMetricAssertions(t *testing.T) {
+	tel := New(t)
+	meter := tel.Meter("test")
+	ctx := context.Background()
+
+	counter, _ := meter.Int64Counter("requests")
+	counter.Add(ctx, 2, metric.WithAttributes(attribute.String("route", "/a"), attribute.Int("status", 200)))
+	counter.Add(ctx, 5, metric.WithAttributes(attribute.String("route", "/b"), attribute.Int("status", 200)))
+
+	hist, _ := meter.Float64Histogram("latency")
+	hist.Record(ctx, 0.5, metric.WithAttributes(attribute.String("route", "/a")))
+	hist.Record(ctx, 1.5, metric.WithAttributes(attribute.String("route", "/a")))
+
+	if got := Sum[int64](t, tel, "requests", attribute.String("route", "/b")); got != 5 {
+		t.Errorf("requests{route=/b} = %d, want 5", got)
+	}
+
+	dp := Histogram[float64](t, tel, "latency", attribute.String("route", "/a"))
+	if dp.Count != 2 || dp.Sum != 2 {
+		t.Errorf("latency{route=/a} count=%d sum=%v, want 2 and 2", dp.Count, dp.Sum)
+	}
+}
@@ -0,0 +1,190 @@
+// Package response is the JSON contract of the example HTTP APIs. Every body
+// is an object with "meta" and either "data" (success) or "errors"
+// (failure), so a client reads any endpoint the same way:
+//
+//	{"data": {...}, "meta": {"timestamp": "...", "trace_id": "..."}}
+//	{"data": [...], "meta": {..., "pagination": {"per_page": 20, ...}}}
+//	{"errors": [{"code": "not_found", "message": "article not found"}], "meta": {...}}
+//
+// The package only builds values; each app writes them with its framework's
+// JSON method. Timestamps in bodies use Time, which always renders in UTC
+// with millisecond precision.
+package response
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TimeFormat is the layout of every timestamp in a response: RFC 3339 in
+// UTC with exactly three fractional digits, so values sort as strings.
+const TimeFormat = "2006-01-02T15:04:05.000Z"
+
+// now is replaced in tests to pin meta.timestamp.
+var now = time.Now
+
+// Time is a timestamp that marshals with TimeFormat. The zero time
+// marshals as null rather than as year 1.
+type Time time.Time
+
+// NewTime converts t for a response body.
+func NewTime(t time.Time) Time {
+	return Time(t)
+}
+
+// NewTimePtr converts an optional timestamp, keeping nil as nil so the field
+// can be omitted.
+func NewTimePtr(t *time.Time) *Time {
+	if t == nil {
+		return nil
+	}
+	rt := Time(*t)
+	return &rt
+}
+
+// String formats t with TimeFormat.
+func (t Time) String() string {
+	return time.Time(t).UTC().Format(TimeFormat)
+}
+
+func (t Time) MarshalJSON() ([]byte, error) {
+	if time.Time(t).IsZero() {
+		return []byte("null"), nil
+	}
+	return []byte(`"` + t.String() + `"`), nil
+}
+
+// UnmarshalJSON accepts any RFC 3339 timestamp, so clients and tests can
+// decode bodies back into response types.
+func (t *Time) UnmarshalJSON(b []byte) error {
+	s := string(b)
+	if s == "null" {
+		*t = Time{}
+		return nil
+	}
+	parsed, err := time.Parse(`"`+time.RFC3339Nano+`"`, s)
+	if err != nil {
+		return err
+	}
+	*t = Time(parsed)
+	return nil
+}
+
+// Envelope is a successful response carrying data of type T.
+type Envelope[T any] struct {
+	Data T    `json:"data"`
+	Meta Meta `json:"meta"`
+}
+
+// ErrorEnvelope is a failed response. Errors always has at least one entry.
+type ErrorEnvelope struct {
+	Errors []Error `json:"errors"`
+	Meta   Meta    `json:"meta"`
+}
+
+// Error is one problem with a request. Code is stable for clients to switch
+// on; Message is for people and may change.
+type Error struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Meta describes the response rather than the resource. TraceID is set when
+// the request was traced, so a client can quote it in a bug report.
+type Meta struct {
+	Timestamp  Time        `json:"timestamp"`
+	TraceID    string      `json:"trace_id,omitempty"`
+	Pagination *Pagination `json:"pagination,omitempty"`
+}
+
+// Pagination describes where a list page sits in the full result. Page and
+// TotalPages are set for page-numbered lists, Offset for offset lists and
+// NextCursor for cursor lists; TotalCount is set whenever the total is
+// known. HasMore is always set, so a client can page through any list by
+// checking it alone.
+type Pagination struct {
+	Page       int    `json:"page,omitempty"`
+	PerPage    int    `json:"per_page"`
+	Offset     int    `json:"offset,omitempty"`
+	TotalCount *int64 `json:"total_count,omitempty"`
+	TotalPages int    `json:"total_pages,omitempty"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	HasMore    bool   `json:"has_more"`
+}
+
+// PageOf describes page (1-based) of a page-numbered list of total items.
+func PageOf(page, perPage int, total int64) *Pagination {
+	p := &Pagination{Page: page, PerPage: perPage, TotalCount: &total}
+	if perPage > 0 {
+		p.TotalPages = int((total + int64(perPage) - 1) / int64(perPage))
+	}
+	p.HasMore = page < p.TotalPages
+	return p
+}
+
+// OffsetOf describes the window starting at offset of a list of total
+// items.
+func OffsetOf(offset, limit int, total int64) *Pagination {
+	return &Pagination{
+		PerPage:    limit,
+		Offset:     offset,
+		TotalCount: &total,
+		HasMore:    int64(offset+limit) < total,
+	}
+}
+
+// CursorOf describes a page of a cursor list. next is the cursor of the
+// following page, empty on the last one.
+func CursorOf(limit int, next string) *Pagination {
+	return &Pagination{PerPage: limit, NextCursor: next, HasMore: next != ""}
+}
+
+// Data wraps data in an envelope.
+func Data[T any](ctx context.Context, data T) Envelope[T] {
+	return Envelope[T]{Data: data, Meta: newMeta(ctx)}
+}
+
+// Page wraps one page of a list in an envelope with its pagination.
+func Page[T any](ctx context.Context, items []T, p *Pagination) Envelope[[]T] {
+	if items == nil {
+		items = []T{}
+	}
+	meta := newMeta(ctx)
+	meta.Pagination = p
+	return Envelope[[]T]{Data: items, Meta: meta}
+}
+
+// Fail is the envelope for an HTTP error status with a single message.
+func Fail(ctx context.Context, status int, message string) ErrorEnvelope {
+	return Errors(ctx, Error{Code: Code(status), Message: message})
+}
+
+// Errors wraps one or more errors in an envelope.
+func Errors(ctx context.Context, errs ...Error) ErrorEnvelope {
+	return ErrorEnvelope{Errors: errs, Meta: newMeta(ctx)}
+}
+
+// Code is the error code for an HTTP status: its status text in snake case,
+// such as "not_found" or "too_many_requests", or "error" for an unknown
+// status.
+func Code(status int) string {
+	text := http.StatusText(status)
+	if text == "" {
+		return "error"
+	}
+	text = strings.ToLower(text)
+	text = strings.NewReplacer(" ", "_", "-", "_", "'", "").Replace(text)
+	return text
+}
+
+func newMeta(ctx context.Context) Meta {
+	meta := Meta{Timestamp: Time(now())}
+	if sc := trace.SpanContextFromContext(ctx); sc.HasTraceID() {
+		meta.TraceID = sc.TraceID().String()
+	}
+	return meta
+}
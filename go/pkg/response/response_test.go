@@ -0,0 +1,180 @@
+package response
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/base-14/examples/go/pkg/oteltest"
+)
+
+var fixedNow = time.Date(2026, 3, 4, 5, 6, 7, 891234567, time.FixedZone("IST", 5*3600+1800))
+
+func pinNow(t *testing.T) {
+	t.Helper()
+	prev := now
+	now = func() time.Time { return fixedNow }
+	t.Cleanup(func() { now = prev })
+}
+
+func marshal(t *testing.T, v any) string {
+	t.Helper()
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	return string(b)
+}
+
+type article struct {
+	Slug      string `json:"slug"`
+	CreatedAt Time   `json:"created_at"`
+	DeletedAt *Time  `json:"deleted_at,omitempty"`
+}
+
+func TestDataEnvelope(t *testing.T) {
+	pinNow(t)
+
+	got := marshal(t, Data(context.Background(), article{Slug: "hello", CreatedAt: NewTime(fixedNow)}))
+	want := `{"data":{"slug":"hello","created_at":"2026-03-03T23:36:07.891Z"},"meta":{"timestamp":"2026-03-03T23:36:07.891Z"}}`
+	if got != want {
+		t.Errorf("body =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestDataEnvelopeCarriesTraceID(t *testing.T) {
+	tel := oteltest.New(t)
+	ctx, span := tel.Tracer("test").Start(context.Background(), "request")
+	defer span.End()
+
+	env := Data(ctx, "ok")
+	if want := span.SpanContext().TraceID().String(); env.Meta.TraceID != want {
+		t.Errorf("trace_id = %q, want %q", env.Meta.TraceID, want)
+	}
+}
+
+func TestPageEnvelope(t *testing.T) {
+	pinNow(t)
+
+	got := marshal(t, Page(context.Background(), []string{"a", "b"}, PageOf(2, 2, 5)))
+	want := `{"data":["a","b"],"meta":{"timestamp":"2026-03-03T23:36:07.891Z","pagination":{"page":2,"per_page":2,"total_count":5,"total_pages":3,"has_more":true}}}`
+	if got != want {
+		t.Errorf("body =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestPageEnvelopeEmptyListIsArray(t *testing.T) {
+	var items []article
+	env := Page(context.Background(), items, PageOf(1, 20, 0))
+
+	var body struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal([]byte(marshal(t, env)), &body); err != nil {
+		t.Fatal(err)
+	}
+	if string(body.Data) != "[]" {
+		t.Errorf("data = %s, want []", body.Data)
+	}
+}
+
+func TestPagination(t *testing.T) {
+	tests := []struct {
+		name string
+		p    *Pagination
+		want string
+	}{
+		{"last page", PageOf(3, 2, 5), `{"page":3,"per_page":2,"total_count":5,"total_pages":3,"has_more":false}`},
+		{"empty list", PageOf(1, 20, 0), `{"page":1,"per_page":20,"total_count":0,"has_more":false}`},
+		{"offset with more", OffsetOf(20, 20, 41), `{"per_page":20,"offset":20,"total_count":41,"has_more":true}`},
+		{"offset at end", OffsetOf(40, 20, 41), `{"per_page":20,"offset":40,"total_count":41,"has_more":false}`},
+		{"cursor with more", CursorOf(20, "abc"), `{"per_page":20,"next_cursor":"abc","has_more":true}`},
+		{"cursor at end", CursorOf(20, ""), `{"per_page":20,"has_more":false}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := marshal(t, tt.p); got != tt.want {
+				t.Errorf("pagination = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFail(t *testing.T) {
+	pinNow(t)
+
+	got := marshal(t, Fail(context.Background(), http.StatusNotFound, "article not found"))
+	want := `{"errors":[{"code":"not_found","message":"article not found"}],"meta":{"timestamp":"2026-03-03T23:36:07.891Z"}}`
+	if got != want {
+		t.Errorf("body =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestCode(t *testing.T) {
+	tests := map[int]string{
+		http.StatusBadRequest:          "bad_request",
+		http.StatusUnauthorized:        "unauthorized",
+		http.StatusTooManyRequests:     "too_many_requests",
+		http.StatusInternalServerError: "internal_server_error",
+		http.StatusRequestURITooLong:   "request_uri_too_long",
+		http.StatusTeapot:              "im_a_teapot",
+		599:                            "error",
+	}
+	for status, want := range tests {
+		if got := Code(status); got != want {
+			t.Errorf("Code(%d) = %q, want %q", status, got, want)
+		}
+	}
+}
+
+func TestTimeFormat(t *testing.T) {
+	tests := []struct {
+		name string
+		in   time.Time
+		want string
+	}{
+		{"converted to UTC", fixedNow, `"2026-03-03T23:36:07.891Z"`},
+		{"whole second keeps fraction", time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC), `"2026-01-02T03:04:05.000Z"`},
+		{"zero is null", time.Time{}, `null`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := marshal(t, NewTime(tt.in)); got != tt.want {
+				t.Errorf("time = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTimePtr(t *testing.T) {
+	if NewTimePtr(nil) != nil {
+		t.Error("NewTimePtr(nil) is not nil")
+	}
+
+	got := marshal(t, article{Slug: "a", CreatedAt: NewTime(fixedNow)})
+	want := `{"slug":"a","created_at":"2026-03-03T23:36:07.891Z"}`
+	if got != want {
+		t.Errorf("body = %s, want %s", got, want)
+	}
+
+	deleted := fixedNow.Add(time.Hour)
+	got = marshal(t, article{Slug: "a", CreatedAt: NewTime(fixedNow), DeletedAt: NewTimePtr(&deleted)})
+	want = `{"slug":"a","created_at":"2026-03-03T23:36:07.891Z","deleted_at":"2026-03-04T00:36:07.891Z"}`
+	if got != want {
+		t.Errorf("body = %s, want %s", got, want)
+	}
+}
+
+func TestTimeRoundTrip(t *testing.T) {
+	var decoded Envelope[article]
+	body := marshal(t, Data(context.Background(), article{Slug: "a", CreatedAt: NewTime(fixedNow)}))
+	if err := json.Unmarshal([]byte(body), &decoded); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	want := fixedNow.Truncate(time.Millisecond)
+	if got := time.Time(decoded.Data.CreatedAt); !got.Equal(want) {
+		t.Errorf("created_at = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,224 @@
+// Package shutdown stops a service in a fixed order: the HTTP server first,
+// so no new work arrives, then background workers, then database pools and
+// other clients the first two used, and telemetry last, so the spans and
+// metrics of everything before it are flushed. Each closer has its own
+// timeout, so one that hangs cannot use up the time of those after it.
+package shutdown
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// Phase orders closers. Phases run one after another; the closers within a
+// phase run concurrently.
+type Phase int
+
+const (
+	// PhaseServer stops accepting requests and drains those in flight.
+	PhaseServer Phase = iota
+	// PhaseWorkers stops background loops, consumers and relays.
+	PhaseWorkers
+	// PhaseClients closes database pools, caches and queue clients.
+	PhaseClients
+	// PhaseTelemetry flushes and shuts down the trace, metric and log
+	// providers.
+	PhaseTelemetry
+)
+
+func (p Phase) String() string {
+	switch p {
+	case PhaseServer:
+		return "server"
+	case PhaseWorkers:
+		return "workers"
+	case PhaseClients:
+		return "clients"
+	case PhaseTelemetry:
+		return "telemetry"
+	}
+	return fmt.Sprintf("phase(%d)", int(p))
+}
+
+// ErrTimeout is returned, wrapped, for a closer that did not return within
+// its timeout. It is left running.
+var ErrTimeout = errors.New("shutdown timed out")
+
+// Event describes one closer that finished or timed out.
+type Event struct {
+	Name     string
+	Phase    Phase
+	Duration time.Duration
+	Err      error
+}
+
+// Config tunes an Orchestrator. Zero values use the defaults.
+type Config struct {
+	// Timeout bounds each closer that does not set its own.
+	Timeout time.Duration
+	// OnClose, if set, is called after every closer so the caller can log
+	// with its own logger.
+	OnClose func(Event)
+	// Meter records app.shutdown.duration. Defaults to the global provider.
+	Meter metric.Meter
+}
+
+const defaultTimeout = 10 * time.Second
+
+// Orchestrator collects closers as a service starts and runs them in phase
+// order when it stops.
+type Orchestrator struct {
+	cfg Config
+
+	mu      sync.Mutex
+	closers []closer
+}
+
+type closer struct {
+	name    string
+	phase   Phase
+	timeout time.Duration
+	close   func(context.Context) error
+}
+
+func New(cfg Config) *Orchestrator {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultTimeout
+	}
+	return &Orchestrator{cfg: cfg}
+}
+
+// Add registers close to run in phase, under the default timeout.
+func (o *Orchestrator) Add(phase Phase, name string, close func(context.Context) error) {
+	o.AddWithTimeout(phase, name, 0, close)
+}
+
+// AddWithTimeout registers close to run in phase, under timeout, or the
+// default one when timeout is 0. The context close gets expires with it.
+func (o *Orchestrator) AddWithTimeout(phase Phase, name string, timeout time.Duration, close func(context.Context) error) {
+	if timeout <= 0 {
+		timeout = o.cfg.Timeout
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.closers = append(o.closers, closer{name: name, phase: phase, timeout: timeout, close: close})
+}
+
+// Func adapts a close method without context or error, such as
+// (*pgxpool.Pool).Close, for Add.
+func Func(f func()) func(context.Context) error {
+	return func(context.Context) error {
+		f()
+		return nil
+	}
+}
+
+// ErrFunc adapts a close method without context, such as (*sql.DB).Close,
+// for Add.
+func ErrFunc(f func() error) func(context.Context) error {
+	return func(context.Context) error {
+		return f()
+	}
+}
+
+// Shutdown runs the closers phase by phase and returns their errors
+// joined. A closer that outlives its timeout is abandoned with ErrTimeout
+// and the next one starts. The time taken up to the telemetry phase is
+// recorded as app.shutdown.duration, with outcome "clean", "error" or
+// "timeout", before that phase runs, so the measurement is flushed with the
+// rest.
+func (o *Orchestrator) Shutdown(ctx context.Context) error {
+	o.mu.Lock()
+	closers := append([]closer(nil), o.closers...)
+	o.mu.Unlock()
+
+	start := time.Now()
+	var errs []error
+	for phase := PhaseServer; phase <= PhaseTelemetry; phase++ {
+		if phase == PhaseTelemetry {
+			o.record(start, errs)
+		}
+		errs = append(errs, o.runPhase(ctx, phase, closers)...)
+	}
+	return errors.Join(errs...)
+}
+
+func (o *Orchestrator) runPhase(ctx context.Context, phase Phase, closers []closer) []error {
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+	for _, c := range closers {
+		if c.phase != phase {
+			continue
+		}
+		wg.Go(func() {
+			if err := o.run(ctx, c); err != nil {
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+			}
+		})
+	}
+	wg.Wait()
+	return errs
+}
+
+func (o *Orchestrator) run(ctx context.Context, c closer) error {
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() { done <- c.close(ctx) }()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ErrTimeout
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		err = ErrTimeout
+	}
+	if err != nil {
+		err = fmt.Errorf("%s: %w", c.name, err)
+	}
+	if o.cfg.OnClose != nil {
+		o.cfg.OnClose(Event{Name: c.name, Phase: c.phase, Duration: time.Since(start), Err: err})
+	}
+	return err
+}
+
+func (o *Orchestrator) record(start time.Time, errs []error) {
+	outcome := "clean"
+	for _, err := range errs {
+		outcome = "error"
+		if errors.Is(err, ErrTimeout) {
+			outcome = "timeout"
+			break
+		}
+	}
+
+	meter := o.cfg.Meter
+	if meter == nil {
+		meter = otel.Meter("github.com/base-14/examples/go/pkg/shutdown")
+	}
+	hist, err := meter.Float64Histogram("app.shutdown.duration",
+		metric.WithUnit("s"),
+		metric.WithDescription("Time to stop serving, stop workers and close clients on shutdown"),
+	)
+	if err != nil {
+		return
+	}
+	hist.Record(context.Background(), time.Since(start).Seconds(),
+		metric.WithAttributes(attribute.String("outcome", outcome)))
+}
@@ -0,0 +1,107 @@
+package shutdown
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/base-14/examples/go/pkg/oteltest"
+)
+
+func TestShutdownRunsPhasesInOrder(t *testing.T) {
+	tel := oteltest.New(t)
+	o := New(Config{Meter: tel.Meter("test")})
+
+	var mu sync.Mutex
+	var order []string
+	closeAs := func(name string) func(context.Context) error {
+		return func(context.Context) error {
+			mu.Lock()
+			defer mu.Unlock()
+			order = append(order, name)
+			return nil
+		}
+	}
+	// Registered out of order, as a main's setup would.
+	o.Add(PhaseTelemetry, "telemetry", closeAs("telemetry"))
+	o.Add(PhaseClients, "postgres", closeAs("postgres"))
+	o.Add(PhaseWorkers, "relay", closeAs("relay"))
+	o.Add(PhaseServer, "http", closeAs("http"))
+
+	if err := o.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	if want := []string{"http", "relay", "postgres", "telemetry"}; !slices.Equal(order, want) {
+		t.Errorf("order = %v, want %v", order, want)
+	}
+	if hist := oteltest.Histogram[float64](t, tel, "app.shutdown.duration", attribute.String("outcome", "clean")); hist.Count != 1 {
+		t.Errorf("app.shutdown.duration count = %d, want 1", hist.Count)
+	}
+}
+
+func TestShutdownAbandonsSlowCloser(t *testing.T) {
+	tel := oteltest.New(t)
+	var events []Event
+	o := New(Config{Meter: tel.Meter("test"), OnClose: func(ev Event) { events = append(events, ev) }})
+
+	release := make(chan struct{})
+	defer close(release)
+	o.AddWithTimeout(PhaseWorkers, "stuck", 20*time.Millisecond, func(context.Context) error {
+		<-release // ignores its context
+		return nil
+	})
+	closed := false
+	o.Add(PhaseClients, "postgres", func(context.Context) error {
+		closed = true
+		return nil
+	})
+
+	start := time.Now()
+	err := o.Shutdown(context.Background())
+	if !errors.Is(err, ErrTimeout) {
+		t.Fatalf("Shutdown error = %v, want ErrTimeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Shutdown took %s, want the stuck closer abandoned after its timeout", elapsed)
+	}
+	if !closed {
+		t.Error("closers after the stuck one did not run")
+	}
+	if len(events) != 2 || events[0].Name != "stuck" || events[0].Phase != PhaseWorkers || events[0].Err == nil {
+		t.Errorf("events = %+v", events)
+	}
+	if hist := oteltest.Histogram[float64](t, tel, "app.shutdown.duration", attribute.String("outcome", "timeout")); hist.Count != 1 {
+		t.Errorf("app.shutdown.duration{outcome=timeout} count = %d, want 1", hist.Count)
+	}
+}
+
+func TestShutdownRecordsBeforeTelemetryPhase(t *testing.T) {
+	tel := oteltest.New(t)
+	o := New(Config{Meter: tel.Meter("test")})
+
+	o.Add(PhaseClients, "cache", func(context.Context) error { return errors.New("connection reset") })
+	var seen int64
+	o.Add(PhaseTelemetry, "telemetry", func(context.Context) error {
+		seen = int64(oteltest.Histogram[float64](t, tel, "app.shutdown.duration", attribute.String("outcome", "error")).Count)
+		return nil
+	})
+
+	err := o.Shutdown(context.Background())
+	if err == nil || err.Error() != "cache: connection reset" {
+		t.Fatalf("Shutdown error = %v, want the cache error", err)
+	}
+	if seen != 1 {
+		t.Errorf("app.shutdown.duration not recorded before the telemetry phase")
+	}
+}
+
+func TestPhaseString(t *testing.T) {
+	if got := PhaseClients.String(); got != "clients" {
+		t.Errorf("PhaseClients = %q", got)
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"stdlib-articles/model"
 	"stdlib-articles/repository"
 
+	"github.com/base-14/examples/go/pkg/milestone"
 	"go.opentelemetry.io/otel/metric"
 	"go.opentelemetry.io/otel/trace"
 )
@@ -113,7 +114,8 @@ func (h *ArticleHandler) create(w http.ResponseWriter, r *http.Request) {
 	}
 
 	h.created.Add(r.Context(), 1)
-	h.logger.InfoContext(r.Context(), "Article created", "article_id", article.ID)
+	ev := milestone.Record(r.Context(), milestone.ArticlePublished, milestone.ArticleID.Int64(article.ID))
+	h.logger.InfoContext(r.Context(), ev.Name, ev.Args()...)
 
 	if h.notify != nil {
 		if err := h.notify(r.Context(), article); err != nil {
@@ -240,7 +242,12 @@ func writeJSON(w http.ResponseWriter, status int, v any) {
 	_ = json.NewEncoder(w).Encode(v)
 }
 
+// writeError reports server errors caused by the request deadline (set by
+// middleware.Timeout) as 504 rather than 500.
 func writeError(ctx context.Context, w http.ResponseWriter, status int, code, message string) {
+	if status >= http.StatusInternalServerError && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		status, code, message = http.StatusGatewayTimeout, "TIMEOUT", "Request timed out"
+	}
 	writeJSON(w, status, map[string]any{
 		"error": map[string]string{"code": code, "message": message},
 		"meta":  map[string]any{"trace_id": traceID(ctx)},
@@ -0,0 +1,13 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/base-14/examples/go/pkg/buildinfo"
+)
+
+func Version(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(buildinfo.Get())
+}
@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 	"time"
 
@@ -16,10 +17,16 @@ import (
 	"stdlib-articles/repository"
 	"stdlib-articles/service"
 
+	"github.com/base-14/examples/go/pkg/depwait"
+	"github.com/base-14/examples/go/pkg/healthcheck"
+	"github.com/base-14/examples/go/pkg/shutdown"
 	"github.com/exaring/otelpgx"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/metric"
 )
 
 func main() {
@@ -30,24 +37,31 @@ func main() {
 	otlpEndpoint := envOr("OTEL_EXPORTER_OTLP_ENDPOINT", "http://otel-collector:4318")
 	notifyURL := envOr("NOTIFY_URL", "")
 	serviceName := envOr("OTEL_SERVICE_NAME", "stdlib-articles")
+	cacheMaxAge := durationOr("CACHE_MAX_AGE", 30*time.Second)
+	surrogateMaxAge := durationOr("CACHE_SURROGATE_MAX_AGE", 5*time.Minute)
+	readTimeout := durationOr("REQUEST_TIMEOUT_READ", 2*time.Second)
+	writeTimeout := durationOr("REQUEST_TIMEOUT_WRITE", 5*time.Second)
+	queryWarnThreshold := intOr("DB_QUERY_WARN_THRESHOLD", 10)
 
-	shutdownTel, err := initTelemetry(ctx, serviceName, otlpEndpoint)
+	healthcheck.Main(depwait.Dependency{Name: "app", Check: healthcheck.HTTP(healthcheck.Local(port, "/api/health"))})
+
+	var promRegistry *prometheus.Registry
+	if envOr("PROMETHEUS_ENABLED", "false") == "true" {
+		promRegistry = prometheus.NewRegistry()
+	}
+
+	shutdownTel, err := initTelemetry(ctx, serviceName, otlpEndpoint, promRegistry)
 	if err != nil {
 		log.Fatalf("telemetry: %v", err)
 	}
-	defer func() {
-		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
-		if err := shutdownTel(shutdownCtx); err != nil {
-			log.Printf("telemetry shutdown: %v", err)
-		}
-	}()
+	sd := shutdown.New(shutdown.Config{OnClose: logShutdown})
+	sd.Add(shutdown.PhaseTelemetry, "telemetry", shutdownTel)
 
 	pool, err := newPool(ctx, dsn)
 	if err != nil {
 		log.Fatalf("db pool: %v", err)
 	}
-	defer pool.Close()
+	sd.Add(shutdown.PhaseClients, "postgres", shutdown.Func(pool.Close))
 
 	if _, err := pool.Exec(ctx, model.Schema); err != nil {
 		log.Fatalf("schema: %v", err)
@@ -55,7 +69,27 @@ func main() {
 
 	logger := middleware.NewLogger(serviceName)
 
-	createdCounter, err := otel.Meter("stdlib-articles").Int64Counter("articles.created")
+	meter := otel.Meter("stdlib-articles")
+	createdCounter, err := meter.Int64Counter("articles.created")
+	if err != nil {
+		log.Fatalf("counter: %v", err)
+	}
+	cacheCounter, err := meter.Int64Counter("http.server.cache.responses")
+	if err != nil {
+		log.Fatalf("counter: %v", err)
+	}
+	deadlineCounter, err := meter.Int64Counter("http.server.request.deadline_exceeded")
+	if err != nil {
+		log.Fatalf("counter: %v", err)
+	}
+	queriesHistogram, err := meter.Int64Histogram("http.server.db.queries",
+		metric.WithUnit("{query}"),
+		metric.WithExplicitBucketBoundaries(0, 1, 2, 3, 5, 8, 13, 21, 34, 55, 100),
+	)
+	if err != nil {
+		log.Fatalf("histogram: %v", err)
+	}
+	queryThresholdCounter, err := meter.Int64Counter("http.server.db.query_threshold_exceeded")
 	if err != nil {
 		log.Fatalf("counter: %v", err)
 	}
@@ -68,17 +102,38 @@ func main() {
 
 	mux := http.NewServeMux()
 	mux.HandleFunc("GET /api/health", handler.Health)
+	mux.HandleFunc("GET /version", handler.Version)
+	if promRegistry != nil {
+		mux.Handle("GET /metrics", promhttp.HandlerFor(promRegistry, promhttp.HandlerOpts{}))
+	}
 	articles.Register(mux)
 
+	articleCache := middleware.CachePolicy{
+		Visibility:           middleware.CachePublic,
+		MaxAge:               cacheMaxAge,
+		SurrogateMaxAge:      surrogateMaxAge,
+		StaleWhileRevalidate: cacheMaxAge,
+	}
+	root := middleware.CacheControl(mux, middleware.CachePolicies{
+		"GET /api/health":        {Visibility: middleware.CacheNoStore},
+		"GET /version":           {Visibility: middleware.CacheNoStore},
+		"GET /metrics":           {Visibility: middleware.CacheNoStore},
+		"GET /api/articles":      articleCache,
+		"GET /api/articles/{id}": articleCache,
+	}, cacheCounter)
+	root = middleware.QueryCount(root, queryWarnThreshold, logger, queriesHistogram, queryThresholdCounter)
+	root = middleware.Timeout(root, readTimeout, writeTimeout, deadlineCounter)
+
 	server := &http.Server{
 		Addr: ":" + port,
-		Handler: otelhttp.NewHandler(mux, "http.server",
+		Handler: otelhttp.NewHandler(root, "http.server",
 			otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
 				return r.Method + " " + r.URL.Path
 			}),
 		),
 		ReadHeaderTimeout: 5 * time.Second,
 	}
+	sd.Add(shutdown.PhaseServer, "http", server.Shutdown)
 
 	go func() {
 		log.Printf("stdlib-articles listening on :%s", port)
@@ -91,9 +146,7 @@ func main() {
 	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
 	<-sigCh
 
-	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-	if err := server.Shutdown(shutdownCtx); err != nil {
+	if err := sd.Shutdown(context.Background()); err != nil {
 		log.Printf("shutdown error: %v", err)
 	}
 }
@@ -103,7 +156,7 @@ func newPool(ctx context.Context, dsn string) (*pgxpool.Pool, error) {
 	if err != nil {
 		return nil, err
 	}
-	cfg.ConnConfig.Tracer = otelpgx.NewTracer()
+	cfg.ConnConfig.Tracer = repository.QueryCountTracer{Tracer: otelpgx.NewTracer()}
 
 	pool, err := pgxpool.NewWithConfig(ctx, cfg)
 	if err != nil {
@@ -119,9 +172,31 @@ func newPool(ctx context.Context, dsn string) (*pgxpool.Pool, error) {
 	return pool, nil
 }
 
+func logShutdown(ev shutdown.Event) {
+	if ev.Err != nil {
+		log.Printf("shutdown: %s (%s) failed after %s: %v", ev.Name, ev.Phase, ev.Duration, ev.Err)
+		return
+	}
+	log.Printf("shutdown: %s (%s) done in %s", ev.Name, ev.Phase, ev.Duration)
+}
+
 func envOr(k, def string) string {
 	if v := os.Getenv(k); v != "" {
 		return v
 	}
 	return def
 }
+
+func intOr(k string, def int) int {
+	if n, err := strconv.Atoi(os.Getenv(k)); err == nil {
+		return n
+	}
+	return def
+}
+
+func durationOr(k string, def time.Duration) time.Duration {
+	if d, err := time.ParseDuration(os.Getenv(k)); err == nil {
+		return d
+	}
+	return def
+}
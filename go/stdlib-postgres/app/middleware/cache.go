@@ -0,0 +1,112 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+type CacheVisibility string
+
+const (
+	CachePublic  CacheVisibility = "public"
+	CachePrivate CacheVisibility = "private"
+	CacheNoStore CacheVisibility = "no-store"
+)
+
+// CachePolicy describes the Cache-Control and Surrogate-Control headers for a
+// route. MaxAge applies to browsers; SurrogateMaxAge to CDNs and shared caches.
+type CachePolicy struct {
+	Visibility           CacheVisibility
+	MaxAge               time.Duration
+	SurrogateMaxAge      time.Duration
+	StaleWhileRevalidate time.Duration
+}
+
+// CachePolicies is keyed by the ServeMux pattern that matched the request,
+// e.g. "GET /api/articles/{id}". Unlisted GETs are private; everything else
+// is no-store.
+type CachePolicies map[string]CachePolicy
+
+// CacheControl sets cache headers just before the status line is written, once
+// the mux has resolved the pattern and the handler has chosen a status. Error
+// responses are always no-store. Each response is counted on the given
+// counter with its cacheability.
+func CacheControl(next http.Handler, policies CachePolicies, responses metric.Int64Counter) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cw := &cacheWriter{ResponseWriter: w, r: r, policies: policies, responses: responses}
+		next.ServeHTTP(cw, r)
+		if !cw.wroteHeader {
+			cw.WriteHeader(http.StatusOK)
+		}
+	})
+}
+
+type cacheWriter struct {
+	http.ResponseWriter
+	r           *http.Request
+	policies    CachePolicies
+	responses   metric.Int64Counter
+	wroteHeader bool
+}
+
+func (cw *cacheWriter) WriteHeader(status int) {
+	if cw.wroteHeader {
+		return
+	}
+	cw.wroteHeader = true
+
+	policy, ok := cw.policies[cw.r.Pattern]
+	if !ok {
+		policy = CachePolicy{Visibility: CacheNoStore}
+		if cw.r.Method == http.MethodGet {
+			policy.Visibility = CachePrivate
+		}
+	}
+	if status >= http.StatusBadRequest {
+		policy = CachePolicy{Visibility: CacheNoStore}
+	}
+
+	h := cw.Header()
+	h.Set("Cache-Control", policy.cacheControl())
+	if policy.Visibility == CachePublic && policy.SurrogateMaxAge > 0 {
+		h.Set("Surrogate-Control", fmt.Sprintf("max-age=%d", int(policy.SurrogateMaxAge.Seconds())))
+	}
+
+	cw.responses.Add(cw.r.Context(), 1, metric.WithAttributes(
+		attribute.String("http.route", cw.r.Pattern),
+		attribute.Bool("cache.cacheable", policy.Visibility == CachePublic),
+		attribute.String("cache.visibility", string(policy.Visibility)),
+	))
+
+	cw.ResponseWriter.WriteHeader(status)
+}
+
+func (cw *cacheWriter) Write(b []byte) (int, error) {
+	if !cw.wroteHeader {
+		cw.WriteHeader(http.StatusOK)
+	}
+	return cw.ResponseWriter.Write(b)
+}
+
+func (p CachePolicy) cacheControl() string {
+	switch p.Visibility {
+	case CachePublic:
+		parts := []string{"public", fmt.Sprintf("max-age=%d", int(p.MaxAge.Seconds()))}
+		if p.StaleWhileRevalidate > 0 {
+			parts = append(parts, fmt.Sprintf("stale-while-revalidate=%d", int(p.StaleWhileRevalidate.Seconds())))
+		}
+		return strings.Join(parts, ", ")
+	case CachePrivate:
+		if p.MaxAge > 0 {
+			return fmt.Sprintf("private, max-age=%d", int(p.MaxAge.Seconds()))
+		}
+		return "private, no-cache"
+	default:
+		return "no-store"
+	}
+}
@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+
+	"stdlib-articles/repository"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// QueryCount counts the database queries each request runs and records them
+// on queries by ServeMux pattern, so next must be (or wrap) the mux. A request
+// running more than threshold queries, usually an N+1 loop, is logged as a
+// warning and counted on exceeded; a threshold of 0 disables the warning.
+func QueryCount(next http.Handler, threshold int, logger *slog.Logger, queries metric.Int64Histogram, exceeded metric.Int64Counter) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, counter := repository.WithQueryCounter(r.Context())
+		r = r.WithContext(ctx)
+
+		next.ServeHTTP(w, r)
+
+		n := counter.Count()
+		attrs := metric.WithAttributes(
+			attribute.String("http.method", r.Method),
+			attribute.String("http.route", r.Pattern),
+		)
+		queries.Record(ctx, n, attrs)
+		trace.SpanFromContext(ctx).SetAttributes(attribute.Int64("db.query_count", n))
+
+		if threshold > 0 && n > int64(threshold) {
+			exceeded.Add(ctx, 1, attrs)
+			logger.WarnContext(ctx, "request exceeded database query threshold",
+				"method", r.Method,
+				"route", r.Pattern,
+				"queries", n,
+				"threshold", threshold,
+			)
+		}
+	})
+}
@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Timeout puts a deadline on the request context: reads (GET, HEAD) get
+// readTimeout, everything else writeTimeout. pgx cancels a query still running
+// at the deadline. Requests that overran are counted on deadlineExceeded by
+// the ServeMux pattern, so next must be (or wrap) the mux.
+func Timeout(next http.Handler, readTimeout, writeTimeout time.Duration, deadlineExceeded metric.Int64Counter) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		timeout := writeTimeout
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			timeout = readTimeout
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+		r = r.WithContext(ctx)
+
+		next.ServeHTTP(w, r)
+
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			trace.SpanFromContext(ctx).SetAttributes(attribute.Bool("http.request.deadline_exceeded", true))
+			deadlineExceeded.Add(ctx, 1, metric.WithAttributes(
+				attribute.String("http.route", r.Pattern),
+				attribute.String("timeout", timeout.String()),
+			))
+		}
+	})
+}
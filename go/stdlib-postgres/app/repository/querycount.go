@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/exaring/otelpgx"
+	"github.com/jackc/pgx/v5"
+)
+
+type queryCounterKey struct{}
+
+// QueryCounter counts the statements pgx runs with a context carrying it.
+type QueryCounter struct {
+	n atomic.Int64
+}
+
+func (c *QueryCounter) Count() int64 {
+	return c.n.Load()
+}
+
+// WithQueryCounter returns a context whose queries are counted by the
+// returned counter.
+func WithQueryCounter(ctx context.Context) (context.Context, *QueryCounter) {
+	c := &QueryCounter{}
+	return context.WithValue(ctx, queryCounterKey{}, c), c
+}
+
+// QueryCountTracer wraps the otelpgx tracer, counting each Query, QueryRow
+// and Exec against the counter in its context before tracing it. The batch,
+// copy and prepare hooks are otelpgx's own.
+type QueryCountTracer struct {
+	*otelpgx.Tracer
+}
+
+func (t QueryCountTracer) TraceQueryStart(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	if c, ok := ctx.Value(queryCounterKey{}).(*QueryCounter); ok {
+		c.n.Add(1)
+	}
+	return t.Tracer.TraceQueryStart(ctx, conn, data)
+}
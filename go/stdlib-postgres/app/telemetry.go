@@ -6,10 +6,14 @@ import (
 	"strings"
 	"time"
 
+	"github.com/base-14/examples/go/pkg/buildinfo"
+	"github.com/base-14/examples/go/pkg/metricattr"
+	"github.com/prometheus/client_golang/prometheus"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploghttp"
 	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	otelprometheus "go.opentelemetry.io/otel/exporters/prometheus"
 	"go.opentelemetry.io/otel/log/global"
 	"go.opentelemetry.io/otel/propagation"
 	sdklog "go.opentelemetry.io/otel/sdk/log"
@@ -21,14 +25,18 @@ import (
 
 type shutdownFunc func(context.Context) error
 
-func initTelemetry(ctx context.Context, serviceName, endpoint string) (shutdownFunc, error) {
+// initTelemetry sets up OTLP export for all three signals. A non-nil
+// promRegistry also registers every metric there through a Prometheus
+// exporter, a second reader on the same meter provider.
+func initTelemetry(ctx context.Context, serviceName, endpoint string, promRegistry *prometheus.Registry) (shutdownFunc, error) {
 	res, err := resource.New(ctx,
 		resource.WithFromEnv(),
 		resource.WithProcess(),
 		resource.WithTelemetrySDK(),
 		resource.WithAttributes(
 			semconv.ServiceName(serviceName),
-			semconv.ServiceVersion("1.0.0"),
+			semconv.ServiceVersion(buildinfo.Version),
+			semconv.ServiceInstanceID(buildinfo.InstanceID),
 		),
 	)
 	if err != nil {
@@ -55,11 +63,22 @@ func initTelemetry(ctx context.Context, serviceName, endpoint string) (shutdownF
 	if err != nil {
 		return nil, fmt.Errorf("metric exporter: %w", err)
 	}
-	mp := sdkmetric.NewMeterProvider(
+	mpOpts := []sdkmetric.Option{
 		sdkmetric.WithReader(sdkmetric.NewPeriodicReader(metricExp,
 			sdkmetric.WithInterval(60*time.Second))),
 		sdkmetric.WithResource(res),
-	)
+		// Warn about identifiers and other high-cardinality attributes
+		// recorded on a metric; see go/pkg/metricattr.
+		sdkmetric.WithView(metricattr.Guard(metricattr.GuardConfig{})),
+	}
+	if promRegistry != nil {
+		promExp, err := otelprometheus.New(otelprometheus.WithRegisterer(promRegistry))
+		if err != nil {
+			return nil, fmt.Errorf("prometheus exporter: %w", err)
+		}
+		mpOpts = append(mpOpts, sdkmetric.WithReader(promExp))
+	}
+	mp := sdkmetric.NewMeterProvider(mpOpts...)
 
 	logExp, err := otlploghttp.New(ctx,
 		otlploghttp.WithEndpoint(stripScheme(endpoint)),
@@ -11,6 +11,10 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/base-14/examples/go/pkg/buildinfo"
+	"github.com/base-14/examples/go/pkg/depwait"
+	"github.com/base-14/examples/go/pkg/healthcheck"
+	"github.com/base-14/examples/go/pkg/shutdown"
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 )
 
@@ -27,17 +31,14 @@ func main() {
 	otlpEndpoint := envOr("OTEL_EXPORTER_OTLP_ENDPOINT", "http://otel-collector:4318")
 	serviceName := envOr("OTEL_SERVICE_NAME", "stdlib-notify")
 
+	healthcheck.Main(depwait.Dependency{Name: "notify", Check: healthcheck.HTTP(healthcheck.Local(port, "/api/health"))})
+
 	shutdownTel, err := initTelemetry(ctx, serviceName, otlpEndpoint)
 	if err != nil {
 		log.Fatalf("telemetry: %v", err)
 	}
-	defer func() {
-		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
-		if err := shutdownTel(shutdownCtx); err != nil {
-			log.Printf("telemetry shutdown: %v", err)
-		}
-	}()
+	sd := shutdown.New(shutdown.Config{OnClose: logShutdown})
+	sd.Add(shutdown.PhaseTelemetry, "telemetry", shutdownTel)
 
 	logger := newLogger(serviceName)
 
@@ -51,6 +52,11 @@ func main() {
 		})
 	})
 
+	mux.HandleFunc("GET /version", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(buildinfo.Get())
+	})
+
 	mux.HandleFunc("POST /notify", func(w http.ResponseWriter, r *http.Request) {
 		var p payload
 		if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
@@ -76,6 +82,7 @@ func main() {
 		),
 		ReadHeaderTimeout: 5 * time.Second,
 	}
+	sd.Add(shutdown.PhaseServer, "http", server.Shutdown)
 
 	go func() {
 		logger.Info("stdlib-notify listening", "port", port)
@@ -88,13 +95,19 @@ func main() {
 	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
 	<-sigCh
 
-	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-	if err := server.Shutdown(shutdownCtx); err != nil {
+	if err := sd.Shutdown(context.Background()); err != nil {
 		logger.Error("shutdown error", "error", err)
 	}
 }
 
+func logShutdown(ev shutdown.Event) {
+	if ev.Err != nil {
+		log.Printf("shutdown: %s (%s) failed after %s: %v", ev.Name, ev.Phase, ev.Duration, ev.Err)
+		return
+	}
+	log.Printf("shutdown: %s (%s) done in %s", ev.Name, ev.Phase, ev.Duration)
+}
+
 func envOr(k, def string) string {
 	if v := os.Getenv(k); v != "" {
 		return v
@@ -6,6 +6,8 @@ import (
 	"strings"
 	"time"
 
+	"github.com/base-14/examples/go/pkg/buildinfo"
+	"github.com/base-14/examples/go/pkg/metricattr"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploghttp"
 	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
@@ -28,7 +30,8 @@ func initTelemetry(ctx context.Context, serviceName, endpoint string) (shutdownF
 		resource.WithTelemetrySDK(),
 		resource.WithAttributes(
 			semconv.ServiceName(serviceName),
-			semconv.ServiceVersion("1.0.0"),
+			semconv.ServiceVersion(buildinfo.Version),
+			semconv.ServiceInstanceID(buildinfo.InstanceID),
 		),
 	)
 	if err != nil {
@@ -59,6 +62,9 @@ func initTelemetry(ctx context.Context, serviceName, endpoint string) (shutdownF
 		sdkmetric.WithReader(sdkmetric.NewPeriodicReader(metricExp,
 			sdkmetric.WithInterval(60*time.Second))),
 		sdkmetric.WithResource(res),
+		// Warn about identifiers and other high-cardinality attributes
+		// recorded on a metric; see go/pkg/metricattr.
+		sdkmetric.WithView(metricattr.Guard(metricattr.GuardConfig{})),
 	)
 
 	logExp, err := otlploghttp.New(ctx,